@@ -0,0 +1,120 @@
+// Administrative commands run against the database directly.
+//
+//	mindflowctl import-users -admin <uuid> -file users.csv [-dry-run]
+//
+// import-users validates the CSV, prints the rows that failed validation and,
+// unless it is a dry run, creates the valid rows as users with invite links,
+// printing the progress after every batch
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/bogdanshibilov/mindflowbackend/internal/db/postgres"
+	"github.com/bogdanshibilov/mindflowbackend/internal/entity"
+	"github.com/bogdanshibilov/mindflowbackend/internal/repository"
+	onboardingservice "github.com/bogdanshibilov/mindflowbackend/internal/services/onboarding"
+)
+
+func main() {
+	if len(os.Args) < 2 {
+		fmt.Fprintln(os.Stderr, "usage: mindflowctl <command> [flags]\n\ncommands:\n  import-users")
+		os.Exit(2)
+	}
+
+	switch os.Args[1] {
+	case "import-users":
+		importUsers(os.Args[2:])
+	default:
+		fmt.Fprintf(os.Stderr, "unknown command %q\n", os.Args[1])
+		os.Exit(2)
+	}
+}
+
+func importUsers(args []string) {
+	var connUrl, path, adminId, inviteURL string
+	var inviteTTL time.Duration
+	var dryRun bool
+
+	flags := flag.NewFlagSet("import-users", flag.ExitOnError)
+	flags.StringVar(&connUrl, "conn-url", os.Getenv("PG_CONN_URL"), "path to storage")
+	flags.StringVar(&path, "file", "", "path to the CSV")
+	flags.StringVar(&adminId, "admin", "", "uuid of the admin the import is recorded for")
+	flags.StringVar(&inviteURL, "invite-url", "http://localhost:3000/invite", "page the invite links open")
+	flags.DurationVar(&inviteTTL, "invite-ttl", 14*24*time.Hour, "how long invite links are valid")
+	flags.BoolVar(&dryRun, "dry-run", false, "only validate the CSV")
+	_ = flags.Parse(args)
+
+	if connUrl == "" {
+		panic("conn-url is required")
+	}
+	if path == "" {
+		panic("file is required")
+	}
+	if adminId == "" {
+		panic("admin is required")
+	}
+
+	file, err := os.Open(path)
+	if err != nil {
+		panic(err)
+	}
+	defer file.Close()
+
+	db, err := postgres.New(connUrl)
+	if err != nil {
+		panic(err)
+	}
+	defer db.Close()
+
+	ctx := context.Background()
+	onboarding := onboardingservice.New(repository.NewUser(db), inviteURL, inviteTTL)
+
+	report, err := onboarding.Import(ctx, adminId, file, dryRun)
+	if err != nil {
+		panic(err)
+	}
+
+	// Lines already reported, to print only the rows that fail on creation later
+	reported := map[int]bool{}
+	for _, row := range report.Rows {
+		if row.Error != nil {
+			reported[row.Line] = true
+			fmt.Printf("line %d (%s): %s\n", row.Line, row.Email, *row.Error)
+		}
+	}
+	fmt.Printf("%d valid, %d invalid rows\n", len(report.Rows)-len(reported), len(reported))
+
+	if report.Import == nil {
+		return
+	}
+
+	fmt.Printf("Import %s\n", report.Import.Uuid)
+	userImport := report.Import
+	for userImport.Status != entity.UserImportDone {
+		userImport, err = onboarding.RunBatch(ctx, userImport.Uuid)
+		if err != nil {
+			panic(err)
+		}
+		fmt.Printf(
+			"%d/%d rows processed, %d users created\n",
+			userImport.CreatedRows+userImport.FailedRows,
+			userImport.TotalRows,
+			userImport.CreatedRows,
+		)
+	}
+
+	_, failed, err := onboarding.ImportById(ctx, userImport.Uuid.String())
+	if err != nil {
+		panic(err)
+	}
+	for _, row := range failed {
+		if row.Error != nil && !reported[row.Line] {
+			fmt.Printf("line %d (%s): %s\n", row.Line, row.Email, *row.Error)
+		}
+	}
+}
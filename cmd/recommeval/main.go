@@ -0,0 +1,186 @@
+// Evaluates expert recommendations offline against historical consultations.
+// For every accepted consultation it ranks the experts for the mentee and
+// reports how high the expert the mentee actually booked was placed, for the
+// combined score and for each factor alone. Rankings use today's profiles and
+// reviews, so the booked expert's own reviews favour them slightly
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"os"
+	"sort"
+
+	"github.com/google/uuid"
+
+	"github.com/bogdanshibilov/mindflowbackend/internal/db/postgres"
+	"github.com/bogdanshibilov/mindflowbackend/internal/entity"
+	"github.com/bogdanshibilov/mindflowbackend/internal/recommend"
+	"github.com/bogdanshibilov/mindflowbackend/internal/repository"
+	currencyservice "github.com/bogdanshibilov/mindflowbackend/internal/services/currency"
+	expertservice "github.com/bogdanshibilov/mindflowbackend/internal/services/expert"
+	organizationservice "github.com/bogdanshibilov/mindflowbackend/internal/services/organization"
+	userservice "github.com/bogdanshibilov/mindflowbackend/internal/services/user"
+	"github.com/bogdanshibilov/mindflowbackend/internal/tenant"
+)
+
+// Accumulates hit rates and reciprocal ranks of one ranking strategy
+type metrics struct {
+	hitsAtOne int
+	hitsAtK   int
+	rrSum     float64
+}
+
+func (m *metrics) add(rank int, k int) {
+	if rank == 1 {
+		m.hitsAtOne++
+	}
+	if rank <= k {
+		m.hitsAtK++
+	}
+	m.rrSum += 1 / float64(rank)
+}
+
+func main() {
+	var connUrl, currency string
+	var k int
+
+	flag.StringVar(&connUrl, "conn-url", os.Getenv("PG_CONN_URL"), "path to storage")
+	flag.StringVar(&currency, "currency", "USD", "base currency")
+	flag.IntVar(&k, "k", 10, "cutoff of the hit rate")
+	flag.Parse()
+
+	if connUrl == "" {
+		panic("conn-url is required")
+	}
+	if k < 1 {
+		panic("k must be positive")
+	}
+
+	db, err := postgres.New(connUrl)
+	if err != nil {
+		panic(err)
+	}
+	defer db.Close()
+
+	ctx := context.Background()
+	userRepo := repository.NewUser(db)
+	experts := expertservice.New(
+		repository.NewExpert(db),
+		userRepo,
+		repository.NewSkill(db),
+		currencyservice.New(repository.NewCurrency(db), currency),
+		nil,
+		0,
+		0,
+	)
+
+	consultRepo := repository.NewConsultation(db)
+	orgs := organizationservice.New(repository.NewOrganization(db), consultRepo, userservice.New(userRepo, nil, nil, 0, 0))
+
+	consultations, err := consultRepo.ByStatuses(ctx, tenant.Unscoped, entity.Approved, entity.Scheduled)
+	if err != nil {
+		panic(err)
+	}
+
+	// Historical consultations carry no budget, so price is never scored
+	factors := make([]recommend.Factor, 0, len(recommend.Factors))
+	for _, factor := range recommend.Factors {
+		if factor != recommend.FactorPrice {
+			factors = append(factors, factor)
+		}
+	}
+
+	combined := &metrics{}
+	byFactor := make(map[recommend.Factor]*metrics)
+	for _, factor := range factors {
+		byFactor[factor] = &metrics{}
+	}
+	var randomHitsAtOne, randomHitsAtK, randomRrSum float64
+	evaluated, missing := 0, 0
+	rankings := make(map[uuid.UUID][]recommend.Recommendation)
+
+	for _, consultation := range consultations {
+		recommendations, ok := rankings[consultation.MenteeUuid]
+		if !ok {
+			// Mentees are ranked the experts they may see today
+			scope, err := orgs.Scope(ctx, consultation.MenteeUuid.String())
+			if err != nil {
+				panic(err)
+			}
+			recommendations, err = experts.Recommend(ctx, scope, consultation.MenteeUuid.String(), nil, 0)
+			if err != nil {
+				panic(err)
+			}
+			rankings[consultation.MenteeUuid] = recommendations
+		}
+
+		rank := rankOf(recommendations, consultation.ExpertUuid)
+		if rank == 0 {
+			// The expert is no longer approved or is paused
+			missing++
+			continue
+		}
+		evaluated++
+		combined.add(rank, k)
+
+		for _, factor := range factors {
+			byFactor[factor].add(rankOf(rankedByFactor(recommendations, factor), consultation.ExpertUuid), k)
+		}
+
+		n := float64(len(recommendations))
+		randomHitsAtOne += 1 / n
+		randomHitsAtK += min(float64(k), n) / n
+		for i := 1; i <= len(recommendations); i++ {
+			randomRrSum += 1 / (float64(i) * n)
+		}
+	}
+
+	fmt.Printf("Consultations: %d, evaluated: %d, expert no longer listed: %d\n", len(consultations), evaluated, missing)
+	if evaluated == 0 {
+		return
+	}
+
+	total := float64(evaluated)
+	fmt.Printf("%-14s %8s %8s %8s\n", "ranking", "hit@1", fmt.Sprintf("hit@%d", k), "mrr")
+	report := func(name string, m *metrics) {
+		fmt.Printf("%-14s %8.3f %8.3f %8.3f\n", name, float64(m.hitsAtOne)/total, float64(m.hitsAtK)/total, m.rrSum/total)
+	}
+	report("combined", combined)
+	for _, factor := range factors {
+		report(string(factor), byFactor[factor])
+	}
+	fmt.Printf("%-14s %8.3f %8.3f %8.3f\n", "random", randomHitsAtOne/total, randomHitsAtK/total, randomRrSum/total)
+}
+
+// Position of the expert starting from 1, 0 when the expert is not ranked
+func rankOf(recommendations []recommend.Recommendation, expertUuid uuid.UUID) int {
+	for i, recommendation := range recommendations {
+		if recommendation.Expert.UserUuid == expertUuid {
+			return i + 1
+		}
+	}
+	return 0
+}
+
+// Orders the recommendations by the score of a single factor. Recommendations
+// the factor was not scored for come last
+func rankedByFactor(recommendations []recommend.Recommendation, factor recommend.Factor) []recommend.Recommendation {
+	ranked := make([]recommend.Recommendation, len(recommendations))
+	copy(ranked, recommendations)
+
+	score := func(recommendation *recommend.Recommendation) float64 {
+		for _, reason := range recommendation.Reasons {
+			if reason.Factor == factor {
+				return reason.Score
+			}
+		}
+		return -1
+	}
+	sort.SliceStable(ranked, func(i, j int) bool {
+		return score(&ranked[i]) > score(&ranked[j])
+	})
+
+	return ranked
+}
@@ -1,6 +1,7 @@
 package app
 
 import (
+	"context"
 	"log/slog"
 	"os"
 	"os/signal"
@@ -8,14 +9,28 @@ import (
 
 	"github.com/gin-gonic/gin"
 
+	"github.com/bogdanshibilov/mindflowbackend/internal/blobstore"
 	"github.com/bogdanshibilov/mindflowbackend/internal/config"
 	v1 "github.com/bogdanshibilov/mindflowbackend/internal/controller/http/v1"
 	"github.com/bogdanshibilov/mindflowbackend/internal/db/postgres"
 	"github.com/bogdanshibilov/mindflowbackend/internal/httpserver"
+	"github.com/bogdanshibilov/mindflowbackend/internal/paymentprovider"
 	"github.com/bogdanshibilov/mindflowbackend/internal/repository"
+	"github.com/bogdanshibilov/mindflowbackend/internal/scheduler"
 	authservice "github.com/bogdanshibilov/mindflowbackend/internal/services/auth"
+	bulkservice "github.com/bogdanshibilov/mindflowbackend/internal/services/bulk"
 	consultationservice "github.com/bogdanshibilov/mindflowbackend/internal/services/consultation"
+	currencyservice "github.com/bogdanshibilov/mindflowbackend/internal/services/currency"
 	expertservice "github.com/bogdanshibilov/mindflowbackend/internal/services/expert"
+	exportservice "github.com/bogdanshibilov/mindflowbackend/internal/services/export"
+	onboardingservice "github.com/bogdanshibilov/mindflowbackend/internal/services/onboarding"
+	organizationservice "github.com/bogdanshibilov/mindflowbackend/internal/services/organization"
+	paymentservice "github.com/bogdanshibilov/mindflowbackend/internal/services/payment"
+	payoutservice "github.com/bogdanshibilov/mindflowbackend/internal/services/payout"
+	promoservice "github.com/bogdanshibilov/mindflowbackend/internal/services/promo"
+	sessionpackageservice "github.com/bogdanshibilov/mindflowbackend/internal/services/sessionpackage"
+	skillservice "github.com/bogdanshibilov/mindflowbackend/internal/services/skill"
+	suspensionservice "github.com/bogdanshibilov/mindflowbackend/internal/services/suspension"
 	userservice "github.com/bogdanshibilov/mindflowbackend/internal/services/user"
 )
 
@@ -40,16 +55,75 @@ func (a *App) Run() {
 	}
 	defer db.Close()
 
+	blobs, err := blobstore.NewLocal(a.cfg.Blobstore.Dir)
+	if err != nil {
+		panic(op + " " + err.Error())
+	}
 	userRepo := repository.NewUser(db)
-	users := userservice.New(userRepo)
-	auth := authservice.New(users, os.Getenv("JWTSECRET"), a.cfg.TokenTTL)
+	currencies := currencyservice.New(repository.NewCurrency(db), a.cfg.Payments.Currency)
+	if err := currencies.EnsureBaseRate(context.Background()); err != nil {
+		panic(op + " " + err.Error())
+	}
 	expertsRepo := repository.NewExpert(db)
-	experts := expertservice.New(expertsRepo, userRepo)
+	skillRepo := repository.NewSkill(db)
+	experts := expertservice.New(
+		expertsRepo,
+		userRepo,
+		skillRepo,
+		currencies,
+		blobs,
+		a.cfg.Experts.ResubmitCooldown,
+		a.cfg.Experts.MaxDocumentSize,
+	)
 	consultRepo := repository.NewConsultation(db)
-	consultations := consultationservice.New(*consultRepo, *userRepo)
+	paymentRepo := repository.NewPayment(db)
+	packageRepo := repository.NewSessionPackage(db)
+	promoRepo := repository.NewPromo(db)
+	provider, webhookSource := a.newPaymentProvider()
+	payments := paymentservice.New(
+		paymentRepo,
+		consultRepo,
+		packageRepo,
+		promoRepo,
+		userRepo,
+		provider,
+		paymentservice.RefundPolicy{
+			FullRefundNotice:  a.cfg.Refunds.FullRefundNotice,
+			LateRefundPercent: a.cfg.Refunds.LateRefundPercent,
+		},
+	)
+	if webhookSource != nil {
+		webhookSource.SetWebhookSink(func(payload []byte, signature string) error {
+			err := payments.HandleWebhook(context.Background(), payload, signature)
+			if err != nil {
+				a.log.Error("failed to handle payment webhook", op, err)
+			}
+			return err
+		})
+	}
+	consultations := consultationservice.New(*consultRepo, *userRepo, payments)
+	users := userservice.New(userRepo, consultations, blobs, a.cfg.Users.DeletedRetention, a.cfg.Users.MaxAvatarSize)
+	auth := authservice.New(users, os.Getenv("JWTSECRET"), a.cfg.TokenTTL)
+	payouts := payoutservice.New(paymentRepo, userRepo, a.cfg.Payouts.CommissionPercent)
+	packages := sessionpackageservice.New(packageRepo, expertsRepo, payments, currencies)
+	promos := promoservice.New(promoRepo, currencies)
+	skills := skillservice.New(skillRepo)
+	exports := exportservice.New(userRepo, expertsRepo, consultRepo, blobs, a.cfg.Users.ExportTTL)
+	suspensions := suspensionservice.New(userRepo, consultations)
+	bulk := bulkservice.New(userRepo, repository.NewAudit(db), suspensions)
+	onboarding := onboardingservice.New(userRepo, a.cfg.Users.InviteURL, a.cfg.Users.InviteTTL)
+	orgs := organizationservice.New(repository.NewOrganization(db), consultRepo, users)
+
+	jobsCtx, stopJobs := context.WithCancel(context.Background())
+	defer stopJobs()
+	go scheduler.Every(jobsCtx, a.log, "end expired expert pauses", a.cfg.Experts.PauseCheckInterval, experts.EndExpiredPauses)
+	go scheduler.Every(jobsCtx, a.log, "purge deleted users", a.cfg.Users.PurgeInterval, users.PurgeDeleted)
+	go scheduler.Every(jobsCtx, a.log, "build data exports", a.cfg.Users.ExportInterval, exports.BuildPending)
+	go scheduler.Every(jobsCtx, a.log, "delete expired data exports", a.cfg.Users.PurgeInterval, exports.DeleteExpired)
+	go scheduler.Every(jobsCtx, a.log, "create imported users", a.cfg.Users.ImportInterval, onboarding.RunPending)
 
 	handler := gin.New()
-	v1.NewRouter(handler, a.log, auth, experts, users, consultations)
+	v1.NewRouter(handler, a.log, auth, experts, users, consultations, payments, payouts, currencies, packages, promos, skills, exports, bulk, suspensions, onboarding, orgs)
 	httpserver := httpserver.New(handler, httpserver.Port(a.cfg.Port))
 	httpserver.Run()
 
@@ -67,3 +141,21 @@ func (a *App) Run() {
 		a.log.Error("%s: %w", op, err)
 	}
 }
+
+type webhookSource interface {
+	SetWebhookSink(sink paymentprovider.WebhookSink)
+}
+
+// Builds the configured payment provider. In-process providers are also
+// returned as a webhook source so their events can be routed to the payment service
+func (a *App) newPaymentProvider() (paymentprovider.PaymentProvider, webhookSource) {
+	const op = "app.newPaymentProvider"
+
+	switch a.cfg.Payments.Provider {
+	case "fake":
+		fake := paymentprovider.NewFake(os.Getenv("PAYMENT_WEBHOOK_SECRET"), a.cfg.Payments.WebhookDelay)
+		return fake, fake
+	default:
+		panic(op + " unknown payment provider " + a.cfg.Payments.Provider)
+	}
+}
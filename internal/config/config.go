@@ -12,6 +12,12 @@ type Config struct {
 	Env        string `yaml:"env" env-required:"true"`
 	HTTPServer `yaml:"http_server"`
 	Jwt        `yaml:"jwt"`
+	Payments   `yaml:"payments"`
+	Refunds    `yaml:"refunds"`
+	Payouts    `yaml:"payouts"`
+	Experts    `yaml:"experts"`
+	Users      `yaml:"users"`
+	Blobstore  `yaml:"blobstore"`
 }
 
 type HTTPServer struct {
@@ -24,6 +30,55 @@ type Jwt struct {
 	TokenTTL time.Duration `yaml:"token_ttl" env-default:"3600s"`
 }
 
+type Payments struct {
+	Provider string `yaml:"provider" env-default:"fake"`
+	// Base currency, exchange rates and price filters are relative to it
+	Currency     string        `yaml:"currency" env-default:"USD"`
+	WebhookDelay time.Duration `yaml:"webhook_delay" env-default:"5s"`
+}
+
+type Refunds struct {
+	FullRefundNotice  time.Duration `yaml:"full_refund_notice" env-default:"24h"`
+	LateRefundPercent int           `yaml:"late_refund_percent" env-default:"50"`
+}
+
+type Payouts struct {
+	CommissionPercent int `yaml:"commission_percent" env-default:"20"`
+}
+
+type Experts struct {
+	// How long rejected applicants wait before they can apply again
+	ResubmitCooldown time.Duration `yaml:"resubmit_cooldown" env-default:"720h"`
+	// Maximum size of an uploaded verification document in bytes
+	MaxDocumentSize int64 `yaml:"max_document_size" env-default:"10485760"`
+	// How often ended pauses are cleared and the experts notified
+	PauseCheckInterval time.Duration `yaml:"pause_check_interval" env-default:"10m"`
+}
+
+type Users struct {
+	// How long deleted users can be restored before they are purged
+	DeletedRetention time.Duration `yaml:"deleted_retention" env-default:"720h"`
+	// How often users past the retention window are purged
+	PurgeInterval time.Duration `yaml:"purge_interval" env-default:"1h"`
+	// How long a data export can be downloaded once it is ready
+	ExportTTL time.Duration `yaml:"export_ttl" env-default:"168h"`
+	// How often pending data exports are built
+	ExportInterval time.Duration `yaml:"export_interval" env-default:"1m"`
+	// Maximum size of an uploaded avatar in bytes
+	MaxAvatarSize int64 `yaml:"max_avatar_size" env-default:"5242880"`
+	// Page of the frontend where invited users choose their password, the token is added as a query param
+	InviteURL string `yaml:"invite_url" env-default:"http://localhost:3000/invite"`
+	// How long an invite link can be used
+	InviteTTL time.Duration `yaml:"invite_ttl" env-default:"336h"`
+	// How often the next batch of imported users is created
+	ImportInterval time.Duration `yaml:"import_interval" env-default:"10s"`
+}
+
+type Blobstore struct {
+	// Directory uploaded files are kept in
+	Dir string `yaml:"dir" env-default:"./data/blobs"`
+}
+
 func MustLoad() *Config {
 	configPath := os.Getenv("CONFIG_PATH")
 	if configPath == "" {
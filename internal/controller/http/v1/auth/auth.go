@@ -8,29 +8,35 @@ import (
 
 	"github.com/gin-gonic/gin"
 
+	"github.com/bogdanshibilov/mindflowbackend/internal/controller/http/v1/middleware"
 	userrepo "github.com/bogdanshibilov/mindflowbackend/internal/repository/user"
 	authservice "github.com/bogdanshibilov/mindflowbackend/internal/services/auth"
+	userservice "github.com/bogdanshibilov/mindflowbackend/internal/services/user"
 )
 
 type routes struct {
-	log  *slog.Logger
-	auth *authservice.Service
+	log   *slog.Logger
+	auth  *authservice.Service
+	users *userservice.Service
 }
 
 func New(
 	handler *gin.RouterGroup,
 	log *slog.Logger,
 	auth *authservice.Service,
+	users *userservice.Service,
 ) {
 	r := &routes{
-		log:  log,
-		auth: auth,
+		log:   log,
+		auth:  auth,
+		users: users,
 	}
 
 	authHandler := handler.Group("/auth")
 	{
 		authHandler.POST("/signup", r.SignUp)
 		authHandler.POST("/emailsignin", r.SignInWithEmail)
+		authHandler.POST("/invite", r.AcceptInvite)
 	}
 }
 
@@ -55,6 +61,10 @@ func (r *routes) SignUp(ctx *gin.Context) {
 		req.ExperienceDescription,
 	)
 	if err != nil {
+		if errors.Is(err, userservice.ErrWeakPassword) {
+			ctx.JSON(http.StatusBadRequest, gin.H{"message": userservice.ErrWeakPassword.Error()})
+			return
+		}
 		r.log.Error("failed to register a new user", op, err)
 		ctx.JSON(http.StatusInternalServerError, gin.H{"message": "failed to register"})
 		return
@@ -83,6 +93,15 @@ func (r *routes) SignInWithEmail(ctx *gin.Context) {
 			r.log.Warn("nonexisting credentials received", op, err)
 			ctx.JSON(http.StatusUnauthorized, gin.H{"message": "invalid credentials"})
 			return
+		} else if errors.Is(err, userservice.ErrAccountSuspended) {
+			user, err := r.users.ByEmail(ctx, req.Email)
+			if err != nil {
+				r.log.Error("failed to get suspension", op, err)
+				ctx.JSON(http.StatusForbidden, gin.H{"message": "account is suspended"})
+				return
+			}
+			ctx.JSON(http.StatusForbidden, middleware.SuspendedResponse(&user.AccountSuspension))
+			return
 		} else {
 			r.log.Error("failed to login a new user", op, err)
 			ctx.JSON(http.StatusInternalServerError, gin.H{"message": "failed to sign in"})
@@ -94,3 +113,33 @@ func (r *routes) SignInWithEmail(ctx *gin.Context) {
 		AccessToken: token,
 	})
 }
+
+// Sets the password of an imported user from their invite link and signs them in
+func (r *routes) AcceptInvite(ctx *gin.Context) {
+	const op = "AuthRoutes.AcceptInvite"
+
+	var req *acceptInviteRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		r.log.Warn("invalid JSON received", op, err)
+		ctx.JSON(http.StatusBadRequest, gin.H{"message": "invalid JSON"})
+		return
+	}
+
+	token, err := r.auth.AcceptInvite(ctx, req.Token, req.Password)
+	if err != nil {
+		switch {
+		case errors.Is(err, userservice.ErrWeakPassword):
+			ctx.JSON(http.StatusBadRequest, gin.H{"message": userservice.ErrWeakPassword.Error()})
+		case errors.Is(err, userrepo.ErrInviteInvalid):
+			ctx.JSON(http.StatusGone, gin.H{"message": "invite link is invalid, used or expired"})
+		default:
+			r.log.Error("failed to accept invite", op, err)
+			ctx.JSON(http.StatusInternalServerError, gin.H{"message": "failed to accept invite"})
+		}
+		return
+	}
+
+	ctx.JSON(http.StatusOK, signInWithEmailResponse{
+		AccessToken: token,
+	})
+}
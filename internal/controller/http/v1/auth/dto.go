@@ -2,7 +2,7 @@ package authroutes
 
 type signUpRequest struct {
 	Name                  string `json:"name" binding:"required"`
-	Password              string `json:"password" binding:"required,min=5"`
+	Password              string `json:"password" binding:"required"`
 	Email                 string `json:"email" binding:"required,email"`
 	Phone                 string `json:"phone" binding:"required,e164"`
 	ProfessionalField     string `json:"professionalField" binding:"required"`
@@ -17,3 +17,8 @@ type signInWithEmailRequest struct {
 type signInWithEmailResponse struct {
 	AccessToken string `json:"accessToken"`
 }
+
+type acceptInviteRequest struct {
+	Token    string `json:"token" binding:"required"`
+	Password string `json:"password" binding:"required"`
+}
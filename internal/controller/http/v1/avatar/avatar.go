@@ -0,0 +1,105 @@
+package avatarroutes
+
+import (
+	"errors"
+	"io"
+	"log/slog"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/bogdanshibilov/mindflowbackend/internal/blobstore"
+	userservice "github.com/bogdanshibilov/mindflowbackend/internal/services/user"
+)
+
+// Path avatars are served under, set when the routes are registered
+var basePath = "/avatars"
+
+type routes struct {
+	log   *slog.Logger
+	users *userservice.Service
+}
+
+func New(
+	handler *gin.RouterGroup,
+	log *slog.Logger,
+	users *userservice.Service,
+) {
+	r := &routes{
+		log:   log,
+		users: users,
+	}
+
+	avatarsHandler := handler.Group("/avatars")
+	basePath = avatarsHandler.BasePath()
+	{
+		avatarsHandler.GET("/:hash/:file", r.Avatar)
+	}
+}
+
+// URLs of the stored copies of an avatar
+type URLsDTO struct {
+	Small  string `json:"small"`
+	Medium string `json:"medium"`
+	Large  string `json:"large"`
+}
+
+// Returns nil without an avatar. URLs contain the content hash, so they change
+// whenever the picture does and can be cached for good
+func URLs(hash *string) *URLsDTO {
+	if hash == nil {
+		return nil
+	}
+
+	url := func(size int) string {
+		return basePath + "/" + *hash + "/" + strconv.Itoa(size) + ".jpg"
+	}
+	sizes := userservice.AvatarSizes
+
+	return &URLsDTO{
+		Small:  url(sizes[0]),
+		Medium: url(sizes[1]),
+		Large:  url(sizes[2]),
+	}
+}
+
+func (r *routes) Avatar(ctx *gin.Context) {
+	const op = "AvatarRoutes.Avatar"
+
+	hash := ctx.Param("hash")
+	sizeParam, ok := strings.CutSuffix(ctx.Param("file"), ".jpg")
+	size, err := strconv.Atoi(sizeParam)
+	if !ok || err != nil {
+		ctx.JSON(http.StatusNotFound, gin.H{"message": "avatar not found"})
+		return
+	}
+
+	etag := `"` + hash + "-" + sizeParam + `"`
+	if ctx.GetHeader("If-None-Match") == etag {
+		ctx.Status(http.StatusNotModified)
+		return
+	}
+
+	avatar, err := r.users.OpenAvatar(ctx, hash, size)
+	if err != nil {
+		if errors.Is(err, blobstore.ErrNotFound) {
+			ctx.JSON(http.StatusNotFound, gin.H{"message": "avatar not found"})
+			return
+		}
+		r.log.Error("failed to open avatar", op, err)
+		ctx.JSON(http.StatusInternalServerError, gin.H{"message": "failed to get avatar"})
+		return
+	}
+	defer avatar.Close()
+
+	ctx.Header("Cache-Control", "public, max-age=31536000, immutable")
+	ctx.Header("ETag", etag)
+	ctx.Status(http.StatusOK)
+	ctx.Header("Content-Type", "image/jpeg")
+	_, err = io.Copy(ctx.Writer, avatar)
+	if err != nil {
+		r.log.Warn("failed to send avatar", op, err)
+	}
+}
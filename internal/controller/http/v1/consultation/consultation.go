@@ -1,6 +1,7 @@
 package consultationroute
 
 import (
+	"errors"
 	"log/slog"
 	"net/http"
 	"os"
@@ -9,8 +10,11 @@ import (
 
 	"github.com/bogdanshibilov/mindflowbackend/internal/controller/http/v1/middleware"
 	"github.com/bogdanshibilov/mindflowbackend/internal/entity"
+	"github.com/bogdanshibilov/mindflowbackend/internal/pagination"
 	consultationrepo "github.com/bogdanshibilov/mindflowbackend/internal/repository/consultation"
 	consultationservice "github.com/bogdanshibilov/mindflowbackend/internal/services/consultation"
+	organizationservice "github.com/bogdanshibilov/mindflowbackend/internal/services/organization"
+	paymentservice "github.com/bogdanshibilov/mindflowbackend/internal/services/payment"
 	userservice "github.com/bogdanshibilov/mindflowbackend/internal/services/user"
 )
 
@@ -24,6 +28,7 @@ func New(
 	log *slog.Logger,
 	consultations *consultationservice.Service,
 	userservice *userservice.Service,
+	orgs *organizationservice.Service,
 ) {
 	r := &routes{
 		log:           log,
@@ -32,13 +37,26 @@ func New(
 
 	consultHandler := handler.Group("/consultation")
 	{
-		consultHandler.Use(middleware.RequireJwt(os.Getenv("JWTSECRET")))
+		consultHandler.Use(middleware.RequireJwt(os.Getenv("JWTSECRET"), userservice))
 		consultHandler.Use(middleware.ParseClaimsIntoContext())
+		consultHandler.Use(middleware.ResolveScope(orgs, log))
 		consultHandler.POST("apply", r.ApplyForConsultation)
 		consultHandler.GET("alreadyapplied/:expertid", r.AlreadyApplied)
 		consultHandler.GET("meetasstudent", r.MeetingsAsStudent)
 		consultHandler.GET("meetasexpert", r.MeetingsAsExpert)
 		consultHandler.GET("/:id", r.ById)
+		consultHandler.POST("/:id/cancel", r.Cancel)
+		consultHandler.POST("/:id/review", r.Review)
+		consultHandler.GET("/expert/:expertid/reviews", r.ExpertReviews)
+		consultHandler.GET("/:id/notes", r.Notes)
+		consultHandler.POST("/:id/notes", r.AddNote)
+		consultHandler.PUT("/notes/:noteid", r.UpdateNote)
+		consultHandler.DELETE("/notes/:noteid", r.DeleteNote)
+		consultHandler.GET("/meeting/:meetingid/summary", r.Summary)
+		consultHandler.PUT("/meeting/:meetingid/summary", r.SaveSummary)
+		consultHandler.POST("/meeting/:meetingid/summary/publish", r.PublishSummary)
+		consultHandler.GET("/meeting/:meetingid/summary/history", r.SummaryHistory)
+		consultHandler.GET("/meeting/:meetingid/summary/export", r.ExportSummary)
 		consultHandler.Use(middleware.RequireAdminPermission(userservice, log))
 		consultHandler.GET("", r.Consultations)
 		consultHandler.POST("/meeting", r.CreateMeeting)
@@ -58,8 +76,16 @@ func (r *routes) ApplyForConsultation(ctx *gin.Context) {
 
 	id := ctx.GetString("uuid")
 
-	err := r.consultations.ApplyForConsultation(ctx, id, req.ExpertId, req.MenteeQuestions)
+	err := r.consultations.ApplyForConsultation(ctx, middleware.ScopeOf(ctx), id, req.ExpertId, req.MenteeQuestions, req.PackagePurchaseId)
 	if err != nil {
+		if errors.Is(err, consultationrepo.ErrNoPackageCredits) {
+			ctx.JSON(http.StatusConflict, gin.H{"message": "package has no credits left for this expert"})
+			return
+		}
+		if errors.Is(err, consultationrepo.ErrExpertUnavailable) {
+			ctx.JSON(http.StatusConflict, gin.H{"message": "expert is not taking bookings"})
+			return
+		}
 		r.log.Error("failed to appy for consultation", op, err)
 		ctx.JSON(http.StatusInternalServerError, gin.H{"message": "failed to appy for consultation"})
 		return
@@ -71,7 +97,13 @@ func (r *routes) ApplyForConsultation(ctx *gin.Context) {
 func (r *routes) Consultations(ctx *gin.Context) {
 	const op = "consultationroutes.ApplyForConsultation"
 
-	consults, err := r.consultations.Consultations(ctx)
+	page, err := pagination.FromQuery(ctx.Request.URL.Query(), consultationrepo.Sorts, "submitted")
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"message": err.Error()})
+		return
+	}
+
+	consults, err := r.consultations.Consultations(ctx, middleware.ScopeOf(ctx), page)
 	if err != nil {
 		r.log.Error("failed to get consultations", op, err)
 		ctx.JSON(http.StatusInternalServerError, gin.H{"message": "failed to get consultations"})
@@ -82,15 +114,60 @@ func (r *routes) Consultations(ctx *gin.Context) {
 }
 
 func (r *routes) ById(ctx *gin.Context) {
+	const op = "consultationroutes.ById"
+
 	id := ctx.Param("id")
 
-	consult, err := r.consultations.ById(ctx, id)
+	consult, err := r.consultations.ById(ctx, middleware.ScopeOf(ctx), id, ctx.GetString("uuid"))
+	if err != nil {
+		switch {
+		case errors.Is(err, consultationservice.ErrNotParticipant):
+			ctx.JSON(http.StatusForbidden, gin.H{"message": "forbidden"})
+		case errors.Is(err, consultationrepo.ErrConsultationNotFound):
+			ctx.JSON(http.StatusNotFound, gin.H{"message": "consultation not found"})
+		default:
+			ctx.JSON(http.StatusBadRequest, gin.H{"message": "bad id"})
+		}
+		return
+	}
+
+	payment, err := r.consultations.PaymentDetails(ctx, consult.Uuid)
 	if err != nil {
-		ctx.JSON(http.StatusBadRequest, gin.H{"message": "bad id"})
+		r.log.Error("failed to get payment details", op, err)
+		ctx.JSON(http.StatusInternalServerError, gin.H{"message": "failed to get consultation"})
 		return
 	}
 
-	ctx.JSON(http.StatusOK, consult)
+	ctx.JSON(http.StatusOK, consultationDetailDtoFrom(consult, payment))
+}
+
+func (r *routes) Cancel(ctx *gin.Context) {
+	const op = "consultationroutes.Cancel"
+
+	refund, err := r.consultations.Cancel(ctx, middleware.ScopeOf(ctx), ctx.Param("id"), ctx.GetString("uuid"))
+	if err != nil {
+		switch {
+		case errors.Is(err, consultationservice.ErrNotParticipant):
+			ctx.JSON(http.StatusForbidden, gin.H{"message": "forbidden"})
+		case errors.Is(err, consultationrepo.ErrConsultationNotFound):
+			ctx.JSON(http.StatusNotFound, gin.H{"message": "consultation not found"})
+		case errors.Is(err, consultationrepo.ErrNotCancellable):
+			ctx.JSON(http.StatusConflict, gin.H{"message": "consultation cannot be cancelled"})
+		case errors.Is(err, paymentservice.ErrRefundInFlight):
+			ctx.JSON(http.StatusConflict, gin.H{"message": "refund is being processed, try again later"})
+		default:
+			r.log.Error("failed to cancel consultation", op, err)
+			ctx.JSON(http.StatusInternalServerError, gin.H{"message": "failed to cancel consultation"})
+		}
+		return
+	}
+
+	if refund == nil {
+		ctx.Status(http.StatusOK)
+		return
+	}
+
+	ctx.JSON(http.StatusOK, refundDtoFrom(refund))
 }
 
 func (r *routes) CreateMeeting(ctx *gin.Context) {
@@ -105,13 +182,21 @@ func (r *routes) CreateMeeting(ctx *gin.Context) {
 
 	err := r.consultations.CreateMeeting(
 		ctx,
+		middleware.ScopeOf(ctx),
 		req.ConsultationId,
 		req.StartTime,
 		req.Link,
 	)
 	if err != nil {
-		r.log.Error("failed to create a meeting", op, err)
-		ctx.JSON(http.StatusInternalServerError, gin.H{"message": "failed to create a meeting"})
+		switch {
+		case errors.Is(err, consultationrepo.ErrConsultationNotFound):
+			ctx.JSON(http.StatusNotFound, gin.H{"message": "consultation not found"})
+		case errors.Is(err, consultationrepo.ErrNotSchedulable):
+			ctx.JSON(http.StatusConflict, gin.H{"message": "consultation is cancelled or rejected"})
+		default:
+			r.log.Error("failed to create a meeting", op, err)
+			ctx.JSON(http.StatusInternalServerError, gin.H{"message": "failed to create a meeting"})
+		}
 		return
 	}
 
@@ -121,9 +206,16 @@ func (r *routes) CreateMeeting(ctx *gin.Context) {
 func (r *routes) RejectApplication(ctx *gin.Context) {
 	id := ctx.Param("id")
 
-	err := r.consultations.ChangeApplicationStatus(ctx, id, entity.Rejected)
+	err := r.consultations.RejectApplication(ctx, middleware.ScopeOf(ctx), id)
 	if err != nil {
-		ctx.JSON(http.StatusBadRequest, gin.H{"message": "bad id"})
+		switch {
+		case errors.Is(err, consultationrepo.ErrConsultationNotFound):
+			ctx.JSON(http.StatusNotFound, gin.H{"message": "consultation not found"})
+		case errors.Is(err, consultationrepo.ErrNotRejectable):
+			ctx.JSON(http.StatusConflict, gin.H{"message": "only pending consultations can be rejected"})
+		default:
+			ctx.JSON(http.StatusBadRequest, gin.H{"message": "bad id"})
+		}
 		return
 	}
 
@@ -135,6 +227,7 @@ func (r *routes) MeetingsAsStudent(ctx *gin.Context) {
 
 	consults, err := r.consultations.ByPersonId(
 		ctx,
+		middleware.ScopeOf(ctx),
 		id,
 		consultationrepo.SelectByWhoseUuid(consultationrepo.ByMenteeUuid),
 	)
@@ -145,7 +238,7 @@ func (r *routes) MeetingsAsStudent(ctx *gin.Context) {
 
 	var meetings []entity.ConsultationMeeting
 	for _, consult := range consults {
-		meets, err := r.consultations.MeetingsByConsultationId(ctx, consult.Uuid.String())
+		meets, err := r.consultations.MeetingsByConsultationId(ctx, middleware.ScopeOf(ctx), consult.Uuid.String())
 		if err != nil {
 			ctx.JSON(http.StatusBadRequest, gin.H{"message": "bad request"})
 			return
@@ -161,6 +254,7 @@ func (r *routes) MeetingsAsExpert(ctx *gin.Context) {
 
 	consults, err := r.consultations.ByPersonId(
 		ctx,
+		middleware.ScopeOf(ctx),
 		id,
 		consultationrepo.SelectByWhoseUuid(consultationrepo.ByExpertUuid),
 	)
@@ -171,7 +265,7 @@ func (r *routes) MeetingsAsExpert(ctx *gin.Context) {
 
 	var meetings []entity.ConsultationMeeting
 	for _, consult := range consults {
-		meets, err := r.consultations.MeetingsByConsultationId(ctx, consult.Uuid.String())
+		meets, err := r.consultations.MeetingsByConsultationId(ctx, middleware.ScopeOf(ctx), consult.Uuid.String())
 		if err != nil {
 			ctx.JSON(http.StatusBadRequest, gin.H{"message": "bad request"})
 			return
@@ -188,7 +282,7 @@ func (r *routes) AlreadyApplied(ctx *gin.Context) {
 	menteeId := ctx.GetString("uuid")
 	expertId := ctx.Param("expertid")
 
-	alreadyApplied, err := r.consultations.DoesExist(ctx, menteeId, expertId)
+	alreadyApplied, err := r.consultations.DoesExist(ctx, middleware.ScopeOf(ctx), menteeId, expertId)
 	if err != nil {
 		r.log.Error("failed to check existance", op, err)
 		ctx.JSON(http.StatusInternalServerError, gin.H{"message": "failed to check existance"})
@@ -1,10 +1,17 @@
 package consultationroute
 
-import "time"
+import (
+	"time"
+
+	"github.com/bogdanshibilov/mindflowbackend/internal/entity"
+	paymentservice "github.com/bogdanshibilov/mindflowbackend/internal/services/payment"
+)
 
 type applyForConsultationRequest struct {
 	ExpertId        string `json:"expertId" binding:"required"`
 	MenteeQuestions string `json:"menteeQuestions" binding:"required"`
+	// Books the consultation with a credit of this package purchase
+	PackagePurchaseId string `json:"packagePurchaseId"`
 }
 
 type createMeetingRequest struct {
@@ -12,3 +19,151 @@ type createMeetingRequest struct {
 	StartTime      time.Time `json:"startTime"`
 	Link           string    `json:"link"`
 }
+
+type reviewRequest struct {
+	Rating  int    `json:"rating" binding:"required,min=1,max=5"`
+	Comment string `json:"comment"`
+}
+
+type reviewDTO struct {
+	ConsultationId string    `json:"consultationId"`
+	Rating         int       `json:"rating"`
+	Comment        *string   `json:"comment"`
+	CreatedAt      time.Time `json:"createdAt"`
+}
+
+func reviewDtoFrom(entity *entity.ConsultationReview) *reviewDTO {
+	return &reviewDTO{
+		ConsultationId: entity.ConsultationUuid.String(),
+		Rating:         entity.Rating,
+		Comment:        entity.Comment,
+		CreatedAt:      entity.CreatedAt,
+	}
+}
+
+type noteRequest struct {
+	Content string `json:"content" binding:"required"`
+}
+
+type noteDTO struct {
+	Id             string    `json:"id"`
+	ConsultationId string    `json:"consultationId"`
+	Content        string    `json:"content"`
+	CreatedAt      time.Time `json:"createdAt"`
+	UpdatedAt      time.Time `json:"updatedAt"`
+}
+
+func noteDtoFrom(entity *entity.ConsultationNote) *noteDTO {
+	return &noteDTO{
+		Id:             entity.Uuid.String(),
+		ConsultationId: entity.ConsultationUuid.String(),
+		Content:        entity.Content,
+		CreatedAt:      entity.CreatedAt,
+		UpdatedAt:      entity.UpdatedAt,
+	}
+}
+
+type saveSummaryRequest struct {
+	Content     string   `json:"content" binding:"required"`
+	ActionItems []string `json:"actionItems"`
+}
+
+type summaryDTO struct {
+	MeetingId   string     `json:"meetingId"`
+	Version     int        `json:"version"`
+	Content     string     `json:"content"`
+	ActionItems []string   `json:"actionItems"`
+	PublishedAt *time.Time `json:"publishedAt"`
+	UpdatedAt   time.Time  `json:"updatedAt"`
+}
+
+func summaryDtoFrom(entity *entity.MeetingSummary) *summaryDTO {
+	return &summaryDTO{
+		MeetingId:   entity.MeetingUuid.String(),
+		Version:     entity.Version,
+		Content:     entity.Content,
+		ActionItems: entity.ActionItems,
+		PublishedAt: entity.PublishedAt,
+		UpdatedAt:   entity.UpdatedAt,
+	}
+}
+
+type summaryRevisionDTO struct {
+	Version     int       `json:"version"`
+	EditorId    string    `json:"editorId"`
+	Content     string    `json:"content"`
+	ActionItems []string  `json:"actionItems"`
+	EditedAt    time.Time `json:"editedAt"`
+}
+
+func summaryRevisionDtoFrom(entity *entity.MeetingSummaryRevision) *summaryRevisionDTO {
+	return &summaryRevisionDTO{
+		Version:     entity.Version,
+		EditorId:    entity.EditorUuid.String(),
+		Content:     entity.Content,
+		ActionItems: entity.ActionItems,
+		EditedAt:    entity.EditedAt,
+	}
+}
+
+type consultationDetailDTO struct {
+	*entity.Consultation
+	Payment *paymentDTO `json:"payment"`
+}
+
+type paymentDTO struct {
+	Id             string      `json:"id"`
+	Amount         int         `json:"amount"`
+	Currency       string      `json:"currency"`
+	Status         string      `json:"status"`
+	RefundedAmount int         `json:"refundedAmount"`
+	RefundStatus   string      `json:"refundStatus"`
+	Refunds        []refundDTO `json:"refunds"`
+}
+
+type refundDTO struct {
+	Id        string    `json:"id"`
+	Amount    int       `json:"amount"`
+	Currency  string    `json:"currency"`
+	Status    string    `json:"status"`
+	Reason    string    `json:"reason"`
+	CreatedAt time.Time `json:"createdAt"`
+}
+
+func refundDtoFrom(entity *entity.Refund) *refundDTO {
+	return &refundDTO{
+		Id:        entity.Uuid.String(),
+		Amount:    entity.Amount,
+		Currency:  entity.Currency,
+		Status:    string(entity.Status),
+		Reason:    entity.Reason,
+		CreatedAt: entity.CreatedAt,
+	}
+}
+
+func consultationDetailDtoFrom(
+	consult *entity.Consultation,
+	payment *paymentservice.PaymentDetails,
+) *consultationDetailDTO {
+	dto := &consultationDetailDTO{
+		Consultation: consult,
+	}
+	if payment == nil {
+		return dto
+	}
+
+	dto.Payment = &paymentDTO{
+		Id:             payment.Intent.Uuid.String(),
+		Amount:         payment.Intent.Amount,
+		Currency:       payment.Intent.Currency,
+		Status:         string(payment.Intent.Status),
+		RefundedAmount: payment.RefundedAmount,
+		RefundStatus:   string(payment.RefundState),
+		Refunds:        make([]refundDTO, 0),
+	}
+	for _, refund := range payment.Refunds {
+		dto.Payment.Refunds = append(dto.Payment.Refunds, *refundDtoFrom(&refund))
+	}
+
+	return dto
+}
@@ -0,0 +1,178 @@
+package consultationroute
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/bogdanshibilov/mindflowbackend/internal/controller/http/v1/middleware"
+	consultationrepo "github.com/bogdanshibilov/mindflowbackend/internal/repository/consultation"
+	consultationservice "github.com/bogdanshibilov/mindflowbackend/internal/services/consultation"
+)
+
+func (r *routes) Notes(ctx *gin.Context) {
+	const op = "consultationroutes.Notes"
+
+	notes, err := r.consultations.Notes(ctx, middleware.ScopeOf(ctx), ctx.Param("id"), ctx.GetString("uuid"))
+	if err != nil {
+		r.respondNotesError(ctx, op, err)
+		return
+	}
+
+	DTOs := make([]noteDTO, 0)
+	for _, entity := range notes {
+		DTOs = append(DTOs, *noteDtoFrom(&entity))
+	}
+
+	ctx.JSON(http.StatusOK, DTOs)
+}
+
+func (r *routes) AddNote(ctx *gin.Context) {
+	const op = "consultationroutes.AddNote"
+
+	var req *noteRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		r.log.Warn("invalid JSON received", op, err)
+		ctx.JSON(http.StatusBadRequest, gin.H{"message": "invalid JSON"})
+		return
+	}
+
+	note, err := r.consultations.AddNote(ctx, middleware.ScopeOf(ctx), ctx.Param("id"), ctx.GetString("uuid"), req.Content)
+	if err != nil {
+		r.respondNotesError(ctx, op, err)
+		return
+	}
+
+	ctx.JSON(http.StatusCreated, noteDtoFrom(note))
+}
+
+func (r *routes) UpdateNote(ctx *gin.Context) {
+	const op = "consultationroutes.UpdateNote"
+
+	var req *noteRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		r.log.Warn("invalid JSON received", op, err)
+		ctx.JSON(http.StatusBadRequest, gin.H{"message": "invalid JSON"})
+		return
+	}
+
+	err := r.consultations.UpdateNote(ctx, ctx.Param("noteid"), ctx.GetString("uuid"), req.Content)
+	if err != nil {
+		r.respondNotesError(ctx, op, err)
+		return
+	}
+
+	ctx.Status(http.StatusOK)
+}
+
+func (r *routes) DeleteNote(ctx *gin.Context) {
+	const op = "consultationroutes.DeleteNote"
+
+	err := r.consultations.DeleteNote(ctx, ctx.Param("noteid"), ctx.GetString("uuid"))
+	if err != nil {
+		r.respondNotesError(ctx, op, err)
+		return
+	}
+
+	ctx.Status(http.StatusOK)
+}
+
+func (r *routes) Summary(ctx *gin.Context) {
+	const op = "consultationroutes.Summary"
+
+	summary, err := r.consultations.Summary(ctx, middleware.ScopeOf(ctx), ctx.Param("meetingid"), ctx.GetString("uuid"))
+	if err != nil {
+		r.respondNotesError(ctx, op, err)
+		return
+	}
+
+	ctx.JSON(http.StatusOK, summaryDtoFrom(summary))
+}
+
+func (r *routes) SaveSummary(ctx *gin.Context) {
+	const op = "consultationroutes.SaveSummary"
+
+	var req *saveSummaryRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		r.log.Warn("invalid JSON received", op, err)
+		ctx.JSON(http.StatusBadRequest, gin.H{"message": "invalid JSON"})
+		return
+	}
+
+	summary, err := r.consultations.SaveSummary(
+		ctx,
+		middleware.ScopeOf(ctx),
+		ctx.Param("meetingid"),
+		ctx.GetString("uuid"),
+		req.Content,
+		req.ActionItems,
+	)
+	if err != nil {
+		r.respondNotesError(ctx, op, err)
+		return
+	}
+
+	ctx.JSON(http.StatusOK, summaryDtoFrom(summary))
+}
+
+func (r *routes) PublishSummary(ctx *gin.Context) {
+	const op = "consultationroutes.PublishSummary"
+
+	err := r.consultations.PublishSummary(ctx, middleware.ScopeOf(ctx), ctx.Param("meetingid"), ctx.GetString("uuid"))
+	if err != nil {
+		r.respondNotesError(ctx, op, err)
+		return
+	}
+
+	ctx.Status(http.StatusOK)
+}
+
+func (r *routes) SummaryHistory(ctx *gin.Context) {
+	const op = "consultationroutes.SummaryHistory"
+
+	revisions, err := r.consultations.SummaryHistory(ctx, middleware.ScopeOf(ctx), ctx.Param("meetingid"), ctx.GetString("uuid"))
+	if err != nil {
+		r.respondNotesError(ctx, op, err)
+		return
+	}
+
+	DTOs := make([]summaryRevisionDTO, 0)
+	for _, entity := range revisions {
+		DTOs = append(DTOs, *summaryRevisionDtoFrom(&entity))
+	}
+
+	ctx.JSON(http.StatusOK, DTOs)
+}
+
+func (r *routes) ExportSummary(ctx *gin.Context) {
+	const op = "consultationroutes.ExportSummary"
+
+	text, err := r.consultations.ExportSummary(ctx, middleware.ScopeOf(ctx), ctx.Param("meetingid"), ctx.GetString("uuid"))
+	if err != nil {
+		r.respondNotesError(ctx, op, err)
+		return
+	}
+
+	ctx.Header("Content-Disposition", `attachment; filename="session-summary.txt"`)
+	ctx.String(http.StatusOK, text)
+}
+
+func (r *routes) respondNotesError(ctx *gin.Context, op string, err error) {
+	switch {
+	case errors.Is(err, consultationservice.ErrNotExpert),
+		errors.Is(err, consultationservice.ErrNotParticipant):
+		r.log.Warn("user tried to access foreign session notes", op, err)
+		ctx.JSON(http.StatusForbidden, gin.H{"message": "forbidden"})
+	case errors.Is(err, consultationrepo.ErrConsultationNotFound),
+		errors.Is(err, consultationrepo.ErrMeetingNotFound),
+		errors.Is(err, consultationrepo.ErrNoteNotFound),
+		errors.Is(err, consultationrepo.ErrSummaryNotFound):
+		ctx.JSON(http.StatusNotFound, gin.H{"message": "not found"})
+	case errors.Is(err, consultationservice.ErrInvalidId):
+		ctx.JSON(http.StatusBadRequest, gin.H{"message": "bad id"})
+	default:
+		r.log.Error("failed to process session notes", op, err)
+		ctx.JSON(http.StatusInternalServerError, gin.H{"message": "failed to process session notes"})
+	}
+}
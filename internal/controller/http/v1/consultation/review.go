@@ -0,0 +1,64 @@
+package consultationroute
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/bogdanshibilov/mindflowbackend/internal/controller/http/v1/middleware"
+	consultationrepo "github.com/bogdanshibilov/mindflowbackend/internal/repository/consultation"
+	consultationservice "github.com/bogdanshibilov/mindflowbackend/internal/services/consultation"
+)
+
+// Rates a held consultation, only its mentee can review it and only once
+func (r *routes) Review(ctx *gin.Context) {
+	const op = "consultationroutes.Review"
+
+	var req *reviewRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		r.log.Warn("invalid JSON received", op, err)
+		ctx.JSON(http.StatusBadRequest, gin.H{"message": "invalid JSON"})
+		return
+	}
+
+	review, err := r.consultations.Review(ctx, middleware.ScopeOf(ctx), ctx.Param("id"), ctx.GetString("uuid"), req.Rating, req.Comment)
+	if err != nil {
+		switch {
+		case errors.Is(err, consultationservice.ErrInvalidRating):
+			ctx.JSON(http.StatusBadRequest, gin.H{"message": "rating must be between 1 and 5"})
+		case errors.Is(err, consultationservice.ErrNotMentee):
+			ctx.JSON(http.StatusForbidden, gin.H{"message": "forbidden"})
+		case errors.Is(err, consultationrepo.ErrConsultationNotFound):
+			ctx.JSON(http.StatusNotFound, gin.H{"message": "consultation not found"})
+		case errors.Is(err, consultationservice.ErrNotHeld):
+			ctx.JSON(http.StatusConflict, gin.H{"message": "consultation has not taken place yet"})
+		case errors.Is(err, consultationrepo.ErrAlreadyReviewed):
+			ctx.JSON(http.StatusConflict, gin.H{"message": "consultation was already reviewed"})
+		default:
+			r.log.Error("failed to review consultation", op, err)
+			ctx.JSON(http.StatusInternalServerError, gin.H{"message": "failed to review consultation"})
+		}
+		return
+	}
+
+	ctx.JSON(http.StatusCreated, reviewDtoFrom(review))
+}
+
+func (r *routes) ExpertReviews(ctx *gin.Context) {
+	const op = "consultationroutes.ExpertReviews"
+
+	reviews, err := r.consultations.ReviewsByExpertId(ctx, middleware.ScopeOf(ctx), ctx.Param("expertid"))
+	if err != nil {
+		r.log.Warn("failed to get reviews", op, err)
+		ctx.JSON(http.StatusBadRequest, gin.H{"message": "bad id"})
+		return
+	}
+
+	DTOs := make([]reviewDTO, 0)
+	for _, entity := range reviews {
+		DTOs = append(DTOs, *reviewDtoFrom(&entity))
+	}
+
+	ctx.JSON(http.StatusOK, DTOs)
+}
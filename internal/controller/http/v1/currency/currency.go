@@ -0,0 +1,113 @@
+package currencyroutes
+
+import (
+	"errors"
+	"log/slog"
+	"net/http"
+	"os"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/bogdanshibilov/mindflowbackend/internal/controller/http/v1/middleware"
+	currencyrepo "github.com/bogdanshibilov/mindflowbackend/internal/repository/currency"
+	currencyservice "github.com/bogdanshibilov/mindflowbackend/internal/services/currency"
+	userservice "github.com/bogdanshibilov/mindflowbackend/internal/services/user"
+)
+
+type routes struct {
+	log        *slog.Logger
+	currencies *currencyservice.Service
+}
+
+func New(
+	handler *gin.RouterGroup,
+	log *slog.Logger,
+	currencies *currencyservice.Service,
+	users *userservice.Service,
+) {
+	r := &routes{
+		log:        log,
+		currencies: currencies,
+	}
+
+	currenciesHandler := handler.Group("/currencies")
+	{
+		currenciesHandler.GET("/rates", r.Rates)
+		currenciesHandler.Use(middleware.RequireJwt(os.Getenv("JWTSECRET"), users))
+		currenciesHandler.Use(middleware.ParseClaimsIntoContext())
+		currenciesHandler.Use(middleware.RequireAdminPermission(users, log))
+		currenciesHandler.PUT("/rates/:currency", r.SetRate)
+		currenciesHandler.DELETE("/rates/:currency", r.DeleteRate)
+	}
+}
+
+func (r *routes) Rates(ctx *gin.Context) {
+	const op = "CurrencyRoutes.Rates"
+
+	rates, err := r.currencies.Rates(ctx)
+	if err != nil {
+		r.log.Error("failed to get exchange rates", op, err)
+		ctx.JSON(http.StatusInternalServerError, gin.H{"message": "failed to get exchange rates"})
+		return
+	}
+
+	DTOs := make([]rateDTO, 0)
+	for _, rate := range rates {
+		DTOs = append(DTOs, *rateDtoFrom(&rate, r.currencies.Base()))
+	}
+
+	ctx.JSON(http.StatusOK, DTOs)
+}
+
+func (r *routes) SetRate(ctx *gin.Context) {
+	const op = "CurrencyRoutes.SetRate"
+
+	var req *setRateRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		r.log.Warn("invalid JSON received", op, err)
+		ctx.JSON(http.StatusBadRequest, gin.H{"message": "invalid JSON"})
+		return
+	}
+
+	currency := strings.ToUpper(ctx.Param("currency"))
+
+	rate, err := r.currencies.SetRate(ctx, ctx.GetString("uuid"), currency, req.Rate)
+	if err != nil {
+		switch {
+		case errors.Is(err, currencyservice.ErrInvalidCurrency),
+			errors.Is(err, currencyservice.ErrInvalidRate):
+			ctx.JSON(http.StatusBadRequest, gin.H{"message": "invalid currency or rate"})
+		case errors.Is(err, currencyservice.ErrBaseCurrency):
+			ctx.JSON(http.StatusConflict, gin.H{"message": "base currency rate cannot be changed"})
+		default:
+			r.log.Error("failed to set exchange rate", op, err)
+			ctx.JSON(http.StatusInternalServerError, gin.H{"message": "failed to set exchange rate"})
+		}
+		return
+	}
+
+	ctx.JSON(http.StatusOK, rateDtoFrom(rate, r.currencies.Base()))
+}
+
+func (r *routes) DeleteRate(ctx *gin.Context) {
+	const op = "CurrencyRoutes.DeleteRate"
+
+	err := r.currencies.DeleteRate(ctx, strings.ToUpper(ctx.Param("currency")))
+	if err != nil {
+		switch {
+		case errors.Is(err, currencyrepo.ErrRateNotFound):
+			ctx.JSON(http.StatusNotFound, gin.H{"message": "exchange rate not found"})
+		case errors.Is(err, currencyservice.ErrBaseCurrency):
+			ctx.JSON(http.StatusConflict, gin.H{"message": "base currency rate cannot be deleted"})
+		case errors.Is(err, currencyrepo.ErrRateInUse):
+			ctx.JSON(http.StatusConflict, gin.H{"message": "currency is used by expert prices"})
+		default:
+			r.log.Error("failed to delete exchange rate", op, err)
+			ctx.JSON(http.StatusInternalServerError, gin.H{"message": "failed to delete exchange rate"})
+		}
+		return
+	}
+
+	ctx.Status(http.StatusOK)
+}
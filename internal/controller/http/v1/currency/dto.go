@@ -0,0 +1,27 @@
+package currencyroutes
+
+import (
+	"time"
+
+	currencyservice "github.com/bogdanshibilov/mindflowbackend/internal/services/currency"
+)
+
+type setRateRequest struct {
+	Rate float64 `json:"rate" binding:"required,gt=0"`
+}
+
+type rateDTO struct {
+	Currency  string    `json:"currency"`
+	Base      string    `json:"base"`
+	Rate      float64   `json:"rate"`
+	UpdatedAt time.Time `json:"updatedAt"`
+}
+
+func rateDtoFrom(rate *currencyservice.Rate, base string) *rateDTO {
+	return &rateDTO{
+		Currency:  rate.Currency,
+		Base:      base,
+		Rate:      rate.Rate,
+		UpdatedAt: rate.UpdatedAt,
+	}
+}
@@ -0,0 +1,60 @@
+// Package etag turns resource versions into ETags and reads them back from
+// If-Match so handlers can reject writes based on a stale read
+package etag
+
+import (
+	"errors"
+	"strconv"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+)
+
+var ErrMismatch = errors.New("If-Match does not name a version of this resource")
+
+// Formats the versions of a resource as a strong ETag, e.g. "3" or "3.7"
+func Format(versions ...int) string {
+	parts := make([]string, 0, len(versions))
+	for _, version := range versions {
+		parts = append(parts, strconv.Itoa(version))
+	}
+	return `"` + strings.Join(parts, ".") + `"`
+}
+
+func Set(ctx *gin.Context, versions ...int) {
+	ctx.Header("ETag", Format(versions...))
+}
+
+// Parses the If-Match header back into the count versions of a tag made by
+// Format. Returns nil without a header or for "*". Weak tags, lists of tags and
+// tags of another shape can never match and fail with ErrMismatch
+func IfMatch(ctx *gin.Context, count int) ([]int, error) {
+	header := strings.TrimSpace(ctx.GetHeader("If-Match"))
+	if header == "" || header == "*" {
+		return nil, nil
+	}
+
+	tag, ok := strings.CutPrefix(header, `"`)
+	if !ok {
+		return nil, ErrMismatch
+	}
+	tag, ok = strings.CutSuffix(tag, `"`)
+	if !ok {
+		return nil, ErrMismatch
+	}
+
+	parts := strings.Split(tag, ".")
+	if len(parts) != count {
+		return nil, ErrMismatch
+	}
+	versions := make([]int, 0, count)
+	for _, part := range parts {
+		version, err := strconv.Atoi(part)
+		if err != nil {
+			return nil, ErrMismatch
+		}
+		versions = append(versions, version)
+	}
+
+	return versions, nil
+}
@@ -0,0 +1,74 @@
+package expertroutes
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/bogdanshibilov/mindflowbackend/internal/controller/http/v1/middleware"
+	"github.com/bogdanshibilov/mindflowbackend/internal/entity"
+	expertrepo "github.com/bogdanshibilov/mindflowbackend/internal/repository/expert"
+	expertservice "github.com/bogdanshibilov/mindflowbackend/internal/services/expert"
+)
+
+func (r *routes) Availability(ctx *gin.Context) {
+	const op = "ExpertRoutes.Availability"
+
+	slots, err := r.experts.Availability(ctx, middleware.ScopeOf(ctx), ctx.Param("id"))
+	if err != nil {
+		r.log.Warn("failed to get availability", op, err)
+		ctx.JSON(http.StatusBadRequest, gin.H{"message": "bad id"})
+		return
+	}
+
+	DTOs := make([]availabilitySlotDTO, 0)
+	for _, entity := range slots {
+		DTOs = append(DTOs, *availabilitySlotDtoFrom(&entity))
+	}
+
+	ctx.JSON(http.StatusOK, DTOs)
+}
+
+// Replaces the weekly availability of the expert with the slots in the body,
+// minutes since midnight UTC
+func (r *routes) SetAvailability(ctx *gin.Context) {
+	const op = "ExpertRoutes.SetAvailability"
+
+	var req []availabilitySlotRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		r.log.Warn("invalid JSON received", op, err)
+		ctx.JSON(http.StatusBadRequest, gin.H{"message": "invalid JSON"})
+		return
+	}
+
+	slots := make([]entity.ExpertAvailability, 0, len(req))
+	for _, slot := range req {
+		slots = append(slots, entity.ExpertAvailability{
+			Weekday:     slot.Weekday,
+			StartMinute: slot.StartMinute,
+			EndMinute:   slot.EndMinute,
+		})
+	}
+
+	saved, err := r.experts.SetAvailability(ctx, ctx.GetString("uuid"), slots)
+	if err != nil {
+		switch {
+		case errors.Is(err, expertservice.ErrInvalidAvailability):
+			ctx.JSON(http.StatusBadRequest, gin.H{"message": "slots need a weekday from 0 to 6 and must not overlap within a day"})
+		case errors.Is(err, expertrepo.ErrExpertNotFound):
+			ctx.JSON(http.StatusNotFound, gin.H{"message": "expert not found"})
+		default:
+			r.log.Error("failed to set availability", op, err)
+			ctx.JSON(http.StatusInternalServerError, gin.H{"message": "failed to set availability"})
+		}
+		return
+	}
+
+	DTOs := make([]availabilitySlotDTO, 0)
+	for _, entity := range saved {
+		DTOs = append(DTOs, *availabilitySlotDtoFrom(&entity))
+	}
+
+	ctx.JSON(http.StatusOK, DTOs)
+}
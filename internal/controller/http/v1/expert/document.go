@@ -0,0 +1,195 @@
+package expertroutes
+
+import (
+	"errors"
+	"io"
+	"mime"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/bogdanshibilov/mindflowbackend/internal/blobstore"
+	"github.com/bogdanshibilov/mindflowbackend/internal/entity"
+	expertrepo "github.com/bogdanshibilov/mindflowbackend/internal/repository/expert"
+	expertservice "github.com/bogdanshibilov/mindflowbackend/internal/services/expert"
+)
+
+// Accepts a multipart form with kind, title and either a file or a url
+func (r *routes) AddDocument(ctx *gin.Context) {
+	const op = "ExpertRoutes.AddDocument"
+
+	kind := entity.DocumentKind(ctx.PostForm("kind"))
+	title := ctx.PostForm("title")
+
+	var document *entity.ExpertDocument
+	var err error
+	if link := ctx.PostForm("url"); link != "" {
+		document, err = r.experts.AddDocumentLink(ctx, ctx.GetString("uuid"), kind, title, link)
+	} else {
+		header, formErr := ctx.FormFile("file")
+		if formErr != nil {
+			ctx.JSON(http.StatusBadRequest, gin.H{"message": "file or url is required"})
+			return
+		}
+		file, openErr := header.Open()
+		if openErr != nil {
+			r.log.Warn("failed to open uploaded file", op, openErr)
+			ctx.JSON(http.StatusBadRequest, gin.H{"message": "invalid file"})
+			return
+		}
+		defer file.Close()
+
+		document, err = r.experts.UploadDocument(ctx, ctx.GetString("uuid"), kind, title, header.Filename, header.Size, file)
+	}
+	if err != nil {
+		switch {
+		case errors.Is(err, expertservice.ErrInvalidDocument):
+			ctx.JSON(http.StatusBadRequest, gin.H{"message": "document needs a kind, a title and a file or an http link"})
+		case errors.Is(err, expertservice.ErrUnsupportedDocument):
+			ctx.JSON(http.StatusUnsupportedMediaType, gin.H{"message": "documents must be PDF, PNG or JPEG files"})
+		case errors.Is(err, expertservice.ErrDocumentTooLarge):
+			ctx.JSON(http.StatusRequestEntityTooLarge, gin.H{"message": "document is too large"})
+		case errors.Is(err, expertrepo.ErrExpertNotFound):
+			ctx.JSON(http.StatusNotFound, gin.H{"message": "apply for expert first"})
+		default:
+			r.log.Error("failed to add document", op, err)
+			ctx.JSON(http.StatusInternalServerError, gin.H{"message": "failed to add document"})
+		}
+		return
+	}
+
+	ctx.JSON(http.StatusCreated, documentDtoFrom(document))
+}
+
+func (r *routes) MyDocuments(ctx *gin.Context) {
+	r.documentsOf(ctx, ctx.GetString("uuid"))
+}
+
+func (r *routes) Documents(ctx *gin.Context) {
+	r.documentsOf(ctx, ctx.Param("id"))
+}
+
+func (r *routes) MyDocumentFile(ctx *gin.Context) {
+	const op = "ExpertRoutes.MyDocumentFile"
+
+	document, err := r.experts.OwnDocument(ctx, ctx.Param("docid"), ctx.GetString("uuid"))
+	if err != nil {
+		r.documentFailed(ctx, op, err)
+		return
+	}
+
+	r.sendDocument(ctx, op, document)
+}
+
+func (r *routes) DocumentFile(ctx *gin.Context) {
+	const op = "ExpertRoutes.DocumentFile"
+
+	document, err := r.experts.DocumentById(ctx, ctx.Param("docid"))
+	if err != nil {
+		r.documentFailed(ctx, op, err)
+		return
+	}
+
+	r.sendDocument(ctx, op, document)
+}
+
+func (r *routes) DeleteDocument(ctx *gin.Context) {
+	const op = "ExpertRoutes.DeleteDocument"
+
+	err := r.experts.DeleteDocument(ctx, ctx.Param("docid"), ctx.GetString("uuid"))
+	if err != nil {
+		if errors.Is(err, expertservice.ErrDocumentAccepted) {
+			ctx.JSON(http.StatusConflict, gin.H{"message": "accepted documents cannot be deleted"})
+			return
+		}
+		r.documentFailed(ctx, op, err)
+		return
+	}
+
+	ctx.Status(http.StatusOK)
+}
+
+func (r *routes) ReviewDocument(ctx *gin.Context) {
+	const op = "ExpertRoutes.ReviewDocument"
+
+	var req *reviewDocumentRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		r.log.Warn("invalid JSON received", op, err)
+		ctx.JSON(http.StatusBadRequest, gin.H{"message": "invalid JSON"})
+		return
+	}
+
+	document, err := r.experts.ReviewDocument(
+		ctx,
+		ctx.GetString("uuid"),
+		ctx.Param("docid"),
+		entity.DocumentStatus(req.Status),
+		req.Note,
+	)
+	if err != nil {
+		if errors.Is(err, expertservice.ErrInvalidReviewStatus) {
+			ctx.JSON(http.StatusBadRequest, gin.H{"message": "status must be accepted or rejected"})
+			return
+		}
+		r.documentFailed(ctx, op, err)
+		return
+	}
+
+	ctx.JSON(http.StatusOK, documentDtoFrom(document))
+}
+
+func (r *routes) documentsOf(ctx *gin.Context, expertId string) {
+	const op = "ExpertRoutes.Documents"
+
+	documents, err := r.experts.Documents(ctx, expertId)
+	if err != nil {
+		r.log.Warn("failed to get documents", op, err)
+		ctx.JSON(http.StatusBadRequest, gin.H{"message": "bad id"})
+		return
+	}
+
+	DTOs := make([]documentDTO, 0)
+	for _, entity := range documents {
+		DTOs = append(DTOs, *documentDtoFrom(&entity))
+	}
+
+	ctx.JSON(http.StatusOK, DTOs)
+}
+
+func (r *routes) sendDocument(ctx *gin.Context, op string, document *entity.ExpertDocument) {
+	file, err := r.experts.OpenDocument(ctx, document)
+	if err != nil {
+		r.documentFailed(ctx, op, err)
+		return
+	}
+	defer file.Close()
+
+	contentType := "application/octet-stream"
+	if document.ContentType != nil {
+		contentType = *document.ContentType
+	}
+	if document.FileName != nil {
+		ctx.Header("Content-Disposition", mime.FormatMediaType("attachment", map[string]string{"filename": *document.FileName}))
+	}
+	ctx.Status(http.StatusOK)
+	ctx.Header("Content-Type", contentType)
+	_, err = io.Copy(ctx.Writer, file)
+	if err != nil {
+		r.log.Warn("failed to send document", op, err)
+	}
+}
+
+func (r *routes) documentFailed(ctx *gin.Context, op string, err error) {
+	switch {
+	case errors.Is(err, expertrepo.ErrDocumentNotFound),
+		errors.Is(err, blobstore.ErrNotFound):
+		ctx.JSON(http.StatusNotFound, gin.H{"message": "document not found"})
+	case errors.Is(err, expertservice.ErrNotDocumentOwner):
+		ctx.JSON(http.StatusForbidden, gin.H{"message": "forbidden"})
+	case errors.Is(err, expertservice.ErrDocumentIsLink):
+		ctx.JSON(http.StatusConflict, gin.H{"message": "document is a link and has no file"})
+	default:
+		r.log.Error("failed to handle document", op, err)
+		ctx.JSON(http.StatusBadRequest, gin.H{"message": "bad request"})
+	}
+}
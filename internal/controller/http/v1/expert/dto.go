@@ -1,39 +1,344 @@
 package expertroutes
 
 import (
+	"time"
+
+	avatarroutes "github.com/bogdanshibilov/mindflowbackend/internal/controller/http/v1/avatar"
 	"github.com/bogdanshibilov/mindflowbackend/internal/entity"
+	"github.com/bogdanshibilov/mindflowbackend/internal/recommend"
+	expertrepo "github.com/bogdanshibilov/mindflowbackend/internal/repository/expert"
+	expertservice "github.com/bogdanshibilov/mindflowbackend/internal/services/expert"
+	userservice "github.com/bogdanshibilov/mindflowbackend/internal/services/user"
 )
 
 type applyForExpertRequest struct {
 	HelpDescription string `json:"helpDescription" binding:"required"`
 	Price           int    `json:"price" binding:"required"`
+	Currency        string `json:"currency"`
+}
+
+type changePriceRequest struct {
+	Price    int    `json:"price" binding:"min=0"`
+	Currency string `json:"currency" binding:"required"`
 }
 
 type changeStatusExpertRequest struct {
 	ExpertId string `json:"expertId" binding:"required"`
 	Status   int    `json:"status" binding:"required"`
+	// Required for rejections
+	Reason string `json:"reason"`
+}
+
+type updateProfileRequest struct {
+	Name                  *string `json:"name"`
+	Phone                 *string `json:"phone"`
+	ExperienceDescription *string `json:"experienceDescription"`
+	ProfessionalField     *string `json:"professionalField"`
+	HelpDescription       *string `json:"helpDescription"`
+	// ISO 639 codes replacing the current languages
+	Languages *[]string `json:"languages"`
+	// Skill ids, names or aliases replacing the current skills
+	Skills *[]string `json:"skills"`
+}
+
+type reviewRevisionRequest struct {
+	RevisionId string `json:"revisionId" binding:"required"`
+	Status     int    `json:"status" binding:"required"`
+}
+
+type availabilitySlotRequest struct {
+	Weekday     int `json:"weekday" binding:"min=0,max=6"`
+	StartMinute int `json:"startMinute" binding:"min=0"`
+	EndMinute   int `json:"endMinute" binding:"min=1,max=1440"`
+}
+
+type pauseRequest struct {
+	// Bookings reopen by themselves at this time, or when the expert resumes if omitted
+	Until *time.Time `json:"until"`
+}
+
+type suspendRequest struct {
+	Reason string `json:"reason" binding:"required"`
+}
+
+type reviewDocumentRequest struct {
+	Status string `json:"status" binding:"required"`
+	Note   string `json:"note"`
 }
 
+// Expert profile as mentees see it. Fields hidden by the privacy settings are left out
 type expertDTO struct {
-	UserId                string `json:"userId"`
-	Email                 string `json:"email"`
-	Name                  string `json:"name"`
-	Phone                 string `json:"phone"`
-	ProfessionalField     string `json:"professionalField"`
-	ExperienceDescription string `json:"experienceDescription"`
-	HelpDescription       string `json:"helpDescription"`
-	Price                 int    `json:"price"`
+	UserId                string                `json:"userId"`
+	Email                 *string               `json:"email,omitempty"`
+	Name                  string                `json:"name"`
+	Phone                 *string               `json:"phone,omitempty"`
+	ProfessionalField     string                `json:"professionalField"`
+	ExperienceDescription *string               `json:"experienceDescription,omitempty"`
+	Avatar                *avatarroutes.URLsDTO `json:"avatar"`
+	HelpDescription       string                `json:"helpDescription"`
+	Price                 int                   `json:"price"`
+	Currency              string                `json:"currency"`
+	Verified              bool                  `json:"verified"`
+	Skills                []skillDTO            `json:"skills"`
+	Languages             []string              `json:"languages"`
+	Rating                *float64              `json:"rating"`
+	ReviewCount           int                   `json:"reviewCount"`
+	// False while the expert is paused or suspended and takes no bookings
+	Active      bool       `json:"active"`
+	PausedUntil *time.Time `json:"pausedUntil"`
+	// Set when only the organization's members see the expert
+	OrganizationId *string `json:"organizationId"`
 }
 
+type skillDTO struct {
+	Id         string `json:"id"`
+	Name       string `json:"name"`
+	CategoryId string `json:"categoryId"`
+}
+
+// Public projection for anonymous viewers and listings
 func expertDtoFrom(entity *entity.Expert) *expertDTO {
-	return &expertDTO{
-		UserId:                entity.UserUuid.String(),
-		Email:                 entity.Email,
-		Name:                  entity.Name,
-		Phone:                 entity.Phone,
-		ProfessionalField:     entity.ProfessionalField,
-		ExperienceDescription: entity.ExperienceDescription,
-		HelpDescription:       entity.HelpDescription,
-		Price:                 entity.Price,
+	return expertDtoFor(entity, userservice.ViewerPublic)
+}
+
+func expertDtoFor(entity *entity.Expert, viewer userservice.Viewer) *expertDTO {
+	skills := make([]skillDTO, 0)
+	for _, skill := range entity.Skills {
+		skills = append(skills, skillDTO{
+			Id:         skill.Uuid.String(),
+			Name:       skill.Name,
+			CategoryId: skill.CategoryUuid.String(),
+		})
+	}
+
+	languages := entity.Languages
+	if languages == nil {
+		languages = make([]string, 0)
+	}
+
+	dto := &expertDTO{
+		UserId:            entity.UserUuid.String(),
+		Name:              entity.Name,
+		ProfessionalField: entity.ProfessionalField,
+		Avatar:            avatarroutes.URLs(entity.AvatarHash),
+		HelpDescription:   entity.HelpDescription,
+		Price:             entity.Price,
+		Currency:          entity.Currency,
+		Verified:          entity.Verified,
+		Skills:            skills,
+		Languages:         languages,
+		Rating:            entity.Rating,
+		ReviewCount:       entity.ReviewCount,
+		Active:            entity.Active,
+		PausedUntil:       entity.PausedUntil,
+	}
+	if entity.OrganizationUuid != nil {
+		organizationId := entity.OrganizationUuid.String()
+		dto.OrganizationId = &organizationId
+	}
+	if viewer.CanSee(entity.ExperienceVisibility) {
+		dto.ExperienceDescription = &entity.ExperienceDescription
+	}
+	if viewer.CanSee(entity.EmailVisibility) {
+		dto.Email = &entity.Email
+	}
+	if viewer.CanSee(entity.PhoneVisibility) {
+		dto.Phone = &entity.Phone
+	}
+
+	return dto
+}
+
+// Complete expert profile with application and suspension details for staff
+type staffExpertDTO struct {
+	expertDTO
+	Status           entity.Status `json:"status"`
+	SubmittedAt      time.Time     `json:"submittedAt"`
+	SuspendedAt      *time.Time    `json:"suspendedAt"`
+	SuspensionReason *string       `json:"suspensionReason"`
+}
+
+func staffExpertDtoFrom(entity *entity.Expert) *staffExpertDTO {
+	return &staffExpertDTO{
+		expertDTO:        *expertDtoFor(entity, userservice.ViewerStaff),
+		Status:           entity.Status,
+		SubmittedAt:      entity.SubmittedAt,
+		SuspendedAt:      entity.SuspendedAt,
+		SuspensionReason: entity.SuspensionReason,
+	}
+}
+
+type searchHitDTO struct {
+	expertDTO
+	Rank float64 `json:"rank"`
+	// HTML escaped excerpt, matched words are wrapped in <mark> tags
+	Snippet string `json:"snippet"`
+}
+
+func searchHitDtoFrom(hit *expertrepo.ExpertSearchHit) *searchHitDTO {
+	return &searchHitDTO{
+		expertDTO: *expertDtoFrom(&hit.Expert),
+		Rank:      hit.Rank,
+		Snippet:   hit.Snippet,
+	}
+}
+
+type recommendationDTO struct {
+	Expert expertDTO `json:"expert"`
+	// From 0 to 1
+	Score   float64     `json:"score"`
+	Reasons []reasonDTO `json:"reasons"`
+}
+
+type reasonDTO struct {
+	Factor string  `json:"factor"`
+	Score  float64 `json:"score"`
+	Text   string  `json:"text"`
+}
+
+func recommendationDtoFrom(recommendation *recommend.Recommendation) *recommendationDTO {
+	reasons := make([]reasonDTO, 0, len(recommendation.Reasons))
+	for _, reason := range recommendation.Reasons {
+		reasons = append(reasons, reasonDTO{
+			Factor: string(reason.Factor),
+			Score:  reason.Score,
+			Text:   reason.Text,
+		})
+	}
+
+	return &recommendationDTO{
+		Expert:  *expertDtoFrom(&recommendation.Expert),
+		Score:   recommendation.Score,
+		Reasons: reasons,
+	}
+}
+
+type expertPriceDTO struct {
+	Price     int       `json:"price"`
+	Currency  string    `json:"currency"`
+	ChangedAt time.Time `json:"changedAt"`
+}
+
+func expertPriceDtoFrom(entity *entity.ExpertPrice) *expertPriceDTO {
+	return &expertPriceDTO{
+		Price:     entity.Amount,
+		Currency:  entity.Currency,
+		ChangedAt: entity.ChangedAt,
+	}
+}
+
+type availabilitySlotDTO struct {
+	Weekday     int `json:"weekday"`
+	StartMinute int `json:"startMinute"`
+	EndMinute   int `json:"endMinute"`
+}
+
+func availabilitySlotDtoFrom(entity *entity.ExpertAvailability) *availabilitySlotDTO {
+	return &availabilitySlotDTO{
+		Weekday:     entity.Weekday,
+		StartMinute: entity.StartMinute,
+		EndMinute:   entity.EndMinute,
+	}
+}
+
+type revisionDTO struct {
+	Id                string     `json:"id"`
+	ExpertId          string     `json:"expertId"`
+	ProfessionalField *string    `json:"professionalField"`
+	HelpDescription   *string    `json:"helpDescription"`
+	SkillIds          []string   `json:"skillIds"`
+	Status            int        `json:"status"`
+	SubmittedAt       time.Time  `json:"submittedAt"`
+	ReviewedAt        *time.Time `json:"reviewedAt"`
+}
+
+func revisionDtoFrom(entity *entity.ExpertRevision) *revisionDTO {
+	var skillIds []string
+	if entity.SkillUuids != nil {
+		skillIds = make([]string, 0, len(entity.SkillUuids))
+		for _, skillUuid := range entity.SkillUuids {
+			skillIds = append(skillIds, skillUuid.String())
+		}
+	}
+
+	return &revisionDTO{
+		Id:                entity.Uuid.String(),
+		ExpertId:          entity.ExpertUuid.String(),
+		ProfessionalField: entity.ProfessionalField,
+		HelpDescription:   entity.HelpDescription,
+		SkillIds:          skillIds,
+		Status:            int(entity.Status),
+		SubmittedAt:       entity.SubmittedAt,
+		ReviewedAt:        entity.ReviewedAt,
+	}
+}
+
+type applicationStatusDTO struct {
+	AlreadyApplied bool       `json:"alreadyApplied"`
+	Status         *int       `json:"status,omitempty"`
+	Reason         *string    `json:"reason,omitempty"`
+	ReviewedAt     *time.Time `json:"reviewedAt,omitempty"`
+	ResubmitAt     *time.Time `json:"resubmitAt,omitempty"`
+}
+
+func applicationStatusDtoFrom(status *expertservice.ApplicationStatus) *applicationStatusDTO {
+	code := int(status.Status)
+	return &applicationStatusDTO{
+		AlreadyApplied: true,
+		Status:         &code,
+		Reason:         status.Reason,
+		ReviewedAt:     status.ReviewedAt,
+		ResubmitAt:     status.ResubmitAt,
+	}
+}
+
+type applicationEventDTO struct {
+	Id         string    `json:"id"`
+	Status     int       `json:"status"`
+	Reason     *string   `json:"reason"`
+	ReviewerId string    `json:"reviewerId,omitempty"`
+	CreatedAt  time.Time `json:"createdAt"`
+}
+
+func applicationEventDtoFrom(entity *entity.ExpertApplicationEvent) *applicationEventDTO {
+	dto := &applicationEventDTO{
+		Id:        entity.Uuid.String(),
+		Status:    int(entity.Status),
+		Reason:    entity.Reason,
+		CreatedAt: entity.CreatedAt,
+	}
+	if entity.ReviewedBy != nil {
+		dto.ReviewerId = entity.ReviewedBy.String()
+	}
+	return dto
+}
+
+type documentDTO struct {
+	Id          string     `json:"id"`
+	Kind        string     `json:"kind"`
+	Title       string     `json:"title"`
+	FileName    *string    `json:"fileName,omitempty"`
+	ContentType *string    `json:"contentType,omitempty"`
+	SizeBytes   *int64     `json:"sizeBytes,omitempty"`
+	Url         *string    `json:"url,omitempty"`
+	Status      string     `json:"status"`
+	VerdictNote *string    `json:"verdictNote"`
+	ReviewedAt  *time.Time `json:"reviewedAt"`
+	UploadedAt  time.Time  `json:"uploadedAt"`
+}
+
+func documentDtoFrom(entity *entity.ExpertDocument) *documentDTO {
+	return &documentDTO{
+		Id:          entity.Uuid.String(),
+		Kind:        string(entity.Kind),
+		Title:       entity.Title,
+		FileName:    entity.FileName,
+		ContentType: entity.ContentType,
+		SizeBytes:   entity.SizeBytes,
+		Url:         entity.Url,
+		Status:      string(entity.Status),
+		VerdictNote: entity.VerdictNote,
+		ReviewedAt:  entity.ReviewedAt,
+		UploadedAt:  entity.UploadedAt,
 	}
 }
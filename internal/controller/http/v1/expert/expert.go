@@ -1,60 +1,112 @@
 package expertroutes
 
 import (
+	"errors"
 	"log/slog"
 	"net/http"
 	"os"
+	"strconv"
+	"strings"
 
 	"github.com/gin-gonic/gin"
 
+	"github.com/bogdanshibilov/mindflowbackend/internal/controller/http/v1/etag"
 	"github.com/bogdanshibilov/mindflowbackend/internal/controller/http/v1/middleware"
 	"github.com/bogdanshibilov/mindflowbackend/internal/entity"
+	"github.com/bogdanshibilov/mindflowbackend/internal/pagination"
+	expertrepo "github.com/bogdanshibilov/mindflowbackend/internal/repository/expert"
 	expertservice "github.com/bogdanshibilov/mindflowbackend/internal/services/expert"
+	organizationservice "github.com/bogdanshibilov/mindflowbackend/internal/services/organization"
 	userservice "github.com/bogdanshibilov/mindflowbackend/internal/services/user"
 )
 
 type routes struct {
 	log     *slog.Logger
 	experts *expertservice.Service
+	users   *userservice.Service
 }
 
+// Experts of an organization are visible to its members only, so every
+// route resolves the scope of the user, if any, first
 func New(
 	handler *gin.RouterGroup,
 	log *slog.Logger,
 	experts *expertservice.Service,
 	users *userservice.Service,
+	orgs *organizationservice.Service,
 ) {
 	r := &routes{
 		log:     log,
 		experts: experts,
+		users:   users,
 	}
 
 	expertsHandler := handler.Group("/experts")
 	{
+		expertsHandler.Use(middleware.OptionalJwt(os.Getenv("JWTSECRET"), users))
+		expertsHandler.Use(middleware.ResolveScope(orgs, log))
 		expertsHandler.GET("/filterdata", r.FilterData)
 		expertsHandler.GET("/:id", r.ById)
 		expertsHandler.GET("/approved", r.ExpertsWithFilter)
-		expertsHandler.Use(middleware.RequireJwt(os.Getenv("JWTSECRET")))
+		expertsHandler.GET("/search", r.Search)
+		expertsHandler.GET("/:id/prices", r.PriceHistory)
+		expertsHandler.GET("/:id/availability", r.Availability)
+		expertsHandler.Use(middleware.RequireJwt(os.Getenv("JWTSECRET"), users))
 		expertsHandler.Use(middleware.ParseClaimsIntoContext())
 		expertsHandler.POST("", r.ApplyForExpert)
 		expertsHandler.GET("/alreadyapplied", r.AlreadyApplied)
+		expertsHandler.GET("/recommended", r.Recommended)
+		expertsHandler.PUT("/price", r.ChangePrice)
+		expertsHandler.PUT("/me", r.UpdateProfile)
+		expertsHandler.PATCH("/me", r.PatchProfile)
+		expertsHandler.GET("/me/revisions", r.MyRevisions)
+		expertsHandler.PUT("/me/availability", r.SetAvailability)
+		expertsHandler.PUT("/me/pause", r.Pause)
+		expertsHandler.DELETE("/me/pause", r.Resume)
+		expertsHandler.POST("/me/documents", r.AddDocument)
+		expertsHandler.GET("/me/documents", r.MyDocuments)
+		expertsHandler.GET("/me/documents/:docid/file", r.MyDocumentFile)
+		expertsHandler.DELETE("/me/documents/:docid", r.DeleteDocument)
 		expertsHandler.Use(middleware.RequireAdminPermission(users, log))
 		expertsHandler.GET("", r.Experts)
 		expertsHandler.PUT("/status", r.ChangeExpertStatus)
+		expertsHandler.GET("/:id/applications", r.ApplicationHistory)
+		expertsHandler.GET("/revisions", r.Revisions)
+		expertsHandler.PUT("/revisions/status", r.ReviewRevision)
+		expertsHandler.GET("/:id/documents", r.Documents)
+		expertsHandler.GET("/documents/:docid/file", r.DocumentFile)
+		expertsHandler.PUT("/documents/:docid/verdict", r.ReviewDocument)
+		expertsHandler.PUT("/:id/suspension", r.Suspend)
+		expertsHandler.DELETE("/:id/suspension", r.LiftSuspension)
 	}
 }
 
+// Anonymous viewers get the public projection, logged in viewers may see more
+// depending on the expert's privacy settings
 func (r *routes) ById(ctx *gin.Context) {
+	const op = "ExpertRoutes.ById"
+
 	id := ctx.Param("id")
 
-	expert, err := r.experts.ById(ctx, id)
+	expert, err := r.experts.ById(ctx, middleware.ScopeOf(ctx), id)
 	if err != nil {
 		ctx.JSON(http.StatusBadRequest, gin.H{"message": "bad id"})
 		return
 	}
 
-	dto := expertDtoFrom(expert)
-	ctx.JSON(http.StatusOK, dto)
+	viewer, err := r.users.ViewerOf(ctx, ctx.GetString("uuid"), expert.UserUuid)
+	if err != nil {
+		r.log.Error("failed to check profile viewer", op, err)
+		ctx.JSON(http.StatusInternalServerError, gin.H{"message": "failed to get expert"})
+		return
+	}
+
+	etag.Set(ctx, expert.UserProfile.Version, expert.ExpertInformation.Version)
+	if viewer == userservice.ViewerStaff {
+		ctx.JSON(http.StatusOK, staffExpertDtoFrom(expert))
+		return
+	}
+	ctx.JSON(http.StatusOK, expertDtoFor(expert, viewer))
 }
 
 func (r *routes) ApplyForExpert(ctx *gin.Context) {
@@ -71,12 +123,26 @@ func (r *routes) ApplyForExpert(ctx *gin.Context) {
 
 	err := r.experts.ApplyForExpert(
 		ctx,
+		middleware.ScopeOf(ctx),
 		id,
 		req.HelpDescription,
 		req.Price,
+		strings.ToUpper(req.Currency),
 	)
 
 	if err != nil {
+		if isPriceError(err) {
+			ctx.JSON(http.StatusBadRequest, gin.H{"message": "invalid price or unsupported currency"})
+			return
+		}
+		if errors.Is(err, expertrepo.ErrExpertAlreadyExists) {
+			ctx.JSON(http.StatusConflict, gin.H{"message": "already applied"})
+			return
+		}
+		if errors.Is(err, expertservice.ErrResubmitCooldown) {
+			ctx.JSON(http.StatusConflict, gin.H{"message": "rejected application cannot be resubmitted yet"})
+			return
+		}
 		r.log.Error("failed to appy for expert", op, err)
 		ctx.JSON(http.StatusInternalServerError, gin.H{"message": "failed to appy for expert"})
 		return
@@ -89,19 +155,25 @@ func (r *routes) Experts(ctx *gin.Context) {
 	const op = "ExpertRoutes.Experts"
 
 	status := getStatusQuery(ctx)
-	filter := make(map[string]any)
+	filter := &expertrepo.ExpertFilter{}
 	if status >= 0 {
-		filter["status IN (?)"] = status
+		filter.Status = &status
+	}
+
+	page, err := pagination.FromQuery(ctx.Request.URL.Query(), expertrepo.Sorts, "submitted")
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"message": err.Error()})
+		return
 	}
 
-	experts, err := r.experts.ExpertsWithFilter(ctx, filter)
+	experts, err := r.experts.ExpertsWithFilter(ctx, middleware.ScopeOf(ctx), filter, page)
 	if err != nil {
 		r.log.Error("failed to get pending experts", op, err)
 		ctx.JSON(http.StatusInternalServerError, gin.H{"message": "failed to get experts"})
 		return
 	}
 
-	ctx.JSON(http.StatusOK, experts)
+	ctx.JSON(http.StatusOK, pagination.Map(experts, staffExpertDtoFrom))
 }
 
 func (r *routes) ChangeExpertStatus(ctx *gin.Context) {
@@ -114,8 +186,20 @@ func (r *routes) ChangeExpertStatus(ctx *gin.Context) {
 		return
 	}
 
-	err := r.experts.ChangeExpertStatus(ctx, req.ExpertId, entity.Status(req.Status))
+	err := r.experts.ChangeExpertStatus(ctx, ctx.GetString("uuid"), req.ExpertId, entity.Status(req.Status), req.Reason)
 	if err != nil {
+		if errors.Is(err, expertservice.ErrReasonRequired) {
+			ctx.JSON(http.StatusBadRequest, gin.H{"message": "rejections need a reason"})
+			return
+		}
+		if errors.Is(err, expertrepo.ErrExpertNotFound) {
+			ctx.JSON(http.StatusNotFound, gin.H{"message": "expert not found"})
+			return
+		}
+		if errors.Is(err, expertrepo.ErrNotReviewable) {
+			ctx.JSON(http.StatusConflict, gin.H{"message": "only pending applications can be reviewed"})
+			return
+		}
 		r.log.Error("failed to approve expert", op, err)
 		ctx.JSON(http.StatusInternalServerError, gin.H{"message": "failed to approve expert"})
 		return
@@ -127,8 +211,12 @@ func (r *routes) ChangeExpertStatus(ctx *gin.Context) {
 func (r *routes) FilterData(ctx *gin.Context) {
 	const op = "ExpertRoutes.FilterData"
 
-	fieldsData, err := r.experts.FilterData(ctx)
+	fieldsData, err := r.experts.FilterData(ctx, middleware.ScopeOf(ctx), strings.ToUpper(ctx.Query("currency")))
 	if err != nil {
+		if isPriceError(err) {
+			ctx.JSON(http.StatusBadRequest, gin.H{"message": "unsupported currency"})
+			return
+		}
 		r.log.Error("failed to get filter data", op, err)
 		ctx.JSON(http.StatusInternalServerError, gin.H{"message": "failed to get filter data"})
 		return
@@ -140,53 +228,305 @@ func (r *routes) FilterData(ctx *gin.Context) {
 func (r *routes) ExpertsWithFilter(ctx *gin.Context) {
 	const op = "ExpertRoutes.ExpertsWithFilter"
 
-	filter := make(map[string]any)
-	minprice := ctx.Query("minprice")
-	if minprice != "" {
-		filter["price >= ?"] = minprice
-	}
-	maxprice := ctx.Query("maxprice")
-	if minprice != "" {
-		filter["price <= ?"] = maxprice
-	}
-	filter["status IN (?)"] = entity.Approved
-
-	field := ctx.Query("field")
-	if field != "" {
-		filter["professional_field IN (?)"] = field
+	filter, ok := r.approvedExpertsFilter(ctx, op)
+	if !ok {
+		return
 	}
 
-	name := ctx.Query("name")
-	if name != "" {
-		filter["name ILIKE (?)"] = "%" + name + "%"
+	page, err := pagination.FromQuery(ctx.Request.URL.Query(), expertrepo.Sorts, "name")
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"message": err.Error()})
+		return
 	}
 
-	experts, err := r.experts.ExpertsWithFilter(ctx, filter)
+	experts, err := r.experts.ExpertsWithFilter(ctx, middleware.ScopeOf(ctx), filter, page)
 	if err != nil {
 		r.log.Error("failed to get experts", op, err)
 		ctx.JSON(http.StatusInternalServerError, gin.H{"message": "failed to get experts"})
 		return
 	}
 
-	DTOs := make([]expertDTO, 0)
-	for _, entity := range experts {
-		DTOs = append(DTOs, *expertDtoFrom(&entity))
+	ctx.JSON(http.StatusOK, pagination.Map(experts, expertDtoFrom))
+}
+
+// Builds the filter of approved experts from the query params shared by
+// listing and search. Responds with an error and returns false on bad params
+func (r *routes) approvedExpertsFilter(ctx *gin.Context, op string) (*expertrepo.ExpertFilter, bool) {
+	approved := entity.Approved
+	filter := &expertrepo.ExpertFilter{
+		Status:       &approved,
+		Name:         strings.TrimSpace(ctx.Query("name")),
+		OnlyVerified: ctx.Query("verified") == "true",
+		OnlyActive:   true,
 	}
 
-	ctx.JSON(http.StatusOK, DTOs)
+	// Price bounds are in minor units of the currency query param, the base currency by default
+	currency := strings.ToUpper(ctx.Query("currency"))
+	for param, bound := range map[string]**int{
+		"minprice": &filter.MinPrice,
+		"maxprice": &filter.MaxPrice,
+	} {
+		if ctx.Query(param) == "" {
+			continue
+		}
+		price, err := strconv.Atoi(ctx.Query(param))
+		if err != nil {
+			ctx.JSON(http.StatusBadRequest, gin.H{"message": "invalid " + param})
+			return nil, false
+		}
+		basePrice, err := r.experts.BasePrice(ctx, price, currency)
+		if err != nil {
+			if isPriceError(err) {
+				ctx.JSON(http.StatusBadRequest, gin.H{"message": "unsupported currency"})
+				return nil, false
+			}
+			r.log.Error("failed to convert price", op, err)
+			ctx.JSON(http.StatusInternalServerError, gin.H{"message": "failed to get experts"})
+			return nil, false
+		}
+		*bound = &basePrice
+	}
+
+	// Comma separated skill ids, names or aliases, match=all keeps experts having every skill
+	if ctx.Query("skills") != "" {
+		skillUuids, err := r.experts.ResolveSkills(ctx, strings.Split(ctx.Query("skills"), ","))
+		if err != nil {
+			if errors.Is(err, expertservice.ErrUnknownSkill) {
+				ctx.JSON(http.StatusBadRequest, gin.H{"message": "unknown skill"})
+				return nil, false
+			}
+			r.log.Error("failed to resolve skills", op, err)
+			ctx.JSON(http.StatusInternalServerError, gin.H{"message": "failed to get experts"})
+			return nil, false
+		}
+		filter.SkillUuids = skillUuids
+		switch ctx.DefaultQuery("match", "any") {
+		case "any":
+		case "all":
+			filter.MatchAllSkills = true
+		default:
+			ctx.JSON(http.StatusBadRequest, gin.H{"message": "match must be any or all"})
+			return nil, false
+		}
+	}
+
+	// Comma separated ISO 639 codes
+	if ctx.Query("languages") != "" {
+		for _, language := range strings.Split(ctx.Query("languages"), ",") {
+			filter.Languages = append(filter.Languages, strings.ToLower(strings.TrimSpace(language)))
+		}
+	}
+
+	if ctx.Query("minrating") != "" {
+		rating, err := strconv.ParseFloat(ctx.Query("minrating"), 64)
+		if err != nil {
+			ctx.JSON(http.StatusBadRequest, gin.H{"message": "invalid minrating"})
+			return nil, false
+		}
+		filter.MinRating = &rating
+	}
+
+	// Comma separated weekdays, numbers from 0 (sunday) or english names
+	if ctx.Query("available") != "" {
+		for _, day := range strings.Split(ctx.Query("available"), ",") {
+			weekday, ok := parseWeekday(day)
+			if !ok {
+				ctx.JSON(http.StatusBadRequest, gin.H{"message": "invalid available weekday"})
+				return nil, false
+			}
+			filter.AvailableOn = append(filter.AvailableOn, weekday)
+		}
+	}
+
+	if err := filter.Validate(); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"message": err.Error()})
+		return nil, false
+	}
+
+	return filter, true
 }
 
+// Also returns the status of the application, the reason of the last
+// review and when a rejected applicant may apply again
 func (r *routes) AlreadyApplied(ctx *gin.Context) {
 	const op = "ExpertRoutes.AlreadyApplied"
 
-	id := ctx.GetString("uuid")
-
-	alreadyApplied, err := r.experts.DoesExist(ctx, id)
+	application, err := r.experts.ApplicationStatus(ctx, ctx.GetString("uuid"))
 	if err != nil {
+		if errors.Is(err, expertrepo.ErrExpertNotFound) {
+			ctx.JSON(http.StatusOK, applicationStatusDTO{AlreadyApplied: false})
+			return
+		}
 		r.log.Error("failed to check existance", op, err)
 		ctx.JSON(http.StatusInternalServerError, gin.H{"message": "failed to check existance"})
 		return
 	}
 
-	ctx.JSON(http.StatusOK, gin.H{"alreadyApplied": alreadyApplied})
+	ctx.JSON(http.StatusOK, applicationStatusDtoFrom(application))
+}
+
+func (r *routes) ApplicationHistory(ctx *gin.Context) {
+	const op = "ExpertRoutes.ApplicationHistory"
+
+	events, err := r.experts.ApplicationHistory(ctx, ctx.Param("id"))
+	if err != nil {
+		r.log.Warn("failed to get application history", op, err)
+		ctx.JSON(http.StatusBadRequest, gin.H{"message": "bad id"})
+		return
+	}
+
+	DTOs := make([]applicationEventDTO, 0)
+	for _, entity := range events {
+		DTOs = append(DTOs, *applicationEventDtoFrom(&entity))
+	}
+
+	ctx.JSON(http.StatusOK, DTOs)
+}
+
+func (r *routes) ChangePrice(ctx *gin.Context) {
+	const op = "ExpertRoutes.ChangePrice"
+
+	var req *changePriceRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		r.log.Warn("invalid JSON received", op, err)
+		ctx.JSON(http.StatusBadRequest, gin.H{"message": "invalid JSON"})
+		return
+	}
+
+	err := r.experts.ChangePrice(ctx, ctx.GetString("uuid"), req.Price, strings.ToUpper(req.Currency))
+	if err != nil {
+		switch {
+		case isPriceError(err):
+			ctx.JSON(http.StatusBadRequest, gin.H{"message": "invalid price or unsupported currency"})
+		case errors.Is(err, expertrepo.ErrExpertNotFound):
+			ctx.JSON(http.StatusNotFound, gin.H{"message": "expert not found"})
+		default:
+			r.log.Error("failed to change price", op, err)
+			ctx.JSON(http.StatusInternalServerError, gin.H{"message": "failed to change price"})
+		}
+		return
+	}
+
+	ctx.Status(http.StatusOK)
+}
+
+func (r *routes) PriceHistory(ctx *gin.Context) {
+	const op = "ExpertRoutes.PriceHistory"
+
+	prices, err := r.experts.PriceHistory(ctx, middleware.ScopeOf(ctx), ctx.Param("id"))
+	if err != nil {
+		r.log.Warn("failed to get price history", op, err)
+		ctx.JSON(http.StatusBadRequest, gin.H{"message": "bad id"})
+		return
+	}
+
+	DTOs := make([]expertPriceDTO, 0)
+	for _, entity := range prices {
+		DTOs = append(DTOs, *expertPriceDtoFrom(&entity))
+	}
+
+	ctx.JSON(http.StatusOK, DTOs)
+}
+
+// Cosmetic changes apply immediately, the response holds the revision
+// created for sensitive changes if any
+func (r *routes) UpdateProfile(ctx *gin.Context) {
+	const op = "ExpertRoutes.UpdateProfile"
+
+	var req *updateProfileRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		r.log.Warn("invalid JSON received", op, err)
+		ctx.JSON(http.StatusBadRequest, gin.H{"message": "invalid JSON"})
+		return
+	}
+
+	ifMatch, ok := ifMatchVersion(ctx)
+	if !ok {
+		return
+	}
+
+	revision, version, err := r.experts.UpdateProfile(ctx, middleware.ScopeOf(ctx), ctx.GetString("uuid"), &expertservice.ProfileUpdate{
+		Name:                  req.Name,
+		Phone:                 req.Phone,
+		ExperienceDescription: req.ExperienceDescription,
+		ProfessionalField:     req.ProfessionalField,
+		HelpDescription:       req.HelpDescription,
+		Languages:             req.Languages,
+		Skills:                req.Skills,
+	}, ifMatch)
+	if err != nil {
+		r.profileUpdateFailed(ctx, op, err)
+		return
+	}
+
+	profileUpdated(ctx, revision, version)
+}
+
+func (r *routes) MyRevisions(ctx *gin.Context) {
+	const op = "ExpertRoutes.MyRevisions"
+
+	revisions, err := r.experts.RevisionsByExpertId(ctx, ctx.GetString("uuid"))
+	if err != nil {
+		r.log.Error("failed to get revisions", op, err)
+		ctx.JSON(http.StatusInternalServerError, gin.H{"message": "failed to get revisions"})
+		return
+	}
+
+	DTOs := make([]revisionDTO, 0)
+	for _, entity := range revisions {
+		DTOs = append(DTOs, *revisionDtoFrom(&entity))
+	}
+
+	ctx.JSON(http.StatusOK, DTOs)
+}
+
+// Lists revisions by the status query param, pending by default
+func (r *routes) Revisions(ctx *gin.Context) {
+	const op = "ExpertRoutes.Revisions"
+
+	status := getStatusQuery(ctx)
+	if status < 0 {
+		status = entity.Pending
+	}
+
+	revisions, err := r.experts.RevisionsByStatus(ctx, status)
+	if err != nil {
+		r.log.Error("failed to get revisions", op, err)
+		ctx.JSON(http.StatusInternalServerError, gin.H{"message": "failed to get revisions"})
+		return
+	}
+
+	DTOs := make([]revisionDTO, 0)
+	for _, entity := range revisions {
+		DTOs = append(DTOs, *revisionDtoFrom(&entity))
+	}
+
+	ctx.JSON(http.StatusOK, DTOs)
+}
+
+func (r *routes) ReviewRevision(ctx *gin.Context) {
+	const op = "ExpertRoutes.ReviewRevision"
+
+	var req *reviewRevisionRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		r.log.Warn("invalid JSON received", op, err)
+		ctx.JSON(http.StatusBadRequest, gin.H{"message": "invalid JSON"})
+		return
+	}
+
+	revision, err := r.experts.ReviewRevision(ctx, ctx.GetString("uuid"), req.RevisionId, entity.Status(req.Status))
+	if err != nil {
+		switch {
+		case errors.Is(err, expertservice.ErrInvalidReviewStatus):
+			ctx.JSON(http.StatusBadRequest, gin.H{"message": "status must be approved or rejected"})
+		case errors.Is(err, expertrepo.ErrRevisionNotPending):
+			ctx.JSON(http.StatusConflict, gin.H{"message": "revision not found or already reviewed"})
+		default:
+			r.log.Error("failed to review revision", op, err)
+			ctx.JSON(http.StatusInternalServerError, gin.H{"message": "failed to review revision"})
+		}
+		return
+	}
+
+	ctx.JSON(http.StatusOK, revisionDtoFrom(revision))
 }
@@ -1,11 +1,16 @@
 package expertroutes
 
 import (
+	"errors"
+	"strconv"
 	"strings"
+	"time"
 
 	"github.com/gin-gonic/gin"
 
 	"github.com/bogdanshibilov/mindflowbackend/internal/entity"
+	currencyservice "github.com/bogdanshibilov/mindflowbackend/internal/services/currency"
+	expertservice "github.com/bogdanshibilov/mindflowbackend/internal/services/expert"
 )
 
 func getStatusQuery(ctx *gin.Context) entity.Status {
@@ -22,3 +27,24 @@ func getStatusQuery(ctx *gin.Context) entity.Status {
 		return -1
 	}
 }
+
+func isPriceError(err error) bool {
+	return errors.Is(err, expertservice.ErrInvalidPrice) ||
+		errors.Is(err, currencyservice.ErrInvalidCurrency) ||
+		errors.Is(err, currencyservice.ErrUnsupportedCurrency)
+}
+
+// Parses a weekday given as a number from 0 (sunday) or an english name
+func parseWeekday(value string) (time.Weekday, bool) {
+	value = strings.ToLower(strings.TrimSpace(value))
+	if number, err := strconv.Atoi(value); err == nil {
+		return time.Weekday(number), number >= 0 && number <= 6
+	}
+	for weekday := time.Sunday; weekday <= time.Saturday; weekday++ {
+		name := strings.ToLower(weekday.String())
+		if value == name || value == name[:3] {
+			return weekday, true
+		}
+	}
+	return 0, false
+}
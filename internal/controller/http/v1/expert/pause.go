@@ -0,0 +1,101 @@
+package expertroutes
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+
+	expertrepo "github.com/bogdanshibilov/mindflowbackend/internal/repository/expert"
+	expertservice "github.com/bogdanshibilov/mindflowbackend/internal/services/expert"
+)
+
+// Hides the expert from listings and stops new bookings, existing
+// consultations are kept
+func (r *routes) Pause(ctx *gin.Context) {
+	const op = "ExpertRoutes.Pause"
+
+	var req *pauseRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		r.log.Warn("invalid JSON received", op, err)
+		ctx.JSON(http.StatusBadRequest, gin.H{"message": "invalid JSON"})
+		return
+	}
+
+	err := r.experts.Pause(ctx, ctx.GetString("uuid"), req.Until)
+	if err != nil {
+		switch {
+		case errors.Is(err, expertservice.ErrInvalidPauseEnd):
+			ctx.JSON(http.StatusBadRequest, gin.H{"message": "pause must end in the future"})
+		case errors.Is(err, expertrepo.ErrExpertNotFound):
+			ctx.JSON(http.StatusNotFound, gin.H{"message": "expert not found"})
+		default:
+			r.log.Error("failed to pause expert", op, err)
+			ctx.JSON(http.StatusInternalServerError, gin.H{"message": "failed to pause"})
+		}
+		return
+	}
+
+	ctx.Status(http.StatusOK)
+}
+
+func (r *routes) Resume(ctx *gin.Context) {
+	const op = "ExpertRoutes.Resume"
+
+	err := r.experts.Resume(ctx, ctx.GetString("uuid"))
+	if err != nil {
+		if errors.Is(err, expertrepo.ErrExpertNotFound) {
+			ctx.JSON(http.StatusNotFound, gin.H{"message": "expert not found"})
+			return
+		}
+		r.log.Error("failed to resume expert", op, err)
+		ctx.JSON(http.StatusInternalServerError, gin.H{"message": "failed to resume"})
+		return
+	}
+
+	ctx.Status(http.StatusOK)
+}
+
+func (r *routes) Suspend(ctx *gin.Context) {
+	const op = "ExpertRoutes.Suspend"
+
+	var req *suspendRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		r.log.Warn("invalid JSON received", op, err)
+		ctx.JSON(http.StatusBadRequest, gin.H{"message": "invalid JSON"})
+		return
+	}
+
+	err := r.experts.Suspend(ctx, ctx.GetString("uuid"), ctx.Param("id"), req.Reason)
+	if err != nil {
+		switch {
+		case errors.Is(err, expertservice.ErrReasonRequired):
+			ctx.JSON(http.StatusBadRequest, gin.H{"message": "suspensions need a reason"})
+		case errors.Is(err, expertrepo.ErrExpertNotFound):
+			ctx.JSON(http.StatusNotFound, gin.H{"message": "expert not found"})
+		default:
+			r.log.Error("failed to suspend expert", op, err)
+			ctx.JSON(http.StatusInternalServerError, gin.H{"message": "failed to suspend expert"})
+		}
+		return
+	}
+
+	ctx.Status(http.StatusOK)
+}
+
+func (r *routes) LiftSuspension(ctx *gin.Context) {
+	const op = "ExpertRoutes.LiftSuspension"
+
+	err := r.experts.LiftSuspension(ctx, ctx.Param("id"))
+	if err != nil {
+		if errors.Is(err, expertrepo.ErrExpertNotFound) {
+			ctx.JSON(http.StatusNotFound, gin.H{"message": "expert not found"})
+			return
+		}
+		r.log.Error("failed to lift suspension", op, err)
+		ctx.JSON(http.StatusInternalServerError, gin.H{"message": "failed to lift suspension"})
+		return
+	}
+
+	ctx.Status(http.StatusOK)
+}
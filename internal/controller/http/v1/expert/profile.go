@@ -0,0 +1,91 @@
+package expertroutes
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/bogdanshibilov/mindflowbackend/internal/controller/http/v1/etag"
+	"github.com/bogdanshibilov/mindflowbackend/internal/controller/http/v1/middleware"
+	"github.com/bogdanshibilov/mindflowbackend/internal/entity"
+	"github.com/bogdanshibilov/mindflowbackend/internal/mergepatch"
+	expertrepo "github.com/bogdanshibilov/mindflowbackend/internal/repository/expert"
+	expertservice "github.com/bogdanshibilov/mindflowbackend/internal/services/expert"
+)
+
+// Accepts a JSON Merge Patch of the fields of updateProfileRequest, with
+// languages and skills replaced as a whole
+func (r *routes) PatchProfile(ctx *gin.Context) {
+	const op = "ExpertRoutes.PatchProfile"
+
+	if ctx.ContentType() != mergepatch.ContentType && ctx.ContentType() != gin.MIMEJSON {
+		ctx.JSON(http.StatusUnsupportedMediaType, gin.H{"message": "body must be " + mergepatch.ContentType})
+		return
+	}
+
+	ifMatch, ok := ifMatchVersion(ctx)
+	if !ok {
+		return
+	}
+
+	patch, err := ctx.GetRawData()
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"message": "invalid body"})
+		return
+	}
+
+	revision, version, err := r.experts.PatchProfile(ctx, middleware.ScopeOf(ctx), ctx.GetString("uuid"), patch, ifMatch)
+	if err != nil {
+		r.profileUpdateFailed(ctx, op, err)
+		return
+	}
+
+	profileUpdated(ctx, revision, version)
+}
+
+// Returns the profile version from If-Match, nil without the header. Responds
+// and returns false when it can never match
+func ifMatchVersion(ctx *gin.Context) (*expertservice.Version, bool) {
+	versions, err := etag.IfMatch(ctx, 2)
+	if err != nil {
+		ctx.JSON(http.StatusPreconditionFailed, gin.H{"message": "profile was changed, reload it and try again"})
+		return nil, false
+	}
+	if versions == nil {
+		return nil, true
+	}
+	return &expertservice.Version{Profile: versions[0], Information: versions[1]}, true
+}
+
+// Responds with the new ETag and the revision submitted for review, if any
+func profileUpdated(ctx *gin.Context, revision *entity.ExpertRevision, version expertservice.Version) {
+	etag.Set(ctx, version.Profile, version.Information)
+
+	if revision == nil {
+		ctx.JSON(http.StatusOK, gin.H{"pendingRevision": nil})
+		return
+	}
+
+	ctx.JSON(http.StatusAccepted, gin.H{"pendingRevision": revisionDtoFrom(revision)})
+}
+
+func (r *routes) profileUpdateFailed(ctx *gin.Context, op string, err error) {
+	switch {
+	case errors.Is(err, mergepatch.ErrInvalidPatch):
+		ctx.JSON(http.StatusBadRequest, gin.H{"message": "invalid merge patch"})
+	case errors.Is(err, expertservice.ErrInvalidProfile):
+		ctx.JSON(http.StatusBadRequest, gin.H{"message": "name and help description must not be empty"})
+	case errors.Is(err, expertservice.ErrUnknownSkill):
+		ctx.JSON(http.StatusBadRequest, gin.H{"message": "unknown skill"})
+	case errors.Is(err, expertservice.ErrInvalidLanguage):
+		ctx.JSON(http.StatusBadRequest, gin.H{"message": "languages must be ISO 639 codes"})
+	case errors.Is(err, expertrepo.ErrVersionMismatch):
+		ctx.JSON(http.StatusPreconditionFailed, gin.H{"message": "profile was changed, reload it and try again"})
+	case errors.Is(err, expertrepo.ErrExpertNotFound):
+		ctx.JSON(http.StatusNotFound, gin.H{"message": "expert not found"})
+	default:
+		r.log.Error("failed to update profile", op, err)
+		ctx.JSON(http.StatusInternalServerError, gin.H{"message": "failed to update profile"})
+	}
+}
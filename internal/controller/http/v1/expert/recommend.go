@@ -0,0 +1,68 @@
+package expertroutes
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/bogdanshibilov/mindflowbackend/internal/controller/http/v1/middleware"
+	userrepo "github.com/bogdanshibilov/mindflowbackend/internal/repository/user"
+)
+
+const (
+	defaultRecommendationLimit = 10
+	maxRecommendationLimit     = 50
+)
+
+// Recommends approved experts for the user's professional field and experience.
+// The optional maxprice budget is in minor units of the currency param
+func (r *routes) Recommended(ctx *gin.Context) {
+	const op = "ExpertRoutes.Recommended"
+
+	limit, err := strconv.Atoi(ctx.DefaultQuery("limit", strconv.Itoa(defaultRecommendationLimit)))
+	if err != nil || limit < 1 {
+		ctx.JSON(http.StatusBadRequest, gin.H{"message": "invalid limit"})
+		return
+	}
+
+	var budget *int
+	if ctx.Query("maxprice") != "" {
+		price, err := strconv.Atoi(ctx.Query("maxprice"))
+		if err != nil || price < 0 {
+			ctx.JSON(http.StatusBadRequest, gin.H{"message": "invalid maxprice"})
+			return
+		}
+		basePrice, err := r.experts.BasePrice(ctx, price, strings.ToUpper(ctx.Query("currency")))
+		if err != nil {
+			if isPriceError(err) {
+				ctx.JSON(http.StatusBadRequest, gin.H{"message": "unsupported currency"})
+				return
+			}
+			r.log.Error("failed to convert price", op, err)
+			ctx.JSON(http.StatusInternalServerError, gin.H{"message": "failed to get recommendations"})
+			return
+		}
+		budget = &basePrice
+	}
+
+	recommendations, err := r.experts.Recommend(ctx, middleware.ScopeOf(ctx), ctx.GetString("uuid"), budget, min(limit, maxRecommendationLimit))
+	if err != nil {
+		if errors.Is(err, userrepo.ErrUserNotFound) {
+			ctx.JSON(http.StatusNotFound, gin.H{"message": "user not found"})
+			return
+		}
+		r.log.Error("failed to get recommendations", op, err)
+		ctx.JSON(http.StatusInternalServerError, gin.H{"message": "failed to get recommendations"})
+		return
+	}
+
+	DTOs := make([]recommendationDTO, 0)
+	for _, recommendation := range recommendations {
+		DTOs = append(DTOs, *recommendationDtoFrom(&recommendation))
+	}
+
+	ctx.JSON(http.StatusOK, DTOs)
+}
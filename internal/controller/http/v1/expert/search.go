@@ -0,0 +1,66 @@
+package expertroutes
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/bogdanshibilov/mindflowbackend/internal/controller/http/v1/middleware"
+	expertrepo "github.com/bogdanshibilov/mindflowbackend/internal/repository/expert"
+	expertservice "github.com/bogdanshibilov/mindflowbackend/internal/services/expert"
+)
+
+const (
+	defaultSearchLimit = 20
+	maxSearchLimit     = 50
+)
+
+// Searches approved experts by the q param, taking the same filters as
+// /experts/approved. Hits are ranked by relevance unless sort is given
+func (r *routes) Search(ctx *gin.Context) {
+	const op = "ExpertRoutes.Search"
+
+	filter, ok := r.approvedExpertsFilter(ctx, op)
+	if !ok {
+		return
+	}
+
+	limit, err := strconv.Atoi(ctx.DefaultQuery("limit", strconv.Itoa(defaultSearchLimit)))
+	if err != nil || limit < 1 {
+		ctx.JSON(http.StatusBadRequest, gin.H{"message": "invalid limit"})
+		return
+	}
+	offset, err := strconv.Atoi(ctx.DefaultQuery("offset", "0"))
+	if err != nil || offset < 0 {
+		ctx.JSON(http.StatusBadRequest, gin.H{"message": "invalid offset"})
+		return
+	}
+	var opts []expertrepo.ExpertsOption
+	switch ctx.Query("sort") {
+	case "price_asc":
+		opts = append(opts, expertrepo.OrderByBasePrice(false))
+	case "price_desc":
+		opts = append(opts, expertrepo.OrderByBasePrice(true))
+	}
+	opts = append(opts, expertrepo.Page(uint64(min(limit, maxSearchLimit)), uint64(offset)))
+
+	hits, err := r.experts.SearchExperts(ctx, middleware.ScopeOf(ctx), ctx.Query("q"), filter, opts...)
+	if err != nil {
+		if errors.Is(err, expertservice.ErrSearchQueryTooShort) {
+			ctx.JSON(http.StatusBadRequest, gin.H{"message": "search query needs at least 2 characters"})
+			return
+		}
+		r.log.Error("failed to search experts", op, err)
+		ctx.JSON(http.StatusInternalServerError, gin.H{"message": "failed to search experts"})
+		return
+	}
+
+	DTOs := make([]searchHitDTO, 0)
+	for _, hit := range hits {
+		DTOs = append(DTOs, *searchHitDtoFrom(&hit))
+	}
+
+	ctx.JSON(http.StatusOK, DTOs)
+}
@@ -13,7 +13,10 @@ import (
 	userservice "github.com/bogdanshibilov/mindflowbackend/internal/services/user"
 )
 
-func RequireJwt(secret string) gin.HandlerFunc {
+// Rejects requests without a valid token, or with one of a user who was
+// deleted or signed out everywhere since it was issued. Suspended users get
+// a 403 telling them why
+func RequireJwt(secret string, users *userservice.Service) gin.HandlerFunc {
 	return func(ctx *gin.Context) {
 		tokenString, err := getAuthorizationToken(ctx)
 		if err != nil || tokenString == "null" || tokenString == "undefined" {
@@ -27,7 +30,49 @@ func RequireJwt(secret string) gin.HandlerFunc {
 			return
 		}
 
+		err = users.CheckSession(ctx, claims.Uuid, claims.TokenVersion)
+		if err != nil {
+			if errors.Is(err, userservice.ErrAccountSuspended) {
+				suspension, err := users.Suspension(ctx, claims.Uuid)
+				if err == nil {
+					ctx.AbortWithStatusJSON(http.StatusForbidden, SuspendedResponse(suspension))
+					return
+				}
+			}
+			ctx.AbortWithStatus(http.StatusUnauthorized)
+			return
+		}
+
+		ctx.Set("claims", claims)
+
+		ctx.Next()
+	}
+}
+
+// Sets claims and "uuid" in context when a valid token is sent, requests
+// without one pass through anonymously. For public routes that show more to known users
+func OptionalJwt(secret string, users *userservice.Service) gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		tokenString, err := getAuthorizationToken(ctx)
+		if err != nil {
+			ctx.Next()
+			return
+		}
+
+		claims, err := jwtservice.ParseJwtToken(tokenString, []byte(secret))
+		if err != nil {
+			ctx.Next()
+			return
+		}
+
+		err = users.CheckSession(ctx, claims.Uuid, claims.TokenVersion)
+		if err != nil {
+			ctx.Next()
+			return
+		}
+
 		ctx.Set("claims", claims)
+		ctx.Set("uuid", claims.Uuid)
 
 		ctx.Next()
 	}
@@ -0,0 +1,37 @@
+package middleware
+
+import (
+	"log/slog"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+
+	organizationservice "github.com/bogdanshibilov/mindflowbackend/internal/services/organization"
+	"github.com/bogdanshibilov/mindflowbackend/internal/tenant"
+)
+
+// Sets "scope" in context to what the user in "uuid" may see, requests
+// without a user get the public scope. Must go after OptionalJwt or ParseClaimsIntoContext
+func ResolveScope(orgs *organizationservice.Service, log *slog.Logger) gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		const op = "middleware.scope.ResolveScope"
+
+		scope, err := orgs.Scope(ctx, ctx.GetString("uuid"))
+		if err != nil {
+			log.Error("failed to resolve organization scope", op, err)
+			ctx.AbortWithStatus(http.StatusInternalServerError)
+			return
+		}
+
+		ctx.Set("scope", scope)
+
+		ctx.Next()
+	}
+}
+
+// Returns the scope set by ResolveScope, the public scope when there is none
+func ScopeOf(ctx *gin.Context) tenant.Scope {
+	scopeMaybe, _ := ctx.Get("scope")
+	scope, _ := scopeMaybe.(tenant.Scope)
+	return scope
+}
@@ -0,0 +1,18 @@
+package middleware
+
+import (
+	"github.com/gin-gonic/gin"
+
+	"github.com/bogdanshibilov/mindflowbackend/internal/entity"
+)
+
+// Body of the 403 sent to suspended users, so they learn why and how to appeal
+func SuspendedResponse(suspension *entity.AccountSuspension) gin.H {
+	return gin.H{
+		"message": "account is suspended",
+		"reason":  suspension.SuspensionReason,
+		// Nil for permanent bans
+		"suspendedUntil": suspension.SuspendedUntil,
+		"appealNote":     suspension.AppealNote,
+	}
+}
@@ -0,0 +1,115 @@
+package organizationroutes
+
+import (
+	"time"
+
+	"github.com/bogdanshibilov/mindflowbackend/internal/entity"
+)
+
+type createOrganizationRequest struct {
+	Slug     string          `json:"slug" binding:"required"`
+	Name     string          `json:"name" binding:"required"`
+	Branding brandingRequest `json:"branding"`
+	// Defaults to true
+	PublicExperts *bool `json:"publicExperts"`
+}
+
+type brandingRequest struct {
+	// Empty values clear the branding
+	LogoUrl      *string `json:"logoUrl"`
+	PrimaryColor *string `json:"primaryColor"`
+}
+
+type settingsRequest struct {
+	PublicExperts *bool `json:"publicExperts" binding:"required"`
+}
+
+type setMemberRequest struct {
+	Role entity.OrganizationRole `json:"role" binding:"required"`
+}
+
+type expertScopeRequest struct {
+	// True limits the expert to the organization's members, false makes them public
+	OrgOnly *bool `json:"orgOnly" binding:"required"`
+}
+
+type brandingDto struct {
+	LogoUrl      *string `json:"logoUrl"`
+	PrimaryColor *string `json:"primaryColor"`
+}
+
+type settingsDto struct {
+	PublicExperts bool `json:"publicExperts"`
+}
+
+type organizationDto struct {
+	Id        string      `json:"id"`
+	Slug      string      `json:"slug"`
+	Name      string      `json:"name"`
+	Branding  brandingDto `json:"branding"`
+	Settings  settingsDto `json:"settings"`
+	CreatedAt time.Time   `json:"createdAt"`
+}
+
+func organizationDtoFrom(entity *entity.Organization) *organizationDto {
+	return &organizationDto{
+		Id:   entity.Uuid.String(),
+		Slug: entity.Slug,
+		Name: entity.Name,
+		Branding: brandingDto{
+			LogoUrl:      entity.LogoUrl,
+			PrimaryColor: entity.PrimaryColor,
+		},
+		Settings: settingsDto{
+			PublicExperts: entity.PublicExperts,
+		},
+		CreatedAt: entity.CreatedAt,
+	}
+}
+
+type myOrganizationDto struct {
+	organizationDto
+	Role entity.OrganizationRole `json:"role"`
+}
+
+type memberDto struct {
+	UserId   string                  `json:"userId"`
+	Name     string                  `json:"name"`
+	Email    string                  `json:"email"`
+	Role     entity.OrganizationRole `json:"role"`
+	JoinedAt time.Time               `json:"joinedAt"`
+}
+
+func memberDtoFrom(entity *entity.OrganizationMember) *memberDto {
+	return &memberDto{
+		UserId:   entity.UserUuid.String(),
+		Name:     entity.Name,
+		Email:    entity.Email,
+		Role:     entity.Role,
+		JoinedAt: entity.JoinedAt,
+	}
+}
+
+type consultationDto struct {
+	Id            string        `json:"id"`
+	ExpertId      string        `json:"expertId"`
+	MenteeId      string        `json:"menteeId"`
+	Status        entity.Status `json:"status"`
+	PriceAmount   int           `json:"priceAmount"`
+	PriceCurrency string        `json:"priceCurrency"`
+	SubmittedAt   time.Time     `json:"submittedAt"`
+	CancelledAt   *time.Time    `json:"cancelledAt"`
+}
+
+func consultationDtoFrom(entity *entity.Consultation) *consultationDto {
+	return &consultationDto{
+		Id:            entity.Uuid.String(),
+		ExpertId:      entity.ExpertUuid.String(),
+		MenteeId:      entity.MenteeUuid.String(),
+		Status:        entity.Status,
+		PriceAmount:   entity.PriceAmount,
+		PriceCurrency: entity.PriceCurrency,
+		SubmittedAt:   entity.SubmittedAt,
+		CancelledAt:   entity.CancelledAt,
+	}
+}
@@ -0,0 +1,100 @@
+package organizationroutes
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/bogdanshibilov/mindflowbackend/internal/pagination"
+	organizationrepo "github.com/bogdanshibilov/mindflowbackend/internal/repository/organization"
+)
+
+func (r *routes) Members(ctx *gin.Context) {
+	const op = "OrganizationRoutes.Members"
+
+	if !validIds(ctx, "id") {
+		return
+	}
+
+	page, err := pagination.FromQuery(ctx.Request.URL.Query(), organizationrepo.MemberSorts, "name")
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"message": err.Error()})
+		return
+	}
+
+	members, err := r.orgs.Members(ctx, ctx.GetString("uuid"), ctx.Param("id"), page)
+	if err != nil {
+		r.respondError(ctx, op, err, "failed to get members")
+		return
+	}
+
+	ctx.JSON(http.StatusOK, pagination.Map(members, memberDtoFrom))
+}
+
+// Adds the user to the organization or changes their role
+func (r *routes) SetMember(ctx *gin.Context) {
+	const op = "OrganizationRoutes.SetMember"
+
+	if !validIds(ctx, "id", "userid") {
+		return
+	}
+
+	var req *setMemberRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		r.log.Warn("invalid JSON received", op, err)
+		ctx.JSON(http.StatusBadRequest, gin.H{"message": "invalid JSON"})
+		return
+	}
+
+	member, err := r.orgs.SetMember(ctx, ctx.GetString("uuid"), ctx.Param("id"), ctx.Param("userid"), req.Role)
+	if err != nil {
+		r.respondError(ctx, op, err, "failed to set member")
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{
+		"userId":   member.UserUuid.String(),
+		"role":     member.Role,
+		"joinedAt": member.JoinedAt,
+	})
+}
+
+func (r *routes) RemoveMember(ctx *gin.Context) {
+	const op = "OrganizationRoutes.RemoveMember"
+
+	if !validIds(ctx, "id", "userid") {
+		return
+	}
+
+	err := r.orgs.RemoveMember(ctx, ctx.GetString("uuid"), ctx.Param("id"), ctx.Param("userid"))
+	if err != nil {
+		r.respondError(ctx, op, err, "failed to remove member")
+		return
+	}
+
+	ctx.Status(http.StatusNoContent)
+}
+
+// Limits a member's expert profile to the organization or makes it public
+func (r *routes) SetExpertScope(ctx *gin.Context) {
+	const op = "OrganizationRoutes.SetExpertScope"
+
+	if !validIds(ctx, "id", "expertid") {
+		return
+	}
+
+	var req *expertScopeRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		r.log.Warn("invalid JSON received", op, err)
+		ctx.JSON(http.StatusBadRequest, gin.H{"message": "invalid JSON"})
+		return
+	}
+
+	err := r.orgs.SetExpertScope(ctx, ctx.GetString("uuid"), ctx.Param("id"), ctx.Param("expertid"), *req.OrgOnly)
+	if err != nil {
+		r.respondError(ctx, op, err, "failed to set expert scope")
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{"orgOnly": *req.OrgOnly})
+}
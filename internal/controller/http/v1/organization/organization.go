@@ -0,0 +1,274 @@
+package organizationroutes
+
+import (
+	"errors"
+	"log/slog"
+	"net/http"
+	"os"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+
+	"github.com/bogdanshibilov/mindflowbackend/internal/controller/http/v1/middleware"
+	"github.com/bogdanshibilov/mindflowbackend/internal/entity"
+	"github.com/bogdanshibilov/mindflowbackend/internal/pagination"
+	consultationrepo "github.com/bogdanshibilov/mindflowbackend/internal/repository/consultation"
+	organizationrepo "github.com/bogdanshibilov/mindflowbackend/internal/repository/organization"
+	organizationservice "github.com/bogdanshibilov/mindflowbackend/internal/services/organization"
+	userservice "github.com/bogdanshibilov/mindflowbackend/internal/services/user"
+)
+
+type routes struct {
+	log  *slog.Logger
+	orgs *organizationservice.Service
+}
+
+// Members read their organization, its admins and platform staff manage it.
+// Only staff create and list organizations
+func New(
+	handler *gin.RouterGroup,
+	log *slog.Logger,
+	orgs *organizationservice.Service,
+	users *userservice.Service,
+) {
+	r := &routes{
+		log:  log,
+		orgs: orgs,
+	}
+
+	orgsHandler := handler.Group("/organizations")
+	{
+		orgsHandler.Use(middleware.RequireJwt(os.Getenv("JWTSECRET"), users))
+		orgsHandler.Use(middleware.ParseClaimsIntoContext())
+		orgsHandler.GET("/me", r.Mine)
+		orgsHandler.GET("/:id", r.ById)
+		orgsHandler.PUT("/:id/branding", r.UpdateBranding)
+		orgsHandler.PUT("/:id/settings", r.UpdateSettings)
+		orgsHandler.GET("/:id/members", r.Members)
+		orgsHandler.PUT("/:id/members/:userid", r.SetMember)
+		orgsHandler.DELETE("/:id/members/:userid", r.RemoveMember)
+		orgsHandler.PUT("/:id/experts/:expertid/scope", r.SetExpertScope)
+		orgsHandler.GET("/:id/consultations", r.Consultations)
+		orgsHandler.Use(middleware.RequireAdminPermission(users, log))
+		orgsHandler.POST("", r.Create)
+		orgsHandler.GET("", r.Organizations)
+	}
+}
+
+func (r *routes) Create(ctx *gin.Context) {
+	const op = "OrganizationRoutes.Create"
+
+	var req *createOrganizationRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		r.log.Warn("invalid JSON received", op, err)
+		ctx.JSON(http.StatusBadRequest, gin.H{"message": "invalid JSON"})
+		return
+	}
+
+	org := &entity.Organization{
+		Slug: req.Slug,
+		Name: req.Name,
+		OrganizationBranding: entity.OrganizationBranding{
+			LogoUrl:      req.Branding.LogoUrl,
+			PrimaryColor: req.Branding.PrimaryColor,
+		},
+		OrganizationSettings: entity.OrganizationSettings{
+			PublicExperts: req.PublicExperts == nil || *req.PublicExperts,
+		},
+	}
+
+	err := r.orgs.CreateOrganization(ctx, org)
+	if err != nil {
+		switch {
+		case isValidationError(err):
+			ctx.JSON(http.StatusBadRequest, gin.H{"message": errors.Unwrap(err).Error()})
+		case errors.Is(err, organizationrepo.ErrSlugTaken):
+			ctx.JSON(http.StatusConflict, gin.H{"message": "slug is already taken"})
+		default:
+			r.log.Error("failed to create organization", op, err)
+			ctx.JSON(http.StatusInternalServerError, gin.H{"message": "failed to create organization"})
+		}
+		return
+	}
+
+	ctx.JSON(http.StatusCreated, organizationDtoFrom(org))
+}
+
+func (r *routes) Organizations(ctx *gin.Context) {
+	const op = "OrganizationRoutes.Organizations"
+
+	page, err := pagination.FromQuery(ctx.Request.URL.Query(), organizationrepo.Sorts, "name")
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"message": err.Error()})
+		return
+	}
+
+	orgs, err := r.orgs.Organizations(ctx, page)
+	if err != nil {
+		r.log.Error("failed to get organizations", op, err)
+		ctx.JSON(http.StatusInternalServerError, gin.H{"message": "failed to get organizations"})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, pagination.Map(orgs, organizationDtoFrom))
+}
+
+// Returns the organization of the user with its branding and their role
+func (r *routes) Mine(ctx *gin.Context) {
+	const op = "OrganizationRoutes.Mine"
+
+	org, member, err := r.orgs.Mine(ctx, ctx.GetString("uuid"))
+	if err != nil {
+		if errors.Is(err, organizationservice.ErrNotMember) {
+			ctx.JSON(http.StatusNotFound, gin.H{"message": "user is not a member of any organization"})
+			return
+		}
+		r.log.Error("failed to get organization", op, err)
+		ctx.JSON(http.StatusInternalServerError, gin.H{"message": "failed to get organization"})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, &myOrganizationDto{
+		organizationDto: *organizationDtoFrom(org),
+		Role:            member.Role,
+	})
+}
+
+func (r *routes) ById(ctx *gin.Context) {
+	const op = "OrganizationRoutes.ById"
+
+	if !validIds(ctx, "id") {
+		return
+	}
+
+	org, err := r.orgs.ById(ctx, ctx.GetString("uuid"), ctx.Param("id"))
+	if err != nil {
+		if errors.Is(err, organizationservice.ErrNotMember) {
+			ctx.JSON(http.StatusForbidden, gin.H{"message": "forbidden"})
+			return
+		}
+		r.respondError(ctx, op, err, "failed to get organization")
+		return
+	}
+
+	ctx.JSON(http.StatusOK, organizationDtoFrom(org))
+}
+
+func (r *routes) UpdateBranding(ctx *gin.Context) {
+	const op = "OrganizationRoutes.UpdateBranding"
+
+	if !validIds(ctx, "id") {
+		return
+	}
+
+	var req *brandingRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		r.log.Warn("invalid JSON received", op, err)
+		ctx.JSON(http.StatusBadRequest, gin.H{"message": "invalid JSON"})
+		return
+	}
+
+	branding := &entity.OrganizationBranding{
+		LogoUrl:      req.LogoUrl,
+		PrimaryColor: req.PrimaryColor,
+	}
+	err := r.orgs.UpdateBranding(ctx, ctx.GetString("uuid"), ctx.Param("id"), branding)
+	if err != nil {
+		r.respondError(ctx, op, err, "failed to update branding")
+		return
+	}
+
+	ctx.JSON(http.StatusOK, brandingDto{
+		LogoUrl:      branding.LogoUrl,
+		PrimaryColor: branding.PrimaryColor,
+	})
+}
+
+func (r *routes) UpdateSettings(ctx *gin.Context) {
+	const op = "OrganizationRoutes.UpdateSettings"
+
+	if !validIds(ctx, "id") {
+		return
+	}
+
+	var req *settingsRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		r.log.Warn("invalid JSON received", op, err)
+		ctx.JSON(http.StatusBadRequest, gin.H{"message": "invalid JSON"})
+		return
+	}
+
+	settings := &entity.OrganizationSettings{
+		PublicExperts: *req.PublicExperts,
+	}
+	err := r.orgs.UpdateSettings(ctx, ctx.GetString("uuid"), ctx.Param("id"), settings)
+	if err != nil {
+		r.respondError(ctx, op, err, "failed to update settings")
+		return
+	}
+
+	ctx.JSON(http.StatusOK, settingsDto{PublicExperts: settings.PublicExperts})
+}
+
+// Lists the consultations with the organization's experts
+func (r *routes) Consultations(ctx *gin.Context) {
+	const op = "OrganizationRoutes.Consultations"
+
+	if !validIds(ctx, "id") {
+		return
+	}
+
+	page, err := pagination.FromQuery(ctx.Request.URL.Query(), consultationrepo.Sorts, "submitted")
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"message": err.Error()})
+		return
+	}
+
+	consultations, err := r.orgs.Consultations(ctx, ctx.GetString("uuid"), ctx.Param("id"), page)
+	if err != nil {
+		r.respondError(ctx, op, err, "failed to get consultations")
+		return
+	}
+
+	ctx.JSON(http.StatusOK, pagination.Map(consultations, consultationDtoFrom))
+}
+
+// Responds to the errors every organization route shares, message is sent with a 500
+func (r *routes) respondError(ctx *gin.Context, op string, err error, message string) {
+	switch {
+	case isValidationError(err):
+		ctx.JSON(http.StatusBadRequest, gin.H{"message": errors.Unwrap(err).Error()})
+	case errors.Is(err, organizationservice.ErrNotAdmin):
+		ctx.JSON(http.StatusForbidden, gin.H{"message": "forbidden"})
+	case errors.Is(err, organizationservice.ErrNotMember):
+		ctx.JSON(http.StatusNotFound, gin.H{"message": "user is not a member of the organization"})
+	case errors.Is(err, organizationrepo.ErrOrganizationNotFound):
+		ctx.JSON(http.StatusNotFound, gin.H{"message": "organization not found"})
+	case errors.Is(err, organizationrepo.ErrUserNotFound):
+		ctx.JSON(http.StatusNotFound, gin.H{"message": "user not found"})
+	case errors.Is(err, organizationrepo.ErrMemberElsewhere):
+		ctx.JSON(http.StatusConflict, gin.H{"message": "user is a member of another organization"})
+	default:
+		r.log.Error(message, op, err)
+		ctx.JSON(http.StatusInternalServerError, gin.H{"message": message})
+	}
+}
+
+func isValidationError(err error) bool {
+	return errors.Is(err, organizationservice.ErrInvalidSlug) ||
+		errors.Is(err, organizationservice.ErrInvalidName) ||
+		errors.Is(err, organizationservice.ErrInvalidColor) ||
+		errors.Is(err, organizationservice.ErrInvalidLogo) ||
+		errors.Is(err, organizationservice.ErrInvalidRole)
+}
+
+// Responds with 400 unless the path params are uuids
+func validIds(ctx *gin.Context, params ...string) bool {
+	for _, param := range params {
+		if _, err := uuid.Parse(ctx.Param(param)); err != nil {
+			ctx.JSON(http.StatusBadRequest, gin.H{"message": "bad id"})
+			return false
+		}
+	}
+	return true
+}
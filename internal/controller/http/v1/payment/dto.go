@@ -0,0 +1,104 @@
+package paymentroutes
+
+import (
+	"time"
+
+	"github.com/bogdanshibilov/mindflowbackend/internal/entity"
+)
+
+type payRequest struct {
+	PaymentMethod string `json:"paymentMethod"`
+}
+
+type applyPromoRequest struct {
+	Code string `json:"code" binding:"required"`
+}
+
+type manualRefundRequest struct {
+	Amount int    `json:"amount" binding:"required,gt=0"`
+	Reason string `json:"reason" binding:"required"`
+}
+
+type refundDTO struct {
+	Id        string    `json:"id"`
+	PaymentId string    `json:"paymentId"`
+	Amount    int       `json:"amount"`
+	Currency  string    `json:"currency"`
+	Status    string    `json:"status"`
+	Reason    string    `json:"reason"`
+	CreatedAt time.Time `json:"createdAt"`
+}
+
+func refundDtoFrom(entity *entity.Refund) *refundDTO {
+	return &refundDTO{
+		Id:        entity.Uuid.String(),
+		PaymentId: entity.PaymentIntentUuid.String(),
+		Amount:    entity.Amount,
+		Currency:  entity.Currency,
+		Status:    string(entity.Status),
+		Reason:    entity.Reason,
+		CreatedAt: entity.CreatedAt,
+	}
+}
+
+type paymentIntentDTO struct {
+	Id                string    `json:"id"`
+	ConsultationId    string    `json:"consultationId,omitempty"`
+	PackagePurchaseId string    `json:"packagePurchaseId,omitempty"`
+	OriginalAmount    int       `json:"originalAmount"`
+	Discount          int       `json:"discount"`
+	Amount            int       `json:"amount"`
+	Currency          string    `json:"currency"`
+	Status            string    `json:"status"`
+	Provider          string    `json:"provider"`
+	CreatedAt         time.Time `json:"createdAt"`
+	UpdatedAt         time.Time `json:"updatedAt"`
+}
+
+func paymentIntentDtoFrom(entity *entity.PaymentIntent) *paymentIntentDTO {
+	dto := &paymentIntentDTO{
+		Id:             entity.Uuid.String(),
+		OriginalAmount: entity.OriginalAmount,
+		Discount:       entity.OriginalAmount - entity.Amount,
+		Amount:         entity.Amount,
+		Currency:       entity.Currency,
+		Status:         string(entity.Status),
+		Provider:       entity.Provider,
+		CreatedAt:      entity.CreatedAt,
+		UpdatedAt:      entity.UpdatedAt,
+	}
+	if entity.ConsultationUuid != nil {
+		dto.ConsultationId = entity.ConsultationUuid.String()
+	}
+	if entity.PackagePurchaseUuid != nil {
+		dto.PackagePurchaseId = entity.PackagePurchaseUuid.String()
+	}
+	return dto
+}
+
+type ledgerEntryDTO struct {
+	Id            string    `json:"id"`
+	TransactionId string    `json:"transactionId"`
+	Account       string    `json:"account"`
+	OwnerId       string    `json:"ownerId,omitempty"`
+	Direction     string    `json:"direction"`
+	Amount        int       `json:"amount"`
+	Currency      string    `json:"currency"`
+	CreatedAt     time.Time `json:"createdAt"`
+}
+
+func ledgerEntryDtoFrom(entity *entity.LedgerEntry) *ledgerEntryDTO {
+	dto := &ledgerEntryDTO{
+		Id:            entity.Uuid.String(),
+		TransactionId: entity.TransactionUuid.String(),
+		Account:       entity.Account,
+		Direction:     string(entity.Direction),
+		Amount:        entity.Amount,
+		Currency:      entity.Currency,
+		CreatedAt:     entity.CreatedAt,
+	}
+	if entity.OwnerUuid != nil {
+		dto.OwnerId = entity.OwnerUuid.String()
+	}
+	return dto
+}
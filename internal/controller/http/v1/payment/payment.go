@@ -0,0 +1,240 @@
+package paymentroutes
+
+import (
+	"errors"
+	"io"
+	"log/slog"
+	"net/http"
+	"os"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/bogdanshibilov/mindflowbackend/internal/controller/http/v1/middleware"
+	"github.com/bogdanshibilov/mindflowbackend/internal/paymentprovider"
+	paymentrepo "github.com/bogdanshibilov/mindflowbackend/internal/repository/payment"
+	promorepo "github.com/bogdanshibilov/mindflowbackend/internal/repository/promo"
+	organizationservice "github.com/bogdanshibilov/mindflowbackend/internal/services/organization"
+	paymentservice "github.com/bogdanshibilov/mindflowbackend/internal/services/payment"
+	userservice "github.com/bogdanshibilov/mindflowbackend/internal/services/user"
+)
+
+const signatureHeader = "X-Webhook-Signature"
+
+type routes struct {
+	log      *slog.Logger
+	payments *paymentservice.Service
+}
+
+func New(
+	handler *gin.RouterGroup,
+	log *slog.Logger,
+	payments *paymentservice.Service,
+	users *userservice.Service,
+	orgs *organizationservice.Service,
+) {
+	r := &routes{
+		log:      log,
+		payments: payments,
+	}
+
+	paymentsHandler := handler.Group("/payments")
+	{
+		paymentsHandler.POST("/webhook", r.Webhook)
+		paymentsHandler.Use(middleware.RequireJwt(os.Getenv("JWTSECRET"), users))
+		paymentsHandler.Use(middleware.ParseClaimsIntoContext())
+		paymentsHandler.Use(middleware.ResolveScope(orgs, log))
+		paymentsHandler.GET("/consultation/:id", r.ByConsultationId)
+		paymentsHandler.POST("/consultation/:id/pay", r.Pay)
+		paymentsHandler.POST("/:id/pay", r.PayIntent)
+		paymentsHandler.POST("/:id/promo", r.ApplyPromo)
+		paymentsHandler.Use(middleware.RequireAdminPermission(users, log))
+		paymentsHandler.GET("/:id/ledger", r.Ledger)
+		paymentsHandler.POST("/:id/refund", r.ManualRefund)
+	}
+}
+
+func (r *routes) Webhook(ctx *gin.Context) {
+	const op = "PaymentRoutes.Webhook"
+
+	payload, err := io.ReadAll(ctx.Request.Body)
+	if err != nil {
+		r.log.Warn("failed to read webhook body", op, err)
+		ctx.Status(http.StatusBadRequest)
+		return
+	}
+
+	err = r.payments.HandleWebhook(ctx, payload, ctx.GetHeader(signatureHeader))
+	if err != nil {
+		if errors.Is(err, paymentprovider.ErrInvalidSignature) ||
+			errors.Is(err, paymentprovider.ErrInvalidPayload) {
+			r.log.Warn("invalid webhook received", op, err)
+			ctx.Status(http.StatusBadRequest)
+			return
+		}
+		r.log.Error("failed to handle webhook", op, err)
+		ctx.Status(http.StatusInternalServerError)
+		return
+	}
+
+	ctx.Status(http.StatusOK)
+}
+
+func (r *routes) ByConsultationId(ctx *gin.Context) {
+	const op = "PaymentRoutes.ByConsultationId"
+
+	intent, err := r.payments.IntentByConsultationId(ctx, middleware.ScopeOf(ctx), ctx.Param("id"), ctx.GetString("uuid"))
+	if err != nil {
+		switch {
+		case errors.Is(err, paymentservice.ErrNotPayer):
+			ctx.JSON(http.StatusForbidden, gin.H{"message": "forbidden"})
+		case errors.Is(err, paymentrepo.ErrIntentNotFound):
+			ctx.JSON(http.StatusNotFound, gin.H{"message": "payment not found"})
+		default:
+			r.log.Error("failed to get payment", op, err)
+			ctx.JSON(http.StatusBadRequest, gin.H{"message": "bad request"})
+		}
+		return
+	}
+
+	ctx.JSON(http.StatusOK, paymentIntentDtoFrom(intent))
+}
+
+func (r *routes) Pay(ctx *gin.Context) {
+	const op = "PaymentRoutes.Pay"
+
+	var req *payRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		r.log.Warn("invalid JSON received", op, err)
+		ctx.JSON(http.StatusBadRequest, gin.H{"message": "invalid JSON"})
+		return
+	}
+
+	intent, err := r.payments.Pay(ctx, ctx.Param("id"), ctx.GetString("uuid"), req.PaymentMethod)
+	if err != nil {
+		r.payFailed(ctx, op, err)
+		return
+	}
+
+	ctx.JSON(http.StatusOK, paymentIntentDtoFrom(intent))
+}
+
+// Pays an intent by its id, used for package purchases
+func (r *routes) PayIntent(ctx *gin.Context) {
+	const op = "PaymentRoutes.PayIntent"
+
+	var req *payRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		r.log.Warn("invalid JSON received", op, err)
+		ctx.JSON(http.StatusBadRequest, gin.H{"message": "invalid JSON"})
+		return
+	}
+
+	intent, err := r.payments.PayIntent(ctx, ctx.Param("id"), ctx.GetString("uuid"), req.PaymentMethod)
+	if err != nil {
+		r.payFailed(ctx, op, err)
+		return
+	}
+
+	ctx.JSON(http.StatusOK, paymentIntentDtoFrom(intent))
+}
+
+func (r *routes) ApplyPromo(ctx *gin.Context) {
+	const op = "PaymentRoutes.ApplyPromo"
+
+	var req *applyPromoRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		r.log.Warn("invalid JSON received", op, err)
+		ctx.JSON(http.StatusBadRequest, gin.H{"message": "invalid JSON"})
+		return
+	}
+
+	intent, err := r.payments.ApplyPromo(ctx, ctx.Param("id"), ctx.GetString("uuid"), req.Code)
+	if err != nil {
+		switch {
+		case errors.Is(err, paymentservice.ErrNotPayer):
+			ctx.JSON(http.StatusForbidden, gin.H{"message": "forbidden"})
+		case errors.Is(err, paymentrepo.ErrIntentNotFound):
+			ctx.JSON(http.StatusNotFound, gin.H{"message": "payment not found"})
+		case errors.Is(err, promorepo.ErrPromoNotFound):
+			ctx.JSON(http.StatusNotFound, gin.H{"message": "promo code not found"})
+		case errors.Is(err, paymentservice.ErrPromoNotApplicable),
+			errors.Is(err, paymentrepo.ErrPromoUnavailable):
+			ctx.JSON(http.StatusUnprocessableEntity, gin.H{"message": "promo code cannot be used for this payment"})
+		case errors.Is(err, paymentrepo.ErrPromoRedeemed):
+			ctx.JSON(http.StatusConflict, gin.H{"message": "promo code already used"})
+		case errors.Is(err, paymentrepo.ErrPromoAlreadyApplied):
+			ctx.JSON(http.StatusConflict, gin.H{"message": "payment already has a promo code or is not awaiting payment"})
+		default:
+			r.log.Error("failed to apply promo code", op, err)
+			ctx.JSON(http.StatusInternalServerError, gin.H{"message": "failed to apply promo code"})
+		}
+		return
+	}
+
+	ctx.JSON(http.StatusOK, paymentIntentDtoFrom(intent))
+}
+
+func (r *routes) payFailed(ctx *gin.Context, op string, err error) {
+	switch {
+	case errors.Is(err, paymentservice.ErrNotPayer):
+		ctx.JSON(http.StatusForbidden, gin.H{"message": "forbidden"})
+	case errors.Is(err, paymentrepo.ErrIntentNotFound):
+		ctx.JSON(http.StatusNotFound, gin.H{"message": "payment not found"})
+	case errors.Is(err, paymentservice.ErrAlreadyPaid):
+		ctx.JSON(http.StatusConflict, gin.H{"message": "already paid"})
+	case errors.Is(err, paymentservice.ErrPaymentInProgress):
+		ctx.JSON(http.StatusConflict, gin.H{"message": "payment is being processed"})
+	case errors.Is(err, paymentprovider.ErrUnknownPaymentMethod):
+		ctx.JSON(http.StatusBadRequest, gin.H{"message": "unknown payment method"})
+	default:
+		r.log.Error("failed to pay", op, err)
+		ctx.JSON(http.StatusInternalServerError, gin.H{"message": "failed to pay"})
+	}
+}
+
+func (r *routes) Ledger(ctx *gin.Context) {
+	const op = "PaymentRoutes.Ledger"
+
+	entries, err := r.payments.LedgerByIntentId(ctx, ctx.Param("id"))
+	if err != nil {
+		r.log.Error("failed to get ledger", op, err)
+		ctx.JSON(http.StatusBadRequest, gin.H{"message": "bad request"})
+		return
+	}
+
+	DTOs := make([]ledgerEntryDTO, 0)
+	for _, entity := range entries {
+		DTOs = append(DTOs, *ledgerEntryDtoFrom(&entity))
+	}
+
+	ctx.JSON(http.StatusOK, DTOs)
+}
+
+func (r *routes) ManualRefund(ctx *gin.Context) {
+	const op = "PaymentRoutes.ManualRefund"
+
+	var req *manualRefundRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		r.log.Warn("invalid JSON received", op, err)
+		ctx.JSON(http.StatusBadRequest, gin.H{"message": "invalid JSON"})
+		return
+	}
+
+	refund, err := r.payments.ManualRefund(ctx, ctx.Param("id"), ctx.GetString("uuid"), req.Amount, req.Reason)
+	if err != nil {
+		switch {
+		case errors.Is(err, paymentrepo.ErrIntentNotFound):
+			ctx.JSON(http.StatusNotFound, gin.H{"message": "payment not found"})
+		case errors.Is(err, paymentservice.ErrNotRefundable),
+			errors.Is(err, paymentservice.ErrInvalidRefundAmount),
+			errors.Is(err, paymentprovider.ErrRefundExceedsPayment):
+			ctx.JSON(http.StatusConflict, gin.H{"message": "payment cannot be refunded by this amount"})
+		default:
+			r.log.Error("failed to refund payment", op, err)
+			ctx.JSON(http.StatusInternalServerError, gin.H{"message": "failed to refund payment"})
+		}
+		return
+	}
+
+	ctx.JSON(http.StatusCreated, refundDtoFrom(refund))
+}
@@ -0,0 +1,134 @@
+package payoutroutes
+
+import (
+	"time"
+
+	"github.com/bogdanshibilov/mindflowbackend/internal/entity"
+	payoutservice "github.com/bogdanshibilov/mindflowbackend/internal/services/payout"
+)
+
+type createBatchRequest struct {
+	PeriodEnd time.Time `json:"periodEnd" binding:"required"`
+}
+
+type markPayoutRequest struct {
+	Status    string `json:"status" binding:"required"`
+	Reference string `json:"reference"`
+}
+
+type earningLineDTO struct {
+	ConsultationId    string    `json:"consultationId,omitempty"`
+	PackagePurchaseId string    `json:"packagePurchaseId,omitempty"`
+	CompletedAt       time.Time `json:"completedAt"`
+	Currency          string    `json:"currency"`
+	Amount            int       `json:"amount"`
+	Refunded          int       `json:"refunded"`
+	Gross             int       `json:"gross"`
+	Commission        int       `json:"commission"`
+	Net               int       `json:"net"`
+	PayoutId          string    `json:"payoutId,omitempty"`
+	PayoutStatus      string    `json:"payoutStatus,omitempty"`
+}
+
+type currencyTotalDTO struct {
+	Currency   string `json:"currency"`
+	Gross      int    `json:"gross"`
+	Commission int    `json:"commission"`
+	Net        int    `json:"net"`
+	PaidOut    int    `json:"paidOut"`
+}
+
+type earningsDTO struct {
+	Totals []currencyTotalDTO `json:"totals"`
+	Items  []earningLineDTO   `json:"items"`
+}
+
+func earningsDtoFrom(earnings *payoutservice.Earnings) *earningsDTO {
+	dto := &earningsDTO{
+		Totals: make([]currencyTotalDTO, 0),
+		Items:  make([]earningLineDTO, 0),
+	}
+	for _, total := range earnings.Totals {
+		dto.Totals = append(dto.Totals, currencyTotalDTO{
+			Currency:   total.Currency,
+			Gross:      total.Gross,
+			Commission: total.Commission,
+			Net:        total.Net,
+			PaidOut:    total.PaidOut,
+		})
+	}
+	for _, line := range earnings.Lines {
+		item := earningLineDTO{
+			CompletedAt: line.CompletedAt,
+			Currency:    line.Currency,
+			Amount:      line.Amount,
+			Refunded:    line.Refunded,
+			Gross:       line.Gross,
+			Commission:  line.Commission,
+			Net:         line.Net,
+		}
+		if line.ConsultationUuid != nil {
+			item.ConsultationId = line.ConsultationUuid.String()
+		}
+		if line.PackagePurchaseUuid != nil {
+			item.PackagePurchaseId = line.PackagePurchaseUuid.String()
+		}
+		if line.PayoutUuid != nil {
+			item.PayoutId = line.PayoutUuid.String()
+		}
+		if line.PayoutStatus != nil {
+			item.PayoutStatus = string(*line.PayoutStatus)
+		}
+		dto.Items = append(dto.Items, item)
+	}
+	return dto
+}
+
+type payoutDTO struct {
+	Id         string     `json:"id"`
+	BatchId    string     `json:"batchId"`
+	ExpertId   string     `json:"expertId"`
+	Currency   string     `json:"currency"`
+	Gross      int        `json:"gross"`
+	Commission int        `json:"commission"`
+	Net        int        `json:"net"`
+	Status     string     `json:"status"`
+	Reference  string     `json:"reference"`
+	SentAt     *time.Time `json:"sentAt"`
+}
+
+func payoutDtoFrom(entity *entity.Payout) *payoutDTO {
+	return &payoutDTO{
+		Id:         entity.Uuid.String(),
+		BatchId:    entity.BatchUuid.String(),
+		ExpertId:   entity.ExpertUuid.String(),
+		Currency:   entity.Currency,
+		Gross:      entity.Gross,
+		Commission: entity.Commission,
+		Net:        entity.Net,
+		Status:     string(entity.Status),
+		Reference:  entity.Reference,
+		SentAt:     entity.SentAt,
+	}
+}
+
+type payoutBatchDTO struct {
+	Id        string      `json:"id"`
+	PeriodEnd time.Time   `json:"periodEnd"`
+	Status    string      `json:"status"`
+	CreatedAt time.Time   `json:"createdAt"`
+	Payouts   []payoutDTO `json:"payouts,omitempty"`
+}
+
+func payoutBatchDtoFrom(entity *entity.PayoutBatch, payouts []entity.Payout) *payoutBatchDTO {
+	dto := &payoutBatchDTO{
+		Id:        entity.Uuid.String(),
+		PeriodEnd: entity.PeriodEnd,
+		Status:    string(entity.Status),
+		CreatedAt: entity.CreatedAt,
+	}
+	for _, payout := range payouts {
+		dto.Payouts = append(dto.Payouts, *payoutDtoFrom(&payout))
+	}
+	return dto
+}
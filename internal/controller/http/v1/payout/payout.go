@@ -0,0 +1,205 @@
+package payoutroutes
+
+import (
+	"errors"
+	"log/slog"
+	"net/http"
+	"os"
+	"time"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/bogdanshibilov/mindflowbackend/internal/controller/http/v1/middleware"
+	"github.com/bogdanshibilov/mindflowbackend/internal/entity"
+	paymentrepo "github.com/bogdanshibilov/mindflowbackend/internal/repository/payment"
+	payoutservice "github.com/bogdanshibilov/mindflowbackend/internal/services/payout"
+	userservice "github.com/bogdanshibilov/mindflowbackend/internal/services/user"
+)
+
+const dateLayout = "2006-01-02"
+
+type routes struct {
+	log     *slog.Logger
+	payouts *payoutservice.Service
+}
+
+func New(
+	handler *gin.RouterGroup,
+	log *slog.Logger,
+	payouts *payoutservice.Service,
+	users *userservice.Service,
+) {
+	r := &routes{
+		log:     log,
+		payouts: payouts,
+	}
+
+	payoutsHandler := handler.Group("/payouts")
+	{
+		payoutsHandler.Use(middleware.RequireJwt(os.Getenv("JWTSECRET"), users))
+		payoutsHandler.Use(middleware.ParseClaimsIntoContext())
+		payoutsHandler.GET("/earnings", r.Earnings)
+		payoutsHandler.GET("/statement", r.Statement)
+		payoutsHandler.GET("/me", r.MyPayouts)
+		payoutsHandler.Use(middleware.RequireAdminPermission(users, log))
+		payoutsHandler.POST("/batches", r.CreateBatch)
+		payoutsHandler.GET("/batches", r.Batches)
+		payoutsHandler.GET("/batches/:id", r.BatchById)
+		payoutsHandler.PUT("/:id/status", r.MarkPayout)
+	}
+}
+
+// Optional from and to query params are dates, to is exclusive
+func (r *routes) Earnings(ctx *gin.Context) {
+	const op = "PayoutRoutes.Earnings"
+
+	var from, to time.Time
+	if ctx.Query("from") != "" || ctx.Query("to") != "" {
+		var errFrom, errTo error
+		from, errFrom = time.Parse(dateLayout, ctx.Query("from"))
+		to, errTo = time.Parse(dateLayout, ctx.Query("to"))
+		if errFrom != nil || errTo != nil {
+			ctx.JSON(http.StatusBadRequest, gin.H{"message": "from and to must be dates in YYYY-MM-DD format"})
+			return
+		}
+	}
+
+	earnings, err := r.payouts.Earnings(ctx, ctx.GetString("uuid"), from, to)
+	if err != nil {
+		r.log.Error("failed to get earnings", op, err)
+		ctx.JSON(http.StatusInternalServerError, gin.H{"message": "failed to get earnings"})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, earningsDtoFrom(earnings))
+}
+
+func (r *routes) Statement(ctx *gin.Context) {
+	const op = "PayoutRoutes.Statement"
+
+	month := ctx.Query("month")
+
+	statement, err := r.payouts.MonthlyStatementCSV(ctx, ctx.GetString("uuid"), month)
+	if err != nil {
+		if errors.Is(err, payoutservice.ErrInvalidMonth) {
+			ctx.JSON(http.StatusBadRequest, gin.H{"message": "month must be in YYYY-MM format"})
+			return
+		}
+		r.log.Error("failed to build statement", op, err)
+		ctx.JSON(http.StatusInternalServerError, gin.H{"message": "failed to build statement"})
+		return
+	}
+
+	ctx.Header("Content-Disposition", "attachment; filename=\"statement-"+month+".csv\"")
+	ctx.Data(http.StatusOK, "text/csv; charset=utf-8", statement)
+}
+
+func (r *routes) MyPayouts(ctx *gin.Context) {
+	const op = "PayoutRoutes.MyPayouts"
+
+	payouts, err := r.payouts.PayoutsByExpertId(ctx, ctx.GetString("uuid"))
+	if err != nil {
+		r.log.Error("failed to get payouts", op, err)
+		ctx.JSON(http.StatusInternalServerError, gin.H{"message": "failed to get payouts"})
+		return
+	}
+
+	DTOs := make([]payoutDTO, 0)
+	for _, entity := range payouts {
+		DTOs = append(DTOs, *payoutDtoFrom(&entity))
+	}
+
+	ctx.JSON(http.StatusOK, DTOs)
+}
+
+func (r *routes) CreateBatch(ctx *gin.Context) {
+	const op = "PayoutRoutes.CreateBatch"
+
+	var req *createBatchRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		r.log.Warn("invalid JSON received", op, err)
+		ctx.JSON(http.StatusBadRequest, gin.H{"message": "invalid JSON"})
+		return
+	}
+
+	batch, payouts, err := r.payouts.CreateBatch(ctx, ctx.GetString("uuid"), req.PeriodEnd)
+	if err != nil {
+		if errors.Is(err, payoutservice.ErrNothingToPay) {
+			ctx.JSON(http.StatusConflict, gin.H{"message": "nothing to pay out"})
+			return
+		}
+		if errors.Is(err, paymentrepo.ErrAlreadyPaidOut) {
+			ctx.JSON(http.StatusConflict, gin.H{"message": "another batch paid out some of the earnings, try again"})
+			return
+		}
+		r.log.Error("failed to create payout batch", op, err)
+		ctx.JSON(http.StatusInternalServerError, gin.H{"message": "failed to create payout batch"})
+		return
+	}
+
+	ctx.JSON(http.StatusCreated, payoutBatchDtoFrom(batch, payouts))
+}
+
+func (r *routes) Batches(ctx *gin.Context) {
+	const op = "PayoutRoutes.Batches"
+
+	batches, err := r.payouts.Batches(ctx)
+	if err != nil {
+		r.log.Error("failed to get payout batches", op, err)
+		ctx.JSON(http.StatusInternalServerError, gin.H{"message": "failed to get payout batches"})
+		return
+	}
+
+	DTOs := make([]payoutBatchDTO, 0)
+	for _, entity := range batches {
+		DTOs = append(DTOs, *payoutBatchDtoFrom(&entity, nil))
+	}
+
+	ctx.JSON(http.StatusOK, DTOs)
+}
+
+func (r *routes) BatchById(ctx *gin.Context) {
+	const op = "PayoutRoutes.BatchById"
+
+	batch, payouts, err := r.payouts.BatchById(ctx, ctx.Param("id"))
+	if err != nil {
+		if errors.Is(err, paymentrepo.ErrPayoutBatchNotFound) {
+			ctx.JSON(http.StatusNotFound, gin.H{"message": "payout batch not found"})
+			return
+		}
+		r.log.Error("failed to get payout batch", op, err)
+		ctx.JSON(http.StatusBadRequest, gin.H{"message": "bad request"})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, payoutBatchDtoFrom(batch, payouts))
+}
+
+func (r *routes) MarkPayout(ctx *gin.Context) {
+	const op = "PayoutRoutes.MarkPayout"
+
+	var req *markPayoutRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		r.log.Warn("invalid JSON received", op, err)
+		ctx.JSON(http.StatusBadRequest, gin.H{"message": "invalid JSON"})
+		return
+	}
+
+	payout, err := r.payouts.MarkPayout(ctx, ctx.Param("id"), entity.PayoutStatus(req.Status), req.Reference)
+	if err != nil {
+		switch {
+		case errors.Is(err, payoutservice.ErrInvalidPayoutStatus):
+			ctx.JSON(http.StatusBadRequest, gin.H{"message": "status must be sent or failed"})
+		case errors.Is(err, paymentrepo.ErrPayoutNotFound):
+			ctx.JSON(http.StatusNotFound, gin.H{"message": "payout not found"})
+		case errors.Is(err, paymentrepo.ErrPayoutNotPending):
+			ctx.JSON(http.StatusConflict, gin.H{"message": "payout is not pending"})
+		default:
+			r.log.Error("failed to update payout", op, err)
+			ctx.JSON(http.StatusInternalServerError, gin.H{"message": "failed to update payout"})
+		}
+		return
+	}
+
+	ctx.JSON(http.StatusOK, payoutDtoFrom(payout))
+}
@@ -0,0 +1,50 @@
+package promoroutes
+
+import (
+	"time"
+
+	"github.com/bogdanshibilov/mindflowbackend/internal/entity"
+)
+
+type createPromoRequest struct {
+	Code      string     `json:"code" binding:"required"`
+	Kind      string     `json:"kind" binding:"required"`
+	Value     int        `json:"value" binding:"required"`
+	Currency  *string    `json:"currency"`
+	MaxUses   *int       `json:"maxUses"`
+	ExpiresAt *time.Time `json:"expiresAt"`
+	ExpertId  *string    `json:"expertId"`
+}
+
+type promoDTO struct {
+	Id        string     `json:"id"`
+	Code      string     `json:"code"`
+	Kind      string     `json:"kind"`
+	Value     int        `json:"value"`
+	Currency  *string    `json:"currency"`
+	MaxUses   *int       `json:"maxUses"`
+	UsedCount int        `json:"usedCount"`
+	ExpiresAt *time.Time `json:"expiresAt"`
+	ExpertId  string     `json:"expertId,omitempty"`
+	Active    bool       `json:"active"`
+	CreatedAt time.Time  `json:"createdAt"`
+}
+
+func promoDtoFrom(entity *entity.PromoCode) *promoDTO {
+	dto := &promoDTO{
+		Id:        entity.Uuid.String(),
+		Code:      entity.Code,
+		Kind:      string(entity.Kind),
+		Value:     entity.Value,
+		Currency:  entity.Currency,
+		MaxUses:   entity.MaxUses,
+		UsedCount: entity.UsedCount,
+		ExpiresAt: entity.ExpiresAt,
+		Active:    entity.Active,
+		CreatedAt: entity.CreatedAt,
+	}
+	if entity.ExpertUuid != nil {
+		dto.ExpertId = entity.ExpertUuid.String()
+	}
+	return dto
+}
@@ -0,0 +1,130 @@
+package promoroutes
+
+import (
+	"errors"
+	"log/slog"
+	"net/http"
+	"os"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+
+	"github.com/bogdanshibilov/mindflowbackend/internal/controller/http/v1/middleware"
+	"github.com/bogdanshibilov/mindflowbackend/internal/entity"
+	promorepo "github.com/bogdanshibilov/mindflowbackend/internal/repository/promo"
+	currencyservice "github.com/bogdanshibilov/mindflowbackend/internal/services/currency"
+	promoservice "github.com/bogdanshibilov/mindflowbackend/internal/services/promo"
+	userservice "github.com/bogdanshibilov/mindflowbackend/internal/services/user"
+)
+
+type routes struct {
+	log    *slog.Logger
+	promos *promoservice.Service
+}
+
+func New(
+	handler *gin.RouterGroup,
+	log *slog.Logger,
+	promos *promoservice.Service,
+	users *userservice.Service,
+) {
+	r := &routes{
+		log:    log,
+		promos: promos,
+	}
+
+	promosHandler := handler.Group("/promocodes")
+	{
+		promosHandler.Use(middleware.RequireJwt(os.Getenv("JWTSECRET"), users))
+		promosHandler.Use(middleware.ParseClaimsIntoContext())
+		promosHandler.Use(middleware.RequireAdminPermission(users, log))
+		promosHandler.POST("", r.Create)
+		promosHandler.GET("", r.Promos)
+		promosHandler.DELETE("/:id", r.Deactivate)
+	}
+}
+
+func (r *routes) Create(ctx *gin.Context) {
+	const op = "PromoRoutes.Create"
+
+	var req *createPromoRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		r.log.Warn("invalid JSON received", op, err)
+		ctx.JSON(http.StatusBadRequest, gin.H{"message": "invalid JSON"})
+		return
+	}
+
+	promo := &entity.PromoCode{
+		Code:      req.Code,
+		Kind:      entity.PromoKind(req.Kind),
+		Value:     req.Value,
+		MaxUses:   req.MaxUses,
+		ExpiresAt: req.ExpiresAt,
+	}
+	if req.Currency != nil {
+		currency := strings.ToUpper(*req.Currency)
+		promo.Currency = &currency
+	}
+	if req.ExpertId != nil {
+		expertUuid, err := uuid.Parse(*req.ExpertId)
+		if err != nil {
+			ctx.JSON(http.StatusBadRequest, gin.H{"message": "invalid expert id"})
+			return
+		}
+		promo.ExpertUuid = &expertUuid
+	}
+
+	err := r.promos.Create(ctx, ctx.GetString("uuid"), promo)
+	if err != nil {
+		switch {
+		case errors.Is(err, promoservice.ErrInvalidPromo),
+			errors.Is(err, currencyservice.ErrInvalidCurrency),
+			errors.Is(err, currencyservice.ErrUnsupportedCurrency):
+			ctx.JSON(http.StatusBadRequest, gin.H{"message": "invalid promo code"})
+		case errors.Is(err, promorepo.ErrPromoCodeTaken):
+			ctx.JSON(http.StatusConflict, gin.H{"message": "promo code already exists"})
+		default:
+			r.log.Error("failed to create promo code", op, err)
+			ctx.JSON(http.StatusInternalServerError, gin.H{"message": "failed to create promo code"})
+		}
+		return
+	}
+
+	ctx.JSON(http.StatusCreated, promoDtoFrom(promo))
+}
+
+func (r *routes) Promos(ctx *gin.Context) {
+	const op = "PromoRoutes.Promos"
+
+	promos, err := r.promos.Promos(ctx)
+	if err != nil {
+		r.log.Error("failed to get promo codes", op, err)
+		ctx.JSON(http.StatusInternalServerError, gin.H{"message": "failed to get promo codes"})
+		return
+	}
+
+	DTOs := make([]promoDTO, 0)
+	for _, entity := range promos {
+		DTOs = append(DTOs, *promoDtoFrom(&entity))
+	}
+
+	ctx.JSON(http.StatusOK, DTOs)
+}
+
+func (r *routes) Deactivate(ctx *gin.Context) {
+	const op = "PromoRoutes.Deactivate"
+
+	err := r.promos.Deactivate(ctx, ctx.Param("id"))
+	if err != nil {
+		if errors.Is(err, promorepo.ErrPromoNotFound) {
+			ctx.JSON(http.StatusNotFound, gin.H{"message": "promo code not found"})
+			return
+		}
+		r.log.Error("failed to deactivate promo code", op, err)
+		ctx.JSON(http.StatusBadRequest, gin.H{"message": "bad request"})
+		return
+	}
+
+	ctx.Status(http.StatusOK)
+}
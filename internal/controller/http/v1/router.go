@@ -9,12 +9,31 @@ import (
 	"github.com/gin-gonic/gin"
 
 	authroutes "github.com/bogdanshibilov/mindflowbackend/internal/controller/http/v1/auth"
+	avatarroutes "github.com/bogdanshibilov/mindflowbackend/internal/controller/http/v1/avatar"
 	consultationroute "github.com/bogdanshibilov/mindflowbackend/internal/controller/http/v1/consultation"
+	currencyroutes "github.com/bogdanshibilov/mindflowbackend/internal/controller/http/v1/currency"
 	expertroutes "github.com/bogdanshibilov/mindflowbackend/internal/controller/http/v1/expert"
+	organizationroutes "github.com/bogdanshibilov/mindflowbackend/internal/controller/http/v1/organization"
+	paymentroutes "github.com/bogdanshibilov/mindflowbackend/internal/controller/http/v1/payment"
+	payoutroutes "github.com/bogdanshibilov/mindflowbackend/internal/controller/http/v1/payout"
+	promoroutes "github.com/bogdanshibilov/mindflowbackend/internal/controller/http/v1/promo"
+	sessionpackageroutes "github.com/bogdanshibilov/mindflowbackend/internal/controller/http/v1/sessionpackage"
+	skillroutes "github.com/bogdanshibilov/mindflowbackend/internal/controller/http/v1/skill"
 	userroutes "github.com/bogdanshibilov/mindflowbackend/internal/controller/http/v1/user"
 	authservice "github.com/bogdanshibilov/mindflowbackend/internal/services/auth"
+	bulkservice "github.com/bogdanshibilov/mindflowbackend/internal/services/bulk"
 	consultationservice "github.com/bogdanshibilov/mindflowbackend/internal/services/consultation"
+	currencyservice "github.com/bogdanshibilov/mindflowbackend/internal/services/currency"
 	expertservice "github.com/bogdanshibilov/mindflowbackend/internal/services/expert"
+	exportservice "github.com/bogdanshibilov/mindflowbackend/internal/services/export"
+	onboardingservice "github.com/bogdanshibilov/mindflowbackend/internal/services/onboarding"
+	organizationservice "github.com/bogdanshibilov/mindflowbackend/internal/services/organization"
+	paymentservice "github.com/bogdanshibilov/mindflowbackend/internal/services/payment"
+	payoutservice "github.com/bogdanshibilov/mindflowbackend/internal/services/payout"
+	promoservice "github.com/bogdanshibilov/mindflowbackend/internal/services/promo"
+	sessionpackageservice "github.com/bogdanshibilov/mindflowbackend/internal/services/sessionpackage"
+	skillservice "github.com/bogdanshibilov/mindflowbackend/internal/services/skill"
+	suspensionservice "github.com/bogdanshibilov/mindflowbackend/internal/services/suspension"
 	userservice "github.com/bogdanshibilov/mindflowbackend/internal/services/user"
 )
 
@@ -25,23 +44,43 @@ func NewRouter(
 	experts *expertservice.Service,
 	users *userservice.Service,
 	consultations *consultationservice.Service,
+	payments *paymentservice.Service,
+	payouts *payoutservice.Service,
+	currencies *currencyservice.Service,
+	packages *sessionpackageservice.Service,
+	promos *promoservice.Service,
+	skills *skillservice.Service,
+	exports *exportservice.Service,
+	bulk *bulkservice.Service,
+	suspensions *suspensionservice.Service,
+	onboarding *onboardingservice.Service,
+	orgs *organizationservice.Service,
 ) {
 	handler.Use(gin.Recovery())
 
 	handler.Use(cors.New(cors.Config{
 		AllowWildcard: true,
 		AllowOrigins:  []string{"*"},
-		AllowMethods:  []string{"GET", "POST", "PUT", "DELETE"},
-		AllowHeaders:  []string{"Origin", "authorization", "content-type", "accept"},
+		AllowMethods:  []string{"GET", "POST", "PUT", "PATCH", "DELETE"},
+		AllowHeaders:  []string{"Origin", "authorization", "content-type", "accept", "if-match"},
+		ExposeHeaders: []string{"ETag"},
 	}))
 
 	handler.GET("/healthz", func(ctx *gin.Context) { ctx.Status(http.StatusOK) })
 
 	h := handler.Group("/api/v1")
 	{
-		authroutes.New(h, log, auth)
-		expertroutes.New(h, log, experts, users)
-		consultationroute.New(h, log, consultations, users)
-		userroutes.New(h, log, users)
+		authroutes.New(h, log, auth, users)
+		avatarroutes.New(h, log, users)
+		expertroutes.New(h, log, experts, users, orgs)
+		consultationroute.New(h, log, consultations, users, orgs)
+		userroutes.New(h, log, users, exports, bulk, suspensions, onboarding, orgs)
+		paymentroutes.New(h, log, payments, users, orgs)
+		payoutroutes.New(h, log, payouts, users)
+		currencyroutes.New(h, log, currencies, users)
+		sessionpackageroutes.New(h, log, packages, users, orgs)
+		promoroutes.New(h, log, promos, users)
+		skillroutes.New(h, log, skills, users)
+		organizationroutes.New(h, log, orgs, users)
 	}
 }
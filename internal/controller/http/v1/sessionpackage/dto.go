@@ -0,0 +1,70 @@
+package sessionpackageroutes
+
+import (
+	"time"
+
+	"github.com/bogdanshibilov/mindflowbackend/internal/entity"
+)
+
+type createPackageRequest struct {
+	Title        string `json:"title" binding:"required"`
+	SessionCount int    `json:"sessionCount" binding:"required"`
+	PriceAmount  int    `json:"priceAmount"`
+	Currency     string `json:"currency" binding:"required"`
+	ValidityDays int    `json:"validityDays" binding:"required"`
+}
+
+type packageDTO struct {
+	Id           string    `json:"id"`
+	ExpertId     string    `json:"expertId"`
+	Title        string    `json:"title"`
+	SessionCount int       `json:"sessionCount"`
+	PriceAmount  int       `json:"priceAmount"`
+	Currency     string    `json:"currency"`
+	ValidityDays int       `json:"validityDays"`
+	Active       bool      `json:"active"`
+	CreatedAt    time.Time `json:"createdAt"`
+}
+
+func packageDtoFrom(entity *entity.SessionPackage) *packageDTO {
+	return &packageDTO{
+		Id:           entity.Uuid.String(),
+		ExpertId:     entity.ExpertUuid.String(),
+		Title:        entity.Title,
+		SessionCount: entity.SessionCount,
+		PriceAmount:  entity.PriceAmount,
+		Currency:     entity.Currency,
+		ValidityDays: entity.ValidityDays,
+		Active:       entity.Active,
+		CreatedAt:    entity.CreatedAt,
+	}
+}
+
+type purchaseDTO struct {
+	Id                string     `json:"id"`
+	PackageId         string     `json:"packageId"`
+	ExpertId          string     `json:"expertId"`
+	SessionsTotal     int        `json:"sessionsTotal"`
+	SessionsRemaining int        `json:"sessionsRemaining"`
+	PriceAmount       int        `json:"priceAmount"`
+	Currency          string     `json:"currency"`
+	Status            string     `json:"status"`
+	PurchasedAt       time.Time  `json:"purchasedAt"`
+	ExpiresAt         *time.Time `json:"expiresAt"`
+	PaymentId         string     `json:"paymentId,omitempty"`
+}
+
+func purchaseDtoFrom(entity *entity.PackagePurchase) *purchaseDTO {
+	return &purchaseDTO{
+		Id:                entity.Uuid.String(),
+		PackageId:         entity.PackageUuid.String(),
+		ExpertId:          entity.ExpertUuid.String(),
+		SessionsTotal:     entity.SessionsTotal,
+		SessionsRemaining: entity.SessionsTotal - entity.SessionsUsed,
+		PriceAmount:       entity.PriceAmount,
+		Currency:          entity.Currency,
+		Status:            string(entity.Status),
+		PurchasedAt:       entity.PurchasedAt,
+		ExpiresAt:         entity.ExpiresAt,
+	}
+}
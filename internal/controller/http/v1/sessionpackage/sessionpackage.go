@@ -0,0 +1,173 @@
+package sessionpackageroutes
+
+import (
+	"errors"
+	"log/slog"
+	"net/http"
+	"os"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/bogdanshibilov/mindflowbackend/internal/controller/http/v1/middleware"
+	"github.com/bogdanshibilov/mindflowbackend/internal/entity"
+	sessionpackagerepo "github.com/bogdanshibilov/mindflowbackend/internal/repository/sessionpackage"
+	currencyservice "github.com/bogdanshibilov/mindflowbackend/internal/services/currency"
+	organizationservice "github.com/bogdanshibilov/mindflowbackend/internal/services/organization"
+	sessionpackageservice "github.com/bogdanshibilov/mindflowbackend/internal/services/sessionpackage"
+	userservice "github.com/bogdanshibilov/mindflowbackend/internal/services/user"
+)
+
+type routes struct {
+	log      *slog.Logger
+	packages *sessionpackageservice.Service
+}
+
+func New(
+	handler *gin.RouterGroup,
+	log *slog.Logger,
+	packages *sessionpackageservice.Service,
+	users *userservice.Service,
+	orgs *organizationservice.Service,
+) {
+	r := &routes{
+		log:      log,
+		packages: packages,
+	}
+
+	packagesHandler := handler.Group("/packages")
+	{
+		// Packages of an organization's experts are visible to its members only
+		packagesHandler.Use(middleware.OptionalJwt(os.Getenv("JWTSECRET"), users))
+		packagesHandler.Use(middleware.ResolveScope(orgs, log))
+		packagesHandler.GET("/expert/:expertid", r.ByExpertId)
+		packagesHandler.Use(middleware.RequireJwt(os.Getenv("JWTSECRET"), users))
+		packagesHandler.Use(middleware.ParseClaimsIntoContext())
+		packagesHandler.POST("", r.Create)
+		packagesHandler.DELETE("/:id", r.Deactivate)
+		packagesHandler.POST("/:id/purchase", r.Purchase)
+		packagesHandler.GET("/purchases/me", r.MyPurchases)
+	}
+}
+
+func (r *routes) ByExpertId(ctx *gin.Context) {
+	const op = "PackageRoutes.ByExpertId"
+
+	packages, err := r.packages.PackagesByExpertId(ctx, middleware.ScopeOf(ctx), ctx.Param("expertid"))
+	if err != nil {
+		r.log.Error("failed to get packages", op, err)
+		ctx.JSON(http.StatusBadRequest, gin.H{"message": "bad request"})
+		return
+	}
+
+	DTOs := make([]packageDTO, 0)
+	for _, entity := range packages {
+		DTOs = append(DTOs, *packageDtoFrom(&entity))
+	}
+
+	ctx.JSON(http.StatusOK, DTOs)
+}
+
+func (r *routes) Create(ctx *gin.Context) {
+	const op = "PackageRoutes.Create"
+
+	var req *createPackageRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		r.log.Warn("invalid JSON received", op, err)
+		ctx.JSON(http.StatusBadRequest, gin.H{"message": "invalid JSON"})
+		return
+	}
+
+	pkg := &entity.SessionPackage{
+		Title:        req.Title,
+		SessionCount: req.SessionCount,
+		PriceAmount:  req.PriceAmount,
+		Currency:     strings.ToUpper(req.Currency),
+		ValidityDays: req.ValidityDays,
+	}
+
+	err := r.packages.CreatePackage(ctx, middleware.ScopeOf(ctx), ctx.GetString("uuid"), pkg)
+	if err != nil {
+		switch {
+		case errors.Is(err, sessionpackageservice.ErrNotApprovedExpert):
+			ctx.JSON(http.StatusForbidden, gin.H{"message": "only approved experts can offer packages"})
+		case errors.Is(err, sessionpackageservice.ErrInvalidPackage),
+			errors.Is(err, currencyservice.ErrInvalidCurrency),
+			errors.Is(err, currencyservice.ErrUnsupportedCurrency):
+			ctx.JSON(http.StatusBadRequest, gin.H{"message": "invalid package"})
+		default:
+			r.log.Error("failed to create package", op, err)
+			ctx.JSON(http.StatusInternalServerError, gin.H{"message": "failed to create package"})
+		}
+		return
+	}
+
+	ctx.JSON(http.StatusCreated, packageDtoFrom(pkg))
+}
+
+func (r *routes) Deactivate(ctx *gin.Context) {
+	const op = "PackageRoutes.Deactivate"
+
+	err := r.packages.Deactivate(ctx, ctx.Param("id"), ctx.GetString("uuid"))
+	if err != nil {
+		switch {
+		case errors.Is(err, sessionpackagerepo.ErrPackageNotFound):
+			ctx.JSON(http.StatusNotFound, gin.H{"message": "package not found"})
+		case errors.Is(err, sessionpackageservice.ErrNotPackageOwner):
+			ctx.JSON(http.StatusForbidden, gin.H{"message": "forbidden"})
+		default:
+			r.log.Error("failed to deactivate package", op, err)
+			ctx.JSON(http.StatusBadRequest, gin.H{"message": "bad request"})
+		}
+		return
+	}
+
+	ctx.Status(http.StatusOK)
+}
+
+func (r *routes) Purchase(ctx *gin.Context) {
+	const op = "PackageRoutes.Purchase"
+
+	purchase, intent, err := r.packages.Purchase(ctx, middleware.ScopeOf(ctx), ctx.Param("id"), ctx.GetString("uuid"))
+	if err != nil {
+		switch {
+		case errors.Is(err, sessionpackagerepo.ErrPackageNotFound):
+			ctx.JSON(http.StatusNotFound, gin.H{"message": "package not found"})
+		case errors.Is(err, sessionpackageservice.ErrPackageInactive):
+			ctx.JSON(http.StatusConflict, gin.H{"message": "package is no longer offered"})
+		case errors.Is(err, sessionpackageservice.ErrOwnPackage):
+			ctx.JSON(http.StatusForbidden, gin.H{"message": "experts cannot buy their own packages"})
+		case errors.Is(err, sessionpackageservice.ErrExpertUnavailable):
+			ctx.JSON(http.StatusConflict, gin.H{"message": "expert is not taking bookings"})
+		default:
+			r.log.Error("failed to purchase package", op, err)
+			ctx.JSON(http.StatusInternalServerError, gin.H{"message": "failed to purchase package"})
+		}
+		return
+	}
+
+	dto := purchaseDtoFrom(purchase)
+	if intent != nil {
+		dto.PaymentId = intent.Uuid.String()
+	}
+
+	ctx.JSON(http.StatusCreated, dto)
+}
+
+func (r *routes) MyPurchases(ctx *gin.Context) {
+	const op = "PackageRoutes.MyPurchases"
+
+	purchases, err := r.packages.PurchasesByMenteeId(ctx, ctx.GetString("uuid"))
+	if err != nil {
+		r.log.Error("failed to get package purchases", op, err)
+		ctx.JSON(http.StatusInternalServerError, gin.H{"message": "failed to get package purchases"})
+		return
+	}
+
+	DTOs := make([]purchaseDTO, 0)
+	for _, entity := range purchases {
+		DTOs = append(DTOs, *purchaseDtoFrom(&entity))
+	}
+
+	ctx.JSON(http.StatusOK, DTOs)
+}
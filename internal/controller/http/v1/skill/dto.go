@@ -0,0 +1,60 @@
+package skillroutes
+
+import (
+	"github.com/bogdanshibilov/mindflowbackend/internal/entity"
+)
+
+type createCategoryRequest struct {
+	Name     string `json:"name" binding:"required"`
+	ParentId string `json:"parentId"`
+}
+
+type createSkillRequest struct {
+	Name       string `json:"name" binding:"required"`
+	CategoryId string `json:"categoryId" binding:"required"`
+}
+
+type addAliasRequest struct {
+	Alias string `json:"alias" binding:"required"`
+}
+
+type mergeSkillRequest struct {
+	IntoId string `json:"intoId" binding:"required"`
+}
+
+type categoryDTO struct {
+	Id       string `json:"id"`
+	Name     string `json:"name"`
+	ParentId string `json:"parentId,omitempty"`
+}
+
+func categoryDtoFrom(entity *entity.SkillCategory) *categoryDTO {
+	dto := &categoryDTO{
+		Id:   entity.Uuid.String(),
+		Name: entity.Name,
+	}
+	if entity.ParentUuid != nil {
+		dto.ParentId = entity.ParentUuid.String()
+	}
+	return dto
+}
+
+type skillDTO struct {
+	Id         string   `json:"id"`
+	Name       string   `json:"name"`
+	CategoryId string   `json:"categoryId"`
+	Aliases    []string `json:"aliases"`
+}
+
+func skillDtoFrom(entity *entity.Skill) *skillDTO {
+	aliases := entity.Aliases
+	if aliases == nil {
+		aliases = []string{}
+	}
+	return &skillDTO{
+		Id:         entity.Uuid.String(),
+		Name:       entity.Name,
+		CategoryId: entity.CategoryUuid.String(),
+		Aliases:    aliases,
+	}
+}
@@ -0,0 +1,239 @@
+package skillroutes
+
+import (
+	"errors"
+	"log/slog"
+	"net/http"
+	"os"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/bogdanshibilov/mindflowbackend/internal/controller/http/v1/middleware"
+	skillrepo "github.com/bogdanshibilov/mindflowbackend/internal/repository/skill"
+	skillservice "github.com/bogdanshibilov/mindflowbackend/internal/services/skill"
+	userservice "github.com/bogdanshibilov/mindflowbackend/internal/services/user"
+)
+
+type routes struct {
+	log    *slog.Logger
+	skills *skillservice.Service
+}
+
+func New(
+	handler *gin.RouterGroup,
+	log *slog.Logger,
+	skills *skillservice.Service,
+	users *userservice.Service,
+) {
+	r := &routes{
+		log:    log,
+		skills: skills,
+	}
+
+	skillsHandler := handler.Group("/skills")
+	{
+		skillsHandler.GET("", r.Taxonomy)
+		skillsHandler.Use(middleware.RequireJwt(os.Getenv("JWTSECRET"), users))
+		skillsHandler.Use(middleware.ParseClaimsIntoContext())
+		skillsHandler.Use(middleware.RequireAdminPermission(users, log))
+		skillsHandler.POST("/categories", r.CreateCategory)
+		skillsHandler.DELETE("/categories/:id", r.DeleteCategory)
+		skillsHandler.POST("", r.CreateSkill)
+		skillsHandler.DELETE("/:id", r.DeleteSkill)
+		skillsHandler.POST("/:id/aliases", r.AddAlias)
+		skillsHandler.DELETE("/aliases/:alias", r.DeleteAlias)
+		skillsHandler.POST("/:id/merge", r.Merge)
+	}
+}
+
+// Returns the flat lists of categories and skills
+func (r *routes) Taxonomy(ctx *gin.Context) {
+	const op = "SkillRoutes.Taxonomy"
+
+	taxonomy, err := r.skills.Taxonomy(ctx)
+	if err != nil {
+		r.log.Error("failed to get skills", op, err)
+		ctx.JSON(http.StatusInternalServerError, gin.H{"message": "failed to get skills"})
+		return
+	}
+
+	categories := make([]categoryDTO, 0)
+	for _, entity := range taxonomy.Categories {
+		categories = append(categories, *categoryDtoFrom(&entity))
+	}
+	skills := make([]skillDTO, 0)
+	for _, entity := range taxonomy.Skills {
+		skills = append(skills, *skillDtoFrom(&entity))
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{"categories": categories, "skills": skills})
+}
+
+func (r *routes) CreateCategory(ctx *gin.Context) {
+	const op = "SkillRoutes.CreateCategory"
+
+	var req *createCategoryRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		r.log.Warn("invalid JSON received", op, err)
+		ctx.JSON(http.StatusBadRequest, gin.H{"message": "invalid JSON"})
+		return
+	}
+
+	category, err := r.skills.CreateCategory(ctx, req.Name, req.ParentId)
+	if err != nil {
+		switch {
+		case errors.Is(err, skillservice.ErrInvalidName):
+			ctx.JSON(http.StatusBadRequest, gin.H{"message": "name must not be empty"})
+		case errors.Is(err, skillrepo.ErrCategoryNotFound):
+			ctx.JSON(http.StatusNotFound, gin.H{"message": "parent category not found"})
+		case errors.Is(err, skillrepo.ErrCategoryExists):
+			ctx.JSON(http.StatusConflict, gin.H{"message": "category already exists"})
+		default:
+			r.log.Warn("failed to create category", op, err)
+			ctx.JSON(http.StatusBadRequest, gin.H{"message": "bad request"})
+		}
+		return
+	}
+
+	ctx.JSON(http.StatusCreated, categoryDtoFrom(category))
+}
+
+func (r *routes) DeleteCategory(ctx *gin.Context) {
+	const op = "SkillRoutes.DeleteCategory"
+
+	err := r.skills.DeleteCategory(ctx, ctx.Param("id"))
+	if err != nil {
+		switch {
+		case errors.Is(err, skillrepo.ErrCategoryNotFound):
+			ctx.JSON(http.StatusNotFound, gin.H{"message": "category not found"})
+		case errors.Is(err, skillrepo.ErrCategoryInUse):
+			ctx.JSON(http.StatusConflict, gin.H{"message": "category still has skills or subcategories"})
+		default:
+			r.log.Warn("failed to delete category", op, err)
+			ctx.JSON(http.StatusBadRequest, gin.H{"message": "bad request"})
+		}
+		return
+	}
+
+	ctx.Status(http.StatusOK)
+}
+
+func (r *routes) CreateSkill(ctx *gin.Context) {
+	const op = "SkillRoutes.CreateSkill"
+
+	var req *createSkillRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		r.log.Warn("invalid JSON received", op, err)
+		ctx.JSON(http.StatusBadRequest, gin.H{"message": "invalid JSON"})
+		return
+	}
+
+	skill, err := r.skills.CreateSkill(ctx, req.CategoryId, req.Name)
+	if err != nil {
+		switch {
+		case errors.Is(err, skillservice.ErrInvalidName):
+			ctx.JSON(http.StatusBadRequest, gin.H{"message": "name must not be empty"})
+		case errors.Is(err, skillrepo.ErrCategoryNotFound):
+			ctx.JSON(http.StatusNotFound, gin.H{"message": "category not found"})
+		case errors.Is(err, skillrepo.ErrSkillExists):
+			ctx.JSON(http.StatusConflict, gin.H{"message": "skill already exists"})
+		default:
+			r.log.Warn("failed to create skill", op, err)
+			ctx.JSON(http.StatusBadRequest, gin.H{"message": "bad request"})
+		}
+		return
+	}
+
+	ctx.JSON(http.StatusCreated, skillDtoFrom(skill))
+}
+
+func (r *routes) DeleteSkill(ctx *gin.Context) {
+	const op = "SkillRoutes.DeleteSkill"
+
+	err := r.skills.DeleteSkill(ctx, ctx.Param("id"))
+	if err != nil {
+		if errors.Is(err, skillrepo.ErrSkillNotFound) {
+			ctx.JSON(http.StatusNotFound, gin.H{"message": "skill not found"})
+			return
+		}
+		r.log.Warn("failed to delete skill", op, err)
+		ctx.JSON(http.StatusBadRequest, gin.H{"message": "bad request"})
+		return
+	}
+
+	ctx.Status(http.StatusOK)
+}
+
+func (r *routes) AddAlias(ctx *gin.Context) {
+	const op = "SkillRoutes.AddAlias"
+
+	var req *addAliasRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		r.log.Warn("invalid JSON received", op, err)
+		ctx.JSON(http.StatusBadRequest, gin.H{"message": "invalid JSON"})
+		return
+	}
+
+	err := r.skills.AddAlias(ctx, ctx.Param("id"), req.Alias)
+	if err != nil {
+		switch {
+		case errors.Is(err, skillservice.ErrInvalidName):
+			ctx.JSON(http.StatusBadRequest, gin.H{"message": "alias must not be empty"})
+		case errors.Is(err, skillrepo.ErrSkillNotFound):
+			ctx.JSON(http.StatusNotFound, gin.H{"message": "skill not found"})
+		case errors.Is(err, skillrepo.ErrAliasTaken):
+			ctx.JSON(http.StatusConflict, gin.H{"message": "alias already names a skill"})
+		default:
+			r.log.Warn("failed to add alias", op, err)
+			ctx.JSON(http.StatusBadRequest, gin.H{"message": "bad request"})
+		}
+		return
+	}
+
+	ctx.Status(http.StatusCreated)
+}
+
+func (r *routes) DeleteAlias(ctx *gin.Context) {
+	const op = "SkillRoutes.DeleteAlias"
+
+	err := r.skills.DeleteAlias(ctx, ctx.Param("alias"))
+	if err != nil {
+		if errors.Is(err, skillrepo.ErrAliasNotFound) {
+			ctx.JSON(http.StatusNotFound, gin.H{"message": "alias not found"})
+			return
+		}
+		r.log.Error("failed to delete alias", op, err)
+		ctx.JSON(http.StatusInternalServerError, gin.H{"message": "failed to delete alias"})
+		return
+	}
+
+	ctx.Status(http.StatusOK)
+}
+
+// Merges the skill into the one given by intoId and deletes it
+func (r *routes) Merge(ctx *gin.Context) {
+	const op = "SkillRoutes.Merge"
+
+	var req *mergeSkillRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		r.log.Warn("invalid JSON received", op, err)
+		ctx.JSON(http.StatusBadRequest, gin.H{"message": "invalid JSON"})
+		return
+	}
+
+	err := r.skills.Merge(ctx, ctx.Param("id"), req.IntoId)
+	if err != nil {
+		switch {
+		case errors.Is(err, skillservice.ErrSelfMerge):
+			ctx.JSON(http.StatusBadRequest, gin.H{"message": "skill cannot be merged into itself"})
+		case errors.Is(err, skillrepo.ErrSkillNotFound):
+			ctx.JSON(http.StatusNotFound, gin.H{"message": "skill not found"})
+		default:
+			r.log.Warn("failed to merge skills", op, err)
+			ctx.JSON(http.StatusBadRequest, gin.H{"message": "bad request"})
+		}
+		return
+	}
+
+	ctx.Status(http.StatusOK)
+}
@@ -0,0 +1,63 @@
+package userroutes
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+
+	avatarroutes "github.com/bogdanshibilov/mindflowbackend/internal/controller/http/v1/avatar"
+	userrepo "github.com/bogdanshibilov/mindflowbackend/internal/repository/user"
+	userservice "github.com/bogdanshibilov/mindflowbackend/internal/services/user"
+)
+
+// Accepts a multipart form with the picture in file
+func (r *routes) SetMyAvatar(ctx *gin.Context) {
+	const op = "UserRoutes.SetMyAvatar"
+
+	header, err := ctx.FormFile("file")
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"message": "file is required"})
+		return
+	}
+	file, err := header.Open()
+	if err != nil {
+		r.log.Warn("failed to open uploaded file", op, err)
+		ctx.JSON(http.StatusBadRequest, gin.H{"message": "invalid file"})
+		return
+	}
+	defer file.Close()
+
+	hash, err := r.users.SetAvatar(ctx, ctx.GetString("uuid"), header.Size, file)
+	if err != nil {
+		switch {
+		case errors.Is(err, userservice.ErrAvatarTooLarge):
+			ctx.JSON(http.StatusRequestEntityTooLarge, gin.H{"message": "avatar is too large"})
+		case errors.Is(err, userservice.ErrUnsupportedAvatar):
+			ctx.JSON(http.StatusUnsupportedMediaType, gin.H{"message": err.Error()})
+		case errors.Is(err, userservice.ErrInvalidAvatarDimensions):
+			ctx.JSON(http.StatusBadRequest, gin.H{"message": err.Error()})
+		case errors.Is(err, userrepo.ErrUserNotFound):
+			ctx.JSON(http.StatusNotFound, gin.H{"message": "user not found"})
+		default:
+			r.log.Error("failed to set avatar", op, err)
+			ctx.JSON(http.StatusInternalServerError, gin.H{"message": "failed to set avatar"})
+		}
+		return
+	}
+
+	ctx.JSON(http.StatusOK, avatarroutes.URLs(&hash))
+}
+
+func (r *routes) DeleteMyAvatar(ctx *gin.Context) {
+	const op = "UserRoutes.DeleteMyAvatar"
+
+	err := r.users.DeleteAvatar(ctx, ctx.GetString("uuid"))
+	if err != nil {
+		r.log.Error("failed to delete avatar", op, err)
+		ctx.JSON(http.StatusInternalServerError, gin.H{"message": "failed to delete avatar"})
+		return
+	}
+
+	ctx.Status(http.StatusOK)
+}
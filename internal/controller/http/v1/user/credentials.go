@@ -0,0 +1,88 @@
+package userroutes
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/bogdanshibilov/mindflowbackend/internal/controller/http/v1/etag"
+	userrepo "github.com/bogdanshibilov/mindflowbackend/internal/repository/user"
+	userservice "github.com/bogdanshibilov/mindflowbackend/internal/services/user"
+)
+
+// Signs the user out of every session, this one included
+func (r *routes) ChangeMyPassword(ctx *gin.Context) {
+	const op = "UserRoutes.ChangeMyPassword"
+
+	var req *ChangePasswordRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		r.log.Warn("invalid JSON received", op, err)
+		ctx.JSON(http.StatusBadRequest, gin.H{"message": "invalid JSON"})
+		return
+	}
+
+	err := r.users.ChangePassword(ctx, ctx.GetString("uuid"), req.OldPassword, req.NewPassword)
+	if err != nil {
+		r.credentialsChangeFailed(ctx, op, err)
+		return
+	}
+
+	ctx.Status(http.StatusOK)
+}
+
+func (r *routes) ChangeMyEmail(ctx *gin.Context) {
+	const op = "UserRoutes.ChangeMyEmail"
+
+	var req *ChangeEmailRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		r.log.Warn("invalid JSON received", op, err)
+		ctx.JSON(http.StatusBadRequest, gin.H{"message": "invalid JSON"})
+		return
+	}
+
+	version, err := r.users.ChangeEmail(ctx, ctx.GetString("uuid"), req.Password, req.NewEmail)
+	if err != nil {
+		r.credentialsChangeFailed(ctx, op, err)
+		return
+	}
+
+	etag.Set(ctx, version)
+	ctx.Status(http.StatusOK)
+}
+
+func (r *routes) ChangeMyPhone(ctx *gin.Context) {
+	const op = "UserRoutes.ChangeMyPhone"
+
+	var req *ChangePhoneRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		r.log.Warn("invalid JSON received", op, err)
+		ctx.JSON(http.StatusBadRequest, gin.H{"message": "invalid JSON"})
+		return
+	}
+
+	version, err := r.users.ChangePhone(ctx, ctx.GetString("uuid"), req.NewPhone)
+	if err != nil {
+		r.credentialsChangeFailed(ctx, op, err)
+		return
+	}
+
+	etag.Set(ctx, version)
+	ctx.Status(http.StatusOK)
+}
+
+func (r *routes) credentialsChangeFailed(ctx *gin.Context, op string, err error) {
+	switch {
+	case errors.Is(err, userservice.ErrWrongPassword):
+		ctx.JSON(http.StatusForbidden, gin.H{"message": "wrong password"})
+	case errors.Is(err, userservice.ErrWeakPassword):
+		ctx.JSON(http.StatusBadRequest, gin.H{"message": userservice.ErrWeakPassword.Error()})
+	case errors.Is(err, userrepo.ErrEmailTaken):
+		ctx.JSON(http.StatusConflict, gin.H{"message": userrepo.ErrEmailTaken.Error()})
+	case errors.Is(err, userrepo.ErrUserNotFound):
+		ctx.JSON(http.StatusNotFound, gin.H{"message": "user not found"})
+	default:
+		r.log.Error("failed to change credentials", op, err)
+		ctx.JSON(http.StatusInternalServerError, gin.H{"message": "failed to update user"})
+	}
+}
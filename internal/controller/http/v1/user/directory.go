@@ -0,0 +1,182 @@
+package userroutes
+
+import (
+	"errors"
+	"mime"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/bogdanshibilov/mindflowbackend/internal/entity"
+	"github.com/bogdanshibilov/mindflowbackend/internal/pagination"
+	auditrepo "github.com/bogdanshibilov/mindflowbackend/internal/repository/audit"
+	userrepo "github.com/bogdanshibilov/mindflowbackend/internal/repository/user"
+	bulkservice "github.com/bogdanshibilov/mindflowbackend/internal/services/bulk"
+)
+
+const dateLayout = "2006-01-02"
+
+var expertStatuses = map[string]entity.Status{
+	"none":     userrepo.NoApplication,
+	"pending":  entity.Pending,
+	"approved": entity.Approved,
+	"rejected": entity.Rejected,
+}
+
+// Searches the users by the q param and filters them by role, expertstatus,
+// registeredfrom, registeredto, activesince, inactivesince and suspended
+func (r *routes) Users(ctx *gin.Context) {
+	const op = "UserRoutes.Users"
+
+	filter, ok := usersFilter(ctx)
+	if !ok {
+		return
+	}
+
+	page, err := pagination.FromQuery(ctx.Request.URL.Query(), userrepo.Sorts, "created")
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"message": err.Error()})
+		return
+	}
+
+	users, err := r.users.Users(ctx, filter, page)
+	if err != nil {
+		r.log.Error("failed to get users", op, err)
+		ctx.JSON(http.StatusInternalServerError, gin.H{"message": "failed to get users"})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, pagination.Map(users, directoryUserDtoFrom))
+}
+
+// Builds the directory filter from the query params. Responds with an error
+// and returns false on bad params
+func usersFilter(ctx *gin.Context) (*userrepo.UserFilter, bool) {
+	filter := &userrepo.UserFilter{
+		Query: strings.TrimSpace(ctx.Query("q")),
+	}
+
+	// Comma separated staff, expert or member
+	if ctx.Query("role") != "" {
+		for _, role := range strings.Split(ctx.Query("role"), ",") {
+			filter.Roles = append(filter.Roles, strings.ToLower(strings.TrimSpace(role)))
+		}
+	}
+
+	if ctx.Query("expertstatus") != "" {
+		status, ok := expertStatuses[strings.ToLower(ctx.Query("expertstatus"))]
+		if !ok {
+			ctx.JSON(http.StatusBadRequest, gin.H{"message": "expertstatus must be none, pending, approved or rejected"})
+			return nil, false
+		}
+		filter.ExpertStatus = &status
+	}
+
+	if ctx.Query("suspended") != "" {
+		suspended, err := strconv.ParseBool(ctx.Query("suspended"))
+		if err != nil {
+			ctx.JSON(http.StatusBadRequest, gin.H{"message": "suspended must be true or false"})
+			return nil, false
+		}
+		filter.Suspended = &suspended
+	}
+
+	// Dates, registeredto is exclusive
+	for param, bound := range map[string]**time.Time{
+		"registeredfrom": &filter.RegisteredFrom,
+		"registeredto":   &filter.RegisteredTo,
+		"activesince":    &filter.ActiveSince,
+		"inactivesince":  &filter.InactiveSince,
+	} {
+		if ctx.Query(param) == "" {
+			continue
+		}
+		date, err := time.Parse(dateLayout, ctx.Query(param))
+		if err != nil {
+			ctx.JSON(http.StatusBadRequest, gin.H{"message": param + " must be a date in YYYY-MM-DD format"})
+			return nil, false
+		}
+		*bound = &date
+	}
+
+	if err := filter.Validate(); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"message": err.Error()})
+		return nil, false
+	}
+
+	return filter, true
+}
+
+// Applies an action to the selected users. A dry run reports which users the
+// action would skip. A real export responds with the CSV instead of the report
+func (r *routes) Bulk(ctx *gin.Context) {
+	const op = "UserRoutes.Bulk"
+
+	var req *BulkRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		r.log.Warn("invalid JSON received", op, err)
+		ctx.JSON(http.StatusBadRequest, gin.H{"message": "invalid JSON"})
+		return
+	}
+
+	report, err := r.bulk.Run(ctx, ctx.GetString("uuid"), &bulkservice.Request{
+		Action:     bulkservice.Action(req.Action),
+		UserIds:    req.UserIds,
+		Reason:     req.Reason,
+		Until:      req.Until,
+		AppealNote: req.AppealNote,
+		Subject:    req.Subject,
+		Message:    req.Message,
+		DryRun:     req.DryRun,
+	})
+	if err != nil {
+		for _, invalid := range []error{
+			bulkservice.ErrUnknownAction,
+			bulkservice.ErrNoUsers,
+			bulkservice.ErrTooManyUsers,
+			bulkservice.ErrReasonRequired,
+			bulkservice.ErrEndsInPast,
+			bulkservice.ErrMessageRequired,
+		} {
+			if errors.Is(err, invalid) {
+				ctx.JSON(http.StatusBadRequest, gin.H{"message": invalid.Error()})
+				return
+			}
+		}
+		r.log.Error("failed to run bulk action", op, err)
+		ctx.JSON(http.StatusBadRequest, gin.H{"message": "bad request"})
+		return
+	}
+
+	if report.CSV != nil {
+		fileName := "mindflow-users-" + report.Audit.CreatedAt.Format(dateLayout) + ".csv"
+		ctx.Header("Content-Disposition", mime.FormatMediaType("attachment", map[string]string{"filename": fileName}))
+		ctx.Data(http.StatusOK, "text/csv; charset=utf-8", report.CSV)
+		return
+	}
+
+	ctx.JSON(http.StatusOK, bulkReportDtoFrom(report))
+}
+
+// Lists the audit records of bulk actions
+func (r *routes) AdminActions(ctx *gin.Context) {
+	const op = "UserRoutes.AdminActions"
+
+	page, err := pagination.FromQuery(ctx.Request.URL.Query(), auditrepo.Sorts, "created")
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"message": err.Error()})
+		return
+	}
+
+	actions, err := r.bulk.Actions(ctx, page)
+	if err != nil {
+		r.log.Error("failed to get admin actions", op, err)
+		ctx.JSON(http.StatusInternalServerError, gin.H{"message": "failed to get admin actions"})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, pagination.Map(actions, adminActionDtoFrom))
+}
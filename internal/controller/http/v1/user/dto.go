@@ -1,7 +1,19 @@
 package userroutes
 
-import "github.com/bogdanshibilov/mindflowbackend/internal/entity"
+import (
+	"time"
 
+	"github.com/google/uuid"
+
+	avatarroutes "github.com/bogdanshibilov/mindflowbackend/internal/controller/http/v1/avatar"
+	"github.com/bogdanshibilov/mindflowbackend/internal/entity"
+	userrepo "github.com/bogdanshibilov/mindflowbackend/internal/repository/user"
+	bulkservice "github.com/bogdanshibilov/mindflowbackend/internal/services/bulk"
+	onboardingservice "github.com/bogdanshibilov/mindflowbackend/internal/services/onboarding"
+	userservice "github.com/bogdanshibilov/mindflowbackend/internal/services/user"
+)
+
+// Complete profile as staff sees it
 type userDto struct {
 	Id                    string   `json:"id"`
 	Email                 string   `json:"email"`
@@ -10,6 +22,8 @@ type userDto struct {
 	ProfessionalField     string   `json:"professionalField"`
 	ExperienceDescription string   `json:"experienceDescription"`
 	Phone                 string   `json:"phone"`
+	// Nil without an avatar
+	Avatar *avatarroutes.URLsDTO `json:"avatar"`
 }
 
 func userDtoFrom(entity *entity.User) *userDto {
@@ -21,9 +35,316 @@ func userDtoFrom(entity *entity.User) *userDto {
 		ProfessionalField:     entity.ProfessionalField,
 		ExperienceDescription: entity.ExperienceDescription,
 		Phone:                 entity.Phone,
+		Avatar:                avatarroutes.URLs(entity.AvatarHash),
+	}
+}
+
+// User as the admin directory lists them
+type directoryUserDto struct {
+	userDto
+	Staff bool `json:"staff"`
+	// Nil without an expert application
+	ExpertStatus    *entity.Status `json:"expertStatus"`
+	ExpertSuspended bool           `json:"expertSuspended"`
+	RegisteredAt    time.Time      `json:"registeredAt"`
+	// Nil if the user never signed in
+	LastLoginAt *time.Time `json:"lastLoginAt"`
+	// Nil unless a suspension is in force
+	Suspension *suspensionDto `json:"suspension"`
+}
+
+func directoryUserDtoFrom(user *userrepo.DirectoryUser) *directoryUserDto {
+	return &directoryUserDto{
+		userDto:         *userDtoFrom(&user.User),
+		Staff:           user.Staff,
+		ExpertStatus:    user.ExpertStatus,
+		ExpertSuspended: user.ExpertSuspended,
+		RegisteredAt:    user.CreatedAt,
+		LastLoginAt:     user.LastLoginAt,
+		Suspension:      suspensionDtoFrom(&user.AccountSuspension),
+	}
+}
+
+type suspensionDto struct {
+	SuspendedAt time.Time `json:"suspendedAt"`
+	// Nil for permanent bans
+	SuspendedUntil *time.Time `json:"suspendedUntil"`
+	Reason         string     `json:"reason"`
+	SuspendedBy    *string    `json:"suspendedBy"`
+	AppealNote     *string    `json:"appealNote"`
+}
+
+// Returns nil unless the suspension is in force
+func suspensionDtoFrom(suspension *entity.AccountSuspension) *suspensionDto {
+	if !suspension.Active(time.Now()) {
+		return nil
+	}
+	dto := &suspensionDto{
+		SuspendedAt:    *suspension.SuspendedAt,
+		SuspendedUntil: suspension.SuspendedUntil,
+		AppealNote:     suspension.AppealNote,
+	}
+	if suspension.SuspensionReason != nil {
+		dto.Reason = *suspension.SuspensionReason
 	}
+	if suspension.SuspendedBy != nil {
+		adminId := suspension.SuspendedBy.String()
+		dto.SuspendedBy = &adminId
+	}
+	return dto
+}
+
+type suspensionResultDto struct {
+	CancelledConsultations []string `json:"cancelledConsultations"`
+}
+
+func suspensionResultDtoFrom(cancelled []uuid.UUID) *suspensionResultDto {
+	dto := &suspensionResultDto{CancelledConsultations: make([]string, 0, len(cancelled))}
+	for _, consultUuid := range cancelled {
+		dto.CancelledConsultations = append(dto.CancelledConsultations, consultUuid.String())
+	}
+	return dto
+}
+
+type bulkResultDto struct {
+	UserId  string `json:"userId"`
+	Outcome string `json:"outcome"`
+	Reason  string `json:"reason,omitempty"`
+}
+
+type bulkReportDto struct {
+	Action  string          `json:"action"`
+	DryRun  bool            `json:"dryRun"`
+	Results []bulkResultDto `json:"results"`
+	// Nil for dry runs
+	Audit *adminActionDto `json:"audit"`
 }
 
+func bulkReportDtoFrom(report *bulkservice.Report) *bulkReportDto {
+	dto := &bulkReportDto{
+		Action:  string(report.Action),
+		DryRun:  report.DryRun,
+		Results: make([]bulkResultDto, 0, len(report.Results)),
+	}
+	for _, result := range report.Results {
+		dto.Results = append(dto.Results, bulkResultDto{
+			UserId:  result.UserUuid.String(),
+			Outcome: string(result.Outcome),
+			Reason:  result.Reason,
+		})
+	}
+	if report.Audit != nil {
+		dto.Audit = adminActionDtoFrom(report.Audit)
+	}
+	return dto
+}
+
+type adminActionDto struct {
+	Id string `json:"id"`
+	// Nil once the admin is purged
+	AdminId   *string           `json:"adminId"`
+	Action    string            `json:"action"`
+	UserIds   []string          `json:"userIds"`
+	Requested int               `json:"requested"`
+	Details   map[string]string `json:"details"`
+	CreatedAt time.Time         `json:"createdAt"`
+}
+
+func adminActionDtoFrom(entity *entity.AdminAction) *adminActionDto {
+	dto := &adminActionDto{
+		Id:        entity.Uuid.String(),
+		Action:    entity.Action,
+		UserIds:   make([]string, 0, len(entity.UserUuids)),
+		Requested: entity.Requested,
+		Details:   entity.Details,
+		CreatedAt: entity.CreatedAt,
+	}
+	if entity.AdminUuid != nil {
+		adminId := entity.AdminUuid.String()
+		dto.AdminId = &adminId
+	}
+	for _, userUuid := range entity.UserUuids {
+		dto.UserIds = append(dto.UserIds, userUuid.String())
+	}
+	return dto
+}
+
+type importDto struct {
+	Id          string     `json:"id"`
+	Status      string     `json:"status"`
+	CreatedAt   time.Time  `json:"createdAt"`
+	CompletedAt *time.Time `json:"completedAt"`
+	TotalRows   int        `json:"totalRows"`
+	CreatedRows int        `json:"createdRows"`
+	FailedRows  int        `json:"failedRows"`
+	PendingRows int        `json:"pendingRows"`
+}
+
+func importDtoFrom(entity *entity.UserImport) *importDto {
+	return &importDto{
+		Id:          entity.Uuid.String(),
+		Status:      string(entity.Status),
+		CreatedAt:   entity.CreatedAt,
+		CompletedAt: entity.CompletedAt,
+		TotalRows:   entity.TotalRows,
+		CreatedRows: entity.CreatedRows,
+		FailedRows:  entity.FailedRows,
+		PendingRows: entity.TotalRows - entity.CreatedRows - entity.FailedRows,
+	}
+}
+
+type importRowErrorDto struct {
+	Line  int    `json:"line"`
+	Email string `json:"email"`
+	Error string `json:"error"`
+}
+
+func importRowErrorDtosFrom(rows []entity.UserImportRow) []importRowErrorDto {
+	dtos := make([]importRowErrorDto, 0)
+	for _, row := range rows {
+		if row.Error == nil {
+			continue
+		}
+		dtos = append(dtos, importRowErrorDto{
+			Line:  row.Line,
+			Email: row.Email,
+			Error: *row.Error,
+		})
+	}
+	return dtos
+}
+
+type importReportDto struct {
+	DryRun      bool `json:"dryRun"`
+	ValidRows   int  `json:"validRows"`
+	InvalidRows int  `json:"invalidRows"`
+	// Rows that failed validation, they are not imported
+	Errors []importRowErrorDto `json:"errors"`
+	// Nil for dry runs
+	Import *importDto `json:"import"`
+}
+
+func importReportDtoFrom(report *onboardingservice.Report) *importReportDto {
+	dto := &importReportDto{
+		DryRun: report.Import == nil,
+		Errors: importRowErrorDtosFrom(report.Rows),
+	}
+	dto.InvalidRows = len(dto.Errors)
+	dto.ValidRows = len(report.Rows) - dto.InvalidRows
+	if report.Import != nil {
+		dto.Import = importDtoFrom(report.Import)
+	}
+	return dto
+}
+
+type importDetailDto struct {
+	importDto
+	// Rows that failed validation or could not be created
+	Errors []importRowErrorDto `json:"errors"`
+}
+
+func importDetailDtoFrom(userImport *entity.UserImport, failed []entity.UserImportRow) *importDetailDto {
+	return &importDetailDto{
+		importDto: *importDtoFrom(userImport),
+		Errors:    importRowErrorDtosFrom(failed),
+	}
+}
+
+type privacyDto struct {
+	Email                 string `json:"email"`
+	Phone                 string `json:"phone"`
+	ExperienceDescription string `json:"experienceDescription"`
+}
+
+func privacyDtoFrom(settings *entity.PrivacySettings) *privacyDto {
+	return &privacyDto{
+		Email:                 string(settings.EmailVisibility),
+		Phone:                 string(settings.PhoneVisibility),
+		ExperienceDescription: string(settings.ExperienceVisibility),
+	}
+}
+
+// Profile as the user sees it, with their privacy settings
+type ownerUserDto struct {
+	userDto
+	Privacy privacyDto `json:"privacy"`
+}
+
+func ownerUserDtoFrom(entity *entity.User) *ownerUserDto {
+	return &ownerUserDto{
+		userDto: *userDtoFrom(entity),
+		Privacy: *privacyDtoFrom(&entity.PrivacySettings),
+	}
+}
+
+// Profile as other users see it. Fields hidden by the privacy settings are left out
+type publicUserDto struct {
+	Id                    string                `json:"id"`
+	Name                  string                `json:"name"`
+	ProfessionalField     string                `json:"professionalField"`
+	ExperienceDescription *string               `json:"experienceDescription,omitempty"`
+	Email                 *string               `json:"email,omitempty"`
+	Phone                 *string               `json:"phone,omitempty"`
+	Avatar                *avatarroutes.URLsDTO `json:"avatar"`
+}
+
+func publicUserDtoFrom(entity *entity.User, viewer userservice.Viewer) *publicUserDto {
+	dto := &publicUserDto{
+		Id:                entity.Uuid.String(),
+		Name:              entity.Name,
+		ProfessionalField: entity.ProfessionalField,
+		Avatar:            avatarroutes.URLs(entity.AvatarHash),
+	}
+	if viewer.CanSee(entity.ExperienceVisibility) {
+		dto.ExperienceDescription = &entity.ExperienceDescription
+	}
+	if viewer.CanSee(entity.EmailVisibility) {
+		dto.Email = &entity.Email
+	}
+	if viewer.CanSee(entity.PhoneVisibility) {
+		dto.Phone = &entity.Phone
+	}
+
+	return dto
+}
+
+type deletedUserDto struct {
+	userDto
+	DeletedAt       time.Time `json:"deletedAt"`
+	RestorableUntil time.Time `json:"restorableUntil"`
+}
+
+func deletedUserDtoFrom(user *userservice.DeletedUser) *deletedUserDto {
+	return &deletedUserDto{
+		userDto:         *userDtoFrom(&user.User),
+		DeletedAt:       *user.DeletedAt,
+		RestorableUntil: user.RestorableUntil,
+	}
+}
+
+type exportDto struct {
+	Id          string     `json:"id"`
+	Status      string     `json:"status"`
+	SizeBytes   *int64     `json:"sizeBytes"`
+	RequestedAt time.Time  `json:"requestedAt"`
+	CompletedAt *time.Time `json:"completedAt"`
+	ExpiresAt   *time.Time `json:"expiresAt"`
+	// Set once the archive is ready
+	DownloadUrl *string `json:"downloadUrl"`
+}
+
+func exportDtoFrom(entity *entity.DataExport) *exportDto {
+	return &exportDto{
+		Id:          entity.Uuid.String(),
+		Status:      string(entity.Status),
+		SizeBytes:   entity.SizeBytes,
+		RequestedAt: entity.RequestedAt,
+		CompletedAt: entity.CompletedAt,
+		ExpiresAt:   entity.ExpiresAt,
+	}
+}
+
+// Replaces the whole profile of a user, for admins
 type UpdateUserProfileRequest struct {
 	Id                    string `json:"id"`
 	Name                  string `json:"name" binding:"required"`
@@ -33,13 +354,60 @@ type UpdateUserProfileRequest struct {
 	ExperienceDescription string `json:"experienceDescription" binding:"required"`
 }
 
+// Replaces the whole own profile, PATCH /users/me takes partial changes.
+// The email changes through PUT /users/me/email
+type UpdateMyProfileRequest struct {
+	Name                  string `json:"name" binding:"required"`
+	Phone                 string `json:"phone" binding:"required"`
+	ProfessionalField     string `json:"professionalField" binding:"required"`
+	ExperienceDescription string `json:"experienceDescription" binding:"required"`
+}
+
+type BulkRequest struct {
+	// suspend, delete, email or export
+	Action  string   `json:"action" binding:"required"`
+	UserIds []string `json:"userIds" binding:"required"`
+	// Suspension reason, end and appeal note. Suspensions without an end are permanent
+	Reason     string     `json:"reason"`
+	Until      *time.Time `json:"until"`
+	AppealNote string     `json:"appealNote"`
+	Subject    string     `json:"subject"`
+	Message    string     `json:"message"`
+	DryRun     bool       `json:"dryRun"`
+}
+
+type SuspendRequest struct {
+	Reason string `json:"reason" binding:"required"`
+	// RFC 3339, permanent without one
+	Until      *time.Time `json:"until"`
+	AppealNote string     `json:"appealNote"`
+}
+
 type DeleteUserByIdRequest struct {
 	Id string `json:"id" binding:"required"`
 }
 
-type UpdateSettingsRequest struct {
-	NewEmail    string `json:"newEmail" binding:"required"`
-	NewPhone    string `json:"newPhone" binding:"required"`
-	NewPassword string `json:"newPassword" binding:"required"`
+// Visibility of each field: public, participants or staff
+type UpdatePrivacyRequest struct {
+	Email                 string `json:"email" binding:"required"`
+	Phone                 string `json:"phone" binding:"required"`
+	ExperienceDescription string `json:"experienceDescription" binding:"required"`
+}
+
+type EraseMeRequest struct {
+	Password string `json:"password" binding:"required"`
+}
+
+type ChangePasswordRequest struct {
 	OldPassword string `json:"oldPassword" binding:"required"`
+	NewPassword string `json:"newPassword" binding:"required"`
+}
+
+type ChangeEmailRequest struct {
+	Password string `json:"password" binding:"required"`
+	NewEmail string `json:"newEmail" binding:"required,email"`
+}
+
+type ChangePhoneRequest struct {
+	NewPhone string `json:"newPhone" binding:"required,e164"`
 }
@@ -0,0 +1,80 @@
+package userroutes
+
+import (
+	"errors"
+	"io"
+	"mime"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/bogdanshibilov/mindflowbackend/internal/blobstore"
+	"github.com/bogdanshibilov/mindflowbackend/internal/entity"
+	userrepo "github.com/bogdanshibilov/mindflowbackend/internal/repository/user"
+	exportservice "github.com/bogdanshibilov/mindflowbackend/internal/services/export"
+)
+
+func (r *routes) RequestExport(ctx *gin.Context) {
+	const op = "UserRoutes.RequestExport"
+
+	export, err := r.exports.Request(ctx, ctx.GetString("uuid"))
+	if err != nil {
+		r.exportFailed(ctx, op, err)
+		return
+	}
+
+	ctx.JSON(http.StatusAccepted, exportDtoFrom(export))
+}
+
+func (r *routes) Export(ctx *gin.Context) {
+	const op = "UserRoutes.Export"
+
+	export, err := r.exports.ById(ctx, ctx.GetString("uuid"), ctx.Param("exportid"))
+	if err != nil {
+		r.exportFailed(ctx, op, err)
+		return
+	}
+
+	dto := exportDtoFrom(export)
+	if export.Status == entity.DataExportReady {
+		downloadUrl := ctx.Request.URL.Path + "/download"
+		dto.DownloadUrl = &downloadUrl
+	}
+
+	ctx.JSON(http.StatusOK, dto)
+}
+
+func (r *routes) DownloadExport(ctx *gin.Context) {
+	const op = "UserRoutes.DownloadExport"
+
+	archive, err := r.exports.Open(ctx, ctx.GetString("uuid"), ctx.Param("exportid"))
+	if err != nil {
+		r.exportFailed(ctx, op, err)
+		return
+	}
+	defer archive.Close()
+
+	fileName := "mindflow-data-" + ctx.Param("exportid") + ".zip"
+	ctx.Header("Content-Disposition", mime.FormatMediaType("attachment", map[string]string{"filename": fileName}))
+	ctx.Status(http.StatusOK)
+	ctx.Header("Content-Type", "application/zip")
+	_, err = io.Copy(ctx.Writer, archive)
+	if err != nil {
+		r.log.Warn("failed to send data export", op, err)
+	}
+}
+
+func (r *routes) exportFailed(ctx *gin.Context, op string, err error) {
+	switch {
+	case errors.Is(err, userrepo.ErrExportNotFound),
+		errors.Is(err, blobstore.ErrNotFound):
+		ctx.JSON(http.StatusNotFound, gin.H{"message": "data export not found"})
+	case errors.Is(err, userrepo.ErrExportInProgress):
+		ctx.JSON(http.StatusConflict, gin.H{"message": "a data export is already being built"})
+	case errors.Is(err, exportservice.ErrExportNotReady):
+		ctx.JSON(http.StatusConflict, gin.H{"message": "data export is not ready"})
+	default:
+		r.log.Error("failed to handle data export", op, err)
+		ctx.JSON(http.StatusBadRequest, gin.H{"message": "bad request"})
+	}
+}
@@ -0,0 +1,77 @@
+package userroutes
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+
+	userrepo "github.com/bogdanshibilov/mindflowbackend/internal/repository/user"
+	onboardingservice "github.com/bogdanshibilov/mindflowbackend/internal/services/onboarding"
+)
+
+// Imports users from the CSV in the file form field. Rows are validated right
+// away and created in the background, each user gets an invite link instead
+// of a password. With dryrun=true only the validation report is returned
+func (r *routes) ImportUsers(ctx *gin.Context) {
+	const op = "UserRoutes.ImportUsers"
+
+	header, err := ctx.FormFile("file")
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"message": "file is required"})
+		return
+	}
+	if header.Size > onboardingservice.MaxCsvSize {
+		ctx.JSON(http.StatusRequestEntityTooLarge, gin.H{"message": "CSV is too large"})
+		return
+	}
+	file, err := header.Open()
+	if err != nil {
+		r.log.Warn("failed to open uploaded file", op, err)
+		ctx.JSON(http.StatusBadRequest, gin.H{"message": "invalid file"})
+		return
+	}
+	defer file.Close()
+
+	dryRun := ctx.Query("dryrun") == "true"
+	report, err := r.onboarding.Import(ctx, ctx.GetString("uuid"), file, dryRun)
+	if err != nil {
+		switch {
+		case errors.Is(err, onboardingservice.ErrImportTooLarge):
+			ctx.JSON(http.StatusRequestEntityTooLarge, gin.H{"message": "CSV is too large"})
+		case errors.Is(err, onboardingservice.ErrInvalidCsv),
+			errors.Is(err, onboardingservice.ErrNoRows),
+			errors.Is(err, onboardingservice.ErrTooManyRows):
+			// Without the op prefix, invalid CSV errors tell the line and column at fault
+			ctx.JSON(http.StatusBadRequest, gin.H{"message": errors.Unwrap(err).Error()})
+		default:
+			r.log.Error("failed to import users", op, err)
+			ctx.JSON(http.StatusInternalServerError, gin.H{"message": "failed to import users"})
+		}
+		return
+	}
+
+	status := http.StatusOK
+	if !dryRun {
+		status = http.StatusAccepted
+	}
+	ctx.JSON(status, importReportDtoFrom(report))
+}
+
+// Reports the progress of an import and the rows that failed
+func (r *routes) UserImport(ctx *gin.Context) {
+	const op = "UserRoutes.UserImport"
+
+	userImport, failed, err := r.onboarding.ImportById(ctx, ctx.Param("importid"))
+	if err != nil {
+		if errors.Is(err, userrepo.ErrImportNotFound) {
+			ctx.JSON(http.StatusNotFound, gin.H{"message": "import not found"})
+			return
+		}
+		r.log.Error("failed to get user import", op, err)
+		ctx.JSON(http.StatusBadRequest, gin.H{"message": "bad request"})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, importDetailDtoFrom(userImport, failed))
+}
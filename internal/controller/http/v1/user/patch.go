@@ -0,0 +1,127 @@
+package userroutes
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/bogdanshibilov/mindflowbackend/internal/controller/http/v1/etag"
+	"github.com/bogdanshibilov/mindflowbackend/internal/mergepatch"
+	userrepo "github.com/bogdanshibilov/mindflowbackend/internal/repository/user"
+	userservice "github.com/bogdanshibilov/mindflowbackend/internal/services/user"
+)
+
+// Accepts a JSON Merge Patch of name, phone, professionalField and
+// experienceDescription. Responds with the saved profile and its ETag
+func (r *routes) PatchMyProfile(ctx *gin.Context) {
+	const op = "UserRoutes.PatchMyProfile"
+
+	patch, ifMatch, ok := readPatch(ctx)
+	if !ok {
+		return
+	}
+
+	user, err := r.users.PatchProfile(ctx, ctx.GetString("uuid"), patch, ifMatch)
+	if err != nil {
+		r.patchFailed(ctx, op, err)
+		return
+	}
+
+	etag.Set(ctx, user.Version)
+	ctx.JSON(http.StatusOK, ownerUserDtoFrom(user))
+}
+
+// Same as PatchMyProfile for any user and with email, for admins
+func (r *routes) PatchUserProfile(ctx *gin.Context) {
+	const op = "UserRoutes.PatchUserProfile"
+
+	patch, ifMatch, ok := readPatch(ctx)
+	if !ok {
+		return
+	}
+
+	user, err := r.users.PatchUserProfile(ctx, ctx.Param("id"), patch, ifMatch)
+	if err != nil {
+		r.patchFailed(ctx, op, err)
+		return
+	}
+
+	etag.Set(ctx, user.Version)
+	ctx.JSON(http.StatusOK, userDtoFrom(user))
+}
+
+// Accepts a JSON Merge Patch of privacy
+func (r *routes) PatchMySettings(ctx *gin.Context) {
+	const op = "UserRoutes.PatchMySettings"
+
+	patch, ifMatch, ok := readPatch(ctx)
+	if !ok {
+		return
+	}
+
+	user, err := r.users.PatchSettings(ctx, ctx.GetString("uuid"), patch, ifMatch)
+	if err != nil {
+		r.patchFailed(ctx, op, err)
+		return
+	}
+
+	etag.Set(ctx, user.Version)
+	ctx.JSON(http.StatusOK, ownerUserDtoFrom(user))
+}
+
+// Reads the merge patch body and the profile version from If-Match, which is nil
+// without the header. Responds and returns false when either is unusable
+func readPatch(ctx *gin.Context) ([]byte, *int, bool) {
+	if ctx.ContentType() != mergepatch.ContentType && ctx.ContentType() != gin.MIMEJSON {
+		ctx.JSON(http.StatusUnsupportedMediaType, gin.H{"message": "body must be " + mergepatch.ContentType})
+		return nil, nil, false
+	}
+
+	ifMatch, ok := ifMatchVersion(ctx)
+	if !ok {
+		return nil, nil, false
+	}
+
+	patch, err := ctx.GetRawData()
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"message": "invalid body"})
+		return nil, nil, false
+	}
+
+	return patch, ifMatch, true
+}
+
+// Returns the profile version from If-Match, nil without the header. Responds
+// and returns false when it can never match
+func ifMatchVersion(ctx *gin.Context) (*int, bool) {
+	versions, err := etag.IfMatch(ctx, 1)
+	if err != nil {
+		ctx.JSON(http.StatusPreconditionFailed, gin.H{"message": "profile was changed, reload it and try again"})
+		return nil, false
+	}
+	if versions == nil {
+		return nil, true
+	}
+	return &versions[0], true
+}
+
+func (r *routes) patchFailed(ctx *gin.Context, op string, err error) {
+	switch {
+	case errors.Is(err, mergepatch.ErrInvalidPatch):
+		ctx.JSON(http.StatusBadRequest, gin.H{"message": "invalid merge patch"})
+	case errors.Is(err, userservice.ErrInvalidProfile):
+		ctx.JSON(http.StatusBadRequest, gin.H{"message": userservice.ErrInvalidProfile.Error()})
+	case errors.Is(err, userservice.ErrInvalidVisibility):
+		ctx.JSON(http.StatusBadRequest, gin.H{"message": userservice.ErrInvalidVisibility.Error()})
+	case errors.Is(err, userrepo.ErrEmailTaken):
+		ctx.JSON(http.StatusConflict, gin.H{"message": userrepo.ErrEmailTaken.Error()})
+	case errors.Is(err, userrepo.ErrVersionMismatch):
+		ctx.JSON(http.StatusPreconditionFailed, gin.H{"message": "profile was changed, reload it and try again"})
+	case errors.Is(err, userrepo.ErrUserNotFound):
+		ctx.JSON(http.StatusNotFound, gin.H{"message": "user not found"})
+	default:
+		r.log.Error("failed to update user", op, err)
+		ctx.JSON(http.StatusInternalServerError, gin.H{"message": "failed to update user"})
+	}
+}
@@ -0,0 +1,66 @@
+package userroutes
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+
+	userrepo "github.com/bogdanshibilov/mindflowbackend/internal/repository/user"
+	suspensionservice "github.com/bogdanshibilov/mindflowbackend/internal/services/suspension"
+)
+
+// Suspends the account until the given time, or for good without one, and
+// cancels the user's upcoming consultations. Replaces a suspension in force,
+// so suspending again retries consultations that failed to cancel
+func (r *routes) Suspend(ctx *gin.Context) {
+	const op = "UserRoutes.Suspend"
+
+	var req *SuspendRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		r.log.Warn("invalid JSON received", op, err)
+		ctx.JSON(http.StatusBadRequest, gin.H{"message": "invalid JSON"})
+		return
+	}
+
+	cancelled, err := r.suspensions.Suspend(ctx, ctx.GetString("uuid"), ctx.Param("id"), &suspensionservice.Request{
+		Reason:     req.Reason,
+		Until:      req.Until,
+		AppealNote: req.AppealNote,
+	})
+	if err != nil {
+		switch {
+		case errors.Is(err, suspensionservice.ErrReasonRequired):
+			ctx.JSON(http.StatusBadRequest, gin.H{"message": "suspension reason is required"})
+		case errors.Is(err, suspensionservice.ErrEndsInPast):
+			ctx.JSON(http.StatusBadRequest, gin.H{"message": "suspension must end in the future"})
+		case errors.Is(err, suspensionservice.ErrSelfSuspension):
+			ctx.JSON(http.StatusConflict, gin.H{"message": "admins cannot suspend themselves"})
+		case errors.Is(err, userrepo.ErrUserNotFound):
+			ctx.JSON(http.StatusNotFound, gin.H{"message": "user not found"})
+		default:
+			r.log.Error("failed to suspend user", op, err)
+			ctx.JSON(http.StatusInternalServerError, gin.H{"message": "failed to suspend user or cancel their consultations"})
+		}
+		return
+	}
+
+	ctx.JSON(http.StatusOK, suspensionResultDtoFrom(cancelled))
+}
+
+func (r *routes) LiftSuspension(ctx *gin.Context) {
+	const op = "UserRoutes.LiftSuspension"
+
+	err := r.suspensions.Lift(ctx, ctx.Param("id"))
+	if err != nil {
+		if errors.Is(err, userrepo.ErrNotSuspended) {
+			ctx.JSON(http.StatusNotFound, gin.H{"message": "user not found or not suspended"})
+			return
+		}
+		r.log.Error("failed to lift suspension", op, err)
+		ctx.JSON(http.StatusInternalServerError, gin.H{"message": "failed to lift suspension"})
+		return
+	}
+
+	ctx.Status(http.StatusOK)
+}
@@ -1,64 +1,88 @@
 package userroutes
 
 import (
+	"errors"
 	"log/slog"
 	"net/http"
 	"os"
 
 	"github.com/gin-gonic/gin"
 
+	"github.com/bogdanshibilov/mindflowbackend/internal/controller/http/v1/etag"
 	"github.com/bogdanshibilov/mindflowbackend/internal/controller/http/v1/middleware"
+	"github.com/bogdanshibilov/mindflowbackend/internal/entity"
+	userrepo "github.com/bogdanshibilov/mindflowbackend/internal/repository/user"
+	bulkservice "github.com/bogdanshibilov/mindflowbackend/internal/services/bulk"
+	exportservice "github.com/bogdanshibilov/mindflowbackend/internal/services/export"
+	onboardingservice "github.com/bogdanshibilov/mindflowbackend/internal/services/onboarding"
+	organizationservice "github.com/bogdanshibilov/mindflowbackend/internal/services/organization"
+	suspensionservice "github.com/bogdanshibilov/mindflowbackend/internal/services/suspension"
 	userservice "github.com/bogdanshibilov/mindflowbackend/internal/services/user"
 )
 
 type routes struct {
-	log   *slog.Logger
-	users *userservice.Service
+	log         *slog.Logger
+	users       *userservice.Service
+	exports     *exportservice.Service
+	bulk        *bulkservice.Service
+	suspensions *suspensionservice.Service
+	onboarding  *onboardingservice.Service
 }
 
 func New(
 	handler *gin.RouterGroup,
 	log *slog.Logger,
 	users *userservice.Service,
+	exports *exportservice.Service,
+	bulk *bulkservice.Service,
+	suspensions *suspensionservice.Service,
+	onboarding *onboardingservice.Service,
+	orgs *organizationservice.Service,
 ) {
 	r := &routes{
-		log:   log,
-		users: users,
+		log:         log,
+		users:       users,
+		exports:     exports,
+		bulk:        bulk,
+		suspensions: suspensions,
+		onboarding:  onboarding,
 	}
 
 	usersHandler := handler.Group("/users")
 	{
-		usersHandler.Use(middleware.RequireJwt(os.Getenv("JWTSECRET")))
+		usersHandler.Use(middleware.RequireJwt(os.Getenv("JWTSECRET"), users))
 		usersHandler.Use(middleware.ParseClaimsIntoContext())
 		usersHandler.PUT("/myprofile", r.UpdateMyProfile)
-		usersHandler.PUT("/settings", r.UpdateMySettings)
 		usersHandler.GET("/me", r.MyUserInfo)
-		usersHandler.GET("/:id", r.ById)
+		usersHandler.PATCH("/me", r.PatchMyProfile)
+		usersHandler.PATCH("/me/settings", r.PatchMySettings)
+		usersHandler.PUT("/me/password", r.ChangeMyPassword)
+		usersHandler.PUT("/me/email", r.ChangeMyEmail)
+		usersHandler.PUT("/me/phone", r.ChangeMyPhone)
+		usersHandler.DELETE("/me", r.EraseMe)
+		usersHandler.PUT("/me/privacy", r.UpdateMyPrivacy)
+		usersHandler.PUT("/me/avatar", r.SetMyAvatar)
+		usersHandler.DELETE("/me/avatar", r.DeleteMyAvatar)
+		usersHandler.POST("/me/export", r.RequestExport)
+		usersHandler.GET("/me/export/:exportid", r.Export)
+		usersHandler.GET("/me/export/:exportid/download", r.DownloadExport)
+		usersHandler.GET("/:id", middleware.ResolveScope(orgs, log), r.ById)
 		usersHandler.Use(middleware.RequireAdminPermission(users, log))
 		usersHandler.GET("", r.Users)
+		usersHandler.POST("/bulk", r.Bulk)
+		usersHandler.GET("/actions", r.AdminActions)
+		usersHandler.POST("/imports", r.ImportUsers)
+		usersHandler.GET("/imports/:importid", r.UserImport)
 		usersHandler.PUT("/forceupdateuserprofile", r.ForceUpdateUserProfile)
+		usersHandler.PATCH("/:id", r.PatchUserProfile)
 		usersHandler.DELETE("", r.DeleteUserById)
+		usersHandler.GET("/deleted", r.DeletedUsers)
+		usersHandler.POST("/:id/restore", r.Restore)
+		usersHandler.PUT("/:id/suspension", r.Suspend)
+		usersHandler.DELETE("/:id/suspension", r.LiftSuspension)
 	}
 }
 
-func (r *routes) Users(ctx *gin.Context) {
-	const op = "UserRoutes.Users"
-
-	users, err := r.users.Users(ctx)
-	if err != nil {
-		r.log.Error("failed to get users", op, err)
-		ctx.JSON(http.StatusInternalServerError, gin.H{"message": "failed to get users"})
-		return
-	}
-
-	DTOs := make([]userDto, 0)
-	for _, entity := range users {
-		DTOs = append(DTOs, *userDtoFrom(&entity))
-	}
-
-	ctx.JSON(http.StatusOK, DTOs)
-}
-
 func (r *routes) ForceUpdateUserProfile(ctx *gin.Context) {
 	const op = "UserRoutes.ForceUpdateUserProfile"
 
@@ -69,7 +93,12 @@ func (r *routes) ForceUpdateUserProfile(ctx *gin.Context) {
 		return
 	}
 
-	err := r.users.UpdateProfile(
+	ifMatch, ok := ifMatchVersion(ctx)
+	if !ok {
+		return
+	}
+
+	version, err := r.users.UpdateProfile(
 		ctx,
 		req.Name,
 		req.Email,
@@ -77,20 +106,21 @@ func (r *routes) ForceUpdateUserProfile(ctx *gin.Context) {
 		req.ProfessionalField,
 		req.ExperienceDescription,
 		req.Id,
+		ifMatch,
 	)
 	if err != nil {
-		r.log.Error("failed to update user", op, err)
-		ctx.JSON(http.StatusInternalServerError, gin.H{"message": "failed to update user"})
+		r.patchFailed(ctx, op, err)
 		return
 	}
 
+	etag.Set(ctx, version)
 	ctx.Status(http.StatusOK)
 }
 
 func (r *routes) UpdateMyProfile(ctx *gin.Context) {
 	const op = "UserRoutes.UpdateMyProfile"
 
-	var req *UpdateUserProfileRequest
+	var req *UpdateMyProfileRequest
 	if err := ctx.ShouldBindJSON(&req); err != nil {
 		r.log.Warn("invalid JSON received", op, err)
 		ctx.JSON(http.StatusBadRequest, gin.H{"message": "invalid JSON"})
@@ -99,21 +129,26 @@ func (r *routes) UpdateMyProfile(ctx *gin.Context) {
 
 	id := ctx.GetString("uuid")
 
-	err := r.users.UpdateProfile(
+	ifMatch, ok := ifMatchVersion(ctx)
+	if !ok {
+		return
+	}
+
+	version, err := r.users.UpdateOwnProfile(
 		ctx,
 		req.Name,
-		req.Email,
 		req.Phone,
 		req.ProfessionalField,
 		req.ExperienceDescription,
 		id,
+		ifMatch,
 	)
 	if err != nil {
-		r.log.Error("failed to update user", op, err)
-		ctx.JSON(http.StatusInternalServerError, gin.H{"message": "failed to update user"})
+		r.patchFailed(ctx, op, err)
 		return
 	}
 
+	etag.Set(ctx, version)
 	ctx.Status(http.StatusOK)
 }
 
@@ -127,8 +162,16 @@ func (r *routes) DeleteUserById(ctx *gin.Context) {
 		return
 	}
 
-	err := r.users.DeleteUserById(ctx, req.Id)
+	err := r.users.DeleteUserById(ctx, ctx.GetString("uuid"), req.Id)
 	if err != nil {
+		if errors.Is(err, userrepo.ErrUserNotFound) {
+			ctx.JSON(http.StatusNotFound, gin.H{"message": "user not found"})
+			return
+		}
+		if errors.Is(err, userrepo.ErrUpcomingConsultations) {
+			ctx.JSON(http.StatusConflict, gin.H{"message": "user booked a consultation while being deleted, try again"})
+			return
+		}
 		r.log.Error("failed to delete user", op, err)
 		ctx.JSON(http.StatusInternalServerError, gin.H{"message": "failed to delete user"})
 		return
@@ -137,16 +180,73 @@ func (r *routes) DeleteUserById(ctx *gin.Context) {
 	ctx.Status(http.StatusOK)
 }
 
+func (r *routes) DeletedUsers(ctx *gin.Context) {
+	const op = "UserRoutes.DeletedUsers"
+
+	users, err := r.users.DeletedUsers(ctx)
+	if err != nil {
+		r.log.Error("failed to get deleted users", op, err)
+		ctx.JSON(http.StatusInternalServerError, gin.H{"message": "failed to get deleted users"})
+		return
+	}
+
+	DTOs := make([]deletedUserDto, 0)
+	for _, user := range users {
+		DTOs = append(DTOs, *deletedUserDtoFrom(&user))
+	}
+
+	ctx.JSON(http.StatusOK, DTOs)
+}
+
+func (r *routes) Restore(ctx *gin.Context) {
+	const op = "UserRoutes.Restore"
+
+	err := r.users.Restore(ctx, ctx.Param("id"))
+	if err != nil {
+		if errors.Is(err, userrepo.ErrNotRestorable) {
+			ctx.JSON(http.StatusConflict, gin.H{"message": "user is not deleted or can no longer be restored"})
+			return
+		}
+		r.log.Error("failed to restore user", op, err)
+		ctx.JSON(http.StatusInternalServerError, gin.H{"message": "failed to restore user"})
+		return
+	}
+
+	ctx.Status(http.StatusOK)
+}
+
+// Responds with the projection of the profile the viewer is allowed to see
 func (r *routes) ById(ctx *gin.Context) {
+	const op = "UserRoutes.ById"
+
 	id := ctx.Param("id")
 
-	user, err := r.users.ById(ctx, id)
+	user, err := r.users.ByIdInScope(ctx, middleware.ScopeOf(ctx), ctx.GetString("uuid"), id)
 	if err != nil {
+		if errors.Is(err, userrepo.ErrUserNotFound) {
+			ctx.JSON(http.StatusNotFound, gin.H{"message": "user not found"})
+			return
+		}
 		ctx.JSON(http.StatusBadRequest, gin.H{"message": "bad id"})
 		return
 	}
 
-	ctx.JSON(http.StatusOK, userDtoFrom(user))
+	viewer, err := r.users.ViewerOf(ctx, ctx.GetString("uuid"), user.Uuid)
+	if err != nil {
+		r.log.Error("failed to check profile viewer", op, err)
+		ctx.JSON(http.StatusInternalServerError, gin.H{"message": "failed to get user"})
+		return
+	}
+
+	etag.Set(ctx, user.Version)
+	switch viewer {
+	case userservice.ViewerOwner:
+		ctx.JSON(http.StatusOK, ownerUserDtoFrom(user))
+	case userservice.ViewerStaff:
+		ctx.JSON(http.StatusOK, userDtoFrom(user))
+	default:
+		ctx.JSON(http.StatusOK, publicUserDtoFrom(user, viewer))
+	}
 }
 
 func (r *routes) MyUserInfo(ctx *gin.Context) {
@@ -158,24 +258,61 @@ func (r *routes) MyUserInfo(ctx *gin.Context) {
 		return
 	}
 
-	ctx.JSON(http.StatusOK, userDtoFrom(user))
+	etag.Set(ctx, user.Version)
+	ctx.JSON(http.StatusOK, ownerUserDtoFrom(user))
 }
 
-func (r *routes) UpdateMySettings(ctx *gin.Context) {
-	const op = "UserRoutes.UpdateMySettings"
+func (r *routes) UpdateMyPrivacy(ctx *gin.Context) {
+	const op = "UserRoutes.UpdateMyPrivacy"
 
-	id := ctx.GetString("uuid")
+	var req *UpdatePrivacyRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		r.log.Warn("invalid JSON received", op, err)
+		ctx.JSON(http.StatusBadRequest, gin.H{"message": "invalid JSON"})
+		return
+	}
+
+	err := r.users.UpdatePrivacy(ctx, ctx.GetString("uuid"), &entity.PrivacySettings{
+		EmailVisibility:      entity.Visibility(req.Email),
+		PhoneVisibility:      entity.Visibility(req.Phone),
+		ExperienceVisibility: entity.Visibility(req.ExperienceDescription),
+	})
+	if err != nil {
+		if errors.Is(err, userservice.ErrInvalidVisibility) {
+			ctx.JSON(http.StatusBadRequest, gin.H{"message": err.Error()})
+			return
+		}
+		r.log.Error("failed to update privacy settings", op, err)
+		ctx.JSON(http.StatusInternalServerError, gin.H{"message": "failed to update privacy settings"})
+		return
+	}
+
+	ctx.Status(http.StatusOK)
+}
+
+func (r *routes) EraseMe(ctx *gin.Context) {
+	const op = "UserRoutes.EraseMe"
 
-	var req *UpdateSettingsRequest
+	var req *EraseMeRequest
 	if err := ctx.ShouldBindJSON(&req); err != nil {
 		r.log.Warn("invalid JSON received", op, err)
 		ctx.JSON(http.StatusBadRequest, gin.H{"message": "invalid JSON"})
 		return
 	}
 
-	err := r.users.UpdateSettings(ctx, req.NewEmail, req.NewPhone, req.OldPassword, req.NewPassword, id)
+	err := r.users.Erase(ctx, ctx.GetString("uuid"), req.Password)
 	if err != nil {
-		ctx.JSON(http.StatusBadRequest, gin.H{"message": "bad request"})
+		switch {
+		case errors.Is(err, userservice.ErrWrongPassword):
+			ctx.JSON(http.StatusForbidden, gin.H{"message": "wrong password"})
+		case errors.Is(err, userrepo.ErrUpcomingConsultations):
+			ctx.JSON(http.StatusConflict, gin.H{"message": "finish or cancel your upcoming consultations first"})
+		case errors.Is(err, userrepo.ErrUserNotFound):
+			ctx.JSON(http.StatusNotFound, gin.H{"message": "user not found"})
+		default:
+			r.log.Error("failed to erase user", op, err)
+			ctx.JSON(http.StatusInternalServerError, gin.H{"message": "failed to close account"})
+		}
 		return
 	}
 
@@ -0,0 +1,21 @@
+package entity
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// A bulk action an admin took on users
+type AdminAction struct {
+	Uuid uuid.UUID `db:"uuid"`
+	// Nil once the admin is purged
+	AdminUuid *uuid.UUID `db:"admin_uuid"`
+	Action    string     `db:"action"`
+	// Users the action was applied to
+	UserUuids []uuid.UUID `db:"user_uuids"`
+	// Users the admin selected, including skipped and failed ones
+	Requested int               `db:"requested"`
+	Details   map[string]string `db:"details"`
+	CreatedAt time.Time         `db:"created_at"`
+}
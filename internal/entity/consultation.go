@@ -7,17 +7,33 @@ import (
 )
 
 type Consultation struct {
-	Uuid                    uuid.UUID `db:"uuid"`
-	ExpertUuid              uuid.UUID `db:"expert_uuid"`
-	MenteeUuid              uuid.UUID `db:"mentee_uuid"`
+	Uuid          uuid.UUID `db:"uuid"`
+	ExpertUuid    uuid.UUID `db:"expert_uuid"`
+	MenteeUuid    uuid.UUID `db:"mentee_uuid"`
+	PriceAmount   int       `db:"price_amount"`
+	PriceCurrency string    `db:"price_currency"`
+	// Set when the consultation was paid with a package credit
+	PackagePurchaseUuid *uuid.UUID `db:"package_purchase_uuid"`
+	// Organization of the expert at booking time, nil for public experts
+	OrganizationUuid        *uuid.UUID `db:"organization_uuid"`
 	ConsultationApplication `db:"-"`
 }
 
 type ConsultationApplication struct {
+	ConsultationUuid uuid.UUID  `db:"consultation_uuid"`
+	Status           Status     `db:"status"`
+	MenteeQuestions  string     `db:"mentee_questions"`
+	SubmittedAt      time.Time  `db:"submitted_at"`
+	CancelledBy      *uuid.UUID `db:"cancelled_by"`
+	CancelledAt      *time.Time `db:"cancelled_at"`
+}
+
+type ConsultationReview struct {
 	ConsultationUuid uuid.UUID `db:"consultation_uuid"`
-	Status           Status    `db:"status"`
-	MenteeQuestions  string    `db:"mentee_questions"`
-	SubmittedAt      time.Time `db:"submitted_at"`
+	// 1 to 5
+	Rating    int       `db:"rating"`
+	Comment   *string   `db:"comment"`
+	CreatedAt time.Time `db:"created_at"`
 }
 
 type ConsultationMeeting struct {
@@ -26,3 +42,31 @@ type ConsultationMeeting struct {
 	StartTime        time.Time `db:"start_time"`
 	Link             string    `db:"link"`
 }
+
+type ConsultationNote struct {
+	Uuid             uuid.UUID `db:"uuid"`
+	ConsultationUuid uuid.UUID `db:"consultation_uuid"`
+	AuthorUuid       uuid.UUID `db:"author_uuid"`
+	Content          string    `db:"content"`
+	CreatedAt        time.Time `db:"created_at"`
+	UpdatedAt        time.Time `db:"updated_at"`
+}
+
+type MeetingSummary struct {
+	MeetingUuid uuid.UUID  `db:"meeting_uuid"`
+	AuthorUuid  uuid.UUID  `db:"author_uuid"`
+	Version     int        `db:"version"`
+	Content     string     `db:"content"`
+	ActionItems []string   `db:"action_items"`
+	PublishedAt *time.Time `db:"published_at"`
+	UpdatedAt   time.Time  `db:"updated_at"`
+}
+
+type MeetingSummaryRevision struct {
+	MeetingUuid uuid.UUID `db:"meeting_uuid"`
+	Version     int       `db:"version"`
+	EditorUuid  uuid.UUID `db:"editor_uuid"`
+	Content     string    `db:"content"`
+	ActionItems []string  `db:"action_items"`
+	EditedAt    time.Time `db:"edited_at"`
+}
@@ -0,0 +1,15 @@
+package entity
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+type ExchangeRate struct {
+	Currency string `db:"currency"`
+	// Base currency minor units one minor unit of Currency is worth
+	Rate      float64    `db:"rate"`
+	UpdatedBy *uuid.UUID `db:"updated_by"`
+	UpdatedAt time.Time  `db:"updated_at"`
+}
@@ -0,0 +1,42 @@
+package entity
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+type DocumentKind string
+
+const (
+	DocumentDiploma     DocumentKind = "diploma"
+	DocumentCertificate DocumentKind = "certificate"
+	DocumentPortfolio   DocumentKind = "portfolio"
+)
+
+type DocumentStatus string
+
+const (
+	DocumentPending  DocumentStatus = "pending"
+	DocumentAccepted DocumentStatus = "accepted"
+	DocumentRejected DocumentStatus = "rejected"
+)
+
+// A document an expert submits to back their application. It is either an
+// uploaded file kept in the blob store or a link
+type ExpertDocument struct {
+	Uuid        uuid.UUID      `db:"uuid"`
+	ExpertUuid  uuid.UUID      `db:"expert_uuid"`
+	Kind        DocumentKind   `db:"kind"`
+	Title       string         `db:"title"`
+	BlobKey     *string        `db:"blob_key"`
+	FileName    *string        `db:"file_name"`
+	ContentType *string        `db:"content_type"`
+	SizeBytes   *int64         `db:"size_bytes"`
+	Url         *string        `db:"url"`
+	Status      DocumentStatus `db:"status"`
+	VerdictNote *string        `db:"verdict_note"`
+	ReviewedBy  *uuid.UUID     `db:"reviewed_by"`
+	ReviewedAt  *time.Time     `db:"reviewed_at"`
+	UploadedAt  time.Time      `db:"uploaded_at"`
+}
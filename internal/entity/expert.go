@@ -11,14 +11,83 @@ type Expert struct {
 	UserProfile       `db:"-"`
 	ExpertInformation `db:"-"`
 	ExpertApplication `db:"-"`
+	Skills            []Skill `db:"-"`
 }
 
 type ExpertInformation struct {
+	// In minor units of Currency
 	Price           int    `db:"price"`
+	Currency        string `db:"currency"`
 	HelpDescription string `db:"help_description"`
+	// At least one document was accepted and none is waiting for review
+	Verified bool `db:"verified"`
+	// ISO 639 language codes
+	Languages []string `db:"languages"`
+	// Average review rating, nil without reviews
+	Rating      *float64 `db:"rating"`
+	ReviewCount int      `db:"review_count"`
+	// Paused experts take no bookings until PausedUntil, or until they resume when it is nil
+	PausedAt    *time.Time `db:"paused_at"`
+	PausedUntil *time.Time `db:"paused_until"`
+	// Suspended experts are hidden until an admin lifts the suspension
+	SuspendedAt      *time.Time `db:"suspended_at"`
+	SuspensionReason *string    `db:"suspension_reason"`
+	// Neither paused nor suspended, so listed and bookable
+	Active bool `db:"active"`
+	// Organization whose members alone see the expert, nil for public experts
+	OrganizationUuid *uuid.UUID `db:"organization_uuid"`
+	// Incremented on every change of the expert information, the profile has its own
+	Version int `db:"information_version"`
 }
 
 type ExpertApplication struct {
 	Status      Status    `db:"status"`
 	SubmittedAt time.Time `db:"submitted_at"`
+	// Reason given by the admin who last reviewed the application
+	Reason     *string    `db:"reason"`
+	ReviewedBy *uuid.UUID `db:"reviewed_by"`
+	ReviewedAt *time.Time `db:"reviewed_at"`
+}
+
+// A submission or review decision of an expert application.
+// Submissions have Pending status and no reviewer
+type ExpertApplicationEvent struct {
+	Uuid       uuid.UUID  `db:"uuid"`
+	UserUuid   uuid.UUID  `db:"user_uuid"`
+	Status     Status     `db:"status"`
+	Reason     *string    `db:"reason"`
+	ReviewedBy *uuid.UUID `db:"reviewed_by"`
+	CreatedAt  time.Time  `db:"created_at"`
+}
+
+type ExpertPrice struct {
+	Uuid       uuid.UUID `db:"uuid"`
+	ExpertUuid uuid.UUID `db:"expert_uuid"`
+	Amount     int       `db:"amount"`
+	Currency   string    `db:"currency"`
+	ChangedAt  time.Time `db:"changed_at"`
+}
+
+// Changes to sensitive expert fields waiting for admin review.
+// Nil fields are left unchanged
+type ExpertRevision struct {
+	Uuid              uuid.UUID `db:"uuid"`
+	ExpertUuid        uuid.UUID `db:"expert_uuid"`
+	ProfessionalField *string   `db:"professional_field"`
+	HelpDescription   *string   `db:"help_description"`
+	// The complete new set of skills
+	SkillUuids  []uuid.UUID `db:"skill_uuids"`
+	Status      Status      `db:"status"`
+	SubmittedAt time.Time   `db:"submitted_at"`
+	ReviewedBy  *uuid.UUID  `db:"reviewed_by"`
+	ReviewedAt  *time.Time  `db:"reviewed_at"`
+}
+
+// Weekly slot the expert takes consultations in. Minutes since midnight UTC
+type ExpertAvailability struct {
+	Uuid        uuid.UUID `db:"uuid"`
+	ExpertUuid  uuid.UUID `db:"expert_uuid"`
+	Weekday     int       `db:"weekday"`
+	StartMinute int       `db:"start_minute"`
+	EndMinute   int       `db:"end_minute"`
 }
@@ -0,0 +1,27 @@
+package entity
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+type DataExportStatus string
+
+const (
+	DataExportPending DataExportStatus = "pending"
+	DataExportReady   DataExportStatus = "ready"
+	DataExportFailed  DataExportStatus = "failed"
+)
+
+// Archive of a user's personal data, built in the background
+type DataExport struct {
+	Uuid        uuid.UUID        `db:"uuid"`
+	UserUuid    uuid.UUID        `db:"user_uuid"`
+	Status      DataExportStatus `db:"status"`
+	BlobKey     *string          `db:"blob_key"`
+	SizeBytes   *int64           `db:"size_bytes"`
+	RequestedAt time.Time        `db:"requested_at"`
+	CompletedAt *time.Time       `db:"completed_at"`
+	ExpiresAt   *time.Time       `db:"expires_at"`
+}
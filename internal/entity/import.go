@@ -0,0 +1,52 @@
+package entity
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+type UserImportStatus string
+
+const (
+	UserImportPending UserImportStatus = "pending"
+	UserImportDone    UserImportStatus = "done"
+)
+
+// CSV import of users, its rows are created in the background
+type UserImport struct {
+	Uuid uuid.UUID `db:"uuid"`
+	// Nil once the admin is purged
+	AdminUuid   *uuid.UUID       `db:"admin_uuid"`
+	Status      UserImportStatus `db:"status"`
+	CreatedAt   time.Time        `db:"created_at"`
+	CompletedAt *time.Time       `db:"completed_at"`
+	// Progress, rows still pending are TotalRows - CreatedRows - FailedRows
+	TotalRows   int `db:"total_rows"`
+	CreatedRows int `db:"created_rows"`
+	FailedRows  int `db:"failed_rows"`
+}
+
+type UserImportRowStatus string
+
+const (
+	UserImportRowPending UserImportRowStatus = "pending"
+	UserImportRowCreated UserImportRowStatus = "created"
+	UserImportRowFailed  UserImportRowStatus = "failed"
+)
+
+type UserImportRow struct {
+	ImportUuid uuid.UUID `db:"import_uuid"`
+	// Line in the CSV, the header is line 1
+	Line                  int                 `db:"line"`
+	Name                  string              `db:"name"`
+	Email                 string              `db:"email"`
+	Phone                 string              `db:"phone"`
+	ProfessionalField     string              `db:"professional_field"`
+	ExperienceDescription string              `db:"experience_description"`
+	Status                UserImportRowStatus `db:"status"`
+	// Why the row failed validation or could not be created
+	Error *string `db:"error"`
+	// Set once the user is created
+	UserUuid *uuid.UUID `db:"user_uuid"`
+}
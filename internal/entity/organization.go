@@ -0,0 +1,45 @@
+package entity
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+type OrganizationRole string
+
+const (
+	OrganizationRoleMember OrganizationRole = "member"
+	// Manages the members, experts, branding and settings of the organization
+	OrganizationRoleAdmin OrganizationRole = "admin"
+)
+
+// A company with its own pool of experts and employees
+type Organization struct {
+	Uuid                 uuid.UUID `db:"uuid"`
+	Slug                 string    `db:"slug"`
+	Name                 string    `db:"name"`
+	OrganizationBranding `db:"-"`
+	OrganizationSettings `db:"-"`
+	CreatedAt            time.Time `db:"created_at"`
+}
+
+type OrganizationBranding struct {
+	LogoUrl *string `db:"logo_url"`
+	// Hex color like #1a2b3c
+	PrimaryColor *string `db:"primary_color"`
+}
+
+type OrganizationSettings struct {
+	// Members also see and book experts outside any organization
+	PublicExperts bool `db:"public_experts"`
+}
+
+type OrganizationMember struct {
+	OrganizationUuid uuid.UUID        `db:"organization_uuid"`
+	UserUuid         uuid.UUID        `db:"user_uuid"`
+	Role             OrganizationRole `db:"role"`
+	JoinedAt         time.Time        `db:"joined_at"`
+	Name             string           `db:"name"`
+	Email            string           `db:"email"`
+}
@@ -0,0 +1,42 @@
+package entity
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+type SessionPackage struct {
+	Uuid         uuid.UUID `db:"uuid"`
+	ExpertUuid   uuid.UUID `db:"expert_uuid"`
+	Title        string    `db:"title"`
+	SessionCount int       `db:"session_count"`
+	PriceAmount  int       `db:"price_amount"`
+	Currency     string    `db:"currency"`
+	ValidityDays int       `db:"validity_days"`
+	Active       bool      `db:"active"`
+	CreatedAt    time.Time `db:"created_at"`
+}
+
+type PackagePurchaseStatus string
+
+const (
+	PurchasePending PackagePurchaseStatus = "pending"
+	PurchaseActive  PackagePurchaseStatus = "active"
+)
+
+type PackagePurchase struct {
+	Uuid          uuid.UUID             `db:"uuid"`
+	PackageUuid   uuid.UUID             `db:"package_uuid"`
+	MenteeUuid    uuid.UUID             `db:"mentee_uuid"`
+	ExpertUuid    uuid.UUID             `db:"expert_uuid"`
+	SessionsTotal int                   `db:"sessions_total"`
+	SessionsUsed  int                   `db:"sessions_used"`
+	ValidityDays  int                   `db:"validity_days"`
+	PriceAmount   int                   `db:"price_amount"`
+	Currency      string                `db:"currency"`
+	Status        PackagePurchaseStatus `db:"status"`
+	PurchasedAt   time.Time             `db:"purchased_at"`
+	PaidAt        *time.Time            `db:"paid_at"`
+	ExpiresAt     *time.Time            `db:"expires_at"`
+}
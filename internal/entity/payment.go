@@ -0,0 +1,92 @@
+package entity
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+type PaymentStatus string
+
+const (
+	PaymentRequired   PaymentStatus = "requires_payment"
+	PaymentProcessing PaymentStatus = "processing"
+	PaymentSucceeded  PaymentStatus = "succeeded"
+	PaymentFailed     PaymentStatus = "failed"
+)
+
+// A payment for either a consultation or a package purchase
+type PaymentIntent struct {
+	Uuid                uuid.UUID  `db:"uuid"`
+	ConsultationUuid    *uuid.UUID `db:"consultation_uuid"`
+	PackagePurchaseUuid *uuid.UUID `db:"package_purchase_uuid"`
+	PayerUuid           uuid.UUID  `db:"payer_uuid"`
+	// Amount to be charged, OriginalAmount minus the promo code discount
+	Amount         int           `db:"amount"`
+	OriginalAmount int           `db:"original_amount"`
+	PromoCodeUuid  *uuid.UUID    `db:"promo_code_uuid"`
+	Currency       string        `db:"currency"`
+	Status         PaymentStatus `db:"status"`
+	Provider       string        `db:"provider"`
+	ProviderRef    string        `db:"provider_ref"`
+	CreatedAt      time.Time     `db:"created_at"`
+	UpdatedAt      time.Time     `db:"updated_at"`
+}
+
+type LedgerDirection string
+
+const (
+	Debit  LedgerDirection = "debit"
+	Credit LedgerDirection = "credit"
+)
+
+// Ledger accounts. Money collected by the payment provider sits in
+// AccountProviderClearing until it is paid out, and is owed to experts
+// through AccountConsultationEscrow. The platform commission is moved to
+// AccountPlatformRevenue when an expert is paid out
+const (
+	AccountProviderClearing   = "provider_clearing"
+	AccountConsultationEscrow = "consultation_escrow"
+	AccountPlatformRevenue    = "platform_revenue"
+)
+
+type LedgerEntry struct {
+	Uuid              uuid.UUID       `db:"uuid"`
+	TransactionUuid   uuid.UUID       `db:"transaction_uuid"`
+	Account           string          `db:"account"`
+	OwnerUuid         *uuid.UUID      `db:"owner_uuid"`
+	Direction         LedgerDirection `db:"direction"`
+	Amount            int             `db:"amount"`
+	Currency          string          `db:"currency"`
+	PaymentIntentUuid *uuid.UUID      `db:"payment_intent_uuid"`
+	CreatedAt         time.Time       `db:"created_at"`
+}
+
+type PaymentWebhookEvent struct {
+	EventId           string    `db:"event_id"`
+	PaymentIntentUuid uuid.UUID `db:"payment_intent_uuid"`
+	Type              string    `db:"type"`
+	ReceivedAt        time.Time `db:"received_at"`
+}
+
+type RefundStatus string
+
+const (
+	RefundPending   RefundStatus = "pending"
+	RefundSucceeded RefundStatus = "succeeded"
+	RefundFailed    RefundStatus = "failed"
+)
+
+type Refund struct {
+	Uuid              uuid.UUID    `db:"uuid"`
+	PaymentIntentUuid uuid.UUID    `db:"payment_intent_uuid"`
+	Amount            int          `db:"amount"`
+	Currency          string       `db:"currency"`
+	Status            RefundStatus `db:"status"`
+	Reason            string       `db:"reason"`
+	InitiatedBy       *uuid.UUID   `db:"initiated_by"`
+	// Nil until the provider has accepted the refund
+	ProviderRef *string   `db:"provider_ref"`
+	CreatedAt   time.Time `db:"created_at"`
+	UpdatedAt   time.Time `db:"updated_at"`
+}
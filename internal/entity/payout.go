@@ -0,0 +1,66 @@
+package entity
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+type PayoutStatus string
+
+const (
+	PayoutPending PayoutStatus = "pending"
+	PayoutSent    PayoutStatus = "sent"
+	PayoutFailed  PayoutStatus = "failed"
+)
+
+type PayoutBatchStatus string
+
+const (
+	PayoutBatchOpen   PayoutBatchStatus = "open"
+	PayoutBatchClosed PayoutBatchStatus = "closed"
+)
+
+type PayoutBatch struct {
+	Uuid      uuid.UUID         `db:"uuid"`
+	PeriodEnd time.Time         `db:"period_end"`
+	Status    PayoutBatchStatus `db:"status"`
+	CreatedBy *uuid.UUID        `db:"created_by"`
+	CreatedAt time.Time         `db:"created_at"`
+}
+
+type Payout struct {
+	Uuid       uuid.UUID    `db:"uuid"`
+	BatchUuid  uuid.UUID    `db:"batch_uuid"`
+	ExpertUuid uuid.UUID    `db:"expert_uuid"`
+	Currency   string       `db:"currency"`
+	Gross      int          `db:"gross"`
+	Commission int          `db:"commission"`
+	Net        int          `db:"net"`
+	Status     PayoutStatus `db:"status"`
+	Reference  string       `db:"reference"`
+	SentAt     *time.Time   `db:"sent_at"`
+	Items      []PayoutItem `db:"-"`
+}
+
+type PayoutItem struct {
+	PayoutUuid        uuid.UUID `db:"payout_uuid"`
+	PaymentIntentUuid uuid.UUID `db:"payment_intent_uuid"`
+	Gross             int       `db:"gross"`
+	Commission        int       `db:"commission"`
+	Net               int       `db:"net"`
+}
+
+// A paid consultation whose meeting has already taken place, or a paid package purchase
+type EarningItem struct {
+	PaymentIntentUuid   uuid.UUID     `db:"payment_intent_uuid"`
+	ConsultationUuid    *uuid.UUID    `db:"consultation_uuid"`
+	PackagePurchaseUuid *uuid.UUID    `db:"package_purchase_uuid"`
+	ExpertUuid          uuid.UUID     `db:"expert_uuid"`
+	CompletedAt         time.Time     `db:"completed_at"`
+	Amount              int           `db:"amount"`
+	Refunded            int           `db:"refunded"`
+	Currency            string        `db:"currency"`
+	PayoutUuid          *uuid.UUID    `db:"payout_uuid"`
+	PayoutStatus        *PayoutStatus `db:"payout_status"`
+}
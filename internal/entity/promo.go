@@ -0,0 +1,31 @@
+package entity
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+type PromoKind string
+
+const (
+	PromoPercent PromoKind = "percent"
+	PromoFixed   PromoKind = "fixed"
+)
+
+type PromoCode struct {
+	Uuid uuid.UUID `db:"uuid"`
+	Code string    `db:"code"`
+	Kind PromoKind `db:"kind"`
+	// Percent off for PromoPercent, minor units of Currency for PromoFixed
+	Value     int        `db:"value"`
+	Currency  *string    `db:"currency"`
+	MaxUses   *int       `db:"max_uses"`
+	UsedCount int        `db:"used_count"`
+	ExpiresAt *time.Time `db:"expires_at"`
+	// Limits the code to consultations and packages of one expert
+	ExpertUuid *uuid.UUID `db:"expert_uuid"`
+	Active     bool       `db:"active"`
+	CreatedBy  *uuid.UUID `db:"created_by"`
+	CreatedAt  time.Time  `db:"created_at"`
+}
@@ -0,0 +1,24 @@
+package entity
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Categories form a tree, skills belong to one category
+type SkillCategory struct {
+	Uuid       uuid.UUID  `db:"uuid"`
+	Name       string     `db:"name"`
+	ParentUuid *uuid.UUID `db:"parent_uuid"`
+	CreatedAt  time.Time  `db:"created_at"`
+}
+
+type Skill struct {
+	Uuid         uuid.UUID `db:"uuid"`
+	CategoryUuid uuid.UUID `db:"category_uuid"`
+	Name         string    `db:"name"`
+	// Lower case synonyms that resolve to the skill
+	Aliases   []string  `db:"aliases"`
+	CreatedAt time.Time `db:"created_at"`
+}
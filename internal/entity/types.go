@@ -6,6 +6,9 @@ const (
 	Pending Status = iota
 	Approved
 	Rejected
+	// Consultation was approved and paid for
+	Scheduled
+	Cancelled
 )
 
 type Permission int
@@ -1,17 +1,45 @@
 package entity
 
-import "github.com/google/uuid"
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
 
 type User struct {
-	Uuid            uuid.UUID `db:"uuid"`
-	Roles           []string  `db:"roles"`
-	UserCredentials `db:"-"`
-	UserProfile     `db:"-"`
+	Uuid      uuid.UUID `db:"uuid"`
+	Roles     []string  `db:"roles"`
+	CreatedAt time.Time `db:"created_at"`
+	// Nil until the user signs in for the first time
+	LastLoginAt *time.Time `db:"last_login_at"`
+	// Soft deleted users are hidden until restored or purged
+	DeletedAt         *time.Time `db:"deleted_at"`
+	UserCredentials   `db:"-"`
+	UserProfile       `db:"-"`
+	AccountSuspension `db:"-"`
 }
 
 type UserCredentials struct {
 	Username string `db:"username"`
 	PassHash []byte `db:"pass_hash"`
+	// Access tokens issued at an older version are no longer accepted
+	TokenVersion int `db:"token_version"`
+}
+
+// Suspension of an account, all fields are nil while it was never suspended
+type AccountSuspension struct {
+	SuspendedAt *time.Time `db:"suspended_at"`
+	// Nil for permanent bans
+	SuspendedUntil   *time.Time `db:"suspended_until"`
+	SuspensionReason *string    `db:"suspension_reason"`
+	SuspendedBy      *uuid.UUID `db:"suspended_by"`
+	// How the user can appeal, shown to them together with the reason
+	AppealNote *string `db:"appeal_note"`
+}
+
+// Reports whether the suspension is in force at now. Temporary ones lapse on their own
+func (s *AccountSuspension) Active(now time.Time) bool {
+	return s.SuspendedAt != nil && (s.SuspendedUntil == nil || now.Before(*s.SuspendedUntil))
 }
 
 type UserProfile struct {
@@ -20,6 +48,27 @@ type UserProfile struct {
 	Phone                 string `db:"phone"`
 	ProfessionalField     string `db:"professional_field"`
 	ExperienceDescription string `db:"experience_description"`
+	// Content hash of the avatar, nil without one
+	AvatarHash *string `db:"avatar_hash"`
+	// Incremented on every change, serves as the profile's ETag
+	Version         int `db:"version"`
+	PrivacySettings `db:"-"`
+}
+
+type Visibility string
+
+const (
+	VisibilityPublic Visibility = "public"
+	// Only people sharing a consultation with the user
+	VisibilityParticipants Visibility = "participants"
+	VisibilityStaff        Visibility = "staff"
+)
+
+// Who besides the user and staff sees the profile fields
+type PrivacySettings struct {
+	EmailVisibility      Visibility `db:"email_visibility"`
+	PhoneVisibility      Visibility `db:"phone_visibility"`
+	ExperienceVisibility Visibility `db:"experience_visibility"`
 }
 
 type StaffMember struct {
@@ -0,0 +1,101 @@
+package imaging
+
+import (
+	"bytes"
+	"errors"
+	"image"
+	"image/color"
+	"image/draw"
+	_ "image/gif"
+	"image/jpeg"
+	_ "image/png"
+)
+
+var (
+	ErrUnsupportedFormat = errors.New("image must be a JPEG, PNG or GIF")
+	ErrInvalidDimensions = errors.New("image is too small or too large")
+)
+
+// Bounds checked before an image is decoded
+type Limits struct {
+	MinSide int
+	// Keeps decompression bombs from being decoded
+	MaxPixels int
+}
+
+// Decodes a JPEG, PNG or GIF image and rotates JPEG images upright according
+// to their EXIF orientation. Metadata is not kept
+func Decode(data []byte, limits Limits) (image.Image, error) {
+	config, format, err := image.DecodeConfig(bytes.NewReader(data))
+	if err != nil {
+		return nil, ErrUnsupportedFormat
+	}
+	if config.Width < limits.MinSide || config.Height < limits.MinSide ||
+		config.Width*config.Height > limits.MaxPixels {
+		return nil, ErrInvalidDimensions
+	}
+
+	img, _, err := image.Decode(bytes.NewReader(data))
+	if err != nil {
+		return nil, ErrUnsupportedFormat
+	}
+
+	if format == "jpeg" {
+		img = orient(img, jpegOrientation(data))
+	}
+
+	return img, nil
+}
+
+// Crops the center square of the image and scales it to size x size.
+// Downscaling averages the source pixels covered by each target pixel
+func Square(img image.Image, size int) *image.RGBA {
+	bounds := img.Bounds()
+	side := min(bounds.Dx(), bounds.Dy())
+	x0 := bounds.Min.X + (bounds.Dx()-side)/2
+	y0 := bounds.Min.Y + (bounds.Dy()-side)/2
+
+	// Transparent areas end up white instead of black in JPEG
+	src := image.NewRGBA(image.Rect(0, 0, side, side))
+	draw.Draw(src, src.Bounds(), image.NewUniform(color.White), image.Point{}, draw.Src)
+	draw.Draw(src, src.Bounds(), img, image.Pt(x0, y0), draw.Over)
+
+	dst := image.NewRGBA(image.Rect(0, 0, size, size))
+	for y := 0; y < size; y++ {
+		sy0 := y * side / size
+		sy1 := max((y+1)*side/size, sy0+1)
+		for x := 0; x < size; x++ {
+			sx0 := x * side / size
+			sx1 := max((x+1)*side/size, sx0+1)
+
+			var r, g, b, n int
+			for sy := sy0; sy < sy1; sy++ {
+				for sx := sx0; sx < sx1; sx++ {
+					i := src.PixOffset(sx, sy)
+					r += int(src.Pix[i])
+					g += int(src.Pix[i+1])
+					b += int(src.Pix[i+2])
+					n++
+				}
+			}
+
+			i := dst.PixOffset(x, y)
+			dst.Pix[i] = uint8(r / n)
+			dst.Pix[i+1] = uint8(g / n)
+			dst.Pix[i+2] = uint8(b / n)
+			dst.Pix[i+3] = 0xff
+		}
+	}
+
+	return dst
+}
+
+func EncodeJPEG(img image.Image) ([]byte, error) {
+	var buf bytes.Buffer
+	err := jpeg.Encode(&buf, img, &jpeg.Options{Quality: 85})
+	if err != nil {
+		return nil, err
+	}
+
+	return buf.Bytes(), nil
+}
@@ -0,0 +1,81 @@
+// Package mergepatch applies JSON Merge Patches (RFC 7396): members of the
+// patch replace those of the document, objects are merged recursively and
+// null removes a member
+package mergepatch
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+)
+
+// Media type of merge patch request bodies
+const ContentType = "application/merge-patch+json"
+
+var ErrInvalidPatch = errors.New("invalid merge patch")
+
+// Applies the patch to the JSON representation of value and decodes the result
+// into a new T. Removed members are left at their zero value. Fails with
+// ErrInvalidPatch when the patch is not a JSON object, names members T does not
+// have or gives them values of the wrong type
+func Apply[T any](value *T, patch []byte) (*T, error) {
+	document, err := json.Marshal(value)
+	if err != nil {
+		return nil, err
+	}
+
+	merged, err := Merge(document, patch)
+	if err != nil {
+		return nil, err
+	}
+
+	decoder := json.NewDecoder(bytes.NewReader(merged))
+	decoder.DisallowUnknownFields()
+	var patched T
+	if err := decoder.Decode(&patched); err != nil {
+		return nil, fmt.Errorf("%w: %s", ErrInvalidPatch, err)
+	}
+
+	return &patched, nil
+}
+
+// Applies the patch to the JSON document. Only object patches are accepted,
+// the whole document is never replaced
+func Merge(document []byte, patch []byte) ([]byte, error) {
+	var target any
+	if err := json.Unmarshal(document, &target); err != nil {
+		return nil, err
+	}
+
+	var changes any
+	if err := json.Unmarshal(patch, &changes); err != nil {
+		return nil, fmt.Errorf("%w: %s", ErrInvalidPatch, err)
+	}
+	if _, ok := changes.(map[string]any); !ok {
+		return nil, fmt.Errorf("%w: patch must be a JSON object", ErrInvalidPatch)
+	}
+
+	return json.Marshal(merge(target, changes))
+}
+
+func merge(target any, patch any) any {
+	changes, ok := patch.(map[string]any)
+	if !ok {
+		return patch
+	}
+
+	merged, ok := target.(map[string]any)
+	if !ok {
+		merged = make(map[string]any, len(changes))
+	}
+	for name, value := range changes {
+		if value == nil {
+			delete(merged, name)
+			continue
+		}
+		merged[name] = merge(merged[name], value)
+	}
+
+	return merged
+}
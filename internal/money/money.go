@@ -0,0 +1,54 @@
+package money
+
+import (
+	"strconv"
+	"strings"
+)
+
+// Currencies that are not divided into 100 minor units, see ISO 4217
+var exponents = map[string]int{
+	"BHD": 3, "IQD": 3, "JOD": 3, "KWD": 3, "LYD": 3, "OMR": 3, "TND": 3,
+	"BIF": 0, "CLP": 0, "DJF": 0, "GNF": 0, "ISK": 0, "JPY": 0, "KMF": 0, "KRW": 0,
+	"PYG": 0, "RWF": 0, "UGX": 0, "VND": 0, "VUV": 0, "XAF": 0, "XOF": 0, "XPF": 0,
+}
+
+// Reports whether code looks like an ISO 4217 alphabetic code
+func ValidCurrency(code string) bool {
+	if len(code) != 3 {
+		return false
+	}
+	for _, c := range code {
+		if c < 'A' || c > 'Z' {
+			return false
+		}
+	}
+	return true
+}
+
+// Number of digits after the decimal point in the currency's major unit
+func Exponent(currency string) int {
+	if exp, ok := exponents[currency]; ok {
+		return exp
+	}
+	return 2
+}
+
+// Formats an amount in minor units, e.g. 1050 USD as "10.50 USD"
+func Format(amount int, currency string) string {
+	exp := Exponent(currency)
+	if exp == 0 {
+		return strconv.Itoa(amount) + " " + currency
+	}
+
+	sign := ""
+	if amount < 0 {
+		sign = "-"
+		amount = -amount
+	}
+	digits := strconv.Itoa(amount)
+	if len(digits) <= exp {
+		digits = strings.Repeat("0", exp-len(digits)+1) + digits
+	}
+
+	return sign + digits[:len(digits)-exp] + "." + digits[len(digits)-exp:] + " " + currency
+}
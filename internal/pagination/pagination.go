@@ -0,0 +1,213 @@
+// Package pagination pages list queries by keyset cursors, falling back to
+// offsets for clients that jump to a page. Sorting is limited to the sort
+// keys an endpoint whitelists
+package pagination
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"net/url"
+	"strconv"
+
+	sq "github.com/Masterminds/squirrel"
+	"github.com/google/uuid"
+)
+
+const (
+	DefaultLimit = 20
+	MaxLimit     = 100
+)
+
+var (
+	ErrInvalidLimit  = errors.New("limit must be between 1 and 100 and offset must not be negative")
+	ErrInvalidSort   = errors.New("unknown sort or order")
+	ErrInvalidCursor = errors.New("invalid cursor")
+)
+
+// A sort key an endpoint allows
+type Sort struct {
+	// SQL expression sorted by, must never be NULL
+	Expr string
+	// SQL type of Expr, cursor values are cast to it
+	Type string
+	// Order when the order param is not given
+	Desc bool
+}
+
+// Position after the last item of a page. It is only valid for the sort it was made for
+type Cursor struct {
+	Sort string    `json:"s"`
+	Desc bool      `json:"d"`
+	Key  string    `json:"k"`
+	Id   uuid.UUID `json:"i"`
+}
+
+func (c *Cursor) Encode() string {
+	data, _ := json.Marshal(c)
+	return base64.RawURLEncoding.EncodeToString(data)
+}
+
+func DecodeCursor(encoded string) (*Cursor, error) {
+	data, err := base64.RawURLEncoding.DecodeString(encoded)
+	if err != nil {
+		return nil, ErrInvalidCursor
+	}
+
+	var cursor Cursor
+	err = json.Unmarshal(data, &cursor)
+	if err != nil || cursor.Id == uuid.Nil {
+		return nil, ErrInvalidCursor
+	}
+
+	return &cursor, nil
+}
+
+type Request struct {
+	Limit uint64
+	// Used when there is no cursor
+	Offset uint64
+	Cursor *Cursor
+	// Name of the sort key, part of the cursors of the page
+	SortName string
+	Sort     Sort
+	Desc     bool
+}
+
+// Reads the limit, offset, cursor, sort and order query params. sorts holds
+// the sort keys the endpoint allows, defaultSort is used without a sort param
+func FromQuery(query url.Values, sorts map[string]Sort, defaultSort string) (*Request, error) {
+	req := &Request{
+		Limit:    DefaultLimit,
+		SortName: defaultSort,
+	}
+
+	if query.Get("limit") != "" {
+		limit, err := strconv.ParseUint(query.Get("limit"), 10, 64)
+		if err != nil || limit < 1 || limit > MaxLimit {
+			return nil, ErrInvalidLimit
+		}
+		req.Limit = limit
+	}
+	if query.Get("offset") != "" {
+		offset, err := strconv.ParseUint(query.Get("offset"), 10, 64)
+		if err != nil {
+			return nil, ErrInvalidLimit
+		}
+		req.Offset = offset
+	}
+
+	if query.Get("sort") != "" {
+		req.SortName = query.Get("sort")
+	}
+	sort, ok := sorts[req.SortName]
+	if !ok {
+		return nil, ErrInvalidSort
+	}
+	req.Sort = sort
+	req.Desc = sort.Desc
+	switch query.Get("order") {
+	case "":
+	case "asc":
+		req.Desc = false
+	case "desc":
+		req.Desc = true
+	default:
+		return nil, ErrInvalidSort
+	}
+
+	if query.Get("cursor") != "" {
+		cursor, err := DecodeCursor(query.Get("cursor"))
+		if err != nil {
+			return nil, err
+		}
+		if cursor.Sort != req.SortName || cursor.Desc != req.Desc {
+			return nil, ErrInvalidCursor
+		}
+		req.Cursor = cursor
+	}
+
+	return req, nil
+}
+
+// Orders and limits the query, selecting the sort key as sort_key. One row
+// more than the limit is fetched so NewPage knows whether there is a next page.
+// idExpr is the unique id the sort is broken ties with
+func (r *Request) Apply(query sq.SelectBuilder, idExpr string) sq.SelectBuilder {
+	direction, comparison := "ASC", ">"
+	if r.Desc {
+		direction, comparison = "DESC", "<"
+	}
+
+	query = query.Column(r.Sort.Expr + "::text AS sort_key")
+	if r.Cursor != nil {
+		query = query.Where(
+			"("+r.Sort.Expr+", "+idExpr+") "+comparison+" (?::text::"+r.Sort.Type+", ?)",
+			r.Cursor.Key, r.Cursor.Id,
+		)
+	} else if r.Offset > 0 {
+		query = query.Offset(r.Offset)
+	}
+
+	return query.
+		OrderBy(r.Sort.Expr+" "+direction, idExpr+" "+direction).
+		Limit(r.Limit + 1)
+}
+
+// Counts the rows of a query that is not ordered or limited yet
+func Count(query sq.SelectBuilder) sq.SelectBuilder {
+	psql := sq.StatementBuilder.PlaceholderFormat(sq.Dollar)
+	return psql.Select("COUNT(*)").FromSelect(query, "counted")
+}
+
+type Page[T any] struct {
+	Items []T `json:"items"`
+	// Nil on the last page
+	NextCursor *string `json:"nextCursor"`
+	Total      int     `json:"total"`
+}
+
+// Row of a query built with Apply
+type Row[T any] struct {
+	Item    T
+	Id      uuid.UUID
+	SortKey string
+}
+
+// Builds a page from the rows of a query built with Apply
+func NewPage[T any](req *Request, rows []Row[T], total int) *Page[T] {
+	page := &Page[T]{
+		Items: make([]T, 0, min(len(rows), int(req.Limit))),
+		Total: total,
+	}
+
+	for i, row := range rows {
+		if uint64(i) == req.Limit {
+			last := rows[i-1]
+			next := (&Cursor{
+				Sort: req.SortName,
+				Desc: req.Desc,
+				Key:  last.SortKey,
+				Id:   last.Id,
+			}).Encode()
+			page.NextCursor = &next
+			break
+		}
+		page.Items = append(page.Items, row.Item)
+	}
+
+	return page
+}
+
+// Converts the items of a page, for building DTOs
+func Map[T any, D any](page *Page[T], convert func(*T) *D) *Page[D] {
+	mapped := &Page[D]{
+		Items:      make([]D, 0, len(page.Items)),
+		NextCursor: page.NextCursor,
+		Total:      page.Total,
+	}
+	for i := range page.Items {
+		mapped.Items = append(mapped.Items, *convert(&page.Items[i]))
+	}
+	return mapped
+}
@@ -0,0 +1,200 @@
+package paymentprovider
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+
+	"github.com/bogdanshibilov/mindflowbackend/internal/entity"
+)
+
+// Failed webhook deliveries are retried with a doubling backoff
+const (
+	fakeDeliveryAttempts = 5
+	fakeRetryBackoff     = time.Second
+)
+
+// Payment methods understood by the fake provider
+const (
+	FakeMethodSuccess = "fake_card_success"
+	FakeMethodDecline = "fake_card_decline"
+	FakeMethodAsync   = "fake_card_async"
+)
+
+type fakeIntent struct {
+	amount   int
+	currency string
+	refunded int
+}
+
+// Fake is an in-process provider for local development. It never moves real
+// money and delivers signed webhooks to the registered sink: immediately for
+// successful and declined payments, after the configured delay for async
+// payments and refunds
+type Fake struct {
+	secret []byte
+	delay  time.Duration
+
+	mu      sync.Mutex
+	intents map[string]fakeIntent
+	sink    WebhookSink
+}
+
+func NewFake(secret string, delay time.Duration) *Fake {
+	return &Fake{
+		secret:  []byte(secret),
+		delay:   delay,
+		intents: make(map[string]fakeIntent),
+	}
+}
+
+func (f *Fake) SetWebhookSink(sink WebhookSink) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	f.sink = sink
+}
+
+func (f *Fake) Name() string {
+	return "fake"
+}
+
+func (f *Fake) CreateIntent(_ context.Context, amount int, currency string, _ string) (string, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	ref := "fake_pi_" + uuid.NewString()
+	f.intents[ref] = fakeIntent{
+		amount:   amount,
+		currency: currency,
+	}
+
+	return ref, nil
+}
+
+func (f *Fake) UpdateAmount(_ context.Context, providerRef string, amount int) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	intent, ok := f.intents[providerRef]
+	if !ok {
+		return ErrUnknownIntent
+	}
+	intent.amount = amount
+	f.intents[providerRef] = intent
+
+	return nil
+}
+
+func (f *Fake) Confirm(_ context.Context, providerRef string, paymentMethod string) (entity.PaymentStatus, error) {
+	f.mu.Lock()
+	intent, ok := f.intents[providerRef]
+	f.mu.Unlock()
+	if !ok {
+		return "", ErrUnknownIntent
+	}
+
+	switch paymentMethod {
+	case FakeMethodSuccess, "":
+		f.deliver(0, EventPaymentSucceeded, providerRef, intent.amount)
+		return entity.PaymentProcessing, nil
+	case FakeMethodAsync:
+		f.deliver(f.delay, EventPaymentSucceeded, providerRef, intent.amount)
+		return entity.PaymentProcessing, nil
+	case FakeMethodDecline:
+		f.deliver(0, EventPaymentFailed, providerRef, intent.amount)
+		return entity.PaymentFailed, nil
+	default:
+		return "", ErrUnknownPaymentMethod
+	}
+}
+
+func (f *Fake) Refund(_ context.Context, providerRef string, amount int) (string, error) {
+	f.mu.Lock()
+	intent, ok := f.intents[providerRef]
+	if !ok {
+		f.mu.Unlock()
+		return "", ErrUnknownIntent
+	}
+	if intent.refunded+amount > intent.amount {
+		f.mu.Unlock()
+		return "", ErrRefundExceedsPayment
+	}
+	intent.refunded += amount
+	f.intents[providerRef] = intent
+	f.mu.Unlock()
+
+	refundRef := "fake_re_" + uuid.NewString()
+	f.deliverEvent(f.delay, WebhookEvent{
+		Type:        EventRefundSucceeded,
+		ProviderRef: providerRef,
+		RefundRef:   refundRef,
+		Amount:      amount,
+	})
+
+	return refundRef, nil
+}
+
+func (f *Fake) ParseWebhook(payload []byte, signature string) (*WebhookEvent, error) {
+	expected, err := hex.DecodeString(signature)
+	if err != nil || !hmac.Equal(expected, f.sign(payload)) {
+		return nil, ErrInvalidSignature
+	}
+
+	var event WebhookEvent
+	if err := json.Unmarshal(payload, &event); err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrInvalidPayload, err)
+	}
+
+	return &event, nil
+}
+
+func (f *Fake) deliver(delay time.Duration, eventType EventType, providerRef string, amount int) {
+	f.deliverEvent(delay, WebhookEvent{
+		Type:        eventType,
+		ProviderRef: providerRef,
+		Amount:      amount,
+	})
+}
+
+func (f *Fake) deliverEvent(delay time.Duration, event WebhookEvent) {
+	f.mu.Lock()
+	sink := f.sink
+	f.mu.Unlock()
+	if sink == nil {
+		return
+	}
+
+	event.Id = "fake_evt_" + uuid.NewString()
+	payload, err := json.Marshal(event)
+	if err != nil {
+		return
+	}
+	signature := hex.EncodeToString(f.sign(payload))
+
+	go func() {
+		time.Sleep(delay)
+		backoff := fakeRetryBackoff
+		for attempt := 1; attempt < fakeDeliveryAttempts; attempt++ {
+			if sink(payload, signature) == nil {
+				return
+			}
+			time.Sleep(backoff)
+			backoff *= 2
+		}
+		_ = sink(payload, signature)
+	}()
+}
+
+func (f *Fake) sign(payload []byte) []byte {
+	mac := hmac.New(sha256.New, f.secret)
+	mac.Write(payload)
+	return mac.Sum(nil)
+}
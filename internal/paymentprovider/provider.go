@@ -0,0 +1,50 @@
+package paymentprovider
+
+import (
+	"context"
+	"errors"
+
+	"github.com/bogdanshibilov/mindflowbackend/internal/entity"
+)
+
+var (
+	ErrInvalidSignature     = errors.New("invalid webhook signature")
+	ErrInvalidPayload       = errors.New("invalid webhook payload")
+	ErrUnknownIntent        = errors.New("unknown payment intent")
+	ErrUnknownPaymentMethod = errors.New("unknown payment method")
+	ErrRefundExceedsPayment = errors.New("refund exceeds the captured amount")
+)
+
+type EventType string
+
+const (
+	EventPaymentSucceeded EventType = "payment.succeeded"
+	EventPaymentFailed    EventType = "payment.failed"
+	EventRefundSucceeded  EventType = "refund.succeeded"
+	EventRefundFailed     EventType = "refund.failed"
+)
+
+type WebhookEvent struct {
+	Id          string    `json:"id"`
+	Type        EventType `json:"type"`
+	ProviderRef string    `json:"providerRef"`
+	RefundRef   string    `json:"refundRef,omitempty"`
+	Amount      int       `json:"amount"`
+}
+
+// Receives raw webhook deliveries from providers that push events in-process.
+// Deliveries that fail are retried
+type WebhookSink func(payload []byte, signature string) error
+
+// PaymentProvider is implemented by every payment processor MindFlow can charge through.
+// Providers report the final outcome of a payment asynchronously via webhooks,
+// Confirm only tells whether the payment was accepted for processing
+type PaymentProvider interface {
+	Name() string
+	CreateIntent(ctx context.Context, amount int, currency string, reference string) (providerRef string, err error)
+	// Changes the amount of an intent that has not been paid yet
+	UpdateAmount(ctx context.Context, providerRef string, amount int) error
+	Confirm(ctx context.Context, providerRef string, paymentMethod string) (entity.PaymentStatus, error)
+	Refund(ctx context.Context, providerRef string, amount int) (refundRef string, err error)
+	ParseWebhook(payload []byte, signature string) (*WebhookEvent, error)
+}
@@ -0,0 +1,234 @@
+package recommend
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/google/uuid"
+
+	"github.com/bogdanshibilov/mindflowbackend/internal/entity"
+)
+
+type Factor string
+
+const (
+	FactorSkills       Factor = "skills"
+	FactorText         Factor = "text"
+	FactorPrice        Factor = "price"
+	FactorRating       Factor = "rating"
+	FactorAvailability Factor = "availability"
+)
+
+var Factors = []Factor{FactorSkills, FactorText, FactorPrice, FactorRating, FactorAvailability}
+
+// Weights of the factors in the score, they do not need to add up to one
+type Weights map[Factor]float64
+
+var DefaultWeights = Weights{
+	FactorSkills:       0.35,
+	FactorText:         0.25,
+	FactorPrice:        0.15,
+	FactorRating:       0.15,
+	FactorAvailability: 0.10,
+}
+
+const (
+	// Ratings are pulled towards the prior until experts have a few reviews
+	ratingPrior      = 3.5
+	ratingPriorCount = 3
+	// Weekly minutes of availability that earn the full availability score
+	fullAvailability = 20 * 60
+)
+
+// What is known about the mentee asking for recommendations
+type Mentee struct {
+	Uuid              uuid.UUID
+	ProfessionalField string
+	Description       string
+	// Skills the professional field resolves to
+	SkillUuids []uuid.UUID
+	// Highest price in base currency minor units, nil when unknown
+	Budget *int
+}
+
+type Candidate struct {
+	// Expert with skills, rating and review count filled
+	Expert entity.Expert
+	// Price in base currency minor units, nil when the currency has no exchange rate
+	BasePrice    *int
+	Availability []entity.ExpertAvailability
+}
+
+type Reason struct {
+	Factor Factor
+	// From 0 to 1, before weighting
+	Score float64
+	Text  string
+}
+
+type Recommendation struct {
+	Expert entity.Expert
+	// Weighted average of the factor scores, from 0 to 1
+	Score float64
+	// Every scored factor, the largest contribution first
+	Reasons []Reason
+}
+
+// Scores the candidates for the mentee and sorts them by score. Factors that
+// cannot be judged, like price without a budget, are left out of the average
+func Rank(mentee *Mentee, candidates []Candidate, weights Weights) []Recommendation {
+	documents := make([][]string, 0, len(candidates)+1)
+	menteeTerms := tokenize(mentee.ProfessionalField + " " + mentee.Description)
+	documents = append(documents, menteeTerms)
+	expertTerms := make([][]string, 0, len(candidates))
+	for _, candidate := range candidates {
+		terms := tokenize(candidate.Expert.HelpDescription + " " + candidate.Expert.ExperienceDescription)
+		expertTerms = append(expertTerms, terms)
+		documents = append(documents, terms)
+	}
+	idf := inverseDocumentFrequency(documents)
+	menteeVector := weigh(menteeTerms, idf)
+
+	menteeSkills := make(map[uuid.UUID]bool, len(mentee.SkillUuids))
+	for _, skillUuid := range mentee.SkillUuids {
+		menteeSkills[skillUuid] = true
+	}
+
+	recommendations := make([]Recommendation, 0, len(candidates))
+	for i, candidate := range candidates {
+		reasons := []Reason{
+			skillsReason(mentee, menteeSkills, &candidate.Expert),
+			textReason(menteeVector, weigh(expertTerms[i], idf)),
+			ratingReason(&candidate.Expert),
+			availabilityReason(candidate.Availability),
+		}
+		if mentee.Budget != nil && candidate.BasePrice != nil {
+			reasons = append(reasons, priceReason(*mentee.Budget, *candidate.BasePrice))
+		}
+
+		var score, total float64
+		for _, reason := range reasons {
+			score += weights[reason.Factor] * reason.Score
+			total += weights[reason.Factor]
+		}
+		if total > 0 {
+			score /= total
+		}
+
+		sort.SliceStable(reasons, func(a, b int) bool {
+			return weights[reasons[a].Factor]*reasons[a].Score > weights[reasons[b].Factor]*reasons[b].Score
+		})
+
+		recommendations = append(recommendations, Recommendation{
+			Expert:  candidate.Expert,
+			Score:   score,
+			Reasons: reasons,
+		})
+	}
+
+	sort.SliceStable(recommendations, func(a, b int) bool {
+		if recommendations[a].Score != recommendations[b].Score {
+			return recommendations[a].Score > recommendations[b].Score
+		}
+		return recommendations[a].Expert.UserUuid.String() < recommendations[b].Expert.UserUuid.String()
+	})
+
+	return recommendations
+}
+
+// Share of the mentee's skills the expert has. Experts in the same
+// professional field score at least as much as the overlap of its words
+func skillsReason(mentee *Mentee, menteeSkills map[uuid.UUID]bool, expert *entity.Expert) Reason {
+	var shared []string
+	for _, skill := range expert.Skills {
+		if menteeSkills[skill.Uuid] {
+			shared = append(shared, skill.Name)
+		}
+	}
+
+	reason := Reason{Factor: FactorSkills, Text: "No shared skills"}
+	if len(menteeSkills) > 0 {
+		reason.Score = float64(len(shared)) / float64(len(menteeSkills))
+	}
+	if len(shared) > 0 {
+		reason.Text = "Has your skills " + strings.Join(shared, ", ")
+	}
+
+	menteeField := strings.ToLower(strings.TrimSpace(mentee.ProfessionalField))
+	expertField := strings.ToLower(strings.TrimSpace(expert.ProfessionalField))
+	if menteeField == "" || expertField == "" {
+		return reason
+	}
+	if menteeField == expertField {
+		reason.Score = 1
+		if len(shared) == 0 {
+			reason.Text = "Works in your field " + expert.ProfessionalField
+		}
+		return reason
+	}
+	if overlap := jaccard(tokenize(menteeField), tokenize(expertField)); overlap > reason.Score {
+		reason.Score = overlap
+		if len(shared) == 0 {
+			reason.Text = "Works in a related field " + expert.ProfessionalField
+		}
+	}
+
+	return reason
+}
+
+func textReason(mentee, expert vector) Reason {
+	similarity, terms := cosine(mentee, expert)
+	reason := Reason{Factor: FactorText, Score: similarity, Text: "Help description does not match your experience"}
+	if len(terms) > 0 {
+		reason.Text = "Help description mentions " + strings.Join(terms, ", ")
+	}
+	return reason
+}
+
+// Full score within the budget, falling to zero at twice the budget
+func priceReason(budget int, price int) Reason {
+	if price <= budget {
+		return Reason{Factor: FactorPrice, Score: 1, Text: "Within your budget"}
+	}
+	score := 0.0
+	if budget > 0 {
+		score = max(0, 1-float64(price-budget)/float64(budget))
+	}
+	return Reason{
+		Factor: FactorPrice,
+		Score:  score,
+		Text:   fmt.Sprintf("%d%% above your budget", (price-budget)*100/max(budget, 1)),
+	}
+}
+
+func ratingReason(expert *entity.Expert) Reason {
+	var sum float64
+	if expert.Rating != nil {
+		sum = *expert.Rating * float64(expert.ReviewCount)
+	}
+	smoothed := (sum + ratingPrior*ratingPriorCount) / float64(expert.ReviewCount+ratingPriorCount)
+
+	reason := Reason{Factor: FactorRating, Score: (smoothed - 1) / 4, Text: "No reviews yet"}
+	if expert.Rating != nil {
+		reason.Text = fmt.Sprintf("Rated %.1f from %d reviews", *expert.Rating, expert.ReviewCount)
+	}
+	return reason
+}
+
+func availabilityReason(slots []entity.ExpertAvailability) Reason {
+	minutes := 0
+	days := make(map[int]bool)
+	for _, slot := range slots {
+		minutes += slot.EndMinute - slot.StartMinute
+		days[slot.Weekday] = true
+	}
+	if minutes == 0 {
+		return Reason{Factor: FactorAvailability, Text: "No weekly availability set"}
+	}
+	return Reason{
+		Factor: FactorAvailability,
+		Score:  min(1, float64(minutes)/fullAvailability),
+		Text:   fmt.Sprintf("Available %d days a week", len(days)),
+	}
+}
@@ -0,0 +1,139 @@
+package recommend
+
+import (
+	"math"
+	"sort"
+	"strings"
+	"unicode"
+)
+
+// Terms the text similarity reports as shared at most
+const maxSharedTerms = 3
+
+var stopWords = map[string]bool{
+	"a": true, "about": true, "after": true, "all": true, "also": true, "am": true, "an": true,
+	"and": true, "any": true, "are": true, "as": true, "at": true, "be": true, "been": true,
+	"but": true, "by": true, "can": true, "do": true, "for": true, "from": true, "get": true,
+	"have": true, "help": true, "how": true, "i": true, "if": true, "in": true, "into": true,
+	"is": true, "it": true, "its": true, "me": true, "more": true, "my": true, "not": true,
+	"of": true, "on": true, "or": true, "our": true, "so": true, "some": true, "than": true,
+	"that": true, "the": true, "their": true, "them": true, "then": true, "there": true,
+	"these": true, "they": true, "this": true, "to": true, "up": true, "us": true, "was": true,
+	"we": true, "were": true, "what": true, "when": true, "which": true, "who": true,
+	"will": true, "with": true, "would": true, "you": true, "your": true, "years": true,
+	"year": true, "want": true, "like": true, "work": true, "working": true,
+}
+
+type vector map[string]float64
+
+// Splits text into lower case terms without stop words. Plurals are folded
+// into the singular so "services" matches "service"
+func tokenize(text string) []string {
+	fields := strings.FieldsFunc(strings.ToLower(text), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r) && r != '+' && r != '#'
+	})
+
+	terms := make([]string, 0, len(fields))
+	for _, field := range fields {
+		if len([]rune(field)) < 2 || stopWords[field] {
+			continue
+		}
+		if len(field) > 4 && strings.HasSuffix(field, "s") && !strings.HasSuffix(field, "ss") {
+			field = strings.TrimSuffix(field, "s")
+		}
+		terms = append(terms, field)
+	}
+	return terms
+}
+
+func inverseDocumentFrequency(documents [][]string) map[string]float64 {
+	frequency := make(map[string]int)
+	for _, document := range documents {
+		seen := make(map[string]bool)
+		for _, term := range document {
+			if !seen[term] {
+				seen[term] = true
+				frequency[term]++
+			}
+		}
+	}
+
+	idf := make(map[string]float64, len(frequency))
+	for term, count := range frequency {
+		idf[term] = math.Log(1 + float64(len(documents))/float64(count))
+	}
+	return idf
+}
+
+// Weighs terms by sublinear term frequency times inverse document frequency
+func weigh(terms []string, idf map[string]float64) vector {
+	counts := make(map[string]int)
+	for _, term := range terms {
+		counts[term]++
+	}
+
+	v := make(vector, len(counts))
+	for term, count := range counts {
+		v[term] = (1 + math.Log(float64(count))) * idf[term]
+	}
+	return v
+}
+
+// Returns the cosine similarity of the vectors and the shared terms that
+// contribute the most to it
+func cosine(a, b vector) (float64, []string) {
+	var dot, normA, normB float64
+	contributions := make(map[string]float64)
+	for term, weight := range a {
+		normA += weight * weight
+		if other, ok := b[term]; ok {
+			dot += weight * other
+			contributions[term] = weight * other
+		}
+	}
+	for _, weight := range b {
+		normB += weight * weight
+	}
+	if dot == 0 {
+		return 0, nil
+	}
+
+	shared := make([]string, 0, len(contributions))
+	for term := range contributions {
+		shared = append(shared, term)
+	}
+	sort.Slice(shared, func(i, j int) bool {
+		if contributions[shared[i]] != contributions[shared[j]] {
+			return contributions[shared[i]] > contributions[shared[j]]
+		}
+		return shared[i] < shared[j]
+	})
+	if len(shared) > maxSharedTerms {
+		shared = shared[:maxSharedTerms]
+	}
+
+	return dot / (math.Sqrt(normA) * math.Sqrt(normB)), shared
+}
+
+func jaccard(a, b []string) float64 {
+	setA := make(map[string]bool, len(a))
+	for _, term := range a {
+		setA[term] = true
+	}
+	setB := make(map[string]bool, len(b))
+	for _, term := range b {
+		setB[term] = true
+	}
+
+	intersection := 0
+	for term := range setA {
+		if setB[term] {
+			intersection++
+		}
+	}
+	union := len(setA) + len(setB) - intersection
+	if union == 0 {
+		return 0
+	}
+	return float64(intersection) / float64(union)
+}
@@ -0,0 +1,112 @@
+package auditrepo
+
+import (
+	"context"
+	"fmt"
+
+	sq "github.com/Masterminds/squirrel"
+	"github.com/jackc/pgx/v5"
+
+	"github.com/bogdanshibilov/mindflowbackend/internal/db/postgres"
+	"github.com/bogdanshibilov/mindflowbackend/internal/entity"
+	"github.com/bogdanshibilov/mindflowbackend/internal/pagination"
+)
+
+type Repo struct {
+	Db postgres.Db
+}
+
+// Sort keys of admin action lists
+var Sorts = map[string]pagination.Sort{
+	"created": {Expr: "admin_action.created_at", Type: "timestamp", Desc: true},
+}
+
+// Stores the action and sets its uuid and creation time
+func (r *Repo) Record(ctx context.Context, action *entity.AdminAction) error {
+	const op = "repository.audit.Record"
+
+	psql := sq.StatementBuilder.PlaceholderFormat(sq.Dollar)
+	sql, args, err := psql.Insert("admin_action").
+		Columns(
+			"admin_uuid",
+			"action",
+			"user_uuids",
+			"requested",
+			"details",
+		).
+		Values(
+			action.AdminUuid,
+			action.Action,
+			action.UserUuids,
+			action.Requested,
+			action.Details,
+		).
+		Suffix("RETURNING uuid, created_at").
+		ToSql()
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	err = r.Db.QueryRow(ctx, sql, args...).Scan(&action.Uuid, &action.CreatedAt)
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	return nil
+}
+
+func (r *Repo) Actions(ctx context.Context, page *pagination.Request) (*pagination.Page[entity.AdminAction], error) {
+	const op = "repository.audit.Actions"
+
+	psql := sq.StatementBuilder.PlaceholderFormat(sq.Dollar)
+	actionsQuery := psql.Select(
+		"uuid",
+		"admin_uuid",
+		"action",
+		"user_uuids",
+		"requested",
+		"details",
+		"created_at",
+	).
+		From("admin_action")
+
+	countSql, countArgs, err := pagination.Count(actionsQuery).ToSql()
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+	sql, args, err := page.Apply(actionsQuery, "admin_action.uuid").ToSql()
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	var total int
+	err = r.Db.QueryRow(ctx, countSql, countArgs...).Scan(&total)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	rows, err := r.Db.Query(ctx, sql, args...)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+	actionRows, err := pgx.CollectRows(rows, pgx.RowToStructByNameLax[actionRow])
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	pageRows := make([]pagination.Row[entity.AdminAction], 0, len(actionRows))
+	for _, row := range actionRows {
+		pageRows = append(pageRows, pagination.Row[entity.AdminAction]{
+			Item:    row.AdminAction,
+			Id:      row.Uuid,
+			SortKey: row.SortKey,
+		})
+	}
+
+	return pagination.NewPage(page, pageRows, total), nil
+}
+
+type actionRow struct {
+	entity.AdminAction
+	SortKey string `db:"sort_key"`
+}
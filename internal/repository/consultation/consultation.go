@@ -13,25 +13,72 @@ import (
 
 	"github.com/bogdanshibilov/mindflowbackend/internal/db/postgres"
 	"github.com/bogdanshibilov/mindflowbackend/internal/entity"
+	"github.com/bogdanshibilov/mindflowbackend/internal/pagination"
+	"github.com/bogdanshibilov/mindflowbackend/internal/tenant"
 )
 
 type Repo struct {
 	Db postgres.Db
 }
 
-func (r *Repo) CreateConsultation(ctx context.Context, consult *entity.Consultation) error {
+// Creates a consultation at the expert's current price, or, when
+// PackagePurchaseUuid is set, consumes one credit of that purchase instead.
+// The expert must be visible in the mentee's scope and the consultation
+// takes the expert's organization
+func (r *Repo) CreateConsultation(ctx context.Context, scope tenant.Scope, consult *entity.Consultation) error {
 	const op = "repository.consultation.CreateConsultation"
 
 	psql := sq.StatementBuilder.PlaceholderFormat(sq.Dollar)
-	insertConsultSql, insertConsultArgs, err := psql.Insert("consultation").
+	insertConsult := psql.Insert("consultation").
 		Columns(
 			"expert_uuid",
 			"mentee_uuid",
+			"price_amount",
+			"price_currency",
+			"package_purchase_uuid",
+			"organization_uuid",
 		).
-		Values(
-			consult.ExpertUuid,
-			consult.MenteeUuid,
-		).
+		Suffix("RETURNING uuid, price_amount, price_currency, organization_uuid")
+	if consult.PackagePurchaseUuid == nil {
+		// The consultation keeps the expert's price at booking time
+		insertConsult = insertConsult.Select(
+			psql.Select("user_uuid").
+				Column(sq.Expr("?::uuid", consult.MenteeUuid)).
+				Columns("price", "currency").
+				Column("NULL::uuid").
+				Column("organization_uuid").
+				From("expert_information").
+				Where("user_uuid IN (?)", consult.ExpertUuid),
+		)
+	} else {
+		// Prepaid with a package credit
+		insertConsult = insertConsult.Select(
+			psql.Select("expert_uuid", "mentee_uuid").
+				Column("0").
+				Columns("currency", "uuid").
+				Column("(SELECT organization_uuid FROM expert_information WHERE expert_information.user_uuid = package_purchase.expert_uuid)").
+				From("package_purchase").
+				Where("uuid IN (?)", *consult.PackagePurchaseUuid),
+		)
+	}
+	insertConsultSql, insertConsultArgs, err := insertConsult.ToSql()
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	// Deleted, paused and suspended experts take no bookings, whether the expert
+	// profile or the whole account is suspended. Pauses end at paused_until
+	bookableSql, bookableArgs, err := psql.Select("1").
+		From("expert_information").
+		Where("user_uuid IN (?)", consult.ExpertUuid).
+		Where("deleted_at IS NULL").
+		Where("suspended_at IS NULL").
+		Where("(paused_at IS NULL OR paused_until <= now())").
+		Where("NOT EXISTS (SELECT 1 FROM users WHERE users.uuid = expert_information.user_uuid" +
+			" AND users.suspended_at IS NOT NULL AND (users.suspended_until IS NULL OR users.suspended_until > now()))").
+		Where(scope.Where("organization_uuid")).
+		Prefix("SELECT EXISTS (").
+		Suffix(")").
 		ToSql()
 	if err != nil {
 		return fmt.Errorf("%s: %w", op, err)
@@ -49,27 +96,50 @@ func (r *Repo) CreateConsultation(ctx context.Context, consult *entity.Consultat
 		}
 	}()
 
-	_, err = tx.Exec(ctx, insertConsultSql, insertConsultArgs...)
+	var bookable bool
+	err = tx.QueryRow(ctx, bookableSql, bookableArgs...).Scan(&bookable)
 	if err != nil {
 		return fmt.Errorf("%s: %w", op, err)
 	}
-
-	selectInsertedConsultSql, selectInsertedConsultArgs, err := psql.Select("uuid").
-		From("consultation").
-		Where(
-			"expert_uuid IN (?) AND mentee_uuid IN (?)",
-			consult.ExpertUuid,
-			consult.MenteeUuid,
-		).
-		ToSql()
-	if err != nil {
+	if !bookable {
+		err = ErrExpertUnavailable
 		return fmt.Errorf("%s: %w", op, err)
 	}
+
+	if consult.PackagePurchaseUuid != nil {
+		var useCreditSql string
+		var useCreditArgs []any
+		useCreditSql, useCreditArgs, err = psql.Update("package_purchase").
+			Set("sessions_used", sq.Expr("sessions_used + 1")).
+			Where("uuid IN (?)", *consult.PackagePurchaseUuid).
+			Where("mentee_uuid IN (?)", consult.MenteeUuid).
+			Where("expert_uuid IN (?)", consult.ExpertUuid).
+			Where("status IN (?)", entity.PurchaseActive).
+			Where("expires_at > now()").
+			Where("sessions_used < sessions_total").
+			ToSql()
+		if err != nil {
+			return fmt.Errorf("%s: %w", op, err)
+		}
+
+		var tag pgconn.CommandTag
+		tag, err = tx.Exec(ctx, useCreditSql, useCreditArgs...)
+		if err != nil {
+			return fmt.Errorf("%s: %w", op, err)
+		}
+		if tag.RowsAffected() == 0 {
+			err = ErrNoPackageCredits
+			return fmt.Errorf("%s: %w", op, err)
+		}
+	}
+
 	var consultUuid uuid.UUID
-	err = tx.QueryRow(ctx, selectInsertedConsultSql, selectInsertedConsultArgs...).Scan(&consultUuid)
+	err = tx.QueryRow(ctx, insertConsultSql, insertConsultArgs...).
+		Scan(&consultUuid, &consult.PriceAmount, &consult.PriceCurrency, &consult.OrganizationUuid)
 	if err != nil {
 		return fmt.Errorf("%s: %w", op, err)
 	}
+	consult.Uuid = consultUuid
 
 	insertApplicationSql, insertApplicationArgs, err := psql.Insert("consultation_application").
 		Columns(
@@ -93,13 +163,24 @@ func (r *Repo) CreateConsultation(ctx context.Context, consult *entity.Consultat
 	return nil
 }
 
-func (r *Repo) Consultations(ctx context.Context) ([]entity.Consultation, error) {
+// Sort keys of consultation lists
+var Sorts = map[string]pagination.Sort{
+	"submitted": {Expr: "COALESCE(consultation_application.submitted_at, 'epoch')", Type: "timestamp", Desc: true},
+}
+
+// Returns consultation applications visible in the scope waiting for review
+func (r *Repo) Consultations(
+	ctx context.Context,
+	scope tenant.Scope,
+	page *pagination.Request,
+) (*pagination.Page[entity.Consultation], error) {
 	const op = "repository.consultation.Consultations"
 
 	psql := sq.StatementBuilder.PlaceholderFormat(sq.Dollar)
-	sql, args, err := psql.Select(
+	consultationsQuery := psql.Select(
 		"consultation.uuid AS uuid",
 		"mentee_uuid",
+		"organization_uuid",
 		"status",
 		"mentee_questions",
 		"submitted_at",
@@ -107,39 +188,79 @@ func (r *Repo) Consultations(ctx context.Context) ([]entity.Consultation, error)
 		From("consultation").
 		InnerJoin("consultation_application ON consultation.uuid = consultation_application.consultation_uuid").
 		Where("status IN (?)", entity.Pending).
-		ToSql()
+		Where(scope.Where("organization_uuid"))
+
+	return r.consultationsPage(ctx, op, consultationsQuery, page)
+}
+
+// Returns every consultation of the organization
+func (r *Repo) ByOrganization(
+	ctx context.Context,
+	orgUuid uuid.UUID,
+	page *pagination.Request,
+) (*pagination.Page[entity.Consultation], error) {
+	const op = "repository.consultation.ByOrganization"
+
+	consultationsQuery := selectConsultations().
+		Where("organization_uuid IN (?)", orgUuid)
+
+	return r.consultationsPage(ctx, op, consultationsQuery, page)
+}
+
+func (r *Repo) consultationsPage(
+	ctx context.Context,
+	op string,
+	consultationsQuery sq.SelectBuilder,
+	page *pagination.Request,
+) (*pagination.Page[entity.Consultation], error) {
+	countSql, countArgs, err := pagination.Count(consultationsQuery).ToSql()
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+	sql, args, err := page.Apply(consultationsQuery, "consultation.uuid").ToSql()
 	if err != nil {
 		return nil, fmt.Errorf("%s: %w", op, err)
 	}
 
-	rows, err := r.Db.Query(ctx, sql, args...)
+	var total int
+	err = r.Db.QueryRow(ctx, countSql, countArgs...).Scan(&total)
 	if err != nil {
 		return nil, fmt.Errorf("%s: %w", op, err)
 	}
 
-	applications, err := pgx.CollectRows(rows, pgx.RowToStructByNameLax[entity.Consultation])
+	rows, err := r.Db.Query(ctx, sql, args...)
 	if err != nil {
 		return nil, fmt.Errorf("%s: %w", op, err)
 	}
+	consultationRows, err := pgx.CollectRows(rows, pgx.RowToStructByNameLax[consultationRow])
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	pageRows := make([]pagination.Row[entity.Consultation], 0, len(consultationRows))
+	for _, row := range consultationRows {
+		pageRows = append(pageRows, pagination.Row[entity.Consultation]{
+			Item:    row.Consultation,
+			Id:      row.Uuid,
+			SortKey: row.SortKey,
+		})
+	}
 
-	return applications, err
+	return pagination.NewPage(page, pageRows, total), nil
 }
 
-func (r *Repo) ByUuid(ctx context.Context, uuid uuid.UUID) (*entity.Consultation, error) {
+type consultationRow struct {
+	entity.Consultation
+	SortKey string `db:"sort_key"`
+}
+
+// Returns the consultation if visible in the scope, ErrConsultationNotFound otherwise
+func (r *Repo) ByUuid(ctx context.Context, scope tenant.Scope, uuid uuid.UUID) (*entity.Consultation, error) {
 	const op = "repository.consultation.ByUuid"
 
-	psql := sq.StatementBuilder.PlaceholderFormat(sq.Dollar)
-	sql, args, err := psql.Select(
-		"consultation.uuid AS uuid",
-		"expert_uuid",
-		"mentee_uuid",
-		"status",
-		"mentee_questions",
-		"submitted_at",
-	).
-		From("consultation").
-		InnerJoin("consultation_application ON consultation.uuid = consultation_application.consultation_uuid").
+	sql, args, err := selectConsultations().
 		Where("uuid IN (?)", uuid).
+		Where(scope.Where("organization_uuid")).
 		ToSql()
 	if err != nil {
 		return nil, fmt.Errorf("%s: %w", op, err)
@@ -160,7 +281,12 @@ func (r *Repo) ByUuid(ctx context.Context, uuid uuid.UUID) (*entity.Consultation
 	return &consultation, nil
 }
 
-func (r *Repo) ByPersonUuid(ctx context.Context, uuid uuid.UUID, opts ...ByPersonUuidOption) ([]entity.Consultation, error) {
+func (r *Repo) ByPersonUuid(
+	ctx context.Context,
+	scope tenant.Scope,
+	uuid uuid.UUID,
+	opts ...ByPersonUuidOption,
+) ([]entity.Consultation, error) {
 	const op = "repository.consultation.ByPersonUuid"
 
 	options := newDefaultSelectByPersonUuidOptions()
@@ -168,18 +294,71 @@ func (r *Repo) ByPersonUuid(ctx context.Context, uuid uuid.UUID, opts ...ByPerso
 		opt(options)
 	}
 
-	psql := sq.StatementBuilder.PlaceholderFormat(sq.Dollar)
-	sql, args, err := psql.Select(
-		"consultation.uuid AS uuid",
-		"expert_uuid",
-		"mentee_uuid",
-		"status",
-		"mentee_questions",
-		"submitted_at",
-	).
-		From("consultation").
-		InnerJoin("consultation_application ON consultation.uuid = consultation_application.consultation_uuid").
+	sql, args, err := selectConsultations().
 		Where(string(options.whoseUuid)+" IN (?)", uuid).
+		Where(scope.Where("consultation.organization_uuid")).
+		ToSql()
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	rows, err := r.Db.Query(ctx, sql, args...)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+	consultations, err := pgx.CollectRows(rows, pgx.RowToStructByNameLax[entity.Consultation])
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return consultations, nil
+}
+
+// Returns every consultation in the scope with one of the statuses, oldest first
+func (r *Repo) ByStatuses(
+	ctx context.Context,
+	scope tenant.Scope,
+	statuses ...entity.Status,
+) ([]entity.Consultation, error) {
+	const op = "repository.consultation.ByStatuses"
+
+	sql, args, err := selectConsultations().
+		Where(sq.Eq{"status": statuses}).
+		Where(scope.Where("consultation.organization_uuid")).
+		OrderBy("submitted_at").
+		ToSql()
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	rows, err := r.Db.Query(ctx, sql, args...)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+	consultations, err := pgx.CollectRows(rows, pgx.RowToStructByNameLax[entity.Consultation])
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return consultations, nil
+}
+
+// Returns the consultations of the user, as expert or mentee, that are still
+// to come: applications not yet scheduled and scheduled ones with a meeting ahead
+func (r *Repo) Upcoming(ctx context.Context, scope tenant.Scope, userUuid uuid.UUID) ([]entity.Consultation, error) {
+	const op = "repository.consultation.Upcoming"
+
+	sql, args, err := selectConsultations().
+		Where("(consultation.expert_uuid IN (?) OR consultation.mentee_uuid IN (?))", userUuid, userUuid).
+		Where(scope.Where("consultation.organization_uuid")).
+		Where(sq.Or{
+			sq.Eq{"consultation_application.status": []entity.Status{entity.Pending, entity.Approved}},
+			sq.And{
+				sq.Eq{"consultation_application.status": entity.Scheduled},
+				sq.Expr("EXISTS (SELECT 1 FROM consultation_meeting WHERE consultation_meeting.consultation_uuid = consultation.uuid AND consultation_meeting.start_time > now())"),
+			},
+		}).
+		OrderBy("submitted_at").
 		ToSql()
 	if err != nil {
 		return nil, fmt.Errorf("%s: %w", op, err)
@@ -197,7 +376,11 @@ func (r *Repo) ByPersonUuid(ctx context.Context, uuid uuid.UUID, opts ...ByPerso
 	return consultations, nil
 }
 
-func (r *Repo) MeetingsByConsultationUuid(ctx context.Context, uuid uuid.UUID) ([]entity.ConsultationMeeting, error) {
+func (r *Repo) MeetingsByConsultationUuid(
+	ctx context.Context,
+	scope tenant.Scope,
+	uuid uuid.UUID,
+) ([]entity.ConsultationMeeting, error) {
 	const op = "repository.consultation.MeetingsByConsultationUuid"
 
 	psql := sq.StatementBuilder.PlaceholderFormat(sq.Dollar)
@@ -209,6 +392,7 @@ func (r *Repo) MeetingsByConsultationUuid(ctx context.Context, uuid uuid.UUID) (
 	).
 		From("consultation_meeting").
 		Where("consultation_uuid IN (?)", uuid).
+		Where(inScopeExpr(scope, "consultation_meeting.consultation_uuid")).
 		ToSql()
 	if err != nil {
 		return nil, fmt.Errorf("%s: %w", op, err)
@@ -226,11 +410,67 @@ func (r *Repo) MeetingsByConsultationUuid(ctx context.Context, uuid uuid.UUID) (
 	return meetings, nil
 }
 
-func (r *Repo) CreateMeeting(ctx context.Context, meeting *entity.ConsultationMeeting) error {
+func (r *Repo) MeetingByUuid(ctx context.Context, uuid uuid.UUID) (*entity.ConsultationMeeting, error) {
+	const op = "repository.consultation.MeetingByUuid"
+
+	psql := sq.StatementBuilder.PlaceholderFormat(sq.Dollar)
+	sql, args, err := psql.Select(
+		"uuid",
+		"consultation_uuid",
+		"start_time",
+		"link",
+	).
+		From("consultation_meeting").
+		Where("uuid IN (?)", uuid).
+		ToSql()
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	rows, err := r.Db.Query(ctx, sql, args...)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+	meeting, err := pgx.CollectOneRow(rows, pgx.RowToStructByNameLax[entity.ConsultationMeeting])
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, fmt.Errorf("%s: %w", op, ErrMeetingNotFound)
+		}
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return &meeting, nil
+}
+
+// Adds a meeting to a consultation that is not cancelled or rejected and
+// approves it if it was pending. Returns the status the consultation had before
+func (r *Repo) CreateMeeting(
+	ctx context.Context,
+	scope tenant.Scope,
+	meeting *entity.ConsultationMeeting,
+) (entity.Status, error) {
 	const op = "repository.consultation.CreateMeeting"
 
 	psql := sq.StatementBuilder.PlaceholderFormat(sq.Dollar)
-	sql, args, err := psql.Insert("consultation_meeting").
+	statusSql, statusArgs, err := psql.Select("status").
+		From("consultation_application").
+		Where("consultation_uuid IN (?)", meeting.ConsultationUuid).
+		Where(inScopeExpr(scope, "consultation_application.consultation_uuid")).
+		Suffix("FOR UPDATE").
+		ToSql()
+	if err != nil {
+		return 0, fmt.Errorf("%s: %w", op, err)
+	}
+
+	approveSql, approveArgs, err := psql.Update("consultation_application").
+		Set("status", entity.Approved).
+		Where("consultation_uuid IN (?)", meeting.ConsultationUuid).
+		ToSql()
+	if err != nil {
+		return 0, fmt.Errorf("%s: %w", op, err)
+	}
+
+	insertSql, insertArgs, err := psql.Insert("consultation_meeting").
 		Columns(
 			"consultation_uuid",
 			"start_time",
@@ -243,20 +483,53 @@ func (r *Repo) CreateMeeting(ctx context.Context, meeting *entity.ConsultationMe
 		).
 		ToSql()
 	if err != nil {
-		return fmt.Errorf("%s: %w", op, err)
+		return 0, fmt.Errorf("%s: %w", op, err)
 	}
 
-	_, err = r.Db.Exec(ctx, sql, args...)
+	tx, err := r.Db.Begin(ctx)
+	if err != nil {
+		return 0, fmt.Errorf("%s: %w", op, err)
+	}
+	defer func() {
+		if err != nil {
+			_ = tx.Rollback(ctx)
+		} else {
+			_ = tx.Commit(ctx)
+		}
+	}()
+
+	var status entity.Status
+	err = tx.QueryRow(ctx, statusSql, statusArgs...).Scan(&status)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			err = ErrConsultationNotFound
+		}
+		return 0, fmt.Errorf("%s: %w", op, err)
+	}
+	if status == entity.Cancelled || status == entity.Rejected {
+		err = ErrNotSchedulable
+		return 0, fmt.Errorf("%s: %w", op, err)
+	}
+
+	_, err = tx.Exec(ctx, insertSql, insertArgs...)
 	if err != nil {
 		var pgError *pgconn.PgError
 		if errors.As(err, &pgError) {
 			if pgError.Code == pgerrcode.ForeignKeyViolation {
-				return fmt.Errorf("%s: %w", op, ErrMeetingFKViolation)
+				err = ErrMeetingFKViolation
 			}
 		}
-		return fmt.Errorf("%s: %w", op, err)
+		return 0, fmt.Errorf("%s: %w", op, err)
 	}
-	return nil
+
+	if status == entity.Pending {
+		_, err = tx.Exec(ctx, approveSql, approveArgs...)
+		if err != nil {
+			return 0, fmt.Errorf("%s: %w", op, err)
+		}
+	}
+
+	return status, nil
 }
 
 func (r *Repo) UpdateApplication(ctx context.Context, application *entity.ConsultationApplication) error {
@@ -284,7 +557,12 @@ func (r *Repo) UpdateApplication(ctx context.Context, application *entity.Consul
 	return nil
 }
 
-func (r *Repo) UpdateApplicationStatus(ctx context.Context, uuid uuid.UUID, status entity.Status) error {
+func (r *Repo) UpdateApplicationStatus(
+	ctx context.Context,
+	scope tenant.Scope,
+	uuid uuid.UUID,
+	status entity.Status,
+) error {
 	const op = "repository.consultation.UpdateApplicationStatus"
 
 	psql := sq.StatementBuilder.PlaceholderFormat(sq.Dollar)
@@ -295,20 +573,177 @@ func (r *Repo) UpdateApplicationStatus(ctx context.Context, uuid uuid.UUID, stat
 			},
 		).
 		Where("consultation_uuid IN (?)", uuid).
+		Where(inScopeExpr(scope, "consultation_application.consultation_uuid")).
 		ToSql()
 	if err != nil {
 		return fmt.Errorf("%s: %w", op, err)
 	}
 
-	_, err = r.Db.Exec(ctx, sql, args...)
+	tag, err := r.Db.Exec(ctx, sql, args...)
 	if err != nil {
 		return fmt.Errorf("%s: %w", op, err)
 	}
+	if tag.RowsAffected() == 0 {
+		return fmt.Errorf("%s: %w", op, ErrConsultationNotFound)
+	}
 
 	return nil
 }
 
-func (r *Repo) DoesExist(ctx context.Context, menteeUuid, expertUuid uuid.UUID) (bool, error) {
+// Rejects a consultation that is still pending. Approved and paid ones have
+// to be cancelled, so the mentee gets refunded. A package credit used for the
+// consultation is given back
+func (r *Repo) RejectApplication(ctx context.Context, scope tenant.Scope, uuid uuid.UUID) error {
+	const op = "repository.consultation.RejectApplication"
+
+	psql := sq.StatementBuilder.PlaceholderFormat(sq.Dollar)
+	rejectSql, rejectArgs, err := psql.Update("consultation_application").
+		Set("status", entity.Rejected).
+		Where("consultation_uuid IN (?)", uuid).
+		Where("status IN (?)", entity.Pending).
+		Where(inScopeExpr(scope, "consultation_application.consultation_uuid")).
+		ToSql()
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	restoreCreditSql, restoreCreditArgs, err := psql.Update("package_purchase").
+		Set("sessions_used", sq.Expr("sessions_used - 1")).
+		Where("uuid = (SELECT package_purchase_uuid FROM consultation WHERE uuid = ?)", uuid).
+		Where("sessions_used > 0").
+		ToSql()
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	tx, err := r.Db.Begin(ctx)
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+	defer func() {
+		if err != nil {
+			_ = tx.Rollback(ctx)
+		} else {
+			_ = tx.Commit(ctx)
+		}
+	}()
+
+	tag, err := tx.Exec(ctx, rejectSql, rejectArgs...)
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+	if tag.RowsAffected() == 0 {
+		_, err = r.ByUuid(ctx, scope, uuid)
+		if err == nil {
+			err = ErrNotRejectable
+		}
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	_, err = tx.Exec(ctx, restoreCreditSql, restoreCreditArgs...)
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	return nil
+}
+
+// Marks a consultation as cancelled unless it was already cancelled or rejected
+// or its first meeting has started. A package credit used for the consultation is given back
+// and the refund owed, if any, is recorded as pending for the provider to be asked later
+func (r *Repo) CancelConsultation(
+	ctx context.Context,
+	uuid uuid.UUID,
+	cancelledBy uuid.UUID,
+	refund *entity.Refund,
+) error {
+	const op = "repository.consultation.CancelConsultation"
+
+	psql := sq.StatementBuilder.PlaceholderFormat(sq.Dollar)
+	cancelSql, cancelArgs, err := psql.Update("consultation_application").
+		Set("status", entity.Cancelled).
+		Set("cancelled_by", cancelledBy).
+		Set("cancelled_at", sq.Expr("now()")).
+		Where("consultation_uuid IN (?)", uuid).
+		Where(sq.NotEq{"status": []entity.Status{entity.Cancelled, entity.Rejected}}).
+		// Once the first meeting has started the consultation took place
+		Where("NOT EXISTS (SELECT 1 FROM consultation_meeting WHERE consultation_meeting.consultation_uuid = ? AND consultation_meeting.start_time <= now())", uuid).
+		ToSql()
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	restoreCreditSql, restoreCreditArgs, err := psql.Update("package_purchase").
+		Set("sessions_used", sq.Expr("sessions_used - 1")).
+		Where("uuid = (SELECT package_purchase_uuid FROM consultation WHERE uuid = ?)", uuid).
+		Where("sessions_used > 0").
+		ToSql()
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	tx, err := r.Db.Begin(ctx)
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+	defer func() {
+		if err != nil {
+			_ = tx.Rollback(ctx)
+		} else {
+			_ = tx.Commit(ctx)
+		}
+	}()
+
+	tag, err := tx.Exec(ctx, cancelSql, cancelArgs...)
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+	if tag.RowsAffected() == 0 {
+		err = ErrNotCancellable
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	_, err = tx.Exec(ctx, restoreCreditSql, restoreCreditArgs...)
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	if refund == nil {
+		return nil
+	}
+
+	refundSql, refundArgs, err := psql.Insert("refund").
+		Columns(
+			"payment_intent_uuid",
+			"amount",
+			"currency",
+			"status",
+			"reason",
+			"initiated_by",
+		).
+		Values(
+			refund.PaymentIntentUuid,
+			refund.Amount,
+			refund.Currency,
+			refund.Status,
+			refund.Reason,
+			refund.InitiatedBy,
+		).
+		Suffix("RETURNING uuid, created_at, updated_at").
+		ToSql()
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	err = tx.QueryRow(ctx, refundSql, refundArgs...).Scan(&refund.Uuid, &refund.CreatedAt, &refund.UpdatedAt)
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	return nil
+}
+
+func (r *Repo) DoesExist(ctx context.Context, scope tenant.Scope, menteeUuid, expertUuid uuid.UUID) (bool, error) {
 	const op = "repository.consultation.DoesExist"
 
 	psql := sq.StatementBuilder.PlaceholderFormat(sq.Dollar)
@@ -318,6 +753,7 @@ func (r *Repo) DoesExist(ctx context.Context, menteeUuid, expertUuid uuid.UUID)
 		From("consultation").
 		Where("expert_uuid IN (?)", expertUuid).
 		Where("mentee_uuid IN (?)", menteeUuid).
+		Where(scope.Where("organization_uuid")).
 		ToSql()
 	if err != nil {
 		return false, fmt.Errorf("%s: %w", op, err)
@@ -335,3 +771,36 @@ func (r *Repo) DoesExist(ctx context.Context, menteeUuid, expertUuid uuid.UUID)
 		return false, nil
 	}
 }
+
+// Condition that the consultation the column refers to is visible in the scope,
+// for rows that hang off a consultation
+func inScopeExpr(scope tenant.Scope, column string) sq.Sqlizer {
+	return sq.Expr(
+		"EXISTS (SELECT 1 FROM consultation WHERE consultation.uuid = "+column+" AND ?)",
+		scope.Where("consultation.organization_uuid"),
+	)
+}
+
+// Consultations and their reviews have no deleted_at of their own. They are the
+// other participant's history too, so they stay listed when one participant is
+// soft deleted: that user can no longer sign in, their upcoming consultations
+// are cancelled on deletion and reviews carry no reviewer identity
+func selectConsultations() sq.SelectBuilder {
+	psql := sq.StatementBuilder.PlaceholderFormat(sq.Dollar)
+	return psql.Select(
+		"consultation.uuid AS uuid",
+		"expert_uuid",
+		"mentee_uuid",
+		"price_amount",
+		"price_currency",
+		"package_purchase_uuid",
+		"organization_uuid",
+		"status",
+		"mentee_questions",
+		"submitted_at",
+		"cancelled_by",
+		"cancelled_at",
+	).
+		From("consultation").
+		InnerJoin("consultation_application ON consultation.uuid = consultation_application.consultation_uuid")
+}
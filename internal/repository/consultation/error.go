@@ -5,4 +5,13 @@ import "errors"
 var (
 	ErrConsultationNotFound = errors.New("consultation not found")
 	ErrMeetingFKViolation   = errors.New("tried to create a meeting for non existing consultation")
+	ErrMeetingNotFound      = errors.New("meeting not found")
+	ErrNoteNotFound         = errors.New("note not found")
+	ErrSummaryNotFound      = errors.New("summary not found")
+	ErrNotCancellable       = errors.New("consultation is already cancelled, rejected or has started")
+	ErrNoPackageCredits     = errors.New("package purchase has no credits left, is expired or not paid")
+	ErrAlreadyReviewed      = errors.New("consultation was already reviewed")
+	ErrExpertUnavailable    = errors.New("expert is paused, suspended or does not exist")
+	ErrNotSchedulable       = errors.New("cancelled and rejected consultations cannot get meetings")
+	ErrNotRejectable        = errors.New("only pending consultations can be rejected")
 )
@@ -0,0 +1,327 @@
+package consultationrepo
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	sq "github.com/Masterminds/squirrel"
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+
+	"github.com/bogdanshibilov/mindflowbackend/internal/entity"
+)
+
+func (r *Repo) CreateNote(ctx context.Context, note *entity.ConsultationNote) error {
+	const op = "repository.consultation.CreateNote"
+
+	psql := sq.StatementBuilder.PlaceholderFormat(sq.Dollar)
+	sql, args, err := psql.Insert("consultation_note").
+		Columns(
+			"consultation_uuid",
+			"author_uuid",
+			"content",
+		).
+		Values(
+			note.ConsultationUuid,
+			note.AuthorUuid,
+			note.Content,
+		).
+		Suffix("RETURNING uuid, created_at, updated_at").
+		ToSql()
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	err = r.Db.QueryRow(ctx, sql, args...).Scan(&note.Uuid, &note.CreatedAt, &note.UpdatedAt)
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	return nil
+}
+
+func (r *Repo) NotesByConsultationUuid(
+	ctx context.Context,
+	consultUuid uuid.UUID,
+	authorUuid uuid.UUID,
+) ([]entity.ConsultationNote, error) {
+	const op = "repository.consultation.NotesByConsultationUuid"
+
+	psql := sq.StatementBuilder.PlaceholderFormat(sq.Dollar)
+	sql, args, err := psql.Select(
+		"uuid",
+		"consultation_uuid",
+		"author_uuid",
+		"content",
+		"created_at",
+		"updated_at",
+	).
+		From("consultation_note").
+		Where("consultation_uuid IN (?)", consultUuid).
+		Where("author_uuid IN (?)", authorUuid).
+		OrderBy("created_at").
+		ToSql()
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	rows, err := r.Db.Query(ctx, sql, args...)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+	notes, err := pgx.CollectRows(rows, pgx.RowToStructByNameLax[entity.ConsultationNote])
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return notes, nil
+}
+
+func (r *Repo) NoteByUuid(ctx context.Context, uuid uuid.UUID) (*entity.ConsultationNote, error) {
+	const op = "repository.consultation.NoteByUuid"
+
+	psql := sq.StatementBuilder.PlaceholderFormat(sq.Dollar)
+	sql, args, err := psql.Select(
+		"uuid",
+		"consultation_uuid",
+		"author_uuid",
+		"content",
+		"created_at",
+		"updated_at",
+	).
+		From("consultation_note").
+		Where("uuid IN (?)", uuid).
+		ToSql()
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	rows, err := r.Db.Query(ctx, sql, args...)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+	note, err := pgx.CollectOneRow(rows, pgx.RowToStructByNameLax[entity.ConsultationNote])
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, fmt.Errorf("%s: %w", op, ErrNoteNotFound)
+		}
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return &note, nil
+}
+
+func (r *Repo) UpdateNote(ctx context.Context, uuid uuid.UUID, content string) error {
+	const op = "repository.consultation.UpdateNote"
+
+	psql := sq.StatementBuilder.PlaceholderFormat(sq.Dollar)
+	sql, args, err := psql.Update("consultation_note").
+		Set("content", content).
+		Set("updated_at", sq.Expr("now()")).
+		Where("uuid IN (?)", uuid).
+		ToSql()
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	_, err = r.Db.Exec(ctx, sql, args...)
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	return nil
+}
+
+func (r *Repo) DeleteNote(ctx context.Context, uuid uuid.UUID) error {
+	const op = "repository.consultation.DeleteNote"
+
+	psql := sq.StatementBuilder.PlaceholderFormat(sq.Dollar)
+	sql, args, err := psql.Delete("consultation_note").
+		Where("uuid IN (?)", uuid).
+		ToSql()
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	_, err = r.Db.Exec(ctx, sql, args...)
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	return nil
+}
+
+func (r *Repo) SummaryByMeetingUuid(ctx context.Context, meetingUuid uuid.UUID) (*entity.MeetingSummary, error) {
+	const op = "repository.consultation.SummaryByMeetingUuid"
+
+	psql := sq.StatementBuilder.PlaceholderFormat(sq.Dollar)
+	sql, args, err := psql.Select(
+		"meeting_uuid",
+		"author_uuid",
+		"version",
+		"content",
+		"action_items",
+		"published_at",
+		"updated_at",
+	).
+		From("meeting_summary").
+		Where("meeting_uuid IN (?)", meetingUuid).
+		ToSql()
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	rows, err := r.Db.Query(ctx, sql, args...)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+	summary, err := pgx.CollectOneRow(rows, pgx.RowToStructByNameLax[entity.MeetingSummary])
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, fmt.Errorf("%s: %w", op, ErrSummaryNotFound)
+		}
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return &summary, nil
+}
+
+// Creates or updates the summary of a meeting, bumping its version and
+// recording the new content as a revision in the same transaction. Edits
+// unpublish the summary, the mentee sees them once it is published again
+func (r *Repo) SaveSummary(ctx context.Context, summary *entity.MeetingSummary, editorUuid uuid.UUID) error {
+	const op = "repository.consultation.SaveSummary"
+
+	actionItems := summary.ActionItems
+	if actionItems == nil {
+		actionItems = []string{}
+	}
+
+	psql := sq.StatementBuilder.PlaceholderFormat(sq.Dollar)
+	upsertSummarySql, upsertSummaryArgs, err := psql.Insert("meeting_summary").
+		Columns(
+			"meeting_uuid",
+			"author_uuid",
+			"content",
+			"action_items",
+		).
+		Values(
+			summary.MeetingUuid,
+			summary.AuthorUuid,
+			summary.Content,
+			actionItems,
+		).
+		Suffix(
+			"ON CONFLICT (meeting_uuid) DO UPDATE SET " +
+				"content = EXCLUDED.content, " +
+				"action_items = EXCLUDED.action_items, " +
+				"version = meeting_summary.version + 1, " +
+				"published_at = NULL, " +
+				"updated_at = now() " +
+				"RETURNING version, published_at, updated_at",
+		).
+		ToSql()
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	tx, err := r.Db.Begin(ctx)
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+	defer func() {
+		if err != nil {
+			_ = tx.Rollback(ctx)
+		} else {
+			_ = tx.Commit(ctx)
+		}
+	}()
+
+	err = tx.QueryRow(ctx, upsertSummarySql, upsertSummaryArgs...).Scan(&summary.Version, &summary.PublishedAt, &summary.UpdatedAt)
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	insertRevisionSql, insertRevisionArgs, err := psql.Insert("meeting_summary_revision").
+		Columns(
+			"meeting_uuid",
+			"version",
+			"editor_uuid",
+			"content",
+			"action_items",
+		).
+		Values(
+			summary.MeetingUuid,
+			summary.Version,
+			editorUuid,
+			summary.Content,
+			actionItems,
+		).
+		ToSql()
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	_, err = tx.Exec(ctx, insertRevisionSql, insertRevisionArgs...)
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	return nil
+}
+
+func (r *Repo) PublishSummary(ctx context.Context, meetingUuid uuid.UUID) error {
+	const op = "repository.consultation.PublishSummary"
+
+	psql := sq.StatementBuilder.PlaceholderFormat(sq.Dollar)
+	sql, args, err := psql.Update("meeting_summary").
+		Set("published_at", sq.Expr("now()")).
+		Where("meeting_uuid IN (?)", meetingUuid).
+		ToSql()
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	tag, err := r.Db.Exec(ctx, sql, args...)
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+	if tag.RowsAffected() == 0 {
+		return fmt.Errorf("%s: %w", op, ErrSummaryNotFound)
+	}
+
+	return nil
+}
+
+func (r *Repo) SummaryRevisions(ctx context.Context, meetingUuid uuid.UUID) ([]entity.MeetingSummaryRevision, error) {
+	const op = "repository.consultation.SummaryRevisions"
+
+	psql := sq.StatementBuilder.PlaceholderFormat(sq.Dollar)
+	sql, args, err := psql.Select(
+		"meeting_uuid",
+		"version",
+		"editor_uuid",
+		"content",
+		"action_items",
+		"edited_at",
+	).
+		From("meeting_summary_revision").
+		Where("meeting_uuid IN (?)", meetingUuid).
+		OrderBy("version DESC").
+		ToSql()
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	rows, err := r.Db.Query(ctx, sql, args...)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+	revisions, err := pgx.CollectRows(rows, pgx.RowToStructByNameLax[entity.MeetingSummaryRevision])
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return revisions, nil
+}
@@ -0,0 +1,112 @@
+package consultationrepo
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	sq "github.com/Masterminds/squirrel"
+	"github.com/google/uuid"
+	"github.com/jackc/pgerrcode"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+
+	"github.com/bogdanshibilov/mindflowbackend/internal/entity"
+	"github.com/bogdanshibilov/mindflowbackend/internal/tenant"
+)
+
+func (r *Repo) CreateReview(ctx context.Context, review *entity.ConsultationReview) error {
+	const op = "repository.consultation.CreateReview"
+
+	psql := sq.StatementBuilder.PlaceholderFormat(sq.Dollar)
+	sql, args, err := psql.Insert("consultation_review").
+		Columns(
+			"consultation_uuid",
+			"rating",
+			"comment",
+		).
+		Values(
+			review.ConsultationUuid,
+			review.Rating,
+			review.Comment,
+		).
+		Suffix("RETURNING created_at").
+		ToSql()
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	err = r.Db.QueryRow(ctx, sql, args...).Scan(&review.CreatedAt)
+	if err != nil {
+		var pgError *pgconn.PgError
+		if errors.As(err, &pgError) && pgError.Code == pgerrcode.UniqueViolation {
+			return fmt.Errorf("%s: %w", op, ErrAlreadyReviewed)
+		}
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	return nil
+}
+
+// Returns the reviews of the expert's consultations visible in the scope, newest first
+func (r *Repo) ReviewsByExpertUuid(
+	ctx context.Context,
+	scope tenant.Scope,
+	expertUuid uuid.UUID,
+) ([]entity.ConsultationReview, error) {
+	const op = "repository.consultation.ReviewsByExpertUuid"
+
+	reviews, err := r.reviewsBy(ctx, scope, "expert_uuid", expertUuid)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return reviews, nil
+}
+
+// Returns the reviews the mentee wrote visible in the scope
+func (r *Repo) ReviewsByMenteeUuid(
+	ctx context.Context,
+	scope tenant.Scope,
+	menteeUuid uuid.UUID,
+) ([]entity.ConsultationReview, error) {
+	const op = "repository.consultation.ReviewsByMenteeUuid"
+
+	reviews, err := r.reviewsBy(ctx, scope, "mentee_uuid", menteeUuid)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return reviews, nil
+}
+
+func (r *Repo) reviewsBy(
+	ctx context.Context,
+	scope tenant.Scope,
+	column string,
+	personUuid uuid.UUID,
+) ([]entity.ConsultationReview, error) {
+	psql := sq.StatementBuilder.PlaceholderFormat(sq.Dollar)
+	sql, args, err := psql.Select(
+		"consultation_uuid",
+		"rating",
+		"comment",
+		"created_at",
+	).
+		From("consultation_review").
+		InnerJoin("consultation ON consultation.uuid = consultation_review.consultation_uuid").
+		Where(sq.Eq{column: personUuid}).
+		Where(scope.Where("consultation.organization_uuid")).
+		OrderBy("created_at DESC").
+		ToSql()
+	if err != nil {
+		return nil, err
+	}
+
+	rows, err := r.Db.Query(ctx, sql, args...)
+	if err != nil {
+		return nil, err
+	}
+
+	return pgx.CollectRows(rows, pgx.RowToStructByNameLax[entity.ConsultationReview])
+}
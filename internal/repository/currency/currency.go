@@ -0,0 +1,166 @@
+package currencyrepo
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	sq "github.com/Masterminds/squirrel"
+	"github.com/jackc/pgx/v5"
+
+	"github.com/bogdanshibilov/mindflowbackend/internal/db/postgres"
+	"github.com/bogdanshibilov/mindflowbackend/internal/entity"
+)
+
+type Repo struct {
+	Db postgres.Db
+}
+
+func (r *Repo) Rates(ctx context.Context) ([]entity.ExchangeRate, error) {
+	const op = "repository.currency.Rates"
+
+	psql := sq.StatementBuilder.PlaceholderFormat(sq.Dollar)
+	sql, args, err := psql.Select(
+		"currency",
+		"rate",
+		"updated_by",
+		"updated_at",
+	).
+		From("exchange_rate").
+		OrderBy("currency").
+		ToSql()
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	rows, err := r.Db.Query(ctx, sql, args...)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+	rates, err := pgx.CollectRows(rows, pgx.RowToStructByNameLax[entity.ExchangeRate])
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return rates, nil
+}
+
+func (r *Repo) RateByCurrency(ctx context.Context, currency string) (*entity.ExchangeRate, error) {
+	const op = "repository.currency.RateByCurrency"
+
+	psql := sq.StatementBuilder.PlaceholderFormat(sq.Dollar)
+	sql, args, err := psql.Select(
+		"currency",
+		"rate",
+		"updated_by",
+		"updated_at",
+	).
+		From("exchange_rate").
+		Where("currency IN (?)", currency).
+		ToSql()
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	rows, err := r.Db.Query(ctx, sql, args...)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+	rate, err := pgx.CollectOneRow(rows, pgx.RowToStructByNameLax[entity.ExchangeRate])
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, fmt.Errorf("%s: %w", op, ErrRateNotFound)
+		}
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return &rate, nil
+}
+
+func (r *Repo) SetRate(ctx context.Context, rate *entity.ExchangeRate) error {
+	const op = "repository.currency.SetRate"
+
+	psql := sq.StatementBuilder.PlaceholderFormat(sq.Dollar)
+	sql, args, err := psql.Insert("exchange_rate").
+		Columns(
+			"currency",
+			"rate",
+			"updated_by",
+		).
+		Values(
+			rate.Currency,
+			rate.Rate,
+			rate.UpdatedBy,
+		).
+		Suffix("ON CONFLICT (currency) DO UPDATE SET " +
+			"rate = EXCLUDED.rate, updated_by = EXCLUDED.updated_by, updated_at = now() " +
+			"RETURNING updated_at").
+		ToSql()
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	err = r.Db.QueryRow(ctx, sql, args...).Scan(&rate.UpdatedAt)
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	return nil
+}
+
+// Adds the rate only if the currency has none yet
+func (r *Repo) AddRateIfMissing(ctx context.Context, rate *entity.ExchangeRate) error {
+	const op = "repository.currency.AddRateIfMissing"
+
+	psql := sq.StatementBuilder.PlaceholderFormat(sq.Dollar)
+	sql, args, err := psql.Insert("exchange_rate").
+		Columns(
+			"currency",
+			"rate",
+		).
+		Values(
+			rate.Currency,
+			rate.Rate,
+		).
+		Suffix("ON CONFLICT (currency) DO NOTHING").
+		ToSql()
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	_, err = r.Db.Exec(ctx, sql, args...)
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	return nil
+}
+
+// Deletes the rate unless some expert still charges in the currency
+func (r *Repo) DeleteRate(ctx context.Context, currency string) error {
+	const op = "repository.currency.DeleteRate"
+
+	psql := sq.StatementBuilder.PlaceholderFormat(sq.Dollar)
+	sql, args, err := psql.Delete("exchange_rate").
+		Where("currency IN (?)", currency).
+		Where("NOT EXISTS (SELECT 1 FROM expert_information WHERE currency = ?)", currency).
+		ToSql()
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	tag, err := r.Db.Exec(ctx, sql, args...)
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+	if tag.RowsAffected() > 0 {
+		return nil
+	}
+
+	_, err = r.RateByCurrency(ctx, currency)
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	return fmt.Errorf("%s: %w", op, ErrRateInUse)
+}
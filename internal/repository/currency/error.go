@@ -0,0 +1,8 @@
+package currencyrepo
+
+import "errors"
+
+var (
+	ErrRateNotFound = errors.New("exchange rate not found")
+	ErrRateInUse    = errors.New("exchange rate is used by expert prices")
+)
@@ -0,0 +1,249 @@
+package expertrepo
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	sq "github.com/Masterminds/squirrel"
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+
+	"github.com/bogdanshibilov/mindflowbackend/internal/entity"
+)
+
+func (r *Repo) Application(ctx context.Context, userUuid uuid.UUID) (*entity.ExpertApplication, error) {
+	const op = "repository.expert.Application"
+
+	psql := sq.StatementBuilder.PlaceholderFormat(sq.Dollar)
+	sql, args, err := psql.Select(
+		"status",
+		"submitted_at",
+		"reason",
+		"reviewed_by",
+		"reviewed_at",
+	).
+		From("expert_application").
+		Where("user_uuid IN (?)", userUuid).
+		ToSql()
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	rows, err := r.Db.Query(ctx, sql, args...)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+	application, err := pgx.CollectOneRow(rows, pgx.RowToStructByNameLax[entity.ExpertApplication])
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, fmt.Errorf("%s: %w", op, ErrExpertNotFound)
+		}
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return &application, nil
+}
+
+// Records a review decision on a pending application and adds it to the history
+func (r *Repo) ReviewApplication(
+	ctx context.Context,
+	userUuid uuid.UUID,
+	status entity.Status,
+	reason *string,
+	reviewerUuid uuid.UUID,
+) error {
+	const op = "repository.expert.ReviewApplication"
+
+	psql := sq.StatementBuilder.PlaceholderFormat(sq.Dollar)
+	updateSql, updateArgs, err := psql.Update("expert_application").
+		SetMap(sq.Eq{
+			"status":      status,
+			"reason":      reason,
+			"reviewed_by": reviewerUuid,
+			"reviewed_at": sq.Expr("now()"),
+		}).
+		Where("user_uuid IN (?)", userUuid).
+		Where("status IN (?)", entity.Pending).
+		ToSql()
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	existsSql, existsArgs, err := psql.Select("1").
+		From("expert_application").
+		Where("user_uuid IN (?)", userUuid).
+		Prefix("SELECT EXISTS (").
+		Suffix(")").
+		ToSql()
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	insertEventSql, insertEventArgs, err := insertApplicationEvent(&entity.ExpertApplicationEvent{
+		UserUuid:   userUuid,
+		Status:     status,
+		Reason:     reason,
+		ReviewedBy: &reviewerUuid,
+	})
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	tx, err := r.Db.Begin(ctx)
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+	defer func() {
+		if err != nil {
+			_ = tx.Rollback(ctx)
+		} else {
+			_ = tx.Commit(ctx)
+		}
+	}()
+
+	tag, err := tx.Exec(ctx, updateSql, updateArgs...)
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+	if tag.RowsAffected() == 0 {
+		var exists bool
+		err = tx.QueryRow(ctx, existsSql, existsArgs...).Scan(&exists)
+		if err != nil {
+			return fmt.Errorf("%s: %w", op, err)
+		}
+		err = ErrExpertNotFound
+		if exists {
+			err = ErrNotReviewable
+		}
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	_, err = tx.Exec(ctx, insertEventSql, insertEventArgs...)
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	return nil
+}
+
+// Puts a rejected application back into review with a new help description
+func (r *Repo) ResubmitApplication(ctx context.Context, userUuid uuid.UUID, helpDescription string) error {
+	const op = "repository.expert.ResubmitApplication"
+
+	psql := sq.StatementBuilder.PlaceholderFormat(sq.Dollar)
+	updateApplicationSql, updateApplicationArgs, err := psql.Update("expert_application").
+		SetMap(sq.Eq{
+			"status":       entity.Pending,
+			"submitted_at": sq.Expr("now()"),
+			"reason":       nil,
+			"reviewed_by":  nil,
+			"reviewed_at":  nil,
+		}).
+		Where("user_uuid IN (?)", userUuid).
+		Where("status IN (?)", entity.Rejected).
+		ToSql()
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	updateInfoSql, updateInfoArgs, err := psql.Update("expert_information").
+		Set("help_description", helpDescription).
+		Set("version", sq.Expr("version + 1")).
+		Where("user_uuid IN (?)", userUuid).
+		ToSql()
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	insertEventSql, insertEventArgs, err := insertApplicationEvent(&entity.ExpertApplicationEvent{
+		UserUuid: userUuid,
+		Status:   entity.Pending,
+	})
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	tx, err := r.Db.Begin(ctx)
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+	defer func() {
+		if err != nil {
+			_ = tx.Rollback(ctx)
+		} else {
+			_ = tx.Commit(ctx)
+		}
+	}()
+
+	tag, err := tx.Exec(ctx, updateApplicationSql, updateApplicationArgs...)
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+	if tag.RowsAffected() == 0 {
+		err = ErrNotResubmittable
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	_, err = tx.Exec(ctx, updateInfoSql, updateInfoArgs...)
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	_, err = tx.Exec(ctx, insertEventSql, insertEventArgs...)
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	return nil
+}
+
+func (r *Repo) ApplicationHistory(ctx context.Context, userUuid uuid.UUID) ([]entity.ExpertApplicationEvent, error) {
+	const op = "repository.expert.ApplicationHistory"
+
+	psql := sq.StatementBuilder.PlaceholderFormat(sq.Dollar)
+	sql, args, err := psql.Select(
+		"uuid",
+		"user_uuid",
+		"status",
+		"reason",
+		"reviewed_by",
+		"created_at",
+	).
+		From("expert_application_history").
+		Where("user_uuid IN (?)", userUuid).
+		OrderBy("created_at DESC").
+		ToSql()
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	rows, err := r.Db.Query(ctx, sql, args...)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+	events, err := pgx.CollectRows(rows, pgx.RowToStructByNameLax[entity.ExpertApplicationEvent])
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return events, nil
+}
+
+func insertApplicationEvent(event *entity.ExpertApplicationEvent) (string, []any, error) {
+	psql := sq.StatementBuilder.PlaceholderFormat(sq.Dollar)
+	return psql.Insert("expert_application_history").
+		Columns(
+			"user_uuid",
+			"status",
+			"reason",
+			"reviewed_by",
+		).
+		Values(
+			event.UserUuid,
+			event.Status,
+			event.Reason,
+			event.ReviewedBy,
+		).
+		ToSql()
+}
@@ -0,0 +1,152 @@
+package expertrepo
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	sq "github.com/Masterminds/squirrel"
+	"github.com/google/uuid"
+	"github.com/jackc/pgerrcode"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+
+	"github.com/bogdanshibilov/mindflowbackend/internal/entity"
+)
+
+func (r *Repo) Availability(ctx context.Context, expertUuid uuid.UUID) ([]entity.ExpertAvailability, error) {
+	const op = "repository.expert.Availability"
+
+	psql := sq.StatementBuilder.PlaceholderFormat(sq.Dollar)
+	sql, args, err := psql.Select(
+		"uuid",
+		"expert_uuid",
+		"weekday",
+		"start_minute",
+		"end_minute",
+	).
+		From("expert_availability").
+		Where("expert_uuid IN (?)", expertUuid).
+		OrderBy("weekday", "start_minute").
+		ToSql()
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	rows, err := r.Db.Query(ctx, sql, args...)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+	slots, err := pgx.CollectRows(rows, pgx.RowToStructByNameLax[entity.ExpertAvailability])
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return slots, nil
+}
+
+// Returns the weekly availability of each of the experts
+func (r *Repo) AvailabilityByExpertUuids(ctx context.Context, expertUuids []uuid.UUID) (map[uuid.UUID][]entity.ExpertAvailability, error) {
+	const op = "repository.expert.AvailabilityByExpertUuids"
+
+	psql := sq.StatementBuilder.PlaceholderFormat(sq.Dollar)
+	sql, args, err := psql.Select(
+		"uuid",
+		"expert_uuid",
+		"weekday",
+		"start_minute",
+		"end_minute",
+	).
+		From("expert_availability").
+		Where(sq.Eq{"expert_uuid": expertUuids}).
+		OrderBy("weekday", "start_minute").
+		ToSql()
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	rows, err := r.Db.Query(ctx, sql, args...)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+	slots, err := pgx.CollectRows(rows, pgx.RowToStructByNameLax[entity.ExpertAvailability])
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	result := make(map[uuid.UUID][]entity.ExpertAvailability)
+	for _, slot := range slots {
+		result[slot.ExpertUuid] = append(result[slot.ExpertUuid], slot)
+	}
+
+	return result, nil
+}
+
+// Replaces the weekly availability of the expert
+func (r *Repo) SetAvailability(ctx context.Context, expertUuid uuid.UUID, slots []entity.ExpertAvailability) error {
+	const op = "repository.expert.SetAvailability"
+
+	psql := sq.StatementBuilder.PlaceholderFormat(sq.Dollar)
+	deleteSql, deleteArgs, err := psql.Delete("expert_availability").
+		Where("expert_uuid IN (?)", expertUuid).
+		ToSql()
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	var insertSql string
+	var insertArgs []any
+	if len(slots) > 0 {
+		insertQuery := psql.Insert("expert_availability").
+			Columns(
+				"expert_uuid",
+				"weekday",
+				"start_minute",
+				"end_minute",
+			)
+		for _, slot := range slots {
+			insertQuery = insertQuery.Values(
+				expertUuid,
+				slot.Weekday,
+				slot.StartMinute,
+				slot.EndMinute,
+			)
+		}
+		insertSql, insertArgs, err = insertQuery.ToSql()
+		if err != nil {
+			return fmt.Errorf("%s: %w", op, err)
+		}
+	}
+
+	tx, err := r.Db.Begin(ctx)
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+	defer func() {
+		if err != nil {
+			_ = tx.Rollback(ctx)
+		} else {
+			_ = tx.Commit(ctx)
+		}
+	}()
+
+	_, err = tx.Exec(ctx, deleteSql, deleteArgs...)
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	if insertSql == "" {
+		return nil
+	}
+
+	_, err = tx.Exec(ctx, insertSql, insertArgs...)
+	if err != nil {
+		var pgError *pgconn.PgError
+		if errors.As(err, &pgError) && pgError.Code == pgerrcode.ForeignKeyViolation {
+			err = ErrExpertNotFound
+		}
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	return nil
+}
@@ -0,0 +1,165 @@
+package expertrepo
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	sq "github.com/Masterminds/squirrel"
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+
+	"github.com/bogdanshibilov/mindflowbackend/internal/entity"
+)
+
+func (r *Repo) CreateDocument(ctx context.Context, document *entity.ExpertDocument) error {
+	const op = "repository.expert.CreateDocument"
+
+	psql := sq.StatementBuilder.PlaceholderFormat(sq.Dollar)
+	sql, args, err := psql.Insert("expert_document").
+		Columns(
+			"uuid",
+			"expert_uuid",
+			"kind",
+			"title",
+			"blob_key",
+			"file_name",
+			"content_type",
+			"size_bytes",
+			"url",
+			"status",
+		).
+		Values(
+			document.Uuid,
+			document.ExpertUuid,
+			document.Kind,
+			document.Title,
+			document.BlobKey,
+			document.FileName,
+			document.ContentType,
+			document.SizeBytes,
+			document.Url,
+			document.Status,
+		).
+		Suffix("RETURNING uploaded_at").
+		ToSql()
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	err = r.Db.QueryRow(ctx, sql, args...).Scan(&document.UploadedAt)
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	return nil
+}
+
+func (r *Repo) DocumentsByExpertUuid(ctx context.Context, expertUuid uuid.UUID) ([]entity.ExpertDocument, error) {
+	const op = "repository.expert.DocumentsByExpertUuid"
+
+	documents, err := r.documentsBy(ctx, "expert_uuid", expertUuid)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return documents, nil
+}
+
+func (r *Repo) DocumentByUuid(ctx context.Context, uuid uuid.UUID) (*entity.ExpertDocument, error) {
+	const op = "repository.expert.DocumentByUuid"
+
+	documents, err := r.documentsBy(ctx, "uuid", uuid)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+	if len(documents) == 0 {
+		return nil, fmt.Errorf("%s: %w", op, ErrDocumentNotFound)
+	}
+
+	return &documents[0], nil
+}
+
+// Records the admin's verdict on a document
+func (r *Repo) SetDocumentVerdict(
+	ctx context.Context,
+	documentUuid uuid.UUID,
+	status entity.DocumentStatus,
+	note *string,
+	reviewerUuid uuid.UUID,
+) (*entity.ExpertDocument, error) {
+	const op = "repository.expert.SetDocumentVerdict"
+
+	psql := sq.StatementBuilder.PlaceholderFormat(sq.Dollar)
+	sql, args, err := psql.Update("expert_document").
+		SetMap(sq.Eq{
+			"status":       status,
+			"verdict_note": note,
+			"reviewed_by":  reviewerUuid,
+			"reviewed_at":  sq.Expr("now()"),
+		}).
+		Where("uuid IN (?)", documentUuid).
+		Suffix("RETURNING " + documentColumns).
+		ToSql()
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	rows, err := r.Db.Query(ctx, sql, args...)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+	document, err := pgx.CollectOneRow(rows, pgx.RowToStructByNameLax[entity.ExpertDocument])
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, fmt.Errorf("%s: %w", op, ErrDocumentNotFound)
+		}
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return &document, nil
+}
+
+func (r *Repo) DeleteDocument(ctx context.Context, documentUuid uuid.UUID) error {
+	const op = "repository.expert.DeleteDocument"
+
+	psql := sq.StatementBuilder.PlaceholderFormat(sq.Dollar)
+	sql, args, err := psql.Delete("expert_document").
+		Where("uuid IN (?)", documentUuid).
+		ToSql()
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	tag, err := r.Db.Exec(ctx, sql, args...)
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+	if tag.RowsAffected() == 0 {
+		return fmt.Errorf("%s: %w", op, ErrDocumentNotFound)
+	}
+
+	return nil
+}
+
+const documentColumns = "uuid, expert_uuid, kind, title, blob_key, file_name, content_type, size_bytes, url, " +
+	"status, verdict_note, reviewed_by, reviewed_at, uploaded_at"
+
+func (r *Repo) documentsBy(ctx context.Context, column string, value any) ([]entity.ExpertDocument, error) {
+	psql := sq.StatementBuilder.PlaceholderFormat(sq.Dollar)
+	sql, args, err := psql.Select(documentColumns).
+		From("expert_document").
+		Where(sq.Eq{column: value}).
+		OrderBy("uploaded_at DESC").
+		ToSql()
+	if err != nil {
+		return nil, err
+	}
+
+	rows, err := r.Db.Query(ctx, sql, args...)
+	if err != nil {
+		return nil, err
+	}
+
+	return pgx.CollectRows(rows, pgx.RowToStructByNameLax[entity.ExpertDocument])
+}
@@ -3,5 +3,12 @@ package expertrepo
 import "errors"
 
 var (
-	ErrExpertNotFound = errors.New("expert not found")
+	ErrExpertNotFound      = errors.New("expert not found")
+	ErrExpertAlreadyExists = errors.New("user has already applied to become an expert")
+	ErrRevisionNotPending  = errors.New("revision not found or already reviewed")
+	ErrNotResubmittable    = errors.New("only rejected applications can be resubmitted")
+	ErrNotReviewable       = errors.New("only pending applications can be reviewed")
+	ErrDocumentNotFound    = errors.New("document not found")
+	ErrInvalidFilter       = errors.New("invalid filter")
+	ErrVersionMismatch     = errors.New("expert profile was changed since it was read")
 )
@@ -7,10 +7,14 @@ import (
 
 	sq "github.com/Masterminds/squirrel"
 	"github.com/google/uuid"
+	"github.com/jackc/pgerrcode"
 	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
 
 	"github.com/bogdanshibilov/mindflowbackend/internal/db/postgres"
 	"github.com/bogdanshibilov/mindflowbackend/internal/entity"
+	"github.com/bogdanshibilov/mindflowbackend/internal/pagination"
+	"github.com/bogdanshibilov/mindflowbackend/internal/tenant"
 )
 
 type Repo struct {
@@ -25,11 +29,13 @@ func (r *Repo) CreateExpert(ctx context.Context, expert *entity.Expert) error {
 		Columns(
 			"user_uuid",
 			"price",
+			"currency",
 			"help_description",
 		).
 		Values(
 			expert.UserUuid,
 			expert.Price,
+			expert.Currency,
 			expert.HelpDescription,
 		).
 		ToSql()
@@ -37,6 +43,11 @@ func (r *Repo) CreateExpert(ctx context.Context, expert *entity.Expert) error {
 		return fmt.Errorf("%s: %w", op, err)
 	}
 
+	insertPriceSql, insertPriceArgs, err := insertPriceHistory(expert.UserUuid, expert.Price, expert.Currency)
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
 	insertApplicationSql, insertApplicationArgs, err := psql.Insert("expert_application").
 		Columns(
 			"user_uuid",
@@ -51,6 +62,14 @@ func (r *Repo) CreateExpert(ctx context.Context, expert *entity.Expert) error {
 		return fmt.Errorf("%s: %w", op, err)
 	}
 
+	insertEventSql, insertEventArgs, err := insertApplicationEvent(&entity.ExpertApplicationEvent{
+		UserUuid: expert.UserUuid,
+		Status:   expert.Status,
+	})
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
 	tx, err := r.Db.Begin(ctx)
 	if err != nil {
 		return fmt.Errorf("%s: %w", op, err)
@@ -65,6 +84,10 @@ func (r *Repo) CreateExpert(ctx context.Context, expert *entity.Expert) error {
 
 	_, err = tx.Exec(ctx, insertInfoSql, insertInfoArgs...)
 	if err != nil {
+		var pgError *pgconn.PgError
+		if errors.As(err, &pgError) && pgError.Code == pgerrcode.UniqueViolation {
+			err = ErrExpertAlreadyExists
+		}
 		return fmt.Errorf("%s: %w", op, err)
 	}
 
@@ -73,17 +96,39 @@ func (r *Repo) CreateExpert(ctx context.Context, expert *entity.Expert) error {
 		return fmt.Errorf("%s: %w", op, err)
 	}
 
+	_, err = tx.Exec(ctx, insertPriceSql, insertPriceArgs...)
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	_, err = tx.Exec(ctx, insertEventSql, insertEventArgs...)
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
 	return nil
 }
 
-func (r *Repo) ByUuid(ctx context.Context, uuid uuid.UUID) (*entity.Expert, error) {
+// Returns the expert if visible in the scope, ErrExpertNotFound otherwise
+func (r *Repo) ByUuid(ctx context.Context, scope tenant.Scope, uuid uuid.UUID) (*entity.Expert, error) {
 	const op = "repository.expert.ByUuid"
 
 	psql := sq.StatementBuilder.PlaceholderFormat(sq.Dollar)
 	sql, args, err := psql.Select(
 		"expert_information.user_uuid AS user_uuid",
 		"price",
+		"expert_information.currency AS currency",
 		"help_description",
+		VerifiedExpr+" AS verified",
+		"expert_information.languages AS languages",
+		RatingExpr+" AS rating",
+		reviewCountExpr+" AS review_count",
+		"expert_information.paused_at AS paused_at",
+		"expert_information.paused_until AS paused_until",
+		"expert_information.suspended_at AS suspended_at",
+		"expert_information.suspension_reason AS suspension_reason",
+		ActiveExpr+" AS active",
+		"expert_information.organization_uuid AS organization_uuid",
 		"status",
 		"submitted_at",
 		"email",
@@ -91,11 +136,19 @@ func (r *Repo) ByUuid(ctx context.Context, uuid uuid.UUID) (*entity.Expert, erro
 		"phone",
 		"professional_field",
 		"experience_description",
+		"email_visibility",
+		"phone_visibility",
+		"experience_visibility",
+		"avatar_hash",
+		"user_profiles.version AS version",
+		"expert_information.version AS information_version",
 	).
 		From("expert_information").
 		InnerJoin("expert_application ON expert_information.user_uuid = expert_application.user_uuid").
 		InnerJoin("user_profiles ON expert_application.user_uuid = user_profiles.user_uuid").
 		Where("expert_information.user_uuid IN (?)", uuid).
+		Where("expert_information.deleted_at IS NULL").
+		Where(scope.Where("expert_information.organization_uuid")).
 		ToSql()
 	if err != nil {
 		return nil, fmt.Errorf("%s: %w", op, err)
@@ -116,74 +169,71 @@ func (r *Repo) ByUuid(ctx context.Context, uuid uuid.UUID) (*entity.Expert, erro
 	return &expert, nil
 }
 
-func (r *Repo) UpdateExpertApplication(
-	ctx context.Context,
-	application *entity.ExpertApplication,
-	userUuid uuid.UUID,
-) error {
-	const op = "repository.expert.UpdateExpertApplication"
+// Returns the price range of approved, active experts visible in the scope
+// in base currency minor units
+func (r *Repo) MinMaxPrice(ctx context.Context, scope tenant.Scope) (*MinMaxPrice, error) {
+	const op = "repository.expert.MinMaxPrice"
 
 	psql := sq.StatementBuilder.PlaceholderFormat(sq.Dollar)
-	sql, args, err := psql.Update("expert_application").
-		SetMap(sq.Eq{
-			"status": application.Status,
-		},
-		).
-		Where("user_uuid IN (?)", userUuid).
+	sql, args, err := psql.Select(
+		"COALESCE(ROUND(MIN"+BasePriceExpr+"), 0)::INTEGER AS min_price",
+		"COALESCE(ROUND(MAX"+BasePriceExpr+"), 0)::INTEGER AS max_price",
+	).
+		From("expert_information").
+		InnerJoin("expert_application ON expert_information.user_uuid = expert_application.user_uuid").
+		InnerJoin("exchange_rate ON expert_information.currency = exchange_rate.currency").
+		Where("status IN (?)", entity.Approved).
+		Where(ActiveExpr).
+		Where(scope.Where("expert_information.organization_uuid")).
 		ToSql()
 	if err != nil {
-		return fmt.Errorf("%s: %w", op, err)
+		return nil, fmt.Errorf("%s: %w", op, err)
 	}
 
-	_, err = r.Db.Exec(ctx, sql, args...)
+	rows, err := r.Db.Query(ctx, sql, args...)
 	if err != nil {
-		return fmt.Errorf("%s: %w", op, err)
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+	result, err := pgx.CollectOneRow(rows, pgx.RowToStructByNameLax[MinMaxPrice])
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
 	}
 
-	return nil
+	return &result, nil
 }
 
-func (r *Repo) ProffFieldListAndCount(ctx context.Context) ([]ProffFieldListAndCount, error) {
-	const op = "repository.expert.ProffFieldListAndCount"
+func (r *Repo) ExpertsWithFilter(
+	ctx context.Context,
+	scope tenant.Scope,
+	filter *ExpertFilter,
+	page *pagination.Request,
+	opts ...ExpertsOption,
+) (*pagination.Page[entity.Expert], error) {
+	const op = "repository.expert.ExpertsWithFilter"
 
-	psql := sq.StatementBuilder.PlaceholderFormat(sq.Dollar)
-	sql, args, err := psql.Select(
-		"professional_field",
-		"COUNT(*) AS count",
-	).
-		From("user_profiles").
-		InnerJoin("expert_application ON user_profiles.user_uuid = expert_application.user_uuid").
-		Where("status IN (?)", entity.Approved).
-		GroupBy("professional_field").
-		ToSql()
+	err := filter.Validate()
 	if err != nil {
 		return nil, fmt.Errorf("%s: %w", op, err)
 	}
 
-	rows, err := r.Db.Query(ctx, sql, args...)
+	options := &expertsOptions{}
+	for _, opt := range opts {
+		opt(options)
+	}
+
+	expertsQuery := applyExpertsOptions(selectExperts(scope), filter, options)
+
+	countSql, countArgs, err := pagination.Count(expertsQuery).ToSql()
 	if err != nil {
 		return nil, fmt.Errorf("%s: %w", op, err)
 	}
-	result, err := pgx.CollectRows(rows, pgx.RowToStructByNameLax[ProffFieldListAndCount])
+	sql, args, err := page.Apply(expertsQuery, "expert_information.user_uuid").ToSql()
 	if err != nil {
 		return nil, fmt.Errorf("%s: %w", op, err)
 	}
 
-	return result, nil
-}
-
-func (r *Repo) MinMaxPrice(ctx context.Context) (*MinMaxPrice, error) {
-	const op = "repository.expert.MinMaxPrice"
-
-	psql := sq.StatementBuilder.PlaceholderFormat(sq.Dollar)
-	sql, args, err := psql.Select(
-		"MIN(price) AS min_price",
-		"MAX(price) AS max_price",
-	).
-		From("expert_information").
-		InnerJoin("expert_application ON expert_information.user_uuid = expert_application.user_uuid").
-		Where("status IN (?)", entity.Approved).
-		ToSql()
+	var total int
+	err = r.Db.QueryRow(ctx, countSql, countArgs...).Scan(&total)
 	if err != nil {
 		return nil, fmt.Errorf("%s: %w", op, err)
 	}
@@ -192,22 +242,42 @@ func (r *Repo) MinMaxPrice(ctx context.Context) (*MinMaxPrice, error) {
 	if err != nil {
 		return nil, fmt.Errorf("%s: %w", op, err)
 	}
-	result, err := pgx.CollectOneRow(rows, pgx.RowToStructByNameLax[MinMaxPrice])
+	expertRows, err := pgx.CollectRows(rows, pgx.RowToStructByNameLax[expertRow])
 	if err != nil {
 		return nil, fmt.Errorf("%s: %w", op, err)
 	}
 
-	return &result, nil
-}
+	pageRows := make([]pagination.Row[entity.Expert], 0, len(expertRows))
+	for _, row := range expertRows {
+		pageRows = append(pageRows, pagination.Row[entity.Expert]{
+			Item:    row.Expert,
+			Id:      row.UserUuid,
+			SortKey: row.SortKey,
+		})
+	}
 
-func (r *Repo) ExpertsWithFilter(ctx context.Context, filter map[string]any) ([]entity.Expert, error) {
-	const op = "repository.expert.ExpertsWithFilter"
+	return pagination.NewPage(page, pageRows, total), nil
+}
 
+// Selects experts visible in the scope with their profile and application, joined
+// with the exchange rate of their currency so filters can use BasePriceExpr
+func selectExperts(scope tenant.Scope) sq.SelectBuilder {
 	psql := sq.StatementBuilder.PlaceholderFormat(sq.Dollar)
-	expertsQuery := psql.Select(
+	return psql.Select(
 		"expert_information.user_uuid AS user_uuid",
 		"price",
+		"expert_information.currency AS currency",
 		"help_description",
+		VerifiedExpr+" AS verified",
+		"expert_information.languages AS languages",
+		RatingExpr+" AS rating",
+		reviewCountExpr+" AS review_count",
+		"expert_information.paused_at AS paused_at",
+		"expert_information.paused_until AS paused_until",
+		"expert_information.suspended_at AS suspended_at",
+		"expert_information.suspension_reason AS suspension_reason",
+		ActiveExpr+" AS active",
+		"expert_information.organization_uuid AS organization_uuid",
 		"status",
 		"submitted_at",
 		"email",
@@ -215,55 +285,25 @@ func (r *Repo) ExpertsWithFilter(ctx context.Context, filter map[string]any) ([]
 		"phone",
 		"professional_field",
 		"experience_description",
+		"email_visibility",
+		"phone_visibility",
+		"experience_visibility",
+		"avatar_hash",
+		"user_profiles.version AS version",
+		"expert_information.version AS information_version",
 	).
 		From("expert_information").
 		InnerJoin("expert_application ON expert_information.user_uuid = expert_application.user_uuid").
-		InnerJoin("user_profiles ON expert_application.user_uuid = user_profiles.user_uuid")
-
-	for column, value := range filter {
-		expertsQuery = expertsQuery.Where(column, value)
-	}
-	sql, args, err := expertsQuery.ToSql()
-	if err != nil {
-		return nil, fmt.Errorf("%s: %w", op, err)
-	}
-
-	rows, err := r.Db.Query(ctx, sql, args...)
-	if err != nil {
-		return nil, fmt.Errorf("%s: %w", op, err)
-	}
-
-	experts, err := pgx.CollectRows(rows, pgx.RowToStructByNameLax[entity.Expert])
-	if err != nil {
-		return nil, fmt.Errorf("%s: %w", op, err)
-	}
-
-	return experts, err
+		InnerJoin("user_profiles ON expert_application.user_uuid = user_profiles.user_uuid").
+		LeftJoin("exchange_rate ON expert_information.currency = exchange_rate.currency").
+		Where("expert_information.deleted_at IS NULL").
+		Where(scope.Where("expert_information.organization_uuid"))
 }
 
-func (r *Repo) DoesExist(ctx context.Context, uuid uuid.UUID) (bool, error) {
-	const op = "repository.expert.DoesExist"
-
-	psql := sq.StatementBuilder.PlaceholderFormat(sq.Dollar)
-	sql, args, err := psql.Select(
-		"Count(*)",
-	).
-		From("expert_application").
-		Where("user_uuid IN (?)", uuid).
-		ToSql()
-	if err != nil {
-		return false, fmt.Errorf("%s: %w", op, err)
-	}
-
-	var count int
-	err = r.Db.QueryRow(ctx, sql, args...).Scan(&count)
-	if err != nil {
-		return false, fmt.Errorf("%s: %w", op, err)
-	}
-
-	if count > 0 {
-		return true, nil
-	} else {
-		return false, nil
+func applyExpertsOptions(query sq.SelectBuilder, filter *ExpertFilter, options *expertsOptions) sq.SelectBuilder {
+	query = filter.apply(query)
+	if options.limit > 0 {
+		query = query.Limit(options.limit).Offset(options.offset)
 	}
+	return query
 }
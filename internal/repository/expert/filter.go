@@ -0,0 +1,139 @@
+package expertrepo
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+	"time"
+
+	sq "github.com/Masterminds/squirrel"
+	"github.com/google/uuid"
+
+	"github.com/bogdanshibilov/mindflowbackend/internal/entity"
+)
+
+const maxFilterNameLength = 255
+
+var languageCode = regexp.MustCompile(`^[a-z]{2,3}$`)
+
+// Conditions experts are listed and searched by. Zero fields do not filter
+type ExpertFilter struct {
+	Status *entity.Status
+	// Bounds in base currency minor units
+	MinPrice *int
+	MaxPrice *int
+	// Experts with any of the skills, or with all of them when MatchAllSkills is set
+	SkillUuids     []uuid.UUID
+	MatchAllSkills bool
+	// Case insensitive part of the name
+	Name string
+	// Experts consulting in any of the ISO 639 language codes
+	Languages []string
+	// Lowest average rating, experts without reviews are left out
+	MinRating *float64
+	// Experts with an availability slot on any of the weekdays
+	AvailableOn  []time.Weekday
+	OnlyVerified bool
+	// Leaves out paused and suspended experts
+	OnlyActive bool
+}
+
+// Rejects filters that cannot match and drops repeated skills
+func (f *ExpertFilter) Validate() error {
+	if f.Status != nil && *f.Status != entity.Pending && *f.Status != entity.Approved && *f.Status != entity.Rejected {
+		return fmt.Errorf("%w: unknown status", ErrInvalidFilter)
+	}
+	if (f.MinPrice != nil && *f.MinPrice < 0) || (f.MaxPrice != nil && *f.MaxPrice < 0) {
+		return fmt.Errorf("%w: prices must not be negative", ErrInvalidFilter)
+	}
+	if f.MinPrice != nil && f.MaxPrice != nil && *f.MinPrice > *f.MaxPrice {
+		return fmt.Errorf("%w: min price is above max price", ErrInvalidFilter)
+	}
+	if len(f.Name) > maxFilterNameLength {
+		return fmt.Errorf("%w: name is too long", ErrInvalidFilter)
+	}
+	for _, language := range f.Languages {
+		if !languageCode.MatchString(language) {
+			return fmt.Errorf("%w: languages must be lower case ISO 639 codes", ErrInvalidFilter)
+		}
+	}
+	if f.MinRating != nil && (*f.MinRating < 1 || *f.MinRating > 5) {
+		return fmt.Errorf("%w: rating must be between 1 and 5", ErrInvalidFilter)
+	}
+	for _, weekday := range f.AvailableOn {
+		if weekday < time.Sunday || weekday > time.Saturday {
+			return fmt.Errorf("%w: unknown weekday", ErrInvalidFilter)
+		}
+	}
+
+	// MatchAllSkills counts the skills, a repeated one could never be matched
+	seen := make(map[uuid.UUID]bool, len(f.SkillUuids))
+	var skillUuids []uuid.UUID
+	for _, skillUuid := range f.SkillUuids {
+		if !seen[skillUuid] {
+			seen[skillUuid] = true
+			skillUuids = append(skillUuids, skillUuid)
+		}
+	}
+	f.SkillUuids = skillUuids
+
+	return nil
+}
+
+// Adds the conditions to a query built by selectExperts
+func (f *ExpertFilter) apply(query sq.SelectBuilder) sq.SelectBuilder {
+	if f.Status != nil {
+		query = query.Where("status IN (?)", *f.Status)
+	}
+	if f.MinPrice != nil {
+		query = query.Where(BasePriceExpr+" >= ?", *f.MinPrice)
+	}
+	if f.MaxPrice != nil {
+		query = query.Where(BasePriceExpr+" <= ?", *f.MaxPrice)
+	}
+	if len(f.SkillUuids) > 0 {
+		if f.MatchAllSkills {
+			query = query.Where(
+				"expert_information.user_uuid IN (SELECT expert_uuid FROM expert_skill WHERE skill_uuid = ANY(?) GROUP BY expert_uuid HAVING COUNT(*) = ?)",
+				f.SkillUuids, len(f.SkillUuids),
+			)
+		} else {
+			query = query.Where(
+				"expert_information.user_uuid IN (SELECT expert_uuid FROM expert_skill WHERE skill_uuid = ANY(?))",
+				f.SkillUuids,
+			)
+		}
+	}
+	if f.Name != "" {
+		query = query.Where("name ILIKE (?)", "%"+escapeLike(f.Name)+"%")
+	}
+	if len(f.Languages) > 0 {
+		query = query.Where("expert_information.languages && ?::varchar[]", f.Languages)
+	}
+	if f.MinRating != nil {
+		query = query.Where(RatingExpr+" >= ?", *f.MinRating)
+	}
+	if len(f.AvailableOn) > 0 {
+		weekdays := make([]int, 0, len(f.AvailableOn))
+		for _, weekday := range f.AvailableOn {
+			weekdays = append(weekdays, int(weekday))
+		}
+		query = query.Where(
+			"EXISTS (SELECT 1 FROM expert_availability WHERE expert_availability.expert_uuid = expert_information.user_uuid AND weekday = ANY(?))",
+			weekdays,
+		)
+	}
+	if f.OnlyVerified {
+		query = query.Where(VerifiedExpr)
+	}
+	if f.OnlyActive {
+		query = query.Where(ActiveExpr)
+	}
+	return query
+}
+
+var likeEscaper = strings.NewReplacer(`\`, `\\`, `%`, `\%`, `_`, `\_`)
+
+func escapeLike(value string) string {
+	return likeEscaper.Replace(value)
+}
@@ -0,0 +1,232 @@
+package expertrepo
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+	"time"
+
+	sq "github.com/Masterminds/squirrel"
+	"github.com/google/uuid"
+
+	"github.com/bogdanshibilov/mindflowbackend/internal/entity"
+)
+
+func ptr[T any](value T) *T {
+	return &value
+}
+
+func TestExpertFilterApply(t *testing.T) {
+	const base = "SELECT * FROM expert_information"
+
+	skillA := uuid.MustParse("6f1c2a1e-0000-4000-8000-000000000001")
+	skillB := uuid.MustParse("6f1c2a1e-0000-4000-8000-000000000002")
+
+	tests := []struct {
+		name     string
+		filter   ExpertFilter
+		wantSql  string
+		wantArgs []any
+	}{
+		{
+			name:    "empty",
+			filter:  ExpertFilter{},
+			wantSql: base,
+		},
+		{
+			name:     "status",
+			filter:   ExpertFilter{Status: ptr(entity.Approved)},
+			wantSql:  base + " WHERE status IN (?)",
+			wantArgs: []any{entity.Approved},
+		},
+		{
+			name:     "min price",
+			filter:   ExpertFilter{MinPrice: ptr(1000)},
+			wantSql:  base + " WHERE " + BasePriceExpr + " >= ?",
+			wantArgs: []any{1000},
+		},
+		{
+			// Used to be dropped unless a min price was given too
+			name:     "max price",
+			filter:   ExpertFilter{MaxPrice: ptr(5000)},
+			wantSql:  base + " WHERE " + BasePriceExpr + " <= ?",
+			wantArgs: []any{5000},
+		},
+		{
+			name:     "price range",
+			filter:   ExpertFilter{MinPrice: ptr(1000), MaxPrice: ptr(5000)},
+			wantSql:  base + " WHERE " + BasePriceExpr + " >= ? AND " + BasePriceExpr + " <= ?",
+			wantArgs: []any{1000, 5000},
+		},
+		{
+			name:     "any skill",
+			filter:   ExpertFilter{SkillUuids: []uuid.UUID{skillA, skillB}},
+			wantSql:  base + " WHERE expert_information.user_uuid IN (SELECT expert_uuid FROM expert_skill WHERE skill_uuid = ANY(?))",
+			wantArgs: []any{[]uuid.UUID{skillA, skillB}},
+		},
+		{
+			name:     "all skills",
+			filter:   ExpertFilter{SkillUuids: []uuid.UUID{skillA, skillB}, MatchAllSkills: true},
+			wantSql:  base + " WHERE expert_information.user_uuid IN (SELECT expert_uuid FROM expert_skill WHERE skill_uuid = ANY(?) GROUP BY expert_uuid HAVING COUNT(*) = ?)",
+			wantArgs: []any{[]uuid.UUID{skillA, skillB}, 2},
+		},
+		{
+			name:     "all skills repeated",
+			filter:   ExpertFilter{SkillUuids: []uuid.UUID{skillA, skillB, skillA}, MatchAllSkills: true},
+			wantSql:  base + " WHERE expert_information.user_uuid IN (SELECT expert_uuid FROM expert_skill WHERE skill_uuid = ANY(?) GROUP BY expert_uuid HAVING COUNT(*) = ?)",
+			wantArgs: []any{[]uuid.UUID{skillA, skillB}, 2},
+		},
+		{
+			name:    "match all without skills",
+			filter:  ExpertFilter{MatchAllSkills: true},
+			wantSql: base,
+		},
+		{
+			name:     "name",
+			filter:   ExpertFilter{Name: "ann"},
+			wantSql:  base + " WHERE name ILIKE (?)",
+			wantArgs: []any{"%ann%"},
+		},
+		{
+			name:     "name with like wildcards",
+			filter:   ExpertFilter{Name: `50%_off\`},
+			wantSql:  base + " WHERE name ILIKE (?)",
+			wantArgs: []any{`%50\%\_off\\%`},
+		},
+		{
+			name:     "languages",
+			filter:   ExpertFilter{Languages: []string{"en", "kk"}},
+			wantSql:  base + " WHERE expert_information.languages && ?::varchar[]",
+			wantArgs: []any{[]string{"en", "kk"}},
+		},
+		{
+			name:     "min rating",
+			filter:   ExpertFilter{MinRating: ptr(4.5)},
+			wantSql:  base + " WHERE " + RatingExpr + " >= ?",
+			wantArgs: []any{4.5},
+		},
+		{
+			name:     "weekdays",
+			filter:   ExpertFilter{AvailableOn: []time.Weekday{time.Sunday, time.Wednesday}},
+			wantSql:  base + " WHERE EXISTS (SELECT 1 FROM expert_availability WHERE expert_availability.expert_uuid = expert_information.user_uuid AND weekday = ANY(?))",
+			wantArgs: []any{[]int{0, 3}},
+		},
+		{
+			name:    "verified",
+			filter:  ExpertFilter{OnlyVerified: true},
+			wantSql: base + " WHERE " + VerifiedExpr,
+		},
+		{
+			name:    "active",
+			filter:  ExpertFilter{OnlyActive: true},
+			wantSql: base + " WHERE " + ActiveExpr,
+		},
+		{
+			name: "everything",
+			filter: ExpertFilter{
+				Status:         ptr(entity.Approved),
+				MinPrice:       ptr(1000),
+				MaxPrice:       ptr(5000),
+				SkillUuids:     []uuid.UUID{skillA},
+				MatchAllSkills: true,
+				Name:           "ann",
+				Languages:      []string{"en"},
+				MinRating:      ptr(4.0),
+				AvailableOn:    []time.Weekday{time.Monday},
+				OnlyVerified:   true,
+				OnlyActive:     true,
+			},
+			wantSql: base + " WHERE status IN (?)" +
+				" AND " + BasePriceExpr + " >= ?" +
+				" AND " + BasePriceExpr + " <= ?" +
+				" AND expert_information.user_uuid IN (SELECT expert_uuid FROM expert_skill WHERE skill_uuid = ANY(?) GROUP BY expert_uuid HAVING COUNT(*) = ?)" +
+				" AND name ILIKE (?)" +
+				" AND expert_information.languages && ?::varchar[]" +
+				" AND " + RatingExpr + " >= ?" +
+				" AND EXISTS (SELECT 1 FROM expert_availability WHERE expert_availability.expert_uuid = expert_information.user_uuid AND weekday = ANY(?))" +
+				" AND " + VerifiedExpr +
+				" AND " + ActiveExpr,
+			wantArgs: []any{
+				entity.Approved,
+				1000,
+				5000,
+				[]uuid.UUID{skillA},
+				1,
+				"%ann%",
+				[]string{"en"},
+				4.0,
+				[]int{1},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Filters are validated before they reach a query
+			if err := tt.filter.Validate(); err != nil {
+				t.Fatalf("Validate() error = %v", err)
+			}
+			sql, args, err := tt.filter.apply(sq.Select("*").From("expert_information")).ToSql()
+			if err != nil {
+				t.Fatalf("ToSql() error = %v", err)
+			}
+			if sql != tt.wantSql {
+				t.Errorf("sql = %q, want %q", sql, tt.wantSql)
+			}
+			if len(args) != 0 || len(tt.wantArgs) != 0 {
+				if !reflect.DeepEqual(args, tt.wantArgs) {
+					t.Errorf("args = %#v, want %#v", args, tt.wantArgs)
+				}
+			}
+		})
+	}
+}
+
+func TestExpertFilterValidate(t *testing.T) {
+	tests := []struct {
+		name    string
+		filter  ExpertFilter
+		wantErr bool
+	}{
+		{name: "empty", filter: ExpertFilter{}},
+		{name: "pending status", filter: ExpertFilter{Status: ptr(entity.Pending)}},
+		{name: "unknown status", filter: ExpertFilter{Status: ptr(entity.Status(42))}, wantErr: true},
+		{name: "min price", filter: ExpertFilter{MinPrice: ptr(0)}},
+		{name: "max price", filter: ExpertFilter{MaxPrice: ptr(5000)}},
+		{name: "price range", filter: ExpertFilter{MinPrice: ptr(1000), MaxPrice: ptr(5000)}},
+		{name: "equal prices", filter: ExpertFilter{MinPrice: ptr(1000), MaxPrice: ptr(1000)}},
+		{name: "negative min price", filter: ExpertFilter{MinPrice: ptr(-1)}, wantErr: true},
+		{name: "negative max price", filter: ExpertFilter{MaxPrice: ptr(-1)}, wantErr: true},
+		{name: "min price above max price", filter: ExpertFilter{MinPrice: ptr(5000), MaxPrice: ptr(1000)}, wantErr: true},
+		{name: "any skill", filter: ExpertFilter{SkillUuids: []uuid.UUID{uuid.New()}}},
+		{name: "all skills", filter: ExpertFilter{SkillUuids: []uuid.UUID{uuid.New(), uuid.New()}, MatchAllSkills: true}},
+		{name: "name", filter: ExpertFilter{Name: "ann"}},
+		{name: "name too long", filter: ExpertFilter{Name: string(make([]byte, maxFilterNameLength+1))}, wantErr: true},
+		{name: "languages", filter: ExpertFilter{Languages: []string{"en", "kaz"}}},
+		{name: "upper case language", filter: ExpertFilter{Languages: []string{"EN"}}, wantErr: true},
+		{name: "language too long", filter: ExpertFilter{Languages: []string{"english"}}, wantErr: true},
+		{name: "min rating", filter: ExpertFilter{MinRating: ptr(4.5)}},
+		{name: "lowest rating", filter: ExpertFilter{MinRating: ptr(1.0)}},
+		{name: "rating below 1", filter: ExpertFilter{MinRating: ptr(0.5)}, wantErr: true},
+		{name: "rating above 5", filter: ExpertFilter{MinRating: ptr(5.5)}, wantErr: true},
+		{name: "weekdays", filter: ExpertFilter{AvailableOn: []time.Weekday{time.Sunday, time.Saturday}}},
+		{name: "unknown weekday", filter: ExpertFilter{AvailableOn: []time.Weekday{7}}, wantErr: true},
+		{name: "negative weekday", filter: ExpertFilter{AvailableOn: []time.Weekday{-1}}, wantErr: true},
+		{name: "verified and active", filter: ExpertFilter{OnlyVerified: true, OnlyActive: true}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := tt.filter.Validate()
+			if tt.wantErr {
+				if !errors.Is(err, ErrInvalidFilter) {
+					t.Errorf("Validate() error = %v, want %v", err, ErrInvalidFilter)
+				}
+				return
+			}
+			if err != nil {
+				t.Errorf("Validate() error = %v, want nil", err)
+			}
+		})
+	}
+}
@@ -1,7 +1,66 @@
 package expertrepo
 
-import "github.com/bogdanshibilov/mindflowbackend/internal/entity"
+import (
+	"github.com/bogdanshibilov/mindflowbackend/internal/entity"
+	"github.com/bogdanshibilov/mindflowbackend/internal/pagination"
+)
 
 const (
 	AllStatus entity.Status = -1
 )
+
+// Expert price converted to base currency minor units, NULL if the currency has no exchange rate
+const BasePriceExpr = "(expert_information.price * exchange_rate.rate)"
+
+// True when at least one document of the expert was accepted and none is waiting for review
+const VerifiedExpr = "(EXISTS (SELECT 1 FROM expert_document WHERE expert_document.expert_uuid = expert_information.user_uuid AND expert_document.status = 'accepted')" +
+	" AND NOT EXISTS (SELECT 1 FROM expert_document WHERE expert_document.expert_uuid = expert_information.user_uuid AND expert_document.status = 'pending'))"
+
+// Experts that are neither deleted, suspended as experts or as users nor paused.
+// Pauses and account suspensions end by themselves at paused_until and suspended_until
+const ActiveExpr = "(expert_information.deleted_at IS NULL AND expert_information.suspended_at IS NULL" +
+	" AND (expert_information.paused_at IS NULL OR expert_information.paused_until <= now())" +
+	" AND NOT EXISTS (SELECT 1 FROM users AS account WHERE account.uuid = expert_information.user_uuid" +
+	" AND account.suspended_at IS NOT NULL AND (account.suspended_until IS NULL OR account.suspended_until > now())))"
+
+// Average review rating of the expert, NULL without reviews
+const RatingExpr = "(SELECT AVG(consultation_review.rating)::float8 FROM consultation_review" +
+	" INNER JOIN consultation ON consultation.uuid = consultation_review.consultation_uuid" +
+	" WHERE consultation.expert_uuid = expert_information.user_uuid)"
+
+const reviewCountExpr = "(SELECT COUNT(*) FROM consultation_review" +
+	" INNER JOIN consultation ON consultation.uuid = consultation_review.consultation_uuid" +
+	" WHERE consultation.expert_uuid = expert_information.user_uuid)"
+
+// Sort keys of expert lists
+var Sorts = map[string]pagination.Sort{
+	"name":      {Expr: "lower(user_profiles.name)", Type: "text"},
+	"price":     {Expr: "COALESCE(ROUND" + BasePriceExpr + ", 'Infinity')", Type: "numeric"},
+	"rating":    {Expr: "COALESCE(" + RatingExpr + ", 0)", Type: "float8", Desc: true},
+	"submitted": {Expr: "COALESCE(expert_application.submitted_at, 'epoch')", Type: "timestamp", Desc: true},
+}
+
+type expertsOptions struct {
+	orderBy string
+	limit   uint64
+	offset  uint64
+}
+
+type ExpertsOption func(*expertsOptions)
+
+func OrderByBasePrice(desc bool) ExpertsOption {
+	return func(eo *expertsOptions) {
+		if desc {
+			eo.orderBy = BasePriceExpr + " DESC NULLS LAST"
+		} else {
+			eo.orderBy = BasePriceExpr + " ASC NULLS LAST"
+		}
+	}
+}
+
+func Page(limit uint64, offset uint64) ExpertsOption {
+	return func(eo *expertsOptions) {
+		eo.limit = limit
+		eo.offset = offset
+	}
+}
@@ -0,0 +1,118 @@
+package expertrepo
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	sq "github.com/Masterminds/squirrel"
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+)
+
+// Stops bookings of the expert until the given time, or until they resume when it is nil
+func (r *Repo) Pause(ctx context.Context, expertUuid uuid.UUID, until *time.Time) error {
+	const op = "repository.expert.Pause"
+
+	psql := sq.StatementBuilder.PlaceholderFormat(sq.Dollar)
+	sql, args, err := psql.Update("expert_information").
+		Set("paused_at", sq.Expr("now()")).
+		Set("paused_until", until).
+		Where("user_uuid IN (?)", expertUuid).
+		ToSql()
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	return r.execExpertUpdate(ctx, op, sql, args)
+}
+
+func (r *Repo) Resume(ctx context.Context, expertUuid uuid.UUID) error {
+	const op = "repository.expert.Resume"
+
+	psql := sq.StatementBuilder.PlaceholderFormat(sq.Dollar)
+	sql, args, err := psql.Update("expert_information").
+		Set("paused_at", nil).
+		Set("paused_until", nil).
+		Where("user_uuid IN (?)", expertUuid).
+		ToSql()
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	return r.execExpertUpdate(ctx, op, sql, args)
+}
+
+func (r *Repo) Suspend(ctx context.Context, expertUuid uuid.UUID, reason string, adminUuid uuid.UUID) error {
+	const op = "repository.expert.Suspend"
+
+	psql := sq.StatementBuilder.PlaceholderFormat(sq.Dollar)
+	sql, args, err := psql.Update("expert_information").
+		Set("suspended_at", sq.Expr("now()")).
+		Set("suspension_reason", reason).
+		Set("suspended_by", adminUuid).
+		Where("user_uuid IN (?)", expertUuid).
+		ToSql()
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	return r.execExpertUpdate(ctx, op, sql, args)
+}
+
+func (r *Repo) LiftSuspension(ctx context.Context, expertUuid uuid.UUID) error {
+	const op = "repository.expert.LiftSuspension"
+
+	psql := sq.StatementBuilder.PlaceholderFormat(sq.Dollar)
+	sql, args, err := psql.Update("expert_information").
+		Set("suspended_at", nil).
+		Set("suspension_reason", nil).
+		Set("suspended_by", nil).
+		Where("user_uuid IN (?)", expertUuid).
+		ToSql()
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	return r.execExpertUpdate(ctx, op, sql, args)
+}
+
+// Clears pauses that have ended and returns the experts they belonged to
+func (r *Repo) EndExpiredPauses(ctx context.Context) ([]uuid.UUID, error) {
+	const op = "repository.expert.EndExpiredPauses"
+
+	psql := sq.StatementBuilder.PlaceholderFormat(sq.Dollar)
+	sql, args, err := psql.Update("expert_information").
+		Set("paused_at", nil).
+		Set("paused_until", nil).
+		Where("paused_until <= now()").
+		Where("deleted_at IS NULL").
+		Suffix("RETURNING user_uuid").
+		ToSql()
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	rows, err := r.Db.Query(ctx, sql, args...)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+	expertUuids, err := pgx.CollectRows(rows, pgx.RowTo[uuid.UUID])
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return expertUuids, nil
+}
+
+func (r *Repo) execExpertUpdate(ctx context.Context, op string, sql string, args []any) error {
+	tag, err := r.Db.Exec(ctx, sql, args...)
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+	if tag.RowsAffected() == 0 {
+		return fmt.Errorf("%s: %w", op, ErrExpertNotFound)
+	}
+
+	return nil
+}
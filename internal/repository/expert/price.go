@@ -0,0 +1,108 @@
+package expertrepo
+
+import (
+	"context"
+	"fmt"
+
+	sq "github.com/Masterminds/squirrel"
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+
+	"github.com/bogdanshibilov/mindflowbackend/internal/entity"
+)
+
+// Changes the price of an expert and records it in the price history
+func (r *Repo) UpdatePrice(ctx context.Context, expertUuid uuid.UUID, amount int, currency string) error {
+	const op = "repository.expert.UpdatePrice"
+
+	psql := sq.StatementBuilder.PlaceholderFormat(sq.Dollar)
+	updateSql, updateArgs, err := psql.Update("expert_information").
+		Set("price", amount).
+		Set("currency", currency).
+		Set("version", sq.Expr("version + 1")).
+		Where("user_uuid IN (?)", expertUuid).
+		ToSql()
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	insertSql, insertArgs, err := insertPriceHistory(expertUuid, amount, currency)
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	tx, err := r.Db.Begin(ctx)
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+	defer func() {
+		if err != nil {
+			_ = tx.Rollback(ctx)
+		} else {
+			_ = tx.Commit(ctx)
+		}
+	}()
+
+	tag, err := tx.Exec(ctx, updateSql, updateArgs...)
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+	if tag.RowsAffected() == 0 {
+		err = ErrExpertNotFound
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	_, err = tx.Exec(ctx, insertSql, insertArgs...)
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	return nil
+}
+
+func (r *Repo) PriceHistory(ctx context.Context, expertUuid uuid.UUID) ([]entity.ExpertPrice, error) {
+	const op = "repository.expert.PriceHistory"
+
+	psql := sq.StatementBuilder.PlaceholderFormat(sq.Dollar)
+	sql, args, err := psql.Select(
+		"uuid",
+		"expert_uuid",
+		"amount",
+		"currency",
+		"changed_at",
+	).
+		From("expert_price_history").
+		Where("expert_uuid IN (?)", expertUuid).
+		OrderBy("changed_at DESC").
+		ToSql()
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	rows, err := r.Db.Query(ctx, sql, args...)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+	prices, err := pgx.CollectRows(rows, pgx.RowToStructByNameLax[entity.ExpertPrice])
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return prices, nil
+}
+
+func insertPriceHistory(expertUuid uuid.UUID, amount int, currency string) (string, []any, error) {
+	psql := sq.StatementBuilder.PlaceholderFormat(sq.Dollar)
+	return psql.Insert("expert_price_history").
+		Columns(
+			"expert_uuid",
+			"amount",
+			"currency",
+		).
+		Values(
+			expertUuid,
+			amount,
+			currency,
+		).
+		ToSql()
+}
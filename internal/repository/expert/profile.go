@@ -0,0 +1,111 @@
+package expertrepo
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	sq "github.com/Masterminds/squirrel"
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+
+	"github.com/bogdanshibilov/mindflowbackend/internal/entity"
+)
+
+// Saves the profile fields of an expert: name, phone, professional field,
+// experience and help description and languages. Fails with ErrVersionMismatch
+// unless the stored versions are still the ones on the expert, which are
+// advanced on success
+func (r *Repo) UpdateProfile(ctx context.Context, expert *entity.Expert) error {
+	const op = "repository.expert.UpdateProfile"
+
+	psql := sq.StatementBuilder.PlaceholderFormat(sq.Dollar)
+	updateProfileSql, updateProfileArgs, err := psql.Update("user_profiles").
+		SetMap(sq.Eq{
+			"name":                   expert.Name,
+			"phone":                  expert.Phone,
+			"professional_field":     expert.ProfessionalField,
+			"experience_description": expert.ExperienceDescription,
+			"version":                sq.Expr("version + 1"),
+		}).
+		Where("user_uuid IN (?)", expert.UserUuid).
+		Where("version IN (?)", expert.UserProfile.Version).
+		Suffix("RETURNING version").
+		ToSql()
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	updateInfoSql, updateInfoArgs, err := psql.Update("expert_information").
+		Set("help_description", expert.HelpDescription).
+		Set("languages", expert.Languages).
+		Set("version", sq.Expr("version + 1")).
+		Where("user_uuid IN (?)", expert.UserUuid).
+		Where("version IN (?)", expert.ExpertInformation.Version).
+		Suffix("RETURNING version").
+		ToSql()
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	tx, err := r.Db.Begin(ctx)
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+	defer func() {
+		if err != nil {
+			_ = tx.Rollback(ctx)
+		} else {
+			_ = tx.Commit(ctx)
+		}
+	}()
+
+	var profileVersion, infoVersion int
+	err = tx.QueryRow(ctx, updateProfileSql, updateProfileArgs...).Scan(&profileVersion)
+	if errors.Is(err, pgx.ErrNoRows) {
+		err = r.staleOrMissing(ctx, expert.UserUuid)
+	}
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	err = tx.QueryRow(ctx, updateInfoSql, updateInfoArgs...).Scan(&infoVersion)
+	if errors.Is(err, pgx.ErrNoRows) {
+		err = r.staleOrMissing(ctx, expert.UserUuid)
+	}
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	expert.UserProfile.Version = profileVersion
+	expert.ExpertInformation.Version = infoVersion
+
+	return nil
+}
+
+// Tells why a version checked update matched no row: the expert is gone or
+// someone else changed them first
+func (r *Repo) staleOrMissing(ctx context.Context, expertUuid uuid.UUID) error {
+	psql := sq.StatementBuilder.PlaceholderFormat(sq.Dollar)
+	sql, args, err := psql.Select("1").
+		From("expert_information").
+		Where("user_uuid IN (?)", expertUuid).
+		Where("deleted_at IS NULL").
+		Prefix("SELECT EXISTS (").
+		Suffix(")").
+		ToSql()
+	if err != nil {
+		return err
+	}
+
+	var exists bool
+	err = r.Db.QueryRow(ctx, sql, args...).Scan(&exists)
+	if err != nil {
+		return err
+	}
+	if !exists {
+		return ErrExpertNotFound
+	}
+
+	return ErrVersionMismatch
+}
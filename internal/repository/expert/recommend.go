@@ -0,0 +1,43 @@
+package expertrepo
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/jackc/pgx/v5"
+
+	"github.com/bogdanshibilov/mindflowbackend/internal/entity"
+	"github.com/bogdanshibilov/mindflowbackend/internal/tenant"
+)
+
+type PricedExpert struct {
+	entity.Expert
+	// Price in base currency minor units, nil when the currency has no exchange rate
+	BasePrice *int `db:"base_price"`
+}
+
+// Returns every approved, active expert visible in the scope with the price
+// converted to the base currency
+func (r *Repo) ApprovedExperts(ctx context.Context, scope tenant.Scope) ([]PricedExpert, error) {
+	const op = "repository.expert.ApprovedExperts"
+
+	sql, args, err := selectExperts(scope).
+		Column("ROUND"+BasePriceExpr+"::INTEGER AS base_price").
+		Where("status IN (?)", entity.Approved).
+		Where(ActiveExpr).
+		ToSql()
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	rows, err := r.Db.Query(ctx, sql, args...)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+	experts, err := pgx.CollectRows(rows, pgx.RowToStructByNameLax[PricedExpert])
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return experts, nil
+}
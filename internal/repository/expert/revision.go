@@ -0,0 +1,225 @@
+package expertrepo
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	sq "github.com/Masterminds/squirrel"
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+
+	"github.com/bogdanshibilov/mindflowbackend/internal/entity"
+)
+
+// Submits a revision for review. Changes are merged into a revision that is
+// still pending, revision is updated to the merged result
+func (r *Repo) SubmitRevision(ctx context.Context, revision *entity.ExpertRevision) error {
+	const op = "repository.expert.SubmitRevision"
+
+	psql := sq.StatementBuilder.PlaceholderFormat(sq.Dollar)
+	sql, args, err := psql.Insert("expert_revision").
+		Columns(
+			"expert_uuid",
+			"professional_field",
+			"help_description",
+			"skill_uuids",
+			"status",
+		).
+		Values(
+			revision.ExpertUuid,
+			revision.ProfessionalField,
+			revision.HelpDescription,
+			revision.SkillUuids,
+			entity.Pending,
+		).
+		Suffix("ON CONFLICT (expert_uuid) WHERE status = 0 DO UPDATE SET " +
+			"professional_field = COALESCE(EXCLUDED.professional_field, expert_revision.professional_field), " +
+			"help_description = COALESCE(EXCLUDED.help_description, expert_revision.help_description), " +
+			"skill_uuids = COALESCE(EXCLUDED.skill_uuids, expert_revision.skill_uuids), " +
+			"submitted_at = now()").
+		Suffix("RETURNING uuid, professional_field, help_description, skill_uuids, status, submitted_at").
+		ToSql()
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	err = r.Db.QueryRow(ctx, sql, args...).Scan(
+		&revision.Uuid,
+		&revision.ProfessionalField,
+		&revision.HelpDescription,
+		&revision.SkillUuids,
+		&revision.Status,
+		&revision.SubmittedAt,
+	)
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	return nil
+}
+
+func (r *Repo) RevisionsByStatus(ctx context.Context, status entity.Status) ([]entity.ExpertRevision, error) {
+	const op = "repository.expert.RevisionsByStatus"
+
+	revisions, err := r.revisionsBy(ctx, "status", status)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return revisions, nil
+}
+
+func (r *Repo) RevisionsByExpertUuid(ctx context.Context, expertUuid uuid.UUID) ([]entity.ExpertRevision, error) {
+	const op = "repository.expert.RevisionsByExpertUuid"
+
+	revisions, err := r.revisionsBy(ctx, "expert_uuid", expertUuid)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return revisions, nil
+}
+
+// Approves or rejects a pending revision. Approved changes are applied to
+// the expert in the same transaction
+func (r *Repo) ReviewRevision(
+	ctx context.Context,
+	revisionUuid uuid.UUID,
+	status entity.Status,
+	reviewerUuid uuid.UUID,
+) (*entity.ExpertRevision, error) {
+	const op = "repository.expert.ReviewRevision"
+
+	psql := sq.StatementBuilder.PlaceholderFormat(sq.Dollar)
+	reviewSql, reviewArgs, err := psql.Update("expert_revision").
+		Set("status", status).
+		Set("reviewed_by", reviewerUuid).
+		Set("reviewed_at", sq.Expr("now()")).
+		Where("uuid IN (?)", revisionUuid).
+		Where("status IN (?)", entity.Pending).
+		Suffix("RETURNING " + revisionColumns).
+		ToSql()
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	applyProfileSql, applyProfileArgs, err := psql.Update("user_profiles").
+		Set("professional_field", sq.Expr("COALESCE(expert_revision.professional_field, user_profiles.professional_field)")).
+		Set("version", sq.Expr("user_profiles.version + 1")).
+		From("expert_revision").
+		Where("expert_revision.uuid IN (?)", revisionUuid).
+		Where("user_profiles.user_uuid = expert_revision.expert_uuid").
+		ToSql()
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	applyInfoSql, applyInfoArgs, err := psql.Update("expert_information").
+		Set("help_description", sq.Expr("COALESCE(expert_revision.help_description, expert_information.help_description)")).
+		Set("version", sq.Expr("expert_information.version + 1")).
+		From("expert_revision").
+		Where("expert_revision.uuid IN (?)", revisionUuid).
+		Where("expert_information.user_uuid = expert_revision.expert_uuid").
+		ToSql()
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	removeSkillsSql, removeSkillsArgs, err := psql.Delete("expert_skill").
+		Where("EXISTS (SELECT 1 FROM expert_revision WHERE expert_revision.uuid = ?"+
+			" AND expert_revision.expert_uuid = expert_skill.expert_uuid"+
+			" AND NOT (expert_skill.skill_uuid = ANY(expert_revision.skill_uuids)))", revisionUuid).
+		ToSql()
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	// Skills deleted while the revision was pending are skipped
+	addSkillsSql, addSkillsArgs, err := psql.Insert("expert_skill").
+		Columns(
+			"expert_uuid",
+			"skill_uuid",
+		).
+		Select(psql.Select("expert_revision.expert_uuid", "skill.uuid").
+			From("expert_revision").
+			InnerJoin("skill ON skill.uuid = ANY(expert_revision.skill_uuids)").
+			Where("expert_revision.uuid IN (?)", revisionUuid)).
+		Suffix("ON CONFLICT DO NOTHING").
+		ToSql()
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	tx, err := r.Db.Begin(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+	defer func() {
+		if err != nil {
+			_ = tx.Rollback(ctx)
+		} else {
+			_ = tx.Commit(ctx)
+		}
+	}()
+
+	rows, err := tx.Query(ctx, reviewSql, reviewArgs...)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+	revision, err := pgx.CollectOneRow(rows, pgx.RowToStructByNameLax[entity.ExpertRevision])
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			err = ErrRevisionNotPending
+		}
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	if status != entity.Approved {
+		return &revision, nil
+	}
+
+	_, err = tx.Exec(ctx, applyProfileSql, applyProfileArgs...)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	_, err = tx.Exec(ctx, applyInfoSql, applyInfoArgs...)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	_, err = tx.Exec(ctx, removeSkillsSql, removeSkillsArgs...)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	_, err = tx.Exec(ctx, addSkillsSql, addSkillsArgs...)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return &revision, nil
+}
+
+const revisionColumns = "uuid, expert_uuid, professional_field, help_description, skill_uuids, status, submitted_at, reviewed_by, reviewed_at"
+
+func (r *Repo) revisionsBy(ctx context.Context, column string, value any) ([]entity.ExpertRevision, error) {
+	psql := sq.StatementBuilder.PlaceholderFormat(sq.Dollar)
+	sql, args, err := psql.Select(revisionColumns).
+		From("expert_revision").
+		Where(sq.Eq{column: value}).
+		Where("EXISTS (SELECT 1 FROM expert_information WHERE expert_information.user_uuid = expert_revision.expert_uuid AND expert_information.deleted_at IS NULL)").
+		OrderBy("submitted_at DESC").
+		ToSql()
+	if err != nil {
+		return nil, err
+	}
+
+	rows, err := r.Db.Query(ctx, sql, args...)
+	if err != nil {
+		return nil, err
+	}
+
+	return pgx.CollectRows(rows, pgx.RowToStructByNameLax[entity.ExpertRevision])
+}
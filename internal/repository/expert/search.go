@@ -0,0 +1,85 @@
+package expertrepo
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/jackc/pgx/v5"
+
+	"github.com/bogdanshibilov/mindflowbackend/internal/tenant"
+)
+
+// Text search configuration the search_vector columns are built with
+const searchConfig = "english"
+
+const searchVectorExpr = "(user_profiles.search_vector || expert_information.search_vector)"
+
+// Highlighted terms in snippets are wrapped in these markers
+const (
+	SnippetStart = "\x02"
+	SnippetStop  = "\x03"
+)
+
+const headlineOptions = "StartSel=" + SnippetStart + ", StopSel=" + SnippetStop +
+	", MaxFragments=2, MaxWords=20, MinWords=8, FragmentDelimiter=\" … \""
+
+// Searches experts by words in their name, professional field, skills and
+// descriptions. Misspelled names, fields and skills match by trigram word
+// similarity. Hits are ordered by relevance unless options set another order
+func (r *Repo) SearchExperts(
+	ctx context.Context,
+	scope tenant.Scope,
+	query string,
+	filter *ExpertFilter,
+	opts ...ExpertsOption,
+) ([]ExpertSearchHit, error) {
+	const op = "repository.expert.SearchExperts"
+
+	err := filter.Validate()
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	options := &expertsOptions{}
+	for _, opt := range opts {
+		opt(options)
+	}
+
+	tsQuery := "websearch_to_tsquery('" + searchConfig + "', ?)"
+	fuzzySkill := "EXISTS (SELECT 1 FROM expert_skill INNER JOIN skill ON skill.uuid = expert_skill.skill_uuid" +
+		" WHERE expert_skill.expert_uuid = expert_information.user_uuid AND ? <% skill.name)"
+
+	searchQuery := applyExpertsOptions(selectExperts(scope), filter, options).
+		Column(
+			"ts_rank_cd("+searchVectorExpr+", "+tsQuery+") + "+
+				"GREATEST(word_similarity(?, name), word_similarity(?, COALESCE(professional_field, ''))) AS rank",
+			query, query, query,
+		).
+		Column(
+			"ts_headline('"+searchConfig+"', concat_ws(' ', help_description, experience_description), "+tsQuery+", ?) AS snippet",
+			query, headlineOptions,
+		).
+		Where(
+			"("+searchVectorExpr+" @@ "+tsQuery+" OR ? <% name OR ? <% professional_field OR "+fuzzySkill+")",
+			query, query, query, query,
+		)
+	if options.orderBy != "" {
+		searchQuery = searchQuery.OrderBy(options.orderBy)
+	}
+	sql, args, err := searchQuery.OrderBy("rank DESC").ToSql()
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	rows, err := r.Db.Query(ctx, sql, args...)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	hits, err := pgx.CollectRows(rows, pgx.RowToStructByNameLax[ExpertSearchHit])
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return hits, nil
+}
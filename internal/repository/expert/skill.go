@@ -0,0 +1,152 @@
+package expertrepo
+
+import (
+	"context"
+	"fmt"
+
+	sq "github.com/Masterminds/squirrel"
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+
+	"github.com/bogdanshibilov/mindflowbackend/internal/entity"
+	"github.com/bogdanshibilov/mindflowbackend/internal/tenant"
+)
+
+// Returns the skills of each of the experts
+func (r *Repo) SkillsByExpertUuids(ctx context.Context, expertUuids []uuid.UUID) (map[uuid.UUID][]entity.Skill, error) {
+	const op = "repository.expert.SkillsByExpertUuids"
+
+	psql := sq.StatementBuilder.PlaceholderFormat(sq.Dollar)
+	sql, args, err := psql.Select(
+		"expert_skill.expert_uuid AS expert_uuid",
+		"skill.uuid AS uuid",
+		"skill.category_uuid AS category_uuid",
+		"skill.name AS name",
+		"skill.created_at AS created_at",
+	).
+		From("expert_skill").
+		InnerJoin("skill ON skill.uuid = expert_skill.skill_uuid").
+		Where(sq.Eq{"expert_skill.expert_uuid": expertUuids}).
+		OrderBy("skill.name").
+		ToSql()
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	rows, err := r.Db.Query(ctx, sql, args...)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+	expertSkills, err := pgx.CollectRows(rows, pgx.RowToStructByNameLax[expertSkill])
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	result := make(map[uuid.UUID][]entity.Skill)
+	for _, expertSkill := range expertSkills {
+		result[expertSkill.ExpertUuid] = append(result[expertSkill.ExpertUuid], expertSkill.Skill)
+	}
+
+	return result, nil
+}
+
+// Replaces the skills of the expert
+func (r *Repo) SetSkills(ctx context.Context, expertUuid uuid.UUID, skillUuids []uuid.UUID) error {
+	const op = "repository.expert.SetSkills"
+
+	// A nil slice would be sent as NULL and keep every skill
+	if skillUuids == nil {
+		skillUuids = []uuid.UUID{}
+	}
+
+	psql := sq.StatementBuilder.PlaceholderFormat(sq.Dollar)
+	deleteSql, deleteArgs, err := psql.Delete("expert_skill").
+		Where("expert_uuid IN (?)", expertUuid).
+		Where("NOT (skill_uuid = ANY(?))", skillUuids).
+		ToSql()
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	insertSql, insertArgs, err := psql.Insert("expert_skill").
+		Columns(
+			"expert_uuid",
+			"skill_uuid",
+		).
+		Select(psql.Select().
+			Column("?::uuid", expertUuid).
+			Column("skill.uuid").
+			From("skill").
+			Where("skill.uuid = ANY(?)", skillUuids)).
+		Suffix("ON CONFLICT DO NOTHING").
+		ToSql()
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	tx, err := r.Db.Begin(ctx)
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+	defer func() {
+		if err != nil {
+			_ = tx.Rollback(ctx)
+		} else {
+			_ = tx.Commit(ctx)
+		}
+	}()
+
+	_, err = tx.Exec(ctx, deleteSql, deleteArgs...)
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	_, err = tx.Exec(ctx, insertSql, insertArgs...)
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	return nil
+}
+
+// Counts approved experts visible in the scope per skill
+func (r *Repo) SkillCounts(ctx context.Context, scope tenant.Scope) ([]SkillCount, error) {
+	const op = "repository.expert.SkillCounts"
+
+	psql := sq.StatementBuilder.PlaceholderFormat(sq.Dollar)
+	sql, args, err := psql.Select(
+		"skill.uuid AS skill_uuid",
+		"skill.name AS name",
+		"skill.category_uuid AS category_uuid",
+		"COUNT(*) AS count",
+	).
+		From("expert_skill").
+		InnerJoin("skill ON skill.uuid = expert_skill.skill_uuid").
+		InnerJoin("expert_application ON expert_skill.expert_uuid = expert_application.user_uuid").
+		InnerJoin("expert_information ON expert_skill.expert_uuid = expert_information.user_uuid").
+		Where("status IN (?)", entity.Approved).
+		Where(ActiveExpr).
+		Where(scope.Where("expert_information.organization_uuid")).
+		GroupBy("skill.uuid").
+		OrderBy("count DESC", "skill.name").
+		ToSql()
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	rows, err := r.Db.Query(ctx, sql, args...)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+	result, err := pgx.CollectRows(rows, pgx.RowToStructByNameLax[SkillCount])
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return result, nil
+}
+
+type expertSkill struct {
+	entity.Skill
+	ExpertUuid uuid.UUID `db:"expert_uuid"`
+}
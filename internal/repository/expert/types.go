@@ -1,11 +1,32 @@
 package expertrepo
 
-type ProffFieldListAndCount struct {
-	ProfessionalField string `db:"professional_field" json:"professionalField"`
-	Count             int    `db:"count" json:"count"`
+import (
+	"github.com/google/uuid"
+
+	"github.com/bogdanshibilov/mindflowbackend/internal/entity"
+)
+
+type SkillCount struct {
+	SkillUuid    uuid.UUID `db:"skill_uuid" json:"skillId"`
+	Name         string    `db:"name" json:"name"`
+	CategoryUuid uuid.UUID `db:"category_uuid" json:"categoryId"`
+	Count        int       `db:"count" json:"count"`
 }
 
 type MinMaxPrice struct {
-	MinPrice int `json:"minPrice" db:"min_price"`
-	MaxPrice int `json:"maxPrice" db:"max_price"`
+	MinPrice int    `json:"minPrice" db:"min_price"`
+	MaxPrice int    `json:"maxPrice" db:"max_price"`
+	Currency string `json:"currency" db:"-"`
+}
+
+type ExpertSearchHit struct {
+	entity.Expert
+	Rank float64 `db:"rank"`
+	// Excerpt of the descriptions, matched words are wrapped in SnippetStart and SnippetStop
+	Snippet string `db:"snippet"`
+}
+
+type expertRow struct {
+	entity.Expert
+	SortKey string `db:"sort_key"`
 }
@@ -0,0 +1,11 @@
+package organizationrepo
+
+import "errors"
+
+var (
+	ErrOrganizationNotFound = errors.New("organization not found")
+	ErrSlugTaken            = errors.New("slug is already used by another organization")
+	ErrNotMember            = errors.New("user is not a member of the organization")
+	ErrMemberElsewhere      = errors.New("user is a member of another organization")
+	ErrUserNotFound         = errors.New("user not found")
+)
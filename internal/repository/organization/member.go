@@ -0,0 +1,244 @@
+package organizationrepo
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	sq "github.com/Masterminds/squirrel"
+	"github.com/google/uuid"
+	"github.com/jackc/pgerrcode"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+
+	"github.com/bogdanshibilov/mindflowbackend/internal/entity"
+	"github.com/bogdanshibilov/mindflowbackend/internal/pagination"
+)
+
+// Sort keys of member lists
+var MemberSorts = map[string]pagination.Sort{
+	"name":   {Expr: "lower(user_profiles.name)", Type: "text"},
+	"joined": {Expr: "organization_member.joined_at", Type: "timestamp", Desc: true},
+}
+
+// Returns the membership of the user, ErrNotMember outside any organization
+func (r *Repo) Membership(ctx context.Context, userUuid uuid.UUID) (*entity.OrganizationMember, error) {
+	const op = "repository.organization.Membership"
+
+	sql, args, err := selectMembers().
+		Where("organization_member.user_uuid IN (?)", userUuid).
+		ToSql()
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	rows, err := r.Db.Query(ctx, sql, args...)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+	member, err := pgx.CollectOneRow(rows, pgx.RowToStructByNameLax[entity.OrganizationMember])
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, fmt.Errorf("%s: %w", op, ErrNotMember)
+		}
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return &member, nil
+}
+
+func (r *Repo) Members(
+	ctx context.Context,
+	orgUuid uuid.UUID,
+	page *pagination.Request,
+) (*pagination.Page[entity.OrganizationMember], error) {
+	const op = "repository.organization.Members"
+
+	membersQuery := selectMembers().
+		Where("organization_member.organization_uuid IN (?)", orgUuid)
+
+	countSql, countArgs, err := pagination.Count(membersQuery).ToSql()
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+	sql, args, err := page.Apply(membersQuery, "organization_member.user_uuid").ToSql()
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	var total int
+	err = r.Db.QueryRow(ctx, countSql, countArgs...).Scan(&total)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	rows, err := r.Db.Query(ctx, sql, args...)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+	memberRows, err := pgx.CollectRows(rows, pgx.RowToStructByNameLax[memberRow])
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	pageRows := make([]pagination.Row[entity.OrganizationMember], 0, len(memberRows))
+	for _, row := range memberRows {
+		pageRows = append(pageRows, pagination.Row[entity.OrganizationMember]{
+			Item:    row.OrganizationMember,
+			Id:      row.UserUuid,
+			SortKey: row.SortKey,
+		})
+	}
+
+	return pagination.NewPage(page, pageRows, total), nil
+}
+
+type memberRow struct {
+	entity.OrganizationMember
+	SortKey string `db:"sort_key"`
+}
+
+// Adds the user to the organization or changes their role in it. Users
+// belong to one organization at most, ErrMemberElsewhere otherwise
+func (r *Repo) SetMember(ctx context.Context, member *entity.OrganizationMember) error {
+	const op = "repository.organization.SetMember"
+
+	psql := sq.StatementBuilder.PlaceholderFormat(sq.Dollar)
+	sql, args, err := psql.Insert("organization_member").
+		Columns(
+			"organization_uuid",
+			"user_uuid",
+			"role",
+		).
+		Values(
+			member.OrganizationUuid,
+			member.UserUuid,
+			member.Role,
+		).
+		// Members of other organizations are left as they are and return no row
+		Suffix("ON CONFLICT (user_uuid) DO UPDATE SET role = EXCLUDED.role" +
+			" WHERE organization_member.organization_uuid = EXCLUDED.organization_uuid" +
+			" RETURNING joined_at").
+		ToSql()
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	err = r.Db.QueryRow(ctx, sql, args...).Scan(&member.JoinedAt)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return fmt.Errorf("%s: %w", op, ErrMemberElsewhere)
+		}
+		var pgError *pgconn.PgError
+		if errors.As(err, &pgError) && pgError.Code == pgerrcode.ForeignKeyViolation {
+			if pgError.ConstraintName == "organization_member_user_uuid_fkey" {
+				return fmt.Errorf("%s: %w", op, ErrUserNotFound)
+			}
+			return fmt.Errorf("%s: %w", op, ErrOrganizationNotFound)
+		}
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	return nil
+}
+
+// Removes the user from the organization. Their expert profile, if scoped to
+// the organization, becomes public; consultations stay with the organization
+func (r *Repo) RemoveMember(ctx context.Context, orgUuid uuid.UUID, userUuid uuid.UUID) error {
+	const op = "repository.organization.RemoveMember"
+
+	psql := sq.StatementBuilder.PlaceholderFormat(sq.Dollar)
+	deleteSql, deleteArgs, err := psql.Delete("organization_member").
+		Where("organization_uuid IN (?)", orgUuid).
+		Where("user_uuid IN (?)", userUuid).
+		ToSql()
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	unscopeSql, unscopeArgs, err := psql.Update("expert_information").
+		Set("organization_uuid", nil).
+		Where("user_uuid IN (?)", userUuid).
+		Where("organization_uuid IN (?)", orgUuid).
+		ToSql()
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	tx, err := r.Db.Begin(ctx)
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+	defer func() {
+		if err != nil {
+			_ = tx.Rollback(ctx)
+		} else {
+			_ = tx.Commit(ctx)
+		}
+	}()
+
+	tag, err := tx.Exec(ctx, deleteSql, deleteArgs...)
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+	if tag.RowsAffected() == 0 {
+		err = ErrNotMember
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	_, err = tx.Exec(ctx, unscopeSql, unscopeArgs...)
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	return nil
+}
+
+// Scopes the expert to the organization, or makes them public when scoped is
+// false. The expert must be a member, ErrNotMember otherwise
+func (r *Repo) SetExpertScope(ctx context.Context, orgUuid uuid.UUID, expertUuid uuid.UUID, scoped bool) error {
+	const op = "repository.organization.SetExpertScope"
+
+	var scope *uuid.UUID
+	if scoped {
+		scope = &orgUuid
+	}
+
+	psql := sq.StatementBuilder.PlaceholderFormat(sq.Dollar)
+	sql, args, err := psql.Update("expert_information").
+		Set("organization_uuid", scope).
+		Where("user_uuid IN (?)", expertUuid).
+		Where("deleted_at IS NULL").
+		Where(
+			"EXISTS (SELECT 1 FROM organization_member WHERE organization_member.user_uuid = expert_information.user_uuid AND organization_member.organization_uuid = ?)",
+			orgUuid,
+		).
+		ToSql()
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	tag, err := r.Db.Exec(ctx, sql, args...)
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+	if tag.RowsAffected() == 0 {
+		return fmt.Errorf("%s: %w", op, ErrNotMember)
+	}
+
+	return nil
+}
+
+func selectMembers() sq.SelectBuilder {
+	psql := sq.StatementBuilder.PlaceholderFormat(sq.Dollar)
+	return psql.Select(
+		"organization_member.organization_uuid AS organization_uuid",
+		"organization_member.user_uuid AS user_uuid",
+		"role",
+		"joined_at",
+		"name",
+		"email",
+	).
+		From("organization_member").
+		InnerJoin("user_profiles ON organization_member.user_uuid = user_profiles.user_uuid")
+}
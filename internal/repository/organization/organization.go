@@ -0,0 +1,193 @@
+package organizationrepo
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	sq "github.com/Masterminds/squirrel"
+	"github.com/google/uuid"
+	"github.com/jackc/pgerrcode"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+
+	"github.com/bogdanshibilov/mindflowbackend/internal/db/postgres"
+	"github.com/bogdanshibilov/mindflowbackend/internal/entity"
+	"github.com/bogdanshibilov/mindflowbackend/internal/pagination"
+)
+
+type Repo struct {
+	Db postgres.Db
+}
+
+// Sort keys of organization lists
+var Sorts = map[string]pagination.Sort{
+	"name":    {Expr: "lower(organization.name)", Type: "text"},
+	"created": {Expr: "organization.created_at", Type: "timestamp", Desc: true},
+}
+
+// Stores the organization and sets its uuid and creation time
+func (r *Repo) CreateOrganization(ctx context.Context, org *entity.Organization) error {
+	const op = "repository.organization.CreateOrganization"
+
+	psql := sq.StatementBuilder.PlaceholderFormat(sq.Dollar)
+	sql, args, err := psql.Insert("organization").
+		Columns(
+			"slug",
+			"name",
+			"logo_url",
+			"primary_color",
+			"public_experts",
+		).
+		Values(
+			org.Slug,
+			org.Name,
+			org.LogoUrl,
+			org.PrimaryColor,
+			org.PublicExperts,
+		).
+		Suffix("RETURNING uuid, created_at").
+		ToSql()
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	err = r.Db.QueryRow(ctx, sql, args...).Scan(&org.Uuid, &org.CreatedAt)
+	if err != nil {
+		var pgError *pgconn.PgError
+		if errors.As(err, &pgError) && pgError.Code == pgerrcode.UniqueViolation {
+			return fmt.Errorf("%s: %w", op, ErrSlugTaken)
+		}
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	return nil
+}
+
+func (r *Repo) ByUuid(ctx context.Context, orgUuid uuid.UUID) (*entity.Organization, error) {
+	const op = "repository.organization.ByUuid"
+
+	sql, args, err := selectOrganizations().
+		Where("uuid IN (?)", orgUuid).
+		ToSql()
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	rows, err := r.Db.Query(ctx, sql, args...)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+	org, err := pgx.CollectOneRow(rows, pgx.RowToStructByNameLax[entity.Organization])
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, fmt.Errorf("%s: %w", op, ErrOrganizationNotFound)
+		}
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return &org, nil
+}
+
+func (r *Repo) Organizations(ctx context.Context, page *pagination.Request) (*pagination.Page[entity.Organization], error) {
+	const op = "repository.organization.Organizations"
+
+	orgsQuery := selectOrganizations()
+
+	countSql, countArgs, err := pagination.Count(orgsQuery).ToSql()
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+	sql, args, err := page.Apply(orgsQuery, "organization.uuid").ToSql()
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	var total int
+	err = r.Db.QueryRow(ctx, countSql, countArgs...).Scan(&total)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	rows, err := r.Db.Query(ctx, sql, args...)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+	orgRows, err := pgx.CollectRows(rows, pgx.RowToStructByNameLax[organizationRow])
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	pageRows := make([]pagination.Row[entity.Organization], 0, len(orgRows))
+	for _, row := range orgRows {
+		pageRows = append(pageRows, pagination.Row[entity.Organization]{
+			Item:    row.Organization,
+			Id:      row.Uuid,
+			SortKey: row.SortKey,
+		})
+	}
+
+	return pagination.NewPage(page, pageRows, total), nil
+}
+
+type organizationRow struct {
+	entity.Organization
+	SortKey string `db:"sort_key"`
+}
+
+func (r *Repo) UpdateBranding(ctx context.Context, orgUuid uuid.UUID, branding *entity.OrganizationBranding) error {
+	const op = "repository.organization.UpdateBranding"
+
+	psql := sq.StatementBuilder.PlaceholderFormat(sq.Dollar)
+	sql, args, err := psql.Update("organization").
+		Set("logo_url", branding.LogoUrl).
+		Set("primary_color", branding.PrimaryColor).
+		Where("uuid IN (?)", orgUuid).
+		ToSql()
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	return r.execOrganizationUpdate(ctx, op, sql, args)
+}
+
+func (r *Repo) UpdateSettings(ctx context.Context, orgUuid uuid.UUID, settings *entity.OrganizationSettings) error {
+	const op = "repository.organization.UpdateSettings"
+
+	psql := sq.StatementBuilder.PlaceholderFormat(sq.Dollar)
+	sql, args, err := psql.Update("organization").
+		Set("public_experts", settings.PublicExperts).
+		Where("uuid IN (?)", orgUuid).
+		ToSql()
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	return r.execOrganizationUpdate(ctx, op, sql, args)
+}
+
+func (r *Repo) execOrganizationUpdate(ctx context.Context, op string, sql string, args []any) error {
+	tag, err := r.Db.Exec(ctx, sql, args...)
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+	if tag.RowsAffected() == 0 {
+		return fmt.Errorf("%s: %w", op, ErrOrganizationNotFound)
+	}
+
+	return nil
+}
+
+func selectOrganizations() sq.SelectBuilder {
+	psql := sq.StatementBuilder.PlaceholderFormat(sq.Dollar)
+	return psql.Select(
+		"uuid",
+		"slug",
+		"name",
+		"logo_url",
+		"primary_color",
+		"public_experts",
+		"created_at",
+	).
+		From("organization")
+}
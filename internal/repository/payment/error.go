@@ -0,0 +1,18 @@
+package paymentrepo
+
+import "errors"
+
+var (
+	ErrRefundNotFound      = errors.New("refund not found")
+	ErrIntentNotFound      = errors.New("payment intent not found")
+	ErrIntentAlreadyExists = errors.New("payment intent already exists")
+	ErrUnbalancedLedgerTx  = errors.New("ledger transaction debits and credits do not match")
+	ErrEmptyLedgerTx       = errors.New("ledger transaction has no entries")
+	ErrPayoutNotFound      = errors.New("payout not found")
+	ErrPayoutBatchNotFound = errors.New("payout batch not found")
+	ErrPayoutNotPending    = errors.New("payout is not pending")
+	ErrAlreadyPaidOut      = errors.New("payment is already in a pending or sent payout")
+	ErrPromoUnavailable    = errors.New("promo code is inactive, expired or used up")
+	ErrPromoRedeemed       = errors.New("promo code was already redeemed by the user")
+	ErrPromoAlreadyApplied = errors.New("payment already has a promo code or is not awaiting payment")
+)
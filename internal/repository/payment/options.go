@@ -0,0 +1,37 @@
+package paymentrepo
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+type earningsOptions struct {
+	expertUuid *uuid.UUID
+	from       *time.Time
+	to         *time.Time
+	unpaidOnly bool
+}
+
+type EarningsOption func(*earningsOptions)
+
+func EarningsOfExpert(expertUuid uuid.UUID) EarningsOption {
+	return func(eo *earningsOptions) {
+		eo.expertUuid = &expertUuid
+	}
+}
+
+// Only earnings completed in [from, to)
+func EarningsCompletedBetween(from, to time.Time) EarningsOption {
+	return func(eo *earningsOptions) {
+		eo.from = &from
+		eo.to = &to
+	}
+}
+
+// Only earnings that are not part of a pending or sent payout
+func OnlyUnpaidEarnings() EarningsOption {
+	return func(eo *earningsOptions) {
+		eo.unpaidOnly = true
+	}
+}
@@ -0,0 +1,502 @@
+package paymentrepo
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	sq "github.com/Masterminds/squirrel"
+	"github.com/google/uuid"
+	"github.com/jackc/pgerrcode"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+
+	"github.com/bogdanshibilov/mindflowbackend/internal/db/postgres"
+	"github.com/bogdanshibilov/mindflowbackend/internal/entity"
+)
+
+type Repo struct {
+	Db postgres.Db
+}
+
+func (r *Repo) CreateIntent(ctx context.Context, intent *entity.PaymentIntent) error {
+	const op = "repository.payment.CreateIntent"
+
+	psql := sq.StatementBuilder.PlaceholderFormat(sq.Dollar)
+	sql, args, err := psql.Insert("payment_intent").
+		Columns(
+			"consultation_uuid",
+			"package_purchase_uuid",
+			"payer_uuid",
+			"amount",
+			"original_amount",
+			"currency",
+			"status",
+			"provider",
+			"provider_ref",
+		).
+		Values(
+			intent.ConsultationUuid,
+			intent.PackagePurchaseUuid,
+			intent.PayerUuid,
+			intent.Amount,
+			intent.OriginalAmount,
+			intent.Currency,
+			intent.Status,
+			intent.Provider,
+			intent.ProviderRef,
+		).
+		Suffix("RETURNING uuid, created_at, updated_at").
+		ToSql()
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	err = r.Db.QueryRow(ctx, sql, args...).Scan(&intent.Uuid, &intent.CreatedAt, &intent.UpdatedAt)
+	if err != nil {
+		var pgError *pgconn.PgError
+		if errors.As(err, &pgError) {
+			if pgError.Code == pgerrcode.UniqueViolation {
+				return fmt.Errorf("%s: %w", op, ErrIntentAlreadyExists)
+			}
+		}
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	return nil
+}
+
+func (r *Repo) IntentByUuid(ctx context.Context, uuid uuid.UUID) (*entity.PaymentIntent, error) {
+	const op = "repository.payment.IntentByUuid"
+
+	intent, err := r.intentBy(ctx, "uuid", uuid)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return intent, nil
+}
+
+func (r *Repo) IntentByConsultationUuid(ctx context.Context, consultUuid uuid.UUID) (*entity.PaymentIntent, error) {
+	const op = "repository.payment.IntentByConsultationUuid"
+
+	intent, err := r.intentBy(ctx, "consultation_uuid", consultUuid)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return intent, nil
+}
+
+func (r *Repo) IntentByPackagePurchaseUuid(ctx context.Context, purchaseUuid uuid.UUID) (*entity.PaymentIntent, error) {
+	const op = "repository.payment.IntentByPackagePurchaseUuid"
+
+	intent, err := r.intentBy(ctx, "package_purchase_uuid", purchaseUuid)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return intent, nil
+}
+
+func (r *Repo) IntentByProviderRef(ctx context.Context, providerRef string) (*entity.PaymentIntent, error) {
+	const op = "repository.payment.IntentByProviderRef"
+
+	intent, err := r.intentBy(ctx, "provider_ref", providerRef)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return intent, nil
+}
+
+// Moves the intent to the status only while it is in one of the from statuses.
+// Returns false when it was not
+func (r *Repo) TransitionIntentStatus(
+	ctx context.Context,
+	uuid uuid.UUID,
+	to entity.PaymentStatus,
+	from ...entity.PaymentStatus,
+) (bool, error) {
+	const op = "repository.payment.TransitionIntentStatus"
+
+	psql := sq.StatementBuilder.PlaceholderFormat(sq.Dollar)
+	sql, args, err := psql.Update("payment_intent").
+		Set("status", to).
+		Set("updated_at", sq.Expr("now()")).
+		Where(sq.Eq{"uuid": uuid, "status": from}).
+		ToSql()
+	if err != nil {
+		return false, fmt.Errorf("%s: %w", op, err)
+	}
+
+	tag, err := r.Db.Exec(ctx, sql, args...)
+	if err != nil {
+		return false, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return tag.RowsAffected() > 0, nil
+}
+
+// What a payment succeeding changed
+type Completion struct {
+	// False when the intent had succeeded before, nothing was changed then
+	Captured bool
+	// The paid consultation moved from approved to scheduled
+	Scheduled bool
+	// Full refund of a consultation cancelled or rejected while the payment was
+	// in flight. It is recorded but not sent to the provider yet
+	Refund *entity.Refund
+}
+
+// Records a webhook event and applies its effects in one transaction: the
+// intent's new status, the ledger entries of a capture and, once the payment
+// succeeds, the purchase or consultation it paid for. Returns false without
+// changing anything if the event was already processed
+func (r *Repo) ApplyWebhookEvent(
+	ctx context.Context,
+	event *entity.PaymentWebhookEvent,
+	intent *entity.PaymentIntent,
+	status entity.PaymentStatus,
+	entries []entity.LedgerEntry,
+) (applied bool, completion *Completion, err error) {
+	const op = "repository.payment.ApplyWebhookEvent"
+
+	psql := sq.StatementBuilder.PlaceholderFormat(sq.Dollar)
+	// A succeeded payment is final, late events must not move it back
+	updateIntentSql, updateIntentArgs, err := psql.Update("payment_intent").
+		Set("status", status).
+		Set("updated_at", sq.Expr("now()")).
+		Where("uuid IN (?)", intent.Uuid).
+		Where(sq.NotEq{"status": entity.PaymentSucceeded}).
+		ToSql()
+	if err != nil {
+		return false, nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	tx, err := r.Db.Begin(ctx)
+	if err != nil {
+		return false, nil, fmt.Errorf("%s: %w", op, err)
+	}
+	defer func() {
+		if err != nil || !applied {
+			_ = tx.Rollback(ctx)
+		} else {
+			_ = tx.Commit(ctx)
+		}
+	}()
+
+	isNew, err := recordWebhookEvent(ctx, tx, event)
+	if err != nil {
+		return false, nil, fmt.Errorf("%s: %w", op, err)
+	}
+	if !isNew {
+		return false, nil, nil
+	}
+
+	if status == entity.PaymentSucceeded {
+		completion, err = succeed(ctx, tx, intent, entries)
+		if err != nil {
+			return false, nil, fmt.Errorf("%s: %w", op, err)
+		}
+		return true, completion, nil
+	}
+
+	_, err = tx.Exec(ctx, updateIntentSql, updateIntentArgs...)
+	if err != nil {
+		return false, nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return true, nil, nil
+}
+
+// Completes an intent that needs no charge, e.g. one a promo code covers in full
+func (r *Repo) SucceedIntent(ctx context.Context, intent *entity.PaymentIntent) (*Completion, error) {
+	const op = "repository.payment.SucceedIntent"
+
+	tx, err := r.Db.Begin(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+	defer func() {
+		if err != nil {
+			_ = tx.Rollback(ctx)
+		} else {
+			_ = tx.Commit(ctx)
+		}
+	}()
+
+	completion, err := succeed(ctx, tx, intent, nil)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return completion, nil
+}
+
+// Marks the intent succeeded and posts the capture unless it had succeeded
+// before. Activates the purchased package or schedules the approved
+// consultation, one cancelled or rejected meanwhile gets a refund recorded instead
+func succeed(
+	ctx context.Context,
+	tx pgx.Tx,
+	intent *entity.PaymentIntent,
+	entries []entity.LedgerEntry,
+) (*Completion, error) {
+	psql := sq.StatementBuilder.PlaceholderFormat(sq.Dollar)
+	succeedSql, succeedArgs, err := psql.Update("payment_intent").
+		Set("status", entity.PaymentSucceeded).
+		Set("updated_at", sq.Expr("now()")).
+		Where("uuid IN (?)", intent.Uuid).
+		Where(sq.NotEq{"status": entity.PaymentSucceeded}).
+		ToSql()
+	if err != nil {
+		return nil, err
+	}
+
+	tag, err := tx.Exec(ctx, succeedSql, succeedArgs...)
+	if err != nil {
+		return nil, err
+	}
+	completion := &Completion{Captured: tag.RowsAffected() == 1}
+	if !completion.Captured {
+		return completion, nil
+	}
+	intent.Status = entity.PaymentSucceeded
+
+	if len(entries) > 0 {
+		err = insertLedgerTransaction(ctx, tx, entries)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	if intent.PackagePurchaseUuid != nil {
+		activateSql, activateArgs, err := psql.Update("package_purchase").
+			Set("status", entity.PurchaseActive).
+			Set("paid_at", sq.Expr("now()")).
+			Set("expires_at", sq.Expr("now() + validity_days * INTERVAL '1 day'")).
+			Where("uuid IN (?)", *intent.PackagePurchaseUuid).
+			Where("status IN (?)", entity.PurchasePending).
+			ToSql()
+		if err != nil {
+			return nil, err
+		}
+
+		_, err = tx.Exec(ctx, activateSql, activateArgs...)
+		if err != nil {
+			return nil, err
+		}
+		return completion, nil
+	}
+
+	scheduleSql, scheduleArgs, err := psql.Update("consultation_application").
+		Set("status", entity.Scheduled).
+		Where("consultation_uuid IN (?)", *intent.ConsultationUuid).
+		Where("status IN (?)", entity.Approved).
+		ToSql()
+	if err != nil {
+		return nil, err
+	}
+
+	tag, err = tx.Exec(ctx, scheduleSql, scheduleArgs...)
+	if err != nil {
+		return nil, err
+	}
+	if tag.RowsAffected() == 1 {
+		completion.Scheduled = true
+		return completion, nil
+	}
+
+	statusSql, statusArgs, err := psql.Select("status", "cancelled_by").
+		From("consultation_application").
+		Where("consultation_uuid IN (?)", *intent.ConsultationUuid).
+		ToSql()
+	if err != nil {
+		return nil, err
+	}
+
+	var status entity.Status
+	var cancelledBy *uuid.UUID
+	err = tx.QueryRow(ctx, statusSql, statusArgs...).Scan(&status, &cancelledBy)
+	if err != nil {
+		return nil, err
+	}
+	if (status != entity.Cancelled && status != entity.Rejected) || intent.Amount == 0 {
+		return completion, nil
+	}
+
+	reason := "cancelled before payment completed"
+	if status == entity.Rejected {
+		reason = "rejected before payment completed"
+	}
+	completion.Refund = &entity.Refund{
+		PaymentIntentUuid: intent.Uuid,
+		Amount:            intent.Amount,
+		Currency:          intent.Currency,
+		Status:            entity.RefundPending,
+		Reason:            reason,
+		InitiatedBy:       cancelledBy,
+	}
+	err = insertRefund(ctx, tx, completion.Refund)
+	if err != nil {
+		return nil, err
+	}
+
+	return completion, nil
+}
+
+func (r *Repo) LedgerEntriesByIntentUuid(ctx context.Context, intentUuid uuid.UUID) ([]entity.LedgerEntry, error) {
+	const op = "repository.payment.LedgerEntriesByIntentUuid"
+
+	psql := sq.StatementBuilder.PlaceholderFormat(sq.Dollar)
+	sql, args, err := psql.Select(
+		"uuid",
+		"transaction_uuid",
+		"account",
+		"owner_uuid",
+		"direction",
+		"amount",
+		"currency",
+		"payment_intent_uuid",
+		"created_at",
+	).
+		From("ledger_entry").
+		Where("payment_intent_uuid IN (?)", intentUuid).
+		OrderBy("created_at", "transaction_uuid", "direction DESC").
+		ToSql()
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	rows, err := r.Db.Query(ctx, sql, args...)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+	entries, err := pgx.CollectRows(rows, pgx.RowToStructByNameLax[entity.LedgerEntry])
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return entries, nil
+}
+
+func (r *Repo) intentBy(ctx context.Context, column string, value any) (*entity.PaymentIntent, error) {
+	psql := sq.StatementBuilder.PlaceholderFormat(sq.Dollar)
+	sql, args, err := psql.Select(
+		"uuid",
+		"consultation_uuid",
+		"package_purchase_uuid",
+		"payer_uuid",
+		"amount",
+		"original_amount",
+		"promo_code_uuid",
+		"currency",
+		"status",
+		"provider",
+		"provider_ref",
+		"created_at",
+		"updated_at",
+	).
+		From("payment_intent").
+		Where(sq.Eq{column: value}).
+		ToSql()
+	if err != nil {
+		return nil, err
+	}
+
+	rows, err := r.Db.Query(ctx, sql, args...)
+	if err != nil {
+		return nil, err
+	}
+	intent, err := pgx.CollectOneRow(rows, pgx.RowToStructByNameLax[entity.PaymentIntent])
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, ErrIntentNotFound
+		}
+		return nil, err
+	}
+
+	return &intent, nil
+}
+
+// Stores the event id, returns false if it has been stored before
+func recordWebhookEvent(ctx context.Context, tx pgx.Tx, event *entity.PaymentWebhookEvent) (bool, error) {
+	psql := sq.StatementBuilder.PlaceholderFormat(sq.Dollar)
+	sql, args, err := psql.Insert("payment_webhook_event").
+		Columns(
+			"event_id",
+			"payment_intent_uuid",
+			"type",
+		).
+		Values(
+			event.EventId,
+			event.PaymentIntentUuid,
+			event.Type,
+		).
+		Suffix("ON CONFLICT (event_id) DO NOTHING").
+		ToSql()
+	if err != nil {
+		return false, err
+	}
+
+	tag, err := tx.Exec(ctx, sql, args...)
+	if err != nil {
+		return false, err
+	}
+
+	return tag.RowsAffected() > 0, nil
+}
+
+// Inserts entries as a single ledger transaction. Debits and credits
+// must add up to the same amount
+func insertLedgerTransaction(ctx context.Context, tx pgx.Tx, entries []entity.LedgerEntry) error {
+	if len(entries) == 0 {
+		return ErrEmptyLedgerTx
+	}
+
+	balance := 0
+	for _, entry := range entries {
+		switch entry.Direction {
+		case entity.Debit:
+			balance += entry.Amount
+		case entity.Credit:
+			balance -= entry.Amount
+		}
+	}
+	if balance != 0 {
+		return ErrUnbalancedLedgerTx
+	}
+
+	transactionUuid := uuid.New()
+
+	psql := sq.StatementBuilder.PlaceholderFormat(sq.Dollar)
+	query := psql.Insert("ledger_entry").
+		Columns(
+			"transaction_uuid",
+			"account",
+			"owner_uuid",
+			"direction",
+			"amount",
+			"currency",
+			"payment_intent_uuid",
+		)
+	for _, entry := range entries {
+		query = query.Values(
+			transactionUuid,
+			entry.Account,
+			entry.OwnerUuid,
+			entry.Direction,
+			entry.Amount,
+			entry.Currency,
+			entry.PaymentIntentUuid,
+		)
+	}
+	sql, args, err := query.ToSql()
+	if err != nil {
+		return err
+	}
+
+	_, err = tx.Exec(ctx, sql, args...)
+	return err
+}
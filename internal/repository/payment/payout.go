@@ -0,0 +1,443 @@
+package paymentrepo
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	sq "github.com/Masterminds/squirrel"
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+
+	"github.com/bogdanshibilov/mindflowbackend/internal/entity"
+)
+
+// Returns succeeded payments of scheduled consultations whose last meeting
+// has already started and of paid package purchases, along with the payout
+// they were included in
+func (r *Repo) EarningItems(ctx context.Context, opts ...EarningsOption) ([]entity.EarningItem, error) {
+	const op = "repository.payment.EarningItems"
+
+	options := &earningsOptions{}
+	for _, opt := range opts {
+		opt(options)
+	}
+
+	const completedAt = "COALESCE(meeting.start_time, package_purchase.paid_at)"
+	const expertUuid = "COALESCE(consultation.expert_uuid, package_purchase.expert_uuid)"
+
+	psql := sq.StatementBuilder.PlaceholderFormat(sq.Dollar)
+	query := psql.Select(
+		"payment_intent.uuid AS payment_intent_uuid",
+		"payment_intent.consultation_uuid AS consultation_uuid",
+		"payment_intent.package_purchase_uuid AS package_purchase_uuid",
+		expertUuid+" AS expert_uuid",
+		completedAt+" AS completed_at",
+		"payment_intent.amount AS amount",
+		"COALESCE(refunded.amount, 0) AS refunded",
+		"payment_intent.currency AS currency",
+		"paid_out.uuid AS payout_uuid",
+		"paid_out.status AS payout_status",
+	).
+		From("payment_intent").
+		LeftJoin("consultation ON payment_intent.consultation_uuid = consultation.uuid").
+		LeftJoin("consultation_application ON consultation.uuid = consultation_application.consultation_uuid").
+		LeftJoin("(SELECT consultation_uuid, MAX(start_time) AS start_time "+
+			"FROM consultation_meeting GROUP BY consultation_uuid) meeting "+
+			"ON consultation.uuid = meeting.consultation_uuid").
+		LeftJoin("package_purchase ON payment_intent.package_purchase_uuid = package_purchase.uuid").
+		LeftJoin("(SELECT payment_intent_uuid, SUM(amount) AS amount "+
+			"FROM refund WHERE status = 'succeeded' GROUP BY payment_intent_uuid) refunded "+
+			"ON payment_intent.uuid = refunded.payment_intent_uuid").
+		// A payment may appear in several payouts if an earlier one failed, the live one wins
+		LeftJoin("LATERAL (SELECT payout.uuid, payout.status FROM payout_item "+
+			"INNER JOIN payout ON payout_item.payout_uuid = payout.uuid "+
+			"WHERE payout_item.payment_intent_uuid = payment_intent.uuid "+
+			"ORDER BY payout.status = 'failed', payout.sent_at DESC NULLS FIRST LIMIT 1) paid_out ON true").
+		Where("payment_intent.status IN (?)", entity.PaymentSucceeded).
+		Where(sq.Or{
+			sq.Expr("consultation_application.status = ?", entity.Scheduled),
+			sq.Expr("package_purchase.status = ?", entity.PurchaseActive),
+		}).
+		Where(completedAt + " < now()")
+
+	if options.expertUuid != nil {
+		query = query.Where(expertUuid+" = ?", *options.expertUuid)
+	}
+	if options.from != nil && options.to != nil {
+		query = query.
+			Where(completedAt+" >= ?", *options.from).
+			Where(completedAt+" < ?", *options.to)
+	}
+	if options.unpaidOnly {
+		query = query.Where(sq.Or{
+			sq.Expr("paid_out.status IS NULL"),
+			sq.Eq{"paid_out.status": entity.PayoutFailed},
+		})
+	}
+
+	sql, args, err := query.OrderBy(completedAt).ToSql()
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	rows, err := r.Db.Query(ctx, sql, args...)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+	items, err := pgx.CollectRows(rows, pgx.RowToStructByNameLax[entity.EarningItem])
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return items, nil
+}
+
+// Key of the advisory lock batches are created under. A unique index cannot
+// stand in for it as payments of failed payouts are paid out again
+const payoutBatchLock int64 = 5
+
+// Creates the batch with its payouts. Batches are created one at a time and
+// fail with ErrAlreadyPaidOut when a payment got into a live payout since its
+// earnings were read
+func (r *Repo) CreatePayoutBatch(ctx context.Context, batch *entity.PayoutBatch, payouts []entity.Payout) error {
+	const op = "repository.payment.CreatePayoutBatch"
+
+	var intentUuids []uuid.UUID
+	for _, payout := range payouts {
+		for _, item := range payout.Items {
+			intentUuids = append(intentUuids, item.PaymentIntentUuid)
+		}
+	}
+
+	psql := sq.StatementBuilder.PlaceholderFormat(sq.Dollar)
+	paidOutSql, paidOutArgs, err := psql.Select("1").
+		From("payout_item").
+		InnerJoin("payout ON payout_item.payout_uuid = payout.uuid").
+		Where("payout_item.payment_intent_uuid = ANY(?)", intentUuids).
+		Where("payout.status <> ?", entity.PayoutFailed).
+		Prefix("SELECT EXISTS (").
+		Suffix(")").
+		ToSql()
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	lockSql, lockArgs, err := psql.Select().
+		Column("pg_advisory_xact_lock(?)", payoutBatchLock).
+		ToSql()
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	insertBatchSql, insertBatchArgs, err := psql.Insert("payout_batch").
+		Columns(
+			"period_end",
+			"status",
+			"created_by",
+		).
+		Values(
+			batch.PeriodEnd,
+			batch.Status,
+			batch.CreatedBy,
+		).
+		Suffix("RETURNING uuid, created_at").
+		ToSql()
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	tx, err := r.Db.Begin(ctx)
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+	defer func() {
+		if err != nil {
+			_ = tx.Rollback(ctx)
+		} else {
+			_ = tx.Commit(ctx)
+		}
+	}()
+
+	_, err = tx.Exec(ctx, lockSql, lockArgs...)
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	// Sees the batches committed while waiting for the lock
+	var paidOut bool
+	err = tx.QueryRow(ctx, paidOutSql, paidOutArgs...).Scan(&paidOut)
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+	if paidOut {
+		err = ErrAlreadyPaidOut
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	err = tx.QueryRow(ctx, insertBatchSql, insertBatchArgs...).Scan(&batch.Uuid, &batch.CreatedAt)
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	for i := range payouts {
+		payout := &payouts[i]
+		payout.BatchUuid = batch.Uuid
+
+		var insertPayoutSql string
+		var insertPayoutArgs []any
+		insertPayoutSql, insertPayoutArgs, err = psql.Insert("payout").
+			Columns(
+				"batch_uuid",
+				"expert_uuid",
+				"currency",
+				"gross",
+				"commission",
+				"net",
+				"status",
+			).
+			Values(
+				payout.BatchUuid,
+				payout.ExpertUuid,
+				payout.Currency,
+				payout.Gross,
+				payout.Commission,
+				payout.Net,
+				payout.Status,
+			).
+			Suffix("RETURNING uuid").
+			ToSql()
+		if err != nil {
+			return fmt.Errorf("%s: %w", op, err)
+		}
+
+		err = tx.QueryRow(ctx, insertPayoutSql, insertPayoutArgs...).Scan(&payout.Uuid)
+		if err != nil {
+			return fmt.Errorf("%s: %w", op, err)
+		}
+
+		insertItems := psql.Insert("payout_item").
+			Columns(
+				"payout_uuid",
+				"payment_intent_uuid",
+				"gross",
+				"commission",
+				"net",
+			)
+		for _, item := range payout.Items {
+			insertItems = insertItems.Values(
+				payout.Uuid,
+				item.PaymentIntentUuid,
+				item.Gross,
+				item.Commission,
+				item.Net,
+			)
+		}
+
+		var insertItemsSql string
+		var insertItemsArgs []any
+		insertItemsSql, insertItemsArgs, err = insertItems.ToSql()
+		if err != nil {
+			return fmt.Errorf("%s: %w", op, err)
+		}
+
+		_, err = tx.Exec(ctx, insertItemsSql, insertItemsArgs...)
+		if err != nil {
+			return fmt.Errorf("%s: %w", op, err)
+		}
+	}
+
+	return nil
+}
+
+func (r *Repo) PayoutBatches(ctx context.Context) ([]entity.PayoutBatch, error) {
+	const op = "repository.payment.PayoutBatches"
+
+	psql := sq.StatementBuilder.PlaceholderFormat(sq.Dollar)
+	sql, args, err := psql.Select(
+		"uuid",
+		"period_end",
+		"status",
+		"created_by",
+		"created_at",
+	).
+		From("payout_batch").
+		OrderBy("created_at DESC").
+		ToSql()
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	rows, err := r.Db.Query(ctx, sql, args...)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+	batches, err := pgx.CollectRows(rows, pgx.RowToStructByNameLax[entity.PayoutBatch])
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return batches, nil
+}
+
+func (r *Repo) payoutsBy(ctx context.Context, column string, value uuid.UUID) ([]entity.Payout, error) {
+	const op = "repository.payment.payoutsBy"
+
+	psql := sq.StatementBuilder.PlaceholderFormat(sq.Dollar)
+	sql, args, err := psql.Select(
+		"uuid",
+		"batch_uuid",
+		"expert_uuid",
+		"currency",
+		"gross",
+		"commission",
+		"net",
+		"status",
+		"reference",
+		"sent_at",
+	).
+		From("payout").
+		Where(sq.Eq{column: value}).
+		OrderBy("uuid").
+		ToSql()
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	rows, err := r.Db.Query(ctx, sql, args...)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+	payouts, err := pgx.CollectRows(rows, pgx.RowToStructByNameLax[entity.Payout])
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return payouts, nil
+}
+
+func (r *Repo) PayoutsByBatchUuid(ctx context.Context, batchUuid uuid.UUID) ([]entity.Payout, error) {
+	return r.payoutsBy(ctx, "batch_uuid", batchUuid)
+}
+
+func (r *Repo) PayoutsByExpertUuid(ctx context.Context, expertUuid uuid.UUID) ([]entity.Payout, error) {
+	return r.payoutsBy(ctx, "expert_uuid", expertUuid)
+}
+
+func (r *Repo) PayoutByUuid(ctx context.Context, uuid uuid.UUID) (*entity.Payout, error) {
+	const op = "repository.payment.PayoutByUuid"
+
+	payouts, err := r.payoutsBy(ctx, "uuid", uuid)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+	if len(payouts) == 0 {
+		return nil, fmt.Errorf("%s: %w", op, ErrPayoutNotFound)
+	}
+
+	return &payouts[0], nil
+}
+
+// Moves a pending payout to its final status, posts its ledger entries and
+// closes the batch once no payouts in it are pending
+func (r *Repo) CompletePayout(
+	ctx context.Context,
+	payout *entity.Payout,
+	status entity.PayoutStatus,
+	reference string,
+	entries []entity.LedgerEntry,
+) error {
+	const op = "repository.payment.CompletePayout"
+
+	psql := sq.StatementBuilder.PlaceholderFormat(sq.Dollar)
+	updatePayout := psql.Update("payout").
+		Set("status", status).
+		Set("reference", reference).
+		Where("uuid IN (?)", payout.Uuid).
+		Where("status IN (?)", entity.PayoutPending)
+	if status == entity.PayoutSent {
+		updatePayout = updatePayout.Set("sent_at", sq.Expr("now()"))
+	}
+	updatePayoutSql, updatePayoutArgs, err := updatePayout.ToSql()
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	closeBatchSql, closeBatchArgs, err := psql.Update("payout_batch").
+		Set("status", entity.PayoutBatchClosed).
+		Where("uuid IN (?)", payout.BatchUuid).
+		Where("NOT EXISTS (SELECT 1 FROM payout WHERE batch_uuid = ? AND status = ?)",
+			payout.BatchUuid, entity.PayoutPending).
+		ToSql()
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	tx, err := r.Db.Begin(ctx)
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+	defer func() {
+		if err != nil {
+			_ = tx.Rollback(ctx)
+		} else {
+			_ = tx.Commit(ctx)
+		}
+	}()
+
+	tag, err := tx.Exec(ctx, updatePayoutSql, updatePayoutArgs...)
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+	if tag.RowsAffected() == 0 {
+		err = ErrPayoutNotPending
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	if len(entries) > 0 {
+		err = insertLedgerTransaction(ctx, tx, entries)
+		if err != nil {
+			return fmt.Errorf("%s: %w", op, err)
+		}
+	}
+
+	_, err = tx.Exec(ctx, closeBatchSql, closeBatchArgs...)
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	return nil
+}
+
+func (r *Repo) PayoutBatchByUuid(ctx context.Context, uuid uuid.UUID) (*entity.PayoutBatch, error) {
+	const op = "repository.payment.PayoutBatchByUuid"
+
+	psql := sq.StatementBuilder.PlaceholderFormat(sq.Dollar)
+	sql, args, err := psql.Select(
+		"uuid",
+		"period_end",
+		"status",
+		"created_by",
+		"created_at",
+	).
+		From("payout_batch").
+		Where("uuid IN (?)", uuid).
+		ToSql()
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	rows, err := r.Db.Query(ctx, sql, args...)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+	batch, err := pgx.CollectOneRow(rows, pgx.RowToStructByNameLax[entity.PayoutBatch])
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, fmt.Errorf("%s: %w", op, ErrPayoutBatchNotFound)
+		}
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return &batch, nil
+}
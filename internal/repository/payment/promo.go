@@ -0,0 +1,110 @@
+package paymentrepo
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	sq "github.com/Masterminds/squirrel"
+	"github.com/google/uuid"
+	"github.com/jackc/pgerrcode"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+
+	"github.com/bogdanshibilov/mindflowbackend/internal/entity"
+)
+
+// Redeems a promo code for the payer and lowers the intent amount by discount.
+// The usage limit, expiry and one redemption per user are enforced in the same transaction
+func (r *Repo) ApplyPromo(
+	ctx context.Context,
+	intent *entity.PaymentIntent,
+	promoUuid uuid.UUID,
+	discount int,
+) error {
+	const op = "repository.payment.ApplyPromo"
+
+	psql := sq.StatementBuilder.PlaceholderFormat(sq.Dollar)
+	usePromoSql, usePromoArgs, err := psql.Update("promo_code").
+		Set("used_count", sq.Expr("used_count + 1")).
+		Where("uuid IN (?)", promoUuid).
+		Where("active").
+		Where("(max_uses IS NULL OR used_count < max_uses)").
+		Where("(expires_at IS NULL OR expires_at > now())").
+		ToSql()
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	insertRedemptionSql, insertRedemptionArgs, err := psql.Insert("promo_redemption").
+		Columns(
+			"promo_code_uuid",
+			"user_uuid",
+			"payment_intent_uuid",
+			"discount_amount",
+		).
+		Values(
+			promoUuid,
+			intent.PayerUuid,
+			intent.Uuid,
+			discount,
+		).
+		ToSql()
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	updateIntentSql, updateIntentArgs, err := psql.Update("payment_intent").
+		Set("amount", sq.Expr("original_amount - ?", discount)).
+		Set("promo_code_uuid", promoUuid).
+		Set("updated_at", sq.Expr("now()")).
+		Where("uuid IN (?)", intent.Uuid).
+		Where("promo_code_uuid IS NULL").
+		Where(sq.Eq{"status": []entity.PaymentStatus{entity.PaymentRequired, entity.PaymentFailed}}).
+		Suffix("RETURNING amount, updated_at").
+		ToSql()
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	tx, err := r.Db.Begin(ctx)
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+	defer func() {
+		if err != nil {
+			_ = tx.Rollback(ctx)
+		} else {
+			_ = tx.Commit(ctx)
+		}
+	}()
+
+	tag, err := tx.Exec(ctx, usePromoSql, usePromoArgs...)
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+	if tag.RowsAffected() == 0 {
+		err = ErrPromoUnavailable
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	_, err = tx.Exec(ctx, insertRedemptionSql, insertRedemptionArgs...)
+	if err != nil {
+		var pgError *pgconn.PgError
+		if errors.As(err, &pgError) && pgError.Code == pgerrcode.UniqueViolation {
+			return fmt.Errorf("%s: %w", op, ErrPromoRedeemed)
+		}
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	err = tx.QueryRow(ctx, updateIntentSql, updateIntentArgs...).Scan(&intent.Amount, &intent.UpdatedAt)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return fmt.Errorf("%s: %w", op, ErrPromoAlreadyApplied)
+		}
+		return fmt.Errorf("%s: %w", op, err)
+	}
+	intent.PromoCodeUuid = &promoUuid
+
+	return nil
+}
@@ -0,0 +1,285 @@
+package paymentrepo
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	sq "github.com/Masterminds/squirrel"
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+
+	"github.com/bogdanshibilov/mindflowbackend/internal/entity"
+)
+
+func (r *Repo) CreateRefund(ctx context.Context, refund *entity.Refund) error {
+	const op = "repository.payment.CreateRefund"
+
+	tx, err := r.Db.Begin(ctx)
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+	defer func() {
+		if err != nil {
+			_ = tx.Rollback(ctx)
+		} else {
+			_ = tx.Commit(ctx)
+		}
+	}()
+
+	err = insertRefund(ctx, tx, refund)
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	return nil
+}
+
+func insertRefund(ctx context.Context, tx pgx.Tx, refund *entity.Refund) error {
+	psql := sq.StatementBuilder.PlaceholderFormat(sq.Dollar)
+	sql, args, err := psql.Insert("refund").
+		Columns(
+			"payment_intent_uuid",
+			"amount",
+			"currency",
+			"status",
+			"reason",
+			"initiated_by",
+			"provider_ref",
+		).
+		Values(
+			refund.PaymentIntentUuid,
+			refund.Amount,
+			refund.Currency,
+			refund.Status,
+			refund.Reason,
+			refund.InitiatedBy,
+			refund.ProviderRef,
+		).
+		Suffix("RETURNING uuid, created_at, updated_at").
+		ToSql()
+	if err != nil {
+		return err
+	}
+
+	return tx.QueryRow(ctx, sql, args...).Scan(&refund.Uuid, &refund.CreatedAt, &refund.UpdatedAt)
+}
+
+// Stores the reference the provider gave the refund
+func (r *Repo) SetRefundProviderRef(ctx context.Context, refundUuid uuid.UUID, providerRef string) error {
+	const op = "repository.payment.SetRefundProviderRef"
+
+	psql := sq.StatementBuilder.PlaceholderFormat(sq.Dollar)
+	sql, args, err := psql.Update("refund").
+		Set("provider_ref", providerRef).
+		Set("updated_at", sq.Expr("now()")).
+		Where("uuid IN (?)", refundUuid).
+		ToSql()
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	_, err = r.Db.Exec(ctx, sql, args...)
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	return nil
+}
+
+// Marks a pending refund the provider rejected as failed
+func (r *Repo) FailPendingRefund(ctx context.Context, refundUuid uuid.UUID) error {
+	const op = "repository.payment.FailPendingRefund"
+
+	psql := sq.StatementBuilder.PlaceholderFormat(sq.Dollar)
+	sql, args, err := psql.Update("refund").
+		Set("status", entity.RefundFailed).
+		Set("updated_at", sq.Expr("now()")).
+		Where(sq.Eq{"uuid": refundUuid, "status": entity.RefundPending}).
+		ToSql()
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	_, err = r.Db.Exec(ctx, sql, args...)
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	return nil
+}
+
+// How long a refund being sent to the provider is left alone before it may be sent again
+const refundSendLease = "1 minute"
+
+// Returns the pending refunds of the intent the provider has not accepted yet
+func (r *Repo) UnsentRefunds(ctx context.Context, intentUuid uuid.UUID) ([]entity.Refund, error) {
+	const op = "repository.payment.UnsentRefunds"
+
+	refunds, err := r.RefundsByIntentUuid(ctx, intentUuid)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	var unsent []entity.Refund
+	for _, refund := range refunds {
+		if refund.Status == entity.RefundPending && refund.ProviderRef == nil {
+			unsent = append(unsent, refund)
+		}
+	}
+
+	return unsent, nil
+}
+
+// Claims an unsent refund for sending. Returns false while another attempt
+// may still be talking to the provider
+func (r *Repo) ClaimUnsentRefund(ctx context.Context, refundUuid uuid.UUID) (bool, error) {
+	const op = "repository.payment.ClaimUnsentRefund"
+
+	psql := sq.StatementBuilder.PlaceholderFormat(sq.Dollar)
+	sql, args, err := psql.Update("refund").
+		Set("updated_at", sq.Expr("now()")).
+		Where(sq.Eq{"uuid": refundUuid, "status": entity.RefundPending}).
+		Where("provider_ref IS NULL").
+		Where("updated_at < now() - INTERVAL '" + refundSendLease + "'").
+		ToSql()
+	if err != nil {
+		return false, fmt.Errorf("%s: %w", op, err)
+	}
+
+	tag, err := r.Db.Exec(ctx, sql, args...)
+	if err != nil {
+		return false, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return tag.RowsAffected() > 0, nil
+}
+
+func (r *Repo) RefundsByIntentUuid(ctx context.Context, intentUuid uuid.UUID) ([]entity.Refund, error) {
+	const op = "repository.payment.RefundsByIntentUuid"
+
+	psql := sq.StatementBuilder.PlaceholderFormat(sq.Dollar)
+	sql, args, err := psql.Select(
+		"uuid",
+		"payment_intent_uuid",
+		"amount",
+		"currency",
+		"status",
+		"reason",
+		"initiated_by",
+		"provider_ref",
+		"created_at",
+		"updated_at",
+	).
+		From("refund").
+		Where("payment_intent_uuid IN (?)", intentUuid).
+		OrderBy("created_at").
+		ToSql()
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	rows, err := r.Db.Query(ctx, sql, args...)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+	refunds, err := pgx.CollectRows(rows, pgx.RowToStructByNameLax[entity.Refund])
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return refunds, nil
+}
+
+func (r *Repo) RefundByProviderRef(ctx context.Context, providerRef string) (*entity.Refund, error) {
+	const op = "repository.payment.RefundByProviderRef"
+
+	psql := sq.StatementBuilder.PlaceholderFormat(sq.Dollar)
+	sql, args, err := psql.Select(
+		"uuid",
+		"payment_intent_uuid",
+		"amount",
+		"currency",
+		"status",
+		"reason",
+		"initiated_by",
+		"provider_ref",
+		"created_at",
+		"updated_at",
+	).
+		From("refund").
+		Where("provider_ref IN (?)", providerRef).
+		ToSql()
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	rows, err := r.Db.Query(ctx, sql, args...)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+	refund, err := pgx.CollectOneRow(rows, pgx.RowToStructByNameLax[entity.Refund])
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, fmt.Errorf("%s: %w", op, ErrRefundNotFound)
+		}
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return &refund, nil
+}
+
+// Same as ApplyWebhookEvent but moves a refund instead of the payment intent
+func (r *Repo) ApplyRefundWebhookEvent(
+	ctx context.Context,
+	event *entity.PaymentWebhookEvent,
+	refundUuid uuid.UUID,
+	status entity.RefundStatus,
+	entries []entity.LedgerEntry,
+) (applied bool, err error) {
+	const op = "repository.payment.ApplyRefundWebhookEvent"
+
+	psql := sq.StatementBuilder.PlaceholderFormat(sq.Dollar)
+	updateRefundSql, updateRefundArgs, err := psql.Update("refund").
+		Set("status", status).
+		Set("updated_at", sq.Expr("now()")).
+		Where("uuid IN (?)", refundUuid).
+		ToSql()
+	if err != nil {
+		return false, fmt.Errorf("%s: %w", op, err)
+	}
+
+	tx, err := r.Db.Begin(ctx)
+	if err != nil {
+		return false, fmt.Errorf("%s: %w", op, err)
+	}
+	defer func() {
+		if err != nil || !applied {
+			_ = tx.Rollback(ctx)
+		} else {
+			_ = tx.Commit(ctx)
+		}
+	}()
+
+	isNew, err := recordWebhookEvent(ctx, tx, event)
+	if err != nil {
+		return false, fmt.Errorf("%s: %w", op, err)
+	}
+	if !isNew {
+		return false, nil
+	}
+
+	_, err = tx.Exec(ctx, updateRefundSql, updateRefundArgs...)
+	if err != nil {
+		return false, fmt.Errorf("%s: %w", op, err)
+	}
+
+	if len(entries) > 0 {
+		err = insertLedgerTransaction(ctx, tx, entries)
+		if err != nil {
+			return false, fmt.Errorf("%s: %w", op, err)
+		}
+	}
+
+	return true, nil
+}
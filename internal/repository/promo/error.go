@@ -0,0 +1,8 @@
+package promorepo
+
+import "errors"
+
+var (
+	ErrPromoNotFound  = errors.New("promo code not found")
+	ErrPromoCodeTaken = errors.New("promo code already exists")
+)
@@ -0,0 +1,170 @@
+package promorepo
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	sq "github.com/Masterminds/squirrel"
+	"github.com/google/uuid"
+	"github.com/jackc/pgerrcode"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+
+	"github.com/bogdanshibilov/mindflowbackend/internal/db/postgres"
+	"github.com/bogdanshibilov/mindflowbackend/internal/entity"
+)
+
+type Repo struct {
+	Db postgres.Db
+}
+
+func (r *Repo) CreatePromo(ctx context.Context, promo *entity.PromoCode) error {
+	const op = "repository.promo.CreatePromo"
+
+	psql := sq.StatementBuilder.PlaceholderFormat(sq.Dollar)
+	sql, args, err := psql.Insert("promo_code").
+		Columns(
+			"code",
+			"kind",
+			"value",
+			"currency",
+			"max_uses",
+			"expires_at",
+			"expert_uuid",
+			"active",
+			"created_by",
+		).
+		Values(
+			promo.Code,
+			promo.Kind,
+			promo.Value,
+			promo.Currency,
+			promo.MaxUses,
+			promo.ExpiresAt,
+			promo.ExpertUuid,
+			promo.Active,
+			promo.CreatedBy,
+		).
+		Suffix("RETURNING uuid, created_at").
+		ToSql()
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	err = r.Db.QueryRow(ctx, sql, args...).Scan(&promo.Uuid, &promo.CreatedAt)
+	if err != nil {
+		var pgError *pgconn.PgError
+		if errors.As(err, &pgError) && pgError.Code == pgerrcode.UniqueViolation {
+			return fmt.Errorf("%s: %w", op, ErrPromoCodeTaken)
+		}
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	return nil
+}
+
+func (r *Repo) Promos(ctx context.Context) ([]entity.PromoCode, error) {
+	const op = "repository.promo.Promos"
+
+	sql, args, err := selectPromos().OrderBy("created_at DESC").ToSql()
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	rows, err := r.Db.Query(ctx, sql, args...)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+	promos, err := pgx.CollectRows(rows, pgx.RowToStructByNameLax[entity.PromoCode])
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return promos, nil
+}
+
+func (r *Repo) PromoByCode(ctx context.Context, code string) (*entity.PromoCode, error) {
+	const op = "repository.promo.PromoByCode"
+
+	promo, err := r.promoBy(ctx, "code", code)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return promo, nil
+}
+
+func (r *Repo) PromoByUuid(ctx context.Context, uuid uuid.UUID) (*entity.PromoCode, error) {
+	const op = "repository.promo.PromoByUuid"
+
+	promo, err := r.promoBy(ctx, "uuid", uuid)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return promo, nil
+}
+
+func (r *Repo) SetPromoActive(ctx context.Context, uuid uuid.UUID, active bool) error {
+	const op = "repository.promo.SetPromoActive"
+
+	psql := sq.StatementBuilder.PlaceholderFormat(sq.Dollar)
+	sql, args, err := psql.Update("promo_code").
+		Set("active", active).
+		Where("uuid IN (?)", uuid).
+		ToSql()
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	tag, err := r.Db.Exec(ctx, sql, args...)
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+	if tag.RowsAffected() == 0 {
+		return fmt.Errorf("%s: %w", op, ErrPromoNotFound)
+	}
+
+	return nil
+}
+
+func (r *Repo) promoBy(ctx context.Context, column string, value any) (*entity.PromoCode, error) {
+	sql, args, err := selectPromos().Where(sq.Eq{column: value}).ToSql()
+	if err != nil {
+		return nil, err
+	}
+
+	rows, err := r.Db.Query(ctx, sql, args...)
+	if err != nil {
+		return nil, err
+	}
+	promo, err := pgx.CollectOneRow(rows, pgx.RowToStructByNameLax[entity.PromoCode])
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, ErrPromoNotFound
+		}
+		return nil, err
+	}
+
+	return &promo, nil
+}
+
+func selectPromos() sq.SelectBuilder {
+	psql := sq.StatementBuilder.PlaceholderFormat(sq.Dollar)
+	return psql.Select(
+		"uuid",
+		"code",
+		"kind",
+		"value",
+		"currency",
+		"max_uses",
+		"used_count",
+		"expires_at",
+		"expert_uuid",
+		"active",
+		"created_by",
+		"created_at",
+	).
+		From("promo_code")
+}
@@ -2,8 +2,15 @@ package repository
 
 import (
 	"github.com/bogdanshibilov/mindflowbackend/internal/db/postgres"
+	auditrepo "github.com/bogdanshibilov/mindflowbackend/internal/repository/audit"
 	consultationrepo "github.com/bogdanshibilov/mindflowbackend/internal/repository/consultation"
+	currencyrepo "github.com/bogdanshibilov/mindflowbackend/internal/repository/currency"
 	expertrepo "github.com/bogdanshibilov/mindflowbackend/internal/repository/expert"
+	organizationrepo "github.com/bogdanshibilov/mindflowbackend/internal/repository/organization"
+	paymentrepo "github.com/bogdanshibilov/mindflowbackend/internal/repository/payment"
+	promorepo "github.com/bogdanshibilov/mindflowbackend/internal/repository/promo"
+	sessionpackagerepo "github.com/bogdanshibilov/mindflowbackend/internal/repository/sessionpackage"
+	skillrepo "github.com/bogdanshibilov/mindflowbackend/internal/repository/skill"
 	userrepo "github.com/bogdanshibilov/mindflowbackend/internal/repository/user"
 )
 
@@ -24,3 +31,45 @@ func NewConsultation(db *postgres.Db) *consultationrepo.Repo {
 		Db: *db,
 	}
 }
+
+func NewPayment(db *postgres.Db) *paymentrepo.Repo {
+	return &paymentrepo.Repo{
+		Db: *db,
+	}
+}
+
+func NewCurrency(db *postgres.Db) *currencyrepo.Repo {
+	return &currencyrepo.Repo{
+		Db: *db,
+	}
+}
+
+func NewSessionPackage(db *postgres.Db) *sessionpackagerepo.Repo {
+	return &sessionpackagerepo.Repo{
+		Db: *db,
+	}
+}
+
+func NewPromo(db *postgres.Db) *promorepo.Repo {
+	return &promorepo.Repo{
+		Db: *db,
+	}
+}
+
+func NewSkill(db *postgres.Db) *skillrepo.Repo {
+	return &skillrepo.Repo{
+		Db: *db,
+	}
+}
+
+func NewAudit(db *postgres.Db) *auditrepo.Repo {
+	return &auditrepo.Repo{
+		Db: *db,
+	}
+}
+
+func NewOrganization(db *postgres.Db) *organizationrepo.Repo {
+	return &organizationrepo.Repo{
+		Db: *db,
+	}
+}
@@ -0,0 +1,8 @@
+package sessionpackagerepo
+
+import "errors"
+
+var (
+	ErrPackageNotFound  = errors.New("package not found")
+	ErrPurchaseNotFound = errors.New("package purchase not found")
+)
@@ -0,0 +1,164 @@
+package sessionpackagerepo
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	sq "github.com/Masterminds/squirrel"
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+
+	"github.com/bogdanshibilov/mindflowbackend/internal/db/postgres"
+	"github.com/bogdanshibilov/mindflowbackend/internal/entity"
+	"github.com/bogdanshibilov/mindflowbackend/internal/tenant"
+)
+
+type Repo struct {
+	Db postgres.Db
+}
+
+func (r *Repo) CreatePackage(ctx context.Context, pkg *entity.SessionPackage) error {
+	const op = "repository.sessionpackage.CreatePackage"
+
+	psql := sq.StatementBuilder.PlaceholderFormat(sq.Dollar)
+	sql, args, err := psql.Insert("session_package").
+		Columns(
+			"expert_uuid",
+			"title",
+			"session_count",
+			"price_amount",
+			"currency",
+			"validity_days",
+			"active",
+		).
+		Values(
+			pkg.ExpertUuid,
+			pkg.Title,
+			pkg.SessionCount,
+			pkg.PriceAmount,
+			pkg.Currency,
+			pkg.ValidityDays,
+			pkg.Active,
+		).
+		Suffix("RETURNING uuid, created_at").
+		ToSql()
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	err = r.Db.QueryRow(ctx, sql, args...).Scan(&pkg.Uuid, &pkg.CreatedAt)
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	return nil
+}
+
+// Returns the packages of the expert if the expert is visible in the scope
+func (r *Repo) PackagesByExpertUuid(
+	ctx context.Context,
+	scope tenant.Scope,
+	expertUuid uuid.UUID,
+	activeOnly bool,
+) ([]entity.SessionPackage, error) {
+	const op = "repository.sessionpackage.PackagesByExpertUuid"
+
+	psql := sq.StatementBuilder.PlaceholderFormat(sq.Dollar)
+	query := psql.Select(
+		"uuid",
+		"expert_uuid",
+		"title",
+		"session_count",
+		"price_amount",
+		"currency",
+		"validity_days",
+		"active",
+		"created_at",
+	).
+		From("session_package").
+		Where("expert_uuid IN (?)", expertUuid).
+		Where(
+			"EXISTS (SELECT 1 FROM expert_information WHERE expert_information.user_uuid = session_package.expert_uuid AND ?)",
+			scope.Where("expert_information.organization_uuid"),
+		)
+	if activeOnly {
+		query = query.Where("active")
+	}
+
+	sql, args, err := query.OrderBy("session_count", "created_at").ToSql()
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	rows, err := r.Db.Query(ctx, sql, args...)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+	packages, err := pgx.CollectRows(rows, pgx.RowToStructByNameLax[entity.SessionPackage])
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return packages, nil
+}
+
+func (r *Repo) PackageByUuid(ctx context.Context, uuid uuid.UUID) (*entity.SessionPackage, error) {
+	const op = "repository.sessionpackage.PackageByUuid"
+
+	psql := sq.StatementBuilder.PlaceholderFormat(sq.Dollar)
+	sql, args, err := psql.Select(
+		"uuid",
+		"expert_uuid",
+		"title",
+		"session_count",
+		"price_amount",
+		"currency",
+		"validity_days",
+		"active",
+		"created_at",
+	).
+		From("session_package").
+		Where("uuid IN (?)", uuid).
+		ToSql()
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	rows, err := r.Db.Query(ctx, sql, args...)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+	pkg, err := pgx.CollectOneRow(rows, pgx.RowToStructByNameLax[entity.SessionPackage])
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, fmt.Errorf("%s: %w", op, ErrPackageNotFound)
+		}
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return &pkg, nil
+}
+
+func (r *Repo) SetPackageActive(ctx context.Context, uuid uuid.UUID, active bool) error {
+	const op = "repository.sessionpackage.SetPackageActive"
+
+	psql := sq.StatementBuilder.PlaceholderFormat(sq.Dollar)
+	sql, args, err := psql.Update("session_package").
+		Set("active", active).
+		Where("uuid IN (?)", uuid).
+		ToSql()
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	tag, err := r.Db.Exec(ctx, sql, args...)
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+	if tag.RowsAffected() == 0 {
+		return fmt.Errorf("%s: %w", op, ErrPackageNotFound)
+	}
+
+	return nil
+}
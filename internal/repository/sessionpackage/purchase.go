@@ -0,0 +1,133 @@
+package sessionpackagerepo
+
+import (
+	"context"
+	"fmt"
+
+	sq "github.com/Masterminds/squirrel"
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+
+	"github.com/bogdanshibilov/mindflowbackend/internal/entity"
+)
+
+func (r *Repo) CreatePurchase(ctx context.Context, purchase *entity.PackagePurchase) error {
+	const op = "repository.sessionpackage.CreatePurchase"
+
+	psql := sq.StatementBuilder.PlaceholderFormat(sq.Dollar)
+	sql, args, err := psql.Insert("package_purchase").
+		Columns(
+			"package_uuid",
+			"mentee_uuid",
+			"expert_uuid",
+			"sessions_total",
+			"validity_days",
+			"price_amount",
+			"currency",
+			"status",
+		).
+		Values(
+			purchase.PackageUuid,
+			purchase.MenteeUuid,
+			purchase.ExpertUuid,
+			purchase.SessionsTotal,
+			purchase.ValidityDays,
+			purchase.PriceAmount,
+			purchase.Currency,
+			purchase.Status,
+		).
+		Suffix("RETURNING uuid, purchased_at").
+		ToSql()
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	err = r.Db.QueryRow(ctx, sql, args...).Scan(&purchase.Uuid, &purchase.PurchasedAt)
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	return nil
+}
+
+func (r *Repo) PurchaseByUuid(ctx context.Context, uuid uuid.UUID) (*entity.PackagePurchase, error) {
+	const op = "repository.sessionpackage.PurchaseByUuid"
+
+	purchases, err := r.purchasesBy(ctx, "uuid", uuid)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+	if len(purchases) == 0 {
+		return nil, fmt.Errorf("%s: %w", op, ErrPurchaseNotFound)
+	}
+
+	return &purchases[0], nil
+}
+
+func (r *Repo) PurchasesByMenteeUuid(ctx context.Context, menteeUuid uuid.UUID) ([]entity.PackagePurchase, error) {
+	return r.purchasesBy(ctx, "mentee_uuid", menteeUuid)
+}
+
+// Starts the validity period of a paid purchase. Purchases that are
+// already active are left untouched
+func (r *Repo) ActivatePurchase(ctx context.Context, uuid uuid.UUID) error {
+	const op = "repository.sessionpackage.ActivatePurchase"
+
+	psql := sq.StatementBuilder.PlaceholderFormat(sq.Dollar)
+	sql, args, err := psql.Update("package_purchase").
+		Set("status", entity.PurchaseActive).
+		Set("paid_at", sq.Expr("now()")).
+		Set("expires_at", sq.Expr("now() + validity_days * INTERVAL '1 day'")).
+		Where("uuid IN (?)", uuid).
+		Where("status IN (?)", entity.PurchasePending).
+		ToSql()
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	_, err = r.Db.Exec(ctx, sql, args...)
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	return nil
+}
+
+func (r *Repo) purchasesBy(ctx context.Context, column string, value uuid.UUID) ([]entity.PackagePurchase, error) {
+	const op = "repository.sessionpackage.purchasesBy"
+
+	psql := sq.StatementBuilder.PlaceholderFormat(sq.Dollar)
+	sql, args, err := psql.Select(
+		"uuid",
+		"package_uuid",
+		"mentee_uuid",
+		"expert_uuid",
+		"sessions_total",
+		"sessions_used",
+		"validity_days",
+		"price_amount",
+		"currency",
+		"status",
+		"purchased_at",
+		"paid_at",
+		"expires_at",
+	).
+		From("package_purchase").
+		Where(sq.Eq{column: value}).
+		OrderBy("purchased_at DESC").
+		ToSql()
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	rows, err := r.Db.Query(ctx, sql, args...)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+	purchases, err := pgx.CollectRows(rows, pgx.RowToStructByNameLax[entity.PackagePurchase])
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return purchases, nil
+}
@@ -0,0 +1,13 @@
+package skillrepo
+
+import "errors"
+
+var (
+	ErrCategoryNotFound = errors.New("skill category not found")
+	ErrCategoryExists   = errors.New("skill category already exists")
+	ErrCategoryInUse    = errors.New("skill category still has skills or subcategories")
+	ErrSkillNotFound    = errors.New("skill not found")
+	ErrSkillExists      = errors.New("skill already exists")
+	ErrAliasTaken       = errors.New("alias already belongs to a skill")
+	ErrAliasNotFound    = errors.New("alias not found")
+)
@@ -0,0 +1,420 @@
+package skillrepo
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	sq "github.com/Masterminds/squirrel"
+	"github.com/google/uuid"
+	"github.com/jackc/pgerrcode"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+
+	"github.com/bogdanshibilov/mindflowbackend/internal/db/postgres"
+	"github.com/bogdanshibilov/mindflowbackend/internal/entity"
+)
+
+type Repo struct {
+	Db postgres.Db
+}
+
+func (r *Repo) CreateCategory(ctx context.Context, category *entity.SkillCategory) error {
+	const op = "repository.skill.CreateCategory"
+
+	psql := sq.StatementBuilder.PlaceholderFormat(sq.Dollar)
+	sql, args, err := psql.Insert("skill_category").
+		Columns(
+			"name",
+			"parent_uuid",
+		).
+		Values(
+			category.Name,
+			category.ParentUuid,
+		).
+		Suffix("RETURNING uuid, created_at").
+		ToSql()
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	err = r.Db.QueryRow(ctx, sql, args...).Scan(&category.Uuid, &category.CreatedAt)
+	if err != nil {
+		var pgError *pgconn.PgError
+		if errors.As(err, &pgError) {
+			switch pgError.Code {
+			case pgerrcode.UniqueViolation:
+				return fmt.Errorf("%s: %w", op, ErrCategoryExists)
+			case pgerrcode.ForeignKeyViolation:
+				return fmt.Errorf("%s: %w", op, ErrCategoryNotFound)
+			}
+		}
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	return nil
+}
+
+func (r *Repo) Categories(ctx context.Context) ([]entity.SkillCategory, error) {
+	const op = "repository.skill.Categories"
+
+	psql := sq.StatementBuilder.PlaceholderFormat(sq.Dollar)
+	sql, args, err := psql.Select(
+		"uuid",
+		"name",
+		"parent_uuid",
+		"created_at",
+	).
+		From("skill_category").
+		OrderBy("name").
+		ToSql()
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	rows, err := r.Db.Query(ctx, sql, args...)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+	categories, err := pgx.CollectRows(rows, pgx.RowToStructByNameLax[entity.SkillCategory])
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return categories, nil
+}
+
+// Deletes an empty category, categories with skills or subcategories are kept
+func (r *Repo) DeleteCategory(ctx context.Context, uuid uuid.UUID) error {
+	const op = "repository.skill.DeleteCategory"
+
+	psql := sq.StatementBuilder.PlaceholderFormat(sq.Dollar)
+	sql, args, err := psql.Delete("skill_category").
+		Where("uuid IN (?)", uuid).
+		ToSql()
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	tag, err := r.Db.Exec(ctx, sql, args...)
+	if err != nil {
+		var pgError *pgconn.PgError
+		if errors.As(err, &pgError) && pgError.Code == pgerrcode.ForeignKeyViolation {
+			return fmt.Errorf("%s: %w", op, ErrCategoryInUse)
+		}
+		return fmt.Errorf("%s: %w", op, err)
+	}
+	if tag.RowsAffected() == 0 {
+		return fmt.Errorf("%s: %w", op, ErrCategoryNotFound)
+	}
+
+	return nil
+}
+
+func (r *Repo) CreateSkill(ctx context.Context, skill *entity.Skill) error {
+	const op = "repository.skill.CreateSkill"
+
+	psql := sq.StatementBuilder.PlaceholderFormat(sq.Dollar)
+	sql, args, err := psql.Insert("skill").
+		Columns(
+			"category_uuid",
+			"name",
+		).
+		Values(
+			skill.CategoryUuid,
+			skill.Name,
+		).
+		Suffix("RETURNING uuid, created_at").
+		ToSql()
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	err = r.Db.QueryRow(ctx, sql, args...).Scan(&skill.Uuid, &skill.CreatedAt)
+	if err != nil {
+		var pgError *pgconn.PgError
+		if errors.As(err, &pgError) {
+			switch pgError.Code {
+			case pgerrcode.UniqueViolation:
+				return fmt.Errorf("%s: %w", op, ErrSkillExists)
+			case pgerrcode.ForeignKeyViolation:
+				return fmt.Errorf("%s: %w", op, ErrCategoryNotFound)
+			}
+		}
+		return fmt.Errorf("%s: %w", op, err)
+	}
+	skill.Aliases = []string{}
+
+	return nil
+}
+
+// Returns all skills with their aliases
+func (r *Repo) Skills(ctx context.Context) ([]entity.Skill, error) {
+	const op = "repository.skill.Skills"
+
+	sql, args, err := selectSkills().OrderBy("skill.name").ToSql()
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	rows, err := r.Db.Query(ctx, sql, args...)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+	skills, err := pgx.CollectRows(rows, pgx.RowToStructByNameLax[entity.Skill])
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return skills, nil
+}
+
+// Deletes a skill, it is removed from all experts
+func (r *Repo) DeleteSkill(ctx context.Context, uuid uuid.UUID) error {
+	const op = "repository.skill.DeleteSkill"
+
+	psql := sq.StatementBuilder.PlaceholderFormat(sq.Dollar)
+	sql, args, err := psql.Delete("skill").
+		Where("uuid IN (?)", uuid).
+		ToSql()
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	tag, err := r.Db.Exec(ctx, sql, args...)
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+	if tag.RowsAffected() == 0 {
+		return fmt.Errorf("%s: %w", op, ErrSkillNotFound)
+	}
+
+	return nil
+}
+
+// Adds a lower case alias. Aliases equal to the name of a skill are refused
+func (r *Repo) AddAlias(ctx context.Context, skillUuid uuid.UUID, alias string) error {
+	const op = "repository.skill.AddAlias"
+
+	psql := sq.StatementBuilder.PlaceholderFormat(sq.Dollar)
+	sql, args, err := psql.Insert("skill_alias").
+		Columns(
+			"alias",
+			"skill_uuid",
+		).
+		Select(psql.Select().
+			Column("?, ?", alias, skillUuid).
+			Where("NOT EXISTS (SELECT 1 FROM skill WHERE lower(name) = ?)", alias)).
+		ToSql()
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	tag, err := r.Db.Exec(ctx, sql, args...)
+	if err != nil {
+		var pgError *pgconn.PgError
+		if errors.As(err, &pgError) {
+			switch pgError.Code {
+			case pgerrcode.UniqueViolation:
+				return fmt.Errorf("%s: %w", op, ErrAliasTaken)
+			case pgerrcode.ForeignKeyViolation:
+				return fmt.Errorf("%s: %w", op, ErrSkillNotFound)
+			}
+		}
+		return fmt.Errorf("%s: %w", op, err)
+	}
+	if tag.RowsAffected() == 0 {
+		return fmt.Errorf("%s: %w", op, ErrAliasTaken)
+	}
+
+	return nil
+}
+
+func (r *Repo) DeleteAlias(ctx context.Context, alias string) error {
+	const op = "repository.skill.DeleteAlias"
+
+	psql := sq.StatementBuilder.PlaceholderFormat(sq.Dollar)
+	sql, args, err := psql.Delete("skill_alias").
+		Where("alias IN (?)", alias).
+		ToSql()
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	tag, err := r.Db.Exec(ctx, sql, args...)
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+	if tag.RowsAffected() == 0 {
+		return fmt.Errorf("%s: %w", op, ErrAliasNotFound)
+	}
+
+	return nil
+}
+
+// Merges a duplicate skill into another one. Experts and aliases move over,
+// pending revisions are rewritten and the old name stays as an alias
+func (r *Repo) MergeSkills(ctx context.Context, fromUuid uuid.UUID, intoUuid uuid.UUID) error {
+	const op = "repository.skill.MergeSkills"
+
+	psql := sq.StatementBuilder.PlaceholderFormat(sq.Dollar)
+	moveExpertsSql, moveExpertsArgs, err := psql.Insert("expert_skill").
+		Columns(
+			"expert_uuid",
+			"skill_uuid",
+		).
+		Select(psql.Select("expert_uuid").
+			Column("?::uuid", intoUuid).
+			From("expert_skill").
+			Where("skill_uuid IN (?)", fromUuid)).
+		Suffix("ON CONFLICT DO NOTHING").
+		ToSql()
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	moveAliasesSql, moveAliasesArgs, err := psql.Update("skill_alias").
+		Set("skill_uuid", intoUuid).
+		Where("skill_uuid IN (?)", fromUuid).
+		ToSql()
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	keepNameSql, keepNameArgs, err := psql.Insert("skill_alias").
+		Columns(
+			"alias",
+			"skill_uuid",
+		).
+		Select(psql.Select("lower(name)").
+			Column("?::uuid", intoUuid).
+			From("skill").
+			Where("uuid IN (?)", fromUuid)).
+		Suffix("ON CONFLICT DO NOTHING").
+		ToSql()
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	rewriteRevisionsSql, rewriteRevisionsArgs, err := psql.Update("expert_revision").
+		Set("skill_uuids", sq.Expr("ARRAY(SELECT DISTINCT unnest(array_replace(skill_uuids, ?::uuid, ?::uuid)))", fromUuid, intoUuid)).
+		Where("status IN (?)", entity.Pending).
+		Where("? = ANY(skill_uuids)", fromUuid).
+		ToSql()
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	lockSql, lockArgs, err := psql.Select("uuid").
+		From("skill").
+		Where(sq.Eq{"uuid": []uuid.UUID{fromUuid, intoUuid}}).
+		Suffix("FOR UPDATE").
+		ToSql()
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	deleteSql, deleteArgs, err := psql.Delete("skill").
+		Where("uuid IN (?)", fromUuid).
+		ToSql()
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	tx, err := r.Db.Begin(ctx)
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+	defer func() {
+		if err != nil {
+			_ = tx.Rollback(ctx)
+		} else {
+			_ = tx.Commit(ctx)
+		}
+	}()
+
+	rows, err := tx.Query(ctx, lockSql, lockArgs...)
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+	locked, err := pgx.CollectRows(rows, pgx.RowTo[uuid.UUID])
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+	if len(locked) != 2 {
+		err = ErrSkillNotFound
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	for _, stmt := range []struct {
+		sql  string
+		args []any
+	}{
+		{moveExpertsSql, moveExpertsArgs},
+		{moveAliasesSql, moveAliasesArgs},
+		{keepNameSql, keepNameArgs},
+		{rewriteRevisionsSql, rewriteRevisionsArgs},
+		{deleteSql, deleteArgs},
+	} {
+		_, err = tx.Exec(ctx, stmt.sql, stmt.args...)
+		if err != nil {
+			return fmt.Errorf("%s: %w", op, err)
+		}
+	}
+
+	return nil
+}
+
+// Finds the skills named by terms, matched case insensitively against names
+// and aliases. Returns the matched skill per lower case term
+func (r *Repo) ResolveSkills(ctx context.Context, terms []string) (map[string]entity.Skill, error) {
+	const op = "repository.skill.ResolveSkills"
+
+	sql, args, err := selectSkills().
+		Column("terms.term AS term").
+		InnerJoin("unnest(?::text[]) AS terms(term) ON lower(skill.name) = terms.term"+
+			" OR skill.uuid::text = terms.term"+
+			" OR EXISTS (SELECT 1 FROM skill_alias WHERE skill_alias.skill_uuid = skill.uuid AND skill_alias.alias = terms.term)", terms).
+		GroupBy("terms.term").
+		// Name matches come last so they win over aliases of other skills
+		OrderBy("lower(skill.name) = terms.term").
+		ToSql()
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	rows, err := r.Db.Query(ctx, sql, args...)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+	matches, err := pgx.CollectRows(rows, pgx.RowToStructByNameLax[resolvedSkill])
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	result := make(map[string]entity.Skill, len(matches))
+	for _, match := range matches {
+		result[match.Term] = match.Skill
+	}
+
+	return result, nil
+}
+
+type resolvedSkill struct {
+	entity.Skill
+	Term string `db:"term"`
+}
+
+func selectSkills() sq.SelectBuilder {
+	psql := sq.StatementBuilder.PlaceholderFormat(sq.Dollar)
+	return psql.Select(
+		"skill.uuid AS uuid",
+		"skill.category_uuid AS category_uuid",
+		"skill.name AS name",
+		"skill.created_at AS created_at",
+		"COALESCE(array_agg(skill_alias.alias ORDER BY skill_alias.alias) FILTER (WHERE skill_alias.alias IS NOT NULL), '{}') AS aliases",
+	).
+		From("skill").
+		LeftJoin("skill_alias ON skill_alias.skill_uuid = skill.uuid").
+		GroupBy("skill.uuid")
+}
@@ -0,0 +1,66 @@
+package userrepo
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	sq "github.com/Masterminds/squirrel"
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+)
+
+// Sets or, with a nil hash, removes the avatar of the user and returns the hash it replaced
+func (r *Repo) SetAvatar(ctx context.Context, userUuid uuid.UUID, hash *string) (*string, error) {
+	const op = "repository.user.SetAvatar"
+
+	// The joined row still holds the value from before the update
+	psql := sq.StatementBuilder.PlaceholderFormat(sq.Dollar)
+	sql, args, err := psql.Update("user_profiles").
+		Set("avatar_hash", hash).
+		Set("version", sq.Expr("user_profiles.version + 1")).
+		From("user_profiles AS previous").
+		Where("previous.user_uuid = user_profiles.user_uuid").
+		Where("user_profiles.user_uuid IN (?)", userUuid).
+		Suffix("RETURNING previous.avatar_hash").
+		ToSql()
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	var previous *string
+	err = r.Db.QueryRow(ctx, sql, args...).Scan(&previous)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, fmt.Errorf("%s: %w", op, ErrUserNotFound)
+		}
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return previous, nil
+}
+
+// Reports whether any user still has the avatar. Users uploading the same
+// picture share its blobs
+func (r *Repo) AvatarInUse(ctx context.Context, hash string) (bool, error) {
+	const op = "repository.user.AvatarInUse"
+
+	psql := sq.StatementBuilder.PlaceholderFormat(sq.Dollar)
+	sql, args, err := psql.Select("1").
+		From("user_profiles").
+		Where("avatar_hash IN (?)", hash).
+		Prefix("SELECT EXISTS (").
+		Suffix(")").
+		ToSql()
+	if err != nil {
+		return false, fmt.Errorf("%s: %w", op, err)
+	}
+
+	var inUse bool
+	err = r.Db.QueryRow(ctx, sql, args...).Scan(&inUse)
+	if err != nil {
+		return false, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return inUse, nil
+}
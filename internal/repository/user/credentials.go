@@ -0,0 +1,103 @@
+package userrepo
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	sq "github.com/Masterminds/squirrel"
+	"github.com/google/uuid"
+	"github.com/jackc/pgerrcode"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+)
+
+// Replaces the password hash and raises the token version, which signs the user
+// out of every session
+func (r *Repo) UpdatePassword(ctx context.Context, userUuid uuid.UUID, passHash []byte) error {
+	const op = "repository.user.UpdatePassword"
+
+	psql := sq.StatementBuilder.PlaceholderFormat(sq.Dollar)
+	sql, args, err := psql.Update("users").
+		Set("pass_hash", passHash).
+		Set("token_version", sq.Expr("token_version + 1")).
+		Where("uuid IN (?)", userUuid).
+		Where("deleted_at IS NULL").
+		ToSql()
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	tag, err := r.Db.Exec(ctx, sql, args...)
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+	if tag.RowsAffected() == 0 {
+		return fmt.Errorf("%s: %w", op, ErrUserNotFound)
+	}
+
+	return nil
+}
+
+// Replaces the email and returns the one it replaced with the new profile version.
+// Fails with ErrEmailTaken when another user has the email
+func (r *Repo) UpdateEmail(ctx context.Context, userUuid uuid.UUID, email string) (string, int, error) {
+	const op = "repository.user.UpdateEmail"
+
+	// The joined row still holds the value from before the update
+	psql := sq.StatementBuilder.PlaceholderFormat(sq.Dollar)
+	sql, args, err := psql.Update("user_profiles").
+		Set("email", email).
+		Set("version", sq.Expr("user_profiles.version + 1")).
+		From("user_profiles AS previous").
+		Where("previous.user_uuid = user_profiles.user_uuid").
+		Where("user_profiles.user_uuid IN (?)", userUuid).
+		Suffix("RETURNING previous.email, user_profiles.version").
+		ToSql()
+	if err != nil {
+		return "", 0, fmt.Errorf("%s: %w", op, err)
+	}
+
+	var previous string
+	var version int
+	err = r.Db.QueryRow(ctx, sql, args...).Scan(&previous, &version)
+	if err != nil {
+		var pgError *pgconn.PgError
+		switch {
+		case errors.Is(err, pgx.ErrNoRows):
+			return "", 0, fmt.Errorf("%s: %w", op, ErrUserNotFound)
+		case errors.As(err, &pgError) && pgError.Code == pgerrcode.UniqueViolation:
+			return "", 0, fmt.Errorf("%s: %w", op, ErrEmailTaken)
+		}
+		return "", 0, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return previous, version, nil
+}
+
+// Replaces the phone and returns the new profile version
+func (r *Repo) UpdatePhone(ctx context.Context, userUuid uuid.UUID, phone string) (int, error) {
+	const op = "repository.user.UpdatePhone"
+
+	psql := sq.StatementBuilder.PlaceholderFormat(sq.Dollar)
+	sql, args, err := psql.Update("user_profiles").
+		Set("phone", phone).
+		Set("version", sq.Expr("version + 1")).
+		Where("user_uuid IN (?)", userUuid).
+		Suffix("RETURNING version").
+		ToSql()
+	if err != nil {
+		return 0, fmt.Errorf("%s: %w", op, err)
+	}
+
+	var version int
+	err = r.Db.QueryRow(ctx, sql, args...).Scan(&version)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return 0, fmt.Errorf("%s: %w", op, ErrUserNotFound)
+		}
+		return 0, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return version, nil
+}
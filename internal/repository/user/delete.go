@@ -0,0 +1,458 @@
+package userrepo
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	sq "github.com/Masterminds/squirrel"
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+
+	"github.com/bogdanshibilov/mindflowbackend/internal/entity"
+)
+
+// Rows other people rely on. Users with any of them are scrubbed instead of removed when purged
+const hasHistoryExpr = "(EXISTS (SELECT 1 FROM consultation WHERE consultation.expert_uuid = users.uuid OR consultation.mentee_uuid = users.uuid)" +
+	" OR EXISTS (SELECT 1 FROM package_purchase WHERE package_purchase.expert_uuid = users.uuid OR package_purchase.mentee_uuid = users.uuid)" +
+	" OR EXISTS (SELECT 1 FROM payout WHERE payout.expert_uuid = users.uuid))"
+
+// Consultations of the user still to come: applications awaiting a decision
+// and scheduled consultations with a meeting ahead. Users with any are not deleted
+func hasUpcomingExpr() sq.Sqlizer {
+	return sq.Select("1").
+		From("consultation").
+		InnerJoin("consultation_application ON consultation.uuid = consultation_application.consultation_uuid").
+		Where("(consultation.expert_uuid = users.uuid OR consultation.mentee_uuid = users.uuid)").
+		Where(sq.Or{
+			sq.Eq{"consultation_application.status": []entity.Status{entity.Pending, entity.Approved}},
+			sq.And{
+				sq.Eq{"consultation_application.status": entity.Scheduled},
+				sq.Expr("EXISTS (SELECT 1 FROM consultation_meeting WHERE consultation_meeting.consultation_uuid = consultation.uuid AND consultation_meeting.start_time > now())"),
+			},
+		}).
+		Prefix("EXISTS (").
+		Suffix(")")
+}
+
+type PurgeResult struct {
+	Removed  int
+	Scrubbed int
+	// Blob store keys of the purged users' uploaded documents and data exports
+	BlobKeys []string
+	// Avatars the purged users had, their blobs are removed unless someone else uses them
+	AvatarHashes []string
+}
+
+// Marks the user and their expert information deleted. Consultations stay
+// visible to the other party. Users with consultations still to come are
+// kept, those have to be cancelled first
+func (r *Repo) SoftDelete(ctx context.Context, userUuid uuid.UUID, deletedBy uuid.UUID) error {
+	const op = "repository.user.SoftDelete"
+
+	psql := sq.StatementBuilder.PlaceholderFormat(sq.Dollar)
+	upcomingSql, upcomingArgs, err := psql.Select("1").
+		From("users").
+		Where("uuid IN (?)", userUuid).
+		Where(hasUpcomingExpr()).
+		Prefix("SELECT EXISTS (").
+		Suffix(")").
+		ToSql()
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	deleteUserSql, deleteUserArgs, err := psql.Update("users").
+		Set("deleted_at", sq.Expr("now()")).
+		Set("deleted_by", deletedBy).
+		Where("uuid IN (?)", userUuid).
+		Where("deleted_at IS NULL").
+		ToSql()
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	deleteExpertSql, deleteExpertArgs, err := psql.Update("expert_information").
+		Set("deleted_at", sq.Expr("now()")).
+		Where("user_uuid IN (?)", userUuid).
+		ToSql()
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	tx, err := r.Db.Begin(ctx)
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+	defer func() {
+		if err != nil {
+			_ = tx.Rollback(ctx)
+		} else {
+			_ = tx.Commit(ctx)
+		}
+	}()
+
+	var upcoming bool
+	err = tx.QueryRow(ctx, upcomingSql, upcomingArgs...).Scan(&upcoming)
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+	if upcoming {
+		err = ErrUpcomingConsultations
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	tag, err := tx.Exec(ctx, deleteUserSql, deleteUserArgs...)
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+	if tag.RowsAffected() == 0 {
+		err = ErrUserNotFound
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	_, err = tx.Exec(ctx, deleteExpertSql, deleteExpertArgs...)
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	return nil
+}
+
+// Returns which of the users have consultations still to come
+func (r *Repo) WithUpcomingConsultations(ctx context.Context, userUuids []uuid.UUID) ([]uuid.UUID, error) {
+	const op = "repository.user.WithUpcomingConsultations"
+
+	psql := sq.StatementBuilder.PlaceholderFormat(sq.Dollar)
+	sql, args, err := psql.Select("uuid").
+		From("users").
+		Where("uuid = ANY(?)", userUuids).
+		Where(hasUpcomingExpr()).
+		ToSql()
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	rows, err := r.Db.Query(ctx, sql, args...)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+	upcoming, err := pgx.CollectRows(rows, pgx.RowTo[uuid.UUID])
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return upcoming, nil
+}
+
+// Undoes a soft delete made after deletedAfter that was not purged yet
+func (r *Repo) Restore(ctx context.Context, userUuid uuid.UUID, deletedAfter time.Time) error {
+	const op = "repository.user.Restore"
+
+	psql := sq.StatementBuilder.PlaceholderFormat(sq.Dollar)
+	restoreUserSql, restoreUserArgs, err := psql.Update("users").
+		Set("deleted_at", nil).
+		Set("deleted_by", nil).
+		Where("uuid IN (?)", userUuid).
+		Where("deleted_at > ?", deletedAfter).
+		Where("purged_at IS NULL").
+		ToSql()
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	restoreExpertSql, restoreExpertArgs, err := psql.Update("expert_information").
+		Set("deleted_at", nil).
+		Where("user_uuid IN (?)", userUuid).
+		ToSql()
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	tx, err := r.Db.Begin(ctx)
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+	defer func() {
+		if err != nil {
+			_ = tx.Rollback(ctx)
+		} else {
+			_ = tx.Commit(ctx)
+		}
+	}()
+
+	tag, err := tx.Exec(ctx, restoreUserSql, restoreUserArgs...)
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+	if tag.RowsAffected() == 0 {
+		err = ErrNotRestorable
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	_, err = tx.Exec(ctx, restoreExpertSql, restoreExpertArgs...)
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	return nil
+}
+
+// Returns soft deleted users that were not purged yet, most recently deleted first
+func (r *Repo) DeletedUsers(ctx context.Context) ([]entity.User, error) {
+	const op = "repository.user.DeletedUsers"
+
+	sql, args, err := selectUsers().
+		Where("users.deleted_at IS NOT NULL").
+		Where("users.purged_at IS NULL").
+		OrderBy("users.deleted_at DESC").
+		ToSql()
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	rows, err := r.Db.Query(ctx, sql, args...)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+	users, err := pgx.CollectRows(rows, pgx.RowToStructByNameLax[entity.User])
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return users, nil
+}
+
+// Purges users deleted before deletedBefore. Users without history are removed,
+// the others keep their row with personal data scrubbed
+func (r *Repo) PurgeDeleted(ctx context.Context, deletedBefore time.Time) (*PurgeResult, error) {
+	const op = "repository.user.PurgeDeleted"
+
+	tx, err := r.Db.Begin(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+	defer func() {
+		if err != nil {
+			_ = tx.Rollback(ctx)
+		} else {
+			_ = tx.Commit(ctx)
+		}
+	}()
+
+	result, err := purge(ctx, tx, sq.And{
+		sq.Expr("deleted_at <= ?", deletedBefore),
+		sq.Expr("purged_at IS NULL"),
+	})
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return result, nil
+}
+
+// Deletes and purges the user right away, for users closing their own account.
+// Users with consultations still to come have to cancel them first
+func (r *Repo) Erase(ctx context.Context, userUuid uuid.UUID) (*PurgeResult, error) {
+	const op = "repository.user.Erase"
+
+	psql := sq.StatementBuilder.PlaceholderFormat(sq.Dollar)
+	upcomingSql, upcomingArgs, err := psql.Select("1").
+		From("users").
+		Where("uuid IN (?)", userUuid).
+		Where(hasUpcomingExpr()).
+		Prefix("SELECT EXISTS (").
+		Suffix(")").
+		ToSql()
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	deleteSql, deleteArgs, err := psql.Update("users").
+		Set("deleted_at", sq.Expr("now()")).
+		Set("deleted_by", userUuid).
+		Where("uuid IN (?)", userUuid).
+		Where("deleted_at IS NULL").
+		ToSql()
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	tx, err := r.Db.Begin(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+	defer func() {
+		if err != nil {
+			_ = tx.Rollback(ctx)
+		} else {
+			_ = tx.Commit(ctx)
+		}
+	}()
+
+	var upcoming bool
+	err = tx.QueryRow(ctx, upcomingSql, upcomingArgs...).Scan(&upcoming)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+	if upcoming {
+		err = ErrUpcomingConsultations
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	tag, err := tx.Exec(ctx, deleteSql, deleteArgs...)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+	if tag.RowsAffected() == 0 {
+		err = ErrUserNotFound
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	result, err := purge(ctx, tx, sq.Eq{"uuid": userUuid})
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return result, nil
+}
+
+// Removes or scrubs the users matching the condition on the users table
+func purge(ctx context.Context, tx pgx.Tx, users sq.Sqlizer) (*PurgeResult, error) {
+	psql := sq.StatementBuilder.PlaceholderFormat(sq.Dollar)
+	purged := psql.Select("uuid").
+		From("users").
+		Where(users)
+
+	deleteDocumentsSql, deleteDocumentsArgs, err := psql.Delete("expert_document").
+		Where(purged.Prefix("expert_uuid IN (").Suffix(")")).
+		Where("blob_key IS NOT NULL").
+		Suffix("RETURNING blob_key").
+		ToSql()
+	if err != nil {
+		return nil, err
+	}
+
+	deleteExportsSql, deleteExportsArgs, err := psql.Delete("data_export").
+		Where(purged.Prefix("user_uuid IN (").Suffix(")")).
+		Where("blob_key IS NOT NULL").
+		Suffix("RETURNING blob_key").
+		ToSql()
+	if err != nil {
+		return nil, err
+	}
+
+	avatarsSql, avatarsArgs, err := psql.Select("avatar_hash").
+		From("user_profiles").
+		Where(purged.Prefix("user_uuid IN (").Suffix(")")).
+		Where("avatar_hash IS NOT NULL").
+		ToSql()
+	if err != nil {
+		return nil, err
+	}
+
+	removeSql, removeArgs, err := psql.Delete("users").
+		Where(users).
+		Where("NOT " + hasHistoryExpr).
+		ToSql()
+	if err != nil {
+		return nil, err
+	}
+
+	// Only users with history are left at this point
+	deleteExpertsSql, deleteExpertsArgs, err := psql.Delete("expert_information").
+		Where(purged.Prefix("user_uuid IN (").Suffix(")")).
+		ToSql()
+	if err != nil {
+		return nil, err
+	}
+
+	deleteStaffSql, deleteStaffArgs, err := psql.Delete("staff").
+		Where(purged.Prefix("user_uuid IN (").Suffix(")")).
+		ToSql()
+	if err != nil {
+		return nil, err
+	}
+
+	scrubProfilesSql, scrubProfilesArgs, err := psql.Update("user_profiles").
+		Set("name", "Deleted user").
+		Set("email", sq.Expr("user_uuid::text || '@deleted.invalid'")).
+		Set("phone", "").
+		Set("professional_field", "").
+		Set("experience_description", "").
+		Set("avatar_hash", nil).
+		Where(purged.Prefix("user_uuid IN (").Suffix(")")).
+		ToSql()
+	if err != nil {
+		return nil, err
+	}
+
+	scrubUsersSql, scrubUsersArgs, err := psql.Update("users").
+		Set("username", sq.Expr("'deleted-' || uuid::text")).
+		Set("pass_hash", []byte{}).
+		Set("roles", []string{}).
+		Set("purged_at", sq.Expr("now()")).
+		Where(users).
+		ToSql()
+	if err != nil {
+		return nil, err
+	}
+
+	result := &PurgeResult{}
+
+	rows, err := tx.Query(ctx, deleteDocumentsSql, deleteDocumentsArgs...)
+	if err != nil {
+		return nil, err
+	}
+	result.BlobKeys, err = pgx.CollectRows(rows, pgx.RowTo[string])
+	if err != nil {
+		return nil, err
+	}
+
+	rows, err = tx.Query(ctx, deleteExportsSql, deleteExportsArgs...)
+	if err != nil {
+		return nil, err
+	}
+	exportKeys, err := pgx.CollectRows(rows, pgx.RowTo[string])
+	if err != nil {
+		return nil, err
+	}
+	result.BlobKeys = append(result.BlobKeys, exportKeys...)
+
+	rows, err = tx.Query(ctx, avatarsSql, avatarsArgs...)
+	if err != nil {
+		return nil, err
+	}
+	result.AvatarHashes, err = pgx.CollectRows(rows, pgx.RowTo[string])
+	if err != nil {
+		return nil, err
+	}
+
+	tag, err := tx.Exec(ctx, removeSql, removeArgs...)
+	if err != nil {
+		return nil, err
+	}
+	result.Removed = int(tag.RowsAffected())
+
+	for _, statement := range []struct {
+		sql  string
+		args []any
+	}{
+		{deleteExpertsSql, deleteExpertsArgs},
+		{deleteStaffSql, deleteStaffArgs},
+		{scrubProfilesSql, scrubProfilesArgs},
+	} {
+		_, err = tx.Exec(ctx, statement.sql, statement.args...)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	tag, err = tx.Exec(ctx, scrubUsersSql, scrubUsersArgs...)
+	if err != nil {
+		return nil, err
+	}
+	result.Scrubbed = int(tag.RowsAffected())
+
+	return result, nil
+}
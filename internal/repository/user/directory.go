@@ -0,0 +1,255 @@
+package userrepo
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	sq "github.com/Masterminds/squirrel"
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+
+	"github.com/bogdanshibilov/mindflowbackend/internal/entity"
+	"github.com/bogdanshibilov/mindflowbackend/internal/pagination"
+)
+
+const (
+	// Users in the staff table
+	RoleStaff = "staff"
+	// Users whose expert application was approved
+	RoleExpert = "expert"
+	// Everyone else
+	RoleMember = "member"
+)
+
+// Expert status filter of users who never applied to become experts
+const NoApplication entity.Status = -1
+
+const maxFilterQueryLength = 255
+
+const staffExpr = "EXISTS (SELECT 1 FROM staff WHERE staff.user_uuid = users.uuid)"
+
+const suspendedExpr = "(users.suspended_at IS NOT NULL AND (users.suspended_until IS NULL OR users.suspended_until > now()))"
+
+const expertStatusExpr = "(SELECT expert_application.status FROM expert_application WHERE expert_application.user_uuid = users.uuid)"
+
+// Sort keys of user lists
+var Sorts = map[string]pagination.Sort{
+	"name":      {Expr: "lower(user_profiles.name)", Type: "text"},
+	"email":     {Expr: "lower(user_profiles.email)", Type: "text"},
+	"created":   {Expr: "users.created_at", Type: "timestamp", Desc: true},
+	"lastlogin": {Expr: "COALESCE(users.last_login_at, 'epoch')", Type: "timestamp", Desc: true},
+}
+
+// A user as the admin directory lists them
+type DirectoryUser struct {
+	entity.User
+	Staff bool `db:"staff"`
+	// Status of the expert application, nil without one
+	ExpertStatus    *entity.Status `db:"expert_status"`
+	ExpertSuspended bool           `db:"expert_suspended"`
+}
+
+// Conditions the admin directory is filtered by. Zero fields do not filter
+type UserFilter struct {
+	// Case insensitive part of the name, email, phone or professional field
+	Query string
+	// Users with any of RoleStaff, RoleExpert and RoleMember
+	Roles []string
+	// Status of the expert application, NoApplication for users without one
+	ExpertStatus *entity.Status
+	// Bounds of the registration time
+	RegisteredFrom *time.Time
+	RegisteredTo   *time.Time
+	// Users who signed in at or after ActiveSince
+	ActiveSince *time.Time
+	// Users who did not sign in since InactiveSince, including those who never did
+	InactiveSince *time.Time
+	// Users with or without a suspension in force
+	Suspended *bool
+}
+
+func (f *UserFilter) Validate() error {
+	if len(f.Query) > maxFilterQueryLength {
+		return fmt.Errorf("%w: query is too long", ErrInvalidFilter)
+	}
+	for _, role := range f.Roles {
+		if role != RoleStaff && role != RoleExpert && role != RoleMember {
+			return fmt.Errorf("%w: role must be staff, expert or member", ErrInvalidFilter)
+		}
+	}
+	if f.ExpertStatus != nil {
+		switch *f.ExpertStatus {
+		case NoApplication, entity.Pending, entity.Approved, entity.Rejected:
+		default:
+			return fmt.Errorf("%w: unknown expert status", ErrInvalidFilter)
+		}
+	}
+	if f.RegisteredFrom != nil && f.RegisteredTo != nil && f.RegisteredFrom.After(*f.RegisteredTo) {
+		return fmt.Errorf("%w: registration range ends before it starts", ErrInvalidFilter)
+	}
+
+	return nil
+}
+
+func (f *UserFilter) apply(query sq.SelectBuilder) sq.SelectBuilder {
+	if f.Query != "" {
+		pattern := "%" + escapeLike(f.Query) + "%"
+		query = query.Where(sq.Or{
+			sq.ILike{"user_profiles.name": pattern},
+			sq.ILike{"user_profiles.email": pattern},
+			sq.ILike{"user_profiles.phone": pattern},
+			sq.ILike{"user_profiles.professional_field": pattern},
+		})
+	}
+	if len(f.Roles) > 0 {
+		roles := sq.Or{}
+		for _, role := range f.Roles {
+			switch role {
+			case RoleStaff:
+				roles = append(roles, sq.Expr(staffExpr))
+			case RoleExpert:
+				roles = append(roles, sq.Expr(expertStatusExpr+" = ?", entity.Approved))
+			case RoleMember:
+				roles = append(roles, sq.Expr("NOT "+staffExpr+" AND "+expertStatusExpr+" IS DISTINCT FROM ?", entity.Approved))
+			}
+		}
+		query = query.Where(roles)
+	}
+	if f.ExpertStatus != nil {
+		if *f.ExpertStatus == NoApplication {
+			query = query.Where(expertStatusExpr + " IS NULL")
+		} else {
+			query = query.Where(expertStatusExpr+" = ?", *f.ExpertStatus)
+		}
+	}
+	if f.RegisteredFrom != nil {
+		query = query.Where("users.created_at >= ?", *f.RegisteredFrom)
+	}
+	if f.RegisteredTo != nil {
+		query = query.Where("users.created_at < ?", *f.RegisteredTo)
+	}
+	if f.ActiveSince != nil {
+		query = query.Where("users.last_login_at >= ?", *f.ActiveSince)
+	}
+	if f.InactiveSince != nil {
+		query = query.Where("(users.last_login_at IS NULL OR users.last_login_at < ?)", *f.InactiveSince)
+	}
+	if f.Suspended != nil {
+		condition := suspendedExpr
+		if !*f.Suspended {
+			condition = "NOT " + suspendedExpr
+		}
+		query = query.Where(condition)
+	}
+	return query
+}
+
+func selectDirectory() sq.SelectBuilder {
+	return selectUsers().
+		Column(staffExpr + " AS staff").
+		Column(expertStatusExpr + " AS expert_status").
+		Column("EXISTS (SELECT 1 FROM expert_information WHERE expert_information.user_uuid = users.uuid" +
+			" AND expert_information.suspended_at IS NOT NULL) AS expert_suspended").
+		Where("users.deleted_at IS NULL")
+}
+
+func (r *Repo) Users(
+	ctx context.Context,
+	filter *UserFilter,
+	page *pagination.Request,
+) (*pagination.Page[DirectoryUser], error) {
+	const op = "repository.user.Users"
+
+	usersQuery := filter.apply(selectDirectory())
+
+	countSql, countArgs, err := pagination.Count(usersQuery).ToSql()
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+	sql, args, err := page.Apply(usersQuery, "users.uuid").ToSql()
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	var total int
+	err = r.Db.QueryRow(ctx, countSql, countArgs...).Scan(&total)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	rows, err := r.Db.Query(ctx, sql, args...)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+	userRows, err := pgx.CollectRows(rows, pgx.RowToStructByNameLax[userRow])
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	pageRows := make([]pagination.Row[DirectoryUser], 0, len(userRows))
+	for _, row := range userRows {
+		pageRows = append(pageRows, pagination.Row[DirectoryUser]{
+			Item:    row.DirectoryUser,
+			Id:      row.Uuid,
+			SortKey: row.SortKey,
+		})
+	}
+
+	return pagination.NewPage(page, pageRows, total), nil
+}
+
+type userRow struct {
+	DirectoryUser
+	SortKey string `db:"sort_key"`
+}
+
+// Returns the users that are not deleted, in no particular order. Unknown ids are left out
+func (r *Repo) DirectoryByUuids(ctx context.Context, userUuids []uuid.UUID) ([]DirectoryUser, error) {
+	const op = "repository.user.DirectoryByUuids"
+
+	sql, args, err := selectDirectory().
+		Where("users.uuid = ANY(?)", userUuids).
+		ToSql()
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	rows, err := r.Db.Query(ctx, sql, args...)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+	users, err := pgx.CollectRows(rows, pgx.RowToStructByNameLax[DirectoryUser])
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return users, nil
+}
+
+func (r *Repo) RecordLogin(ctx context.Context, userUuid uuid.UUID) error {
+	const op = "repository.user.RecordLogin"
+
+	psql := sq.StatementBuilder.PlaceholderFormat(sq.Dollar)
+	sql, args, err := psql.Update("users").
+		Set("last_login_at", sq.Expr("now()")).
+		Where("uuid IN (?)", userUuid).
+		ToSql()
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	_, err = r.Db.Exec(ctx, sql, args...)
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	return nil
+}
+
+var likeEscaper = strings.NewReplacer(`\`, `\\`, `%`, `\%`, `_`, `\_`)
+
+func escapeLike(value string) string {
+	return likeEscaper.Replace(value)
+}
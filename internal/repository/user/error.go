@@ -3,5 +3,17 @@ package userrepo
 import "errors"
 
 var (
-	ErrUserNotFound = errors.New("user not found")
+	ErrUserNotFound          = errors.New("user not found")
+	ErrNotRestorable         = errors.New("user is not deleted, was purged or the restore window has passed")
+	ErrUpcomingConsultations = errors.New("user has consultations that are not finished or cancelled")
+	ErrExportNotFound        = errors.New("data export not found")
+	ErrExportInProgress      = errors.New("a data export of the user is already being built")
+	ErrVersionMismatch       = errors.New("user profile was changed since it was read")
+	ErrEmailTaken            = errors.New("email is already used by another user")
+	ErrInvalidFilter         = errors.New("invalid filter")
+	ErrNotSuspended          = errors.New("user not found or not suspended")
+	ErrUsernameTaken         = errors.New("username is already used by another user")
+	ErrImportNotFound        = errors.New("user import not found")
+	ErrImportRowProcessed    = errors.New("import row was already processed")
+	ErrInviteInvalid         = errors.New("invite is unknown, used or expired")
 )
@@ -0,0 +1,184 @@
+package userrepo
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	sq "github.com/Masterminds/squirrel"
+	"github.com/google/uuid"
+	"github.com/jackc/pgerrcode"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+
+	"github.com/bogdanshibilov/mindflowbackend/internal/entity"
+)
+
+func selectExports() sq.SelectBuilder {
+	psql := sq.StatementBuilder.PlaceholderFormat(sq.Dollar)
+	return psql.Select(
+		"uuid",
+		"user_uuid",
+		"status",
+		"blob_key",
+		"size_bytes",
+		"requested_at",
+		"completed_at",
+		"expires_at",
+	).
+		From("data_export")
+}
+
+func (r *Repo) CreateExport(ctx context.Context, export *entity.DataExport) error {
+	const op = "repository.user.CreateExport"
+
+	psql := sq.StatementBuilder.PlaceholderFormat(sq.Dollar)
+	sql, args, err := psql.Insert("data_export").
+		Columns("user_uuid").
+		Values(export.UserUuid).
+		Suffix("RETURNING uuid, status, requested_at").
+		ToSql()
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	err = r.Db.QueryRow(ctx, sql, args...).Scan(&export.Uuid, &export.Status, &export.RequestedAt)
+	if err != nil {
+		var pgError *pgconn.PgError
+		if errors.As(err, &pgError) && pgError.Code == pgerrcode.UniqueViolation {
+			return fmt.Errorf("%s: %w", op, ErrExportInProgress)
+		}
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	return nil
+}
+
+// Returns the export if it belongs to the user
+func (r *Repo) ExportByUuid(ctx context.Context, exportUuid uuid.UUID, userUuid uuid.UUID) (*entity.DataExport, error) {
+	const op = "repository.user.ExportByUuid"
+
+	sql, args, err := selectExports().
+		Where("uuid IN (?)", exportUuid).
+		Where("user_uuid IN (?)", userUuid).
+		ToSql()
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	rows, err := r.Db.Query(ctx, sql, args...)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+	export, err := pgx.CollectOneRow(rows, pgx.RowToStructByNameLax[entity.DataExport])
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, fmt.Errorf("%s: %w", op, ErrExportNotFound)
+		}
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return &export, nil
+}
+
+// Returns exports waiting to be built, oldest first
+func (r *Repo) PendingExports(ctx context.Context) ([]entity.DataExport, error) {
+	const op = "repository.user.PendingExports"
+
+	sql, args, err := selectExports().
+		Where(sq.Eq{"status": entity.DataExportPending}).
+		OrderBy("requested_at").
+		ToSql()
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	rows, err := r.Db.Query(ctx, sql, args...)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+	exports, err := pgx.CollectRows(rows, pgx.RowToStructByNameLax[entity.DataExport])
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return exports, nil
+}
+
+func (r *Repo) CompleteExport(ctx context.Context, exportUuid uuid.UUID, blobKey string, sizeBytes int64, expiresAt time.Time) error {
+	const op = "repository.user.CompleteExport"
+
+	psql := sq.StatementBuilder.PlaceholderFormat(sq.Dollar)
+	sql, args, err := psql.Update("data_export").
+		Set("status", entity.DataExportReady).
+		Set("blob_key", blobKey).
+		Set("size_bytes", sizeBytes).
+		Set("completed_at", sq.Expr("now()")).
+		Set("expires_at", expiresAt).
+		Where("uuid IN (?)", exportUuid).
+		ToSql()
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	_, err = r.Db.Exec(ctx, sql, args...)
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	return nil
+}
+
+func (r *Repo) FailExport(ctx context.Context, exportUuid uuid.UUID) error {
+	const op = "repository.user.FailExport"
+
+	psql := sq.StatementBuilder.PlaceholderFormat(sq.Dollar)
+	sql, args, err := psql.Update("data_export").
+		Set("status", entity.DataExportFailed).
+		Set("completed_at", sq.Expr("now()")).
+		Where("uuid IN (?)", exportUuid).
+		ToSql()
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	_, err = r.Db.Exec(ctx, sql, args...)
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	return nil
+}
+
+// Deletes exports past their expiry and returns the blob keys of their archives
+func (r *Repo) DeleteExpiredExports(ctx context.Context) ([]string, error) {
+	const op = "repository.user.DeleteExpiredExports"
+
+	psql := sq.StatementBuilder.PlaceholderFormat(sq.Dollar)
+	sql, args, err := psql.Delete("data_export").
+		Where("expires_at <= now()").
+		Suffix("RETURNING blob_key").
+		ToSql()
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	rows, err := r.Db.Query(ctx, sql, args...)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+	blobKeys, err := pgx.CollectRows(rows, pgx.RowTo[*string])
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	keys := make([]string, 0, len(blobKeys))
+	for _, key := range blobKeys {
+		if key != nil {
+			keys = append(keys, *key)
+		}
+	}
+
+	return keys, nil
+}
@@ -0,0 +1,401 @@
+package userrepo
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	sq "github.com/Masterminds/squirrel"
+	"github.com/google/uuid"
+	"github.com/jackc/pgerrcode"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+
+	"github.com/bogdanshibilov/mindflowbackend/internal/entity"
+)
+
+func selectImports() sq.SelectBuilder {
+	psql := sq.StatementBuilder.PlaceholderFormat(sq.Dollar)
+	return psql.Select(
+		"uuid",
+		"admin_uuid",
+		"status",
+		"created_at",
+		"completed_at",
+		"(SELECT count(*) FROM user_import_row WHERE import_uuid = user_import.uuid) AS total_rows",
+		"(SELECT count(*) FROM user_import_row WHERE import_uuid = user_import.uuid AND status = 'created') AS created_rows",
+		"(SELECT count(*) FROM user_import_row WHERE import_uuid = user_import.uuid AND status = 'failed') AS failed_rows",
+	).
+		From("user_import")
+}
+
+// Stores the import with its rows and sets its uuid, status and creation time
+func (r *Repo) CreateImport(ctx context.Context, userImport *entity.UserImport, rows []entity.UserImportRow) error {
+	const op = "repository.user.CreateImport"
+
+	psql := sq.StatementBuilder.PlaceholderFormat(sq.Dollar)
+	importSql, importArgs, err := psql.Insert("user_import").
+		Columns("admin_uuid").
+		Values(userImport.AdminUuid).
+		Suffix("RETURNING uuid, status, created_at").
+		ToSql()
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	tx, err := r.Db.Begin(ctx)
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+	defer func() {
+		if err != nil {
+			_ = tx.Rollback(ctx)
+		} else {
+			_ = tx.Commit(ctx)
+		}
+	}()
+
+	err = tx.QueryRow(ctx, importSql, importArgs...).Scan(&userImport.Uuid, &userImport.Status, &userImport.CreatedAt)
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	insertRows := psql.Insert("user_import_row").
+		Columns(
+			"import_uuid",
+			"line",
+			"name",
+			"email",
+			"phone",
+			"professional_field",
+			"experience_description",
+			"status",
+			"error",
+		)
+	for i := range rows {
+		rows[i].ImportUuid = userImport.Uuid
+		insertRows = insertRows.Values(
+			rows[i].ImportUuid,
+			rows[i].Line,
+			rows[i].Name,
+			rows[i].Email,
+			rows[i].Phone,
+			rows[i].ProfessionalField,
+			rows[i].ExperienceDescription,
+			rows[i].Status,
+			rows[i].Error,
+		)
+	}
+	rowsSql, rowsArgs, err := insertRows.ToSql()
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	_, err = tx.Exec(ctx, rowsSql, rowsArgs...)
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	userImport.TotalRows = len(rows)
+	for _, row := range rows {
+		if row.Status == entity.UserImportRowFailed {
+			userImport.FailedRows++
+		}
+	}
+
+	return nil
+}
+
+func (r *Repo) ImportByUuid(ctx context.Context, importUuid uuid.UUID) (*entity.UserImport, error) {
+	const op = "repository.user.ImportByUuid"
+
+	sql, args, err := selectImports().
+		Where("uuid IN (?)", importUuid).
+		ToSql()
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	rows, err := r.Db.Query(ctx, sql, args...)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+	userImport, err := pgx.CollectOneRow(rows, pgx.RowToStructByNameLax[entity.UserImport])
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, fmt.Errorf("%s: %w", op, ErrImportNotFound)
+		}
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return &userImport, nil
+}
+
+// Returns imports with rows still to create, oldest first
+func (r *Repo) PendingImports(ctx context.Context) ([]entity.UserImport, error) {
+	const op = "repository.user.PendingImports"
+
+	sql, args, err := selectImports().
+		Where(sq.Eq{"status": entity.UserImportPending}).
+		OrderBy("created_at").
+		ToSql()
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	rows, err := r.Db.Query(ctx, sql, args...)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+	imports, err := pgx.CollectRows(rows, pgx.RowToStructByNameLax[entity.UserImport])
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return imports, nil
+}
+
+// Returns rows of the import in line order, all of them without a status
+func (r *Repo) ImportRows(
+	ctx context.Context,
+	importUuid uuid.UUID,
+	status *entity.UserImportRowStatus,
+	limit uint64,
+) ([]entity.UserImportRow, error) {
+	const op = "repository.user.ImportRows"
+
+	psql := sq.StatementBuilder.PlaceholderFormat(sq.Dollar)
+	query := psql.Select(
+		"import_uuid",
+		"line",
+		"name",
+		"email",
+		"phone",
+		"professional_field",
+		"experience_description",
+		"status",
+		"error",
+		"user_uuid",
+	).
+		From("user_import_row").
+		Where("import_uuid IN (?)", importUuid).
+		OrderBy("line")
+	if status != nil {
+		query = query.Where(sq.Eq{"status": *status})
+	}
+	if limit > 0 {
+		query = query.Limit(limit)
+	}
+	sql, args, err := query.ToSql()
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	rows, err := r.Db.Query(ctx, sql, args...)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+	importRows, err := pgx.CollectRows(rows, pgx.RowToStructByNameLax[entity.UserImportRow])
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return importRows, nil
+}
+
+// Returns which of the emails belong to users already, compared case insensitively
+func (r *Repo) RegisteredEmails(ctx context.Context, emails []string) ([]string, error) {
+	const op = "repository.user.RegisteredEmails"
+
+	lowered := make([]string, 0, len(emails))
+	for _, email := range emails {
+		lowered = append(lowered, strings.ToLower(email))
+	}
+
+	psql := sq.StatementBuilder.PlaceholderFormat(sq.Dollar)
+	sql, args, err := psql.Select("lower(email)").
+		From("user_profiles").
+		Where("lower(email) = ANY(?)", lowered).
+		ToSql()
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	rows, err := r.Db.Query(ctx, sql, args...)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+	registered, err := pgx.CollectRows(rows, pgx.RowTo[string])
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return registered, nil
+}
+
+// Creates the user of a pending row together with an invite to choose a
+// password, and marks the row created. The user cannot sign in before the
+// invite is accepted. Fails with ErrImportRowProcessed when the row is no
+// longer pending
+func (r *Repo) CreateImportedUser(
+	ctx context.Context,
+	row *entity.UserImportRow,
+	inviteHash []byte,
+	inviteExpiresAt time.Time,
+) error {
+	const op = "repository.user.CreateImportedUser"
+
+	psql := sq.StatementBuilder.PlaceholderFormat(sq.Dollar)
+	// An empty hash never matches a password
+	userSql, userArgs, err := psql.Insert("users").
+		Columns("username", "pass_hash").
+		Values(strings.ToLower(row.Email), []byte{}).
+		Suffix("RETURNING uuid").
+		ToSql()
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	tx, err := r.Db.Begin(ctx)
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+	defer func() {
+		if err != nil {
+			_ = tx.Rollback(ctx)
+		} else {
+			_ = tx.Commit(ctx)
+		}
+	}()
+
+	var userUuid uuid.UUID
+	err = tx.QueryRow(ctx, userSql, userArgs...).Scan(&userUuid)
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, importUniqueError(err))
+	}
+
+	profileSql, profileArgs, err := psql.Insert("user_profiles").
+		Columns(
+			"user_uuid",
+			"name",
+			"email",
+			"phone",
+			"professional_field",
+			"experience_description",
+		).
+		Values(
+			userUuid,
+			row.Name,
+			row.Email,
+			row.Phone,
+			row.ProfessionalField,
+			row.ExperienceDescription,
+		).
+		ToSql()
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+	_, err = tx.Exec(ctx, profileSql, profileArgs...)
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, importUniqueError(err))
+	}
+
+	inviteSql, inviteArgs, err := psql.Insert("user_invite").
+		Columns("token_hash", "user_uuid", "expires_at").
+		Values(inviteHash, userUuid, inviteExpiresAt).
+		ToSql()
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+	_, err = tx.Exec(ctx, inviteSql, inviteArgs...)
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	rowSql, rowArgs, err := psql.Update("user_import_row").
+		Set("status", entity.UserImportRowCreated).
+		Set("user_uuid", userUuid).
+		Where("import_uuid IN (?)", row.ImportUuid).
+		Where("line IN (?)", row.Line).
+		Where(sq.Eq{"status": entity.UserImportRowPending}).
+		ToSql()
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+	tag, err := tx.Exec(ctx, rowSql, rowArgs...)
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+	if tag.RowsAffected() == 0 {
+		err = ErrImportRowProcessed
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	row.Status = entity.UserImportRowCreated
+	row.UserUuid = &userUuid
+
+	return nil
+}
+
+func importUniqueError(err error) error {
+	var pgError *pgconn.PgError
+	if errors.As(err, &pgError) && pgError.Code == pgerrcode.UniqueViolation {
+		if pgError.TableName == "users" {
+			return ErrUsernameTaken
+		}
+		return ErrEmailTaken
+	}
+	return err
+}
+
+func (r *Repo) FailImportRow(ctx context.Context, row *entity.UserImportRow, reason string) error {
+	const op = "repository.user.FailImportRow"
+
+	psql := sq.StatementBuilder.PlaceholderFormat(sq.Dollar)
+	sql, args, err := psql.Update("user_import_row").
+		Set("status", entity.UserImportRowFailed).
+		Set("error", reason).
+		Where("import_uuid IN (?)", row.ImportUuid).
+		Where("line IN (?)", row.Line).
+		Where(sq.Eq{"status": entity.UserImportRowPending}).
+		ToSql()
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	_, err = r.Db.Exec(ctx, sql, args...)
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	row.Status = entity.UserImportRowFailed
+	row.Error = &reason
+
+	return nil
+}
+
+// Marks the import done once none of its rows are pending
+func (r *Repo) CompleteImport(ctx context.Context, importUuid uuid.UUID) error {
+	const op = "repository.user.CompleteImport"
+
+	psql := sq.StatementBuilder.PlaceholderFormat(sq.Dollar)
+	sql, args, err := psql.Update("user_import").
+		Set("status", entity.UserImportDone).
+		Set("completed_at", sq.Expr("now()")).
+		Where("uuid IN (?)", importUuid).
+		Where(sq.Eq{"status": entity.UserImportPending}).
+		Where("NOT EXISTS (SELECT 1 FROM user_import_row WHERE import_uuid = user_import.uuid AND status = 'pending')").
+		ToSql()
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	_, err = r.Db.Exec(ctx, sql, args...)
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	return nil
+}
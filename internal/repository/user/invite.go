@@ -0,0 +1,69 @@
+package userrepo
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	sq "github.com/Masterminds/squirrel"
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+)
+
+// Sets the password of the invited user and uses up the invite. Fails with
+// ErrInviteInvalid when the invite is unknown, used or expired
+func (r *Repo) AcceptInvite(ctx context.Context, tokenHash []byte, passHash []byte) (uuid.UUID, error) {
+	const op = "repository.user.AcceptInvite"
+
+	psql := sq.StatementBuilder.PlaceholderFormat(sq.Dollar)
+	inviteSql, inviteArgs, err := psql.Update("user_invite").
+		Set("used_at", sq.Expr("now()")).
+		Where("token_hash IN (?)", tokenHash).
+		Where("used_at IS NULL").
+		Where("expires_at > now()").
+		Suffix("RETURNING user_uuid").
+		ToSql()
+	if err != nil {
+		return uuid.Nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	tx, err := r.Db.Begin(ctx)
+	if err != nil {
+		return uuid.Nil, fmt.Errorf("%s: %w", op, err)
+	}
+	defer func() {
+		if err != nil {
+			_ = tx.Rollback(ctx)
+		} else {
+			_ = tx.Commit(ctx)
+		}
+	}()
+
+	var userUuid uuid.UUID
+	err = tx.QueryRow(ctx, inviteSql, inviteArgs...).Scan(&userUuid)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			err = ErrInviteInvalid
+		}
+		return uuid.Nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	userSql, userArgs, err := psql.Update("users").
+		Set("pass_hash", passHash).
+		Where("uuid IN (?)", userUuid).
+		Where("deleted_at IS NULL").
+		ToSql()
+	if err != nil {
+		return uuid.Nil, fmt.Errorf("%s: %w", op, err)
+	}
+	tag, err := tx.Exec(ctx, userSql, userArgs...)
+	if err != nil {
+		return uuid.Nil, fmt.Errorf("%s: %w", op, err)
+	}
+	if tag.RowsAffected() == 0 {
+		err = ErrInviteInvalid
+		return uuid.Nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return userUuid, nil
+}
@@ -0,0 +1,80 @@
+package userrepo
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	sq "github.com/Masterminds/squirrel"
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+
+	"github.com/bogdanshibilov/mindflowbackend/internal/entity"
+)
+
+// Saves the privacy settings. With a version the update only applies while the
+// stored version still matches it. Returns the new version
+func (r *Repo) UpdatePrivacy(
+	ctx context.Context,
+	userUuid uuid.UUID,
+	settings *entity.PrivacySettings,
+	version *int,
+) (int, error) {
+	const op = "repository.user.UpdatePrivacy"
+
+	psql := sq.StatementBuilder.PlaceholderFormat(sq.Dollar)
+	update := psql.Update("user_profiles").
+		Set("email_visibility", settings.EmailVisibility).
+		Set("phone_visibility", settings.PhoneVisibility).
+		Set("experience_visibility", settings.ExperienceVisibility).
+		Set("version", sq.Expr("version + 1")).
+		Where("user_uuid IN (?)", userUuid).
+		Suffix("RETURNING version")
+	if version != nil {
+		update = update.Where("version IN (?)", *version)
+	}
+	sql, args, err := update.ToSql()
+	if err != nil {
+		return 0, fmt.Errorf("%s: %w", op, err)
+	}
+
+	var newVersion int
+	err = r.Db.QueryRow(ctx, sql, args...).Scan(&newVersion)
+	if errors.Is(err, pgx.ErrNoRows) {
+		err = r.staleOrMissing(ctx, userUuid)
+	}
+	if err != nil {
+		return 0, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return newVersion, nil
+}
+
+// Reports whether the users share a consultation that was not rejected, in either role
+func (r *Repo) AreParticipants(ctx context.Context, userUuid uuid.UUID, otherUuid uuid.UUID) (bool, error) {
+	const op = "repository.user.AreParticipants"
+
+	psql := sq.StatementBuilder.PlaceholderFormat(sq.Dollar)
+	sql, args, err := psql.Select("1").
+		From("consultation").
+		InnerJoin("consultation_application ON consultation.uuid = consultation_application.consultation_uuid").
+		Where(sq.Or{
+			sq.Eq{"consultation.expert_uuid": userUuid, "consultation.mentee_uuid": otherUuid},
+			sq.Eq{"consultation.expert_uuid": otherUuid, "consultation.mentee_uuid": userUuid},
+		}).
+		Where(sq.NotEq{"consultation_application.status": entity.Rejected}).
+		Prefix("SELECT EXISTS (").
+		Suffix(")").
+		ToSql()
+	if err != nil {
+		return false, fmt.Errorf("%s: %w", op, err)
+	}
+
+	var participants bool
+	err = r.Db.QueryRow(ctx, sql, args...).Scan(&participants)
+	if err != nil {
+		return false, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return participants, nil
+}
@@ -0,0 +1,125 @@
+package userrepo
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	sq "github.com/Masterminds/squirrel"
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+
+	"github.com/bogdanshibilov/mindflowbackend/internal/entity"
+)
+
+// What is needed to decide whether a token still signs the user in
+type SessionState struct {
+	TokenVersion             int `db:"token_version"`
+	entity.AccountSuspension `db:"-"`
+}
+
+// Returns the session state of a user that is not deleted
+func (r *Repo) SessionState(ctx context.Context, userUuid uuid.UUID) (*SessionState, error) {
+	const op = "repository.user.SessionState"
+
+	psql := sq.StatementBuilder.PlaceholderFormat(sq.Dollar)
+	sql, args, err := psql.Select(
+		"token_version",
+		"suspended_at",
+		"suspended_until",
+		"suspension_reason",
+		"suspended_by",
+		"appeal_note",
+	).
+		From("users").
+		Where("uuid IN (?)", userUuid).
+		Where("deleted_at IS NULL").
+		ToSql()
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	rows, err := r.Db.Query(ctx, sql, args...)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+	state, err := pgx.CollectOneRow(rows, pgx.RowToStructByNameLax[SessionState])
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, fmt.Errorf("%s: %w", op, ErrUserNotFound)
+		}
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return &state, nil
+}
+
+// Suspends the user until the given time, or for good when until is nil, and
+// raises the token version so that no session outlives the suspension. A
+// suspension in force is replaced
+func (r *Repo) Suspend(
+	ctx context.Context,
+	userUuid uuid.UUID,
+	adminUuid uuid.UUID,
+	reason string,
+	until *time.Time,
+	appealNote *string,
+) error {
+	const op = "repository.user.Suspend"
+
+	psql := sq.StatementBuilder.PlaceholderFormat(sq.Dollar)
+	sql, args, err := psql.Update("users").
+		Set("suspended_at", sq.Expr("now()")).
+		Set("suspended_until", until).
+		Set("suspension_reason", reason).
+		Set("suspended_by", adminUuid).
+		Set("appeal_note", appealNote).
+		Set("token_version", sq.Expr("token_version + 1")).
+		Where("uuid IN (?)", userUuid).
+		Where("deleted_at IS NULL").
+		ToSql()
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	tag, err := r.Db.Exec(ctx, sql, args...)
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+	if tag.RowsAffected() == 0 {
+		return fmt.Errorf("%s: %w", op, ErrUserNotFound)
+	}
+
+	return nil
+}
+
+// Ends the suspension of the user, lapsed temporary ones are cleared as well
+func (r *Repo) LiftSuspension(ctx context.Context, userUuid uuid.UUID) error {
+	const op = "repository.user.LiftSuspension"
+
+	psql := sq.StatementBuilder.PlaceholderFormat(sq.Dollar)
+	sql, args, err := psql.Update("users").
+		Set("suspended_at", nil).
+		Set("suspended_until", nil).
+		Set("suspension_reason", nil).
+		Set("suspended_by", nil).
+		Set("appeal_note", nil).
+		Where("uuid IN (?)", userUuid).
+		Where("deleted_at IS NULL").
+		Where("suspended_at IS NOT NULL").
+		ToSql()
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	tag, err := r.Db.Exec(ctx, sql, args...)
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+	if tag.RowsAffected() == 0 {
+		return fmt.Errorf("%s: %w", op, ErrNotSuspended)
+	}
+
+	return nil
+}
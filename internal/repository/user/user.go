@@ -7,10 +7,13 @@ import (
 
 	sq "github.com/Masterminds/squirrel"
 	"github.com/google/uuid"
+	"github.com/jackc/pgerrcode"
 	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
 
 	"github.com/bogdanshibilov/mindflowbackend/internal/db/postgres"
 	"github.com/bogdanshibilov/mindflowbackend/internal/entity"
+	"github.com/bogdanshibilov/mindflowbackend/internal/tenant"
 )
 
 type Repo struct {
@@ -89,120 +92,83 @@ func (r *Repo) CreateUser(ctx context.Context, user *entity.User) error {
 	return nil
 }
 
-func (r *Repo) UpdateCredentials(
+// Saves the profile fields. With a version the update only applies while the
+// stored version still matches it. Returns the new version
+func (r *Repo) UpdateProfile(
 	ctx context.Context,
-	creds *entity.UserCredentials,
+	profile *entity.UserProfile,
 	uuid uuid.UUID,
-) error {
-	const op = "repository.user.UpdateCredentials"
-
-	psql := sq.StatementBuilder.PlaceholderFormat(sq.Dollar)
-	sql, args, err := psql.Update("users").
-		SetMap(
-			sq.Eq{
-				"username":  creds.Username,
-				"pass_hash": creds.Username,
-			},
-		).
-		Where("uuid IN (?)", uuid).
-		ToSql()
-	if err != nil {
-		return fmt.Errorf("%s: %w", op, err)
-	}
-
-	_, err = r.Db.Exec(ctx, sql, args...)
-	if err != nil {
-		return fmt.Errorf("%s: %w", op, err)
-	}
-
-	return nil
-}
-
-func (r *Repo) UpdateSettings(ctx context.Context, newEmail, newPhone, newPassHash string, uuid uuid.UUID) error {
-	const op = "repository.user.UpdateCredentials"
+	version *int,
+) (int, error) {
+	const op = "repository.user.UpdateProfile"
 
 	psql := sq.StatementBuilder.PlaceholderFormat(sq.Dollar)
-	updateInfoSql, updateInfoArgs, err := psql.Update("user_profiles").
+	update := psql.Update("user_profiles").
 		SetMap(
 			sq.Eq{
-				"email": newEmail,
-				"phone": newPhone,
+				"name":                   profile.Name,
+				"email":                  profile.Email,
+				"phone":                  profile.Phone,
+				"professional_field":     profile.ProfessionalField,
+				"experience_description": profile.ExperienceDescription,
+				"version":                sq.Expr("version + 1"),
 			},
 		).
 		Where("user_uuid IN (?)", uuid).
-		ToSql()
-	if err != nil {
-		return fmt.Errorf("%s: %w", op, err)
+		Suffix("RETURNING version")
+	if version != nil {
+		update = update.Where("version IN (?)", *version)
 	}
-	updatePassSql, updatePassArgs, err := psql.Update("users").
-		SetMap(sq.Eq{"pass_hash": newPassHash}).
-		Where("uuid IN (?)", uuid).
-		ToSql()
+	sql, args, err := update.ToSql()
 	if err != nil {
-		return fmt.Errorf("%s: %w", op, err)
-	}
-
-	tx, err := r.Db.Begin(ctx)
-	if err != nil {
-		return fmt.Errorf("%s: %w", op, err)
+		return 0, fmt.Errorf("%s: %w", op, err)
 	}
-	defer func() {
-		if err != nil {
-			_ = tx.Rollback(ctx)
-		} else {
-			_ = tx.Commit(ctx)
-		}
-	}()
 
-	_, err = tx.Exec(ctx, updateInfoSql, updateInfoArgs...)
-	if err != nil {
-		return fmt.Errorf("%s: %w", op, err)
+	var newVersion int
+	err = r.Db.QueryRow(ctx, sql, args...).Scan(&newVersion)
+	if errors.Is(err, pgx.ErrNoRows) {
+		err = r.staleOrMissing(ctx, uuid)
 	}
-	_, err = tx.Exec(ctx, updatePassSql, updatePassArgs...)
 	if err != nil {
-		return fmt.Errorf("%s: %w", op, err)
+		var pgError *pgconn.PgError
+		if errors.As(err, &pgError) && pgError.Code == pgerrcode.UniqueViolation {
+			return 0, fmt.Errorf("%s: %w", op, ErrEmailTaken)
+		}
+		return 0, fmt.Errorf("%s: %w", op, err)
 	}
 
-	return nil
+	return newVersion, nil
 }
 
-func (r *Repo) UpdateProfile(
-	ctx context.Context,
-	profile *entity.UserProfile,
-	uuid uuid.UUID,
-) error {
-	const op = "repository.user.UpdateProfile"
-
+// Tells why a version checked update matched no row: the user is gone or
+// someone else changed the profile first
+func (r *Repo) staleOrMissing(ctx context.Context, userUuid uuid.UUID) error {
 	psql := sq.StatementBuilder.PlaceholderFormat(sq.Dollar)
-	sql, args, err := psql.Update("user_profiles").
-		SetMap(
-			sq.Eq{
-				"name":                   profile.Name,
-				"email":                  profile.Email,
-				"phone":                  profile.Phone,
-				"professional_field":     profile.ProfessionalField,
-				"experience_description": profile.ExperienceDescription,
-			},
-		).
-		Where("user_uuid IN (?)", uuid).
+	sql, args, err := psql.Select("1").
+		From("user_profiles").
+		Where("user_uuid IN (?)", userUuid).
+		Prefix("SELECT EXISTS (").
+		Suffix(")").
 		ToSql()
 	if err != nil {
-		return fmt.Errorf("%s: %w", op, err)
+		return err
 	}
 
-	_, err = r.Db.Exec(ctx, sql, args...)
+	var exists bool
+	err = r.Db.QueryRow(ctx, sql, args...).Scan(&exists)
 	if err != nil {
-		return fmt.Errorf("%s: %w", op, err)
+		return err
+	}
+	if !exists {
+		return ErrUserNotFound
 	}
 
-	return nil
+	return ErrVersionMismatch
 }
 
-func (r *Repo) ByUuid(ctx context.Context, uuid uuid.UUID) (*entity.User, error) {
-	const op = "repository.user.ByUuid"
-
+func selectUsers() sq.SelectBuilder {
 	psql := sq.StatementBuilder.PlaceholderFormat(sq.Dollar)
-	sql, args, err := psql.Select(
+	return psql.Select(
 		"users.uuid AS uuid",
 		"username",
 		"pass_hash",
@@ -212,10 +178,31 @@ func (r *Repo) ByUuid(ctx context.Context, uuid uuid.UUID) (*entity.User, error)
 		"phone",
 		"professional_field",
 		"experience_description",
+		"email_visibility",
+		"phone_visibility",
+		"experience_visibility",
+		"avatar_hash",
+		"user_profiles.version AS version",
+		"users.token_version AS token_version",
+		"users.created_at AS created_at",
+		"users.last_login_at AS last_login_at",
+		"users.suspended_at AS suspended_at",
+		"users.suspended_until AS suspended_until",
+		"users.suspension_reason AS suspension_reason",
+		"users.suspended_by AS suspended_by",
+		"users.appeal_note AS appeal_note",
+		"users.deleted_at AS deleted_at",
 	).
 		From("users").
-		InnerJoin("user_profiles ON users.uuid = user_profiles.user_uuid").
-		Where("uuid IN (?)", uuid).
+		InnerJoin("user_profiles ON users.uuid = user_profiles.user_uuid")
+}
+
+func (r *Repo) ByUuid(ctx context.Context, uuid uuid.UUID) (*entity.User, error) {
+	const op = "repository.user.ByUuid"
+
+	sql, args, err := selectUsers().
+		Where("users.uuid IN (?)", uuid).
+		Where("users.deleted_at IS NULL").
 		ToSql()
 	if err != nil {
 		return nil, fmt.Errorf("%s: %w", op, err)
@@ -236,23 +223,27 @@ func (r *Repo) ByUuid(ctx context.Context, uuid uuid.UUID) (*entity.User, error)
 	return &user, nil
 }
 
-func (r *Repo) ByEmail(ctx context.Context, email string) (*entity.User, error) {
-	const op = "repository.user.ByEmail"
-
-	psql := sq.StatementBuilder.PlaceholderFormat(sq.Dollar)
-	sql, args, err := psql.Select(
-		"uuid",
-		"username",
-		"pass_hash",
-		"roles",
-		"name",
-		"email",
-		"phone",
-		"professional_field",
-		"experience_description",
-	).
-		From("users").InnerJoin("user_profiles ON users.uuid = user_profiles.user_uuid").
-		Where("email in (?)", email).
+// Same as ByUuid but only finds users the viewer may look up: users outside any
+// organization, members of organizations in the scope and anyone the viewer
+// shares a consultation with
+func (r *Repo) ByUuidInScope(
+	ctx context.Context,
+	scope tenant.Scope,
+	viewerUuid uuid.UUID,
+	uuid uuid.UUID,
+) (*entity.User, error) {
+	const op = "repository.user.ByUuidInScope"
+
+	sql, args, err := selectUsers().
+		Where("users.uuid IN (?)", uuid).
+		Where("users.deleted_at IS NULL").
+		Where(sq.Or{
+			scope.Own().Where("(SELECT organization_uuid FROM organization_member WHERE organization_member.user_uuid = users.uuid)"),
+			sq.Eq{"users.uuid": viewerUuid},
+			sq.Expr("EXISTS (SELECT 1 FROM consultation WHERE"+
+				" (consultation.expert_uuid = users.uuid AND consultation.mentee_uuid = ?)"+
+				" OR (consultation.mentee_uuid = users.uuid AND consultation.expert_uuid = ?))", viewerUuid, viewerUuid),
+		}).
 		ToSql()
 	if err != nil {
 		return nil, fmt.Errorf("%s: %w", op, err)
@@ -273,13 +264,13 @@ func (r *Repo) ByEmail(ctx context.Context, email string) (*entity.User, error)
 	return &user, nil
 }
 
-func (r *Repo) StaffMemberByUuid(ctx context.Context, uuid uuid.UUID) (*entity.StaffMember, error) {
-	const op = "repository.user.StaffMemberByUuid"
+// Same as ByUuid but also finds soft deleted users, whose consultations
+// still notify the other party
+func (r *Repo) ByUuidIncludingDeleted(ctx context.Context, uuid uuid.UUID) (*entity.User, error) {
+	const op = "repository.user.ByUuidIncludingDeleted"
 
-	psql := sq.StatementBuilder.PlaceholderFormat(sq.Dollar)
-	sql, args, err := psql.Select("user_uuid", "permissions").
-		From("staff").
-		Where("user_uuid IN (?)", uuid).
+	sql, args, err := selectUsers().
+		Where("users.uuid IN (?)", uuid).
 		ToSql()
 	if err != nil {
 		return nil, fmt.Errorf("%s: %w", op, err)
@@ -289,7 +280,7 @@ func (r *Repo) StaffMemberByUuid(ctx context.Context, uuid uuid.UUID) (*entity.S
 	if err != nil {
 		return nil, fmt.Errorf("%s: %w", op, err)
 	}
-	member, err := pgx.CollectOneRow(rows, pgx.RowToStructByNameLax[entity.StaffMember])
+	user, err := pgx.CollectOneRow(rows, pgx.RowToStructByNameLax[entity.User])
 	if err != nil {
 		if errors.Is(err, pgx.ErrNoRows) {
 			return nil, fmt.Errorf("%s: %w", op, ErrUserNotFound)
@@ -297,26 +288,15 @@ func (r *Repo) StaffMemberByUuid(ctx context.Context, uuid uuid.UUID) (*entity.S
 		return nil, fmt.Errorf("%s: %w", op, err)
 	}
 
-	return &member, nil
+	return &user, nil
 }
 
-func (r *Repo) Users(ctx context.Context) ([]entity.User, error) {
-	const op = "repository.user.Users"
+func (r *Repo) ByEmail(ctx context.Context, email string) (*entity.User, error) {
+	const op = "repository.user.ByEmail"
 
-	psql := sq.StatementBuilder.PlaceholderFormat(sq.Dollar)
-	sql, args, err := psql.Select(
-		"users.uuid AS uuid",
-		"username",
-		"pass_hash",
-		"roles",
-		"name",
-		"email",
-		"phone",
-		"professional_field",
-		"experience_description",
-	).
-		From("users").
-		InnerJoin("user_profiles ON users.uuid = user_profiles.user_uuid").
+	sql, args, err := selectUsers().
+		Where("email in (?)", email).
+		Where("users.deleted_at IS NULL").
 		ToSql()
 	if err != nil {
 		return nil, fmt.Errorf("%s: %w", op, err)
@@ -326,31 +306,42 @@ func (r *Repo) Users(ctx context.Context) ([]entity.User, error) {
 	if err != nil {
 		return nil, fmt.Errorf("%s: %w", op, err)
 	}
-
-	users, err := pgx.CollectRows(rows, pgx.RowToStructByNameLax[entity.User])
+	user, err := pgx.CollectOneRow(rows, pgx.RowToStructByNameLax[entity.User])
 	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, fmt.Errorf("%s: %w", op, ErrUserNotFound)
+		}
 		return nil, fmt.Errorf("%s: %w", op, err)
 	}
 
-	return users, err
+	return &user, nil
 }
 
-func (r *Repo) DeleteUser(ctx context.Context, uuid uuid.UUID) error {
-	const op = "repository.user.DeleteUser"
+func (r *Repo) StaffMemberByUuid(ctx context.Context, uuid uuid.UUID) (*entity.StaffMember, error) {
+	const op = "repository.user.StaffMemberByUuid"
 
 	psql := sq.StatementBuilder.PlaceholderFormat(sq.Dollar)
-	sql, args, err := psql.Delete("users").
-		Where("uuid IN (?)", uuid).
+	sql, args, err := psql.Select("user_uuid", "permissions").
+		From("staff").
+		InnerJoin("users ON users.uuid = staff.user_uuid").
+		Where("user_uuid IN (?)", uuid).
+		Where("users.deleted_at IS NULL").
 		ToSql()
 	if err != nil {
-		return fmt.Errorf("%s: %w", op, err)
+		return nil, fmt.Errorf("%s: %w", op, err)
 	}
 
-	_, err = r.Db.Exec(ctx, sql, args...)
+	rows, err := r.Db.Query(ctx, sql, args...)
 	if err != nil {
-		return fmt.Errorf("%s: %w", op, err)
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+	member, err := pgx.CollectOneRow(rows, pgx.RowToStructByNameLax[entity.StaffMember])
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, fmt.Errorf("%s: %w", op, ErrUserNotFound)
+		}
+		return nil, fmt.Errorf("%s: %w", op, err)
 	}
 
-	return nil
-
+	return &member, nil
 }
@@ -0,0 +1,30 @@
+package scheduler
+
+import (
+	"context"
+	"log/slog"
+	"time"
+)
+
+type Job func(ctx context.Context) error
+
+// Runs the job right away and then every interval until ctx is done. Failed
+// runs are logged and tried again on the next tick
+func Every(ctx context.Context, log *slog.Logger, name string, interval time.Duration, job Job) {
+	const op = "scheduler.Every"
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		if err := job(ctx); err != nil {
+			log.Error("scheduled job failed", op, err, slog.String("job", name))
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
@@ -37,6 +37,10 @@ func (s *Service) Register(
 ) error {
 	const op = "services.auth.Register"
 
+	if err := userservice.ValidatePassword(password); err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
 	passHash, err := bcrypt.
 		GenerateFromPassword([]byte(password), bcrypt.DefaultCost)
 	if err != nil {
@@ -75,11 +79,16 @@ func (s *Service) LoginByEmail(
 	if err := bcrypt.CompareHashAndPassword(user.PassHash, []byte(password)); err != nil {
 		return "", fmt.Errorf("%s: %w", op, ErrInvalidCredentials)
 	}
+	// Only told to those who proved they own the account
+	if user.AccountSuspension.Active(time.Now()) {
+		return "", fmt.Errorf("%s: %w", op, userservice.ErrAccountSuspended)
+	}
 
 	accessToken, err := jwtservice.NewAccessToken(
 		user.Uuid.String(),
 		user.Email,
 		user.Roles,
+		user.TokenVersion,
 		s.secret,
 		s.tokenTTL,
 	)
@@ -87,5 +96,34 @@ func (s *Service) LoginByEmail(
 		return "", fmt.Errorf("%s: %w", op, err)
 	}
 
+	// A missed activity timestamp must not keep the user from signing in
+	_ = s.users.RecordLogin(ctx, user.Uuid)
+
+	return accessToken, nil
+}
+
+// Lets an invited user choose their password and signs them in
+func (s *Service) AcceptInvite(ctx context.Context, token string, password string) (string, error) {
+	const op = "services.auth.AcceptInvite"
+
+	user, err := s.users.AcceptInvite(ctx, token, password)
+	if err != nil {
+		return "", fmt.Errorf("%s: %w", op, err)
+	}
+
+	accessToken, err := jwtservice.NewAccessToken(
+		user.Uuid.String(),
+		user.Email,
+		user.Roles,
+		user.TokenVersion,
+		s.secret,
+		s.tokenTTL,
+	)
+	if err != nil {
+		return "", fmt.Errorf("%s: %w", op, err)
+	}
+
+	_ = s.users.RecordLogin(ctx, user.Uuid)
+
 	return accessToken, nil
 }
@@ -0,0 +1,12 @@
+package bulkservice
+
+import "errors"
+
+var (
+	ErrUnknownAction   = errors.New("action must be suspend, delete, email or export")
+	ErrNoUsers         = errors.New("no users selected")
+	ErrTooManyUsers    = errors.New("too many users selected")
+	ErrReasonRequired  = errors.New("suspension reason is required")
+	ErrEndsInPast      = errors.New("suspension must end in the future")
+	ErrMessageRequired = errors.New("email subject and message are required")
+)
@@ -0,0 +1,343 @@
+package bulkservice
+
+import (
+	"bytes"
+	"context"
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+
+	"github.com/bogdanshibilov/mindflowbackend/internal/entity"
+	"github.com/bogdanshibilov/mindflowbackend/internal/pagination"
+	auditrepo "github.com/bogdanshibilov/mindflowbackend/internal/repository/audit"
+	userrepo "github.com/bogdanshibilov/mindflowbackend/internal/repository/user"
+	"github.com/bogdanshibilov/mindflowbackend/internal/services/mails"
+	suspensionservice "github.com/bogdanshibilov/mindflowbackend/internal/services/suspension"
+)
+
+// Most users one bulk action can select
+const maxUsers = 500
+
+type Service struct {
+	userRepo    *userrepo.Repo
+	auditRepo   *auditrepo.Repo
+	suspensions *suspensionservice.Service
+}
+
+func New(userRepo *userrepo.Repo, auditRepo *auditrepo.Repo, suspensions *suspensionservice.Service) *Service {
+	return &Service{
+		userRepo:    userRepo,
+		auditRepo:   auditRepo,
+		suspensions: suspensions,
+	}
+}
+
+// Applies the action to every selected user and records it in the audit log.
+// Users the action does not fit are skipped, a failure on one user does not
+// stop the others. Dry runs only check which users would be skipped
+func (s *Service) Run(ctx context.Context, adminId string, req *Request) (*Report, error) {
+	const op = "services.bulk.Run"
+
+	adminUuid, err := uuid.Parse(adminId)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+	userUuids, err := validate(req)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	users, err := s.userRepo.DirectoryByUuids(ctx, userUuids)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+	usersByUuid := make(map[uuid.UUID]*userrepo.DirectoryUser, len(users))
+	for i := range users {
+		usersByUuid[users[i].Uuid] = &users[i]
+	}
+	// Checked up front so dry runs report them too
+	hasUpcoming := make(map[uuid.UUID]bool)
+	if req.Action == ActionDelete {
+		upcoming, err := s.userRepo.WithUpcomingConsultations(ctx, userUuids)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", op, err)
+		}
+		for _, userUuid := range upcoming {
+			hasUpcoming[userUuid] = true
+		}
+	}
+
+	report := &Report{
+		Action:  req.Action,
+		DryRun:  req.DryRun,
+		Results: make([]Result, 0, len(userUuids)),
+	}
+	var applied []uuid.UUID
+	var exported []*userrepo.DirectoryUser
+	now := time.Now()
+	for _, userUuid := range userUuids {
+		result := Result{UserUuid: userUuid}
+
+		user, ok := usersByUuid[userUuid]
+		if !ok {
+			result.Outcome, result.Reason = OutcomeSkipped, "user not found"
+		} else if reason := skipReason(adminUuid, req.Action, user, hasUpcoming[userUuid], now); reason != "" {
+			result.Outcome, result.Reason = OutcomeSkipped, reason
+		} else if req.DryRun {
+			result.Outcome = OutcomeReady
+		} else {
+			result.Outcome, result.Reason = s.apply(ctx, adminUuid, req, user)
+		}
+
+		if result.Outcome == OutcomeApplied {
+			applied = append(applied, userUuid)
+			if req.Action == ActionExport {
+				exported = append(exported, user)
+			}
+		}
+		report.Results = append(report.Results, result)
+	}
+
+	if req.DryRun {
+		return report, nil
+	}
+
+	if req.Action == ActionExport {
+		report.CSV, err = exportCsv(exported)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", op, err)
+		}
+	}
+
+	report.Audit = &entity.AdminAction{
+		AdminUuid: &adminUuid,
+		Action:    string(req.Action),
+		UserUuids: applied,
+		Requested: len(userUuids),
+		Details:   details(req),
+	}
+	if report.Audit.UserUuids == nil {
+		report.Audit.UserUuids = []uuid.UUID{}
+	}
+	err = s.auditRepo.Record(ctx, report.Audit)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return report, nil
+}
+
+// Lists the recorded bulk actions, newest first by default
+func (s *Service) Actions(ctx context.Context, page *pagination.Request) (*pagination.Page[entity.AdminAction], error) {
+	const op = "services.bulk.Actions"
+
+	actions, err := s.auditRepo.Actions(ctx, page)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return actions, nil
+}
+
+// Checks the request and returns the selected users without duplicates
+func validate(req *Request) ([]uuid.UUID, error) {
+	switch req.Action {
+	case ActionSuspend:
+		req.Reason = strings.TrimSpace(req.Reason)
+		if req.Reason == "" {
+			return nil, ErrReasonRequired
+		}
+		if req.Until != nil && !req.Until.After(time.Now()) {
+			return nil, ErrEndsInPast
+		}
+	case ActionEmail:
+		req.Subject = strings.TrimSpace(req.Subject)
+		req.Message = strings.TrimSpace(req.Message)
+		if req.Subject == "" || req.Message == "" {
+			return nil, ErrMessageRequired
+		}
+	case ActionDelete, ActionExport:
+	default:
+		return nil, ErrUnknownAction
+	}
+
+	if len(req.UserIds) == 0 {
+		return nil, ErrNoUsers
+	}
+	if len(req.UserIds) > maxUsers {
+		return nil, ErrTooManyUsers
+	}
+
+	userUuids := make([]uuid.UUID, 0, len(req.UserIds))
+	seen := make(map[uuid.UUID]bool, len(req.UserIds))
+	for _, id := range req.UserIds {
+		userUuid, err := uuid.Parse(id)
+		if err != nil {
+			return nil, err
+		}
+		if seen[userUuid] {
+			continue
+		}
+		seen[userUuid] = true
+		userUuids = append(userUuids, userUuid)
+	}
+
+	return userUuids, nil
+}
+
+// Returns why the action does not fit the user, empty if it does
+func skipReason(
+	adminUuid uuid.UUID,
+	action Action,
+	user *userrepo.DirectoryUser,
+	hasUpcoming bool,
+	now time.Time,
+) string {
+	switch action {
+	case ActionSuspend:
+		if user.Uuid == adminUuid {
+			return "admins cannot suspend themselves"
+		}
+		// Staff accounts hold permissions and are suspended one at a time
+		if user.Staff {
+			return "user is a staff member"
+		}
+		if user.AccountSuspension.Active(now) {
+			return "user is already suspended"
+		}
+	case ActionDelete:
+		if user.Uuid == adminUuid {
+			return "admins cannot delete themselves"
+		}
+		// Staff accounts hold permissions and are deleted one at a time
+		if user.Staff {
+			return "user is a staff member"
+		}
+		// Their consultations need cancelling with refunds, which single deletes do
+		if hasUpcoming {
+			return "user has upcoming consultations"
+		}
+	}
+
+	return ""
+}
+
+func (s *Service) apply(
+	ctx context.Context,
+	adminUuid uuid.UUID,
+	req *Request,
+	user *userrepo.DirectoryUser,
+) (Outcome, string) {
+	switch req.Action {
+	case ActionSuspend:
+		_, err := s.suspensions.Suspend(ctx, adminUuid.String(), user.Uuid.String(), &suspensionservice.Request{
+			Reason:     req.Reason,
+			Until:      req.Until,
+			AppealNote: req.AppealNote,
+		})
+		if err != nil {
+			return OutcomeFailed, "failed to suspend user or cancel their consultations"
+		}
+	case ActionDelete:
+		err := s.userRepo.SoftDelete(ctx, user.Uuid, adminUuid)
+		if err != nil {
+			// Booked after the check in Run
+			if errors.Is(err, userrepo.ErrUpcomingConsultations) {
+				return OutcomeSkipped, "user has upcoming consultations"
+			}
+			return OutcomeFailed, "failed to delete user"
+		}
+	case ActionEmail:
+		mails.SendAdminMessage(user.Email, req.Subject, req.Message)
+	}
+
+	return OutcomeApplied, ""
+}
+
+// Parameters of the action worth keeping in the audit log
+func details(req *Request) map[string]string {
+	switch req.Action {
+	case ActionSuspend:
+		details := map[string]string{"reason": req.Reason}
+		if req.Until != nil {
+			details["until"] = req.Until.UTC().Format(time.RFC3339)
+		}
+		if req.AppealNote != "" {
+			details["appealNote"] = req.AppealNote
+		}
+		return details
+	case ActionEmail:
+		return map[string]string{"subject": req.Subject, "message": req.Message}
+	}
+
+	return map[string]string{}
+}
+
+func exportCsv(users []*userrepo.DirectoryUser) ([]byte, error) {
+	var buf bytes.Buffer
+	writer := csv.NewWriter(&buf)
+
+	err := writer.Write([]string{
+		"id",
+		"name",
+		"email",
+		"phone",
+		"professional_field",
+		"staff",
+		"expert_status",
+		"expert_suspended",
+		"registered_at",
+		"last_login_at",
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	for _, user := range users {
+		expertStatus := ""
+		if user.ExpertStatus != nil {
+			expertStatus = strconv.Itoa(int(*user.ExpertStatus))
+		}
+		lastLogin := ""
+		if user.LastLoginAt != nil {
+			lastLogin = user.LastLoginAt.UTC().Format(time.RFC3339)
+		}
+
+		err := writer.Write([]string{
+			user.Uuid.String(),
+			csvCell(user.Name),
+			csvCell(user.Email),
+			csvCell(user.Phone),
+			csvCell(user.ProfessionalField),
+			strconv.FormatBool(user.Staff),
+			expertStatus,
+			strconv.FormatBool(user.ExpertSuspended),
+			user.CreatedAt.UTC().Format(time.RFC3339),
+			lastLogin,
+		})
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	writer.Flush()
+	if err := writer.Error(); err != nil {
+		return nil, err
+	}
+
+	return buf.Bytes(), nil
+}
+
+// Spreadsheets run cells starting with these as formulas, a leading quote
+// keeps user supplied text inert
+func csvCell(value string) string {
+	if value != "" && strings.ContainsRune("=+-@\t\r", rune(value[0])) {
+		return "'" + value
+	}
+	return value
+}
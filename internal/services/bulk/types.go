@@ -0,0 +1,64 @@
+package bulkservice
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+
+	"github.com/bogdanshibilov/mindflowbackend/internal/entity"
+)
+
+type Action string
+
+const (
+	// Suspends the accounts of the users and cancels their upcoming consultations
+	ActionSuspend Action = "suspend"
+	// Soft deletes the users, admins can restore them as usual
+	ActionDelete Action = "delete"
+	// Emails the users a message written by the admin
+	ActionEmail Action = "email"
+	// Exports the users' directory entries as CSV
+	ActionExport Action = "export"
+)
+
+type Request struct {
+	Action  Action
+	UserIds []string
+	// Required by ActionSuspend
+	Reason string
+	// Optional for ActionSuspend, nil suspends for good
+	Until      *time.Time
+	AppealNote string
+	// Required by ActionEmail
+	Subject string
+	Message string
+	// Reports what would happen without changing anything or writing an audit record
+	DryRun bool
+}
+
+type Outcome string
+
+const (
+	// The action would be applied, only reported by dry runs
+	OutcomeReady   Outcome = "ready"
+	OutcomeApplied Outcome = "applied"
+	OutcomeSkipped Outcome = "skipped"
+	OutcomeFailed  Outcome = "failed"
+)
+
+type Result struct {
+	UserUuid uuid.UUID
+	Outcome  Outcome
+	// Why the user was skipped or the action failed
+	Reason string
+}
+
+type Report struct {
+	Action  Action
+	DryRun  bool
+	Results []Result
+	// Nil for dry runs
+	Audit *entity.AdminAction
+	// Directory entries of ActionExport, nil for other actions and dry runs
+	CSV []byte
+}
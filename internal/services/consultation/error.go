@@ -0,0 +1,12 @@
+package consultationservice
+
+import "errors"
+
+var (
+	ErrNotParticipant = errors.New("user is not a participant of the consultation")
+	ErrNotExpert      = errors.New("user is not the expert of the consultation")
+	ErrNotMentee      = errors.New("user is not the mentee of the consultation")
+	ErrNotHeld        = errors.New("consultation has not taken place yet")
+	ErrInvalidRating  = errors.New("rating must be between 1 and 5")
+	ErrInvalidId      = errors.New("id is not a valid uuid")
+)
@@ -0,0 +1,298 @@
+package consultationservice
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+
+	"github.com/bogdanshibilov/mindflowbackend/internal/entity"
+	consultationrepo "github.com/bogdanshibilov/mindflowbackend/internal/repository/consultation"
+	"github.com/bogdanshibilov/mindflowbackend/internal/services/mails"
+	"github.com/bogdanshibilov/mindflowbackend/internal/tenant"
+)
+
+func (s *Service) AddNote(ctx context.Context, scope tenant.Scope, consultId, authorId, content string) (*entity.ConsultationNote, error) {
+	const op = "services.consultation.AddNote"
+
+	consult, authorUuid, err := s.consultationForExpert(ctx, scope, consultId, authorId)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	note := &entity.ConsultationNote{
+		ConsultationUuid: consult.Uuid,
+		AuthorUuid:       authorUuid,
+		Content:          content,
+	}
+
+	err = s.consultRepo.CreateNote(ctx, note)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return note, nil
+}
+
+func (s *Service) Notes(ctx context.Context, scope tenant.Scope, consultId, authorId string) ([]entity.ConsultationNote, error) {
+	const op = "services.consultation.Notes"
+
+	consult, authorUuid, err := s.consultationForExpert(ctx, scope, consultId, authorId)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return s.consultRepo.NotesByConsultationUuid(ctx, consult.Uuid, authorUuid)
+}
+
+func (s *Service) UpdateNote(ctx context.Context, noteId, authorId, content string) error {
+	const op = "services.consultation.UpdateNote"
+
+	note, err := s.ownNote(ctx, noteId, authorId)
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	err = s.consultRepo.UpdateNote(ctx, note.Uuid, content)
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	return nil
+}
+
+func (s *Service) DeleteNote(ctx context.Context, noteId, authorId string) error {
+	const op = "services.consultation.DeleteNote"
+
+	note, err := s.ownNote(ctx, noteId, authorId)
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	err = s.consultRepo.DeleteNote(ctx, note.Uuid)
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	return nil
+}
+
+func (s *Service) SaveSummary(
+	ctx context.Context,
+	scope tenant.Scope,
+	meetingId string,
+	authorId string,
+	content string,
+	actionItems []string,
+) (*entity.MeetingSummary, error) {
+	const op = "services.consultation.SaveSummary"
+
+	meeting, consult, err := s.meetingWithConsultation(ctx, scope, meetingId)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+	if consult.ExpertUuid.String() != authorId {
+		return nil, fmt.Errorf("%s: %w", op, ErrNotExpert)
+	}
+
+	summary := &entity.MeetingSummary{
+		MeetingUuid: meeting.Uuid,
+		AuthorUuid:  consult.ExpertUuid,
+		Content:     content,
+		ActionItems: actionItems,
+	}
+
+	err = s.consultRepo.SaveSummary(ctx, summary, consult.ExpertUuid)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return s.consultRepo.SummaryByMeetingUuid(ctx, meeting.Uuid)
+}
+
+func (s *Service) PublishSummary(ctx context.Context, scope tenant.Scope, meetingId, authorId string) error {
+	const op = "services.consultation.PublishSummary"
+
+	meeting, consult, err := s.meetingWithConsultation(ctx, scope, meetingId)
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+	if consult.ExpertUuid.String() != authorId {
+		return fmt.Errorf("%s: %w", op, ErrNotExpert)
+	}
+
+	err = s.consultRepo.PublishSummary(ctx, meeting.Uuid)
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	expert, err := s.userRepo.ByUuidIncludingDeleted(ctx, consult.ExpertUuid)
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+	mentee, err := s.userRepo.ByUuidIncludingDeleted(ctx, consult.MenteeUuid)
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	mails.SendSessionSummaryNotification(mentee.Email, expert.Name)
+
+	return nil
+}
+
+// Returns the summary of a meeting. The expert always sees the latest version,
+// the mentee only sees it once it has been published
+func (s *Service) Summary(ctx context.Context, scope tenant.Scope, meetingId, userId string) (*entity.MeetingSummary, error) {
+	const op = "services.consultation.Summary"
+
+	meeting, consult, err := s.meetingWithConsultation(ctx, scope, meetingId)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	summary, err := s.consultRepo.SummaryByMeetingUuid(ctx, meeting.Uuid)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	switch userId {
+	case consult.ExpertUuid.String():
+		return summary, nil
+	case consult.MenteeUuid.String():
+		if summary.PublishedAt == nil {
+			return nil, fmt.Errorf("%s: %w", op, consultationrepo.ErrSummaryNotFound)
+		}
+		return summary, nil
+	default:
+		return nil, fmt.Errorf("%s: %w", op, ErrNotParticipant)
+	}
+}
+
+func (s *Service) SummaryHistory(ctx context.Context, scope tenant.Scope, meetingId, authorId string) ([]entity.MeetingSummaryRevision, error) {
+	const op = "services.consultation.SummaryHistory"
+
+	meeting, consult, err := s.meetingWithConsultation(ctx, scope, meetingId)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+	if consult.ExpertUuid.String() != authorId {
+		return nil, fmt.Errorf("%s: %w", op, ErrNotExpert)
+	}
+
+	return s.consultRepo.SummaryRevisions(ctx, meeting.Uuid)
+}
+
+// Renders the summary visible to the user as plain text
+func (s *Service) ExportSummary(ctx context.Context, scope tenant.Scope, meetingId, userId string) (string, error) {
+	const op = "services.consultation.ExportSummary"
+
+	summary, err := s.Summary(ctx, scope, meetingId, userId)
+	if err != nil {
+		return "", fmt.Errorf("%s: %w", op, err)
+	}
+
+	meeting, consult, err := s.meetingWithConsultation(ctx, scope, meetingId)
+	if err != nil {
+		return "", fmt.Errorf("%s: %w", op, err)
+	}
+	expert, err := s.userRepo.ByUuidIncludingDeleted(ctx, consult.ExpertUuid)
+	if err != nil {
+		return "", fmt.Errorf("%s: %w", op, err)
+	}
+	mentee, err := s.userRepo.ByUuidIncludingDeleted(ctx, consult.MenteeUuid)
+	if err != nil {
+		return "", fmt.Errorf("%s: %w", op, err)
+	}
+
+	var b strings.Builder
+	b.WriteString("SESSION SUMMARY\n")
+	b.WriteString("===============\n\n")
+	fmt.Fprintf(&b, "Meeting: %s\n", meeting.StartTime.Format(time.RFC1123))
+	fmt.Fprintf(&b, "Expert: %s\n", expert.Name)
+	fmt.Fprintf(&b, "Mentee: %s\n", mentee.Name)
+	fmt.Fprintf(&b, "Version: %d (updated %s)\n\n", summary.Version, summary.UpdatedAt.Format(time.RFC1123))
+	b.WriteString(summary.Content)
+	b.WriteString("\n")
+	if len(summary.ActionItems) > 0 {
+		b.WriteString("\nACTION ITEMS\n")
+		b.WriteString("------------\n")
+		for _, item := range summary.ActionItems {
+			fmt.Fprintf(&b, "[ ] %s\n", item)
+		}
+	}
+
+	return b.String(), nil
+}
+
+func (s *Service) consultationForExpert(
+	ctx context.Context,
+	scope tenant.Scope,
+	consultId string,
+	expertId string,
+) (*entity.Consultation, uuid.UUID, error) {
+	consultUuid, err := uuid.Parse(consultId)
+	if err != nil {
+		return nil, uuid.Nil, fmt.Errorf("%w: %w", ErrInvalidId, err)
+	}
+	expertUuid, err := uuid.Parse(expertId)
+	if err != nil {
+		return nil, uuid.Nil, fmt.Errorf("%w: %w", ErrInvalidId, err)
+	}
+
+	consult, err := s.consultRepo.ByUuid(ctx, scope.Own(), consultUuid)
+	if err != nil {
+		return nil, uuid.Nil, err
+	}
+	if consult.ExpertUuid != expertUuid {
+		return nil, uuid.Nil, ErrNotExpert
+	}
+
+	return consult, expertUuid, nil
+}
+
+func (s *Service) ownNote(ctx context.Context, noteId, authorId string) (*entity.ConsultationNote, error) {
+	noteUuid, err := uuid.Parse(noteId)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrInvalidId, err)
+	}
+
+	note, err := s.consultRepo.NoteByUuid(ctx, noteUuid)
+	if err != nil {
+		return nil, err
+	}
+	if note.AuthorUuid.String() != authorId {
+		// Other people's notes are private, so they are reported as missing
+		return nil, consultationrepo.ErrNoteNotFound
+	}
+
+	return note, nil
+}
+
+func (s *Service) meetingWithConsultation(
+	ctx context.Context,
+	scope tenant.Scope,
+	meetingId string,
+) (*entity.ConsultationMeeting, *entity.Consultation, error) {
+	meetingUuid, err := uuid.Parse(meetingId)
+	if err != nil {
+		return nil, nil, fmt.Errorf("%w: %w", ErrInvalidId, err)
+	}
+
+	meeting, err := s.consultRepo.MeetingByUuid(ctx, meetingUuid)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	consult, err := s.consultRepo.ByUuid(ctx, scope.Own(), meeting.ConsultationUuid)
+	if err != nil {
+		if errors.Is(err, consultationrepo.ErrConsultationNotFound) {
+			return nil, nil, consultationrepo.ErrMeetingNotFound
+		}
+		return nil, nil, err
+	}
+
+	return meeting, consult, nil
+}
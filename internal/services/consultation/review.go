@@ -0,0 +1,92 @@
+package consultationservice
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+
+	"github.com/bogdanshibilov/mindflowbackend/internal/entity"
+	"github.com/bogdanshibilov/mindflowbackend/internal/tenant"
+)
+
+// Rates a paid consultation. Only the mentee reviews, once, after a meeting has started
+func (s *Service) Review(
+	ctx context.Context,
+	scope tenant.Scope,
+	consultId string,
+	menteeId string,
+	rating int,
+	comment string,
+) (*entity.ConsultationReview, error) {
+	const op = "services.consultation.Review"
+
+	if rating < 1 || rating > 5 {
+		return nil, fmt.Errorf("%s: %w", op, ErrInvalidRating)
+	}
+
+	consultUuid, err := uuid.Parse(consultId)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+	menteeUuid, err := uuid.Parse(menteeId)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	consult, err := s.consultRepo.ByUuid(ctx, scope.Own(), consultUuid)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+	if consult.MenteeUuid != menteeUuid {
+		return nil, fmt.Errorf("%s: %w", op, ErrNotMentee)
+	}
+	// Approved consultations are still awaiting payment
+	if consult.Status != entity.Scheduled {
+		return nil, fmt.Errorf("%s: %w", op, ErrNotHeld)
+	}
+
+	meetings, err := s.consultRepo.MeetingsByConsultationUuid(ctx, scope.Own(), consultUuid)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+	held := false
+	for _, meeting := range meetings {
+		if meeting.StartTime.Before(time.Now()) {
+			held = true
+			break
+		}
+	}
+	if !held {
+		return nil, fmt.Errorf("%s: %w", op, ErrNotHeld)
+	}
+
+	review := &entity.ConsultationReview{
+		ConsultationUuid: consultUuid,
+		Rating:           rating,
+	}
+	comment = strings.TrimSpace(comment)
+	if comment != "" {
+		review.Comment = &comment
+	}
+
+	err = s.consultRepo.CreateReview(ctx, review)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return review, nil
+}
+
+func (s *Service) ReviewsByExpertId(ctx context.Context, scope tenant.Scope, expertId string) ([]entity.ConsultationReview, error) {
+	const op = "services.consultation.ReviewsByExpertId"
+
+	expertUuid, err := uuid.Parse(expertId)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return s.consultRepo.ReviewsByExpertUuid(ctx, scope, expertUuid)
+}
@@ -2,34 +2,46 @@ package consultationservice
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"slices"
 	"time"
 
 	"github.com/google/uuid"
 
 	"github.com/bogdanshibilov/mindflowbackend/internal/entity"
+	"github.com/bogdanshibilov/mindflowbackend/internal/pagination"
 	consultationrepo "github.com/bogdanshibilov/mindflowbackend/internal/repository/consultation"
 	userrepo "github.com/bogdanshibilov/mindflowbackend/internal/repository/user"
 	"github.com/bogdanshibilov/mindflowbackend/internal/services/mails"
+	paymentservice "github.com/bogdanshibilov/mindflowbackend/internal/services/payment"
+	"github.com/bogdanshibilov/mindflowbackend/internal/tenant"
 )
 
 type Service struct {
 	consultRepo *consultationrepo.Repo
 	userRepo    *userrepo.Repo
+	payments    *paymentservice.Service
 }
 
-func New(consultRepo consultationrepo.Repo, userRepo userrepo.Repo) *Service {
+func New(consultRepo consultationrepo.Repo, userRepo userrepo.Repo, payments *paymentservice.Service) *Service {
 	return &Service{
 		consultRepo: &consultRepo,
 		userRepo:    &userRepo,
+		payments:    payments,
 	}
 }
 
+// Applies for a consultation with the expert, who must be visible in the
+// mentee's scope. A non empty packagePurchaseId books it with a credit of
+// that package instead of the expert's price
 func (s *Service) ApplyForConsultation(
 	ctx context.Context,
+	scope tenant.Scope,
 	menteeId string,
 	expertId string,
 	menteeQuestions string,
+	packagePurchaseId string,
 ) error {
 	const op = "services.consultation.ApplyForConsultation"
 
@@ -51,8 +63,15 @@ func (s *Service) ApplyForConsultation(
 		MenteeUuid:              menteeUuid,
 		ConsultationApplication: application,
 	}
+	if packagePurchaseId != "" {
+		purchaseUuid, err := uuid.Parse(packagePurchaseId)
+		if err != nil {
+			return fmt.Errorf("%s: %w", op, err)
+		}
+		consultation.PackagePurchaseUuid = &purchaseUuid
+	}
 
-	err = s.consultRepo.CreateConsultation(ctx, &consultation)
+	err = s.consultRepo.CreateConsultation(ctx, scope, &consultation)
 	if err != nil {
 		return fmt.Errorf("%s: %w", op, err)
 	}
@@ -60,7 +79,8 @@ func (s *Service) ApplyForConsultation(
 	return nil
 }
 
-func (s *Service) ById(ctx context.Context, id string) (*entity.Consultation, error) {
+// Returns the consultation to its participants and to admins
+func (s *Service) ById(ctx context.Context, scope tenant.Scope, id string, viewerId string) (*entity.Consultation, error) {
 	const op = "services.consultation.ById"
 
 	uuid, err := uuid.Parse(id)
@@ -68,14 +88,56 @@ func (s *Service) ById(ctx context.Context, id string) (*entity.Consultation, er
 		return nil, fmt.Errorf("%s: %w", op, err)
 	}
 
-	return s.consultRepo.ByUuid(ctx, uuid)
+	consult, err := s.consultRepo.ByUuid(ctx, scope, uuid)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+	if consult.MenteeUuid.String() == viewerId || consult.ExpertUuid.String() == viewerId {
+		return consult, nil
+	}
+
+	isAdmin, err := s.isAdmin(ctx, viewerId)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+	if !isAdmin {
+		return nil, fmt.Errorf("%s: %w", op, ErrNotParticipant)
+	}
+
+	return consult, nil
 }
 
-func (s *Service) Consultations(ctx context.Context) ([]entity.Consultation, error) {
-	return s.consultRepo.Consultations(ctx)
+func (s *Service) isAdmin(ctx context.Context, userId string) (bool, error) {
+	userUuid, err := uuid.Parse(userId)
+	if err != nil {
+		return false, err
+	}
+
+	member, err := s.userRepo.StaffMemberByUuid(ctx, userUuid)
+	if err != nil {
+		if errors.Is(err, userrepo.ErrUserNotFound) {
+			return false, nil
+		}
+		return false, err
+	}
+
+	return slices.Contains(member.Permissions, entity.Admin), nil
 }
 
-func (s *Service) ByPersonId(ctx context.Context, id string, opts ...consultationrepo.ByPersonUuidOption) ([]entity.Consultation, error) {
+func (s *Service) Consultations(
+	ctx context.Context,
+	scope tenant.Scope,
+	page *pagination.Request,
+) (*pagination.Page[entity.Consultation], error) {
+	return s.consultRepo.Consultations(ctx, scope, page)
+}
+
+func (s *Service) ByPersonId(
+	ctx context.Context,
+	scope tenant.Scope,
+	id string,
+	opts ...consultationrepo.ByPersonUuidOption,
+) ([]entity.Consultation, error) {
 	const op = "services.consultation.ById"
 
 	uuid, err := uuid.Parse(id)
@@ -83,22 +145,28 @@ func (s *Service) ByPersonId(ctx context.Context, id string, opts ...consultatio
 		return nil, fmt.Errorf("%s: %w", op, err)
 	}
 
-	return s.consultRepo.ByPersonUuid(ctx, uuid, opts...)
+	return s.consultRepo.ByPersonUuid(ctx, scope.Own(), uuid, opts...)
 }
 
-func (s *Service) ChangeApplicationStatus(ctx context.Context, id string, status entity.Status) error {
-	const op = "services.consultation.ChangeApplicationStatus"
+// Rejects a consultation that is still pending
+func (s *Service) RejectApplication(ctx context.Context, scope tenant.Scope, id string) error {
+	const op = "services.consultation.RejectApplication"
 
 	uuid, err := uuid.Parse(id)
 	if err != nil {
 		return fmt.Errorf("%s: %w", op, err)
 	}
 
-	return s.consultRepo.UpdateApplicationStatus(ctx, uuid, status)
+	return s.consultRepo.RejectApplication(ctx, scope, uuid)
 }
 
+// Adds a meeting to the consultation. The first meeting approves a pending
+// consultation and asks the mentee to pay, meetings of a consultation awaiting
+// payment remind them. Consultations already paid for keep their status and
+// both participants get the new meeting's link
 func (s *Service) CreateMeeting(
 	ctx context.Context,
+	scope tenant.Scope,
 	consultId string,
 	startTime time.Time,
 	link string,
@@ -116,26 +184,39 @@ func (s *Service) CreateMeeting(
 		Link:             link,
 	}
 
-	err = s.consultRepo.CreateMeeting(ctx, meeting)
+	previous, err := s.consultRepo.CreateMeeting(ctx, scope, meeting)
 	if err != nil {
 		return fmt.Errorf("%s: %w", op, err)
 	}
-
-	err = s.ChangeApplicationStatus(ctx, consultId, entity.Approved)
+	consult, err := s.consultRepo.ByUuid(ctx, scope, uuid)
 	if err != nil {
 		return fmt.Errorf("%s: %w", op, err)
 	}
 
-	consult, err := s.consultRepo.ByUuid(ctx, uuid)
+	expert, err := s.userRepo.ByUuidIncludingDeleted(ctx, consult.ExpertUuid)
 	if err != nil {
 		return fmt.Errorf("%s: %w", op, err)
 	}
+	mentee, err := s.userRepo.ByUuidIncludingDeleted(ctx, consult.MenteeUuid)
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	if previous == entity.Scheduled {
+		mails.SendConsultationNotification([]string{expert.Email, mentee.Email}, meeting.Link)
+		return nil
+	}
 
-	expert, err := s.userRepo.ByUuid(ctx, consult.ExpertUuid)
+	intent, err := s.payments.CreateIntent(ctx, consult)
 	if err != nil {
 		return fmt.Errorf("%s: %w", op, err)
 	}
-	mentee, err := s.userRepo.ByUuid(ctx, consult.MenteeUuid)
+	if intent != nil {
+		mails.SendPaymentRequestNotification(mentee.Email, intent.Amount, intent.Currency)
+		return nil
+	}
+
+	err = s.consultRepo.UpdateApplicationStatus(ctx, scope, consult.Uuid, entity.Scheduled)
 	if err != nil {
 		return fmt.Errorf("%s: %w", op, err)
 	}
@@ -145,7 +226,128 @@ func (s *Service) CreateMeeting(
 	return nil
 }
 
-func (s *Service) MeetingsByConsultationId(ctx context.Context, id string) ([]entity.ConsultationMeeting, error) {
+// Cancels a consultation on behalf of one of its participants and refunds
+// the mentee according to the refund policy
+func (s *Service) Cancel(ctx context.Context, scope tenant.Scope, consultId, userId string) (*entity.Refund, error) {
+	const op = "services.consultation.Cancel"
+
+	consultUuid, err := uuid.Parse(consultId)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+	userUuid, err := uuid.Parse(userId)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	consult, err := s.consultRepo.ByUuid(ctx, scope.Own(), consultUuid)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+	if consult.ExpertUuid != userUuid && consult.MenteeUuid != userUuid {
+		return nil, fmt.Errorf("%s: %w", op, ErrNotParticipant)
+	}
+
+	refund, err := s.cancel(ctx, scope.Own(), consult, userUuid)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return refund, nil
+}
+
+// Cancels every upcoming consultation of the user on their behalf, so mentees
+// of a suspended expert get a full refund. Keeps going when one fails and
+// returns the consultations that were cancelled. Staff run it, so it spans
+// every organization
+func (s *Service) CancelUpcoming(ctx context.Context, userUuid uuid.UUID) ([]uuid.UUID, error) {
+	const op = "services.consultation.CancelUpcoming"
+
+	consults, err := s.consultRepo.Upcoming(ctx, tenant.Unscoped, userUuid)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	cancelled := make([]uuid.UUID, 0, len(consults))
+	var errs []error
+	for i := range consults {
+		_, err := s.cancel(ctx, tenant.Unscoped, &consults[i], userUuid)
+		if err != nil {
+			errs = append(errs, err)
+			continue
+		}
+		cancelled = append(cancelled, consults[i].Uuid)
+	}
+	if len(errs) > 0 {
+		return cancelled, fmt.Errorf("%s: %w", op, errors.Join(errs...))
+	}
+
+	return cancelled, nil
+}
+
+// Cancels the consultation on behalf of one of its participants, refunds the
+// mentee and lets both know. The refund is recorded with the cancellation, so
+// cancelling again sends it if the provider could not be reached
+func (s *Service) cancel(
+	ctx context.Context,
+	scope tenant.Scope,
+	consult *entity.Consultation,
+	userUuid uuid.UUID,
+) (*entity.Refund, error) {
+	if consult.Status == entity.Cancelled {
+		refunds, err := s.payments.ResendRefunds(ctx, consult.Uuid)
+		if err != nil {
+			return nil, err
+		}
+		if len(refunds) > 0 {
+			return &refunds[0], nil
+		}
+	}
+
+	refund, err := s.payments.CancellationRefund(ctx, scope, consult, userUuid)
+	if err != nil {
+		return nil, err
+	}
+
+	err = s.consultRepo.CancelConsultation(ctx, consult.Uuid, userUuid, refund)
+	if err != nil {
+		return nil, err
+	}
+	consult.Status = entity.Cancelled
+	consult.CancelledBy = &userUuid
+
+	expert, err := s.userRepo.ByUuidIncludingDeleted(ctx, consult.ExpertUuid)
+	if err != nil {
+		return nil, err
+	}
+	mentee, err := s.userRepo.ByUuidIncludingDeleted(ctx, consult.MenteeUuid)
+	if err != nil {
+		return nil, err
+	}
+
+	if refund == nil {
+		mails.SendCancellationNotification([]string{expert.Email, mentee.Email}, 0, "")
+		return nil, nil
+	}
+	mails.SendCancellationNotification([]string{expert.Email, mentee.Email}, refund.Amount, refund.Currency)
+
+	err = s.payments.SendRefund(ctx, refund)
+	if err != nil {
+		return nil, err
+	}
+
+	return refund, nil
+}
+
+func (s *Service) PaymentDetails(ctx context.Context, consultUuid uuid.UUID) (*paymentservice.PaymentDetails, error) {
+	return s.payments.PaymentDetails(ctx, consultUuid)
+}
+
+func (s *Service) MeetingsByConsultationId(
+	ctx context.Context,
+	scope tenant.Scope,
+	id string,
+) ([]entity.ConsultationMeeting, error) {
 	const op = "services.consultation.MeetingsByConsultationId"
 
 	uuid, err := uuid.Parse(id)
@@ -153,10 +355,10 @@ func (s *Service) MeetingsByConsultationId(ctx context.Context, id string) ([]en
 		return nil, fmt.Errorf("%s: %w", op, err)
 	}
 
-	return s.consultRepo.MeetingsByConsultationUuid(ctx, uuid)
+	return s.consultRepo.MeetingsByConsultationUuid(ctx, scope.Own(), uuid)
 }
 
-func (s *Service) DoesExist(ctx context.Context, menteeId, expertId string) (bool, error) {
+func (s *Service) DoesExist(ctx context.Context, scope tenant.Scope, menteeId, expertId string) (bool, error) {
 	const op = "services.consultation.DoesExist"
 
 	menteeUuid, err := uuid.Parse(menteeId)
@@ -168,5 +370,5 @@ func (s *Service) DoesExist(ctx context.Context, menteeId, expertId string) (boo
 		return false, fmt.Errorf("%s: %w", op, err)
 	}
 
-	return s.consultRepo.DoesExist(ctx, menteeUuid, expertUuid)
+	return s.consultRepo.DoesExist(ctx, scope.Own(), menteeUuid, expertUuid)
 }
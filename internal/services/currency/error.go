@@ -0,0 +1,10 @@
+package currencyservice
+
+import "errors"
+
+var (
+	ErrInvalidCurrency     = errors.New("currency must be an ISO 4217 code")
+	ErrUnsupportedCurrency = errors.New("currency has no exchange rate")
+	ErrInvalidRate         = errors.New("exchange rate must be positive")
+	ErrBaseCurrency        = errors.New("base currency rate is fixed")
+)
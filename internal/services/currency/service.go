@@ -0,0 +1,176 @@
+package currencyservice
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math"
+
+	"github.com/google/uuid"
+
+	"github.com/bogdanshibilov/mindflowbackend/internal/entity"
+	"github.com/bogdanshibilov/mindflowbackend/internal/money"
+	currencyrepo "github.com/bogdanshibilov/mindflowbackend/internal/repository/currency"
+)
+
+type Service struct {
+	currencyRepo *currencyrepo.Repo
+	base         string
+}
+
+func New(currencyRepo *currencyrepo.Repo, base string) *Service {
+	return &Service{
+		currencyRepo: currencyRepo,
+		base:         base,
+	}
+}
+
+func (s *Service) Base() string {
+	return s.base
+}
+
+// Makes sure the base currency can be converted to itself
+func (s *Service) EnsureBaseRate(ctx context.Context) error {
+	const op = "services.currency.EnsureBaseRate"
+
+	err := s.currencyRepo.AddRateIfMissing(ctx, &entity.ExchangeRate{Currency: s.base, Rate: 1})
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	return nil
+}
+
+func (s *Service) Rates(ctx context.Context) ([]Rate, error) {
+	const op = "services.currency.Rates"
+
+	rates, err := s.currencyRepo.Rates(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	result := make([]Rate, 0, len(rates))
+	for _, rate := range rates {
+		result = append(result, Rate{
+			Currency:  rate.Currency,
+			Rate:      rate.Rate * s.minorUnitsRatio(rate.Currency),
+			UpdatedAt: rate.UpdatedAt,
+		})
+	}
+
+	return result, nil
+}
+
+// Sets how many base currency major units one major unit of currency is worth
+func (s *Service) SetRate(ctx context.Context, adminId, currency string, rate float64) (*Rate, error) {
+	const op = "services.currency.SetRate"
+
+	adminUuid, err := uuid.Parse(adminId)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+	if !money.ValidCurrency(currency) {
+		return nil, fmt.Errorf("%s: %w", op, ErrInvalidCurrency)
+	}
+	if currency == s.base {
+		return nil, fmt.Errorf("%s: %w", op, ErrBaseCurrency)
+	}
+	if rate <= 0 {
+		return nil, fmt.Errorf("%s: %w", op, ErrInvalidRate)
+	}
+
+	exchangeRate := &entity.ExchangeRate{
+		Currency:  currency,
+		Rate:      rate / s.minorUnitsRatio(currency),
+		UpdatedBy: &adminUuid,
+	}
+	err = s.currencyRepo.SetRate(ctx, exchangeRate)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return &Rate{
+		Currency:  currency,
+		Rate:      rate,
+		UpdatedAt: exchangeRate.UpdatedAt,
+	}, nil
+}
+
+func (s *Service) DeleteRate(ctx context.Context, currency string) error {
+	const op = "services.currency.DeleteRate"
+
+	if currency == s.base {
+		return fmt.Errorf("%s: %w", op, ErrBaseCurrency)
+	}
+
+	err := s.currencyRepo.DeleteRate(ctx, currency)
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	return nil
+}
+
+// Checks that amounts in the currency can be converted
+func (s *Service) Supported(ctx context.Context, currency string) error {
+	const op = "services.currency.Supported"
+
+	if !money.ValidCurrency(currency) {
+		return fmt.Errorf("%s: %w", op, ErrInvalidCurrency)
+	}
+
+	_, err := s.currencyRepo.RateByCurrency(ctx, currency)
+	if err != nil {
+		if errors.Is(err, currencyrepo.ErrRateNotFound) {
+			return fmt.Errorf("%s: %w", op, ErrUnsupportedCurrency)
+		}
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	return nil
+}
+
+// Converts an amount in minor units of currency to base currency minor units
+func (s *Service) ToBase(ctx context.Context, amount int, currency string) (int, error) {
+	const op = "services.currency.ToBase"
+
+	rate, err := s.rate(ctx, currency)
+	if err != nil {
+		return 0, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return int(math.Round(float64(amount) * rate)), nil
+}
+
+// Converts an amount in base currency minor units to minor units of currency
+func (s *Service) FromBase(ctx context.Context, amount int, currency string) (int, error) {
+	const op = "services.currency.FromBase"
+
+	rate, err := s.rate(ctx, currency)
+	if err != nil {
+		return 0, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return int(math.Round(float64(amount) / rate)), nil
+}
+
+func (s *Service) rate(ctx context.Context, currency string) (float64, error) {
+	if currency == s.base {
+		return 1, nil
+	}
+
+	rate, err := s.currencyRepo.RateByCurrency(ctx, currency)
+	if err != nil {
+		if errors.Is(err, currencyrepo.ErrRateNotFound) {
+			return 0, ErrUnsupportedCurrency
+		}
+		return 0, err
+	}
+
+	return rate.Rate, nil
+}
+
+// Converts a rate between minor units into a rate between major units
+func (s *Service) minorUnitsRatio(currency string) float64 {
+	return math.Pow10(money.Exponent(currency)) / math.Pow10(money.Exponent(s.base))
+}
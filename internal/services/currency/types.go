@@ -0,0 +1,10 @@
+package currencyservice
+
+import "time"
+
+// Exchange rate as shown to admins: base currency major units per one major unit of Currency
+type Rate struct {
+	Currency  string
+	Rate      float64
+	UpdatedAt time.Time
+}
@@ -0,0 +1,70 @@
+package expertservice
+
+import (
+	"context"
+	"fmt"
+	"sort"
+
+	"github.com/google/uuid"
+
+	"github.com/bogdanshibilov/mindflowbackend/internal/entity"
+	"github.com/bogdanshibilov/mindflowbackend/internal/tenant"
+)
+
+const minutesPerDay = 24 * 60
+
+// Returns the weekly availability of an expert visible in the scope
+func (s *Service) Availability(ctx context.Context, scope tenant.Scope, expertId string) ([]entity.ExpertAvailability, error) {
+	const op = "services.expert.Availability"
+
+	expertUuid, err := uuid.Parse(expertId)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	_, err = s.expertRepo.ByUuid(ctx, scope, expertUuid)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return s.expertRepo.Availability(ctx, expertUuid)
+}
+
+// Replaces the expert's weekly availability. Slots of a day must not overlap
+func (s *Service) SetAvailability(
+	ctx context.Context,
+	expertId string,
+	slots []entity.ExpertAvailability,
+) ([]entity.ExpertAvailability, error) {
+	const op = "services.expert.SetAvailability"
+
+	expertUuid, err := uuid.Parse(expertId)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	sorted := make([]entity.ExpertAvailability, len(slots))
+	copy(sorted, slots)
+	sort.Slice(sorted, func(i, j int) bool {
+		if sorted[i].Weekday != sorted[j].Weekday {
+			return sorted[i].Weekday < sorted[j].Weekday
+		}
+		return sorted[i].StartMinute < sorted[j].StartMinute
+	})
+	for i, slot := range sorted {
+		if slot.Weekday < 0 || slot.Weekday > 6 ||
+			slot.StartMinute < 0 || slot.StartMinute >= slot.EndMinute || slot.EndMinute > minutesPerDay {
+			return nil, fmt.Errorf("%s: %w", op, ErrInvalidAvailability)
+		}
+		if i > 0 && sorted[i-1].Weekday == slot.Weekday && sorted[i-1].EndMinute > slot.StartMinute {
+			return nil, fmt.Errorf("%s: %w", op, ErrInvalidAvailability)
+		}
+	}
+
+	err = s.expertRepo.SetAvailability(ctx, expertUuid, sorted)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return s.expertRepo.Availability(ctx, expertUuid)
+}
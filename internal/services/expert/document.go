@@ -0,0 +1,268 @@
+package expertservice
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+
+	"github.com/google/uuid"
+
+	"github.com/bogdanshibilov/mindflowbackend/internal/entity"
+)
+
+var allowedDocumentTypes = map[string]bool{
+	"application/pdf": true,
+	"image/png":       true,
+	"image/jpeg":      true,
+}
+
+// Stores an uploaded verification document of the expert. The content type is
+// detected from the file itself, only PDF, PNG and JPEG files are accepted
+func (s *Service) UploadDocument(
+	ctx context.Context,
+	expertId string,
+	kind entity.DocumentKind,
+	title string,
+	fileName string,
+	size int64,
+	file io.Reader,
+) (*entity.ExpertDocument, error) {
+	const op = "services.expert.UploadDocument"
+
+	if size > s.maxDocumentSize {
+		return nil, fmt.Errorf("%s: %w", op, ErrDocumentTooLarge)
+	}
+
+	document, err := s.newDocument(ctx, expertId, kind, title)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	reader := bufio.NewReader(io.LimitReader(file, s.maxDocumentSize+1))
+	head, _ := reader.Peek(512)
+	contentType := http.DetectContentType(head)
+	if !allowedDocumentTypes[contentType] {
+		return nil, fmt.Errorf("%s: %w", op, ErrUnsupportedDocument)
+	}
+
+	key := "expert-documents/" + document.ExpertUuid.String() + "/" + document.Uuid.String()
+	counter := &countingReader{r: reader}
+	err = s.blobs.Put(ctx, key, counter)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+	if counter.n > s.maxDocumentSize {
+		_ = s.blobs.Delete(ctx, key)
+		return nil, fmt.Errorf("%s: %w", op, ErrDocumentTooLarge)
+	}
+
+	document.BlobKey = &key
+	document.FileName = &fileName
+	document.ContentType = &contentType
+	document.SizeBytes = &counter.n
+
+	err = s.expertRepo.CreateDocument(ctx, document)
+	if err != nil {
+		_ = s.blobs.Delete(ctx, key)
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return document, nil
+}
+
+// Adds a link, e.g. to a portfolio, as a verification document of the expert
+func (s *Service) AddDocumentLink(
+	ctx context.Context,
+	expertId string,
+	kind entity.DocumentKind,
+	title string,
+	link string,
+) (*entity.ExpertDocument, error) {
+	const op = "services.expert.AddDocumentLink"
+
+	parsed, err := url.Parse(link)
+	if err != nil || (parsed.Scheme != "http" && parsed.Scheme != "https") || parsed.Host == "" {
+		return nil, fmt.Errorf("%s: %w", op, ErrInvalidDocument)
+	}
+
+	document, err := s.newDocument(ctx, expertId, kind, title)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+	document.Url = &link
+
+	err = s.expertRepo.CreateDocument(ctx, document)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return document, nil
+}
+
+func (s *Service) Documents(ctx context.Context, expertId string) ([]entity.ExpertDocument, error) {
+	const op = "services.expert.Documents"
+
+	expertUuid, err := uuid.Parse(expertId)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return s.expertRepo.DocumentsByExpertUuid(ctx, expertUuid)
+}
+
+func (s *Service) DocumentById(ctx context.Context, documentId string) (*entity.ExpertDocument, error) {
+	const op = "services.expert.DocumentById"
+
+	documentUuid, err := uuid.Parse(documentId)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return s.expertRepo.DocumentByUuid(ctx, documentUuid)
+}
+
+// Returns the document if it belongs to the expert
+func (s *Service) OwnDocument(ctx context.Context, documentId, expertId string) (*entity.ExpertDocument, error) {
+	const op = "services.expert.OwnDocument"
+
+	document, err := s.DocumentById(ctx, documentId)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+	if document.ExpertUuid.String() != expertId {
+		return nil, fmt.Errorf("%s: %w", op, ErrNotDocumentOwner)
+	}
+
+	return document, nil
+}
+
+// Opens the uploaded file of a document. The caller closes the reader
+func (s *Service) OpenDocument(ctx context.Context, document *entity.ExpertDocument) (io.ReadCloser, error) {
+	const op = "services.expert.OpenDocument"
+
+	if document.BlobKey == nil {
+		return nil, fmt.Errorf("%s: %w", op, ErrDocumentIsLink)
+	}
+
+	file, err := s.blobs.Get(ctx, *document.BlobKey)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return file, nil
+}
+
+// Deletes a document of the expert that has not been accepted
+func (s *Service) DeleteDocument(ctx context.Context, documentId, expertId string) error {
+	const op = "services.expert.DeleteDocument"
+
+	document, err := s.OwnDocument(ctx, documentId, expertId)
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+	if document.Status == entity.DocumentAccepted {
+		return fmt.Errorf("%s: %w", op, ErrDocumentAccepted)
+	}
+
+	err = s.expertRepo.DeleteDocument(ctx, document.Uuid)
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	if document.BlobKey != nil {
+		err = s.blobs.Delete(ctx, *document.BlobKey)
+		if err != nil {
+			return fmt.Errorf("%s: %w", op, err)
+		}
+	}
+
+	return nil
+}
+
+// Accepts or rejects a document. The expert is verified once a document is
+// accepted and none is left waiting for review
+func (s *Service) ReviewDocument(
+	ctx context.Context,
+	adminId string,
+	documentId string,
+	status entity.DocumentStatus,
+	note string,
+) (*entity.ExpertDocument, error) {
+	const op = "services.expert.ReviewDocument"
+
+	if status != entity.DocumentAccepted && status != entity.DocumentRejected {
+		return nil, fmt.Errorf("%s: %w", op, ErrInvalidReviewStatus)
+	}
+
+	adminUuid, err := uuid.Parse(adminId)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+	documentUuid, err := uuid.Parse(documentId)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	var notePtr *string
+	if note = strings.TrimSpace(note); note != "" {
+		notePtr = &note
+	}
+
+	document, err := s.expertRepo.SetDocumentVerdict(ctx, documentUuid, status, notePtr, adminUuid)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return document, nil
+}
+
+func (s *Service) newDocument(
+	ctx context.Context,
+	expertId string,
+	kind entity.DocumentKind,
+	title string,
+) (*entity.ExpertDocument, error) {
+	expertUuid, err := uuid.Parse(expertId)
+	if err != nil {
+		return nil, err
+	}
+
+	switch kind {
+	case entity.DocumentDiploma, entity.DocumentCertificate, entity.DocumentPortfolio:
+	default:
+		return nil, ErrInvalidDocument
+	}
+	title = strings.TrimSpace(title)
+	if title == "" {
+		return nil, ErrInvalidDocument
+	}
+
+	// Documents can only be attached to an existing application
+	_, err = s.expertRepo.Application(ctx, expertUuid)
+	if err != nil {
+		return nil, err
+	}
+
+	return &entity.ExpertDocument{
+		Uuid:       uuid.New(),
+		ExpertUuid: expertUuid,
+		Kind:       kind,
+		Title:      title,
+		Status:     entity.DocumentPending,
+	}, nil
+}
+
+type countingReader struct {
+	r io.Reader
+	n int64
+}
+
+func (c *countingReader) Read(p []byte) (int, error) {
+	n, err := c.r.Read(p)
+	c.n += int64(n)
+	return n, err
+}
@@ -0,0 +1,22 @@
+package expertservice
+
+import "errors"
+
+var (
+	ErrInvalidPrice        = errors.New("price must not be negative")
+	ErrInvalidProfile      = errors.New("name and help description must not be empty")
+	ErrInvalidReviewStatus = errors.New("revision can only be approved or rejected")
+	ErrReasonRequired      = errors.New("rejections need a reason")
+	ErrResubmitCooldown    = errors.New("rejected application cannot be resubmitted yet")
+	ErrInvalidDocument     = errors.New("document needs a known kind, a title and a valid http link if it is not a file")
+	ErrUnsupportedDocument = errors.New("documents must be PDF, PNG or JPEG files")
+	ErrDocumentTooLarge    = errors.New("document exceeds the maximum size")
+	ErrNotDocumentOwner    = errors.New("document belongs to another expert")
+	ErrDocumentIsLink      = errors.New("document is a link and has no file")
+	ErrDocumentAccepted    = errors.New("accepted documents cannot be deleted")
+	ErrUnknownSkill        = errors.New("unknown skill")
+	ErrSearchQueryTooShort = errors.New("search query needs at least 2 characters")
+	ErrInvalidLanguage     = errors.New("languages must be ISO 639 codes")
+	ErrInvalidPauseEnd     = errors.New("pause must end in the future")
+	ErrInvalidAvailability = errors.New("availability slots need a weekday from 0 to 6 and must not overlap within a day")
+)
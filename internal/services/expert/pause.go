@@ -0,0 +1,129 @@
+package expertservice
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+
+	"github.com/bogdanshibilov/mindflowbackend/internal/services/mails"
+)
+
+// Stops new bookings until the given time, or until the expert resumes when it is nil
+func (s *Service) Pause(ctx context.Context, expertId string, until *time.Time) error {
+	const op = "services.expert.Pause"
+
+	expertUuid, err := uuid.Parse(expertId)
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	if until != nil {
+		if !until.After(time.Now()) {
+			return fmt.Errorf("%s: %w", op, ErrInvalidPauseEnd)
+		}
+		utc := until.UTC()
+		until = &utc
+	}
+
+	err = s.expertRepo.Pause(ctx, expertUuid, until)
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	return nil
+}
+
+func (s *Service) Resume(ctx context.Context, expertId string) error {
+	const op = "services.expert.Resume"
+
+	expertUuid, err := uuid.Parse(expertId)
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	err = s.expertRepo.Resume(ctx, expertUuid)
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	return nil
+}
+
+// Hides the expert and stops bookings until an admin lifts the suspension.
+// The expert is notified with the reason
+func (s *Service) Suspend(ctx context.Context, adminId string, expertId string, reason string) error {
+	const op = "services.expert.Suspend"
+
+	adminUuid, err := uuid.Parse(adminId)
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+	expertUuid, err := uuid.Parse(expertId)
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	reason = strings.TrimSpace(reason)
+	if reason == "" {
+		return fmt.Errorf("%s: %w", op, ErrReasonRequired)
+	}
+
+	err = s.expertRepo.Suspend(ctx, expertUuid, reason, adminUuid)
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	user, err := s.userRepo.ByUuid(ctx, expertUuid)
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+	mails.SendExpertSuspendedNotification(user.Email, reason)
+
+	return nil
+}
+
+func (s *Service) LiftSuspension(ctx context.Context, expertId string) error {
+	const op = "services.expert.LiftSuspension"
+
+	expertUuid, err := uuid.Parse(expertId)
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	err = s.expertRepo.LiftSuspension(ctx, expertUuid)
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	user, err := s.userRepo.ByUuid(ctx, expertUuid)
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+	mails.SendExpertReactivatedNotification(user.Email)
+
+	return nil
+}
+
+// Clears pauses that have ended and lets the experts know they are bookable again.
+// Ended pauses already stop hiding experts, this tidies them up for the experts' own view
+func (s *Service) EndExpiredPauses(ctx context.Context) error {
+	const op = "services.expert.EndExpiredPauses"
+
+	expertUuids, err := s.expertRepo.EndExpiredPauses(ctx)
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	for _, expertUuid := range expertUuids {
+		user, err := s.userRepo.ByUuid(ctx, expertUuid)
+		if err != nil {
+			return fmt.Errorf("%s: %w", op, err)
+		}
+		mails.SendExpertReactivatedNotification(user.Email)
+	}
+
+	return nil
+}
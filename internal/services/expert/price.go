@@ -0,0 +1,59 @@
+package expertservice
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/google/uuid"
+
+	"github.com/bogdanshibilov/mindflowbackend/internal/entity"
+	"github.com/bogdanshibilov/mindflowbackend/internal/tenant"
+)
+
+// Changes the expert's price. Consultations that are already booked keep
+// the price they were booked at
+func (s *Service) ChangePrice(ctx context.Context, expertId string, amount int, currency string) error {
+	const op = "services.expert.ChangePrice"
+
+	expertUuid, err := uuid.Parse(expertId)
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	err = s.validatePrice(ctx, amount, currency)
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	err = s.expertRepo.UpdatePrice(ctx, expertUuid, amount, currency)
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	return nil
+}
+
+// Returns the price changes of an expert visible in the scope
+func (s *Service) PriceHistory(ctx context.Context, scope tenant.Scope, expertId string) ([]entity.ExpertPrice, error) {
+	const op = "services.expert.PriceHistory"
+
+	expertUuid, err := uuid.Parse(expertId)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	_, err = s.expertRepo.ByUuid(ctx, scope, expertUuid)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return s.expertRepo.PriceHistory(ctx, expertUuid)
+}
+
+func (s *Service) validatePrice(ctx context.Context, amount int, currency string) error {
+	if amount < 0 {
+		return ErrInvalidPrice
+	}
+
+	return s.currencies.Supported(ctx, currency)
+}
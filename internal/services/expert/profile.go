@@ -0,0 +1,318 @@
+package expertservice
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"regexp"
+	"slices"
+	"strings"
+
+	"github.com/google/uuid"
+
+	"github.com/bogdanshibilov/mindflowbackend/internal/entity"
+	"github.com/bogdanshibilov/mindflowbackend/internal/mergepatch"
+	expertrepo "github.com/bogdanshibilov/mindflowbackend/internal/repository/expert"
+	"github.com/bogdanshibilov/mindflowbackend/internal/services/mails"
+	"github.com/bogdanshibilov/mindflowbackend/internal/tenant"
+)
+
+// Times an update is rebuilt on a fresh read after losing to a concurrent edit
+const patchAttempts = 3
+
+var languageCode = regexp.MustCompile(`^[a-z]{2,3}$`)
+
+// Updates the expert's profile. Cosmetic changes apply immediately. Changes to
+// sensitive fields of an approved expert are submitted as a revision for admin
+// review, which is returned. Experts that are not approved yet edit them directly.
+// With ifMatch the update fails with expertrepo.ErrVersionMismatch unless the
+// profile is still at that version. Returns the new version
+func (s *Service) UpdateProfile(
+	ctx context.Context,
+	scope tenant.Scope,
+	expertId string,
+	update *ProfileUpdate,
+	ifMatch *Version,
+) (*entity.ExpertRevision, Version, error) {
+	const op = "services.expert.UpdateProfile"
+
+	expertUuid, err := uuid.Parse(expertId)
+	if err != nil {
+		return nil, Version{}, fmt.Errorf("%s: %w", op, err)
+	}
+
+	revision, version, err := s.updateProfile(ctx, scope, expertUuid, ifMatch, func(*entity.Expert) (*ProfileUpdate, error) {
+		return update, nil
+	})
+	if err != nil {
+		return nil, Version{}, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return revision, version, nil
+}
+
+// Applies a JSON Merge Patch to the expert's profile, with the same review rules
+// as UpdateProfile
+func (s *Service) PatchProfile(
+	ctx context.Context,
+	scope tenant.Scope,
+	expertId string,
+	patch []byte,
+	ifMatch *Version,
+) (*entity.ExpertRevision, Version, error) {
+	const op = "services.expert.PatchProfile"
+
+	expertUuid, err := uuid.Parse(expertId)
+	if err != nil {
+		return nil, Version{}, fmt.Errorf("%s: %w", op, err)
+	}
+
+	revision, version, err := s.updateProfile(ctx, scope, expertUuid, ifMatch, func(expert *entity.Expert) (*ProfileUpdate, error) {
+		skills, err := s.expertRepo.SkillsByExpertUuids(ctx, []uuid.UUID{expertUuid})
+		if err != nil {
+			return nil, err
+		}
+
+		current := &ProfileDocument{
+			Name:                  expert.Name,
+			Phone:                 expert.Phone,
+			ExperienceDescription: expert.ExperienceDescription,
+			ProfessionalField:     expert.ProfessionalField,
+			HelpDescription:       expert.HelpDescription,
+			Languages:             expert.Languages,
+			Skills:                make([]string, 0, len(skills[expertUuid])),
+		}
+		for _, skill := range skills[expertUuid] {
+			current.Skills = append(current.Skills, skill.Uuid.String())
+		}
+
+		patched, err := mergepatch.Apply(current, patch)
+		if err != nil {
+			return nil, err
+		}
+
+		// Only changed fields are updated, so untouched sensitive fields never
+		// end up in a revision
+		update := &ProfileUpdate{}
+		if patched.Name != current.Name {
+			update.Name = &patched.Name
+		}
+		if patched.Phone != current.Phone {
+			update.Phone = &patched.Phone
+		}
+		if patched.ExperienceDescription != current.ExperienceDescription {
+			update.ExperienceDescription = &patched.ExperienceDescription
+		}
+		if patched.ProfessionalField != current.ProfessionalField {
+			update.ProfessionalField = &patched.ProfessionalField
+		}
+		if patched.HelpDescription != current.HelpDescription {
+			update.HelpDescription = &patched.HelpDescription
+		}
+		if !slices.Equal(patched.Languages, current.Languages) {
+			update.Languages = &patched.Languages
+		}
+		if !slices.Equal(patched.Skills, current.Skills) {
+			update.Skills = &patched.Skills
+		}
+
+		return update, nil
+	})
+	if err != nil {
+		return nil, Version{}, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return revision, version, nil
+}
+
+// Reads the expert, builds the update from them and saves it. When a concurrent
+// edit wins, the update is rebuilt on a fresh read, unless the caller asked for
+// the version in ifMatch and so worked on a stale copy
+func (s *Service) updateProfile(
+	ctx context.Context,
+	scope tenant.Scope,
+	expertUuid uuid.UUID,
+	ifMatch *Version,
+	build func(expert *entity.Expert) (*ProfileUpdate, error),
+) (*entity.ExpertRevision, Version, error) {
+	for attempt := 1; ; attempt++ {
+		revision, version, err := s.tryUpdateProfile(ctx, scope, expertUuid, ifMatch, build)
+		if errors.Is(err, expertrepo.ErrVersionMismatch) && ifMatch == nil && attempt < patchAttempts {
+			continue
+		}
+		return revision, version, err
+	}
+}
+
+func (s *Service) tryUpdateProfile(
+	ctx context.Context,
+	scope tenant.Scope,
+	expertUuid uuid.UUID,
+	ifMatch *Version,
+	build func(expert *entity.Expert) (*ProfileUpdate, error),
+) (*entity.ExpertRevision, Version, error) {
+	expert, err := s.expertRepo.ByUuid(ctx, scope.Own(), expertUuid)
+	if err != nil {
+		return nil, Version{}, err
+	}
+	if ifMatch != nil && *ifMatch != VersionOf(expert) {
+		return nil, Version{}, expertrepo.ErrVersionMismatch
+	}
+
+	update, err := build(expert)
+	if err != nil {
+		return nil, Version{}, err
+	}
+
+	if update.Name != nil {
+		if strings.TrimSpace(*update.Name) == "" {
+			return nil, Version{}, ErrInvalidProfile
+		}
+		expert.Name = strings.TrimSpace(*update.Name)
+	}
+	if update.Phone != nil {
+		expert.Phone = *update.Phone
+	}
+	if update.ExperienceDescription != nil {
+		expert.ExperienceDescription = *update.ExperienceDescription
+	}
+	if update.Languages != nil {
+		expert.Languages, err = normalizeLanguages(*update.Languages)
+		if err != nil {
+			return nil, Version{}, err
+		}
+	}
+	if expert.Languages == nil {
+		expert.Languages = []string{}
+	}
+	if update.HelpDescription != nil && strings.TrimSpace(*update.HelpDescription) == "" {
+		return nil, Version{}, ErrInvalidProfile
+	}
+
+	revision := &entity.ExpertRevision{ExpertUuid: expertUuid}
+	if update.ProfessionalField != nil && *update.ProfessionalField != expert.ProfessionalField {
+		if expert.Status == entity.Approved {
+			revision.ProfessionalField = update.ProfessionalField
+		} else {
+			expert.ProfessionalField = *update.ProfessionalField
+		}
+	}
+	if update.HelpDescription != nil && *update.HelpDescription != expert.HelpDescription {
+		if expert.Status == entity.Approved {
+			revision.HelpDescription = update.HelpDescription
+		} else {
+			expert.HelpDescription = *update.HelpDescription
+		}
+	}
+
+	var skillUuids []uuid.UUID
+	if update.Skills != nil {
+		skillUuids, err = s.ResolveSkills(ctx, *update.Skills)
+		if err != nil {
+			return nil, Version{}, err
+		}
+		current, err := s.expertRepo.SkillsByExpertUuids(ctx, []uuid.UUID{expertUuid})
+		if err != nil {
+			return nil, Version{}, err
+		}
+		if sameSkills(current[expertUuid], skillUuids) {
+			skillUuids = nil
+		} else if expert.Status == entity.Approved {
+			revision.SkillUuids = skillUuids
+			skillUuids = nil
+		}
+	}
+
+	err = s.expertRepo.UpdateProfile(ctx, expert)
+	if err != nil {
+		return nil, Version{}, err
+	}
+	version := VersionOf(expert)
+
+	if skillUuids != nil {
+		err = s.expertRepo.SetSkills(ctx, expertUuid, skillUuids)
+		if err != nil {
+			return nil, Version{}, err
+		}
+	}
+
+	if revision.ProfessionalField == nil && revision.HelpDescription == nil && revision.SkillUuids == nil {
+		return nil, version, nil
+	}
+
+	err = s.expertRepo.SubmitRevision(ctx, revision)
+	if err != nil {
+		return nil, Version{}, err
+	}
+
+	return revision, version, nil
+}
+
+// Lower cases and deduplicates language codes
+func normalizeLanguages(languages []string) ([]string, error) {
+	normalized := make([]string, 0, len(languages))
+	seen := make(map[string]bool, len(languages))
+	for _, language := range languages {
+		language = strings.ToLower(strings.TrimSpace(language))
+		if !languageCode.MatchString(language) {
+			return nil, ErrInvalidLanguage
+		}
+		if seen[language] {
+			continue
+		}
+		seen[language] = true
+		normalized = append(normalized, language)
+	}
+	return normalized, nil
+}
+
+func (s *Service) RevisionsByStatus(ctx context.Context, status entity.Status) ([]entity.ExpertRevision, error) {
+	return s.expertRepo.RevisionsByStatus(ctx, status)
+}
+
+func (s *Service) RevisionsByExpertId(ctx context.Context, expertId string) ([]entity.ExpertRevision, error) {
+	const op = "services.expert.RevisionsByExpertId"
+
+	expertUuid, err := uuid.Parse(expertId)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return s.expertRepo.RevisionsByExpertUuid(ctx, expertUuid)
+}
+
+// Approves or rejects a pending revision and notifies the expert
+func (s *Service) ReviewRevision(
+	ctx context.Context,
+	adminId string,
+	revisionId string,
+	status entity.Status,
+) (*entity.ExpertRevision, error) {
+	const op = "services.expert.ReviewRevision"
+
+	if status != entity.Approved && status != entity.Rejected {
+		return nil, fmt.Errorf("%s: %w", op, ErrInvalidReviewStatus)
+	}
+
+	adminUuid, err := uuid.Parse(adminId)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+	revisionUuid, err := uuid.Parse(revisionId)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	revision, err := s.expertRepo.ReviewRevision(ctx, revisionUuid, status, adminUuid)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	user, err := s.userRepo.ByUuid(ctx, revision.ExpertUuid)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+	mails.SendExpertRevisionNotification(user.Email, status == entity.Approved)
+
+	return revision, nil
+}
@@ -0,0 +1,136 @@
+package expertservice
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/google/uuid"
+
+	"github.com/bogdanshibilov/mindflowbackend/internal/entity"
+	"github.com/bogdanshibilov/mindflowbackend/internal/recommend"
+	skillservice "github.com/bogdanshibilov/mindflowbackend/internal/services/skill"
+	"github.com/bogdanshibilov/mindflowbackend/internal/tenant"
+)
+
+// Ranks approved experts visible in the mentee's scope by their professional
+// field and experience description. budget is in base currency minor units
+// and may be nil. A limit of zero returns every expert
+func (s *Service) Recommend(
+	ctx context.Context,
+	scope tenant.Scope,
+	menteeId string,
+	budget *int,
+	limit int,
+) ([]recommend.Recommendation, error) {
+	const op = "services.expert.Recommend"
+
+	menteeUuid, err := uuid.Parse(menteeId)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	user, err := s.userRepo.ByUuid(ctx, menteeUuid)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	skillUuids, err := s.fieldSkills(ctx, user.ProfessionalField)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	candidates, err := s.recommendationCandidates(ctx, scope, menteeUuid)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	recommendations := recommend.Rank(&recommend.Mentee{
+		Uuid:              menteeUuid,
+		ProfessionalField: user.ProfessionalField,
+		Description:       user.ExperienceDescription,
+		SkillUuids:        skillUuids,
+		Budget:            budget,
+	}, candidates, recommend.DefaultWeights)
+	if limit > 0 && len(recommendations) > limit {
+		recommendations = recommendations[:limit]
+	}
+
+	return recommendations, nil
+}
+
+// Resolves a free text professional field to known skills, as a whole and
+// split on separators like commas and slashes. Unknown parts are ignored
+func (s *Service) fieldSkills(ctx context.Context, field string) ([]uuid.UUID, error) {
+	parts := strings.FieldsFunc(field, func(r rune) bool {
+		return r == ',' || r == '/' || r == ';' || r == '|'
+	})
+	terms := make([]string, 0, len(parts)+1)
+	for _, term := range append(parts, field) {
+		term = skillservice.NormalizeTerm(term)
+		if term != "" {
+			terms = append(terms, term)
+		}
+	}
+	if len(terms) == 0 {
+		return nil, nil
+	}
+
+	skills, err := s.skillRepo.ResolveSkills(ctx, terms)
+	if err != nil {
+		return nil, err
+	}
+
+	skillUuids := make([]uuid.UUID, 0, len(skills))
+	seen := make(map[uuid.UUID]bool)
+	for _, skill := range skills {
+		if !seen[skill.Uuid] {
+			seen[skill.Uuid] = true
+			skillUuids = append(skillUuids, skill.Uuid)
+		}
+	}
+
+	return skillUuids, nil
+}
+
+// Approved experts other than the mentee with skills and availability
+func (s *Service) recommendationCandidates(
+	ctx context.Context,
+	scope tenant.Scope,
+	menteeUuid uuid.UUID,
+) ([]recommend.Candidate, error) {
+	pricedExperts, err := s.expertRepo.ApprovedExperts(ctx, scope)
+	if err != nil {
+		return nil, err
+	}
+
+	experts := make([]entity.Expert, 0, len(pricedExperts))
+	expertUuids := make([]uuid.UUID, 0, len(pricedExperts))
+	for _, pricedExpert := range pricedExperts {
+		experts = append(experts, pricedExpert.Expert)
+		expertUuids = append(expertUuids, pricedExpert.UserUuid)
+	}
+	err = s.withSkills(ctx, experts)
+	if err != nil {
+		return nil, err
+	}
+
+	availability, err := s.expertRepo.AvailabilityByExpertUuids(ctx, expertUuids)
+	if err != nil {
+		return nil, err
+	}
+
+	candidates := make([]recommend.Candidate, 0, len(experts))
+	for i, expert := range experts {
+		if expert.UserUuid == menteeUuid {
+			continue
+		}
+		candidates = append(candidates, recommend.Candidate{
+			Expert:       expert,
+			BasePrice:    pricedExperts[i].BasePrice,
+			Availability: availability[expert.UserUuid],
+		})
+	}
+
+	return candidates, nil
+}
@@ -0,0 +1,58 @@
+package expertservice
+
+import (
+	"context"
+	"fmt"
+	"html"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/bogdanshibilov/mindflowbackend/internal/entity"
+	expertrepo "github.com/bogdanshibilov/mindflowbackend/internal/repository/expert"
+	"github.com/bogdanshibilov/mindflowbackend/internal/tenant"
+)
+
+const minSearchQueryLength = 2
+
+var snippetReplacer = strings.NewReplacer(
+	expertrepo.SnippetStart, "<mark>",
+	expertrepo.SnippetStop, "</mark>",
+)
+
+// Searches experts ranked by relevance. Snippets are HTML escaped with
+// matched words wrapped in <mark> tags
+func (s *Service) SearchExperts(
+	ctx context.Context,
+	scope tenant.Scope,
+	query string,
+	filter *expertrepo.ExpertFilter,
+	opts ...expertrepo.ExpertsOption,
+) ([]expertrepo.ExpertSearchHit, error) {
+	const op = "services.expert.SearchExperts"
+
+	query = strings.Join(strings.Fields(query), " ")
+	if utf8.RuneCountInString(query) < minSearchQueryLength {
+		return nil, fmt.Errorf("%s: %w", op, ErrSearchQueryTooShort)
+	}
+
+	hits, err := s.expertRepo.SearchExperts(ctx, scope, query, filter, opts...)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	experts := make([]entity.Expert, 0, len(hits))
+	for _, hit := range hits {
+		experts = append(experts, hit.Expert)
+	}
+	err = s.withSkills(ctx, experts)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	for i := range hits {
+		hits[i].Expert = experts[i]
+		hits[i].Snippet = snippetReplacer.Replace(html.EscapeString(hits[i].Snippet))
+	}
+
+	return hits, nil
+}
@@ -2,33 +2,65 @@ package expertservice
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"strings"
+	"time"
 
 	"github.com/google/uuid"
 
+	"github.com/bogdanshibilov/mindflowbackend/internal/blobstore"
 	"github.com/bogdanshibilov/mindflowbackend/internal/entity"
+	"github.com/bogdanshibilov/mindflowbackend/internal/pagination"
 	expertrepo "github.com/bogdanshibilov/mindflowbackend/internal/repository/expert"
+	skillrepo "github.com/bogdanshibilov/mindflowbackend/internal/repository/skill"
 	userrepo "github.com/bogdanshibilov/mindflowbackend/internal/repository/user"
+	currencyservice "github.com/bogdanshibilov/mindflowbackend/internal/services/currency"
 	"github.com/bogdanshibilov/mindflowbackend/internal/services/mails"
+	"github.com/bogdanshibilov/mindflowbackend/internal/tenant"
 )
 
 type Service struct {
 	expertRepo *expertrepo.Repo
 	userRepo   *userrepo.Repo
+	skillRepo  *skillrepo.Repo
+	currencies *currencyservice.Service
+	blobs      blobstore.Store
+	// How long a rejected applicant waits before applying again
+	resubmitCooldown time.Duration
+	// In bytes
+	maxDocumentSize int64
 }
 
-func New(expertRepo *expertrepo.Repo, userRepo *userrepo.Repo) *Service {
+func New(
+	expertRepo *expertrepo.Repo,
+	userRepo *userrepo.Repo,
+	skillRepo *skillrepo.Repo,
+	currencies *currencyservice.Service,
+	blobs blobstore.Store,
+	resubmitCooldown time.Duration,
+	maxDocumentSize int64,
+) *Service {
 	return &Service{
-		expertRepo: expertRepo,
-		userRepo:   userRepo,
+		expertRepo:       expertRepo,
+		userRepo:         userRepo,
+		skillRepo:        skillRepo,
+		currencies:       currencies,
+		blobs:            blobs,
+		resubmitCooldown: resubmitCooldown,
+		maxDocumentSize:  maxDocumentSize,
 	}
 }
 
+// Applies for becoming an expert. Rejected applicants resubmit their
+// application once the cooldown since the rejection has passed
 func (s *Service) ApplyForExpert(
 	ctx context.Context,
+	scope tenant.Scope,
 	userId string,
 	helpDescription string,
 	price int,
+	currency string,
 ) error {
 	const op = "services.expert.ApplyForExpert"
 
@@ -37,8 +69,29 @@ func (s *Service) ApplyForExpert(
 		return fmt.Errorf("%s: %w", op, err)
 	}
 
+	if currency == "" {
+		currency = s.currencies.Base()
+	}
+	err = s.validatePrice(ctx, price, currency)
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	existing, err := s.expertRepo.Application(ctx, uuid)
+	switch {
+	case err == nil:
+		err = s.resubmit(ctx, scope, uuid, existing, helpDescription, price, currency)
+		if err != nil {
+			return fmt.Errorf("%s: %w", op, err)
+		}
+		return nil
+	case !errors.Is(err, expertrepo.ErrExpertNotFound):
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
 	information := &entity.ExpertInformation{
 		Price:           price,
+		Currency:        currency,
 		HelpDescription: helpDescription,
 	}
 	application := &entity.ExpertApplication{
@@ -58,24 +111,41 @@ func (s *Service) ApplyForExpert(
 	return nil
 }
 
-func (s *Service) ChangeExpertStatus(ctx context.Context, expertId string, status entity.Status) error {
-	const op = "services.expert.ApproveExpert"
+// Approves or rejects an expert application. Rejections need a reason,
+// which is sent to the applicant
+func (s *Service) ChangeExpertStatus(
+	ctx context.Context,
+	adminId string,
+	expertId string,
+	status entity.Status,
+	reason string,
+) error {
+	const op = "services.expert.ChangeExpertStatus"
 
-	application := &entity.ExpertApplication{
-		Status: status,
+	adminUuid, err := uuid.Parse(adminId)
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
 	}
-
-	uuid, err := uuid.Parse(expertId)
+	expertUuid, err := uuid.Parse(expertId)
 	if err != nil {
 		return fmt.Errorf("%s: %w", op, err)
 	}
 
-	err = s.expertRepo.UpdateExpertApplication(ctx, application, uuid)
+	reason = strings.TrimSpace(reason)
+	if status == entity.Rejected && reason == "" {
+		return fmt.Errorf("%s: %w", op, ErrReasonRequired)
+	}
+	var reasonPtr *string
+	if reason != "" {
+		reasonPtr = &reason
+	}
+
+	err = s.expertRepo.ReviewApplication(ctx, expertUuid, status, reasonPtr, adminUuid)
 	if err != nil {
 		return fmt.Errorf("%s: %w", op, err)
 	}
 
-	user, err := s.userRepo.ByUuid(ctx, uuid)
+	user, err := s.userRepo.ByUuid(ctx, expertUuid)
 	if err != nil {
 		return fmt.Errorf("%s: %w", op, err)
 	}
@@ -84,13 +154,78 @@ func (s *Service) ChangeExpertStatus(ctx context.Context, expertId string, statu
 	case entity.Approved:
 		mails.SendExpertConfirmationNotification(user.Email)
 	case entity.Rejected:
-		mails.SendExpertRejectNotification(user.Email)
+		mails.SendExpertRejectNotification(user.Email, reason)
 	}
 
 	return nil
 }
 
-func (s *Service) ById(ctx context.Context, expertId string) (*entity.Expert, error) {
+// Returns the state of the user's expert application
+func (s *Service) ApplicationStatus(ctx context.Context, userId string) (*ApplicationStatus, error) {
+	const op = "services.expert.ApplicationStatus"
+
+	userUuid, err := uuid.Parse(userId)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	application, err := s.expertRepo.Application(ctx, userUuid)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	status := &ApplicationStatus{ExpertApplication: *application}
+	if application.Status == entity.Rejected && application.ReviewedAt != nil {
+		resubmitAt := application.ReviewedAt.Add(s.resubmitCooldown)
+		status.ResubmitAt = &resubmitAt
+	}
+
+	return status, nil
+}
+
+func (s *Service) ApplicationHistory(ctx context.Context, expertId string) ([]entity.ExpertApplicationEvent, error) {
+	const op = "services.expert.ApplicationHistory"
+
+	expertUuid, err := uuid.Parse(expertId)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return s.expertRepo.ApplicationHistory(ctx, expertUuid)
+}
+
+func (s *Service) resubmit(
+	ctx context.Context,
+	scope tenant.Scope,
+	userUuid uuid.UUID,
+	application *entity.ExpertApplication,
+	helpDescription string,
+	price int,
+	currency string,
+) error {
+	if application.Status != entity.Rejected {
+		return expertrepo.ErrExpertAlreadyExists
+	}
+	if application.ReviewedAt != nil && time.Now().Before(application.ReviewedAt.Add(s.resubmitCooldown)) {
+		return ErrResubmitCooldown
+	}
+
+	expert, err := s.expertRepo.ByUuid(ctx, scope.Own(), userUuid)
+	if err != nil {
+		return err
+	}
+	if expert.Price != price || expert.Currency != currency {
+		err = s.expertRepo.UpdatePrice(ctx, userUuid, price, currency)
+		if err != nil {
+			return err
+		}
+	}
+
+	return s.expertRepo.ResubmitApplication(ctx, userUuid, helpDescription)
+}
+
+// Returns the expert if visible in the scope
+func (s *Service) ById(ctx context.Context, scope tenant.Scope, expertId string) (*entity.Expert, error) {
 	const op = "services.expert.ApproveExpert"
 
 	uuid, err := uuid.Parse(expertId)
@@ -98,39 +233,88 @@ func (s *Service) ById(ctx context.Context, expertId string) (*entity.Expert, er
 		return nil, fmt.Errorf("%s: %w", op, err)
 	}
 
-	return s.expertRepo.ByUuid(ctx, uuid)
+	expert, err := s.expertRepo.ByUuid(ctx, scope, uuid)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	experts := []entity.Expert{*expert}
+	err = s.withSkills(ctx, experts)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return &experts[0], nil
 }
 
-func (s *Service) FilterData(ctx context.Context) (*FilterData, error) {
+// Returns filter data of the experts visible in the scope with the price
+// range converted to currency
+func (s *Service) FilterData(ctx context.Context, scope tenant.Scope, currency string) (*FilterData, error) {
 	const op = "services.expert.FilterData"
 
-	profFields, err := s.expertRepo.ProffFieldListAndCount(ctx)
+	skills, err := s.expertRepo.SkillCounts(ctx, scope)
 	if err != nil {
 		return nil, fmt.Errorf("%s: %w", op, err)
 	}
 
-	minMaxPrice, err := s.expertRepo.MinMaxPrice(ctx)
+	minMaxPrice, err := s.expertRepo.MinMaxPrice(ctx, scope)
 	if err != nil {
 		return nil, fmt.Errorf("%s: %w", op, err)
 	}
 
+	if currency == "" {
+		currency = s.currencies.Base()
+	}
+	minMaxPrice.MinPrice, err = s.currencies.FromBase(ctx, minMaxPrice.MinPrice, currency)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+	minMaxPrice.MaxPrice, err = s.currencies.FromBase(ctx, minMaxPrice.MaxPrice, currency)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+	minMaxPrice.Currency = currency
+
 	return &FilterData{
-		ProffFieldData: profFields,
-		MinMaxPrice:    *minMaxPrice,
+		Skills:      skills,
+		MinMaxPrice: *minMaxPrice,
 	}, nil
 }
 
-func (s *Service) ExpertsWithFilter(ctx context.Context, filter map[string]any) ([]entity.Expert, error) {
-	return s.expertRepo.ExpertsWithFilter(ctx, filter)
+func (s *Service) ExpertsWithFilter(
+	ctx context.Context,
+	scope tenant.Scope,
+	filter *expertrepo.ExpertFilter,
+	page *pagination.Request,
+	opts ...expertrepo.ExpertsOption,
+) (*pagination.Page[entity.Expert], error) {
+	const op = "services.expert.ExpertsWithFilter"
+
+	experts, err := s.expertRepo.ExpertsWithFilter(ctx, scope, filter, page, opts...)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	err = s.withSkills(ctx, experts.Items)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return experts, nil
 }
 
-func (s *Service) DoesExist(ctx context.Context, id string) (bool, error) {
-	const op = "services.expert.DoesExist"
+// Converts a price to base currency minor units so it can be compared with expertrepo.BasePriceExpr
+func (s *Service) BasePrice(ctx context.Context, amount int, currency string) (int, error) {
+	const op = "services.expert.BasePrice"
+
+	if currency == "" {
+		return amount, nil
+	}
 
-	uuid, err := uuid.Parse(id)
+	base, err := s.currencies.ToBase(ctx, amount, currency)
 	if err != nil {
-		return false, fmt.Errorf("%s: %w", op, err)
+		return 0, fmt.Errorf("%s: %w", op, err)
 	}
 
-	return s.expertRepo.DoesExist(ctx, uuid)
+	return base, nil
 }
@@ -0,0 +1,85 @@
+package expertservice
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/google/uuid"
+
+	"github.com/bogdanshibilov/mindflowbackend/internal/entity"
+	skillservice "github.com/bogdanshibilov/mindflowbackend/internal/services/skill"
+)
+
+// Resolves skill ids, names and aliases to skill ids. Duplicates are dropped
+func (s *Service) ResolveSkills(ctx context.Context, terms []string) ([]uuid.UUID, error) {
+	const op = "services.expert.ResolveSkills"
+
+	normalized := make([]string, 0, len(terms))
+	for _, term := range terms {
+		term = skillservice.NormalizeTerm(term)
+		if term != "" {
+			normalized = append(normalized, term)
+		}
+	}
+
+	skills, err := s.skillRepo.ResolveSkills(ctx, normalized)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	skillUuids := make([]uuid.UUID, 0, len(normalized))
+	seen := make(map[uuid.UUID]bool)
+	for _, term := range normalized {
+		skill, ok := skills[term]
+		if !ok {
+			return nil, fmt.Errorf("%s: %w: %s", op, ErrUnknownSkill, term)
+		}
+		if !seen[skill.Uuid] {
+			seen[skill.Uuid] = true
+			skillUuids = append(skillUuids, skill.Uuid)
+		}
+	}
+
+	return skillUuids, nil
+}
+
+// Fills the skills of the experts
+func (s *Service) withSkills(ctx context.Context, experts []entity.Expert) error {
+	if len(experts) == 0 {
+		return nil
+	}
+
+	expertUuids := make([]uuid.UUID, 0, len(experts))
+	for _, expert := range experts {
+		expertUuids = append(expertUuids, expert.UserUuid)
+	}
+
+	skills, err := s.expertRepo.SkillsByExpertUuids(ctx, expertUuids)
+	if err != nil {
+		return err
+	}
+
+	for i := range experts {
+		experts[i].Skills = skills[experts[i].UserUuid]
+	}
+
+	return nil
+}
+
+func sameSkills(skills []entity.Skill, skillUuids []uuid.UUID) bool {
+	if len(skills) != len(skillUuids) {
+		return false
+	}
+
+	current := make(map[uuid.UUID]bool, len(skills))
+	for _, skill := range skills {
+		current[skill.Uuid] = true
+	}
+	for _, skillUuid := range skillUuids {
+		if !current[skillUuid] {
+			return false
+		}
+	}
+
+	return true
+}
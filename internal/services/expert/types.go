@@ -1,8 +1,59 @@
 package expertservice
 
-import expertrepo "github.com/bogdanshibilov/mindflowbackend/internal/repository/expert"
+import (
+	"time"
+
+	"github.com/bogdanshibilov/mindflowbackend/internal/entity"
+	expertrepo "github.com/bogdanshibilov/mindflowbackend/internal/repository/expert"
+)
 
 type FilterData struct {
-	ProffFieldData []expertrepo.ProffFieldListAndCount `json:"proffFieldData"`
-	MinMaxPrice    expertrepo.MinMaxPrice              `json:"minMaxPrice"`
+	Skills      []expertrepo.SkillCount `json:"skills"`
+	MinMaxPrice expertrepo.MinMaxPrice  `json:"minMaxPrice"`
+}
+
+// Changes an expert makes to their profile. Nil fields are left unchanged
+type ProfileUpdate struct {
+	Name                  *string
+	Phone                 *string
+	ExperienceDescription *string
+	// ISO 639 language codes the expert consults in
+	Languages *[]string
+	// Sensitive fields, approved experts need an admin to review changes to them
+	ProfessionalField *string
+	HelpDescription   *string
+	// Skill ids, names or aliases replacing the current skills
+	Skills *[]string
+}
+
+type ApplicationStatus struct {
+	entity.ExpertApplication
+	// Set for rejected applications, when the applicant may apply again
+	ResubmitAt *time.Time
+}
+
+// Versions of the two parts of an expert's profile, together they identify
+// the state of the profile
+type Version struct {
+	Profile     int
+	Information int
+}
+
+func VersionOf(expert *entity.Expert) Version {
+	return Version{
+		Profile:     expert.UserProfile.Version,
+		Information: expert.ExpertInformation.Version,
+	}
+}
+
+// Profile fields as the document merge patches apply to
+type ProfileDocument struct {
+	Name                  string   `json:"name"`
+	Phone                 string   `json:"phone"`
+	ExperienceDescription string   `json:"experienceDescription"`
+	ProfessionalField     string   `json:"professionalField"`
+	HelpDescription       string   `json:"helpDescription"`
+	Languages             []string `json:"languages"`
+	// Skill ids. Patches may also use names or aliases
+	Skills []string `json:"skills"`
 }
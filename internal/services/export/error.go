@@ -0,0 +1,7 @@
+package exportservice
+
+import "errors"
+
+var (
+	ErrExportNotReady = errors.New("data export is not ready")
+)
@@ -0,0 +1,439 @@
+package exportservice
+
+import (
+	"archive/zip"
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"path"
+	"time"
+
+	"github.com/google/uuid"
+
+	"github.com/bogdanshibilov/mindflowbackend/internal/blobstore"
+	"github.com/bogdanshibilov/mindflowbackend/internal/entity"
+	consultationrepo "github.com/bogdanshibilov/mindflowbackend/internal/repository/consultation"
+	expertrepo "github.com/bogdanshibilov/mindflowbackend/internal/repository/expert"
+	userrepo "github.com/bogdanshibilov/mindflowbackend/internal/repository/user"
+	userservice "github.com/bogdanshibilov/mindflowbackend/internal/services/user"
+	"github.com/bogdanshibilov/mindflowbackend/internal/tenant"
+)
+
+type Service struct {
+	userRepo    *userrepo.Repo
+	expertRepo  *expertrepo.Repo
+	consultRepo *consultationrepo.Repo
+	blobs       blobstore.Store
+	// How long a ready archive can be downloaded
+	ttl time.Duration
+}
+
+func New(
+	userRepo *userrepo.Repo,
+	expertRepo *expertrepo.Repo,
+	consultRepo *consultationrepo.Repo,
+	blobs blobstore.Store,
+	ttl time.Duration,
+) *Service {
+	return &Service{
+		userRepo:    userRepo,
+		expertRepo:  expertRepo,
+		consultRepo: consultRepo,
+		blobs:       blobs,
+		ttl:         ttl,
+	}
+}
+
+// Queues an export of the user's data. The archive is built in the background
+func (s *Service) Request(ctx context.Context, userId string) (*entity.DataExport, error) {
+	const op = "services.export.Request"
+
+	userUuid, err := uuid.Parse(userId)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	export := &entity.DataExport{UserUuid: userUuid}
+	err = s.userRepo.CreateExport(ctx, export)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return export, nil
+}
+
+func (s *Service) ById(ctx context.Context, userId string, exportId string) (*entity.DataExport, error) {
+	const op = "services.export.ById"
+
+	userUuid, err := uuid.Parse(userId)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+	exportUuid, err := uuid.Parse(exportId)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	export, err := s.userRepo.ExportByUuid(ctx, exportUuid, userUuid)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return export, nil
+}
+
+// Opens the archive of a ready export. The caller closes the reader
+func (s *Service) Open(ctx context.Context, userId string, exportId string) (io.ReadCloser, error) {
+	const op = "services.export.Open"
+
+	export, err := s.ById(ctx, userId, exportId)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+	if export.Status != entity.DataExportReady || export.BlobKey == nil {
+		return nil, fmt.Errorf("%s: %w", op, ErrExportNotReady)
+	}
+
+	archive, err := s.blobs.Get(ctx, *export.BlobKey)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return archive, nil
+}
+
+// Builds the archives of pending exports. Exports that fail are marked failed
+// so the user can request a new one
+func (s *Service) BuildPending(ctx context.Context) error {
+	const op = "services.export.BuildPending"
+
+	exports, err := s.userRepo.PendingExports(ctx)
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	var errs []error
+	for _, export := range exports {
+		err = s.build(ctx, &export)
+		if err == nil {
+			continue
+		}
+		errs = append(errs, fmt.Errorf("export %s: %w", export.Uuid, err))
+
+		err = s.userRepo.FailExport(ctx, export.Uuid)
+		if err != nil {
+			errs = append(errs, err)
+		}
+	}
+
+	if len(errs) > 0 {
+		return fmt.Errorf("%s: %w", op, errors.Join(errs...))
+	}
+
+	return nil
+}
+
+// Deletes exports that can no longer be downloaded together with their archives
+func (s *Service) DeleteExpired(ctx context.Context) error {
+	const op = "services.export.DeleteExpired"
+
+	keys, err := s.userRepo.DeleteExpiredExports(ctx)
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	for _, key := range keys {
+		err = s.blobs.Delete(ctx, key)
+		if err != nil {
+			return fmt.Errorf("%s: %w", op, err)
+		}
+	}
+
+	return nil
+}
+
+func (s *Service) build(ctx context.Context, export *entity.DataExport) error {
+	var buf bytes.Buffer
+	archive := zip.NewWriter(&buf)
+
+	err := s.writeArchive(ctx, archive, export.UserUuid)
+	if err != nil {
+		return err
+	}
+	err = archive.Close()
+	if err != nil {
+		return err
+	}
+
+	size := int64(buf.Len())
+	key := "data-exports/" + export.UserUuid.String() + "/" + export.Uuid.String() + ".zip"
+	err = s.blobs.Put(ctx, key, &buf)
+	if err != nil {
+		return err
+	}
+
+	err = s.userRepo.CompleteExport(ctx, export.Uuid, key, size, time.Now().UTC().Add(s.ttl))
+	if err != nil {
+		_ = s.blobs.Delete(ctx, key)
+		return err
+	}
+
+	return nil
+}
+
+func (s *Service) writeArchive(ctx context.Context, archive *zip.Writer, userUuid uuid.UUID) error {
+	user, err := s.userRepo.ByUuid(ctx, userUuid)
+	if err != nil {
+		return err
+	}
+	exportedProfile := &profile{
+		Id:                    user.Uuid,
+		Username:              user.Username,
+		Roles:                 user.Roles,
+		Name:                  user.Name,
+		Email:                 user.Email,
+		Phone:                 user.Phone,
+		ProfessionalField:     user.ProfessionalField,
+		ExperienceDescription: user.ExperienceDescription,
+	}
+	if user.AvatarHash != nil {
+		name := "avatar.jpg"
+		largest := userservice.AvatarSizes[len(userservice.AvatarSizes)-1]
+		err = s.copyBlob(ctx, archive, name, userservice.AvatarKey(*user.AvatarHash, largest))
+		if err != nil {
+			return err
+		}
+		exportedProfile.Avatar = &name
+	}
+	err = writeJson(archive, "profile.json", exportedProfile)
+	if err != nil {
+		return err
+	}
+
+	expertData, err := s.expert(ctx, archive, userUuid)
+	if err != nil {
+		return err
+	}
+	if expertData != nil {
+		err = writeJson(archive, "expert.json", expertData)
+		if err != nil {
+			return err
+		}
+	}
+
+	consultations, err := s.consultations(ctx, userUuid)
+	if err != nil {
+		return err
+	}
+	err = writeJson(archive, "consultations.json", consultations)
+	if err != nil {
+		return err
+	}
+
+	reviews, err := s.consultRepo.ReviewsByMenteeUuid(ctx, tenant.Unscoped, userUuid)
+	if err != nil {
+		return err
+	}
+
+	return writeJson(archive, "reviews.json", reviews)
+}
+
+// Collects the expert data of the user and copies their uploaded documents
+// into the archive. Returns nil for users who never applied as expert
+func (s *Service) expert(ctx context.Context, archive *zip.Writer, userUuid uuid.UUID) (*expert, error) {
+	information, err := s.expertRepo.ByUuid(ctx, tenant.Unscoped, userUuid)
+	if err != nil {
+		if errors.Is(err, expertrepo.ErrExpertNotFound) {
+			return nil, nil
+		}
+		return nil, err
+	}
+
+	data := &expert{
+		Price:            information.Price,
+		Currency:         information.Currency,
+		HelpDescription:  information.HelpDescription,
+		Languages:        information.Languages,
+		Skills:           make([]string, 0),
+		PausedUntil:      information.PausedUntil,
+		SuspendedAt:      information.SuspendedAt,
+		SuspensionReason: information.SuspensionReason,
+		Documents:        make([]document, 0),
+	}
+
+	skills, err := s.expertRepo.SkillsByExpertUuids(ctx, []uuid.UUID{userUuid})
+	if err != nil {
+		return nil, err
+	}
+	for _, skill := range skills[userUuid] {
+		data.Skills = append(data.Skills, skill.Name)
+	}
+
+	data.ApplicationHistory, err = s.expertRepo.ApplicationHistory(ctx, userUuid)
+	if err != nil {
+		return nil, err
+	}
+	data.PriceHistory, err = s.expertRepo.PriceHistory(ctx, userUuid)
+	if err != nil {
+		return nil, err
+	}
+	data.Availability, err = s.expertRepo.Availability(ctx, userUuid)
+	if err != nil {
+		return nil, err
+	}
+	data.Revisions, err = s.expertRepo.RevisionsByExpertUuid(ctx, userUuid)
+	if err != nil {
+		return nil, err
+	}
+	data.ReviewsReceived, err = s.consultRepo.ReviewsByExpertUuid(ctx, tenant.Unscoped, userUuid)
+	if err != nil {
+		return nil, err
+	}
+
+	documents, err := s.expertRepo.DocumentsByExpertUuid(ctx, userUuid)
+	if err != nil {
+		return nil, err
+	}
+	for _, doc := range documents {
+		exported := document{
+			Id:     doc.Uuid,
+			Kind:   doc.Kind,
+			Title:  doc.Title,
+			Url:    doc.Url,
+			Status: doc.Status,
+		}
+		if doc.BlobKey != nil {
+			name := "documents/" + doc.Uuid.String()
+			if doc.FileName != nil {
+				name += "-" + path.Base(*doc.FileName)
+			}
+			err = s.copyBlob(ctx, archive, name, *doc.BlobKey)
+			if err != nil {
+				return nil, err
+			}
+			exported.File = &name
+		}
+		data.Documents = append(data.Documents, exported)
+	}
+
+	return data, nil
+}
+
+// Collects the consultations the user took part in as mentee or expert
+func (s *Service) consultations(ctx context.Context, userUuid uuid.UUID) ([]consultation, error) {
+	consultations := make([]consultation, 0)
+
+	for _, role := range []struct {
+		name  string
+		whose consultationrepo.WhoseUuid
+	}{
+		{"mentee", consultationrepo.ByMenteeUuid},
+		{"expert", consultationrepo.ByExpertUuid},
+	} {
+		consults, err := s.consultRepo.ByPersonUuid(ctx, tenant.Unscoped, userUuid, consultationrepo.SelectByWhoseUuid(role.whose))
+		if err != nil {
+			return nil, err
+		}
+
+		for _, consult := range consults {
+			exported, err := s.consultation(ctx, userUuid, &consult)
+			if err != nil {
+				return nil, err
+			}
+			exported.Role = role.name
+			consultations = append(consultations, *exported)
+		}
+	}
+
+	return consultations, nil
+}
+
+func (s *Service) consultation(ctx context.Context, userUuid uuid.UUID, consult *entity.Consultation) (*consultation, error) {
+	exported := &consultation{
+		Id:              consult.Uuid,
+		ExpertId:        consult.ExpertUuid,
+		MenteeId:        consult.MenteeUuid,
+		Status:          consult.Status,
+		PriceAmount:     consult.PriceAmount,
+		PriceCurrency:   consult.PriceCurrency,
+		MenteeQuestions: consult.MenteeQuestions,
+		SubmittedAt:     consult.SubmittedAt,
+		CancelledAt:     consult.CancelledAt,
+		Meetings:        make([]meeting, 0),
+		Notes:           make([]note, 0),
+	}
+
+	meetings, err := s.consultRepo.MeetingsByConsultationUuid(ctx, tenant.Unscoped, consult.Uuid)
+	if err != nil {
+		return nil, err
+	}
+	for _, m := range meetings {
+		exportedMeeting := meeting{
+			Id:        m.Uuid,
+			StartTime: m.StartTime,
+			Link:      m.Link,
+		}
+
+		// Mentees only see published summaries
+		meetingSummary, err := s.consultRepo.SummaryByMeetingUuid(ctx, m.Uuid)
+		switch {
+		case errors.Is(err, consultationrepo.ErrSummaryNotFound):
+		case err != nil:
+			return nil, err
+		case meetingSummary.AuthorUuid == userUuid || meetingSummary.PublishedAt != nil:
+			exportedMeeting.Summary = &summary{
+				Content:     meetingSummary.Content,
+				ActionItems: meetingSummary.ActionItems,
+				PublishedAt: meetingSummary.PublishedAt,
+				UpdatedAt:   meetingSummary.UpdatedAt,
+			}
+		}
+
+		exported.Meetings = append(exported.Meetings, exportedMeeting)
+	}
+
+	notes, err := s.consultRepo.NotesByConsultationUuid(ctx, consult.Uuid, userUuid)
+	if err != nil {
+		return nil, err
+	}
+	for _, n := range notes {
+		exported.Notes = append(exported.Notes, note{
+			Content:   n.Content,
+			CreatedAt: n.CreatedAt,
+			UpdatedAt: n.UpdatedAt,
+		})
+	}
+
+	return exported, nil
+}
+
+func (s *Service) copyBlob(ctx context.Context, archive *zip.Writer, name string, key string) error {
+	blob, err := s.blobs.Get(ctx, key)
+	if err != nil {
+		return err
+	}
+	defer blob.Close()
+
+	w, err := archive.Create(name)
+	if err != nil {
+		return err
+	}
+	_, err = io.Copy(w, blob)
+
+	return err
+}
+
+func writeJson(archive *zip.Writer, name string, v any) error {
+	w, err := archive.Create(name)
+	if err != nil {
+		return err
+	}
+
+	encoder := json.NewEncoder(w)
+	encoder.SetIndent("", "  ")
+
+	return encoder.Encode(v)
+}
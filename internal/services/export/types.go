@@ -0,0 +1,88 @@
+package exportservice
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+
+	"github.com/bogdanshibilov/mindflowbackend/internal/entity"
+)
+
+// Contents of the archive. Every top level field is written to its own JSON file
+
+type profile struct {
+	Id                    uuid.UUID `json:"id"`
+	Username              string    `json:"username"`
+	Roles                 []string  `json:"roles"`
+	Name                  string    `json:"name"`
+	Email                 string    `json:"email"`
+	Phone                 string    `json:"phone"`
+	ProfessionalField     string    `json:"professionalField"`
+	ExperienceDescription string    `json:"experienceDescription"`
+	// Path of the avatar inside the archive
+	Avatar *string `json:"avatar"`
+}
+
+type expert struct {
+	Price              int                             `json:"price"`
+	Currency           string                          `json:"currency"`
+	HelpDescription    string                          `json:"helpDescription"`
+	Languages          []string                        `json:"languages"`
+	Skills             []string                        `json:"skills"`
+	PausedUntil        *time.Time                      `json:"pausedUntil"`
+	SuspendedAt        *time.Time                      `json:"suspendedAt"`
+	SuspensionReason   *string                         `json:"suspensionReason"`
+	ApplicationHistory []entity.ExpertApplicationEvent `json:"applicationHistory"`
+	PriceHistory       []entity.ExpertPrice            `json:"priceHistory"`
+	Availability       []entity.ExpertAvailability     `json:"availability"`
+	Revisions          []entity.ExpertRevision         `json:"revisions"`
+	Documents          []document                      `json:"documents"`
+	ReviewsReceived    []entity.ConsultationReview     `json:"reviewsReceived"`
+}
+
+type document struct {
+	Id     uuid.UUID             `json:"id"`
+	Kind   entity.DocumentKind   `json:"kind"`
+	Title  string                `json:"title"`
+	Url    *string               `json:"url"`
+	Status entity.DocumentStatus `json:"status"`
+	// Path of the uploaded file inside the archive
+	File *string `json:"file"`
+}
+
+type consultation struct {
+	Id uuid.UUID `json:"id"`
+	// "mentee" or "expert", the part the user had
+	Role            string        `json:"role"`
+	ExpertId        uuid.UUID     `json:"expertId"`
+	MenteeId        uuid.UUID     `json:"menteeId"`
+	Status          entity.Status `json:"status"`
+	PriceAmount     int           `json:"priceAmount"`
+	PriceCurrency   string        `json:"priceCurrency"`
+	MenteeQuestions string        `json:"menteeQuestions"`
+	SubmittedAt     time.Time     `json:"submittedAt"`
+	CancelledAt     *time.Time    `json:"cancelledAt"`
+	Meetings        []meeting     `json:"meetings"`
+	// Private notes the user wrote
+	Notes []note `json:"notes"`
+}
+
+type meeting struct {
+	Id        uuid.UUID `json:"id"`
+	StartTime time.Time `json:"startTime"`
+	Link      string    `json:"link"`
+	Summary   *summary  `json:"summary"`
+}
+
+type summary struct {
+	Content     string     `json:"content"`
+	ActionItems []string   `json:"actionItems"`
+	PublishedAt *time.Time `json:"publishedAt"`
+	UpdatedAt   time.Time  `json:"updatedAt"`
+}
+
+type note struct {
+	Content   string    `json:"content"`
+	CreatedAt time.Time `json:"createdAt"`
+	UpdatedAt time.Time `json:"updatedAt"`
+}
@@ -10,6 +10,8 @@ type UserClaims struct {
 	Uuid  string   `json:"uuid"`
 	Email string   `json:"email"`
 	Roles []string `json:"roles"`
+	// Token version of the user when the token was issued
+	TokenVersion int `json:"tv"`
 	jwt.RegisteredClaims
 }
 
@@ -17,15 +19,17 @@ func NewAccessToken(
 	uuid string,
 	email string,
 	roles []string,
+	tokenVersion int,
 	secret string,
 	duration time.Duration,
 ) (string, error) {
 	issuedAt := time.Now()
 	expiresAt := time.Now().Add(duration)
 	claims := UserClaims{
-		Uuid:  uuid,
-		Email: email,
-		Roles: roles,
+		Uuid:         uuid,
+		Email:        email,
+		Roles:        roles,
+		TokenVersion: tokenVersion,
 		RegisteredClaims: jwt.RegisteredClaims{
 			IssuedAt:  jwt.NewNumericDate(issuedAt),
 			ExpiresAt: jwt.NewNumericDate(expiresAt),
@@ -4,9 +4,12 @@ import (
 	"log"
 	"os"
 	"strings"
+	"time"
 
 	"github.com/emersion/go-sasl"
 	"github.com/emersion/go-smtp"
+
+	"github.com/bogdanshibilov/mindflowbackend/internal/money"
 )
 
 var (
@@ -31,14 +34,15 @@ func SendExpertConfirmationNotification(toEmail string) {
 	}
 }
 
-func SendExpertRejectNotification(toEmail string) {
+func SendExpertRejectNotification(toEmail string, reason string) {
 	auth := sasl.NewPlainClient("", from, password)
 
 	to := []string{toEmail}
 	msg := strings.NewReader("To: " + toEmail + "\r\n" +
 		"Subject: You were rejected to become expert in Mindflow\r\n" +
 		"\r\n" +
-		"You were rejected to become expert in Mindflow")
+		"You were rejected to become expert in Mindflow\r\n" +
+		"Reason: " + reason)
 
 	err := smtp.SendMail(host+":"+port, auth, from, to, msg)
 	if err != nil {
@@ -62,3 +66,255 @@ func SendConsultationNotification(toEmails []string, link string) {
 		}
 	}
 }
+
+func SendSessionSummaryNotification(toEmail string, expertName string) {
+	auth := sasl.NewPlainClient("", from, password)
+
+	to := []string{toEmail}
+	msg := strings.NewReader("To: " + toEmail + "\r\n" +
+		"Subject: Your session summary is ready\r\n" +
+		"\r\n" +
+		expertName + " has shared a summary of your consultation in Mindflow")
+
+	err := smtp.SendMail(host+":"+port, auth, from, to, msg)
+	if err != nil {
+		log.Println(err)
+	}
+}
+
+func SendPaymentRequestNotification(toEmail string, amount int, currency string) {
+	auth := sasl.NewPlainClient("", from, password)
+
+	to := []string{toEmail}
+	msg := strings.NewReader("To: " + toEmail + "\r\n" +
+		"Subject: Your consultation was accepted\r\n" +
+		"\r\n" +
+		"Your consultation was accepted. Please pay " + money.Format(amount, currency) +
+		" in Mindflow to get the meeting link")
+
+	err := smtp.SendMail(host+":"+port, auth, from, to, msg)
+	if err != nil {
+		log.Println(err)
+	}
+}
+
+func SendCancellationNotification(toEmails []string, refundAmount int, currency string) {
+	auth := sasl.NewPlainClient("", from, password)
+
+	body := "Your consultation in Mindflow was cancelled"
+	if refundAmount > 0 {
+		body += "\r\nRefund: " + money.Format(refundAmount, currency)
+	}
+
+	for _, to := range toEmails {
+		msg := strings.NewReader("To: " + to + "\r\n" +
+			"Subject: Your consultation was cancelled\r\n" +
+			"\r\n" +
+			body)
+		toSlice := []string{to}
+		err := smtp.SendMail(host+":"+port, auth, from, toSlice, msg)
+		if err != nil {
+			log.Println(err)
+		}
+	}
+}
+
+func SendPayoutNotification(toEmail string, amount int, currency string, reference string) {
+	auth := sasl.NewPlainClient("", from, password)
+
+	body := "Your Mindflow earnings of " + money.Format(amount, currency) + " were paid out"
+	if reference != "" {
+		body += "\r\nReference: " + reference
+	}
+
+	msg := strings.NewReader("To: " + toEmail + "\r\n" +
+		"Subject: Your payout was sent\r\n" +
+		"\r\n" +
+		body)
+	to := []string{toEmail}
+	err := smtp.SendMail(host+":"+port, auth, from, to, msg)
+	if err != nil {
+		log.Println(err)
+	}
+}
+
+func SendExpertRevisionNotification(toEmail string, approved bool) {
+	auth := sasl.NewPlainClient("", from, password)
+
+	subject := "Your profile changes were approved"
+	body := "The changes to your expert profile in Mindflow were approved and are now public"
+	if !approved {
+		subject = "Your profile changes were rejected"
+		body = "The changes to your expert profile in Mindflow were rejected, your profile was left unchanged"
+	}
+
+	to := []string{toEmail}
+	msg := strings.NewReader("To: " + toEmail + "\r\n" +
+		"Subject: " + subject + "\r\n" +
+		"\r\n" +
+		body)
+
+	err := smtp.SendMail(host+":"+port, auth, from, to, msg)
+	if err != nil {
+		log.Println(err)
+	}
+}
+
+func SendExpertSuspendedNotification(toEmail string, reason string) {
+	auth := sasl.NewPlainClient("", from, password)
+
+	to := []string{toEmail}
+	msg := strings.NewReader("To: " + toEmail + "\r\n" +
+		"Subject: Your expert profile in Mindflow was suspended\r\n" +
+		"\r\n" +
+		"Your expert profile in Mindflow was suspended and is hidden from mentees\r\n" +
+		"Reason: " + reason)
+
+	err := smtp.SendMail(host+":"+port, auth, from, to, msg)
+	if err != nil {
+		log.Println(err)
+	}
+}
+
+func SendExpertReactivatedNotification(toEmail string) {
+	auth := sasl.NewPlainClient("", from, password)
+
+	to := []string{toEmail}
+	msg := strings.NewReader("To: " + toEmail + "\r\n" +
+		"Subject: Your expert profile in Mindflow is active again\r\n" +
+		"\r\n" +
+		"Your expert profile in Mindflow is visible again and mentees can book consultations with you")
+
+	err := smtp.SendMail(host+":"+port, auth, from, to, msg)
+	if err != nil {
+		log.Println(err)
+	}
+}
+
+func SendAccountErasedNotification(toEmail string) {
+	auth := sasl.NewPlainClient("", from, password)
+
+	to := []string{toEmail}
+	msg := strings.NewReader("To: " + toEmail + "\r\n" +
+		"Subject: Your Mindflow account was closed\r\n" +
+		"\r\n" +
+		"Your Mindflow account was closed and your personal data was removed. " +
+		"Consultations you took part in stay visible to the other party without your name or contacts")
+
+	err := smtp.SendMail(host+":"+port, auth, from, to, msg)
+	if err != nil {
+		log.Println(err)
+	}
+}
+
+func SendPasswordChangedNotification(toEmail string) {
+	auth := sasl.NewPlainClient("", from, password)
+
+	to := []string{toEmail}
+	msg := strings.NewReader("To: " + toEmail + "\r\n" +
+		"Subject: Your Mindflow password was changed\r\n" +
+		"\r\n" +
+		"The password of your Mindflow account was changed and you were signed out everywhere. " +
+		"If it was not you, reset your password and contact support")
+
+	err := smtp.SendMail(host+":"+port, auth, from, to, msg)
+	if err != nil {
+		log.Println(err)
+	}
+}
+
+// Goes to the old address, so the owner notices when someone else took over the account
+func SendEmailChangedNotification(toEmail string, newEmail string) {
+	auth := sasl.NewPlainClient("", from, password)
+
+	to := []string{toEmail}
+	msg := strings.NewReader("To: " + toEmail + "\r\n" +
+		"Subject: Your Mindflow email was changed\r\n" +
+		"\r\n" +
+		"The email of your Mindflow account was changed to " + newEmail + ". " +
+		"If it was not you, contact support")
+
+	err := smtp.SendMail(host+":"+port, auth, from, to, msg)
+	if err != nil {
+		log.Println(err)
+	}
+}
+
+// Sends a message an admin wrote to users. Line breaks are dropped from the
+// subject so it cannot add headers
+func SendAdminMessage(toEmail string, subject string, message string) {
+	auth := sasl.NewPlainClient("", from, password)
+
+	subject = strings.NewReplacer("\r", " ", "\n", " ").Replace(subject)
+
+	to := []string{toEmail}
+	msg := strings.NewReader("To: " + toEmail + "\r\n" +
+		"Subject: " + subject + "\r\n" +
+		"\r\n" +
+		message)
+
+	err := smtp.SendMail(host+":"+port, auth, from, to, msg)
+	if err != nil {
+		log.Println(err)
+	}
+}
+
+func SendAccountSuspendedNotification(toEmail string, reason string, until *time.Time, appealNote *string) {
+	auth := sasl.NewPlainClient("", from, password)
+
+	duration := "permanently"
+	if until != nil {
+		duration = "until " + until.UTC().Format("2006-01-02 15:04") + " UTC"
+	}
+	appeal := ""
+	if appealNote != nil {
+		appeal = "\r\nTo appeal: " + *appealNote
+	}
+
+	to := []string{toEmail}
+	msg := strings.NewReader("To: " + toEmail + "\r\n" +
+		"Subject: Your Mindflow account was suspended\r\n" +
+		"\r\n" +
+		"Your Mindflow account was suspended " + duration + ". " +
+		"Your upcoming consultations were cancelled\r\n" +
+		"Reason: " + reason +
+		appeal)
+
+	err := smtp.SendMail(host+":"+port, auth, from, to, msg)
+	if err != nil {
+		log.Println(err)
+	}
+}
+
+func SendAccountReinstatedNotification(toEmail string) {
+	auth := sasl.NewPlainClient("", from, password)
+
+	to := []string{toEmail}
+	msg := strings.NewReader("To: " + toEmail + "\r\n" +
+		"Subject: Your Mindflow account is active again\r\n" +
+		"\r\n" +
+		"The suspension of your Mindflow account was lifted and you can sign in again")
+
+	err := smtp.SendMail(host+":"+port, auth, from, to, msg)
+	if err != nil {
+		log.Println(err)
+	}
+}
+
+func SendInviteNotification(toEmail string, name string, link string, expiresAt time.Time) {
+	auth := sasl.NewPlainClient("", from, password)
+
+	to := []string{toEmail}
+	msg := strings.NewReader("To: " + toEmail + "\r\n" +
+		"Subject: You are invited to Mindflow\r\n" +
+		"\r\n" +
+		"Hi " + name + ", an account in Mindflow was created for you. " +
+		"Choose your password to sign in: " + link + "\r\n" +
+		"The link works once and expires on " + expiresAt.UTC().Format("2006-01-02") + ". " +
+		"If it expired, ask your company admin for a new one")
+
+	err := smtp.SendMail(host+":"+port, auth, from, to, msg)
+	if err != nil {
+		log.Println(err)
+	}
+}
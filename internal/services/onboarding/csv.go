@@ -0,0 +1,156 @@
+package onboardingservice
+
+import (
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"net/mail"
+	"regexp"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/bogdanshibilov/mindflowbackend/internal/entity"
+)
+
+// Columns the CSV may have, in any order. The header row names them
+const (
+	columnName                  = "name"
+	columnEmail                 = "email"
+	columnPhone                 = "phone"
+	columnProfessionalField     = "professional_field"
+	columnExperienceDescription = "experience_description"
+)
+
+var e164 = regexp.MustCompile(`^\+[1-9][0-9]{1,14}$`)
+
+// Reads the rows of the CSV. Rows that fail validation come back failed with
+// the reason, the others pending. Fails when the CSV itself cannot be read
+func parseCsv(file io.Reader) ([]entity.UserImportRow, error) {
+	reader := csv.NewReader(file)
+	reader.TrimLeadingSpace = true
+	reader.FieldsPerRecord = -1
+
+	header, err := reader.Read()
+	if err != nil {
+		if errors.Is(err, io.EOF) {
+			return nil, ErrNoRows
+		}
+		return nil, fmt.Errorf("%w: %s", ErrInvalidCsv, err)
+	}
+	columns := make(map[string]int, len(header))
+	for i, column := range header {
+		column = strings.ToLower(strings.TrimSpace(strings.TrimPrefix(column, "\ufeff")))
+		switch column {
+		case columnName, columnEmail, columnPhone, columnProfessionalField, columnExperienceDescription:
+		default:
+			return nil, fmt.Errorf("%w: unknown column %q", ErrInvalidCsv, column)
+		}
+		if _, ok := columns[column]; ok {
+			return nil, fmt.Errorf("%w: column %q appears twice", ErrInvalidCsv, column)
+		}
+		columns[column] = i
+	}
+	if _, ok := columns[columnName]; !ok {
+		return nil, fmt.Errorf("%w: name column is required", ErrInvalidCsv)
+	}
+	if _, ok := columns[columnEmail]; !ok {
+		return nil, fmt.Errorf("%w: email column is required", ErrInvalidCsv)
+	}
+
+	var rows []entity.UserImportRow
+	// First line of each email, to report duplicates
+	emailLines := make(map[string]int)
+	for {
+		record, err := reader.Read()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("%w: %s", ErrInvalidCsv, err)
+		}
+		if len(rows) == maxRows {
+			return nil, ErrTooManyRows
+		}
+		line, _ := reader.FieldPos(0)
+
+		field := func(column string) string {
+			i, ok := columns[column]
+			if !ok || i >= len(record) {
+				return ""
+			}
+			return strings.TrimSpace(record[i])
+		}
+		row := entity.UserImportRow{
+			Line:                  line,
+			Name:                  field(columnName),
+			Email:                 field(columnEmail),
+			Phone:                 field(columnPhone),
+			ProfessionalField:     field(columnProfessionalField),
+			ExperienceDescription: field(columnExperienceDescription),
+			Status:                entity.UserImportRowPending,
+		}
+
+		problem := validateRow(&row, len(record) == len(header))
+		if problem == "" {
+			email := strings.ToLower(row.Email)
+			if first, ok := emailLines[email]; ok {
+				problem = fmt.Sprintf("email is a duplicate of line %d", first)
+			} else {
+				emailLines[email] = line
+			}
+		}
+		if problem != "" {
+			row.Status = entity.UserImportRowFailed
+			row.Error = &problem
+			// Columns are cut to fit the table, the error tells what was wrong
+			row.Name = truncate(row.Name, 255)
+			row.Email = truncate(row.Email, 255)
+			row.Phone = truncate(row.Phone, 20)
+			row.ProfessionalField = truncate(row.ProfessionalField, 255)
+		}
+		rows = append(rows, row)
+	}
+
+	if len(rows) == 0 {
+		return nil, ErrNoRows
+	}
+
+	return rows, nil
+}
+
+// Returns what is wrong with the row, empty if nothing
+func validateRow(row *entity.UserImportRow, complete bool) string {
+	switch {
+	case !complete:
+		return "row has a different number of columns than the header"
+	case row.Name == "":
+		return "name is required"
+	case utf8.RuneCountInString(row.Name) > 255:
+		return "name is longer than 255 characters"
+	case row.Email == "":
+		return "email is required"
+	case len(row.Email) > 255:
+		return "email is longer than 255 characters"
+	case !validEmail(row.Email):
+		return "email is not a valid address"
+	case row.Phone != "" && !e164.MatchString(row.Phone):
+		return "phone must be in E.164 format, like +14155552671"
+	case utf8.RuneCountInString(row.ProfessionalField) > 255:
+		return "professional_field is longer than 255 characters"
+	}
+	return ""
+}
+
+// Accepts bare addresses only, without a display name
+func validEmail(email string) bool {
+	address, err := mail.ParseAddress(email)
+	return err == nil && address.Address == email
+}
+
+func truncate(value string, maxRunes int) string {
+	if utf8.RuneCountInString(value) <= maxRunes {
+		return value
+	}
+	return string([]rune(value)[:maxRunes])
+}
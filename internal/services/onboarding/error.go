@@ -0,0 +1,10 @@
+package onboardingservice
+
+import "errors"
+
+var (
+	ErrInvalidCsv     = errors.New("invalid CSV")
+	ErrNoRows         = errors.New("CSV has no rows")
+	ErrTooManyRows    = errors.New("CSV has too many rows")
+	ErrImportTooLarge = errors.New("CSV exceeds the maximum size")
+)
@@ -0,0 +1,250 @@
+package onboardingservice
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"io"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+
+	"github.com/bogdanshibilov/mindflowbackend/internal/entity"
+	userrepo "github.com/bogdanshibilov/mindflowbackend/internal/repository/user"
+	"github.com/bogdanshibilov/mindflowbackend/internal/services/mails"
+	userservice "github.com/bogdanshibilov/mindflowbackend/internal/services/user"
+)
+
+const (
+	// Most rows one import can have
+	maxRows = 5000
+	// Largest CSV accepted, in bytes
+	MaxCsvSize = 5 << 20
+	// Users created per import on every run of the job
+	batchSize = 50
+)
+
+type Service struct {
+	userRepo *userrepo.Repo
+	// Frontend page the invite token is appended to
+	inviteURL string
+	inviteTTL time.Duration
+}
+
+func New(userRepo *userrepo.Repo, inviteURL string, inviteTTL time.Duration) *Service {
+	return &Service{
+		userRepo:  userRepo,
+		inviteURL: inviteURL,
+		inviteTTL: inviteTTL,
+	}
+}
+
+// Outcome of reading a CSV
+type Report struct {
+	// Nil for dry runs
+	Import *entity.UserImport
+	// Every row, those that failed validation carry the reason
+	Rows []entity.UserImportRow
+}
+
+// Validates the rows of the CSV and, unless it is a dry run, stores the
+// import for the background job to create the valid rows. Rows with an
+// email that is registered already fail validation
+func (s *Service) Import(ctx context.Context, adminId string, file io.Reader, dryRun bool) (*Report, error) {
+	const op = "services.onboarding.Import"
+
+	adminUuid, err := uuid.Parse(adminId)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	// One byte over the limit tells a large file from one of exactly the maximum size
+	limited := &io.LimitedReader{R: file, N: MaxCsvSize + 1}
+	rows, err := parseCsv(limited)
+	if limited.N == 0 {
+		return nil, fmt.Errorf("%s: %w", op, ErrImportTooLarge)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	err = s.failRegistered(ctx, rows)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	report := &Report{Rows: rows}
+	if dryRun {
+		return report, nil
+	}
+
+	report.Import = &entity.UserImport{AdminUuid: &adminUuid}
+	err = s.userRepo.CreateImport(ctx, report.Import, rows)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return report, nil
+}
+
+// Fails the pending rows whose email belongs to a user already
+func (s *Service) failRegistered(ctx context.Context, rows []entity.UserImportRow) error {
+	var emails []string
+	for _, row := range rows {
+		if row.Status == entity.UserImportRowPending {
+			emails = append(emails, row.Email)
+		}
+	}
+	if len(emails) == 0 {
+		return nil
+	}
+
+	registered, err := s.userRepo.RegisteredEmails(ctx, emails)
+	if err != nil {
+		return err
+	}
+	isRegistered := make(map[string]bool, len(registered))
+	for _, email := range registered {
+		isRegistered[email] = true
+	}
+
+	for i := range rows {
+		if rows[i].Status == entity.UserImportRowPending && isRegistered[strings.ToLower(rows[i].Email)] {
+			problem := "email is already registered"
+			rows[i].Status = entity.UserImportRowFailed
+			rows[i].Error = &problem
+		}
+	}
+
+	return nil
+}
+
+// Returns the import with its progress and the rows that failed
+func (s *Service) ImportById(ctx context.Context, id string) (*entity.UserImport, []entity.UserImportRow, error) {
+	const op = "services.onboarding.ImportById"
+
+	importUuid, err := uuid.Parse(id)
+	if err != nil {
+		return nil, nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	userImport, err := s.userRepo.ImportByUuid(ctx, importUuid)
+	if err != nil {
+		return nil, nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	failed := entity.UserImportRowFailed
+	rows, err := s.userRepo.ImportRows(ctx, importUuid, &failed, 0)
+	if err != nil {
+		return nil, nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return userImport, rows, nil
+}
+
+// Creates the next batch of users of every pending import
+func (s *Service) RunPending(ctx context.Context) error {
+	const op = "services.onboarding.RunPending"
+
+	imports, err := s.userRepo.PendingImports(ctx)
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	var errs []error
+	for _, userImport := range imports {
+		_, err = s.RunBatch(ctx, userImport.Uuid)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("import %s: %w", userImport.Uuid, err))
+		}
+	}
+
+	if len(errs) > 0 {
+		return fmt.Errorf("%s: %w", op, errors.Join(errs...))
+	}
+
+	return nil
+}
+
+// Creates the next batch of users of the import and mails them their
+// invites. Rows whose user cannot be created are failed with the reason.
+// Returns the import with its progress, done once no rows are pending
+func (s *Service) RunBatch(ctx context.Context, importUuid uuid.UUID) (*entity.UserImport, error) {
+	const op = "services.onboarding.RunBatch"
+
+	pending := entity.UserImportRowPending
+	rows, err := s.userRepo.ImportRows(ctx, importUuid, &pending, batchSize)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	for i := range rows {
+		err = s.createUser(ctx, &rows[i])
+		if err != nil {
+			return nil, fmt.Errorf("%s: line %d: %w", op, rows[i].Line, err)
+		}
+	}
+
+	err = s.userRepo.CompleteImport(ctx, importUuid)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	userImport, err := s.userRepo.ImportByUuid(ctx, importUuid)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return userImport, nil
+}
+
+// Creates the user of the row and mails the invite. Conflicts with users
+// registered since the CSV was validated fail the row, other errors are returned
+func (s *Service) createUser(ctx context.Context, row *entity.UserImportRow) error {
+	token, tokenHash, err := newInviteToken()
+	if err != nil {
+		return err
+	}
+	expiresAt := time.Now().Add(s.inviteTTL)
+
+	err = s.userRepo.CreateImportedUser(ctx, row, tokenHash, expiresAt)
+	switch {
+	case err == nil:
+	case errors.Is(err, userrepo.ErrImportRowProcessed):
+		// Another run of the job got to it first
+		return nil
+	case errors.Is(err, userrepo.ErrEmailTaken):
+		return s.userRepo.FailImportRow(ctx, row, "email is already registered")
+	case errors.Is(err, userrepo.ErrUsernameTaken):
+		return s.userRepo.FailImportRow(ctx, row, "a user with this email as username exists")
+	default:
+		return err
+	}
+
+	mails.SendInviteNotification(row.Email, row.Name, s.inviteLink(token), expiresAt)
+
+	return nil
+}
+
+func (s *Service) inviteLink(token string) string {
+	separator := "?"
+	if strings.Contains(s.inviteURL, "?") {
+		separator = "&"
+	}
+	return s.inviteURL + separator + "token=" + url.QueryEscape(token)
+}
+
+// Returns a random token for the link and the hash that is stored instead of it
+func newInviteToken() (string, []byte, error) {
+	raw := make([]byte, 32)
+	_, err := rand.Read(raw)
+	if err != nil {
+		return "", nil, err
+	}
+	token := base64.RawURLEncoding.EncodeToString(raw)
+	return token, userservice.HashInviteToken(token), nil
+}
@@ -0,0 +1,14 @@
+package organizationservice
+
+import "errors"
+
+var (
+	ErrInvalidSlug  = errors.New("slug must be 1 to 63 lower case letters, digits or inner hyphens")
+	ErrInvalidName  = errors.New("name must be 1 to 255 characters")
+	ErrInvalidColor = errors.New("primary color must be a hex color like #1a2b3c")
+	ErrInvalidLogo  = errors.New("logo url must be an absolute https url")
+	ErrInvalidRole  = errors.New("role must be member or admin")
+	// Only members see the organization, only its admins and platform staff manage it
+	ErrNotMember = errors.New("user is not a member of the organization")
+	ErrNotAdmin  = errors.New("user is not an admin of the organization")
+)
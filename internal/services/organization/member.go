@@ -0,0 +1,118 @@
+package organizationservice
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/google/uuid"
+
+	"github.com/bogdanshibilov/mindflowbackend/internal/entity"
+	"github.com/bogdanshibilov/mindflowbackend/internal/pagination"
+	organizationrepo "github.com/bogdanshibilov/mindflowbackend/internal/repository/organization"
+)
+
+func (s *Service) Members(
+	ctx context.Context,
+	adminId string,
+	orgId string,
+	page *pagination.Request,
+) (*pagination.Page[entity.OrganizationMember], error) {
+	const op = "services.organization.Members"
+
+	orgUuid, err := s.authorize(ctx, adminId, orgId)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	members, err := s.orgRepo.Members(ctx, orgUuid, page)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return members, nil
+}
+
+// Adds the user to the organization with the role, or changes the role of a member
+func (s *Service) SetMember(
+	ctx context.Context,
+	adminId string,
+	orgId string,
+	userId string,
+	role entity.OrganizationRole,
+) (*entity.OrganizationMember, error) {
+	const op = "services.organization.SetMember"
+
+	orgUuid, err := s.authorize(ctx, adminId, orgId)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+	userUuid, err := uuid.Parse(userId)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+	if role != entity.OrganizationRoleMember && role != entity.OrganizationRoleAdmin {
+		return nil, fmt.Errorf("%s: %w", op, ErrInvalidRole)
+	}
+
+	member := &entity.OrganizationMember{
+		OrganizationUuid: orgUuid,
+		UserUuid:         userUuid,
+		Role:             role,
+	}
+	err = s.orgRepo.SetMember(ctx, member)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return member, nil
+}
+
+// Removes the user from the organization. Their expert profile becomes public
+func (s *Service) RemoveMember(ctx context.Context, adminId string, orgId string, userId string) error {
+	const op = "services.organization.RemoveMember"
+
+	orgUuid, err := s.authorize(ctx, adminId, orgId)
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+	userUuid, err := uuid.Parse(userId)
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	err = s.orgRepo.RemoveMember(ctx, orgUuid, userUuid)
+	if err != nil {
+		if errors.Is(err, organizationrepo.ErrNotMember) {
+			return fmt.Errorf("%s: %w", op, ErrNotMember)
+		}
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	return nil
+}
+
+// Limits the expert, a member of the organization, to its members or makes
+// them public when scoped is false
+func (s *Service) SetExpertScope(ctx context.Context, adminId string, orgId string, expertId string, scoped bool) error {
+	const op = "services.organization.SetExpertScope"
+
+	orgUuid, err := s.authorize(ctx, adminId, orgId)
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+	expertUuid, err := uuid.Parse(expertId)
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	err = s.orgRepo.SetExpertScope(ctx, orgUuid, expertUuid, scoped)
+	if err != nil {
+		if errors.Is(err, organizationrepo.ErrNotMember) {
+			return fmt.Errorf("%s: %w", op, ErrNotMember)
+		}
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	return nil
+}
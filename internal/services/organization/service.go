@@ -0,0 +1,315 @@
+package organizationservice
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/url"
+	"regexp"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/google/uuid"
+
+	"github.com/bogdanshibilov/mindflowbackend/internal/entity"
+	"github.com/bogdanshibilov/mindflowbackend/internal/pagination"
+	consultationrepo "github.com/bogdanshibilov/mindflowbackend/internal/repository/consultation"
+	organizationrepo "github.com/bogdanshibilov/mindflowbackend/internal/repository/organization"
+	userrepo "github.com/bogdanshibilov/mindflowbackend/internal/repository/user"
+	userservice "github.com/bogdanshibilov/mindflowbackend/internal/services/user"
+	"github.com/bogdanshibilov/mindflowbackend/internal/tenant"
+)
+
+const maxNameLength = 255
+
+var (
+	slugPattern  = regexp.MustCompile(`^[a-z0-9]([a-z0-9-]{0,61}[a-z0-9])?$`)
+	colorPattern = regexp.MustCompile(`^#[0-9a-fA-F]{6}$`)
+)
+
+type Service struct {
+	orgRepo     *organizationrepo.Repo
+	consultRepo *consultationrepo.Repo
+	users       *userservice.Service
+}
+
+func New(orgRepo *organizationrepo.Repo, consultRepo *consultationrepo.Repo, users *userservice.Service) *Service {
+	return &Service{
+		orgRepo:     orgRepo,
+		consultRepo: consultRepo,
+		users:       users,
+	}
+}
+
+// Returns what the viewer may see. Anonymous viewers and users outside any
+// organization see public data, members their organization's too, staff everything
+func (s *Service) Scope(ctx context.Context, viewerId string) (tenant.Scope, error) {
+	const op = "services.organization.Scope"
+
+	if viewerId == "" {
+		return tenant.Scope{}, nil
+	}
+	viewerUuid, err := uuid.Parse(viewerId)
+	if err != nil {
+		return tenant.Scope{}, fmt.Errorf("%s: %w", op, err)
+	}
+
+	isStaff, err := s.isStaff(ctx, viewerId)
+	if err != nil {
+		return tenant.Scope{}, fmt.Errorf("%s: %w", op, err)
+	}
+	if isStaff {
+		return tenant.Unscoped, nil
+	}
+
+	member, err := s.orgRepo.Membership(ctx, viewerUuid)
+	if err != nil {
+		if errors.Is(err, organizationrepo.ErrNotMember) {
+			return tenant.Scope{}, nil
+		}
+		return tenant.Scope{}, fmt.Errorf("%s: %w", op, err)
+	}
+	org, err := s.orgRepo.ByUuid(ctx, member.OrganizationUuid)
+	if err != nil {
+		return tenant.Scope{}, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return tenant.Scope{
+		Org:     org.Uuid,
+		OrgOnly: !org.PublicExperts,
+	}, nil
+}
+
+// Creates the organization with its branding and settings
+func (s *Service) CreateOrganization(ctx context.Context, org *entity.Organization) error {
+	const op = "services.organization.CreateOrganization"
+
+	org.Slug = strings.ToLower(strings.TrimSpace(org.Slug))
+	if !slugPattern.MatchString(org.Slug) {
+		return fmt.Errorf("%s: %w", op, ErrInvalidSlug)
+	}
+	org.Name = strings.TrimSpace(org.Name)
+	if org.Name == "" || utf8.RuneCountInString(org.Name) > maxNameLength {
+		return fmt.Errorf("%s: %w", op, ErrInvalidName)
+	}
+	err := validateBranding(&org.OrganizationBranding)
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	err = s.orgRepo.CreateOrganization(ctx, org)
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	return nil
+}
+
+func (s *Service) Organizations(
+	ctx context.Context,
+	page *pagination.Request,
+) (*pagination.Page[entity.Organization], error) {
+	const op = "services.organization.Organizations"
+
+	orgs, err := s.orgRepo.Organizations(ctx, page)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return orgs, nil
+}
+
+// Returns the organization to its members and to staff
+func (s *Service) ById(ctx context.Context, viewerId string, orgId string) (*entity.Organization, error) {
+	const op = "services.organization.ById"
+
+	orgUuid, err := uuid.Parse(orgId)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	scope, err := s.Scope(ctx, viewerId)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+	if !scope.All && scope.Org != orgUuid {
+		return nil, fmt.Errorf("%s: %w", op, ErrNotMember)
+	}
+
+	org, err := s.orgRepo.ByUuid(ctx, orgUuid)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return org, nil
+}
+
+// Returns the organization of the user with their membership, ErrNotMember
+// outside any organization
+func (s *Service) Mine(ctx context.Context, userId string) (*entity.Organization, *entity.OrganizationMember, error) {
+	const op = "services.organization.Mine"
+
+	userUuid, err := uuid.Parse(userId)
+	if err != nil {
+		return nil, nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	member, err := s.orgRepo.Membership(ctx, userUuid)
+	if err != nil {
+		if errors.Is(err, organizationrepo.ErrNotMember) {
+			return nil, nil, fmt.Errorf("%s: %w", op, ErrNotMember)
+		}
+		return nil, nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	org, err := s.orgRepo.ByUuid(ctx, member.OrganizationUuid)
+	if err != nil {
+		return nil, nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return org, member, nil
+}
+
+func (s *Service) UpdateBranding(
+	ctx context.Context,
+	adminId string,
+	orgId string,
+	branding *entity.OrganizationBranding,
+) error {
+	const op = "services.organization.UpdateBranding"
+
+	orgUuid, err := s.authorize(ctx, adminId, orgId)
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	err = validateBranding(branding)
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	err = s.orgRepo.UpdateBranding(ctx, orgUuid, branding)
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	return nil
+}
+
+func (s *Service) UpdateSettings(
+	ctx context.Context,
+	adminId string,
+	orgId string,
+	settings *entity.OrganizationSettings,
+) error {
+	const op = "services.organization.UpdateSettings"
+
+	orgUuid, err := s.authorize(ctx, adminId, orgId)
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	err = s.orgRepo.UpdateSettings(ctx, orgUuid, settings)
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	return nil
+}
+
+// Returns every consultation with the organization's experts
+func (s *Service) Consultations(
+	ctx context.Context,
+	adminId string,
+	orgId string,
+	page *pagination.Request,
+) (*pagination.Page[entity.Consultation], error) {
+	const op = "services.organization.Consultations"
+
+	orgUuid, err := s.authorize(ctx, adminId, orgId)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	consultations, err := s.consultRepo.ByOrganization(ctx, orgUuid, page)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return consultations, nil
+}
+
+// Checks that the user is an admin of the organization or platform staff
+// and returns the organization uuid
+func (s *Service) authorize(ctx context.Context, adminId string, orgId string) (uuid.UUID, error) {
+	orgUuid, err := uuid.Parse(orgId)
+	if err != nil {
+		return uuid.Nil, err
+	}
+	adminUuid, err := uuid.Parse(adminId)
+	if err != nil {
+		return uuid.Nil, err
+	}
+
+	isStaff, err := s.isStaff(ctx, adminId)
+	if err != nil {
+		return uuid.Nil, err
+	}
+	if isStaff {
+		// Staff manage organizations that exist only
+		_, err = s.orgRepo.ByUuid(ctx, orgUuid)
+		if err != nil {
+			return uuid.Nil, err
+		}
+		return orgUuid, nil
+	}
+
+	member, err := s.orgRepo.Membership(ctx, adminUuid)
+	if err != nil {
+		if errors.Is(err, organizationrepo.ErrNotMember) {
+			return uuid.Nil, ErrNotAdmin
+		}
+		return uuid.Nil, err
+	}
+	if member.OrganizationUuid != orgUuid || member.Role != entity.OrganizationRoleAdmin {
+		return uuid.Nil, ErrNotAdmin
+	}
+
+	return orgUuid, nil
+}
+
+func (s *Service) isStaff(ctx context.Context, userId string) (bool, error) {
+	isAdmin, err := s.users.IsAdmin(ctx, userId)
+	if err != nil && !errors.Is(err, userrepo.ErrUserNotFound) {
+		return false, err
+	}
+	return isAdmin, nil
+}
+
+// Trims the branding and checks it, empty values clear it
+func validateBranding(branding *entity.OrganizationBranding) error {
+	if branding.LogoUrl != nil {
+		logoUrl := strings.TrimSpace(*branding.LogoUrl)
+		if logoUrl == "" {
+			branding.LogoUrl = nil
+		} else {
+			parsed, err := url.Parse(logoUrl)
+			if err != nil || parsed.Scheme != "https" || parsed.Host == "" {
+				return ErrInvalidLogo
+			}
+			branding.LogoUrl = &logoUrl
+		}
+	}
+	if branding.PrimaryColor != nil {
+		color := strings.ToLower(strings.TrimSpace(*branding.PrimaryColor))
+		if color == "" {
+			branding.PrimaryColor = nil
+		} else {
+			if !colorPattern.MatchString(color) {
+				return ErrInvalidColor
+			}
+			branding.PrimaryColor = &color
+		}
+	}
+	return nil
+}
@@ -0,0 +1,13 @@
+package paymentservice
+
+import "errors"
+
+var (
+	ErrNotPayer            = errors.New("user is not the payer of the payment")
+	ErrAlreadyPaid         = errors.New("payment has already succeeded")
+	ErrPaymentInProgress   = errors.New("payment is already being processed")
+	ErrInvalidRefundAmount = errors.New("refund amount must be positive and not exceed the refundable amount")
+	ErrRefundInFlight      = errors.New("refund is being sent to the provider")
+	ErrNotRefundable       = errors.New("payment has not succeeded and cannot be refunded")
+	ErrPromoNotApplicable  = errors.New("promo code does not apply to this payment")
+)
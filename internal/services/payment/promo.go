@@ -0,0 +1,90 @@
+package paymentservice
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+
+	"github.com/bogdanshibilov/mindflowbackend/internal/entity"
+)
+
+// Applies a promo code to an intent awaiting payment. A discount that covers
+// the whole amount completes the payment without charging the payer
+func (s *Service) ApplyPromo(ctx context.Context, intentId, payerId, code string) (*entity.PaymentIntent, error) {
+	const op = "services.payment.ApplyPromo"
+
+	intentUuid, err := uuid.Parse(intentId)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	intent, err := s.paymentRepo.IntentByUuid(ctx, intentUuid)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+	if intent.PayerUuid.String() != payerId {
+		return nil, fmt.Errorf("%s: %w", op, ErrNotPayer)
+	}
+
+	promo, err := s.promoRepo.PromoByCode(ctx, strings.ToUpper(strings.TrimSpace(code)))
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	if promo.ExpertUuid != nil {
+		expertUuid, err := s.intentExpertUuid(ctx, intent)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", op, err)
+		}
+		if expertUuid != *promo.ExpertUuid {
+			return nil, fmt.Errorf("%s: %w", op, ErrPromoNotApplicable)
+		}
+	}
+
+	discount, err := promoDiscount(promo, intent)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	err = s.paymentRepo.ApplyPromo(ctx, intent, promo.Uuid, discount)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	if intent.Amount > 0 {
+		return intent, nil
+	}
+
+	completion, err := s.paymentRepo.SucceedIntent(ctx, intent)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	err = s.onSucceeded(ctx, intent, completion)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return intent, nil
+}
+
+func promoDiscount(promo *entity.PromoCode, intent *entity.PaymentIntent) (int, error) {
+	if promo.ExpiresAt != nil && promo.ExpiresAt.Before(time.Now()) {
+		return 0, ErrPromoNotApplicable
+	}
+
+	switch promo.Kind {
+	case entity.PromoPercent:
+		return intent.OriginalAmount * promo.Value / 100, nil
+	case entity.PromoFixed:
+		if promo.Currency == nil || *promo.Currency != intent.Currency {
+			return 0, ErrPromoNotApplicable
+		}
+		return min(promo.Value, intent.OriginalAmount), nil
+	default:
+		return 0, ErrPromoNotApplicable
+	}
+}
@@ -0,0 +1,397 @@
+package paymentservice
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+
+	"github.com/bogdanshibilov/mindflowbackend/internal/entity"
+	"github.com/bogdanshibilov/mindflowbackend/internal/paymentprovider"
+	paymentrepo "github.com/bogdanshibilov/mindflowbackend/internal/repository/payment"
+	"github.com/bogdanshibilov/mindflowbackend/internal/tenant"
+)
+
+// RefundPolicy decides how much of a payment goes back to the mentee
+// when a consultation is cancelled
+type RefundPolicy struct {
+	// Cancelling at least this long before the meeting gives a full refund
+	FullRefundNotice time.Duration
+	// Share of the payment refunded on later cancellations, in percent
+	LateRefundPercent int
+}
+
+// Returns the amount to refund out of refundable. Meetings that have not
+// been scheduled yet and cancellations by the expert are always refunded in full
+func (p RefundPolicy) Amount(refundable int, startTime *time.Time, cancelledAt time.Time, byExpert bool) int {
+	if byExpert || startTime == nil {
+		return refundable
+	}
+	if startTime.Sub(cancelledAt) >= p.FullRefundNotice {
+		return refundable
+	}
+
+	return refundable * p.LateRefundPercent / 100
+}
+
+// Works out the refund owed for the user cancelling the consultation now,
+// without recording it. Returns nil if nothing is owed
+func (s *Service) CancellationRefund(
+	ctx context.Context,
+	scope tenant.Scope,
+	consult *entity.Consultation,
+	cancelledBy uuid.UUID,
+) (*entity.Refund, error) {
+	const op = "services.payment.CancellationRefund"
+
+	intent, err := s.paymentRepo.IntentByConsultationUuid(ctx, consult.Uuid)
+	if err != nil {
+		if errors.Is(err, paymentrepo.ErrIntentNotFound) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+	// Payments still in flight are refunded by the webhook handler once they succeed
+	if intent.Status != entity.PaymentSucceeded {
+		return nil, nil
+	}
+
+	refundable, err := s.refundableAmount(ctx, intent)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	startTime, err := s.firstMeetingStart(ctx, scope, consult.Uuid)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	byExpert := consult.ExpertUuid == cancelledBy
+	amount := s.policy.Amount(refundable, startTime, time.Now(), byExpert)
+	if amount <= 0 {
+		return nil, nil
+	}
+
+	reason := "cancelled by mentee"
+	if byExpert {
+		reason = "cancelled by expert"
+	}
+
+	return &entity.Refund{
+		PaymentIntentUuid: intent.Uuid,
+		Amount:            amount,
+		Currency:          intent.Currency,
+		Status:            entity.RefundPending,
+		Reason:            reason,
+		InitiatedBy:       &cancelledBy,
+	}, nil
+}
+
+// Sends a refund recorded together with a cancellation to the provider.
+// On failure it stays pending and cancelling again sends it
+func (s *Service) SendRefund(ctx context.Context, refund *entity.Refund) error {
+	const op = "services.payment.SendRefund"
+
+	intent, err := s.paymentRepo.IntentByUuid(ctx, refund.PaymentIntentUuid)
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	err = s.sendRefund(ctx, intent, refund)
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	return nil
+}
+
+// Sends the refunds of the consultation's payment that never reached the
+// provider and returns them
+func (s *Service) ResendRefunds(ctx context.Context, consultUuid uuid.UUID) ([]entity.Refund, error) {
+	const op = "services.payment.ResendRefunds"
+
+	intent, err := s.paymentRepo.IntentByConsultationUuid(ctx, consultUuid)
+	if err != nil {
+		if errors.Is(err, paymentrepo.ErrIntentNotFound) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	sent, err := s.sendUnsentRefunds(ctx, intent)
+	if err != nil {
+		return sent, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return sent, nil
+}
+
+func (s *Service) ManualRefund(
+	ctx context.Context,
+	intentId string,
+	adminId string,
+	amount int,
+	reason string,
+) (*entity.Refund, error) {
+	const op = "services.payment.ManualRefund"
+
+	intentUuid, err := uuid.Parse(intentId)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+	adminUuid, err := uuid.Parse(adminId)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	intent, err := s.paymentRepo.IntentByUuid(ctx, intentUuid)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+	if intent.Status != entity.PaymentSucceeded {
+		return nil, fmt.Errorf("%s: %w", op, ErrNotRefundable)
+	}
+
+	refundable, err := s.refundableAmount(ctx, intent)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+	if amount <= 0 || amount > refundable {
+		return nil, fmt.Errorf("%s: %w", op, ErrInvalidRefundAmount)
+	}
+
+	refund, err := s.issueRefund(ctx, intent, amount, reason, &adminUuid)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return refund, nil
+}
+
+// Returns the payment of a consultation together with its refunds.
+// Returns nil if the consultation has no payment
+func (s *Service) PaymentDetails(ctx context.Context, consultUuid uuid.UUID) (*PaymentDetails, error) {
+	const op = "services.payment.PaymentDetails"
+
+	intent, err := s.paymentRepo.IntentByConsultationUuid(ctx, consultUuid)
+	if err != nil {
+		if errors.Is(err, paymentrepo.ErrIntentNotFound) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	refunds, err := s.paymentRepo.RefundsByIntentUuid(ctx, intent.Uuid)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	details := &PaymentDetails{
+		Intent:      intent,
+		Refunds:     refunds,
+		RefundState: RefundStateNone,
+	}
+
+	pending, failed := false, false
+	for _, refund := range refunds {
+		switch refund.Status {
+		case entity.RefundSucceeded:
+			details.RefundedAmount += refund.Amount
+		case entity.RefundPending:
+			pending = true
+		case entity.RefundFailed:
+			failed = true
+		}
+	}
+
+	switch {
+	case pending:
+		details.RefundState = RefundStatePending
+	case details.RefundedAmount >= intent.Amount:
+		details.RefundState = RefundStateRefunded
+	case details.RefundedAmount > 0:
+		details.RefundState = RefundStatePartiallyRefunded
+	case failed:
+		details.RefundState = RefundStateFailed
+	}
+
+	return details, nil
+}
+
+// Fails while the refund is not known yet, so the event is not recorded and
+// the provider delivers it again
+func (s *Service) handleRefundEvent(ctx context.Context, event *paymentprovider.WebhookEvent) error {
+	refund, err := s.paymentRepo.RefundByProviderRef(ctx, event.RefundRef)
+	if err != nil {
+		return err
+	}
+	intent, err := s.paymentRepo.IntentByUuid(ctx, refund.PaymentIntentUuid)
+	if err != nil {
+		return err
+	}
+	expertUuid, err := s.intentExpertUuid(ctx, intent)
+	if err != nil {
+		return err
+	}
+
+	status := refund.Status
+	var entries []entity.LedgerEntry
+	if refund.Status == entity.RefundPending {
+		switch event.Type {
+		case paymentprovider.EventRefundSucceeded:
+			status = entity.RefundSucceeded
+			entries = refundEntries(refund, expertUuid)
+		case paymentprovider.EventRefundFailed:
+			status = entity.RefundFailed
+		}
+	}
+
+	_, err = s.paymentRepo.ApplyRefundWebhookEvent(
+		ctx,
+		&entity.PaymentWebhookEvent{
+			EventId:           event.Id,
+			PaymentIntentUuid: intent.Uuid,
+			Type:              string(event.Type),
+		},
+		refund.Uuid,
+		status,
+		entries,
+	)
+
+	return err
+}
+
+func (s *Service) issueRefund(
+	ctx context.Context,
+	intent *entity.PaymentIntent,
+	amount int,
+	reason string,
+	initiatedBy *uuid.UUID,
+) (*entity.Refund, error) {
+	// Recorded first so the provider's webhook always finds the refund
+	refund := &entity.Refund{
+		PaymentIntentUuid: intent.Uuid,
+		Amount:            amount,
+		Currency:          intent.Currency,
+		Status:            entity.RefundPending,
+		Reason:            reason,
+		InitiatedBy:       initiatedBy,
+	}
+	err := s.paymentRepo.CreateRefund(ctx, refund)
+	if err != nil {
+		return nil, err
+	}
+
+	err = s.sendRefund(ctx, intent, refund)
+	if err != nil {
+		_ = s.paymentRepo.FailPendingRefund(ctx, refund.Uuid)
+		return nil, err
+	}
+
+	return refund, nil
+}
+
+// Hands a recorded refund to the provider
+func (s *Service) sendRefund(ctx context.Context, intent *entity.PaymentIntent, refund *entity.Refund) error {
+	refundRef, err := s.provider.Refund(ctx, intent.ProviderRef, refund.Amount)
+	if err != nil {
+		return err
+	}
+
+	err = s.paymentRepo.SetRefundProviderRef(ctx, refund.Uuid, refundRef)
+	if err != nil {
+		return err
+	}
+	refund.ProviderRef = &refundRef
+
+	return nil
+}
+
+// Sends the refunds of the intent that were recorded but never reached the
+// provider, e.g. because it was unreachable at the time. Refunds another
+// attempt is still sending are reported, so the caller tries again later.
+// Returns the refunds that were sent
+func (s *Service) sendUnsentRefunds(ctx context.Context, intent *entity.PaymentIntent) ([]entity.Refund, error) {
+	refunds, err := s.paymentRepo.UnsentRefunds(ctx, intent.Uuid)
+	if err != nil {
+		return nil, err
+	}
+
+	var sent []entity.Refund
+	var errs []error
+	for i := range refunds {
+		claimed, err := s.paymentRepo.ClaimUnsentRefund(ctx, refunds[i].Uuid)
+		if err != nil {
+			errs = append(errs, err)
+			continue
+		}
+		if !claimed {
+			errs = append(errs, ErrRefundInFlight)
+			continue
+		}
+		err = s.sendRefund(ctx, intent, &refunds[i])
+		if err != nil {
+			errs = append(errs, err)
+			continue
+		}
+		sent = append(sent, refunds[i])
+	}
+
+	return sent, errors.Join(errs...)
+}
+
+// Captured amount minus refunds that succeeded or are still in flight
+func (s *Service) refundableAmount(ctx context.Context, intent *entity.PaymentIntent) (int, error) {
+	refunds, err := s.paymentRepo.RefundsByIntentUuid(ctx, intent.Uuid)
+	if err != nil {
+		return 0, err
+	}
+
+	refundable := intent.Amount
+	for _, refund := range refunds {
+		if refund.Status != entity.RefundFailed {
+			refundable -= refund.Amount
+		}
+	}
+
+	return refundable, nil
+}
+
+func (s *Service) firstMeetingStart(ctx context.Context, scope tenant.Scope, consultUuid uuid.UUID) (*time.Time, error) {
+	meetings, err := s.consultRepo.MeetingsByConsultationUuid(ctx, scope, consultUuid)
+	if err != nil {
+		return nil, err
+	}
+
+	var first *time.Time
+	for _, meeting := range meetings {
+		if first == nil || meeting.StartTime.Before(*first) {
+			startTime := meeting.StartTime
+			first = &startTime
+		}
+	}
+
+	return first, nil
+}
+
+func refundEntries(refund *entity.Refund, expertUuid uuid.UUID) []entity.LedgerEntry {
+	return []entity.LedgerEntry{
+		{
+			Account:           entity.AccountConsultationEscrow,
+			OwnerUuid:         &expertUuid,
+			Direction:         entity.Debit,
+			Amount:            refund.Amount,
+			Currency:          refund.Currency,
+			PaymentIntentUuid: &refund.PaymentIntentUuid,
+		},
+		{
+			Account:           entity.AccountProviderClearing,
+			Direction:         entity.Credit,
+			Amount:            refund.Amount,
+			Currency:          refund.Currency,
+			PaymentIntentUuid: &refund.PaymentIntentUuid,
+		},
+	}
+}
@@ -0,0 +1,404 @@
+package paymentservice
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/google/uuid"
+
+	"github.com/bogdanshibilov/mindflowbackend/internal/entity"
+	"github.com/bogdanshibilov/mindflowbackend/internal/paymentprovider"
+	consultationrepo "github.com/bogdanshibilov/mindflowbackend/internal/repository/consultation"
+	paymentrepo "github.com/bogdanshibilov/mindflowbackend/internal/repository/payment"
+	promorepo "github.com/bogdanshibilov/mindflowbackend/internal/repository/promo"
+	sessionpackagerepo "github.com/bogdanshibilov/mindflowbackend/internal/repository/sessionpackage"
+	userrepo "github.com/bogdanshibilov/mindflowbackend/internal/repository/user"
+	"github.com/bogdanshibilov/mindflowbackend/internal/services/mails"
+	"github.com/bogdanshibilov/mindflowbackend/internal/tenant"
+)
+
+type Service struct {
+	paymentRepo *paymentrepo.Repo
+	consultRepo *consultationrepo.Repo
+	packageRepo *sessionpackagerepo.Repo
+	promoRepo   *promorepo.Repo
+	userRepo    *userrepo.Repo
+	provider    paymentprovider.PaymentProvider
+	policy      RefundPolicy
+}
+
+func New(
+	paymentRepo *paymentrepo.Repo,
+	consultRepo *consultationrepo.Repo,
+	packageRepo *sessionpackagerepo.Repo,
+	promoRepo *promorepo.Repo,
+	userRepo *userrepo.Repo,
+	provider paymentprovider.PaymentProvider,
+	policy RefundPolicy,
+) *Service {
+	return &Service{
+		paymentRepo: paymentRepo,
+		consultRepo: consultRepo,
+		packageRepo: packageRepo,
+		promoRepo:   promoRepo,
+		userRepo:    userRepo,
+		provider:    provider,
+		policy:      policy,
+	}
+}
+
+// Creates a payment intent for an accepted consultation at the price it was booked at.
+// Returns nil if the consultation is free
+func (s *Service) CreateIntent(ctx context.Context, consult *entity.Consultation) (*entity.PaymentIntent, error) {
+	const op = "services.payment.CreateIntent"
+
+	existing, err := s.paymentRepo.IntentByConsultationUuid(ctx, consult.Uuid)
+	if err == nil {
+		return existing, nil
+	}
+	if !errors.Is(err, paymentrepo.ErrIntentNotFound) {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	if consult.PriceAmount <= 0 {
+		return nil, nil
+	}
+
+	providerRef, err := s.provider.CreateIntent(ctx, consult.PriceAmount, consult.PriceCurrency, consult.Uuid.String())
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	intent := &entity.PaymentIntent{
+		ConsultationUuid: &consult.Uuid,
+		PayerUuid:        consult.MenteeUuid,
+		Amount:           consult.PriceAmount,
+		OriginalAmount:   consult.PriceAmount,
+		Currency:         consult.PriceCurrency,
+		Status:           entity.PaymentRequired,
+		Provider:         s.provider.Name(),
+		ProviderRef:      providerRef,
+	}
+
+	err = s.paymentRepo.CreateIntent(ctx, intent)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return intent, nil
+}
+
+// Creates a payment intent for a package purchase.
+// Returns nil if the package is free
+func (s *Service) CreatePackageIntent(ctx context.Context, purchase *entity.PackagePurchase) (*entity.PaymentIntent, error) {
+	const op = "services.payment.CreatePackageIntent"
+
+	if purchase.PriceAmount <= 0 {
+		return nil, nil
+	}
+
+	providerRef, err := s.provider.CreateIntent(ctx, purchase.PriceAmount, purchase.Currency, purchase.Uuid.String())
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	intent := &entity.PaymentIntent{
+		PackagePurchaseUuid: &purchase.Uuid,
+		PayerUuid:           purchase.MenteeUuid,
+		Amount:              purchase.PriceAmount,
+		OriginalAmount:      purchase.PriceAmount,
+		Currency:            purchase.Currency,
+		Status:              entity.PaymentRequired,
+		Provider:            s.provider.Name(),
+		ProviderRef:         providerRef,
+	}
+
+	err = s.paymentRepo.CreateIntent(ctx, intent)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return intent, nil
+}
+
+func (s *Service) Pay(ctx context.Context, consultId, payerId, paymentMethod string) (*entity.PaymentIntent, error) {
+	const op = "services.payment.Pay"
+
+	consultUuid, err := uuid.Parse(consultId)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	intent, err := s.paymentRepo.IntentByConsultationUuid(ctx, consultUuid)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	err = s.confirm(ctx, intent, payerId, paymentMethod)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return intent, nil
+}
+
+func (s *Service) PayIntent(ctx context.Context, intentId, payerId, paymentMethod string) (*entity.PaymentIntent, error) {
+	const op = "services.payment.PayIntent"
+
+	intentUuid, err := uuid.Parse(intentId)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	intent, err := s.paymentRepo.IntentByUuid(ctx, intentUuid)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	err = s.confirm(ctx, intent, payerId, paymentMethod)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return intent, nil
+}
+
+// Returns the payment intent of a consultation to one of its participants
+func (s *Service) IntentByConsultationId(
+	ctx context.Context,
+	scope tenant.Scope,
+	consultId string,
+	userId string,
+) (*entity.PaymentIntent, error) {
+	const op = "services.payment.IntentByConsultationId"
+
+	consultUuid, err := uuid.Parse(consultId)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	consult, err := s.consultRepo.ByUuid(ctx, scope.Own(), consultUuid)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+	if consult.MenteeUuid.String() != userId && consult.ExpertUuid.String() != userId {
+		return nil, fmt.Errorf("%s: %w", op, ErrNotPayer)
+	}
+
+	return s.paymentRepo.IntentByConsultationUuid(ctx, consultUuid)
+}
+
+func (s *Service) LedgerByIntentId(ctx context.Context, intentId string) ([]entity.LedgerEntry, error) {
+	const op = "services.payment.LedgerByIntentId"
+
+	intentUuid, err := uuid.Parse(intentId)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return s.paymentRepo.LedgerEntriesByIntentUuid(ctx, intentUuid)
+}
+
+// Verifies and applies a provider webhook. Deliveries of an already
+// processed event are acknowledged without side effects
+func (s *Service) HandleWebhook(ctx context.Context, payload []byte, signature string) error {
+	const op = "services.payment.HandleWebhook"
+
+	event, err := s.provider.ParseWebhook(payload, signature)
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	if event.Type == paymentprovider.EventRefundSucceeded || event.Type == paymentprovider.EventRefundFailed {
+		err = s.handleRefundEvent(ctx, event)
+		if err != nil {
+			return fmt.Errorf("%s: %w", op, err)
+		}
+		return nil
+	}
+
+	intent, err := s.paymentRepo.IntentByProviderRef(ctx, event.ProviderRef)
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	expertUuid, err := s.intentExpertUuid(ctx, intent)
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	// The repository keeps a succeeded payment final and posts its capture once
+	status := intent.Status
+	var entries []entity.LedgerEntry
+	switch event.Type {
+	case paymentprovider.EventPaymentSucceeded:
+		status = entity.PaymentSucceeded
+		entries = captureEntries(intent, expertUuid)
+	case paymentprovider.EventPaymentFailed:
+		status = entity.PaymentFailed
+	}
+
+	applied, completion, err := s.paymentRepo.ApplyWebhookEvent(
+		ctx,
+		&entity.PaymentWebhookEvent{
+			EventId:           event.Id,
+			PaymentIntentUuid: intent.Uuid,
+			Type:              string(event.Type),
+		},
+		intent,
+		status,
+		entries,
+	)
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+	if !applied {
+		// A refund recorded on an earlier delivery may not have reached the provider
+		_, err = s.sendUnsentRefunds(ctx, intent)
+		if err != nil {
+			return fmt.Errorf("%s: %w", op, err)
+		}
+		return nil
+	}
+	if completion == nil {
+		return nil
+	}
+
+	err = s.onSucceeded(ctx, intent, completion)
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	return nil
+}
+
+// Confirms the intent with the provider on behalf of its payer
+func (s *Service) confirm(ctx context.Context, intent *entity.PaymentIntent, payerId, paymentMethod string) error {
+	if intent.PayerUuid.String() != payerId {
+		return ErrNotPayer
+	}
+
+	if intent.Status == entity.PaymentSucceeded {
+		return ErrAlreadyPaid
+	}
+
+	// Claims the intent before the provider is called, so concurrent confirms
+	// are rejected and the webhook's status is never overwritten
+	claimed, err := s.paymentRepo.TransitionIntentStatus(
+		ctx,
+		intent.Uuid,
+		entity.PaymentProcessing,
+		entity.PaymentRequired,
+		entity.PaymentFailed,
+	)
+	if err != nil {
+		return err
+	}
+	if !claimed {
+		return ErrPaymentInProgress
+	}
+	previous := intent.Status
+	intent.Status = entity.PaymentProcessing
+
+	// The amount may have changed since the intent was created, e.g. by a promo code
+	err = s.provider.UpdateAmount(ctx, intent.ProviderRef, intent.Amount)
+	if err != nil {
+		_, _ = s.paymentRepo.TransitionIntentStatus(ctx, intent.Uuid, previous, entity.PaymentProcessing)
+		return err
+	}
+
+	status, err := s.provider.Confirm(ctx, intent.ProviderRef, paymentMethod)
+	if err != nil {
+		_, _ = s.paymentRepo.TransitionIntentStatus(ctx, intent.Uuid, previous, entity.PaymentProcessing)
+		return err
+	}
+
+	// Otherwise the webhook moves the intent on
+	if status == entity.PaymentFailed {
+		_, err = s.paymentRepo.TransitionIntentStatus(ctx, intent.Uuid, entity.PaymentFailed, entity.PaymentProcessing)
+		if err != nil {
+			return err
+		}
+		intent.Status = status
+	}
+
+	return nil
+}
+
+// Runs what a completed payment leaves to do outside the database: sends
+// the refund of a consultation cancelled meanwhile or notifies the
+// participants of the scheduled one
+func (s *Service) onSucceeded(ctx context.Context, intent *entity.PaymentIntent, completion *paymentrepo.Completion) error {
+	if completion.Refund != nil {
+		return s.sendRefund(ctx, intent, completion.Refund)
+	}
+	if !completion.Scheduled {
+		return nil
+	}
+
+	consult, err := s.consultRepo.ByUuid(ctx, tenant.Unscoped, *intent.ConsultationUuid)
+	if err != nil {
+		return err
+	}
+
+	return s.notifyScheduled(ctx, consult)
+}
+
+// Returns the expert the money of the intent is owed to
+func (s *Service) intentExpertUuid(ctx context.Context, intent *entity.PaymentIntent) (uuid.UUID, error) {
+	if intent.PackagePurchaseUuid != nil {
+		purchase, err := s.packageRepo.PurchaseByUuid(ctx, *intent.PackagePurchaseUuid)
+		if err != nil {
+			return uuid.Nil, err
+		}
+		return purchase.ExpertUuid, nil
+	}
+
+	consult, err := s.consultRepo.ByUuid(ctx, tenant.Unscoped, *intent.ConsultationUuid)
+	if err != nil {
+		return uuid.Nil, err
+	}
+	return consult.ExpertUuid, nil
+}
+
+func (s *Service) notifyScheduled(ctx context.Context, consult *entity.Consultation) error {
+	meetings, err := s.consultRepo.MeetingsByConsultationUuid(ctx, tenant.Unscoped, consult.Uuid)
+	if err != nil {
+		return err
+	}
+	expert, err := s.userRepo.ByUuidIncludingDeleted(ctx, consult.ExpertUuid)
+	if err != nil {
+		return err
+	}
+	mentee, err := s.userRepo.ByUuidIncludingDeleted(ctx, consult.MenteeUuid)
+	if err != nil {
+		return err
+	}
+
+	for _, meeting := range meetings {
+		mails.SendConsultationNotification([]string{expert.Email, mentee.Email}, meeting.Link)
+	}
+
+	return nil
+}
+
+func captureEntries(intent *entity.PaymentIntent, expertUuid uuid.UUID) []entity.LedgerEntry {
+	return []entity.LedgerEntry{
+		{
+			Account:           entity.AccountProviderClearing,
+			Direction:         entity.Debit,
+			Amount:            intent.Amount,
+			Currency:          intent.Currency,
+			PaymentIntentUuid: &intent.Uuid,
+		},
+		{
+			Account:           entity.AccountConsultationEscrow,
+			OwnerUuid:         &expertUuid,
+			Direction:         entity.Credit,
+			Amount:            intent.Amount,
+			Currency:          intent.Currency,
+			PaymentIntentUuid: &intent.Uuid,
+		},
+	}
+}
@@ -0,0 +1,20 @@
+package paymentservice
+
+import "github.com/bogdanshibilov/mindflowbackend/internal/entity"
+
+type RefundState string
+
+const (
+	RefundStateNone              RefundState = "none"
+	RefundStatePending           RefundState = "pending"
+	RefundStatePartiallyRefunded RefundState = "partially_refunded"
+	RefundStateRefunded          RefundState = "refunded"
+	RefundStateFailed            RefundState = "failed"
+)
+
+type PaymentDetails struct {
+	Intent         *entity.PaymentIntent
+	Refunds        []entity.Refund
+	RefundedAmount int
+	RefundState    RefundState
+}
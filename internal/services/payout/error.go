@@ -0,0 +1,9 @@
+package payoutservice
+
+import "errors"
+
+var (
+	ErrNothingToPay        = errors.New("no unpaid earnings up to the period end")
+	ErrInvalidPayoutStatus = errors.New("payout can only be marked as sent or failed")
+	ErrInvalidMonth        = errors.New("month must be in YYYY-MM format")
+)
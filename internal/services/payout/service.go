@@ -0,0 +1,373 @@
+package payoutservice
+
+import (
+	"bytes"
+	"context"
+	"encoding/csv"
+	"fmt"
+	"sort"
+	"strconv"
+	"time"
+
+	"github.com/google/uuid"
+
+	"github.com/bogdanshibilov/mindflowbackend/internal/entity"
+	paymentrepo "github.com/bogdanshibilov/mindflowbackend/internal/repository/payment"
+	userrepo "github.com/bogdanshibilov/mindflowbackend/internal/repository/user"
+	"github.com/bogdanshibilov/mindflowbackend/internal/services/mails"
+)
+
+type Service struct {
+	paymentRepo       *paymentrepo.Repo
+	userRepo          *userrepo.Repo
+	commissionPercent int
+}
+
+func New(paymentRepo *paymentrepo.Repo, userRepo *userrepo.Repo, commissionPercent int) *Service {
+	return &Service{
+		paymentRepo:       paymentRepo,
+		userRepo:          userRepo,
+		commissionPercent: commissionPercent,
+	}
+}
+
+// Returns the earnings of an expert from consultations completed and packages
+// sold in [from, to).
+// Zero from and to return all earnings
+func (s *Service) Earnings(ctx context.Context, expertId string, from, to time.Time) (*Earnings, error) {
+	const op = "services.payout.Earnings"
+
+	expertUuid, err := uuid.Parse(expertId)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	opts := []paymentrepo.EarningsOption{paymentrepo.EarningsOfExpert(expertUuid)}
+	if !from.IsZero() && !to.IsZero() {
+		opts = append(opts, paymentrepo.EarningsCompletedBetween(from, to))
+	}
+
+	items, err := s.paymentRepo.EarningItems(ctx, opts...)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return s.earningsFrom(items), nil
+}
+
+func (s *Service) PayoutsByExpertId(ctx context.Context, expertId string) ([]entity.Payout, error) {
+	const op = "services.payout.PayoutsByExpertId"
+
+	expertUuid, err := uuid.Parse(expertId)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return s.paymentRepo.PayoutsByExpertUuid(ctx, expertUuid)
+}
+
+// Groups all unpaid earnings completed before periodEnd into one payout
+// per expert and currency
+func (s *Service) CreateBatch(
+	ctx context.Context,
+	adminId string,
+	periodEnd time.Time,
+) (*entity.PayoutBatch, []entity.Payout, error) {
+	const op = "services.payout.CreateBatch"
+
+	adminUuid, err := uuid.Parse(adminId)
+	if err != nil {
+		return nil, nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	items, err := s.paymentRepo.EarningItems(
+		ctx,
+		paymentrepo.OnlyUnpaidEarnings(),
+		paymentrepo.EarningsCompletedBetween(time.Time{}, periodEnd),
+	)
+	if err != nil {
+		return nil, nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	type payoutKey struct {
+		expertUuid uuid.UUID
+		currency   string
+	}
+	byKey := make(map[payoutKey]*entity.Payout)
+	var keys []payoutKey
+	for _, item := range items {
+		line := s.lineFrom(item)
+		// Fully refunded consultations have nothing to pay out
+		if line.Net <= 0 {
+			continue
+		}
+
+		key := payoutKey{expertUuid: item.ExpertUuid, currency: item.Currency}
+		payout, ok := byKey[key]
+		if !ok {
+			payout = &entity.Payout{
+				ExpertUuid: item.ExpertUuid,
+				Currency:   item.Currency,
+				Status:     entity.PayoutPending,
+			}
+			byKey[key] = payout
+			keys = append(keys, key)
+		}
+		payout.Gross += line.Gross
+		payout.Commission += line.Commission
+		payout.Net += line.Net
+		payout.Items = append(payout.Items, entity.PayoutItem{
+			PaymentIntentUuid: item.PaymentIntentUuid,
+			Gross:             line.Gross,
+			Commission:        line.Commission,
+			Net:               line.Net,
+		})
+	}
+	if len(keys) == 0 {
+		return nil, nil, fmt.Errorf("%s: %w", op, ErrNothingToPay)
+	}
+
+	payouts := make([]entity.Payout, 0, len(keys))
+	for _, key := range keys {
+		payouts = append(payouts, *byKey[key])
+	}
+
+	batch := &entity.PayoutBatch{
+		PeriodEnd: periodEnd,
+		Status:    entity.PayoutBatchOpen,
+		CreatedBy: &adminUuid,
+	}
+
+	err = s.paymentRepo.CreatePayoutBatch(ctx, batch, payouts)
+	if err != nil {
+		return nil, nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return batch, payouts, nil
+}
+
+func (s *Service) Batches(ctx context.Context) ([]entity.PayoutBatch, error) {
+	return s.paymentRepo.PayoutBatches(ctx)
+}
+
+func (s *Service) BatchById(ctx context.Context, batchId string) (*entity.PayoutBatch, []entity.Payout, error) {
+	const op = "services.payout.BatchById"
+
+	batchUuid, err := uuid.Parse(batchId)
+	if err != nil {
+		return nil, nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	batch, err := s.paymentRepo.PayoutBatchByUuid(ctx, batchUuid)
+	if err != nil {
+		return nil, nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	payouts, err := s.paymentRepo.PayoutsByBatchUuid(ctx, batchUuid)
+	if err != nil {
+		return nil, nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return batch, payouts, nil
+}
+
+// Records the outcome of a pending payout. Sent payouts move the expert's
+// share out of escrow and the commission to platform revenue
+func (s *Service) MarkPayout(
+	ctx context.Context,
+	payoutId string,
+	status entity.PayoutStatus,
+	reference string,
+) (*entity.Payout, error) {
+	const op = "services.payout.MarkPayout"
+
+	if status != entity.PayoutSent && status != entity.PayoutFailed {
+		return nil, fmt.Errorf("%s: %w", op, ErrInvalidPayoutStatus)
+	}
+
+	payoutUuid, err := uuid.Parse(payoutId)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	payout, err := s.paymentRepo.PayoutByUuid(ctx, payoutUuid)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	var entries []entity.LedgerEntry
+	if status == entity.PayoutSent {
+		entries = payoutEntries(payout)
+	}
+
+	err = s.paymentRepo.CompletePayout(ctx, payout, status, reference, entries)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+	payout.Status = status
+	payout.Reference = reference
+
+	if status == entity.PayoutSent {
+		expert, err := s.userRepo.ByUuidIncludingDeleted(ctx, payout.ExpertUuid)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", op, err)
+		}
+		mails.SendPayoutNotification(expert.Email, payout.Net, payout.Currency, reference)
+	}
+
+	return payout, nil
+}
+
+// Builds a CSV statement of the expert's earnings for a month given as YYYY-MM
+func (s *Service) MonthlyStatementCSV(ctx context.Context, expertId, month string) ([]byte, error) {
+	const op = "services.payout.MonthlyStatementCSV"
+
+	from, err := time.Parse("2006-01", month)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, ErrInvalidMonth)
+	}
+
+	earnings, err := s.Earnings(ctx, expertId, from, from.AddDate(0, 1, 0))
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	var buf bytes.Buffer
+	w := csv.NewWriter(&buf)
+	records := [][]string{{
+		"consultation_id",
+		"package_purchase_id",
+		"completed_at",
+		"currency",
+		"amount",
+		"refunded",
+		"gross",
+		"commission",
+		"net",
+		"payout_id",
+		"payout_status",
+	}}
+	for _, line := range earnings.Lines {
+		consultId, purchaseId, payoutId, payoutStatus := "", "", "", ""
+		if line.ConsultationUuid != nil {
+			consultId = line.ConsultationUuid.String()
+		}
+		if line.PackagePurchaseUuid != nil {
+			purchaseId = line.PackagePurchaseUuid.String()
+		}
+		if line.PayoutUuid != nil {
+			payoutId = line.PayoutUuid.String()
+		}
+		if line.PayoutStatus != nil {
+			payoutStatus = string(*line.PayoutStatus)
+		}
+		records = append(records, []string{
+			consultId,
+			purchaseId,
+			line.CompletedAt.Format(time.RFC3339),
+			line.Currency,
+			strconv.Itoa(line.Amount),
+			strconv.Itoa(line.Refunded),
+			strconv.Itoa(line.Gross),
+			strconv.Itoa(line.Commission),
+			strconv.Itoa(line.Net),
+			payoutId,
+			payoutStatus,
+		})
+	}
+	for _, total := range earnings.Totals {
+		records = append(records, []string{
+			"total",
+			"",
+			"",
+			total.Currency,
+			"",
+			"",
+			strconv.Itoa(total.Gross),
+			strconv.Itoa(total.Commission),
+			strconv.Itoa(total.Net),
+			"",
+			"",
+		})
+	}
+
+	err = w.WriteAll(records)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return buf.Bytes(), nil
+}
+
+func (s *Service) earningsFrom(items []entity.EarningItem) *Earnings {
+	earnings := &Earnings{Lines: make([]EarningLine, 0, len(items))}
+	totals := make(map[string]*CurrencyTotal)
+	for _, item := range items {
+		line := s.lineFrom(item)
+		earnings.Lines = append(earnings.Lines, line)
+
+		total, ok := totals[item.Currency]
+		if !ok {
+			total = &CurrencyTotal{Currency: item.Currency}
+			totals[item.Currency] = total
+		}
+		total.Gross += line.Gross
+		total.Commission += line.Commission
+		total.Net += line.Net
+		if item.PayoutStatus != nil && *item.PayoutStatus == entity.PayoutSent {
+			total.PaidOut += line.Net
+		}
+	}
+
+	for _, total := range totals {
+		earnings.Totals = append(earnings.Totals, *total)
+	}
+	sort.Slice(earnings.Totals, func(i, j int) bool {
+		return earnings.Totals[i].Currency < earnings.Totals[j].Currency
+	})
+
+	return earnings
+}
+
+func (s *Service) lineFrom(item entity.EarningItem) EarningLine {
+	gross := item.Amount - item.Refunded
+	if gross < 0 {
+		gross = 0
+	}
+	commission := gross * s.commissionPercent / 100
+
+	return EarningLine{
+		EarningItem: item,
+		Gross:       gross,
+		Commission:  commission,
+		Net:         gross - commission,
+	}
+}
+
+func payoutEntries(payout *entity.Payout) []entity.LedgerEntry {
+	entries := []entity.LedgerEntry{
+		{
+			Account:   entity.AccountConsultationEscrow,
+			OwnerUuid: &payout.ExpertUuid,
+			Direction: entity.Debit,
+			Amount:    payout.Gross,
+			Currency:  payout.Currency,
+		},
+		{
+			Account:   entity.AccountProviderClearing,
+			Direction: entity.Credit,
+			Amount:    payout.Net,
+			Currency:  payout.Currency,
+		},
+	}
+	if payout.Commission > 0 {
+		entries = append(entries, entity.LedgerEntry{
+			Account:   entity.AccountPlatformRevenue,
+			Direction: entity.Credit,
+			Amount:    payout.Commission,
+			Currency:  payout.Currency,
+		})
+	}
+
+	return entries
+}
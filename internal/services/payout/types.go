@@ -0,0 +1,25 @@
+package payoutservice
+
+import "github.com/bogdanshibilov/mindflowbackend/internal/entity"
+
+// A completed consultation or sold package with the platform commission applied
+type EarningLine struct {
+	entity.EarningItem
+	Gross      int
+	Commission int
+	Net        int
+}
+
+type CurrencyTotal struct {
+	Currency   string
+	Gross      int
+	Commission int
+	Net        int
+	// Net amount already sent to the expert
+	PaidOut int
+}
+
+type Earnings struct {
+	Totals []CurrencyTotal
+	Lines  []EarningLine
+}
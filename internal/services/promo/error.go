@@ -0,0 +1,7 @@
+package promoservice
+
+import "errors"
+
+var (
+	ErrInvalidPromo = errors.New("promo code needs a code and a kind, percent codes take 1 to 100, fixed codes a positive amount and a currency")
+)
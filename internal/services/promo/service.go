@@ -0,0 +1,88 @@
+package promoservice
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/google/uuid"
+
+	"github.com/bogdanshibilov/mindflowbackend/internal/entity"
+	promorepo "github.com/bogdanshibilov/mindflowbackend/internal/repository/promo"
+	currencyservice "github.com/bogdanshibilov/mindflowbackend/internal/services/currency"
+)
+
+type Service struct {
+	promoRepo  *promorepo.Repo
+	currencies *currencyservice.Service
+}
+
+func New(promoRepo *promorepo.Repo, currencies *currencyservice.Service) *Service {
+	return &Service{
+		promoRepo:  promoRepo,
+		currencies: currencies,
+	}
+}
+
+// Creates a promo code. Codes are case insensitive and stored upper case
+func (s *Service) Create(ctx context.Context, adminId string, promo *entity.PromoCode) error {
+	const op = "services.promo.Create"
+
+	adminUuid, err := uuid.Parse(adminId)
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	promo.Code = strings.ToUpper(strings.TrimSpace(promo.Code))
+	if promo.Code == "" || (promo.MaxUses != nil && *promo.MaxUses < 1) {
+		return fmt.Errorf("%s: %w", op, ErrInvalidPromo)
+	}
+
+	switch promo.Kind {
+	case entity.PromoPercent:
+		if promo.Value < 1 || promo.Value > 100 {
+			return fmt.Errorf("%s: %w", op, ErrInvalidPromo)
+		}
+		promo.Currency = nil
+	case entity.PromoFixed:
+		if promo.Value < 1 || promo.Currency == nil {
+			return fmt.Errorf("%s: %w", op, ErrInvalidPromo)
+		}
+		err = s.currencies.Supported(ctx, *promo.Currency)
+		if err != nil {
+			return fmt.Errorf("%s: %w", op, err)
+		}
+	default:
+		return fmt.Errorf("%s: %w", op, ErrInvalidPromo)
+	}
+
+	promo.Active = true
+	promo.CreatedBy = &adminUuid
+
+	err = s.promoRepo.CreatePromo(ctx, promo)
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	return nil
+}
+
+func (s *Service) Promos(ctx context.Context) ([]entity.PromoCode, error) {
+	return s.promoRepo.Promos(ctx)
+}
+
+func (s *Service) Deactivate(ctx context.Context, promoId string) error {
+	const op = "services.promo.Deactivate"
+
+	promoUuid, err := uuid.Parse(promoId)
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	err = s.promoRepo.SetPromoActive(ctx, promoUuid, false)
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	return nil
+}
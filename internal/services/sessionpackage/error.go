@@ -0,0 +1,12 @@
+package sessionpackageservice
+
+import "errors"
+
+var (
+	ErrNotApprovedExpert = errors.New("only approved experts can offer packages")
+	ErrInvalidPackage    = errors.New("package needs a title, at least one session, a non negative price and a positive validity")
+	ErrNotPackageOwner   = errors.New("package belongs to another expert")
+	ErrPackageInactive   = errors.New("package is no longer offered")
+	ErrOwnPackage        = errors.New("experts cannot buy their own packages")
+	ErrExpertUnavailable = errors.New("expert is paused, suspended or deleted")
+)
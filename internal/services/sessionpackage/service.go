@@ -0,0 +1,205 @@
+package sessionpackageservice
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/google/uuid"
+
+	"github.com/bogdanshibilov/mindflowbackend/internal/entity"
+	expertrepo "github.com/bogdanshibilov/mindflowbackend/internal/repository/expert"
+	sessionpackagerepo "github.com/bogdanshibilov/mindflowbackend/internal/repository/sessionpackage"
+	currencyservice "github.com/bogdanshibilov/mindflowbackend/internal/services/currency"
+	paymentservice "github.com/bogdanshibilov/mindflowbackend/internal/services/payment"
+	"github.com/bogdanshibilov/mindflowbackend/internal/tenant"
+)
+
+type Service struct {
+	packageRepo *sessionpackagerepo.Repo
+	expertRepo  *expertrepo.Repo
+	payments    *paymentservice.Service
+	currencies  *currencyservice.Service
+}
+
+func New(
+	packageRepo *sessionpackagerepo.Repo,
+	expertRepo *expertrepo.Repo,
+	payments *paymentservice.Service,
+	currencies *currencyservice.Service,
+) *Service {
+	return &Service{
+		packageRepo: packageRepo,
+		expertRepo:  expertRepo,
+		payments:    payments,
+		currencies:  currencies,
+	}
+}
+
+func (s *Service) CreatePackage(ctx context.Context, scope tenant.Scope, expertId string, pkg *entity.SessionPackage) error {
+	const op = "services.sessionpackage.CreatePackage"
+
+	expertUuid, err := uuid.Parse(expertId)
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	expert, err := s.expertRepo.ByUuid(ctx, scope.Own(), expertUuid)
+	if err != nil {
+		if errors.Is(err, expertrepo.ErrExpertNotFound) {
+			return fmt.Errorf("%s: %w", op, ErrNotApprovedExpert)
+		}
+		return fmt.Errorf("%s: %w", op, err)
+	}
+	if expert.Status != entity.Approved {
+		return fmt.Errorf("%s: %w", op, ErrNotApprovedExpert)
+	}
+
+	pkg.Title = strings.TrimSpace(pkg.Title)
+	if pkg.Title == "" || pkg.SessionCount < 1 || pkg.PriceAmount < 0 || pkg.ValidityDays < 1 {
+		return fmt.Errorf("%s: %w", op, ErrInvalidPackage)
+	}
+
+	err = s.currencies.Supported(ctx, pkg.Currency)
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	pkg.ExpertUuid = expertUuid
+	pkg.Active = true
+
+	err = s.packageRepo.CreatePackage(ctx, pkg)
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	return nil
+}
+
+// Returns the packages an expert visible in the scope currently offers
+func (s *Service) PackagesByExpertId(ctx context.Context, scope tenant.Scope, expertId string) ([]entity.SessionPackage, error) {
+	const op = "services.sessionpackage.PackagesByExpertId"
+
+	expertUuid, err := uuid.Parse(expertId)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return s.packageRepo.PackagesByExpertUuid(ctx, scope, expertUuid, true)
+}
+
+// Stops offering a package. Credits that were already bought stay usable
+func (s *Service) Deactivate(ctx context.Context, packageId, expertId string) error {
+	const op = "services.sessionpackage.Deactivate"
+
+	packageUuid, err := uuid.Parse(packageId)
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	pkg, err := s.packageRepo.PackageByUuid(ctx, packageUuid)
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+	if pkg.ExpertUuid.String() != expertId {
+		return fmt.Errorf("%s: %w", op, ErrNotPackageOwner)
+	}
+
+	err = s.packageRepo.SetPackageActive(ctx, packageUuid, false)
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	return nil
+}
+
+// Buys a package for the mentee from an expert visible in their scope. The
+// returned intent is nil for free packages, which are activated right away
+func (s *Service) Purchase(
+	ctx context.Context,
+	scope tenant.Scope,
+	packageId string,
+	menteeId string,
+) (*entity.PackagePurchase, *entity.PaymentIntent, error) {
+	const op = "services.sessionpackage.Purchase"
+
+	packageUuid, err := uuid.Parse(packageId)
+	if err != nil {
+		return nil, nil, fmt.Errorf("%s: %w", op, err)
+	}
+	menteeUuid, err := uuid.Parse(menteeId)
+	if err != nil {
+		return nil, nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	pkg, err := s.packageRepo.PackageByUuid(ctx, packageUuid)
+	if err != nil {
+		return nil, nil, fmt.Errorf("%s: %w", op, err)
+	}
+	if !pkg.Active {
+		return nil, nil, fmt.Errorf("%s: %w", op, ErrPackageInactive)
+	}
+	if pkg.ExpertUuid == menteeUuid {
+		return nil, nil, fmt.Errorf("%s: %w", op, ErrOwnPackage)
+	}
+
+	// Deleted experts and those of other organizations are not found
+	expert, err := s.expertRepo.ByUuid(ctx, scope, pkg.ExpertUuid)
+	if err != nil {
+		if errors.Is(err, expertrepo.ErrExpertNotFound) {
+			return nil, nil, fmt.Errorf("%s: %w", op, ErrExpertUnavailable)
+		}
+		return nil, nil, fmt.Errorf("%s: %w", op, err)
+	}
+	if !expert.Active {
+		return nil, nil, fmt.Errorf("%s: %w", op, ErrExpertUnavailable)
+	}
+
+	purchase := &entity.PackagePurchase{
+		PackageUuid:   pkg.Uuid,
+		MenteeUuid:    menteeUuid,
+		ExpertUuid:    pkg.ExpertUuid,
+		SessionsTotal: pkg.SessionCount,
+		ValidityDays:  pkg.ValidityDays,
+		PriceAmount:   pkg.PriceAmount,
+		Currency:      pkg.Currency,
+		Status:        entity.PurchasePending,
+	}
+
+	err = s.packageRepo.CreatePurchase(ctx, purchase)
+	if err != nil {
+		return nil, nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	intent, err := s.payments.CreatePackageIntent(ctx, purchase)
+	if err != nil {
+		return nil, nil, fmt.Errorf("%s: %w", op, err)
+	}
+	if intent != nil {
+		return purchase, intent, nil
+	}
+
+	err = s.packageRepo.ActivatePurchase(ctx, purchase.Uuid)
+	if err != nil {
+		return nil, nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	purchase, err = s.packageRepo.PurchaseByUuid(ctx, purchase.Uuid)
+	if err != nil {
+		return nil, nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return purchase, nil, nil
+}
+
+func (s *Service) PurchasesByMenteeId(ctx context.Context, menteeId string) ([]entity.PackagePurchase, error) {
+	const op = "services.sessionpackage.PurchasesByMenteeId"
+
+	menteeUuid, err := uuid.Parse(menteeId)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return s.packageRepo.PurchasesByMenteeUuid(ctx, menteeUuid)
+}
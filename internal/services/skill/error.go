@@ -0,0 +1,8 @@
+package skillservice
+
+import "errors"
+
+var (
+	ErrInvalidName = errors.New("names and aliases must not be empty")
+	ErrSelfMerge   = errors.New("skill cannot be merged into itself")
+)
@@ -0,0 +1,185 @@
+package skillservice
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/google/uuid"
+
+	"github.com/bogdanshibilov/mindflowbackend/internal/entity"
+	skillrepo "github.com/bogdanshibilov/mindflowbackend/internal/repository/skill"
+)
+
+type Service struct {
+	skillRepo *skillrepo.Repo
+}
+
+func New(skillRepo *skillrepo.Repo) *Service {
+	return &Service{
+		skillRepo: skillRepo,
+	}
+}
+
+// Returns all categories and skills, clients build the tree from parent ids
+func (s *Service) Taxonomy(ctx context.Context) (*Taxonomy, error) {
+	const op = "services.skill.Taxonomy"
+
+	categories, err := s.skillRepo.Categories(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	skills, err := s.skillRepo.Skills(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return &Taxonomy{
+		Categories: categories,
+		Skills:     skills,
+	}, nil
+}
+
+// Creates a category, a top level one when parentId is empty
+func (s *Service) CreateCategory(ctx context.Context, name string, parentId string) (*entity.SkillCategory, error) {
+	const op = "services.skill.CreateCategory"
+
+	category := &entity.SkillCategory{Name: strings.Join(strings.Fields(name), " ")}
+	if category.Name == "" {
+		return nil, fmt.Errorf("%s: %w", op, ErrInvalidName)
+	}
+	if parentId != "" {
+		parentUuid, err := uuid.Parse(parentId)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", op, err)
+		}
+		category.ParentUuid = &parentUuid
+	}
+
+	err := s.skillRepo.CreateCategory(ctx, category)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return category, nil
+}
+
+func (s *Service) DeleteCategory(ctx context.Context, categoryId string) error {
+	const op = "services.skill.DeleteCategory"
+
+	categoryUuid, err := uuid.Parse(categoryId)
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	err = s.skillRepo.DeleteCategory(ctx, categoryUuid)
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	return nil
+}
+
+func (s *Service) CreateSkill(ctx context.Context, categoryId string, name string) (*entity.Skill, error) {
+	const op = "services.skill.CreateSkill"
+
+	categoryUuid, err := uuid.Parse(categoryId)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	skill := &entity.Skill{
+		CategoryUuid: categoryUuid,
+		Name:         strings.Join(strings.Fields(name), " "),
+	}
+	if skill.Name == "" {
+		return nil, fmt.Errorf("%s: %w", op, ErrInvalidName)
+	}
+
+	err = s.skillRepo.CreateSkill(ctx, skill)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return skill, nil
+}
+
+func (s *Service) DeleteSkill(ctx context.Context, skillId string) error {
+	const op = "services.skill.DeleteSkill"
+
+	skillUuid, err := uuid.Parse(skillId)
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	err = s.skillRepo.DeleteSkill(ctx, skillUuid)
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	return nil
+}
+
+// Adds a synonym of the skill. Aliases are case insensitive and stored lower case
+func (s *Service) AddAlias(ctx context.Context, skillId string, alias string) error {
+	const op = "services.skill.AddAlias"
+
+	skillUuid, err := uuid.Parse(skillId)
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	alias = NormalizeTerm(alias)
+	if alias == "" {
+		return fmt.Errorf("%s: %w", op, ErrInvalidName)
+	}
+
+	err = s.skillRepo.AddAlias(ctx, skillUuid, alias)
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	return nil
+}
+
+func (s *Service) DeleteAlias(ctx context.Context, alias string) error {
+	const op = "services.skill.DeleteAlias"
+
+	err := s.skillRepo.DeleteAlias(ctx, NormalizeTerm(alias))
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	return nil
+}
+
+// Merges a duplicate skill into another one, the duplicate is deleted and
+// its name resolves to the remaining skill
+func (s *Service) Merge(ctx context.Context, fromId string, intoId string) error {
+	const op = "services.skill.Merge"
+
+	fromUuid, err := uuid.Parse(fromId)
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+	intoUuid, err := uuid.Parse(intoId)
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+	if fromUuid == intoUuid {
+		return fmt.Errorf("%s: %w", op, ErrSelfMerge)
+	}
+
+	err = s.skillRepo.MergeSkills(ctx, fromUuid, intoUuid)
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	return nil
+}
+
+// Lower cases a skill name or alias and collapses whitespace
+func NormalizeTerm(term string) string {
+	return strings.Join(strings.Fields(strings.ToLower(term)), " ")
+}
@@ -0,0 +1,8 @@
+package skillservice
+
+import "github.com/bogdanshibilov/mindflowbackend/internal/entity"
+
+type Taxonomy struct {
+	Categories []entity.SkillCategory
+	Skills     []entity.Skill
+}
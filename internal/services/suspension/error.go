@@ -0,0 +1,9 @@
+package suspensionservice
+
+import "errors"
+
+var (
+	ErrReasonRequired = errors.New("suspension reason is required")
+	ErrEndsInPast     = errors.New("suspension must end in the future")
+	ErrSelfSuspension = errors.New("admins cannot suspend themselves")
+)
@@ -0,0 +1,106 @@
+package suspensionservice
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+
+	userrepo "github.com/bogdanshibilov/mindflowbackend/internal/repository/user"
+	consultationservice "github.com/bogdanshibilov/mindflowbackend/internal/services/consultation"
+	"github.com/bogdanshibilov/mindflowbackend/internal/services/mails"
+)
+
+type Service struct {
+	userRepo      *userrepo.Repo
+	consultations *consultationservice.Service
+}
+
+func New(userRepo *userrepo.Repo, consultations *consultationservice.Service) *Service {
+	return &Service{
+		userRepo:      userRepo,
+		consultations: consultations,
+	}
+}
+
+type Request struct {
+	Reason string
+	// Nil suspends the account for good
+	Until *time.Time
+	// How the user can appeal, optional
+	AppealNote string
+}
+
+// Suspends the account, which signs the user out everywhere, and cancels
+// their upcoming consultations. Returns the cancelled consultations. When
+// some fail to cancel the suspension stays and suspending again retries them
+func (s *Service) Suspend(ctx context.Context, adminId string, userId string, req *Request) ([]uuid.UUID, error) {
+	const op = "services.suspension.Suspend"
+
+	adminUuid, err := uuid.Parse(adminId)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+	userUuid, err := uuid.Parse(userId)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+	if userUuid == adminUuid {
+		return nil, fmt.Errorf("%s: %w", op, ErrSelfSuspension)
+	}
+
+	reason := strings.TrimSpace(req.Reason)
+	if reason == "" {
+		return nil, fmt.Errorf("%s: %w", op, ErrReasonRequired)
+	}
+	if req.Until != nil && !req.Until.After(time.Now()) {
+		return nil, fmt.Errorf("%s: %w", op, ErrEndsInPast)
+	}
+	var appealNote *string
+	if note := strings.TrimSpace(req.AppealNote); note != "" {
+		appealNote = &note
+	}
+
+	err = s.userRepo.Suspend(ctx, userUuid, adminUuid, reason, req.Until, appealNote)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	cancelled, err := s.consultations.CancelUpcoming(ctx, userUuid)
+	if err != nil {
+		return cancelled, fmt.Errorf("%s: %w", op, err)
+	}
+
+	user, err := s.userRepo.ByUuid(ctx, userUuid)
+	if err != nil {
+		return cancelled, fmt.Errorf("%s: %w", op, err)
+	}
+	mails.SendAccountSuspendedNotification(user.Email, reason, req.Until, appealNote)
+
+	return cancelled, nil
+}
+
+// Ends the suspension early. The user has to sign in again
+func (s *Service) Lift(ctx context.Context, userId string) error {
+	const op = "services.suspension.Lift"
+
+	userUuid, err := uuid.Parse(userId)
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	err = s.userRepo.LiftSuspension(ctx, userUuid)
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	user, err := s.userRepo.ByUuid(ctx, userUuid)
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+	mails.SendAccountReinstatedNotification(user.Email)
+
+	return nil
+}
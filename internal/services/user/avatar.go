@@ -0,0 +1,152 @@
+package userservice
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"regexp"
+	"slices"
+	"strconv"
+
+	"github.com/google/uuid"
+
+	"github.com/bogdanshibilov/mindflowbackend/internal/blobstore"
+	"github.com/bogdanshibilov/mindflowbackend/internal/imaging"
+)
+
+// Side lengths in pixels of the square copies kept of every avatar, smallest first
+var AvatarSizes = []int{64, 256, 512}
+
+var avatarLimits = imaging.Limits{
+	MinSide:   64,
+	MaxPixels: 40_000_000,
+}
+
+var avatarHash = regexp.MustCompile(`^[0-9a-f]{32}$`)
+
+func AvatarKey(hash string, size int) string {
+	return "avatars/" + hash + "/" + strconv.Itoa(size) + ".jpg"
+}
+
+// Validates the uploaded picture, stores it resized to AvatarSizes without
+// metadata and makes it the user's avatar. Returns the content hash
+func (s *Service) SetAvatar(ctx context.Context, id string, size int64, file io.Reader) (string, error) {
+	const op = "services.user.SetAvatar"
+
+	uuid, err := uuid.Parse(id)
+	if err != nil {
+		return "", fmt.Errorf("%s: %w", op, err)
+	}
+
+	if size > s.maxAvatarSize {
+		return "", fmt.Errorf("%s: %w", op, ErrAvatarTooLarge)
+	}
+	data, err := io.ReadAll(io.LimitReader(file, s.maxAvatarSize+1))
+	if err != nil {
+		return "", fmt.Errorf("%s: %w", op, err)
+	}
+	if int64(len(data)) > s.maxAvatarSize {
+		return "", fmt.Errorf("%s: %w", op, ErrAvatarTooLarge)
+	}
+
+	img, err := imaging.Decode(data, avatarLimits)
+	if err != nil {
+		switch {
+		case errors.Is(err, imaging.ErrUnsupportedFormat):
+			err = ErrUnsupportedAvatar
+		case errors.Is(err, imaging.ErrInvalidDimensions):
+			err = ErrInvalidAvatarDimensions
+		}
+		return "", fmt.Errorf("%s: %w", op, err)
+	}
+
+	sum := sha256.Sum256(data)
+	hash := hex.EncodeToString(sum[:16])
+
+	for _, side := range AvatarSizes {
+		encoded, err := imaging.EncodeJPEG(imaging.Square(img, side))
+		if err != nil {
+			return "", fmt.Errorf("%s: %w", op, err)
+		}
+		err = s.blobs.Put(ctx, AvatarKey(hash, side), bytes.NewReader(encoded))
+		if err != nil {
+			return "", fmt.Errorf("%s: %w", op, err)
+		}
+	}
+
+	previous, err := s.userRepo.SetAvatar(ctx, uuid, &hash)
+	if err != nil {
+		_ = s.deleteAvatarBlobs(ctx, hash)
+		return "", fmt.Errorf("%s: %w", op, err)
+	}
+	if previous != nil && *previous != hash {
+		err = s.deleteAvatarBlobs(ctx, *previous)
+		if err != nil {
+			return "", fmt.Errorf("%s: %w", op, err)
+		}
+	}
+
+	return hash, nil
+}
+
+func (s *Service) DeleteAvatar(ctx context.Context, id string) error {
+	const op = "services.user.DeleteAvatar"
+
+	uuid, err := uuid.Parse(id)
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	previous, err := s.userRepo.SetAvatar(ctx, uuid, nil)
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+	if previous != nil {
+		err = s.deleteAvatarBlobs(ctx, *previous)
+		if err != nil {
+			return fmt.Errorf("%s: %w", op, err)
+		}
+	}
+
+	return nil
+}
+
+// Opens a stored copy of an avatar. The caller closes the reader
+func (s *Service) OpenAvatar(ctx context.Context, hash string, size int) (io.ReadCloser, error) {
+	const op = "services.user.OpenAvatar"
+
+	if !avatarHash.MatchString(hash) || !slices.Contains(AvatarSizes, size) {
+		return nil, fmt.Errorf("%s: %w", op, blobstore.ErrNotFound)
+	}
+
+	avatar, err := s.blobs.Get(ctx, AvatarKey(hash, size))
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return avatar, nil
+}
+
+// Removes the stored copies of an avatar nobody uses anymore
+func (s *Service) deleteAvatarBlobs(ctx context.Context, hash string) error {
+	inUse, err := s.userRepo.AvatarInUse(ctx, hash)
+	if err != nil {
+		return err
+	}
+	if inUse {
+		return nil
+	}
+
+	for _, side := range AvatarSizes {
+		err = s.blobs.Delete(ctx, AvatarKey(hash, side))
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,169 @@
+package userservice
+
+import (
+	"context"
+	"fmt"
+	"time"
+	"unicode"
+
+	"github.com/google/uuid"
+	"golang.org/x/crypto/bcrypt"
+
+	"github.com/bogdanshibilov/mindflowbackend/internal/entity"
+	userrepo "github.com/bogdanshibilov/mindflowbackend/internal/repository/user"
+	"github.com/bogdanshibilov/mindflowbackend/internal/services/mails"
+)
+
+// Checks the password against the policy. bcrypt ignores everything past 72 bytes
+func ValidatePassword(password string) error {
+	if len(password) < 8 || len(password) > 72 {
+		return ErrWeakPassword
+	}
+
+	var hasLetter, hasDigit bool
+	for _, char := range password {
+		switch {
+		case unicode.IsLetter(char):
+			hasLetter = true
+		case unicode.IsDigit(char):
+			hasDigit = true
+		}
+	}
+	if !hasLetter || !hasDigit {
+		return ErrWeakPassword
+	}
+
+	return nil
+}
+
+// Replaces the password after checking the current one. Every session of the
+// user, this one included, is signed out
+func (s *Service) ChangePassword(ctx context.Context, id string, oldPassword string, newPassword string) error {
+	const op = "services.user.ChangePassword"
+
+	uuid, err := uuid.Parse(id)
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	user, err := s.userRepo.ByUuid(ctx, uuid)
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	if err := bcrypt.CompareHashAndPassword(user.PassHash, []byte(oldPassword)); err != nil {
+		return fmt.Errorf("%s: %w", op, ErrWrongPassword)
+	}
+	if err := ValidatePassword(newPassword); err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	passHash, err := bcrypt.GenerateFromPassword([]byte(newPassword), bcrypt.DefaultCost)
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	err = s.userRepo.UpdatePassword(ctx, uuid, passHash)
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	mails.SendPasswordChangedNotification(user.Email)
+
+	return nil
+}
+
+// Replaces the email after checking the password and tells the old address.
+// Returns the new profile version
+func (s *Service) ChangeEmail(ctx context.Context, id string, password string, newEmail string) (int, error) {
+	const op = "services.user.ChangeEmail"
+
+	uuid, err := uuid.Parse(id)
+	if err != nil {
+		return 0, fmt.Errorf("%s: %w", op, err)
+	}
+
+	user, err := s.userRepo.ByUuid(ctx, uuid)
+	if err != nil {
+		return 0, fmt.Errorf("%s: %w", op, err)
+	}
+
+	if err := bcrypt.CompareHashAndPassword(user.PassHash, []byte(password)); err != nil {
+		return 0, fmt.Errorf("%s: %w", op, ErrWrongPassword)
+	}
+
+	previous, version, err := s.userRepo.UpdateEmail(ctx, uuid, newEmail)
+	if err != nil {
+		return 0, fmt.Errorf("%s: %w", op, err)
+	}
+
+	if previous != newEmail {
+		mails.SendEmailChangedNotification(previous, newEmail)
+	}
+
+	return version, nil
+}
+
+// Returns the new profile version
+func (s *Service) ChangePhone(ctx context.Context, id string, newPhone string) (int, error) {
+	const op = "services.user.ChangePhone"
+
+	uuid, err := uuid.Parse(id)
+	if err != nil {
+		return 0, fmt.Errorf("%s: %w", op, err)
+	}
+
+	version, err := s.userRepo.UpdatePhone(ctx, uuid, newPhone)
+	if err != nil {
+		return 0, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return version, nil
+}
+
+// Checks that a token issued at tokenVersion still signs the user in. Fails
+// with ErrAccountSuspended while the user is suspended, with ErrSessionRevoked
+// once they changed their password since, and with userrepo.ErrUserNotFound
+// once they are deleted
+func (s *Service) CheckSession(ctx context.Context, id string, tokenVersion int) error {
+	const op = "services.user.CheckSession"
+
+	uuid, err := uuid.Parse(id)
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	state, err := s.userRepo.SessionState(ctx, uuid)
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+	if state.Active(time.Now()) {
+		return fmt.Errorf("%s: %w", op, ErrAccountSuspended)
+	}
+	if tokenVersion != state.TokenVersion {
+		return fmt.Errorf("%s: %w", op, ErrSessionRevoked)
+	}
+
+	return nil
+}
+
+// Returns the suspension in force on the user, fails with
+// userrepo.ErrNotSuspended when there is none
+func (s *Service) Suspension(ctx context.Context, id string) (*entity.AccountSuspension, error) {
+	const op = "services.user.Suspension"
+
+	uuid, err := uuid.Parse(id)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	state, err := s.userRepo.SessionState(ctx, uuid)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+	if !state.Active(time.Now()) {
+		return nil, fmt.Errorf("%s: %w", op, userrepo.ErrNotSuspended)
+	}
+
+	return &state.AccountSuspension, nil
+}
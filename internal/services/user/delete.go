@@ -0,0 +1,115 @@
+package userservice
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"golang.org/x/crypto/bcrypt"
+
+	userrepo "github.com/bogdanshibilov/mindflowbackend/internal/repository/user"
+	"github.com/bogdanshibilov/mindflowbackend/internal/services/mails"
+)
+
+func (s *Service) Restore(ctx context.Context, id string) error {
+	const op = "services.user.Restore"
+
+	uuid, err := uuid.Parse(id)
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	err = s.userRepo.Restore(ctx, uuid, time.Now().UTC().Add(-s.deletedRetention))
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	return nil
+}
+
+func (s *Service) DeletedUsers(ctx context.Context) ([]DeletedUser, error) {
+	const op = "services.user.DeletedUsers"
+
+	users, err := s.userRepo.DeletedUsers(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	deleted := make([]DeletedUser, 0, len(users))
+	for _, user := range users {
+		deleted = append(deleted, DeletedUser{
+			User:            user,
+			RestorableUntil: user.DeletedAt.Add(s.deletedRetention),
+		})
+	}
+
+	return deleted, nil
+}
+
+// Purges users whose retention window has passed and removes their uploaded documents
+func (s *Service) PurgeDeleted(ctx context.Context) error {
+	const op = "services.user.PurgeDeleted"
+
+	result, err := s.userRepo.PurgeDeleted(ctx, time.Now().UTC().Add(-s.deletedRetention))
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	err = s.deletePurgedBlobs(ctx, result)
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	return nil
+}
+
+// Closes the user's own account. Personal data is scrubbed right away while
+// consultations stay with the other party, so the account cannot be restored
+func (s *Service) Erase(ctx context.Context, id string, password string) error {
+	const op = "services.user.Erase"
+
+	uuid, err := uuid.Parse(id)
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	user, err := s.userRepo.ByUuid(ctx, uuid)
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+	if err := bcrypt.CompareHashAndPassword(user.PassHash, []byte(password)); err != nil {
+		return fmt.Errorf("%s: %w", op, ErrWrongPassword)
+	}
+
+	result, err := s.userRepo.Erase(ctx, uuid)
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	err = s.deletePurgedBlobs(ctx, result)
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	mails.SendAccountErasedNotification(user.Email)
+
+	return nil
+}
+
+func (s *Service) deletePurgedBlobs(ctx context.Context, result *userrepo.PurgeResult) error {
+	for _, key := range result.BlobKeys {
+		err := s.blobs.Delete(ctx, key)
+		if err != nil {
+			return err
+		}
+	}
+	for _, hash := range result.AvatarHashes {
+		err := s.deleteAvatarBlobs(ctx, hash)
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
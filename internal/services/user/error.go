@@ -0,0 +1,16 @@
+package userservice
+
+import "errors"
+
+var (
+	ErrWrongPassword     = errors.New("wrong password")
+	ErrInvalidVisibility = errors.New("visibility must be public, participants or staff")
+	ErrInvalidProfile    = errors.New("name and email must not be empty")
+	ErrWeakPassword      = errors.New("password must be 8 to 72 bytes long and contain a letter and a digit")
+	ErrSessionRevoked    = errors.New("session was signed out")
+	ErrAccountSuspended  = errors.New("account is suspended")
+
+	ErrAvatarTooLarge          = errors.New("avatar exceeds the maximum size")
+	ErrUnsupportedAvatar       = errors.New("avatar must be a JPEG, PNG or GIF image")
+	ErrInvalidAvatarDimensions = errors.New("avatar must be at least 64 pixels wide and high and at most 40 megapixels")
+)
@@ -0,0 +1,46 @@
+package userservice
+
+import (
+	"context"
+	"crypto/sha256"
+	"fmt"
+
+	"golang.org/x/crypto/bcrypt"
+
+	"github.com/bogdanshibilov/mindflowbackend/internal/entity"
+)
+
+// Hash an invite token is stored and looked up by. Tokens are random, so a
+// plain hash is enough
+func HashInviteToken(token string) []byte {
+	hash := sha256.Sum256([]byte(token))
+	return hash[:]
+}
+
+// Sets the password of an invited user and returns them. Fails with
+// userrepo.ErrInviteInvalid when the invite is unknown, used or expired
+func (s *Service) AcceptInvite(ctx context.Context, token string, password string) (*entity.User, error) {
+	const op = "services.user.AcceptInvite"
+
+	err := ValidatePassword(password)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	passHash, err := bcrypt.GenerateFromPassword([]byte(password), bcrypt.DefaultCost)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	userUuid, err := s.userRepo.AcceptInvite(ctx, HashInviteToken(token), passHash)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	user, err := s.userRepo.ByUuid(ctx, userUuid)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return user, nil
+}
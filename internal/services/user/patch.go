@@ -0,0 +1,219 @@
+package userservice
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/google/uuid"
+
+	"github.com/bogdanshibilov/mindflowbackend/internal/entity"
+	"github.com/bogdanshibilov/mindflowbackend/internal/mergepatch"
+	userrepo "github.com/bogdanshibilov/mindflowbackend/internal/repository/user"
+)
+
+// Times a change is reapplied on a fresh read after losing to a concurrent edit
+const patchAttempts = 3
+
+// Replaces the user's own profile fields, keeping the email, which changes
+// through ChangeEmail. Returns the new version
+func (s *Service) UpdateOwnProfile(
+	ctx context.Context,
+	newName string,
+	newPhone string,
+	newProfessionalField string,
+	newExperienceDescription string,
+	userId string,
+	ifMatch *int,
+) (int, error) {
+	const op = "services.user.UpdateOwnProfile"
+
+	uuid, err := uuid.Parse(userId)
+	if err != nil {
+		return 0, fmt.Errorf("%s: %w", op, err)
+	}
+
+	user, err := s.patchUser(ctx, uuid, ifMatch, func(user *entity.User) error {
+		profile := user.UserProfile
+		profile.Name = newName
+		profile.Phone = newPhone
+		profile.ProfessionalField = newProfessionalField
+		profile.ExperienceDescription = newExperienceDescription
+		if err := validateProfile(&profile); err != nil {
+			return err
+		}
+
+		_, err := s.userRepo.UpdateProfile(ctx, &profile, uuid, &user.Version)
+		return err
+	})
+	if err != nil {
+		return 0, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return user.Version, nil
+}
+
+// Applies a JSON Merge Patch of the user to their profile fields and returns
+// the saved user. With ifMatch the patch fails with userrepo.ErrVersionMismatch
+// unless the profile is still at that version
+func (s *Service) PatchProfile(ctx context.Context, id string, patch []byte, ifMatch *int) (*entity.User, error) {
+	const op = "services.user.PatchProfile"
+
+	uuid, err := uuid.Parse(id)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	user, err := s.patchUser(ctx, uuid, ifMatch, func(user *entity.User) error {
+		patched, err := mergepatch.Apply(profileDocumentOf(user), patch)
+		if err != nil {
+			return err
+		}
+
+		profile := user.UserProfile
+		patched.applyTo(&profile)
+		if err := validateProfile(&profile); err != nil {
+			return err
+		}
+
+		_, err = s.userRepo.UpdateProfile(ctx, &profile, uuid, &user.Version)
+		return err
+	})
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return user, nil
+}
+
+// Same as PatchProfile for staff, who may change the email too
+func (s *Service) PatchUserProfile(ctx context.Context, id string, patch []byte, ifMatch *int) (*entity.User, error) {
+	const op = "services.user.PatchUserProfile"
+
+	uuid, err := uuid.Parse(id)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	user, err := s.patchUser(ctx, uuid, ifMatch, func(user *entity.User) error {
+		patched, err := mergepatch.Apply(&StaffProfileDocument{
+			ProfileDocument: *profileDocumentOf(user),
+			Email:           user.Email,
+		}, patch)
+		if err != nil {
+			return err
+		}
+
+		profile := user.UserProfile
+		patched.applyTo(&profile)
+		profile.Email = patched.Email
+		if err := validateProfile(&profile); err != nil {
+			return err
+		}
+
+		_, err = s.userRepo.UpdateProfile(ctx, &profile, uuid, &user.Version)
+		return err
+	})
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return user, nil
+}
+
+// Applies a JSON Merge Patch to the settings and returns the saved user. With
+// ifMatch the patch fails with userrepo.ErrVersionMismatch unless the profile
+// is still at that version
+func (s *Service) PatchSettings(ctx context.Context, id string, patch []byte, ifMatch *int) (*entity.User, error) {
+	const op = "services.user.PatchSettings"
+
+	uuid, err := uuid.Parse(id)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	user, err := s.patchUser(ctx, uuid, ifMatch, func(user *entity.User) error {
+		patched, err := mergepatch.Apply(&SettingsDocument{
+			Privacy: PrivacyDocument{
+				Email:                 user.EmailVisibility,
+				Phone:                 user.PhoneVisibility,
+				ExperienceDescription: user.ExperienceVisibility,
+			},
+		}, patch)
+		if err != nil {
+			return err
+		}
+
+		settings := &entity.PrivacySettings{
+			EmailVisibility:      patched.Privacy.Email,
+			PhoneVisibility:      patched.Privacy.Phone,
+			ExperienceVisibility: patched.Privacy.ExperienceDescription,
+		}
+		if err := validateVisibility(settings); err != nil {
+			return err
+		}
+
+		_, err = s.userRepo.UpdatePrivacy(ctx, uuid, settings, &user.Version)
+		return err
+	})
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return user, nil
+}
+
+// Reads the user, lets change save them and returns the user as saved. change
+// must save with the version of the user it is handed. When a concurrent edit
+// wins, the change is reapplied on a fresh read, unless the caller asked for
+// the version in ifMatch and so worked on a stale copy
+func (s *Service) patchUser(
+	ctx context.Context,
+	userUuid uuid.UUID,
+	ifMatch *int,
+	change func(user *entity.User) error,
+) (*entity.User, error) {
+	for attempt := 1; ; attempt++ {
+		user, err := s.userRepo.ByUuid(ctx, userUuid)
+		if err != nil {
+			return nil, err
+		}
+		if ifMatch != nil && *ifMatch != user.Version {
+			return nil, userrepo.ErrVersionMismatch
+		}
+
+		err = change(user)
+		if errors.Is(err, userrepo.ErrVersionMismatch) && ifMatch == nil && attempt < patchAttempts {
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		return s.userRepo.ByUuid(ctx, userUuid)
+	}
+}
+
+func profileDocumentOf(user *entity.User) *ProfileDocument {
+	return &ProfileDocument{
+		Name:                  user.Name,
+		Phone:                 user.Phone,
+		ProfessionalField:     user.ProfessionalField,
+		ExperienceDescription: user.ExperienceDescription,
+	}
+}
+
+func (d *ProfileDocument) applyTo(profile *entity.UserProfile) {
+	profile.Name = d.Name
+	profile.Phone = d.Phone
+	profile.ProfessionalField = d.ProfessionalField
+	profile.ExperienceDescription = d.ExperienceDescription
+}
+
+func validateProfile(profile *entity.UserProfile) error {
+	if strings.TrimSpace(profile.Name) == "" || strings.TrimSpace(profile.Email) == "" {
+		return ErrInvalidProfile
+	}
+	return nil
+}
@@ -0,0 +1,81 @@
+package userservice
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/google/uuid"
+
+	"github.com/bogdanshibilov/mindflowbackend/internal/entity"
+	userrepo "github.com/bogdanshibilov/mindflowbackend/internal/repository/user"
+)
+
+// Returns how the viewer relates to the user. An empty viewerId is an anonymous viewer
+func (s *Service) ViewerOf(ctx context.Context, viewerId string, userUuid uuid.UUID) (Viewer, error) {
+	const op = "services.user.ViewerOf"
+
+	if viewerId == "" {
+		return ViewerPublic, nil
+	}
+	viewerUuid, err := uuid.Parse(viewerId)
+	if err != nil {
+		return ViewerPublic, fmt.Errorf("%s: %w", op, err)
+	}
+	if viewerUuid == userUuid {
+		return ViewerOwner, nil
+	}
+
+	isAdmin, err := s.IsAdmin(ctx, viewerId)
+	if err != nil && !errors.Is(err, userrepo.ErrUserNotFound) {
+		return ViewerPublic, fmt.Errorf("%s: %w", op, err)
+	}
+	if isAdmin {
+		return ViewerStaff, nil
+	}
+
+	participants, err := s.userRepo.AreParticipants(ctx, viewerUuid, userUuid)
+	if err != nil {
+		return ViewerPublic, fmt.Errorf("%s: %w", op, err)
+	}
+	if participants {
+		return ViewerParticipant, nil
+	}
+
+	return ViewerPublic, nil
+}
+
+func (s *Service) UpdatePrivacy(ctx context.Context, id string, settings *entity.PrivacySettings) error {
+	const op = "services.user.UpdatePrivacy"
+
+	uuid, err := uuid.Parse(id)
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	if err := validateVisibility(settings); err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	_, err = s.userRepo.UpdatePrivacy(ctx, uuid, settings, nil)
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	return nil
+}
+
+func validateVisibility(settings *entity.PrivacySettings) error {
+	for _, level := range []entity.Visibility{
+		settings.EmailVisibility,
+		settings.PhoneVisibility,
+		settings.ExperienceVisibility,
+	} {
+		switch level {
+		case entity.VisibilityPublic, entity.VisibilityParticipants, entity.VisibilityStaff:
+		default:
+			return ErrInvalidVisibility
+		}
+	}
+	return nil
+}
@@ -3,21 +3,41 @@ package userservice
 import (
 	"context"
 	"fmt"
+	"time"
 
 	"github.com/google/uuid"
-	"golang.org/x/crypto/bcrypt"
 
+	"github.com/bogdanshibilov/mindflowbackend/internal/blobstore"
 	"github.com/bogdanshibilov/mindflowbackend/internal/entity"
+	"github.com/bogdanshibilov/mindflowbackend/internal/pagination"
 	userrepo "github.com/bogdanshibilov/mindflowbackend/internal/repository/user"
+	consultationservice "github.com/bogdanshibilov/mindflowbackend/internal/services/consultation"
+	"github.com/bogdanshibilov/mindflowbackend/internal/tenant"
 )
 
 type Service struct {
-	userRepo *userrepo.Repo
+	userRepo      *userrepo.Repo
+	consultations *consultationservice.Service
+	blobs         blobstore.Store
+	// How long deleted users can be restored before they are purged
+	deletedRetention time.Duration
+	// In bytes
+	maxAvatarSize int64
 }
 
-func New(userRepo *userrepo.Repo) *Service {
+func New(
+	userRepo *userrepo.Repo,
+	consultations *consultationservice.Service,
+	blobs blobstore.Store,
+	deletedRetention time.Duration,
+	maxAvatarSize int64,
+) *Service {
 	return &Service{
-		userRepo: userRepo,
+		userRepo:         userRepo,
+		consultations:    consultations,
+		blobs:            blobs,
+		deletedRetention: deletedRetention,
+		maxAvatarSize:    maxAvatarSize,
 	}
 }
 
@@ -56,32 +76,9 @@ func (s *Service) CreateUser(
 	return nil
 }
 
-func (s *Service) UpdateCredentials(
-	ctx context.Context,
-	newUsername string,
-	newPassHash string,
-	userId string,
-) error {
-	const op = "services.user.UpdateCredentials"
-
-	uuid, err := uuid.Parse(userId)
-	if err != nil {
-		return fmt.Errorf("%s: %w", op, err)
-	}
-
-	newCreds := &entity.UserCredentials{
-		Username: newUsername,
-		PassHash: []byte(newPassHash),
-	}
-
-	err = s.userRepo.UpdateCredentials(ctx, newCreds, uuid)
-	if err != nil {
-		return fmt.Errorf("%s: %w", op, err)
-	}
-
-	return nil
-}
-
+// Replaces the profile fields. With ifMatch the update fails with
+// userrepo.ErrVersionMismatch unless the profile is still at that version.
+// Returns the new version
 func (s *Service) UpdateProfile(
 	ctx context.Context,
 	newName string,
@@ -90,12 +87,13 @@ func (s *Service) UpdateProfile(
 	newProfessionalField string,
 	newExperienceDescription string,
 	userId string,
-) error {
+	ifMatch *int,
+) (int, error) {
 	const op = "services.user.UpdateProfile"
 
 	uuid, err := uuid.Parse(userId)
 	if err != nil {
-		return fmt.Errorf("%s: %w", op, err)
+		return 0, fmt.Errorf("%s: %w", op, err)
 	}
 
 	newProfile := &entity.UserProfile{
@@ -105,13 +103,37 @@ func (s *Service) UpdateProfile(
 		ProfessionalField:     newProfessionalField,
 		ExperienceDescription: newExperienceDescription,
 	}
+	if err := validateProfile(newProfile); err != nil {
+		return 0, fmt.Errorf("%s: %w", op, err)
+	}
 
-	err = s.userRepo.UpdateProfile(ctx, newProfile, uuid)
+	version, err := s.userRepo.UpdateProfile(ctx, newProfile, uuid, ifMatch)
 	if err != nil {
-		return fmt.Errorf("%s: %w", op, err)
+		return 0, fmt.Errorf("%s: %w", op, err)
 	}
 
-	return nil
+	return version, nil
+}
+
+// Returns the user if the viewer may look them up within the scope
+func (s *Service) ByIdInScope(ctx context.Context, scope tenant.Scope, viewerId, id string) (*entity.User, error) {
+	const op = "services.user.ByIdInScope"
+
+	viewerUuid, err := uuid.Parse(viewerId)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+	uuid, err := uuid.Parse(id)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	user, err := s.userRepo.ByUuidInScope(ctx, scope, viewerUuid, uuid)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return user, nil
 }
 
 func (s *Service) ById(ctx context.Context, id string) (*entity.User, error) {
@@ -163,50 +185,59 @@ func (s *Service) IsAdmin(ctx context.Context, userId string) (bool, error) {
 	return false, nil
 }
 
-func (s *Service) Users(ctx context.Context) ([]entity.User, error) {
-	return s.userRepo.Users(ctx)
+// Lists users matching the filter for the admin directory
+func (s *Service) Users(
+	ctx context.Context,
+	filter *userrepo.UserFilter,
+	page *pagination.Request,
+) (*pagination.Page[userrepo.DirectoryUser], error) {
+	const op = "services.user.Users"
+
+	users, err := s.userRepo.Users(ctx, filter, page)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return users, nil
 }
 
-func (s *Service) DeleteUserById(ctx context.Context, id string) error {
-	const op = "services.user.DeleteUserById"
+// Remembers when the user last signed in, so admins can filter by activity
+func (s *Service) RecordLogin(ctx context.Context, id uuid.UUID) error {
+	const op = "services.user.RecordLogin"
 
-	uuid, err := uuid.Parse(id)
+	err := s.userRepo.RecordLogin(ctx, id)
 	if err != nil {
 		return fmt.Errorf("%s: %w", op, err)
 	}
 
-	return s.userRepo.DeleteUser(ctx, uuid)
+	return nil
 }
 
-func (s *Service) UpdateSettings(
-	ctx context.Context,
-	newEmail,
-	newPhone,
-	oldPassword,
-	newPassword,
-	id string,
-) error {
-	const op = "services.user.UpdateSettings"
+// Cancels the upcoming consultations of the user, so the other party is
+// refunded and notified, and soft deletes them. Admins can restore them until
+// the retention window passes. When some consultations fail to cancel the user
+// is kept and deleting again retries them
+func (s *Service) DeleteUserById(ctx context.Context, adminId string, id string) error {
+	const op = "services.user.DeleteUserById"
 
-	uuid, err := uuid.Parse(id)
+	adminUuid, err := uuid.Parse(adminId)
 	if err != nil {
 		return fmt.Errorf("%s: %w", op, err)
 	}
-
-	user, err := s.userRepo.ByUuid(ctx, uuid)
+	uuid, err := uuid.Parse(id)
 	if err != nil {
 		return fmt.Errorf("%s: %w", op, err)
 	}
 
-	if err := bcrypt.CompareHashAndPassword(user.PassHash, []byte(oldPassword)); err != nil {
+	_, err = s.consultations.CancelUpcoming(ctx, uuid)
+	if err != nil {
 		return fmt.Errorf("%s: %w", op, err)
 	}
 
-	newPassHash, err := bcrypt.
-		GenerateFromPassword([]byte(newPassword), bcrypt.DefaultCost)
+	err = s.userRepo.SoftDelete(ctx, uuid, adminUuid)
 	if err != nil {
 		return fmt.Errorf("%s: %w", op, err)
 	}
 
-	return s.userRepo.UpdateSettings(ctx, newEmail, newPhone, string(newPassHash), uuid)
+	return nil
 }
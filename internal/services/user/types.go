@@ -0,0 +1,63 @@
+package userservice
+
+import (
+	"time"
+
+	"github.com/bogdanshibilov/mindflowbackend/internal/entity"
+)
+
+type DeletedUser struct {
+	entity.User
+	// Purged afterwards and no longer restorable
+	RestorableUntil time.Time
+}
+
+// How the viewer of a profile relates to its user, from least to most trusted
+type Viewer int
+
+const (
+	// Anonymous or unrelated users
+	ViewerPublic Viewer = iota
+	// Shares a consultation with the user
+	ViewerParticipant
+	ViewerStaff
+	ViewerOwner
+)
+
+// Reports whether a field with the visibility level is shown to the viewer
+func (v Viewer) CanSee(level entity.Visibility) bool {
+	switch v {
+	case ViewerOwner, ViewerStaff:
+		return true
+	case ViewerParticipant:
+		return level == entity.VisibilityPublic || level == entity.VisibilityParticipants
+	default:
+		return level == entity.VisibilityPublic
+	}
+}
+
+// Profile fields as the document the user's merge patches apply to. The email
+// has its own flow
+type ProfileDocument struct {
+	Name                  string `json:"name"`
+	Phone                 string `json:"phone"`
+	ProfessionalField     string `json:"professionalField"`
+	ExperienceDescription string `json:"experienceDescription"`
+}
+
+// Profile fields as the document staff merge patches apply to
+type StaffProfileDocument struct {
+	ProfileDocument
+	Email string `json:"email"`
+}
+
+// Settings as the document merge patches apply to
+type SettingsDocument struct {
+	Privacy PrivacyDocument `json:"privacy"`
+}
+
+type PrivacyDocument struct {
+	Email                 entity.Visibility `json:"email"`
+	Phone                 entity.Visibility `json:"phone"`
+	ExperienceDescription entity.Visibility `json:"experienceDescription"`
+}
@@ -0,0 +1,56 @@
+// Package tenant scopes queries to organizations. Experts and consultations
+// of an organization are visible to its members only, those outside any
+// organization are public. Every repository query over them takes a Scope
+package tenant
+
+import (
+	sq "github.com/Masterminds/squirrel"
+	"github.com/google/uuid"
+)
+
+// Organizations whose data a query may return. The zero Scope is an
+// anonymous viewer or a user outside any organization and sees public data only
+type Scope struct {
+	// Organization of the viewer, uuid.Nil outside any
+	Org uuid.UUID
+	// Hides public data from members of organizations that keep to their own experts
+	OrgOnly bool
+	// Platform staff see every organization
+	All bool
+}
+
+// For background jobs and staff actions that span every organization
+var Unscoped = Scope{All: true}
+
+// Scope for records the viewer owns or takes part in. OrgOnly only narrows
+// which experts members browse and book, their own public records stay visible
+func (s Scope) Own() Scope {
+	s.OrgOnly = false
+	return s
+}
+
+// Condition on a nullable organization column, NULL marks public rows
+func (s Scope) Where(column string) sq.Sqlizer {
+	switch {
+	case s.All:
+		return sq.Expr("TRUE")
+	case s.Org == uuid.Nil:
+		return sq.Expr(column + " IS NULL")
+	case s.OrgOnly:
+		return sq.Expr(column+" = ?", s.Org)
+	default:
+		return sq.Expr("("+column+" IS NULL OR "+column+" = ?)", s.Org)
+	}
+}
+
+// Whether data of the organization, nil for public data, is visible in the scope
+func (s Scope) Sees(org *uuid.UUID) bool {
+	switch {
+	case s.All:
+		return true
+	case org == nil:
+		return !s.OrgOnly || s.Org == uuid.Nil
+	default:
+		return *org == s.Org
+	}
+}
@@ -0,0 +1,22 @@
+CREATE TABLE IF NOT EXISTS expert_document
+(
+    uuid uuid PRIMARY KEY,
+    expert_uuid uuid NOT NULL,
+    kind VARCHAR(32) NOT NULL CHECK (kind IN ('diploma', 'certificate', 'portfolio')),
+    title VARCHAR(255) NOT NULL,
+    -- Uploaded files are kept in the blob store, portfolio links only have url
+    blob_key TEXT,
+    file_name VARCHAR(255),
+    content_type VARCHAR(255),
+    size_bytes BIGINT,
+    url TEXT,
+    status VARCHAR(32) NOT NULL DEFAULT 'pending' CHECK (status IN ('pending', 'accepted', 'rejected')),
+    verdict_note TEXT,
+    reviewed_by uuid,
+    reviewed_at TIMESTAMP,
+    uploaded_at TIMESTAMP NOT NULL DEFAULT now(),
+    FOREIGN KEY (expert_uuid) REFERENCES expert_information(user_uuid) ON DELETE CASCADE,
+    FOREIGN KEY (reviewed_by) REFERENCES users(uuid) ON DELETE SET NULL,
+    CHECK ((blob_key IS NULL) <> (url IS NULL))
+);
+CREATE INDEX IF NOT EXISTS idx_expert_document_expert on expert_document (expert_uuid);
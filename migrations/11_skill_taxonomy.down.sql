@@ -0,0 +1,10 @@
+ALTER TABLE expert_revision DROP CONSTRAINT IF EXISTS expert_revision_changes_check;
+DELETE FROM expert_revision WHERE professional_field IS NULL AND help_description IS NULL;
+ALTER TABLE expert_revision DROP COLUMN IF EXISTS skill_uuids;
+ALTER TABLE expert_revision ADD CONSTRAINT expert_revision_check
+    CHECK (professional_field IS NOT NULL OR help_description IS NOT NULL);
+
+DROP TABLE IF EXISTS expert_skill;
+DROP TABLE IF EXISTS skill_alias;
+DROP TABLE IF EXISTS skill;
+DROP TABLE IF EXISTS skill_category;
@@ -0,0 +1,64 @@
+CREATE TABLE IF NOT EXISTS skill_category
+(
+    uuid uuid DEFAULT gen_random_uuid(),
+    name VARCHAR(255) NOT NULL,
+    parent_uuid uuid,
+    created_at TIMESTAMP NOT NULL DEFAULT now(),
+    PRIMARY KEY (uuid),
+    FOREIGN KEY (parent_uuid) REFERENCES skill_category(uuid) ON DELETE RESTRICT
+);
+CREATE UNIQUE INDEX IF NOT EXISTS idx_skill_category_name on skill_category
+    (COALESCE(parent_uuid, '00000000-0000-0000-0000-000000000000'::uuid), lower(name));
+
+CREATE TABLE IF NOT EXISTS skill
+(
+    uuid uuid DEFAULT gen_random_uuid(),
+    category_uuid uuid NOT NULL,
+    name VARCHAR(255) NOT NULL,
+    created_at TIMESTAMP NOT NULL DEFAULT now(),
+    PRIMARY KEY (uuid),
+    FOREIGN KEY (category_uuid) REFERENCES skill_category(uuid) ON DELETE RESTRICT
+);
+CREATE UNIQUE INDEX IF NOT EXISTS idx_skill_name on skill (lower(name));
+
+-- Synonyms resolving to a skill, stored lower case
+CREATE TABLE IF NOT EXISTS skill_alias
+(
+    alias VARCHAR(255) PRIMARY KEY,
+    skill_uuid uuid NOT NULL,
+    FOREIGN KEY (skill_uuid) REFERENCES skill(uuid) ON DELETE CASCADE
+);
+CREATE INDEX IF NOT EXISTS idx_skill_alias_skill on skill_alias (skill_uuid);
+
+CREATE TABLE IF NOT EXISTS expert_skill
+(
+    expert_uuid uuid NOT NULL,
+    skill_uuid uuid NOT NULL,
+    PRIMARY KEY (expert_uuid, skill_uuid),
+    FOREIGN KEY (expert_uuid) REFERENCES expert_information(user_uuid) ON DELETE CASCADE,
+    FOREIGN KEY (skill_uuid) REFERENCES skill(uuid) ON DELETE CASCADE
+);
+CREATE INDEX IF NOT EXISTS idx_expert_skill_skill on expert_skill (skill_uuid);
+
+-- Skill changes of approved experts go through review like other sensitive fields
+ALTER TABLE expert_revision ADD COLUMN IF NOT EXISTS skill_uuids uuid[];
+ALTER TABLE expert_revision DROP CONSTRAINT IF EXISTS expert_revision_check;
+ALTER TABLE expert_revision ADD CONSTRAINT expert_revision_changes_check
+    CHECK (professional_field IS NOT NULL OR help_description IS NOT NULL OR skill_uuids IS NOT NULL);
+
+-- Existing professional fields become skills of a General category,
+-- admins merge the near duplicates afterwards
+INSERT INTO skill_category (name) VALUES ('General');
+
+INSERT INTO skill (category_uuid, name)
+SELECT (SELECT uuid FROM skill_category WHERE name = 'General' AND parent_uuid IS NULL), MIN(trim(professional_field))
+FROM user_profiles
+INNER JOIN expert_information ON expert_information.user_uuid = user_profiles.user_uuid
+WHERE trim(COALESCE(professional_field, '')) <> ''
+GROUP BY lower(trim(professional_field));
+
+INSERT INTO expert_skill (expert_uuid, skill_uuid)
+SELECT expert_information.user_uuid, skill.uuid
+FROM expert_information
+INNER JOIN user_profiles ON user_profiles.user_uuid = expert_information.user_uuid
+INNER JOIN skill ON lower(skill.name) = lower(trim(user_profiles.professional_field));
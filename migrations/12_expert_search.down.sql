@@ -0,0 +1,11 @@
+DROP INDEX IF EXISTS idx_skill_name_trgm;
+DROP INDEX IF EXISTS idx_user_profiles_field_trgm;
+DROP INDEX IF EXISTS idx_user_profiles_name_trgm;
+
+DROP INDEX IF EXISTS idx_expert_information_search;
+ALTER TABLE expert_information DROP COLUMN IF EXISTS search_vector;
+
+DROP INDEX IF EXISTS idx_user_profiles_search;
+ALTER TABLE user_profiles DROP COLUMN IF EXISTS search_vector;
+
+DROP EXTENSION IF EXISTS pg_trgm;
@@ -0,0 +1,21 @@
+CREATE EXTENSION IF NOT EXISTS pg_trgm;
+
+-- Weights: A name, B professional field and help description, C experience description
+ALTER TABLE user_profiles ADD COLUMN IF NOT EXISTS search_vector tsvector
+    GENERATED ALWAYS AS (
+        setweight(to_tsvector('english', COALESCE(name, '')), 'A') ||
+        setweight(to_tsvector('english', COALESCE(professional_field, '')), 'B') ||
+        setweight(to_tsvector('english', COALESCE(experience_description, '')), 'C')
+    ) STORED;
+CREATE INDEX IF NOT EXISTS idx_user_profiles_search on user_profiles USING GIN (search_vector);
+
+ALTER TABLE expert_information ADD COLUMN IF NOT EXISTS search_vector tsvector
+    GENERATED ALWAYS AS (
+        setweight(to_tsvector('english', COALESCE(help_description, '')), 'B')
+    ) STORED;
+CREATE INDEX IF NOT EXISTS idx_expert_information_search on expert_information USING GIN (search_vector);
+
+-- Fuzzy matching of misspelled names and fields
+CREATE INDEX IF NOT EXISTS idx_user_profiles_name_trgm on user_profiles USING GIN (name gin_trgm_ops);
+CREATE INDEX IF NOT EXISTS idx_user_profiles_field_trgm on user_profiles USING GIN (professional_field gin_trgm_ops);
+CREATE INDEX IF NOT EXISTS idx_skill_name_trgm on skill USING GIN (name gin_trgm_ops);
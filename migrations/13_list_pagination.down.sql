@@ -0,0 +1,7 @@
+DROP INDEX IF EXISTS idx_consultation_application_submitted;
+DROP INDEX IF EXISTS idx_expert_application_submitted;
+DROP INDEX IF EXISTS idx_user_profiles_email_sort;
+DROP INDEX IF EXISTS idx_user_profiles_name_sort;
+DROP INDEX IF EXISTS idx_users_created;
+
+ALTER TABLE users DROP COLUMN IF EXISTS created_at;
@@ -0,0 +1,8 @@
+ALTER TABLE users ADD COLUMN IF NOT EXISTS created_at TIMESTAMP NOT NULL DEFAULT now();
+
+-- Sort keys of paginated lists, ids break ties
+CREATE INDEX IF NOT EXISTS idx_users_created on users (created_at, uuid);
+CREATE INDEX IF NOT EXISTS idx_user_profiles_name_sort on user_profiles (lower(name), user_uuid);
+CREATE INDEX IF NOT EXISTS idx_user_profiles_email_sort on user_profiles (lower(email), user_uuid);
+CREATE INDEX IF NOT EXISTS idx_expert_application_submitted on expert_application (submitted_at, user_uuid);
+CREATE INDEX IF NOT EXISTS idx_consultation_application_submitted on consultation_application (submitted_at, consultation_uuid);
@@ -0,0 +1,5 @@
+DROP TABLE IF EXISTS expert_availability;
+DROP TABLE IF EXISTS consultation_review;
+
+DROP INDEX IF EXISTS idx_expert_information_languages;
+ALTER TABLE expert_information DROP COLUMN IF EXISTS languages;
@@ -0,0 +1,27 @@
+-- ISO 639 codes of the languages the expert consults in
+ALTER TABLE expert_information ADD COLUMN IF NOT EXISTS languages VARCHAR(3)[] NOT NULL DEFAULT '{}';
+CREATE INDEX IF NOT EXISTS idx_expert_information_languages on expert_information USING GIN (languages);
+
+-- Mentee rating of a held consultation
+CREATE TABLE IF NOT EXISTS consultation_review
+(
+    consultation_uuid uuid PRIMARY KEY,
+    rating SMALLINT NOT NULL CHECK (rating BETWEEN 1 AND 5),
+    comment TEXT,
+    created_at TIMESTAMP NOT NULL DEFAULT now(),
+    FOREIGN KEY (consultation_uuid) REFERENCES consultation(uuid) ON DELETE CASCADE
+);
+
+-- Weekly slots in UTC, minutes since midnight
+CREATE TABLE IF NOT EXISTS expert_availability
+(
+    uuid uuid DEFAULT gen_random_uuid(),
+    expert_uuid uuid NOT NULL,
+    weekday SMALLINT NOT NULL CHECK (weekday BETWEEN 0 AND 6),
+    start_minute SMALLINT NOT NULL CHECK (start_minute BETWEEN 0 AND 1439),
+    end_minute SMALLINT NOT NULL CHECK (end_minute BETWEEN 1 AND 1440),
+    PRIMARY KEY (uuid),
+    FOREIGN KEY (expert_uuid) REFERENCES expert_information(user_uuid) ON DELETE CASCADE,
+    CHECK (end_minute > start_minute)
+);
+CREATE INDEX IF NOT EXISTS idx_expert_availability_expert on expert_availability (expert_uuid, weekday);
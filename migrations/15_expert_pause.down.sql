@@ -0,0 +1,7 @@
+DROP INDEX IF EXISTS idx_expert_information_paused_until;
+
+ALTER TABLE expert_information DROP COLUMN IF EXISTS suspended_by;
+ALTER TABLE expert_information DROP COLUMN IF EXISTS suspension_reason;
+ALTER TABLE expert_information DROP COLUMN IF EXISTS suspended_at;
+ALTER TABLE expert_information DROP COLUMN IF EXISTS paused_until;
+ALTER TABLE expert_information DROP COLUMN IF EXISTS paused_at;
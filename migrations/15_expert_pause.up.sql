@@ -0,0 +1,10 @@
+-- Paused experts take no bookings until paused_until, or until they resume
+ALTER TABLE expert_information ADD COLUMN IF NOT EXISTS paused_at TIMESTAMP;
+ALTER TABLE expert_information ADD COLUMN IF NOT EXISTS paused_until TIMESTAMP;
+
+-- Suspended experts are hidden and take no bookings until an admin lifts the suspension
+ALTER TABLE expert_information ADD COLUMN IF NOT EXISTS suspended_at TIMESTAMP;
+ALTER TABLE expert_information ADD COLUMN IF NOT EXISTS suspension_reason TEXT;
+ALTER TABLE expert_information ADD COLUMN IF NOT EXISTS suspended_by uuid REFERENCES users(uuid) ON DELETE SET NULL;
+
+CREATE INDEX IF NOT EXISTS idx_expert_information_paused_until on expert_information (paused_until) WHERE paused_until IS NOT NULL;
@@ -0,0 +1,6 @@
+ALTER TABLE expert_information DROP COLUMN IF EXISTS deleted_at;
+
+DROP INDEX IF EXISTS idx_users_deleted_at;
+ALTER TABLE users DROP COLUMN IF EXISTS purged_at;
+ALTER TABLE users DROP COLUMN IF EXISTS deleted_by;
+ALTER TABLE users DROP COLUMN IF EXISTS deleted_at;
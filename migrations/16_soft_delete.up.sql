@@ -0,0 +1,9 @@
+-- Deleted users keep their rows until the retention window passes so admins can restore them
+ALTER TABLE users ADD COLUMN IF NOT EXISTS deleted_at TIMESTAMP;
+ALTER TABLE users ADD COLUMN IF NOT EXISTS deleted_by uuid REFERENCES users(uuid) ON DELETE SET NULL;
+-- Set when personal data of a deleted user was scrubbed. Users with consultations
+-- or payments are scrubbed instead of removed so the other party keeps its history
+ALTER TABLE users ADD COLUMN IF NOT EXISTS purged_at TIMESTAMP;
+CREATE INDEX IF NOT EXISTS idx_users_deleted_at on users (deleted_at) WHERE deleted_at IS NOT NULL;
+
+ALTER TABLE expert_information ADD COLUMN IF NOT EXISTS deleted_at TIMESTAMP;
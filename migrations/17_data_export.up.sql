@@ -0,0 +1,17 @@
+-- Archives of a user's personal data. Pending exports are built by a background job
+CREATE TABLE IF NOT EXISTS data_export
+(
+    uuid uuid PRIMARY KEY DEFAULT gen_random_uuid(),
+    user_uuid uuid NOT NULL,
+    status VARCHAR(32) NOT NULL DEFAULT 'pending' CHECK (status IN ('pending', 'ready', 'failed')),
+    blob_key TEXT,
+    size_bytes BIGINT,
+    requested_at TIMESTAMP NOT NULL DEFAULT now(),
+    completed_at TIMESTAMP,
+    -- Ready archives are removed from the blob store afterwards
+    expires_at TIMESTAMP,
+    FOREIGN KEY (user_uuid) REFERENCES users(uuid) ON DELETE CASCADE
+);
+CREATE INDEX IF NOT EXISTS idx_data_export_user_uuid on data_export (user_uuid);
+-- One export in progress per user
+CREATE UNIQUE INDEX IF NOT EXISTS idx_data_export_pending on data_export (user_uuid) WHERE status = 'pending';
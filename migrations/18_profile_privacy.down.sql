@@ -0,0 +1,3 @@
+ALTER TABLE user_profiles DROP COLUMN IF EXISTS experience_visibility;
+ALTER TABLE user_profiles DROP COLUMN IF EXISTS phone_visibility;
+ALTER TABLE user_profiles DROP COLUMN IF EXISTS email_visibility;
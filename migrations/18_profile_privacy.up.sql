@@ -0,0 +1,8 @@
+-- Who besides the user and staff sees a profile field: everyone ('public'),
+-- people sharing a consultation with the user ('participants') or staff only ('staff')
+ALTER TABLE user_profiles ADD COLUMN IF NOT EXISTS email_visibility VARCHAR(32) NOT NULL DEFAULT 'participants'
+    CHECK (email_visibility IN ('public', 'participants', 'staff'));
+ALTER TABLE user_profiles ADD COLUMN IF NOT EXISTS phone_visibility VARCHAR(32) NOT NULL DEFAULT 'participants'
+    CHECK (phone_visibility IN ('public', 'participants', 'staff'));
+ALTER TABLE user_profiles ADD COLUMN IF NOT EXISTS experience_visibility VARCHAR(32) NOT NULL DEFAULT 'public'
+    CHECK (experience_visibility IN ('public', 'participants', 'staff'));
@@ -0,0 +1,2 @@
+DROP INDEX IF EXISTS idx_user_profiles_avatar_hash;
+ALTER TABLE user_profiles DROP COLUMN IF EXISTS avatar_hash;
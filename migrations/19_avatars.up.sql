@@ -0,0 +1,3 @@
+-- Content hash of the uploaded avatar, its resized copies are kept in the blob store under it
+ALTER TABLE user_profiles ADD COLUMN IF NOT EXISTS avatar_hash VARCHAR(64);
+CREATE INDEX IF NOT EXISTS idx_user_profiles_avatar_hash on user_profiles (avatar_hash) WHERE avatar_hash IS NOT NULL;
@@ -0,0 +1,2 @@
+ALTER TABLE expert_information DROP COLUMN IF EXISTS version;
+ALTER TABLE user_profiles DROP COLUMN IF EXISTS version;
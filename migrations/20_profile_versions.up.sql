@@ -0,0 +1,3 @@
+-- Incremented on every change, clients send it back in If-Match to detect concurrent edits
+ALTER TABLE user_profiles ADD COLUMN IF NOT EXISTS version INTEGER NOT NULL DEFAULT 1;
+ALTER TABLE expert_information ADD COLUMN IF NOT EXISTS version INTEGER NOT NULL DEFAULT 1;
@@ -0,0 +1,3 @@
+-- Tokens carry the version they were issued at, raising it signs the user out everywhere.
+-- Tokens issued before the column existed carry none and read as 0
+ALTER TABLE users ADD COLUMN IF NOT EXISTS token_version INTEGER NOT NULL DEFAULT 0;
@@ -0,0 +1,3 @@
+DROP TABLE IF EXISTS admin_action;
+DROP INDEX IF EXISTS idx_users_last_login;
+ALTER TABLE users DROP COLUMN IF EXISTS last_login_at;
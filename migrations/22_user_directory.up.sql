@@ -0,0 +1,18 @@
+ALTER TABLE users ADD COLUMN IF NOT EXISTS last_login_at TIMESTAMP;
+CREATE INDEX IF NOT EXISTS idx_users_last_login on users (last_login_at);
+
+-- Bulk actions admins took on users, one row per executed action. Dry runs are not recorded
+CREATE TABLE IF NOT EXISTS admin_action
+(
+    uuid uuid DEFAULT gen_random_uuid(),
+    admin_uuid uuid REFERENCES users(uuid) ON DELETE SET NULL,
+    action VARCHAR(32) NOT NULL,
+    -- Users the action was applied to, skipped and failed ones are left out
+    user_uuids uuid[] NOT NULL DEFAULT '{}',
+    requested INTEGER NOT NULL,
+    -- Reason, email subject and the like
+    details JSONB NOT NULL DEFAULT '{}',
+    created_at TIMESTAMP NOT NULL DEFAULT now(),
+    PRIMARY KEY (uuid)
+);
+CREATE INDEX IF NOT EXISTS idx_admin_action_created on admin_action (created_at, uuid);
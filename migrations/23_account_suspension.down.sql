@@ -0,0 +1,5 @@
+ALTER TABLE users DROP COLUMN IF EXISTS appeal_note;
+ALTER TABLE users DROP COLUMN IF EXISTS suspended_by;
+ALTER TABLE users DROP COLUMN IF EXISTS suspension_reason;
+ALTER TABLE users DROP COLUMN IF EXISTS suspended_until;
+ALTER TABLE users DROP COLUMN IF EXISTS suspended_at;
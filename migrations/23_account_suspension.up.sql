@@ -0,0 +1,7 @@
+-- Suspended users cannot sign in and their tokens are rejected, suspended_until is NULL for permanent bans
+ALTER TABLE users ADD COLUMN IF NOT EXISTS suspended_at TIMESTAMP;
+ALTER TABLE users ADD COLUMN IF NOT EXISTS suspended_until TIMESTAMP;
+ALTER TABLE users ADD COLUMN IF NOT EXISTS suspension_reason TEXT;
+ALTER TABLE users ADD COLUMN IF NOT EXISTS suspended_by uuid REFERENCES users(uuid) ON DELETE SET NULL;
+-- How the user can appeal, shown to them together with the reason
+ALTER TABLE users ADD COLUMN IF NOT EXISTS appeal_note TEXT;
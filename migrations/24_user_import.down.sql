@@ -0,0 +1,3 @@
+DROP TABLE IF EXISTS user_invite;
+DROP TABLE IF EXISTS user_import_row;
+DROP TABLE IF EXISTS user_import;
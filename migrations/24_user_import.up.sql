@@ -0,0 +1,38 @@
+-- CSV imports of users. Valid rows are created in batches by a background job
+CREATE TABLE IF NOT EXISTS user_import
+(
+    uuid uuid PRIMARY KEY DEFAULT gen_random_uuid(),
+    admin_uuid uuid REFERENCES users(uuid) ON DELETE SET NULL,
+    status VARCHAR(32) NOT NULL DEFAULT 'pending' CHECK (status IN ('pending', 'done')),
+    created_at TIMESTAMP NOT NULL DEFAULT now(),
+    completed_at TIMESTAMP
+);
+
+-- Rows that failed validation are stored as failed right away so the import reports every row
+CREATE TABLE IF NOT EXISTS user_import_row
+(
+    import_uuid uuid NOT NULL REFERENCES user_import(uuid) ON DELETE CASCADE,
+    -- Line in the CSV, the header is line 1
+    line INTEGER NOT NULL,
+    name VARCHAR(255) NOT NULL,
+    email VARCHAR(255) NOT NULL,
+    phone VARCHAR(20) NOT NULL,
+    professional_field VARCHAR(255) NOT NULL,
+    experience_description TEXT NOT NULL,
+    status VARCHAR(32) NOT NULL DEFAULT 'pending' CHECK (status IN ('pending', 'created', 'failed')),
+    error TEXT,
+    user_uuid uuid REFERENCES users(uuid) ON DELETE SET NULL,
+    PRIMARY KEY (import_uuid, line)
+);
+CREATE INDEX IF NOT EXISTS idx_user_import_row_pending on user_import_row (import_uuid, line) WHERE status = 'pending';
+
+-- Invited users choose their password through a link. Only a hash of the token is kept
+CREATE TABLE IF NOT EXISTS user_invite
+(
+    token_hash BYTEA PRIMARY KEY,
+    user_uuid uuid NOT NULL REFERENCES users(uuid) ON DELETE CASCADE,
+    created_at TIMESTAMP NOT NULL DEFAULT now(),
+    expires_at TIMESTAMP NOT NULL,
+    used_at TIMESTAMP
+);
+CREATE INDEX IF NOT EXISTS idx_user_invite_user_uuid on user_invite (user_uuid);
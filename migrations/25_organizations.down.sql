@@ -0,0 +1,4 @@
+ALTER TABLE consultation DROP COLUMN IF EXISTS organization_uuid;
+ALTER TABLE expert_information DROP COLUMN IF EXISTS organization_uuid;
+DROP TABLE IF EXISTS organization_member;
+DROP TABLE IF EXISTS organization;
@@ -0,0 +1,29 @@
+-- Companies with their own pool of experts and employees
+CREATE TABLE IF NOT EXISTS organization
+(
+    uuid uuid PRIMARY KEY DEFAULT gen_random_uuid(),
+    slug VARCHAR(63) NOT NULL UNIQUE,
+    name VARCHAR(255) NOT NULL,
+    logo_url TEXT,
+    -- Hex color like #1a2b3c
+    primary_color VARCHAR(7),
+    -- Members also see and book experts outside any organization
+    public_experts BOOLEAN NOT NULL DEFAULT TRUE,
+    created_at TIMESTAMP NOT NULL DEFAULT now()
+);
+
+-- A user belongs to one organization at most
+CREATE TABLE IF NOT EXISTS organization_member
+(
+    organization_uuid uuid NOT NULL REFERENCES organization(uuid) ON DELETE CASCADE,
+    user_uuid uuid NOT NULL UNIQUE REFERENCES users(uuid) ON DELETE CASCADE,
+    role VARCHAR(32) NOT NULL DEFAULT 'member' CHECK (role IN ('member', 'admin')),
+    joined_at TIMESTAMP NOT NULL DEFAULT now(),
+    PRIMARY KEY (organization_uuid, user_uuid)
+);
+
+-- NULL for public experts and consultations. Consultations take the organization of their expert
+ALTER TABLE expert_information ADD COLUMN IF NOT EXISTS organization_uuid uuid REFERENCES organization(uuid);
+ALTER TABLE consultation ADD COLUMN IF NOT EXISTS organization_uuid uuid REFERENCES organization(uuid);
+CREATE INDEX IF NOT EXISTS idx_expert_information_organization on expert_information (organization_uuid);
+CREATE INDEX IF NOT EXISTS idx_consultation_organization on consultation (organization_uuid);
@@ -0,0 +1,5 @@
+DROP TABLE IF EXISTS meeting_summary_revision;
+
+DROP TABLE IF EXISTS meeting_summary;
+
+DROP TABLE IF EXISTS consultation_note;
@@ -0,0 +1,39 @@
+CREATE TABLE IF NOT EXISTS consultation_note
+(
+    uuid uuid DEFAULT gen_random_uuid(),
+    consultation_uuid uuid NOT NULL,
+    author_uuid uuid NOT NULL,
+    content TEXT NOT NULL,
+    created_at TIMESTAMP DEFAULT now(),
+    updated_at TIMESTAMP DEFAULT now(),
+    PRIMARY KEY (uuid),
+    FOREIGN KEY (consultation_uuid) REFERENCES consultation(uuid) ON DELETE CASCADE,
+    FOREIGN KEY (author_uuid) REFERENCES users(uuid) ON DELETE CASCADE
+);
+CREATE INDEX IF NOT EXISTS idx_consultation_note_consultation on consultation_note (consultation_uuid);
+
+CREATE TABLE IF NOT EXISTS meeting_summary
+(
+    meeting_uuid uuid PRIMARY KEY,
+    author_uuid uuid NOT NULL,
+    version INTEGER NOT NULL DEFAULT 1,
+    content TEXT NOT NULL,
+    action_items TEXT[] DEFAULT '{}',
+    published_at TIMESTAMP,
+    updated_at TIMESTAMP DEFAULT now(),
+    FOREIGN KEY (meeting_uuid) REFERENCES consultation_meeting(uuid) ON DELETE CASCADE,
+    FOREIGN KEY (author_uuid) REFERENCES users(uuid) ON DELETE CASCADE
+);
+
+CREATE TABLE IF NOT EXISTS meeting_summary_revision
+(
+    meeting_uuid uuid NOT NULL,
+    version INTEGER NOT NULL,
+    editor_uuid uuid NOT NULL,
+    content TEXT NOT NULL,
+    action_items TEXT[] DEFAULT '{}',
+    edited_at TIMESTAMP DEFAULT now(),
+    PRIMARY KEY (meeting_uuid, version),
+    FOREIGN KEY (meeting_uuid) REFERENCES meeting_summary(meeting_uuid) ON DELETE CASCADE,
+    FOREIGN KEY (editor_uuid) REFERENCES users(uuid) ON DELETE CASCADE
+);
@@ -0,0 +1,5 @@
+DROP TABLE IF EXISTS ledger_entry;
+
+DROP TABLE IF EXISTS payment_webhook_event;
+
+DROP TABLE IF EXISTS payment_intent;
@@ -0,0 +1,42 @@
+CREATE TABLE IF NOT EXISTS payment_intent
+(
+    uuid uuid DEFAULT gen_random_uuid(),
+    consultation_uuid uuid NOT NULL UNIQUE,
+    payer_uuid uuid NOT NULL,
+    amount INTEGER NOT NULL CHECK (amount > 0),
+    currency VARCHAR(3) NOT NULL,
+    status VARCHAR(32) NOT NULL,
+    provider VARCHAR(32) NOT NULL,
+    provider_ref VARCHAR(255) NOT NULL UNIQUE,
+    created_at TIMESTAMP DEFAULT now(),
+    updated_at TIMESTAMP DEFAULT now(),
+    PRIMARY KEY (uuid),
+    FOREIGN KEY (consultation_uuid) REFERENCES consultation(uuid) ON DELETE CASCADE,
+    FOREIGN KEY (payer_uuid) REFERENCES users(uuid) ON DELETE CASCADE
+);
+
+CREATE TABLE IF NOT EXISTS payment_webhook_event
+(
+    event_id VARCHAR(255) PRIMARY KEY,
+    payment_intent_uuid uuid NOT NULL,
+    type VARCHAR(64) NOT NULL,
+    received_at TIMESTAMP DEFAULT now(),
+    FOREIGN KEY (payment_intent_uuid) REFERENCES payment_intent(uuid) ON DELETE CASCADE
+);
+
+CREATE TABLE IF NOT EXISTS ledger_entry
+(
+    uuid uuid DEFAULT gen_random_uuid(),
+    transaction_uuid uuid NOT NULL,
+    account VARCHAR(64) NOT NULL,
+    owner_uuid uuid,
+    direction VARCHAR(6) NOT NULL CHECK (direction IN ('debit', 'credit')),
+    amount INTEGER NOT NULL CHECK (amount > 0),
+    currency VARCHAR(3) NOT NULL,
+    payment_intent_uuid uuid,
+    created_at TIMESTAMP DEFAULT now(),
+    PRIMARY KEY (uuid),
+    FOREIGN KEY (payment_intent_uuid) REFERENCES payment_intent(uuid) ON DELETE SET NULL
+);
+CREATE INDEX IF NOT EXISTS idx_ledger_entry_transaction on ledger_entry (transaction_uuid);
+CREATE INDEX IF NOT EXISTS idx_ledger_entry_account on ledger_entry (account, owner_uuid);
@@ -0,0 +1,5 @@
+DROP TABLE IF EXISTS refund;
+
+ALTER TABLE consultation_application
+    DROP COLUMN IF EXISTS cancelled_at,
+    DROP COLUMN IF EXISTS cancelled_by;
@@ -0,0 +1,22 @@
+ALTER TABLE consultation_application
+    ADD COLUMN IF NOT EXISTS cancelled_by uuid,
+    ADD COLUMN IF NOT EXISTS cancelled_at TIMESTAMP;
+
+CREATE TABLE IF NOT EXISTS refund
+(
+    uuid uuid DEFAULT gen_random_uuid(),
+    payment_intent_uuid uuid NOT NULL,
+    amount INTEGER NOT NULL CHECK (amount > 0),
+    currency VARCHAR(3) NOT NULL,
+    status VARCHAR(32) NOT NULL,
+    reason TEXT NOT NULL DEFAULT '',
+    initiated_by uuid,
+    -- Refunds are recorded before the provider is called and get their reference afterwards
+    provider_ref VARCHAR(255) UNIQUE,
+    created_at TIMESTAMP DEFAULT now(),
+    updated_at TIMESTAMP DEFAULT now(),
+    PRIMARY KEY (uuid),
+    FOREIGN KEY (payment_intent_uuid) REFERENCES payment_intent(uuid) ON DELETE CASCADE,
+    FOREIGN KEY (initiated_by) REFERENCES users(uuid) ON DELETE SET NULL
+);
+CREATE INDEX IF NOT EXISTS idx_refund_payment_intent on refund (payment_intent_uuid);
@@ -0,0 +1,5 @@
+DROP TABLE IF EXISTS payout_item;
+
+DROP TABLE IF EXISTS payout;
+
+DROP TABLE IF EXISTS payout_batch;
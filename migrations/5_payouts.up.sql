@@ -0,0 +1,41 @@
+CREATE TABLE IF NOT EXISTS payout_batch
+(
+    uuid uuid DEFAULT gen_random_uuid(),
+    period_end TIMESTAMP NOT NULL,
+    status VARCHAR(32) NOT NULL,
+    created_by uuid,
+    created_at TIMESTAMP DEFAULT now(),
+    PRIMARY KEY (uuid),
+    FOREIGN KEY (created_by) REFERENCES users(uuid) ON DELETE SET NULL
+);
+
+CREATE TABLE IF NOT EXISTS payout
+(
+    uuid uuid DEFAULT gen_random_uuid(),
+    batch_uuid uuid NOT NULL,
+    expert_uuid uuid NOT NULL,
+    currency VARCHAR(3) NOT NULL,
+    gross INTEGER NOT NULL,
+    commission INTEGER NOT NULL,
+    net INTEGER NOT NULL,
+    status VARCHAR(32) NOT NULL,
+    reference TEXT NOT NULL DEFAULT '',
+    sent_at TIMESTAMP,
+    PRIMARY KEY (uuid),
+    FOREIGN KEY (batch_uuid) REFERENCES payout_batch(uuid) ON DELETE CASCADE,
+    FOREIGN KEY (expert_uuid) REFERENCES users(uuid) ON DELETE CASCADE
+);
+CREATE INDEX IF NOT EXISTS idx_payout_expert on payout (expert_uuid);
+
+CREATE TABLE IF NOT EXISTS payout_item
+(
+    payout_uuid uuid NOT NULL,
+    payment_intent_uuid uuid NOT NULL,
+    gross INTEGER NOT NULL,
+    commission INTEGER NOT NULL,
+    net INTEGER NOT NULL,
+    PRIMARY KEY (payout_uuid, payment_intent_uuid),
+    FOREIGN KEY (payout_uuid) REFERENCES payout(uuid) ON DELETE CASCADE,
+    FOREIGN KEY (payment_intent_uuid) REFERENCES payment_intent(uuid) ON DELETE CASCADE
+);
+CREATE INDEX IF NOT EXISTS idx_payout_item_payment_intent on payout_item (payment_intent_uuid);
@@ -0,0 +1,16 @@
+DROP TABLE IF EXISTS exchange_rate;
+
+ALTER TABLE consultation
+    DROP COLUMN IF EXISTS price_currency,
+    DROP COLUMN IF EXISTS price_amount;
+
+DROP TABLE IF EXISTS expert_price_history;
+
+UPDATE payout_item SET gross = gross / 100, commission = commission / 100, net = net / 100;
+UPDATE payout SET gross = gross / 100, commission = commission / 100, net = net / 100;
+UPDATE ledger_entry SET amount = amount / 100;
+UPDATE refund SET amount = amount / 100;
+UPDATE payment_intent SET amount = amount / 100;
+UPDATE expert_information SET price = price / 100;
+ALTER TABLE expert_information
+    DROP COLUMN IF EXISTS currency;
@@ -0,0 +1,48 @@
+-- Amounts were stored in whole currency units, from now on they are in minor units
+ALTER TABLE expert_information
+    ADD COLUMN IF NOT EXISTS currency VARCHAR(3) NOT NULL DEFAULT 'USD';
+UPDATE expert_information SET price = price * 100;
+UPDATE payment_intent SET amount = amount * 100;
+UPDATE refund SET amount = amount * 100;
+UPDATE ledger_entry SET amount = amount * 100;
+UPDATE payout SET gross = gross * 100, commission = commission * 100, net = net * 100;
+UPDATE payout_item SET gross = gross * 100, commission = commission * 100, net = net * 100;
+
+CREATE TABLE IF NOT EXISTS expert_price_history
+(
+    uuid uuid DEFAULT gen_random_uuid(),
+    expert_uuid uuid NOT NULL,
+    amount INTEGER NOT NULL CHECK (amount >= 0),
+    currency VARCHAR(3) NOT NULL,
+    changed_at TIMESTAMP DEFAULT now(),
+    PRIMARY KEY (uuid),
+    FOREIGN KEY (expert_uuid) REFERENCES users(uuid) ON DELETE CASCADE
+);
+CREATE INDEX IF NOT EXISTS idx_expert_price_history_expert on expert_price_history (expert_uuid, changed_at);
+
+INSERT INTO expert_price_history (expert_uuid, amount, currency)
+SELECT user_uuid, price, currency FROM expert_information;
+
+-- Price of the consultation at booking time, later price changes do not affect it
+ALTER TABLE consultation
+    ADD COLUMN IF NOT EXISTS price_amount INTEGER,
+    ADD COLUMN IF NOT EXISTS price_currency VARCHAR(3);
+UPDATE consultation
+SET price_amount = expert_information.price, price_currency = expert_information.currency
+FROM expert_information
+WHERE consultation.expert_uuid = expert_information.user_uuid;
+UPDATE consultation SET price_amount = 0, price_currency = 'USD' WHERE price_amount IS NULL;
+ALTER TABLE consultation
+    ALTER COLUMN price_amount SET NOT NULL,
+    ALTER COLUMN price_currency SET NOT NULL;
+
+-- Rate is the number of base currency minor units one minor unit of currency is worth
+CREATE TABLE IF NOT EXISTS exchange_rate
+(
+    currency VARCHAR(3) PRIMARY KEY,
+    rate NUMERIC(24, 12) NOT NULL CHECK (rate > 0),
+    updated_by uuid,
+    updated_at TIMESTAMP DEFAULT now(),
+    FOREIGN KEY (updated_by) REFERENCES users(uuid) ON DELETE SET NULL
+);
+INSERT INTO exchange_rate (currency, rate) VALUES ('USD', 1) ON CONFLICT DO NOTHING;
@@ -0,0 +1,18 @@
+ALTER TABLE consultation
+    DROP COLUMN IF EXISTS package_purchase_uuid;
+
+DROP TABLE IF EXISTS promo_redemption;
+
+DELETE FROM payment_intent WHERE consultation_uuid IS NULL OR amount = 0;
+ALTER TABLE payment_intent
+    DROP CONSTRAINT IF EXISTS payment_intent_subject_check,
+    DROP CONSTRAINT IF EXISTS payment_intent_amount_check_nonnegative,
+    DROP COLUMN IF EXISTS promo_code_uuid,
+    DROP COLUMN IF EXISTS original_amount,
+    DROP COLUMN IF EXISTS package_purchase_uuid,
+    ALTER COLUMN consultation_uuid SET NOT NULL,
+    ADD CONSTRAINT payment_intent_amount_check CHECK (amount > 0);
+
+DROP TABLE IF EXISTS promo_code;
+DROP TABLE IF EXISTS package_purchase;
+DROP TABLE IF EXISTS session_package;
@@ -0,0 +1,92 @@
+CREATE TABLE IF NOT EXISTS session_package
+(
+    uuid uuid DEFAULT gen_random_uuid(),
+    expert_uuid uuid NOT NULL,
+    title VARCHAR(255) NOT NULL,
+    session_count INTEGER NOT NULL CHECK (session_count > 0),
+    price_amount INTEGER NOT NULL CHECK (price_amount >= 0),
+    currency VARCHAR(3) NOT NULL,
+    validity_days INTEGER NOT NULL CHECK (validity_days > 0),
+    active BOOLEAN NOT NULL DEFAULT true,
+    created_at TIMESTAMP DEFAULT now(),
+    PRIMARY KEY (uuid),
+    FOREIGN KEY (expert_uuid) REFERENCES users(uuid) ON DELETE CASCADE
+);
+CREATE INDEX IF NOT EXISTS idx_session_package_expert on session_package (expert_uuid);
+
+-- Package terms are copied so later package changes do not affect purchases
+CREATE TABLE IF NOT EXISTS package_purchase
+(
+    uuid uuid DEFAULT gen_random_uuid(),
+    package_uuid uuid NOT NULL,
+    mentee_uuid uuid NOT NULL,
+    expert_uuid uuid NOT NULL,
+    sessions_total INTEGER NOT NULL,
+    sessions_used INTEGER NOT NULL DEFAULT 0,
+    validity_days INTEGER NOT NULL,
+    price_amount INTEGER NOT NULL,
+    currency VARCHAR(3) NOT NULL,
+    status VARCHAR(32) NOT NULL,
+    purchased_at TIMESTAMP DEFAULT now(),
+    paid_at TIMESTAMP,
+    expires_at TIMESTAMP,
+    PRIMARY KEY (uuid),
+    CHECK (sessions_used >= 0 AND sessions_used <= sessions_total),
+    FOREIGN KEY (package_uuid) REFERENCES session_package(uuid) ON DELETE CASCADE,
+    FOREIGN KEY (mentee_uuid) REFERENCES users(uuid) ON DELETE CASCADE,
+    FOREIGN KEY (expert_uuid) REFERENCES users(uuid) ON DELETE CASCADE
+);
+CREATE INDEX IF NOT EXISTS idx_package_purchase_mentee on package_purchase (mentee_uuid);
+
+CREATE TABLE IF NOT EXISTS promo_code
+(
+    uuid uuid DEFAULT gen_random_uuid(),
+    code VARCHAR(64) NOT NULL UNIQUE,
+    kind VARCHAR(16) NOT NULL CHECK (kind IN ('percent', 'fixed')),
+    value INTEGER NOT NULL CHECK (value > 0),
+    currency VARCHAR(3),
+    max_uses INTEGER CHECK (max_uses > 0),
+    used_count INTEGER NOT NULL DEFAULT 0,
+    expires_at TIMESTAMP,
+    expert_uuid uuid,
+    active BOOLEAN NOT NULL DEFAULT true,
+    created_by uuid,
+    created_at TIMESTAMP DEFAULT now(),
+    PRIMARY KEY (uuid),
+    CHECK (kind <> 'percent' OR value <= 100),
+    CHECK (kind <> 'fixed' OR currency IS NOT NULL),
+    FOREIGN KEY (expert_uuid) REFERENCES users(uuid) ON DELETE CASCADE,
+    FOREIGN KEY (created_by) REFERENCES users(uuid) ON DELETE SET NULL
+);
+
+-- A payment is made either for a consultation or for a package purchase
+ALTER TABLE payment_intent
+    ALTER COLUMN consultation_uuid DROP NOT NULL,
+    ADD COLUMN IF NOT EXISTS package_purchase_uuid uuid UNIQUE,
+    ADD COLUMN IF NOT EXISTS original_amount INTEGER,
+    ADD COLUMN IF NOT EXISTS promo_code_uuid uuid,
+    ADD CONSTRAINT payment_intent_amount_check_nonnegative CHECK (amount >= 0),
+    ADD CONSTRAINT payment_intent_subject_check CHECK ((consultation_uuid IS NULL) <> (package_purchase_uuid IS NULL)),
+    ADD FOREIGN KEY (package_purchase_uuid) REFERENCES package_purchase(uuid) ON DELETE CASCADE,
+    ADD FOREIGN KEY (promo_code_uuid) REFERENCES promo_code(uuid) ON DELETE SET NULL;
+ALTER TABLE payment_intent DROP CONSTRAINT IF EXISTS payment_intent_amount_check;
+UPDATE payment_intent SET original_amount = amount;
+ALTER TABLE payment_intent ALTER COLUMN original_amount SET NOT NULL;
+
+-- Each user can redeem a code once
+CREATE TABLE IF NOT EXISTS promo_redemption
+(
+    promo_code_uuid uuid NOT NULL,
+    user_uuid uuid NOT NULL,
+    payment_intent_uuid uuid NOT NULL,
+    discount_amount INTEGER NOT NULL,
+    redeemed_at TIMESTAMP DEFAULT now(),
+    PRIMARY KEY (promo_code_uuid, user_uuid),
+    FOREIGN KEY (promo_code_uuid) REFERENCES promo_code(uuid) ON DELETE CASCADE,
+    FOREIGN KEY (user_uuid) REFERENCES users(uuid) ON DELETE CASCADE,
+    FOREIGN KEY (payment_intent_uuid) REFERENCES payment_intent(uuid) ON DELETE CASCADE
+);
+
+ALTER TABLE consultation
+    ADD COLUMN IF NOT EXISTS package_purchase_uuid uuid,
+    ADD FOREIGN KEY (package_purchase_uuid) REFERENCES package_purchase(uuid) ON DELETE SET NULL;
@@ -0,0 +1,19 @@
+CREATE TABLE IF NOT EXISTS expert_revision
+(
+    uuid uuid DEFAULT gen_random_uuid(),
+    expert_uuid uuid NOT NULL,
+    -- NULL keeps the current value
+    professional_field VARCHAR(255),
+    help_description TEXT,
+    status INTEGER NOT NULL DEFAULT 0,
+    submitted_at TIMESTAMP NOT NULL DEFAULT now(),
+    reviewed_by uuid,
+    reviewed_at TIMESTAMP,
+    PRIMARY KEY (uuid),
+    FOREIGN KEY (expert_uuid) REFERENCES expert_information(user_uuid) ON DELETE CASCADE,
+    FOREIGN KEY (reviewed_by) REFERENCES users(uuid) ON DELETE SET NULL,
+    CHECK (professional_field IS NOT NULL OR help_description IS NOT NULL)
+);
+CREATE INDEX IF NOT EXISTS idx_expert_revision_expert on expert_revision (expert_uuid);
+-- An expert has at most one revision waiting for review
+CREATE UNIQUE INDEX IF NOT EXISTS idx_expert_revision_pending on expert_revision (expert_uuid) WHERE status = 0;
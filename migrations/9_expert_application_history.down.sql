@@ -0,0 +1,6 @@
+DROP TABLE IF EXISTS expert_application_history;
+
+ALTER TABLE expert_application
+    DROP COLUMN IF EXISTS reason,
+    DROP COLUMN IF EXISTS reviewed_by,
+    DROP COLUMN IF EXISTS reviewed_at;
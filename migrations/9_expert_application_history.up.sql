@@ -0,0 +1,25 @@
+ALTER TABLE expert_application
+    ADD COLUMN IF NOT EXISTS reason TEXT,
+    ADD COLUMN IF NOT EXISTS reviewed_by uuid REFERENCES users(uuid) ON DELETE SET NULL,
+    ADD COLUMN IF NOT EXISTS reviewed_at TIMESTAMP;
+
+-- Every submission and review decision of an expert application
+CREATE TABLE IF NOT EXISTS expert_application_history
+(
+    uuid uuid DEFAULT gen_random_uuid(),
+    user_uuid uuid NOT NULL,
+    status INTEGER NOT NULL,
+    reason TEXT,
+    reviewed_by uuid,
+    created_at TIMESTAMP NOT NULL DEFAULT now(),
+    PRIMARY KEY (uuid),
+    FOREIGN KEY (user_uuid) REFERENCES expert_application(user_uuid) ON DELETE CASCADE,
+    FOREIGN KEY (reviewed_by) REFERENCES users(uuid) ON DELETE SET NULL
+);
+CREATE INDEX IF NOT EXISTS idx_expert_application_history_user on expert_application_history (user_uuid);
+
+INSERT INTO expert_application_history (user_uuid, status, created_at)
+SELECT user_uuid, 0, submitted_at FROM expert_application;
+
+INSERT INTO expert_application_history (user_uuid, status, created_at)
+SELECT user_uuid, status, submitted_at FROM expert_application WHERE status <> 0;